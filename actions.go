@@ -0,0 +1,106 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import "time"
+
+// Action is one phase of a composite rule, set with SetActions or as
+// "actions" in a spec. The phases of a rule follow each other in order, each
+// lasting its own number of calls, so a key can go slow before it fails, as
+// real dependencies usually do:
+//
+//	rules:
+//	  payments-api:
+//	    actions:
+//	      - {calls: 5, latency: 800ms}          # calls 1-5 are slow
+//	      - {calls: 3, latency: 2s, fail: true} # calls 6-8 are slower, then fail
+//	      - {calls: 2}                          # calls 9-10 are healthy
+//	      - {fail: true}                        # every later call fails
+type Action struct {
+	// Calls is how many calls the phase lasts. Zero means every remaining
+	// call, so it only makes sense for the last phase.
+	Calls int `yaml:"calls,omitempty" json:"calls,omitempty"`
+	// Latency is the fixed delay InjectLatency adds during the phase,
+	// instead of the rule's latency distribution.
+	Latency time.Duration `yaml:"latency,omitempty" json:"latency,omitempty"`
+	// Fail makes Inject fail during the phase, with the rule's error
+	// template, code and status.
+	Fail bool `yaml:"fail,omitempty" json:"fail,omitempty"`
+}
+
+// SetActions gives key a composite rule that goes through actions in order,
+// replacing its failure mode and restarting its call count. No actions
+// clears it. Call sites see a phase's latency if they call InjectLatency
+// before Inject for the same key, as sqlfi does, since the phase is picked by
+// the call count Inject reaches next. Fault injection is disabled in
+// production environments.
+func (in *Injector) SetActions(key string, actions ...Action) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		if len(actions) == 0 {
+			if r.Mode == ModeActions {
+				r.setMode(ModeNone, in.timeNow())
+			}
+			return
+		}
+		r.setMode(ModeActions, in.timeNow())
+		r.Actions = append([]Action(nil), actions...)
+	})
+	in.restartCountsLocked(key)
+}
+
+// SetActions calls SetActions on the default Injector.
+func SetActions(key string, actions ...Action) {
+	std.SetActions(key, actions...)
+}
+
+// action returns the phase call number cnt falls in, or false once every
+// phase is over.
+func (r Rule) action(cnt int) (Action, bool) {
+	end := 0
+	for _, a := range r.Actions {
+		if a.Calls <= 0 {
+			return a, true
+		}
+		end += a.Calls
+		if cnt <= end {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// remainingActionFailures returns how many calls after the first calls will
+// fail, or -1 if every later call fails.
+func (r Rule) remainingActionFailures(calls int) int {
+	n, end := 0, 0
+	for _, a := range r.Actions {
+		if a.Calls <= 0 {
+			if a.Fail {
+				return -1
+			}
+			break
+		}
+		start := end
+		end += a.Calls
+		if a.Fail && end > calls {
+			n += end - max(start, calls)
+		}
+	}
+	return n
+}
+
+// nextCall returns the call count the next call to key will reach.
+func (in *Injector) nextCall(key string) int {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	return in.counters[key] + 1
+}
@@ -0,0 +1,106 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetActions(t *testing.T) {
+	resetState()
+	SetActions("payments-api",
+		Action{Calls: 2, Latency: time.Millisecond},
+		Action{Calls: 2, Latency: 2 * time.Millisecond, Fail: true},
+		Action{Calls: 1},
+		Action{Fail: true},
+	)
+	expected := []struct {
+		latency time.Duration
+		fired   bool
+		reason  Reason
+	}{
+		{time.Millisecond, false, ReasonPassingAction},
+		{time.Millisecond, false, ReasonPassingAction},
+		{2 * time.Millisecond, true, ReasonFired},
+		{2 * time.Millisecond, true, ReasonFired},
+		{0, false, ReasonPassingAction},
+		{0, true, ReasonFired},
+		{0, true, ReasonFired},
+	}
+	for i, e := range expected {
+		d, _ := InjectLatency(context.Background(), "payments-api")
+		fired := Inject("payments-api")
+		if d != e.latency || fired != e.fired {
+			t.Errorf("Call %d: expected latency %v fired %v, got %v %v", i+1, e.latency, e.fired, d, fired)
+		}
+		if h := History("payments-api"); h[len(h)-1].Reason != e.reason {
+			t.Errorf("Call %d: expected reason %s, got %s", i+1, e.reason, h[len(h)-1].Reason)
+		}
+	}
+}
+
+func TestActionsExhausted(t *testing.T) {
+	resetState()
+	SetLatency("db", FixedLatency(3*time.Millisecond))
+	SetActions("db", Action{Calls: 1, Fail: true})
+	if s := FullStatus()["db"]; s.Remaining != 1 {
+		t.Errorf("Expected 1 remaining failure, got %d", s.Remaining)
+	}
+	if d, _ := InjectLatency(context.Background(), "db"); d != 3*time.Millisecond {
+		t.Errorf("Expected the rule's latency during an action without one, got %v", d)
+	}
+	if !Inject("db") || Inject("db") {
+		t.Error("Expected only the first call to fail")
+	}
+	if h := History("db"); h[len(h)-1].Reason != ReasonExhausted {
+		t.Errorf("Expected exhausted after the last action, got %s", h[len(h)-1].Reason)
+	}
+
+	SetActions("db")
+	if r := Rules()["db"]; r.Mode != ModeNone || len(r.Latency) == 0 {
+		t.Errorf("Expected no actions to clear the mode but keep the latency, got %+v", r)
+	}
+}
+
+func TestRemainingActionFailures(t *testing.T) {
+	r := Rule{Mode: ModeActions, Actions: []Action{{Calls: 2}, {Calls: 3, Fail: true}, {Calls: 1}, {Calls: 2, Fail: true}}}
+	tests := []struct {
+		calls    int
+		expected int
+	}{
+		{0, 5},
+		{3, 4},
+		{5, 2},
+		{7, 1},
+		{8, 0},
+	}
+	for _, tt := range tests {
+		if got := r.remainingActionFailures(tt.calls); got != tt.expected {
+			t.Errorf("After %d calls: expected %d, got %d", tt.calls, tt.expected, got)
+		}
+	}
+	r.Actions = append(r.Actions, Action{Fail: true})
+	if got := r.remainingActionFailures(100); got != -1 {
+		t.Errorf("Expected -1 for an unbounded failing action, got %d", got)
+	}
+}
+
+func TestActionsFromSpec(t *testing.T) {
+	resetState()
+	spec := "rules:\n  api:\n    actions:\n      - {calls: 1, latency: 1ms}\n      - {fail: true}\n"
+	if err := LoadSpecFromBytes([]byte(spec), FormatYAML); err != nil {
+		t.Fatalf("LoadSpecFromBytes failed: %v", err)
+	}
+	r := Rules()["api"]
+	if r.Mode != ModeActions || len(r.Actions) != 2 || r.Actions[0].Latency != time.Millisecond {
+		t.Fatalf("Expected two actions, got %+v", r)
+	}
+	if Inject("api") || !Inject("api") {
+		t.Error("Expected the second call to fail")
+	}
+	if err := ValidateInvariants(); err != nil {
+		t.Errorf("Unexpected invariant violation: %v", err)
+	}
+}
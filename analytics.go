@@ -0,0 +1,75 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"sort"
+)
+
+// RuleUsage is how much the calls a configured rule applies to have been
+// exercised.
+type RuleUsage struct {
+	Rule     string   `json:"rule"`           // the key or pattern the rule is set on
+	Keys     []string `json:"keys,omitempty"` // evaluated keys the rule applies to
+	Calls    uint64   `json:"calls"`
+	Injected uint64   `json:"injected"`
+}
+
+// KeyReport compares the configured rules with the keys the program
+// evaluates, for cleaning up stale specs and finding hot paths that are not
+// under test.
+type KeyReport struct {
+	// Rules lists every configured rule with the evaluated keys it applies
+	// to, sorted by rule.
+	Rules []RuleUsage `json:"rules"`
+	// Dead lists the rules that no evaluated key matches, such as rules
+	// for keys that were renamed or removed, sorted.
+	Dead []string `json:"dead"`
+	// Uncovered lists the evaluated keys no rule applies to, busiest first.
+	Uncovered []KeyInfo `json:"uncovered"`
+}
+
+// KeyAnalytics reports which configured rules match the keys evaluated so
+// far and which evaluated keys have no rule. Keys are matched against the
+// current rules, so a rule counts as matched by calls made before it was
+// set, and the report, like Keys, survives Reset and Prune.
+func (in *Injector) KeyAnalytics() KeyReport {
+	s := in.snapshot()
+	configured := make(map[string]*RuleUsage, len(s.rules)+len(s.skews))
+	for key := range s.rules {
+		configured[key] = &RuleUsage{Rule: key}
+	}
+	for key := range s.skews {
+		configured[key] = &RuleUsage{Rule: key}
+	}
+
+	report := KeyReport{Rules: []RuleUsage{}, Dead: []string{}, Uncovered: []KeyInfo{}}
+	for _, info := range in.Keys() {
+		u, ok := configured[s.ruleKey(info.Key)]
+		if !ok {
+			report.Uncovered = append(report.Uncovered, info)
+			continue
+		}
+		u.Keys = append(u.Keys, info.Key)
+		u.Calls += info.Calls
+		u.Injected += info.Injected
+	}
+	for _, u := range configured {
+		report.Rules = append(report.Rules, *u)
+		if len(u.Keys) == 0 {
+			report.Dead = append(report.Dead, u.Rule)
+		}
+	}
+	sort.Slice(report.Rules, func(i, j int) bool { return report.Rules[i].Rule < report.Rules[j].Rule })
+	sort.Strings(report.Dead)
+	sort.SliceStable(report.Uncovered, func(i, j int) bool { return report.Uncovered[i].Calls > report.Uncovered[j].Calls })
+	return report
+}
+
+// KeyAnalytics calls KeyAnalytics on the default Injector.
+func KeyAnalytics() KeyReport {
+	return std.KeyAnalytics()
+}
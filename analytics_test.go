@@ -0,0 +1,65 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestKeyAnalytics(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-*", 1)
+	in.SetNthFailure("payment-charge", 2)
+	in.SetFailures("cache-get", 1)
+	in.Inject("db-insert")
+	in.Inject("db-select")
+	in.Inject("cache-get")
+	for i := 0; i < 3; i++ {
+		in.Inject("search-query")
+	}
+	in.Inject("auth-check")
+
+	report := in.KeyAnalytics()
+	expectedRules := []RuleUsage{
+		{Rule: "cache-get", Keys: []string{"cache-get"}, Calls: 1, Injected: 1},
+		{Rule: "db-*", Keys: []string{"db-insert", "db-select"}, Calls: 2, Injected: 2},
+		{Rule: "payment-charge"},
+	}
+	if !reflect.DeepEqual(report.Rules, expectedRules) {
+		t.Errorf("Expected rules %+v, got %+v", expectedRules, report.Rules)
+	}
+	if expected := []string{"payment-charge"}; !reflect.DeepEqual(report.Dead, expected) {
+		t.Errorf("Expected dead rules %v, got %v", expected, report.Dead)
+	}
+	var uncovered []string
+	for _, k := range report.Uncovered {
+		uncovered = append(uncovered, k.Key)
+	}
+	if expected := []string{"search-query", "auth-check"}; !reflect.DeepEqual(uncovered, expected) {
+		t.Errorf("Expected uncovered keys %v, busiest first, got %v", expected, uncovered)
+	}
+}
+
+func TestKeyAnalyticsEndpoint(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-insert", 1)
+	in.Inject("cache-get")
+
+	rec := httptest.NewRecorder()
+	in.ControlHandler(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/analytics/keys", nil))
+	var report KeyReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(report.Dead) != 1 || report.Dead[0] != "db-insert" {
+		t.Errorf("Expected db-insert to be dead, got %v", report.Dead)
+	}
+	if len(report.Uncovered) != 1 || report.Uncovered[0].Key != "cache-get" {
+		t.Errorf("Expected cache-get to be uncovered, got %+v", report.Uncovered)
+	}
+}
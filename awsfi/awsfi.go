@@ -0,0 +1,148 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package awsfi injects faults into aws-sdk-go-v2 calls with a middleware,
+// so throttling, server errors and latency can be tested on the real SDK
+// path, retries included.
+//
+// Each attempt of a call evaluates key+"."+<service>+"."+<operation>, such
+// as "aws.EC2.RunInstances", so "aws.EC2.*" targets every call to a service
+// and "aws.*" every call. The service and operation are also set as the
+// "service" and "operation" attributes. The key's latency is added to the
+// attempt, and when the key fires the attempt fails without being sent, as
+// a throttled request by default:
+//
+//	cfg.APIOptions = append(cfg.APIOptions, awsfi.Middleware("aws"))
+//	faultinject.SetFailureRate("aws.DynamoDB.PutItem", 0.1)
+package awsfi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Option configures the middleware and the error returned when a fault
+// fires.
+type Option func(*config)
+
+type config struct {
+	status int
+	code   string
+	in     *faultinject.Injector
+}
+
+// WithInjector makes the middleware decide calls with in instead of the
+// default Injector.
+func WithInjector(in *faultinject.Injector) Option {
+	return func(c *config) { c.in = in }
+}
+
+// WithThrottling makes injected failures look like throttling, with status
+// 429 and code "ThrottlingException", which the SDK retries with backoff.
+// It is the default.
+func WithThrottling() Option {
+	return WithStatus(http.StatusTooManyRequests, "ThrottlingException")
+}
+
+// WithStatus makes injected failures look like a response with status and
+// the API error code, such as 503 and "ServiceUnavailable". A rule's code
+// replaces the API error code.
+func WithStatus(status int, code string) Option {
+	return func(c *config) { c.status, c.code = status, code }
+}
+
+// Middleware returns an API option, for aws.Config.APIOptions or a
+// client's Options.APIOptions, that injects the faults of key into every
+// call made with it.
+func Middleware(key string, opts ...Option) func(*middleware.Stack) error {
+	cfg := config{status: http.StatusTooManyRequests, code: "ThrottlingException", in: faultinject.Default()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return func(stack *middleware.Stack) error {
+		return stack.Finalize.Add(&faultMiddleware{key: key, cfg: cfg}, middleware.After)
+	}
+}
+
+// faultMiddleware is added last to the finalize step, after the retry
+// middleware, so each attempt is a call.
+type faultMiddleware struct {
+	key string
+	cfg config
+}
+
+func (m *faultMiddleware) ID() string { return "FaultInjection" }
+
+func (m *faultMiddleware) HandleFinalize(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+	ctx = faultinject.WithAttributes(ctx, faultinject.Attributes{"service": service, "operation": operation})
+	k := m.key + "." + service + "." + operation
+	if _, err := m.cfg.in.InjectLatency(ctx, k); err != nil {
+		return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+	}
+	if err := m.cfg.in.InjectWithContextError(ctx, k, "aws "+service+"."+operation); err != nil {
+		return middleware.FinalizeOutput{}, middleware.Metadata{}, m.cfg.err(err)
+	}
+	return next.HandleFinalize(ctx, in)
+}
+
+// err returns the error of a response with the status and code of c, which
+// the SDK's retryer classifies as it would a real one.
+func (c config) err(injected error) error {
+	code := c.code
+	var ie *faultinject.InjectedError
+	if errors.As(injected, &ie) && ie.Code != "" {
+		code = ie.Code
+	}
+	fault := smithy.FaultClient
+	if c.status >= 500 {
+		fault = smithy.FaultServer
+	}
+	resp := &smithyhttp.Response{Response: &http.Response{
+		StatusCode: c.status,
+		Status:     fmt.Sprintf("%d %s", c.status, http.StatusText(c.status)),
+		Header:     http.Header{},
+		Body:       http.NoBody,
+	}}
+	return &awshttp.ResponseError{
+		ResponseError: &smithyhttp.ResponseError{
+			Response: resp,
+			Err:      &APIError{Code: code, Message: injected.Error(), Fault: fault, Err: injected},
+		},
+		RequestID: "faultinject",
+	}
+}
+
+// APIError is the smithy.APIError of an injected failure. It wraps the
+// *faultinject.InjectedError, so errors.Is(err, faultinject.ErrInjected)
+// tells it from a real one.
+type APIError struct {
+	Code    string
+	Message string
+	Fault   smithy.ErrorFault
+	Err     error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("api error %s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the API error code.
+func (e *APIError) ErrorCode() string { return e.Code }
+
+// ErrorMessage returns the message of the injected failure.
+func (e *APIError) ErrorMessage() string { return e.Message }
+
+// ErrorFault reports whether the failure is the client's or the server's.
+func (e *APIError) ErrorFault() smithy.ErrorFault { return e.Fault }
+
+func (e *APIError) Unwrap() error { return e.Err }
@@ -0,0 +1,123 @@
+//go:build faultinject
+
+package awsfi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func resetState() {
+	faultinject.Reset()
+	os.Setenv("ENVIRONMENT", "development")
+}
+
+// invoke runs a call to service and operation through a stack with the
+// standard retryer and opts, counting the requests that reach the network.
+func invoke(t *testing.T, service, operation string, maxAttempts int, opts ...func(*middleware.Stack) error) (int, error) {
+	t.Helper()
+	stack := middleware.NewStack(operation, smithyhttp.NewStackRequest)
+	stack.Initialize.Add(&awsmiddleware.RegisterServiceMetadata{ServiceID: service, OperationName: operation}, middleware.Before)
+	// the SDK's retry middleware goes before signing
+	stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("Signing", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		return next.HandleFinalize(ctx, in)
+	}), middleware.After)
+	retryer := retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = maxAttempts
+		o.Backoff = retry.BackoffDelayerFunc(func(int, error) (time.Duration, error) { return 0, nil })
+	})
+	if err := retry.AddRetryMiddlewares(stack, retry.AddRetryMiddlewaresOptions{Retryer: retryer}); err != nil {
+		t.Fatalf("Failed to add retry middleware: %v", err)
+	}
+	for _, opt := range opts {
+		if err := opt(stack); err != nil {
+			t.Fatalf("Failed to add middleware: %v", err)
+		}
+	}
+	sent := 0
+	handler := middleware.DecorateHandler(smithyhttp.NewClientHandler(smithyhttp.ClientDoFunc(func(r *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	})), stack)
+	_, _, err := handler.Handle(context.Background(), struct{}{})
+	return sent, err
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		failures      int
+		opts          []Option
+		expectedSent  int
+		expectedCode  string
+		expectedRetry bool
+	}{
+		{name: "no fault", key: "aws.EC2.RunInstances", expectedSent: 1},
+		{name: "retried throttling", key: "aws.EC2.RunInstances", failures: 2, expectedSent: 1},
+		{name: "throttling", key: "aws.EC2.*", failures: 3, expectedCode: "ThrottlingException", expectedRetry: true},
+		{name: "server error", key: "aws.*", failures: 3, opts: []Option{WithStatus(http.StatusServiceUnavailable, "ServiceUnavailable")}, expectedCode: "ServiceUnavailable", expectedRetry: true},
+		{name: "other service", key: "aws.S3.*", failures: 3, expectedSent: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			faultinject.SetFailures(tt.key, tt.failures)
+			sent, err := invoke(t, "EC2", "RunInstances", 3, Middleware("aws", tt.opts...))
+			if sent != tt.expectedSent {
+				t.Errorf("Expected %d requests sent, got %d", tt.expectedSent, sent)
+			}
+			if tt.expectedCode == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			var apiErr smithy.APIError
+			if !errors.As(err, &apiErr) || apiErr.ErrorCode() != tt.expectedCode {
+				t.Fatalf("Expected API error %s, got %v", tt.expectedCode, err)
+			}
+			if !errors.Is(err, faultinject.ErrInjected) {
+				t.Errorf("Expected the error to match ErrInjected, got %v", err)
+			}
+			if got := retry.NewStandard().IsErrorRetryable(err); got != tt.expectedRetry {
+				t.Errorf("Expected retryable %v, got %v", tt.expectedRetry, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRuleCode(t *testing.T) {
+	resetState()
+	faultinject.SetRule("aws.DynamoDB.PutItem", faultinject.Rule{Mode: faultinject.ModeFirstN, Count: 1, Code: "ProvisionedThroughputExceededException"})
+	_, err := invoke(t, "DynamoDB", "PutItem", 1, Middleware("aws"))
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "ProvisionedThroughputExceededException" {
+		t.Errorf("Expected the rule's code, got %v", err)
+	}
+}
+
+func TestWithInjector(t *testing.T) {
+	resetState()
+	in := faultinject.NewInjector()
+	in.SetFailures("aws.S3.PutObject", 1)
+	sent, err := invoke(t, "S3", "PutObject", 1, Middleware("aws", WithInjector(in)))
+	if sent != 0 || !errors.Is(err, faultinject.ErrInjected) {
+		t.Errorf("Expected the injector's fault before sending, got %d sent and %v", sent, err)
+	}
+	if _, ok := faultinject.FullStatus()["aws.S3.PutObject"]; ok {
+		t.Error("Expected the default Injector not to be used")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Budget caps how many faults may fire within a sliding window, so a
+// misconfigured rule, such as a rate of 1 on a busy key, cannot take out a
+// whole staging environment. A rule's Budget caps its own faults; the
+// injector's, set with SetGlobalBudget, caps them all:
+//
+//	budget: {max: 20, window: 1m}   # every rule together
+//	rules:
+//	  db-query: {rate: 0.5, budget: {max: 5, window: 1m}}
+//
+// Calls a rule would fail while a budget is spent succeed instead, and are
+// recorded with reason budget-exhausted. They still count towards the rule.
+// Budgets cap the faults rules decide; overrides, directives and decisions
+// made through UseShared are not limited.
+type Budget struct {
+	Max    int           `yaml:"max" json:"max"`
+	Window time.Duration `yaml:"window" json:"window"`
+}
+
+// SetBudget lets key's rule fire at most max times within any window. A max
+// or window of zero or less removes the budget. Fault injection is disabled
+// in production environments.
+func (in *Injector) SetBudget(key string, max int, window time.Duration) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Budget = newBudget(max, window)
+	})
+}
+
+// SetBudget calls SetBudget on the default Injector.
+func SetBudget(key string, max int, window time.Duration) {
+	std.SetBudget(key, max, window)
+}
+
+// SetGlobalBudget lets the rules of every key together fire at most max
+// times within any window. A max or window of zero or less removes the
+// budget. Unlike rules, it is not cleared by Reset.
+func (in *Injector) SetGlobalBudget(max int, window time.Duration) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.budget = newBudget(max, window)
+	in.bumpGenerationLocked()
+}
+
+// SetGlobalBudget calls SetGlobalBudget on the default Injector.
+func SetGlobalBudget(max int, window time.Duration) {
+	std.SetGlobalBudget(max, window)
+}
+
+func newBudget(max int, window time.Duration) *Budget {
+	if max <= 0 || window <= 0 {
+		return nil
+	}
+	return &Budget{Max: max, Window: window}
+}
+
+// spendBudgetLocked reports whether the budgets of the rule r, set for
+// ruleKey, and of the injector allow another fault at now, and if so counts
+// it against both. callsMu must be held.
+func (in *Injector) spendBudgetLocked(s *ruleSnapshot, ruleKey string, r Rule, now time.Time) bool {
+	if r.Budget == nil && s.budget == nil {
+		return true
+	}
+	fired := r.Budget.within(in.budgetFires[ruleKey], now)
+	all := s.budget.within(in.globalFires, now)
+	if r.Budget != nil && len(fired) >= r.Budget.Max || s.budget != nil && len(all) >= s.budget.Max {
+		return false
+	}
+	if r.Budget != nil {
+		in.budgetFires[ruleKey] = append(fired, now)
+	}
+	if s.budget != nil {
+		in.globalFires = append(all, now)
+	}
+	return true
+}
+
+// within returns the times in fires, oldest first, that fall within b's
+// window before now.
+func (b *Budget) within(fires []time.Time, now time.Time) []time.Time {
+	if b == nil {
+		return nil
+	}
+	start := now.Add(-b.Window)
+	for len(fires) > 0 && !fires[0].After(start) {
+		fires = fires[1:]
+	}
+	return fires
+}
+
+// budgetUsedLocked returns how many faults count against the budget of the
+// rule set for ruleKey at now. callsMu must be held.
+func (in *Injector) budgetUsedLocked(ruleKey string, r Rule, now time.Time) int {
+	return len(r.Budget.within(in.budgetFires[ruleKey], now))
+}
+
+// validate reports budgets that would never allow a fault.
+func (b *Budget) validate() error {
+	if b != nil && (b.Max <= 0 || b.Window <= 0) {
+		return fmt.Errorf("budget of %d faults per %v allows none", b.Max, b.Window)
+	}
+	return nil
+}
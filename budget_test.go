@@ -0,0 +1,152 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetBudget(t *testing.T) {
+	clk := NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	in, err := New(WithClock(clk), WithEnvGuard(func() bool { return false }))
+	if err != nil {
+		t.Fatalf("Expected New to succeed, got %v", err)
+	}
+	in.SetFailureRate("db", 1)
+	in.SetBudget("db", 2, time.Minute)
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		expected bool
+	}{
+		{name: "first", expected: true},
+		{name: "second", advance: 10 * time.Second, expected: true},
+		{name: "over budget", advance: 10 * time.Second, expected: false},
+		{name: "first fault left the window", advance: 41 * time.Second, expected: true},
+		{name: "over budget again", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk.Advance(tt.advance)
+			if got := in.Inject("db"); got != tt.expected {
+				t.Errorf("Expected fired=%v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if got := in.History("db"); got[len(got)-1].Reason != ReasonBudget {
+		t.Errorf("Expected the last decision to be %s, got %s", ReasonBudget, got[len(got)-1].Reason)
+	}
+	if got := in.FullStatus()["db"].BudgetUsed; got != 2 {
+		t.Errorf("Expected 2 faults against the budget, got %d", got)
+	}
+	if got := in.Counter("db"); got != 5 {
+		t.Errorf("Expected calls over budget to be counted, got %d", got)
+	}
+
+	in.SetBudget("db", 0, 0)
+	if !in.Inject("db") {
+		t.Error("Expected the key to fire once its budget is removed")
+	}
+}
+
+func TestSetGlobalBudget(t *testing.T) {
+	resetState()
+	defer SetGlobalBudget(0, 0)
+	SetFailureRate("db", 1)
+	SetFailureRate("cache", 1)
+	SetGlobalBudget(3, time.Hour)
+
+	fired := 0
+	for range 3 {
+		for _, key := range []string{"db", "cache"} {
+			if Inject(key) {
+				fired++
+			}
+		}
+	}
+	if fired != 3 {
+		t.Errorf("Expected 3 faults across keys, got %d", fired)
+	}
+	st := StatusDetailed()
+	if st.Budget == nil || st.Budget.Max != 3 || st.BudgetUsed != 3 {
+		t.Errorf("Expected 3 of 3 faults used, got %+v and %d", st.Budget, st.BudgetUsed)
+	}
+
+	Reset()
+	SetFailureRate("db", 1)
+	if !Inject("db") {
+		t.Error("Expected Reset to start the budget's window over")
+	}
+	if StatusDetailed().Budget == nil {
+		t.Error("Expected the budget to outlast Reset")
+	}
+}
+
+func TestBudgetSpec(t *testing.T) {
+	resetState()
+	defer SetGlobalBudget(0, 0)
+	spec := "budget: {max: 10, window: 1m}\nrules:\n  db: {rate: 1, budget: {max: 1, window: 1h}}\n"
+	if err := LoadSpecFromBytes([]byte(spec), FormatYAML); err != nil {
+		t.Fatalf("Expected the spec to load, got %v", err)
+	}
+	if !Inject("db") || Inject("db") {
+		t.Error("Expected the rule's budget to allow one fault")
+	}
+	if b := StatusDetailed().Budget; b == nil || b.Max != 10 || b.Window != time.Minute {
+		t.Errorf("Expected the spec's global budget, got %+v", b)
+	}
+	if b := Snapshot().Budget; b == nil || b.Max != 10 {
+		t.Errorf("Expected the snapshot to carry the global budget, got %+v", b)
+	}
+
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{name: "rule", spec: "rules:\n  db: {rate: 1, budget: {max: 0, window: 1m}}\n"},
+		{name: "global", spec: "budget: {max: 5}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LoadSpecFromBytes([]byte(tt.spec), FormatYAML)
+			if err == nil || !strings.Contains(err.Error(), "budget") {
+				t.Errorf("Expected a budget error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSetBudgetOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{name: "budget first", setup: func() {
+			SetBudget("db", 1, time.Hour)
+			SetFailures("db", 5)
+		}},
+		{name: "mode first", setup: func() {
+			SetFailures("db", 5)
+			SetBudget("db", 1, time.Hour)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			fired := 0
+			for range 5 {
+				if Inject("db") {
+					fired++
+				}
+			}
+			if fired != 1 {
+				t.Errorf("Expected the budget to allow 1 fault, got %d", fired)
+			}
+		})
+	}
+}
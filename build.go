@@ -0,0 +1,108 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata stamped in with -ldflags, overriding what the binary's
+// build info reports:
+//
+//	go build -ldflags "-X github.com/talinashro/go-fi.buildVersion=v1.4.0-canary.2 -X github.com/talinashro/go-fi.buildCommit=$(git rev-parse HEAD)"
+var (
+	buildVersion string
+	buildCommit  string
+)
+
+// Build identifies the running binary. In a Rule, its fields are patterns,
+// matched like rule keys, that limit the rule to the binaries they match,
+// such as canary builds carrying one commit; empty fields match any build.
+type Build struct {
+	Version string `yaml:"version,omitempty" json:"version,omitempty"` // main module version, such as v1.4.0
+	Commit  string `yaml:"commit,omitempty" json:"commit,omitempty"`   // VCS revision
+	Go      string `yaml:"go,omitempty" json:"go,omitempty"`           // Go version, such as go1.24.2
+}
+
+// matches reports whether b, as a pattern, matches the build actual.
+func (b Build) matches(actual Build) bool {
+	match := func(pattern, value string) bool {
+		return pattern == "" || MatchKey(pattern, value)
+	}
+	return match(b.Version, actual.Version) && match(b.Commit, actual.Commit) && match(b.Go, actual.Go)
+}
+
+// SetBuild sets the build rules with Build are matched against. It defaults
+// to the version and commit stamped in with -ldflags, or else the main
+// module version and VCS revision from the binary's build info, and the Go
+// version it was built with. Like the environment lists, it survives Reset.
+func (in *Injector) SetBuild(b Build) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.build = b
+	in.mu.dirty = true
+}
+
+// SetBuild calls SetBuild on the default Injector.
+func SetBuild(b Build) {
+	std.SetBuild(b)
+}
+
+// CurrentBuild returns the build set by SetBuild.
+func (in *Injector) CurrentBuild() Build {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.build
+}
+
+// CurrentBuild calls CurrentBuild on the default Injector.
+func CurrentBuild() Build {
+	return std.CurrentBuild()
+}
+
+// SetBuildFilter limits key's rule to the builds b matches, as Rule.Build
+// does. A zero b applies it to every build again. Fault injection is
+// disabled in production environments.
+func (in *Injector) SetBuildFilter(key string, b Build) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Build = b
+	})
+}
+
+// SetBuildFilter calls SetBuildFilter on the default Injector.
+func SetBuildFilter(key string, b Build) {
+	std.SetBuildFilter(key, b)
+}
+
+// defaultBuild reads the build from -ldflags and the binary's build info.
+func defaultBuild() Build {
+	b := Build{Version: buildVersion, Commit: buildCommit, Go: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	if b.Version == "" && info.Main.Version != "(devel)" {
+		b.Version = info.Main.Version
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" && b.Commit == "" {
+			b.Commit = s.Value
+		}
+	}
+	return b
+}
+
+// onBuild reports whether r applies to this build.
+func (s *ruleSnapshot) onBuild(r Rule) bool {
+	return r.Build == Build{} || r.Build.matches(s.build)
+}
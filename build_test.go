@@ -0,0 +1,81 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestBuildFilter(t *testing.T) {
+	build := Build{Version: "v1.4.0-canary.2", Commit: "3f2a9c71e0", Go: "go1.24.2"}
+	tests := []struct {
+		name     string
+		filter   Build
+		expected bool
+	}{
+		{name: "no filter", filter: Build{}, expected: true},
+		{name: "commit prefix", filter: Build{Commit: "3f2a9c*"}, expected: true},
+		{name: "other commit", filter: Build{Commit: "9e1b*"}, expected: false},
+		{name: "version glob", filter: Build{Version: "v1.4.*-canary*"}, expected: true},
+		{name: "stable version", filter: Build{Version: "v1.4.0"}, expected: false},
+		{name: "go regexp", filter: Build{Go: `^go1\.2[3-9]`}, expected: true},
+		{name: "all fields must match", filter: Build{Commit: "3f2a9c*", Go: "go1.22*"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			in := NewInjector()
+			in.SetBuild(build)
+			in.SetFailures("db-connect", 1)
+			in.SetBuildFilter("db-connect", tt.filter)
+			if got := in.Inject("db-connect"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if !tt.expected {
+				if h := in.History("db-connect"); len(h) != 1 || h[0].Reason != ReasonBuild {
+					t.Errorf("Expected a %s decision, got %+v", ReasonBuild, h)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFromSpec(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetBuild(Build{Commit: "3f2a9c71e0"})
+	err := in.LoadSpecFromBytes([]byte("rules:\n  canary: {count: 1, build: {commit: \"3f2a9c*\"}}\n  stable: {count: 1, build: {commit: \"9e1b*\"}}\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("LoadSpecFromBytes failed: %v", err)
+	}
+	if !in.Inject("canary") || in.Inject("stable") {
+		t.Error("Expected only the rule for this commit to fire")
+	}
+}
+
+func TestDefaultBuild(t *testing.T) {
+	b := NewInjector().CurrentBuild()
+	if b.Go != runtime.Version() {
+		t.Errorf("Expected Go version %s, got %s", runtime.Version(), b.Go)
+	}
+
+	buildVersion, buildCommit = "v9.9.9", "abc123"
+	defer func() { buildVersion, buildCommit = "", "" }()
+	if b := defaultBuild(); b.Version != "v9.9.9" || b.Commit != "abc123" {
+		t.Errorf("Expected the -ldflags build, got %+v", b)
+	}
+}
+
+func TestSetBuildFilterBeforeRule(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetBuild(Build{Version: "v1.4.0"})
+	in.SetBuildFilter("db-connect", Build{Version: "*-canary*"})
+	in.SetFailures("db-connect", 1)
+
+	if in.Inject("db-connect") {
+		t.Error("Expected a build outside the filter set before the rule not to fire")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cachefi injects cache-specific faults into a cache client, so
+// stampede protection and fallback to the source of truth can be tested.
+//
+// A wrapped cache evaluates these keys, formed from the cache name passed to
+// NewCache:
+//
+//	<name>.timeout   Get, Set and Del wait for the key's latency, and fail
+//	                 with an error matching context.DeadlineExceeded
+//	<name>.miss      Get misses without asking the cache
+//	<name>.negative  Get hits with the zero value, as a cached "not found"
+//	                 entry would, so the caller does not go to the source
+//	<name>.stale     Get returns the value the key held before its last Set
+//
+// Values are only remembered for stale reads while <name>.stale has a rule,
+// and are forgotten once it is removed, so a wrapped cache keeps no copy of
+// its entries otherwise.
+//
+// A miss storm is a window in which every Get misses; combine a rate of 1
+// with a TTL:
+//
+//	faultinject.SetFailureRate("orders-cache.miss", 1)
+//	faultinject.SetTTL("orders-cache.miss", 30*time.Second)
+//
+// Each call's context is passed to the keys, with the cache key set as the
+// "cache-key" attribute and the operation, "get", "set" or "del", as "op",
+// so selectors can target individual entries.
+package cachefi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Store is a cache client, such as an adapter around Redis or memcache.
+type Store[V any] interface {
+	// Get returns the value cached for key and whether there was one.
+	Get(ctx context.Context, key string) (v V, ok bool, err error)
+	Set(ctx context.Context, key string, v V) error
+}
+
+// Deleter is implemented by Stores that can remove keys, as Del needs.
+type Deleter interface {
+	Del(ctx context.Context, key string) error
+}
+
+// ErrNoDel is returned by Del for Stores that do not implement Deleter.
+var ErrNoDel = errors.New("cachefi: store does not implement Del")
+
+// Cache is a Store with cache faults for one cache name.
+type Cache[V any] struct {
+	name  string
+	store Store[V]
+
+	mu   sync.Mutex
+	last map[string]V // value of each key's last Set while <name>.stale has a rule
+	prev map[string]V // value each key held before its last Set
+}
+
+// NewCache returns a Cache that injects faults for name into store.
+func NewCache[V any](name string, store Store[V]) *Cache[V] {
+	return &Cache[V]{
+		name:  name,
+		store: store,
+		last:  make(map[string]V),
+		prev:  make(map[string]V),
+	}
+}
+
+// Get returns the value cached for key, unless a fault fires. Stale values
+// are only known for keys set through this Cache twice while <name>.stale
+// had a rule; for other keys it has no effect.
+func (c *Cache[V]) Get(ctx context.Context, key string) (V, bool, error) {
+	var zero V
+	ctx = attributes(ctx, "get", key)
+	if err := c.timeout(ctx, "get"); err != nil {
+		return zero, false, err
+	}
+	if faultinject.InjectWithContext(ctx, c.name+".miss") {
+		return zero, false, nil
+	}
+	if faultinject.InjectWithContext(ctx, c.name+".negative") {
+		return zero, true, nil
+	}
+	if faultinject.InjectWithContext(ctx, c.name+".stale") {
+		c.mu.Lock()
+		v, ok := c.prev[key]
+		c.mu.Unlock()
+		if ok {
+			return v, true, nil
+		}
+	}
+	return c.store.Get(ctx, key)
+}
+
+// Set caches v for key, remembering the value it replaces for stale reads
+// while <name>.stale has a rule.
+func (c *Cache[V]) Set(ctx context.Context, key string, v V) error {
+	ctx = attributes(ctx, "set", key)
+	if err := c.timeout(ctx, "set"); err != nil {
+		return err
+	}
+	if err := c.store.Set(ctx, key, v); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !faultinject.HasRule(c.name + ".stale") {
+		clear(c.last)
+		clear(c.prev)
+		return nil
+	}
+	if old, ok := c.last[key]; ok {
+		c.prev[key] = old
+	}
+	c.last[key] = v
+	return nil
+}
+
+// Del removes key from the cache, and forgets its values for stale reads. It
+// returns ErrNoDel if the Store does not implement Deleter.
+func (c *Cache[V]) Del(ctx context.Context, key string) error {
+	d, ok := c.store.(Deleter)
+	if !ok {
+		return ErrNoDel
+	}
+	ctx = attributes(ctx, "del", key)
+	if err := c.timeout(ctx, "del"); err != nil {
+		return err
+	}
+	if err := d.Del(ctx, key); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.last, key)
+	delete(c.prev, key)
+	return nil
+}
+
+// timeout applies the latency and failure rules of <name>.timeout to op.
+func (c *Cache[V]) timeout(ctx context.Context, op string) error {
+	k := c.name + ".timeout"
+	if _, err := faultinject.InjectLatency(ctx, k); err != nil {
+		return err
+	}
+	if err := faultinject.InjectWithContextError(ctx, k, "cache "+op); err != nil {
+		return fmt.Errorf("%w: %w", err, context.DeadlineExceeded)
+	}
+	return nil
+}
+
+func attributes(ctx context.Context, op, key string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return faultinject.WithAttributes(ctx, faultinject.Attributes{"op": op, "cache-key": key})
+}
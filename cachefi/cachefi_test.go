@@ -0,0 +1,195 @@
+//go:build faultinject
+
+package cachefi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// mapStore is an in-memory Store.
+type mapStore struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func (s *mapStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[key]
+	return v, ok, nil
+}
+
+func (s *mapStore) Set(ctx context.Context, key string, v string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = v
+	return nil
+}
+
+func (s *mapStore) Del(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, key)
+	return nil
+}
+
+func resetState() {
+	faultinject.Reset()
+	os.Setenv("ENVIRONMENT", "development")
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func()
+		value     string
+		ok        bool
+	}{
+		{name: "no fault", configure: func() {}, value: "v2", ok: true},
+		{name: "miss", configure: func() { faultinject.SetFailures("orders.miss", 1) }, value: "", ok: false},
+		{name: "negative", configure: func() { faultinject.SetFailures("orders.negative", 1) }, value: "", ok: true},
+		{name: "stale", configure: func() { faultinject.SetFailures("orders.stale", 1) }, value: "v1", ok: true},
+		{name: "selector", configure: func() {
+			faultinject.SetFailures("orders.miss", 1)
+			faultinject.RegisterSelector("other-key", faultinject.AttributeSelector("cache-key", "other"))
+			faultinject.SetSelectors("orders.miss", "other-key")
+		}, value: "v2", ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			c := NewCache[string]("orders", &mapStore{m: make(map[string]string)})
+			ctx := context.Background()
+			tt.configure()
+			c.Set(ctx, "order-1", "v1")
+			c.Set(ctx, "order-1", "v2")
+
+			v, ok, err := c.Get(ctx, "order-1")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if v != tt.value || ok != tt.ok {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.value, tt.ok, v, ok)
+			}
+		})
+	}
+}
+
+func TestStaleWithoutHistory(t *testing.T) {
+	resetState()
+	store := &mapStore{m: map[string]string{"order-1": "v1"}}
+	c := NewCache[string]("orders", store)
+	faultinject.SetFailures("orders.stale", 1)
+
+	if v, _, _ := c.Get(context.Background(), "order-1"); v != "v1" {
+		t.Errorf("Expected the current value without an older one, got %q", v)
+	}
+}
+
+func TestMissStorm(t *testing.T) {
+	resetState()
+	c := NewCache[string]("orders", &mapStore{m: map[string]string{"order-1": "v1"}})
+	faultinject.SetFailureRate("orders.miss", 1)
+	faultinject.SetTTL("orders.miss", 50*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		if _, ok, _ := c.Get(context.Background(), "order-1"); ok {
+			t.Error("Expected every Get to miss during the storm")
+		}
+	}
+	time.Sleep(60 * time.Millisecond)
+	if _, ok, _ := c.Get(context.Background(), "order-1"); !ok {
+		t.Error("Expected Gets to hit after the storm")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func(c *Cache[string]) error
+	}{
+		{name: "get", op: func(c *Cache[string]) error {
+			_, _, err := c.Get(context.Background(), "order-1")
+			return err
+		}},
+		{name: "set", op: func(c *Cache[string]) error { return c.Set(context.Background(), "order-1", "v2") }},
+		{name: "del", op: func(c *Cache[string]) error { return c.Del(context.Background(), "order-1") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			store := &mapStore{m: map[string]string{"order-1": "v1"}}
+			c := NewCache[string]("orders", store)
+			faultinject.SetFailures("orders.timeout", 1)
+			faultinject.SetLatency("orders.timeout", faultinject.FixedLatency(10*time.Millisecond))
+
+			start := time.Now()
+			err := tt.op(c)
+			if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, faultinject.ErrInjected) {
+				t.Errorf("Expected an injected deadline error, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+				t.Errorf("Expected the call to wait for the latency, took %v", elapsed)
+			}
+			if store.m["order-1"] != "v1" {
+				t.Errorf("Expected the store to be left alone, got %q", store.m["order-1"])
+			}
+			if err := tt.op(c); err != nil {
+				t.Errorf("Expected the second call to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDel(t *testing.T) {
+	resetState()
+	c := NewCache[string]("orders", &mapStore{m: make(map[string]string)})
+	ctx := context.Background()
+	faultinject.SetFailures("orders.stale", 1)
+	c.Set(ctx, "order-1", "v1")
+	c.Set(ctx, "order-1", "v2")
+	if err := c.Del(ctx, "order-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok, _ := c.Get(ctx, "order-1"); ok {
+		t.Error("Expected no stale value once the key was deleted")
+	}
+
+	noDel := NewCache[string]("orders", struct{ Store[string] }{&mapStore{m: make(map[string]string)}})
+	if err := noDel.Del(ctx, "order-1"); !errors.Is(err, ErrNoDel) {
+		t.Errorf("Expected ErrNoDel, got %v", err)
+	}
+}
+
+func TestStaleHistoryOnlyWithRule(t *testing.T) {
+	resetState()
+	c := NewCache[string]("orders", &mapStore{m: make(map[string]string)})
+	ctx := context.Background()
+	c.Set(ctx, "order-1", "v1")
+	c.Set(ctx, "order-1", "v2")
+	if len(c.last) != 0 || len(c.prev) != 0 {
+		t.Errorf("Expected no values kept without a stale rule, got %v and %v", c.last, c.prev)
+	}
+
+	faultinject.SetFailures("orders.stale", 1)
+	c.Set(ctx, "order-1", "v3")
+	c.Set(ctx, "order-1", "v4")
+	if v, _, _ := c.Get(ctx, "order-1"); v != "v3" {
+		t.Errorf("Expected the value before the last Set, got %q", v)
+	}
+
+	faultinject.Reset()
+	c.Set(ctx, "order-2", "v1")
+	if len(c.last) != 0 || len(c.prev) != 0 {
+		t.Errorf("Expected the values to be forgotten once the rule is removed, got %v and %v", c.last, c.prev)
+	}
+}
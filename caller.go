@@ -0,0 +1,181 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// A rule's OnlyFrom limits it to calls reached through a matching function,
+// so a shared low-level function, such as a database call, fails only when
+// called from one flow:
+//
+//	rules:
+//	  db-query: {count: 1, only-from: "shop/checkout.*"}
+//
+// The pattern is matched like a rule key against the name of each function
+// on the call stack, such as "github.com/acme/shop/checkout.(*Service).Pay",
+// as a whole or from any of its path elements on, so "checkout.*" covers
+// every function of a checkout package. InjectWhen sets a predicate for
+// what the pattern cannot express. Calls from elsewhere neither fail nor
+// count towards the rule.
+
+// CallInfo describes a call being decided, for InjectWhen predicates.
+type CallInfo struct {
+	Key string
+	// Stack holds the names of the functions the call was made from,
+	// innermost first, leaving out go-fi's own.
+	Stack []string
+}
+
+// CalledFrom reports whether a function on the stack matches pattern, as
+// a rule's OnlyFrom does. An invalid regular expression matches nothing.
+func (c CallInfo) CalledFrom(pattern string) bool {
+	re, err := compileCaller(pattern)
+	return err == nil && c.calledFrom(pattern, re)
+}
+
+func (c CallInfo) calledFrom(pattern string, re *regexp.Regexp) bool {
+	for _, fn := range c.Stack {
+		if matchCaller(pattern, re, fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileCaller returns the matcher for an OnlyFrom pattern, which is nil
+// for a plain function name, or an error if pattern is an invalid regular
+// expression.
+func compileCaller(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "^") {
+		return regexp.Compile(pattern)
+	}
+	return compilePattern(pattern), nil
+}
+
+// matchCaller reports whether fn, or fn from one of its path elements on,
+// matches pattern, whose matcher is re.
+func matchCaller(pattern string, re *regexp.Regexp, fn string) bool {
+	for {
+		if fn == pattern || re != nil && re.MatchString(fn) {
+			return true
+		}
+		_, rest, ok := strings.Cut(fn, "/")
+		if !ok {
+			return false
+		}
+		fn = rest
+	}
+}
+
+// SetOnlyFrom limits key's rule to calls reached through a function
+// matching pattern, as Rule.OnlyFrom does. An empty pattern applies it to
+// every call again. It returns an error, leaving the rule unchanged, if
+// pattern is an invalid regular expression. Fault injection is disabled in
+// production environments.
+func (in *Injector) SetOnlyFrom(key string, pattern string) error {
+	re, err := compileCaller(pattern)
+	if err != nil {
+		return fmt.Errorf("only-from: %w", err)
+	}
+	if in.isProductionEnvironment() {
+		return nil
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.OnlyFrom, r.caller = pattern, re
+	})
+	return nil
+}
+
+// SetOnlyFrom calls SetOnlyFrom on the default Injector.
+func SetOnlyFrom(key string, pattern string) error {
+	return std.SetOnlyFrom(key, pattern)
+}
+
+// InjectWhen limits key's rule to calls pred accepts, such as those whose
+// CallInfo is CalledFrom both a checkout and a retry function. A nil pred
+// applies it to every call again. Like Match and OnlyFrom, it is kept when
+// the rule's mode changes, but it cannot be written in a spec or exported
+// from /state. Fault injection is disabled in production environments.
+func (in *Injector) InjectWhen(key string, pred func(CallInfo) bool) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.when = pred
+	})
+}
+
+// InjectWhen calls InjectWhen on the default Injector.
+func InjectWhen(key string, pred func(CallInfo) bool) {
+	std.InjectWhen(key, pred)
+}
+
+// calledFrom reports whether the current call to key satisfies r's OnlyFrom
+// and InjectWhen predicate. The stack is only walked for rules with either,
+// and is taken from ctx for calls decided for another process.
+func (r Rule) calledFrom(ctx context.Context, key string) bool {
+	if r.OnlyFrom == "" && r.when == nil {
+		return true
+	}
+	stack, ok := callerStackFrom(ctx)
+	if !ok {
+		stack = callerStack()
+	}
+	c := CallInfo{Key: key, Stack: stack}
+	if r.OnlyFrom != "" && !c.calledFrom(r.OnlyFrom, r.caller) {
+		return false
+	}
+	return r.when == nil || r.when(c)
+}
+
+// callerStackKey carries the stack of a call made in another process, for
+// calls decided by ServeShared.
+type callerStackKey struct{}
+
+func callerStackFrom(ctx context.Context) ([]string, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	stack, ok := ctx.Value(callerStackKey{}).([]string)
+	return stack, ok
+}
+
+// callerStack returns the names of the functions on the stack outside
+// go-fi, innermost first, however deep the stack is.
+func callerStack() []string {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+	frames := runtime.CallersFrames(pcs)
+	var stack []string
+	for {
+		frame, more := frames.Next()
+		if !inModule(frame) {
+			stack = append(stack, frame.Function)
+		}
+		if !more {
+			return stack
+		}
+	}
+}
@@ -0,0 +1,174 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func queryDB() bool {
+	return Inject("db")
+}
+
+func checkoutFlow() bool {
+	return queryDB()
+}
+
+func refundFlow() bool {
+	return queryDB()
+}
+
+func TestSetOnlyFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		checkout bool
+		refund   bool
+	}{
+		{name: "no pattern", pattern: "", checkout: true, refund: true},
+		{name: "glob", pattern: "go-fi.checkout*", checkout: true, refund: false},
+		{name: "full name", pattern: "github.com/talinashro/go-fi.refundFlow", checkout: false, refund: true},
+		{name: "regexp", pattern: `^.*\.(checkout|refund)Flow$`, checkout: true, refund: true},
+		{name: "own frames", pattern: "*.(*Injector).Inject", checkout: false, refund: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailureRate("db", 1)
+			SetOnlyFrom("db", tt.pattern)
+			if got := checkoutFlow(); got != tt.checkout {
+				t.Errorf("Expected checkout to fire=%v, got %v", tt.checkout, got)
+			}
+			if got := refundFlow(); got != tt.refund {
+				t.Errorf("Expected refund to fire=%v, got %v", tt.refund, got)
+			}
+		})
+	}
+}
+
+func TestSetOnlyFromInvalid(t *testing.T) {
+	resetState()
+	SetFailureRate("db", 1)
+	if err := SetOnlyFrom("db", "*checkoutFlow"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if re := Rules()["db"].caller; re == nil || !re.MatchString("checkoutFlow") {
+		t.Errorf("Expected the pattern to be compiled when set, got %v", re)
+	}
+
+	if err := SetOnlyFrom("db", "^("); err == nil || !strings.Contains(err.Error(), "only-from") {
+		t.Errorf("Expected an only-from error, got %v", err)
+	}
+	SetRule("db", Rule{Mode: ModeRate, Rate: 1, OnlyFrom: "^("})
+	if r := Rules()["db"]; r.OnlyFrom != "*checkoutFlow" {
+		t.Errorf("Expected invalid patterns to leave the rule unchanged, got %q", r.OnlyFrom)
+	}
+	if !checkoutFlow() || refundFlow() {
+		t.Error("Expected only checkout calls to fire")
+	}
+}
+
+func deepCall(depth int) bool {
+	if depth == 0 {
+		return queryDB()
+	}
+	return deepCall(depth - 1)
+}
+
+func TestOnlyFromDeepStack(t *testing.T) {
+	resetState()
+	SetFailureRate("db", 1)
+	SetOnlyFrom("db", "*TestOnlyFromDeepStack")
+	if !deepCall(200) {
+		t.Error("Expected a caller more than 64 frames up to match")
+	}
+}
+
+func TestOnlyFromCounts(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	SetOnlyFrom("db", "*checkoutFlow")
+
+	if refundFlow() {
+		t.Error("Expected the refund call not to fire")
+	}
+	if got := History("db"); len(got) != 1 || got[0].Reason != ReasonCaller {
+		t.Errorf("Expected a %s decision, got %+v", ReasonCaller, got)
+	}
+	if !checkoutFlow() {
+		t.Error("Expected the first checkout call to fire")
+	}
+	if checkoutFlow() {
+		t.Error("Expected the failure to be used up")
+	}
+
+	SetFailures("db", 1)
+	if r := Rules()["db"]; r.OnlyFrom != "*checkoutFlow" {
+		t.Errorf("Expected OnlyFrom to outlast the mode change, got %q", r.OnlyFrom)
+	}
+}
+
+func TestInjectWhen(t *testing.T) {
+	resetState()
+	SetFailureRate("db", 1)
+	var seen CallInfo
+	InjectWhen("db", func(c CallInfo) bool {
+		seen = c
+		return c.CalledFrom("*refundFlow")
+	})
+
+	if checkoutFlow() {
+		t.Error("Expected the predicate to reject checkout")
+	}
+	if !refundFlow() {
+		t.Error("Expected the predicate to accept refund")
+	}
+	if seen.Key != "db" {
+		t.Errorf("Expected key db, got %q", seen.Key)
+	}
+	if len(seen.Stack) == 0 || !strings.HasSuffix(seen.Stack[0], ".queryDB") {
+		t.Errorf("Expected the stack to start at queryDB, got %v", seen.Stack)
+	}
+
+	InjectWhen("db", nil)
+	if !checkoutFlow() {
+		t.Error("Expected every call to fire once the predicate is removed")
+	}
+}
+
+func TestOnlyFromSpec(t *testing.T) {
+	resetState()
+	err := LoadSpecFromBytes([]byte("rules:\n  db: {rate: 1, only-from: \"*checkoutFlow\"}\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("Expected the spec to load, got %v", err)
+	}
+	if !checkoutFlow() || refundFlow() {
+		t.Error("Expected only checkout calls to fire")
+	}
+
+	err = LoadSpecFromBytes([]byte("rules:\n  db: {rate: 1, only-from: \"^(\"}\n"), FormatYAML)
+	if err == nil || !strings.Contains(err.Error(), "only-from") {
+		t.Errorf("Expected an only-from error, got %v", err)
+	}
+}
+
+func checkoutLatency() time.Duration {
+	d, _ := InjectLatency(context.Background(), "db")
+	return d
+}
+
+func TestOnlyFromLatency(t *testing.T) {
+	resetState()
+	SetLatency("db", LatencyDistribution{{Min: time.Millisecond, Max: time.Millisecond, Weight: 1}})
+	SetOnlyFrom("db", "*checkoutLatency")
+
+	if d, _ := InjectLatency(context.Background(), "db"); d != 0 {
+		t.Errorf("Expected no latency outside checkout, got %v", d)
+	}
+	if d := checkoutLatency(); d != time.Millisecond {
+		t.Errorf("Expected 1ms of latency on checkout, got %v", d)
+	}
+}
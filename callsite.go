@@ -0,0 +1,123 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CallSite is an injection point discovered by InjectHere.
+type CallSite struct {
+	Key      string `json:"key"`
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// InjectHere is Inject with a key derived from the calling function, such
+// as "github.com/acme/shop/store.DB.Save" for a method of *DB, so functions
+// can be instrumented without inventing a key for each. A pattern rule such
+// as "github.com/acme/shop/store.*" covers every call site in a package.
+// With SetCallSiteLines, the key also names the line.
+func (in *Injector) InjectHere() bool {
+	return in.inject(context.Background(), in.callSiteKey(3))
+}
+
+// InjectHere calls InjectHere on the default Injector, keyed by its caller.
+func InjectHere() bool {
+	return std.inject(context.Background(), std.callSiteKey(3))
+}
+
+// InjectHereWithContext is InjectWithContext with the key InjectHere
+// would use.
+func (in *Injector) InjectHereWithContext(ctx context.Context) bool {
+	fired, _ := in.evaluateWithContext(ctx, in.callSiteKey(3))
+	return fired
+}
+
+// InjectHereWithContext calls InjectHereWithContext on the default Injector,
+// keyed by its caller.
+func InjectHereWithContext(ctx context.Context) bool {
+	fired, _ := std.evaluateWithContext(ctx, std.callSiteKey(3))
+	return fired
+}
+
+// SetCallSiteLines makes InjectHere keys end with "@file.go:line", so
+// several calls in one function can fail independently. It applies to call
+// sites reached after it is set, and survives Reset.
+func (in *Injector) SetCallSiteLines(on bool) {
+	in.callSiteLines.Store(on)
+}
+
+// SetCallSiteLines calls SetCallSiteLines on the default Injector.
+func SetCallSiteLines(on bool) {
+	std.SetCallSiteLines(on)
+}
+
+// CallSites returns every call site InjectHere has been called from, sorted
+// by key. Reset does not clear it.
+func (in *Injector) CallSites() []CallSite {
+	var sites []CallSite
+	in.callSites.Range(func(_, v any) bool {
+		sites = append(sites, v.(CallSite))
+		return true
+	})
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Key != sites[j].Key {
+			return sites[i].Key < sites[j].Key
+		}
+		return sites[i].Line < sites[j].Line
+	})
+	return sites
+}
+
+// CallSites calls CallSites on the default Injector.
+func CallSites() []CallSite {
+	return std.CallSites()
+}
+
+// callSiteKey returns the key for the caller skip frames up, resolving each
+// program counter once.
+func (in *Injector) callSiteKey(skip int) string {
+	var pcs [1]uintptr
+	if runtime.Callers(skip, pcs[:]) == 0 {
+		return "unknown"
+	}
+	lines := in.callSiteLines.Load()
+	if v, ok := in.callSites.Load(callSiteID{pcs[0], lines}); ok {
+		return v.(CallSite).Key
+	}
+
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	site := CallSite{Function: frame.Function, File: frame.File, Line: frame.Line}
+	site.Key = functionKey(frame.Function)
+	if lines {
+		site.Key += "@" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+	}
+	in.callSites.Store(callSiteID{pcs[0], lines}, site)
+	in.RegisterKeys(site.Key)
+	return site.Key
+}
+
+type callSiteID struct {
+	pc    uintptr
+	lines bool
+}
+
+// functionKey turns a runtime function name into a key that is not a
+// pattern: "(*DB)" becomes "DB" and type arguments are dropped.
+func functionKey(fn string) string {
+	fn = strings.NewReplacer("(*", "", "(", "", ")", "", "[...]", "").Replace(fn)
+	if fn == "" {
+		return "unknown"
+	}
+	return fn
+}
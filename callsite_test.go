@@ -0,0 +1,136 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type callSiteStore struct{ in *Injector }
+
+func (s *callSiteStore) Save() bool { return s.in.InjectHere() }
+
+func (s callSiteStore) Load(ctx context.Context) bool { return s.in.InjectHereWithContext(ctx) }
+
+func callSiteDefault() bool { return InjectHere() }
+
+func callSiteGeneric[T any](in *Injector) bool { return in.InjectHere() }
+
+const callSitePkg = "github.com/talinashro/go-fi."
+
+func TestInjectHereKeys(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	store := &callSiteStore{in: in}
+
+	tests := []struct {
+		name string
+		key  string
+		call func() bool
+	}{
+		{name: "pointer method", key: callSitePkg + "callSiteStore.Save", call: store.Save},
+		{name: "value method", key: callSitePkg + "callSiteStore.Load", call: func() bool { return store.Load(context.Background()) }},
+		{name: "generic function", key: callSitePkg + "callSiteGeneric", call: func() bool { return callSiteGeneric[int](in) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in.Reset()
+			in.SetFailures(tt.key, 1)
+			if !tt.call() {
+				t.Errorf("Expected the first call to fail with key %s", tt.key)
+			}
+			if tt.call() {
+				t.Error("Expected the second call to succeed")
+			}
+		})
+	}
+
+	sites := in.CallSites()
+	if len(sites) != len(tests) {
+		t.Fatalf("Expected %d call sites, got %+v", len(tests), sites)
+	}
+	for _, s := range sites {
+		if !strings.HasSuffix(s.File, "callsite_test.go") || s.Line == 0 {
+			t.Errorf("Expected a position in callsite_test.go, got %s:%d", s.File, s.Line)
+		}
+	}
+}
+
+func TestInjectHereDefault(t *testing.T) {
+	resetState()
+	SetFailures(callSitePkg+"callSite*", 1)
+	if !callSiteDefault() {
+		t.Error("Expected a pattern rule to match the call site key")
+	}
+	found := false
+	for _, s := range CallSites() {
+		if s.Key == callSitePkg+"callSiteDefault" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected callSiteDefault among %+v", CallSites())
+	}
+}
+
+func TestInjectHereLines(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetCallSiteLines(true)
+	store := &callSiteStore{in: in}
+
+	store.Save()
+	sites := in.CallSites()
+	if len(sites) != 1 {
+		t.Fatalf("Expected 1 call site, got %+v", sites)
+	}
+	if want := callSitePkg + "callSiteStore.Save@callsite_test.go:"; !strings.HasPrefix(sites[0].Key, want) {
+		t.Errorf("Expected a key starting with %q, got %q", want, sites[0].Key)
+	}
+	in.SetFailures(sites[0].Key, 1)
+	if !store.Save() {
+		t.Error("Expected the line key to fail")
+	}
+}
+
+func TestControlHandlerCallSites(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	(&callSiteStore{in: in}).Save()
+
+	rec := httptest.NewRecorder()
+	in.ControlHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/callsites", nil))
+	var sites []CallSite
+	if err := json.NewDecoder(rec.Body).Decode(&sites); err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 1 || sites[0].Key != callSitePkg+"callSiteStore.Save" {
+		t.Errorf("Expected the Save call site, got %+v", sites)
+	}
+}
+
+func TestFunctionKey(t *testing.T) {
+	tests := []struct {
+		fn       string
+		expected string
+	}{
+		{fn: "main.main", expected: "main.main"},
+		{fn: "github.com/acme/shop/store.(*DB).Save", expected: "github.com/acme/shop/store.DB.Save"},
+		{fn: "github.com/acme/shop/store.Map[...].Get", expected: "github.com/acme/shop/store.Map.Get"},
+		{fn: "github.com/acme/shop/store.(*DB).Save.func1", expected: "github.com/acme/shop/store.DB.Save.func1"},
+		{fn: "", expected: "unknown"},
+	}
+	for _, tt := range tests {
+		if got := functionKey(tt.fn); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+		if compilePattern(functionKey(tt.fn)) != nil {
+			t.Errorf("Expected %q not to be a pattern", functionKey(tt.fn))
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"time"
+)
+
+// InjectContextCancel returns a context derived from ctx that is cancelled
+// when key fires, for checking that code honors cancellation all the way
+// down a call chain. With a latency distribution set for key, the deadline
+// is shortened to a sample of it instead, so the context expires partway
+// through the call. context.Cause reports an *InjectedError either way.
+// Call the returned CancelFunc as with context.WithCancel.
+func (in *Injector) InjectContextCancel(ctx context.Context, key string) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	fired, count := in.evaluateWithContext(ctx, key)
+	if !fired {
+		return context.WithCancel(ctx)
+	}
+	cause := in.newInjectedError(key, count, "context cancelled")
+	s := in.snapshot()
+	if dist := s.rules[s.ruleKey(key)].Latency; len(dist) > 0 {
+		return context.WithDeadlineCause(ctx, time.Now().Add(dist.Sample()), cause)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	cancel(cause)
+	return ctx, func() { cancel(nil) }
+}
+
+// InjectContextCancel calls InjectContextCancel on the default Injector.
+func InjectContextCancel(ctx context.Context, key string) (context.Context, context.CancelFunc) {
+	return std.InjectContextCancel(ctx, key)
+}
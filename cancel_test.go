@@ -0,0 +1,55 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInjectContextCancel(t *testing.T) {
+	resetState()
+	tests := []struct {
+		name        string
+		failures    int
+		latency     LatencyDistribution
+		expectedErr error
+	}{
+		{name: "not fired", expectedErr: nil},
+		{name: "cancelled", failures: 1, expectedErr: context.Canceled},
+		{name: "deadline shortened", failures: 1, latency: FixedLatency(10 * time.Millisecond), expectedErr: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			in.SetFailures("checkout", tt.failures)
+			in.SetLatency("checkout", tt.latency)
+
+			ctx, cancel := in.InjectContextCancel(context.Background(), "checkout")
+			defer cancel()
+			select {
+			case <-ctx.Done():
+			case <-time.After(100 * time.Millisecond):
+			}
+			if !errors.Is(ctx.Err(), tt.expectedErr) || (tt.expectedErr == nil) != (ctx.Err() == nil) {
+				t.Errorf("Expected %v, got %v", tt.expectedErr, ctx.Err())
+			}
+			if tt.expectedErr != nil && !errors.Is(context.Cause(ctx), ErrInjected) {
+				t.Errorf("Expected an injected cause, got %v", context.Cause(ctx))
+			}
+		})
+	}
+}
+
+func TestInjectContextCancelParent(t *testing.T) {
+	resetState()
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := InjectContextCancel(parent, "checkout")
+	defer cancel()
+	cancelParent()
+	if ctx.Err() != context.Canceled || errors.Is(context.Cause(ctx), ErrInjected) {
+		t.Errorf("Expected the parent's cancellation, got %v (cause %v)", ctx.Err(), context.Cause(ctx))
+	}
+}
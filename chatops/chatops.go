@@ -0,0 +1,209 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package chatops connects go-fi control servers to a chat channel. A Bot
+// accepts commands such as "@gofi set db-connect 3 on staging", checks that
+// the sender is authorized, forwards them to the control API of the named
+// environment, and posts activation and expiry events back to the channel.
+package chatops
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/talinashro/go-fi/client"
+)
+
+// Command is a parsed chat command.
+type Command struct {
+	Action string // "set", "reset" or "status"
+	Key    string // fault key, only for "set"
+	Count  int    // failure count, only for "set"
+	Target string // environment name; empty means the bot's default target
+}
+
+// mentionRe matches leading bot mentions in Slack (<@U123>, @gofi) and Teams (<at>gofi</at>) messages.
+var mentionRe = regexp.MustCompile(`^\s*(<@[^>]+>|@\S+|<at>[^<]*</at>)\s*`)
+
+// ParseCommand parses "set <key> <count> [on <target>]", "reset [on <target>]"
+// and "status [on <target>]", ignoring a leading bot mention.
+func ParseCommand(text string) (Command, error) {
+	text = mentionRe.ReplaceAllString(text, "")
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return Command{}, fmt.Errorf("empty command")
+	}
+
+	var cmd Command
+	if n := len(fields); n >= 2 && strings.EqualFold(fields[n-2], "on") {
+		cmd.Target = fields[n-1]
+		fields = fields[:n-2]
+	}
+
+	cmd.Action = strings.ToLower(fields[0])
+	args := fields[1:]
+	switch cmd.Action {
+	case "set":
+		if len(args) != 2 {
+			return Command{}, fmt.Errorf("usage: set <key> <count> [on <target>]")
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return Command{}, fmt.Errorf("invalid count %q", args[1])
+		}
+		cmd.Key, cmd.Count = args[0], n
+	case "reset", "status":
+		if len(args) != 0 {
+			return Command{}, fmt.Errorf("usage: %s [on <target>]", cmd.Action)
+		}
+	default:
+		return Command{}, fmt.Errorf("unknown command %q", fields[0])
+	}
+	return cmd, nil
+}
+
+// Bot executes chat commands against one or more control servers.
+type Bot struct {
+	// Targets maps environment names to their control servers.
+	Targets map[string]*client.Client
+	// DefaultTarget is used when a command has no "on <target>" suffix.
+	DefaultTarget string
+	// Authorize decides whether user may run cmd. If nil, every command is rejected.
+	Authorize func(user string, cmd Command) bool
+	// Notifier receives activation and expiry events. Optional.
+	Notifier Notifier
+
+	mu   sync.Mutex
+	seen map[string]map[string]int // last observed status per target, used by Watch
+}
+
+// AllowUsers returns an Authorize func that accepts any command from the listed users.
+func AllowUsers(users ...string) func(string, Command) bool {
+	allowed := make(map[string]bool, len(users))
+	for _, u := range users {
+		allowed[u] = true
+	}
+	return func(user string, _ Command) bool {
+		return allowed[user]
+	}
+}
+
+// Handle parses and runs text on behalf of user and returns the reply to post.
+func (b *Bot) Handle(ctx context.Context, user, text string) (string, error) {
+	cmd, err := ParseCommand(text)
+	if err != nil {
+		return "", err
+	}
+	if cmd.Target == "" {
+		cmd.Target = b.DefaultTarget
+	}
+	c, ok := b.Targets[cmd.Target]
+	if !ok {
+		return "", fmt.Errorf("unknown target %q", cmd.Target)
+	}
+	if b.Authorize == nil || !b.Authorize(user, cmd) {
+		return "", fmt.Errorf("%s is not allowed to run %s on %s", user, cmd.Action, cmd.Target)
+	}
+
+	switch cmd.Action {
+	case "set":
+		if err := c.Set(cmd.Key, cmd.Count); err != nil {
+			return "", err
+		}
+		msg := fmt.Sprintf("%s armed with %d failures on %s by %s", cmd.Key, cmd.Count, cmd.Target, user)
+		b.notify(ctx, msg)
+		return msg, nil
+	case "reset":
+		if err := c.Reset(); err != nil {
+			return "", err
+		}
+		msg := fmt.Sprintf("all faults reset on %s by %s", cmd.Target, user)
+		b.notify(ctx, msg)
+		return msg, nil
+	default:
+		st, err := c.Status()
+		if err != nil {
+			return "", err
+		}
+		return formatStatus(cmd.Target, st), nil
+	}
+}
+
+// Watch polls every target at interval and posts an event whenever a key
+// runs out of remaining failures. It returns when ctx is done.
+func (b *Bot) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		b.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *Bot) poll(ctx context.Context) {
+	names := make([]string, 0, len(b.Targets))
+	for name := range b.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		st, err := b.Targets[name].Status()
+		if err != nil {
+			continue
+		}
+
+		b.mu.Lock()
+		if b.seen == nil {
+			b.seen = make(map[string]map[string]int)
+		}
+		prev := b.seen[name]
+		b.seen[name] = st
+		b.mu.Unlock()
+
+		var expired []string
+		for k, old := range prev {
+			if old > 0 && st[k] == 0 {
+				expired = append(expired, k)
+			}
+		}
+		sort.Strings(expired)
+		for _, k := range expired {
+			b.notify(ctx, fmt.Sprintf("%s expired on %s", k, name))
+		}
+	}
+}
+
+func (b *Bot) notify(ctx context.Context, msg string) {
+	if b.Notifier != nil {
+		b.Notifier.Notify(ctx, msg)
+	}
+}
+
+func formatStatus(target string, st map[string]int) string {
+	if len(st) == 0 {
+		return fmt.Sprintf("no faults configured on %s", target)
+	}
+	keys := make([]string, 0, len(st))
+	for k := range st {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "faults on %s:", target)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n  %s: %d remaining", k, st[k])
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+//go:build faultinject
+
+package chatops
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	faultinject "github.com/talinashro/go-fi"
+	"github.com/talinashro/go-fi/client"
+)
+
+// recorder is a Notifier that keeps every message.
+type recorder struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (r *recorder) Notify(_ context.Context, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.msgs = append(r.msgs, text)
+	return nil
+}
+
+func newTestBot(t *testing.T) (*Bot, *recorder) {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+	srv := httptest.NewServer(faultinject.ControlHandler(nil))
+	t.Cleanup(srv.Close)
+
+	rec := &recorder{}
+	return &Bot{
+		Targets:       map[string]*client.Client{"staging": client.New(srv.URL)},
+		DefaultTarget: "staging",
+		Authorize:     AllowUsers("alice"),
+		Notifier:      rec,
+	}, rec
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		expected    Command
+		expectError bool
+	}{
+		{
+			name:     "set with mention and target",
+			text:     "@gofi set db-connect 3 on staging",
+			expected: Command{Action: "set", Key: "db-connect", Count: 3, Target: "staging"},
+		},
+		{
+			name:     "slack user mention",
+			text:     "<@U123ABC> reset on prod-eu",
+			expected: Command{Action: "reset", Target: "prod-eu"},
+		},
+		{
+			name:     "teams mention without target",
+			text:     "<at>gofi</at> status",
+			expected: Command{Action: "status"},
+		},
+		{name: "empty", text: "@gofi", expectError: true},
+		{name: "missing count", text: "set db-connect", expectError: true},
+		{name: "negative count", text: "set db-connect -1", expectError: true},
+		{name: "unknown action", text: "explode everything", expectError: true},
+		{name: "reset with extra args", text: "reset db-connect", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := ParseCommand(tt.text)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %+v", tt.text, cmd)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if cmd != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, cmd)
+			}
+		})
+	}
+}
+
+func TestBotHandle(t *testing.T) {
+	bot, rec := newTestBot(t)
+	ctx := context.Background()
+
+	if _, err := bot.Handle(ctx, "mallory", "set db-connect 3"); err == nil {
+		t.Error("Expected unauthorized user to be rejected")
+	}
+	if _, err := bot.Handle(ctx, "alice", "set db-connect 3 on prod"); err == nil {
+		t.Error("Expected unknown target to be rejected")
+	}
+
+	if _, err := bot.Handle(ctx, "alice", "@gofi set db-connect 3 on staging"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := faultinject.Status()["db-connect"]; got != 3 {
+		t.Errorf("Expected 3 remaining failures, got %d", got)
+	}
+
+	reply, err := bot.Handle(ctx, "alice", "status")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(reply, "db-connect: 3 remaining") {
+		t.Errorf("Expected status reply to list db-connect, got %q", reply)
+	}
+
+	if _, err := bot.Handle(ctx, "alice", "reset"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(faultinject.Status()) != 0 {
+		t.Error("Expected reset to clear faults")
+	}
+
+	if len(rec.msgs) != 2 {
+		t.Fatalf("Expected 2 notifications, got %v", rec.msgs)
+	}
+	if !strings.Contains(rec.msgs[0], "db-connect armed with 3 failures on staging by alice") {
+		t.Errorf("Unexpected activation message %q", rec.msgs[0])
+	}
+}
+
+func TestBotNilAuthorizeRejects(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.Authorize = nil
+	if _, err := bot.Handle(context.Background(), "alice", "status"); err == nil {
+		t.Error("Expected nil Authorize to reject every command")
+	}
+}
+
+func TestBotWatchExpiry(t *testing.T) {
+	bot, rec := newTestBot(t)
+	ctx := context.Background()
+
+	faultinject.SetFailures("api-call", 1)
+	bot.poll(ctx)
+	faultinject.Inject("api-call")
+	bot.poll(ctx)
+	bot.poll(ctx)
+
+	if len(rec.msgs) != 1 || rec.msgs[0] != "api-call expired on staging" {
+		t.Errorf("Expected one expiry notification, got %v", rec.msgs)
+	}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	if err := (&Webhook{URL: srv.URL}).Notify(context.Background(), "hello"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got["text"] != "hello" {
+		t.Errorf("Expected text 'hello', got %v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := (&Webhook{URL: failing.URL}).Notify(context.Background(), "hello"); err == nil {
+		t.Error("Expected error from failing webhook")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package chatops
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSkew bounds how old a signed Slack request may be, to limit replays.
+const maxSkew = 5 * time.Minute
+
+// SlackHandler serves Slack slash commands. Requests are verified against
+// signingSecret using Slack's v0 request signature; the sender's user_id is
+// what Authorize sees. With an empty signingSecret every request is refused.
+func (b *Bot) SlackHandler(signingSecret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if !verifySlack(signingSecret, r.Header, body, time.Now()) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		reply, err := b.Handle(r.Context(), form.Get("user_id"), form.Get("text"))
+		respType := "in_channel"
+		if err != nil {
+			reply, respType = err.Error(), "ephemeral"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"response_type": respType, "text": reply})
+	})
+}
+
+// TeamsHandler serves Microsoft Teams outgoing webhooks. Requests are verified
+// against the base64 secret Teams issues for the webhook; the sender's AAD
+// object id is what Authorize sees. With an empty secret every request is
+// refused.
+func (b *Bot) TeamsHandler(secret string) http.Handler {
+	key, keyErr := base64.StdEncoding.DecodeString(secret)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if keyErr != nil || !verifyTeams(key, r.Header.Get("Authorization"), body) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		var msg struct {
+			Text string `json:"text"`
+			From struct {
+				AADObjectID string `json:"aadObjectId"`
+			} `json:"from"`
+		}
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&msg); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		reply, err := b.Handle(r.Context(), msg.From.AADObjectID, msg.Text)
+		if err != nil {
+			reply = err.Error()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"type": "message", "text": reply})
+	})
+}
+
+func verifySlack(secret string, h http.Header, body []byte, now time.Time) bool {
+	if secret == "" {
+		return false // anyone can sign with an empty key
+	}
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > maxSkew || d < -maxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature")))
+}
+
+func verifyTeams(key []byte, auth string, body []byte) bool {
+	if len(key) == 0 {
+		return false
+	}
+	sig, ok := strings.CutPrefix(auth, "HMAC ")
+	if !ok {
+		return false
+	}
+	got, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), got)
+}
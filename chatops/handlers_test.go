@@ -0,0 +1,143 @@
+//go:build faultinject
+
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func signSlack(secret, ts, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSlackHandler(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.Authorize = AllowUsers("U1")
+	h := bot.SlackHandler("s3cret")
+
+	body := url.Values{"user_id": {"U1"}, "text": {"set db-connect 2"}}.Encode()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name           string
+		ts             string
+		sig            string
+		expectedStatus int
+	}{
+		{name: "valid signature", ts: now, sig: signSlack("s3cret", now, body), expectedStatus: 200},
+		{name: "wrong secret", ts: now, sig: signSlack("other", now, body), expectedStatus: 401},
+		{name: "stale timestamp", ts: stale, sig: signSlack("s3cret", stale, body), expectedStatus: 401},
+		{name: "missing signature", ts: now, expectedStatus: 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faultinject.Reset()
+			req := httptest.NewRequest("POST", "/slack", strings.NewReader(body))
+			req.Header.Set("X-Slack-Request-Timestamp", tt.ts)
+			req.Header.Set("X-Slack-Signature", tt.sig)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			want := 0
+			if tt.expectedStatus == 200 {
+				want = 2
+			}
+			if got := faultinject.Status()["db-connect"]; got != want {
+				t.Errorf("Expected %d remaining failures, got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestEmptySecret(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.Authorize = AllowUsers("U1", "aad-1")
+
+	slackBody := url.Values{"user_id": {"U1"}, "text": {"set db-connect 2"}}.Encode()
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	slack := httptest.NewRequest("POST", "/slack", strings.NewReader(slackBody))
+	slack.Header.Set("X-Slack-Request-Timestamp", now)
+	slack.Header.Set("X-Slack-Signature", signSlack("", now, slackBody))
+
+	teamsBody := `{"text":"set db-connect 2","from":{"aadObjectId":"aad-1"}}`
+	mac := hmac.New(sha256.New, nil)
+	mac.Write([]byte(teamsBody))
+	teams := httptest.NewRequest("POST", "/teams", strings.NewReader(teamsBody))
+	teams.Header.Set("Authorization", "HMAC "+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		req     *http.Request
+	}{
+		{name: "slack", handler: bot.SlackHandler(""), req: slack},
+		{name: "teams", handler: bot.TeamsHandler(""), req: teams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faultinject.Reset()
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, tt.req)
+			if w.Code != 401 {
+				t.Errorf("Expected status 401, got %d", w.Code)
+			}
+			if got := faultinject.Status()["db-connect"]; got != 0 {
+				t.Errorf("Expected no failures armed, got %d", got)
+			}
+		})
+	}
+}
+
+func TestTeamsHandler(t *testing.T) {
+	bot, _ := newTestBot(t)
+	bot.Authorize = AllowUsers("aad-1")
+	secret := base64.StdEncoding.EncodeToString([]byte("teams-key"))
+	h := bot.TeamsHandler(secret)
+
+	body := `{"text":"<at>gofi</at> set api-call 1","from":{"aadObjectId":"aad-1"}}`
+	mac := hmac.New(sha256.New, []byte("teams-key"))
+	mac.Write([]byte(body))
+	sig := "HMAC " + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest("POST", "/teams", strings.NewReader(body))
+	req.Header.Set("Authorization", sig)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var reply map[string]string
+	json.NewDecoder(w.Body).Decode(&reply)
+	if !strings.Contains(reply["text"], "api-call armed") {
+		t.Errorf("Unexpected reply %v", reply)
+	}
+
+	req = httptest.NewRequest("POST", "/teams", strings.NewReader(body))
+	req.Header.Set("Authorization", "HMAC bm9wZQ==")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != 401 {
+		t.Errorf("Expected status 401 for bad signature, got %d", w.Code)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Notifier posts a message to a chat channel.
+type Notifier interface {
+	Notify(ctx context.Context, text string) error
+}
+
+// Webhook posts messages to a Slack or Microsoft Teams incoming webhook URL.
+// Both accept a JSON body with a "text" field.
+type Webhook struct {
+	URL    string
+	Client *http.Client // defaults to http.DefaultClient
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	c := w.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package chifi injects faults into requests served by a chi router. Its
+// middleware responds like faultinject.HTTPMiddleware, and exposes the
+// request's route pattern to selectors as the "route" attribute and its URL
+// parameters as "param.<name>" attributes, even from a router's Use, before
+// chi has routed the request. Pass faultinject.WithInjector to decide
+// requests with an Injector other than the default one.
+package chifi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Middleware injects the faults of key into every request.
+func Middleware(key string, opts ...faultinject.MiddlewareOption) func(http.Handler) http.Handler {
+	return middleware(func(string, *http.Request) string { return key }, opts)
+}
+
+// RouteMiddleware injects faults into each request under a key of its own,
+// its method and route pattern, such as "GET /users/{id}", so one
+// middleware on the router covers every endpoint. Requests that match no
+// route are passed on untouched.
+func RouteMiddleware(opts ...faultinject.MiddlewareOption) func(http.Handler) http.Handler {
+	return middleware(func(route string, r *http.Request) string {
+		if route == "" {
+			return ""
+		}
+		return r.Method + " " + route
+	}, opts)
+}
+
+func middleware(key func(route string, r *http.Request) string, opts []faultinject.MiddlewareOption) func(http.Handler) http.Handler {
+	inject := faultinject.HTTPMiddlewareFunc(func(r *http.Request) string {
+		return key(faultinject.AttributesFromContext(r.Context())["route"], r)
+	}, opts...)
+	return func(next http.Handler) http.Handler {
+		h := inject(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r.WithContext(faultinject.WithAttributes(r.Context(), routeAttributes(r))))
+		})
+	}
+}
+
+// routeAttributes returns the route pattern and URL parameters of r. chi
+// routes a request only after running the router's middleware, so they are
+// looked up in the router the request is served by.
+func routeAttributes(r *http.Request) faultinject.Attributes {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return nil
+	}
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	found := chi.NewRouteContext()
+	route := rctx.Routes.Find(found, r.Method, path)
+	if route == "" {
+		return nil
+	}
+	attrs := faultinject.Attributes{"route": route}
+	for i, name := range found.URLParams.Keys {
+		if name != "*" && i < len(found.URLParams.Values) {
+			attrs["param."+name] = found.URLParams.Values[i]
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,107 @@
+//go:build faultinject
+
+package chifi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func resetState() {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	resetState()
+	r := chi.NewRouter()
+	r.Use(RouteMiddleware())
+	r.Get("/users/{id}", ok)
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/orders/{id}", ok)
+	})
+	faultinject.SetRule("GET /users/{id}", faultinject.Rule{Mode: faultinject.ModeFirstN, Count: 1, Status: http.StatusServiceUnavailable})
+	faultinject.SetFailures("GET /api/orders/{id}", 1)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{name: "route fires", method: http.MethodGet, path: "/users/1", expectedStatus: http.StatusServiceUnavailable},
+		{name: "route used up", method: http.MethodGet, path: "/users/2", expectedStatus: http.StatusOK},
+		{name: "mounted route", method: http.MethodGet, path: "/api/orders/7", expectedStatus: http.StatusInternalServerError},
+		{name: "other method", method: http.MethodPost, path: "/users/1", expectedStatus: http.StatusMethodNotAllowed},
+		{name: "no route", method: http.MethodGet, path: "/missing", expectedStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+
+	if _, ok := faultinject.FullStatus()["POST /users/{id}"]; ok {
+		t.Error("Expected requests without a route not to be evaluated")
+	}
+}
+
+func TestMiddlewareAttributes(t *testing.T) {
+	resetState()
+	faultinject.RegisterSelector("user-7", faultinject.AttributeSelector("param.id", "7"))
+	faultinject.RegisterSelector("users-route", faultinject.AttributeSelector("route", "/users/{id}"))
+	faultinject.SetRule("users", faultinject.Rule{Mode: faultinject.ModeRate, Rate: 1, Status: http.StatusBadGateway})
+	faultinject.SetSelectors("users", "user-7", "users-route")
+
+	r := chi.NewRouter()
+	r.Use(Middleware("users"))
+	r.Get("/users/{id}", ok)
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/users/7", expectedStatus: http.StatusBadGateway},
+		{path: "/users/8", expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestWithInjector(t *testing.T) {
+	resetState()
+	in := faultinject.NewInjector()
+	r := chi.NewRouter()
+	r.Use(RouteMiddleware(faultinject.WithInjector(in)))
+	r.Get("/users/{id}", ok)
+	in.SetFailures("GET /users/{id}", 1)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rec.Code)
+	}
+	if _, ok := faultinject.FullStatus()["GET /users/{id}"]; ok {
+		t.Error("Expected the default Injector not to be used")
+	}
+}
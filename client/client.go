@@ -0,0 +1,226 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package client talks to a go-fi control server over HTTP.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrConflict is returned by conditional updates when the server's
+// configuration changed since the given generation was read.
+var ErrConflict = errors.New("configuration changed concurrently")
+
+// Client talks to a single go-fi control server.
+type Client struct {
+	// Base is the server URL, e.g. http://localhost:8081.
+	Base string
+	// HTTP is the client used for requests.
+	HTTP *http.Client
+}
+
+// New returns a Client for addr. A scheme-less addr defaults to http.
+func New(addr string) *Client {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &Client{
+		Base: strings.TrimRight(addr, "/"),
+		HTTP: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Status returns the remaining first-N failures per key.
+func (c *Client) Status() (map[string]int, error) {
+	resp, err := c.HTTP.Get(c.Base + "/status")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status: %s", resp.Status)
+	}
+	out := make(map[string]int)
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+	return out, nil
+}
+
+// StatusWithGeneration returns Status for the given keys, or every key when
+// none are given, together with the configuration generation it was read at.
+func (c *Client) StatusWithGeneration(keys ...string) (map[string]int, uint64, error) {
+	path := "/status"
+	if len(keys) > 0 {
+		path += "?" + url.Values{"keys": {strings.Join(keys, ",")}}.Encode()
+	}
+	resp, err := c.HTTP.Get(c.Base + path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("status: %s", resp.Status)
+	}
+	out := make(map[string]int)
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, 0, fmt.Errorf("status: %w", err)
+	}
+	gen, err := generation(resp)
+	if err != nil {
+		return nil, 0, fmt.Errorf("status: %w", err)
+	}
+	return out, gen, nil
+}
+
+// Apply sets several first-N rules in one step if the server is still at
+// generation gen; a zero gen applies unconditionally. It returns the new
+// generation, or ErrConflict if another client changed the configuration.
+func (c *Client) Apply(gen uint64, failures map[string]int) (uint64, error) {
+	body, err := json.Marshal(failures)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.Base+"/apply", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if gen != 0 {
+		req.Header.Set("If-Match", `"`+strconv.FormatUint(gen, 10)+`"`)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return generation(resp)
+	case http.StatusPreconditionFailed:
+		return 0, ErrConflict
+	}
+	return 0, fmt.Errorf("/apply: %s", resp.Status)
+}
+
+// WaitForChange long-polls the server until its configuration generation
+// differs from gen, or until timeout, and returns the status and generation.
+// On timeout it returns a nil status and gen. A zero gen returns at once.
+// The request is bounded by ctx and timeout rather than the client's Timeout.
+func (c *Client) WaitForChange(ctx context.Context, gen uint64, timeout time.Duration) (map[string]int, uint64, error) {
+	q := url.Values{
+		"generation": {strconv.FormatUint(gen, 10)},
+		"timeout":    {timeout.String()},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.Base+"/faults/watch?"+q.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	hc := *c.HTTP
+	hc.Timeout = 0
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, gen, nil
+	case http.StatusOK:
+	default:
+		return nil, 0, fmt.Errorf("watch: %s", resp.Status)
+	}
+	var out struct {
+		Generation uint64         `json:"generation"`
+		Status     map[string]int `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, 0, fmt.Errorf("watch: %w", err)
+	}
+	return out.Status, out.Generation, nil
+}
+
+// Set configures key to fail its next count calls.
+func (c *Client) Set(key string, count int) error {
+	q := url.Values{"key": {key}, "count": {strconv.Itoa(count)}}
+	return c.post("/set?" + q.Encode())
+}
+
+// Reset clears every fault on the server.
+func (c *Client) Reset() error {
+	return c.post("/reset")
+}
+
+// ResetByLabel clears the faults whose rules match the label selector, such
+// as "scenario=checkout".
+func (c *Client) ResetByLabel(selector string) error {
+	return c.post("/reset?" + url.Values{"label": {selector}}.Encode())
+}
+
+// Export returns the server's configuration and call counts as YAML, for
+// Import into another server.
+func (c *Client) Export() ([]byte, error) {
+	resp, err := c.HTTP.Get(c.Base + "/state")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("export: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("export: %w", err)
+	}
+	return data, nil
+}
+
+// Import replaces the server's configuration and call counts with state, as
+// returned by Export.
+func (c *Client) Import(state []byte) error {
+	resp, err := c.HTTP.Post(c.Base+"/state", "application/yaml", bytes.NewReader(state))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("import: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func (c *Client) post(path string) error {
+	resp, err := c.HTTP.Post(c.Base+path, "text/plain", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return nil
+}
+
+// generation parses the ETag the server sets to its configuration generation.
+func generation(resp *http.Response) (uint64, error) {
+	tag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	gen, err := strconv.ParseUint(tag, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ETag %q", resp.Header.Get("ETag"))
+	}
+	return gen, nil
+}
@@ -0,0 +1,164 @@
+//go:build faultinject
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func newTestServer(t *testing.T) *Client {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+	srv := httptest.NewServer(faultinject.ControlHandler(nil))
+	t.Cleanup(srv.Close)
+	return New(srv.URL)
+}
+
+func TestClient(t *testing.T) {
+	c := newTestServer(t)
+
+	if err := c.Set("db-connect", 3); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	faultinject.Inject("db-connect")
+
+	st, err := c.Status()
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if st["db-connect"] != 2 {
+		t.Errorf("Expected 2 remaining failures, got %d", st["db-connect"])
+	}
+
+	if err := c.Reset(); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	st, err = c.Status()
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	if len(st) != 0 {
+		t.Errorf("Expected empty status after reset, got %v", st)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	c := New(srv.URL)
+
+	if _, err := c.Status(); err == nil {
+		t.Error("Expected error from Status on 404")
+	}
+	if err := c.Set("key", 1); err == nil {
+		t.Error("Expected error from Set on 404")
+	}
+}
+
+func TestNewAddsScheme(t *testing.T) {
+	if got := New("localhost:8081/").Base; got != "http://localhost:8081" {
+		t.Errorf("Expected http://localhost:8081, got %s", got)
+	}
+	if got := New("https://fi.example.com").Base; got != "https://fi.example.com" {
+		t.Errorf("Expected https://fi.example.com, got %s", got)
+	}
+}
+
+func TestClientApply(t *testing.T) {
+	c := newTestServer(t)
+
+	_, gen, err := c.StatusWithGeneration()
+	if err != nil {
+		t.Fatalf("StatusWithGeneration returned error: %v", err)
+	}
+	next, err := c.Apply(gen, map[string]int{"db-connect": 2, "api-call": 1})
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if next <= gen {
+		t.Errorf("Expected generation to advance past %d, got %d", gen, next)
+	}
+
+	if _, err := c.Apply(gen, map[string]int{"db-connect": 5}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict for stale generation, got %v", err)
+	}
+
+	st, _, err := c.StatusWithGeneration("db-connect")
+	if err != nil {
+		t.Fatalf("StatusWithGeneration returned error: %v", err)
+	}
+	if len(st) != 1 || st["db-connect"] != 2 {
+		t.Errorf("Expected only db-connect with 2 remaining, got %v", st)
+	}
+}
+
+func TestClientWaitForChange(t *testing.T) {
+	c := newTestServer(t)
+	c.HTTP.Timeout = 10 * time.Millisecond // must not cut the long poll short
+
+	_, gen, err := c.StatusWithGeneration()
+	if err != nil {
+		t.Fatalf("StatusWithGeneration returned error: %v", err)
+	}
+
+	st, got, err := c.WaitForChange(context.Background(), gen, 20*time.Millisecond)
+	if err != nil || st != nil || got != gen {
+		t.Errorf("Expected timeout to return nil status and %d, got %v, %d, %v", gen, st, got, err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		faultinject.SetFailures("db-connect", 1)
+	}()
+	st, got, err = c.WaitForChange(context.Background(), gen, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForChange returned error: %v", err)
+	}
+	if got <= gen || st["db-connect"] != 1 {
+		t.Errorf("Expected new generation with db-connect, got %d and %v", got, st)
+	}
+}
+
+func TestClientExportImport(t *testing.T) {
+	c := newTestServer(t)
+	faultinject.SetFailures("db-connect", 3)
+	faultinject.Inject("db-connect")
+
+	state, err := c.Export()
+	if err != nil {
+		t.Fatalf("Export returned error: %v", err)
+	}
+	faultinject.Reset()
+	if err := c.Import(state); err != nil {
+		t.Fatalf("Import returned error: %v", err)
+	}
+	if got := faultinject.Status()["db-connect"]; got != 2 {
+		t.Errorf("Expected 2 remaining failures after import, got %d", got)
+	}
+
+	if err := c.Import([]byte("rules: {db: {rate: 2}}")); err == nil {
+		t.Error("Expected an error for an invalid state")
+	}
+}
+
+func TestClientResetByLabel(t *testing.T) {
+	c := newTestServer(t)
+	faultinject.SetFailures("db-connect", 3)
+	faultinject.SetLabels("db-connect", map[string]string{"scenario": "checkout"})
+	faultinject.SetFailures("cache-get", 3)
+
+	if err := c.ResetByLabel("scenario=checkout"); err != nil {
+		t.Fatalf("ResetByLabel returned error: %v", err)
+	}
+	if got := faultinject.Status(); len(got) != 1 || got["cache-get"] != 3 {
+		t.Errorf("Expected only cache-get to remain, got %v", got)
+	}
+}
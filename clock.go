@@ -0,0 +1,175 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the real time, the default Clock of an Injector.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return timeNow() }
+
+// timeNow returns the time on in's clock.
+func (in *Injector) timeNow() time.Time {
+	return in.clock.Now()
+}
+
+// ClockSkew describes how Now(key) departs from the real time. Offset is
+// added to the time, Drift adds that many seconds per real second since the
+// skew was set, and Frozen stops the clock at the moment it was set. Zone,
+// an IANA name such as "Asia/Kolkata", reports times in another location;
+// unknown zone names are ignored.
+type ClockSkew struct {
+	Offset time.Duration `yaml:"offset,omitempty" json:"offset,omitempty"`
+	Drift  float64       `yaml:"drift,omitempty" json:"drift,omitempty"`
+	Frozen bool          `yaml:"frozen,omitempty" json:"frozen,omitempty"`
+	Zone   string        `yaml:"zone,omitempty" json:"zone,omitempty"`
+}
+
+// FixedSkew returns a skew that moves the clock by d.
+func FixedSkew(d time.Duration) ClockSkew {
+	return ClockSkew{Offset: d}
+}
+
+// DriftingSkew returns a skew that gains rate seconds per second, or loses
+// them when rate is negative; 0.01 gains 36s per hour.
+func DriftingSkew(rate float64) ClockSkew {
+	return ClockSkew{Drift: rate}
+}
+
+// FrozenClock returns a skew that stops the clock when it is set.
+func FrozenClock() ClockSkew {
+	return ClockSkew{Frozen: true}
+}
+
+type skewRule struct {
+	skew  ClockSkew
+	start time.Time
+	loc   *time.Location
+}
+
+// SetClockSkew makes Now(key) return skewed times, to test certificate
+// expiry, token expiry and scheduling logic against time anomalies. Like
+// latency, skew applies to every matching call and does not use up the key's
+// failure count. A zero ClockSkew removes it. Fault injection is disabled in
+// production environments.
+func (in *Injector) SetClockSkew(key string, skew ClockSkew) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setClockSkewLocked(key, skew)
+}
+
+func (in *Injector) setClockSkewLocked(key string, skew ClockSkew) {
+	in.bumpGenerationLocked()
+	if skew == (ClockSkew{}) {
+		delete(in.skews, key)
+		return
+	}
+	now := in.timeNow()
+	rule := skewRule{skew: skew, start: now}
+	if skew.Zone != "" {
+		if loc, err := time.LoadLocation(skew.Zone); err == nil {
+			rule.loc = loc
+		}
+	}
+	in.skews[key] = rule
+	in.registerPatternLocked(key)
+	in.touchLocked(key, now)
+}
+
+// SetClockSkew calls SetClockSkew on the default Injector.
+func SetClockSkew(key string, skew ClockSkew) {
+	std.SetClockSkew(key, skew)
+}
+
+// Now returns the current time as seen through key's clock skew, or the real
+// time if key has none.
+func (in *Injector) Now(key string) time.Time {
+	return in.NowWithContext(context.Background(), key)
+}
+
+// Now calls Now on the default Injector.
+func Now(key string) time.Time {
+	return std.Now(key)
+}
+
+// NowWithContext is Now for a call made with ctx, so selectors, tenants and
+// synthetic exclusion apply.
+func (in *Injector) NowWithContext(ctx context.Context, key string) time.Time {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	now := in.timeNow()
+	if in.isProductionEnvironment() {
+		return now
+	}
+	if !in.syntheticAllowed(ctx, key) || !in.tenantAllowed(ctx, key) || !in.selected(ctx, key) {
+		return now
+	}
+
+	in.mu.RLock()
+	rk := in.ruleKeyLocked(key)
+	rule, ok := in.skews[rk]
+	if ok && in.expiredLocked(rk) {
+		ok = false
+	}
+	in.mu.RUnlock()
+	if !ok {
+		return now
+	}
+	return rule.at(now)
+}
+
+// NowWithContext calls NowWithContext on the default Injector.
+func NowWithContext(ctx context.Context, key string) time.Time {
+	return std.NowWithContext(ctx, key)
+}
+
+// at returns the skewed time for the real time now.
+func (r skewRule) at(now time.Time) time.Time {
+	elapsed := now.Sub(r.start)
+	t := now
+	if r.skew.Frozen {
+		t = r.start
+	}
+	t = t.Add(r.skew.Offset + time.Duration(r.skew.Drift*float64(elapsed)))
+	if r.loc != nil {
+		t = t.In(r.loc)
+	}
+	return t
+}
+
+// SkewedClock returns a Clock whose Now is Now(key), for code that takes a
+// Clock.
+func (in *Injector) SkewedClock(key string) Clock {
+	return skewedClock{in: in, key: key}
+}
+
+// SkewedClock calls SkewedClock on the default Injector.
+func SkewedClock(key string) Clock {
+	return std.SkewedClock(key)
+}
+
+type skewedClock struct {
+	in  *Injector
+	key string
+}
+
+func (c skewedClock) Now() time.Time {
+	return c.in.Now(c.key)
+}
@@ -0,0 +1,107 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNow(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		name     string
+		skew     ClockSkew
+		elapsed  time.Duration
+		expected time.Time
+	}{
+		{name: "no skew", elapsed: time.Hour, expected: start.Add(time.Hour)},
+		{name: "fixed", skew: FixedSkew(-10 * time.Minute), elapsed: time.Hour, expected: start.Add(50 * time.Minute)},
+		{name: "drifting", skew: DriftingSkew(0.01), elapsed: time.Hour, expected: start.Add(time.Hour + 36*time.Second)},
+		{name: "frozen", skew: FrozenClock(), elapsed: time.Hour, expected: start},
+		{name: "frozen with offset", skew: ClockSkew{Frozen: true, Offset: 24 * time.Hour}, elapsed: time.Hour, expected: start.Add(24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			now = start
+			SetClockSkew("token", tt.skew)
+			now = start.Add(tt.elapsed)
+			if got := Now("token"); !got.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+			if got := SkewedClock("token").Now(); !got.Equal(tt.expected) {
+				t.Errorf("Expected SkewedClock to return %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNowZone(t *testing.T) {
+	resetState()
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	SetClockSkew("report", ClockSkew{Zone: "Asia/Kolkata"})
+	if got := Now("report").Location(); got.String() != loc.String() {
+		t.Errorf("Expected location %s, got %s", loc, got)
+	}
+}
+
+func TestNowScoping(t *testing.T) {
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	resetState()
+	SetClockSkew("cert-*", FixedSkew(time.Hour))
+	SetTenants("cert-*", "acme")
+	SetTTL("cert-*", time.Minute)
+
+	if got := Now("cert-check"); !got.Equal(start) {
+		t.Errorf("Expected real time for other tenants, got %s", got)
+	}
+	acme := WithTenant(context.Background(), "acme")
+	if got := NowWithContext(acme, "cert-check"); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected skewed time for acme, got %s", got)
+	}
+	now = start.Add(time.Minute)
+	if got := NowWithContext(acme, "cert-check"); !got.Equal(now) {
+		t.Errorf("Expected real time once the TTL passed, got %s", got)
+	}
+
+	SetClockSkew("cert-*", ClockSkew{})
+	t.Setenv("ENVIRONMENT", "production")
+	SetClockSkew("token", FixedSkew(time.Hour))
+	if got := Now("token"); !got.Equal(now) {
+		t.Errorf("Expected real time in production, got %s", got)
+	}
+}
+
+func TestClockSkewsSpec(t *testing.T) {
+	resetState()
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	content := "clock-skews:\n  token: {offset: -5m}\n  cron: {frozen: true}\n"
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := LoadSpec(spec); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	std.mu.Lock()
+	token, cron := std.skews["token"].skew, std.skews["cron"].skew
+	std.mu.Unlock()
+	if token.Offset != -5*time.Minute || !cron.Frozen {
+		t.Errorf("Expected skews loaded from spec, got %+v and %+v", token, cron)
+	}
+}
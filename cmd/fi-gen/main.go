@@ -0,0 +1,330 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Command fi-gen generates a fault-injecting wrapper for an interface, so
+// large repository and service interfaces need no hand-written wrappers.
+//
+//	//go:generate go run github.com/talinashro/go-fi/cmd/fi-gen -type OrderRepository
+//
+// Each method of the wrapper injects the faults of its own key,
+// "<Interface>.<Method>", before calling the wrapped implementation:
+// latency with InjectLatency, then, for methods returning an error last,
+// failures with InjectWithContextError, returning zero values and the
+// injected error. Methods taking a context.Context first pass it on, so
+// selectors and tenants work. Methods without an error result only get
+// latency.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generatedHeader marks files written by fi-gen, which are skipped when the
+// package is loaded so a stale wrapper does not break generation.
+const generatedHeader = "// Code generated by fi-gen. DO NOT EDIT."
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "fi-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("fi-gen", flag.ContinueOnError)
+	typeName := fs.String("type", "", "interface to wrap")
+	dir := fs.String("dir", ".", "directory of the package declaring the interface")
+	out := fs.String("o", "", "output file, \"-\" for standard output (default <type>_fi.go in dir)")
+	name := fs.String("name", "", "wrapper type name (default Faulty<type>)")
+	prefix := fs.String("prefix", "", "key prefix, keys are <prefix>.<Method> (default <type>)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *typeName == "" {
+		return errors.New("-type is required")
+	}
+	if *name == "" {
+		*name = "Faulty" + *typeName
+	}
+	if *prefix == "" {
+		*prefix = *typeName
+	}
+
+	pkg, err := load(*dir)
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg, *typeName, *name, *prefix)
+	if err != nil {
+		return err
+	}
+
+	switch *out {
+	case "-":
+		_, err = stdout.Write(src)
+		return err
+	case "":
+		*out = filepath.Join(*dir, strings.ToLower(*typeName)+"_fi.go")
+	}
+	return os.WriteFile(*out, src, 0644)
+}
+
+// load type-checks the package in dir, leaving out tests and files fi-gen
+// generated.
+func load(dir string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() || !strings.HasSuffix(n, ".go") || strings.HasSuffix(n, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, n))
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(data, []byte(generatedHeader)) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, n, data, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var typeErr error
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error: func(err error) {
+			if typeErr == nil {
+				typeErr = err
+			}
+		},
+	}
+	pkg, _ := conf.Check(abs, fset, files, nil)
+	if pkg == nil {
+		return nil, typeErr
+	}
+	return pkg, nil
+}
+
+// generate returns the source of the wrapper named name for the interface
+// typeName of pkg.
+func generate(pkg *types.Package, typeName, name, prefix string) ([]byte, error) {
+	obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in package %s", typeName, pkg.Name())
+	}
+	iface, ok := obj.Type().Underlying().(*types.Interface)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an interface", typeName)
+	}
+	if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%s is generic, which is not supported", typeName)
+	}
+
+	g := &generator{pkg: pkg, imports: make(map[string]string)}
+	g.imports["github.com/talinashro/go-fi"] = "faultinject"
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "// %s implements %s, injecting the faults of the keys\n", name, typeName)
+	fmt.Fprintf(&body, "// %q before each call to Next.\n", prefix+".<Method>")
+	fmt.Fprintf(&body, "type %s struct {\n\tNext %s\n}\n\n", name, typeName)
+	fmt.Fprintf(&body, "// New%s returns next wrapped with fault injection.\n", name)
+	fmt.Fprintf(&body, "func New%s(next %s) *%s {\n\treturn &%s{Next: next}\n}\n", name, typeName, name, name)
+	for i := 0; i < iface.NumMethods(); i++ {
+		m := iface.Method(i)
+		if !m.Exported() && m.Pkg() != pkg {
+			return nil, fmt.Errorf("method %s of %s is unexported in another package", m.Name(), typeName)
+		}
+		body.WriteString("\n")
+		g.method(&body, name, prefix, m)
+	}
+
+	var src bytes.Buffer
+	src.WriteString(generatedHeader + "\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg.Name())
+	src.WriteString("import (\n")
+	var std, other []string
+	for p := range g.imports {
+		if strings.Contains(strings.Split(p, "/")[0], ".") {
+			other = append(other, p)
+		} else {
+			std = append(std, p)
+		}
+	}
+	for i, group := range [][]string{std, other} {
+		if i > 0 && len(std) > 0 {
+			src.WriteString("\n")
+		}
+		sort.Strings(group)
+		for _, p := range group {
+			if g.imports[p] == filepath.Base(p) {
+				fmt.Fprintf(&src, "\t%q\n", p)
+			} else {
+				fmt.Fprintf(&src, "\t%s %q\n", g.imports[p], p)
+			}
+		}
+	}
+	src.WriteString(")\n\n")
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string // import path -> name in the generated file
+}
+
+// qualifier names packages other than the generated one by their import
+// names, importing them under a new name if another package has theirs.
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	if n, ok := g.imports[p.Path()]; ok {
+		return n
+	}
+	n := p.Name()
+	for i := 2; g.nameTaken(n); i++ {
+		n = p.Name() + strconv.Itoa(i)
+	}
+	g.imports[p.Path()] = n
+	return n
+}
+
+func (g *generator) nameTaken(n string) bool {
+	for _, taken := range g.imports {
+		if taken == n {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// method writes the wrapper of m.
+func (g *generator) method(w io.Writer, name, prefix string, m *types.Func) {
+	sig := m.Type().(*types.Signature)
+	params, results := sig.Params(), sig.Results()
+
+	// the body refers to these packages
+	used := map[string]bool{"faultinject": true, "context": true}
+	names := make([]string, params.Len())
+	decl := make([]string, params.Len())
+	for i := 0; i < params.Len(); i++ {
+		p := params.At(i)
+		n := p.Name()
+		if n == "" || n == "_" || used[n] {
+			n = "p" + strconv.Itoa(i)
+		}
+		used[n] = true
+		names[i] = n
+		t := g.typeString(p.Type())
+		if sig.Variadic() && i == params.Len()-1 {
+			t = "..." + g.typeString(p.Type().(*types.Slice).Elem())
+			names[i] += "..."
+		}
+		decl[i] = n + " " + t
+	}
+	recv := "w"
+	for i := 0; used[recv]; i++ {
+		recv = "w" + strconv.Itoa(i)
+	}
+	var ctx string
+	if params.Len() > 0 && isContext(params.At(0).Type()) {
+		ctx = strings.TrimSuffix(names[0], "...")
+	} else {
+		ctx = "context.Background()"
+		g.imports["context"] = "context"
+	}
+
+	resultTypes := make([]string, results.Len())
+	for i := 0; i < results.Len(); i++ {
+		resultTypes[i] = g.typeString(results.At(i).Type())
+	}
+	resultDecl := strings.Join(resultTypes, ", ")
+	if results.Len() > 1 {
+		resultDecl = "(" + resultDecl + ")"
+	}
+	key := prefix + "." + m.Name()
+
+	fmt.Fprintf(w, "// %s injects the faults of %q, then calls Next.%s.\n", m.Name(), key, m.Name())
+	fmt.Fprintf(w, "func (%s *%s) %s(%s) %s {\n", recv, name, m.Name(), strings.Join(decl, ", "), resultDecl)
+	call := fmt.Sprintf("%s.Next.%s(%s)", recv, m.Name(), strings.Join(names, ", "))
+	returnsErr := results.Len() > 0 && isError(results.At(results.Len()-1).Type())
+	if returnsErr {
+		zeros := make([]string, 0, results.Len())
+		for i := 0; i < results.Len()-1; i++ {
+			zeros = append(zeros, zero(results.At(i).Type(), resultTypes[i]))
+		}
+		ret := strings.Join(append(zeros, "err"), ", ")
+		fmt.Fprintf(w, "\tif _, err := faultinject.InjectLatency(%s, %q); err != nil {\n\t\treturn %s\n\t}\n", ctx, key, ret)
+		fmt.Fprintf(w, "\tif err := faultinject.InjectWithContextError(%s, %q, %q); err != nil {\n\t\treturn %s\n\t}\n", ctx, key, key, ret)
+	} else {
+		fmt.Fprintf(w, "\tfaultinject.InjectLatency(%s, %q)\n", ctx, key)
+	}
+	if results.Len() > 0 {
+		fmt.Fprintf(w, "\treturn %s\n}\n", call)
+	} else {
+		fmt.Fprintf(w, "\t%s\n}\n", call)
+	}
+}
+
+func isContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// zero returns the zero value of t, written as typ.
+func zero(t types.Type, typ string) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil"
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil"
+	}
+	return typ + "{}"
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "repo_fi.go")
+	if err := run([]string{"-dir", "testdata/repo", "-type", "OrderRepository", "-o", out}, nil); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	src := string(data)
+
+	for _, want := range []string{
+		generatedHeader,
+		"type FaultyOrderRepository struct",
+		`func (w *FaultyOrderRepository) Get(ctx context.Context, id string) (*Order, error) {`,
+		`faultinject.InjectWithContextError(ctx, "OrderRepository.Get", "OrderRepository.Get")`,
+		`return Order{}, 0, false, err`,
+		`return w.Next.List(ctx, ids...)`,
+		`faultinject.InjectWithContextError(context.Background(), "OrderRepository.Touch"`,
+		`faultinject.InjectLatency(context.Background(), "OrderRepository.Count")`,
+		`func (w *FaultyOrderRepository) Close() error {`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", want, src)
+		}
+	}
+
+	// the wrapper must compile alongside the interface
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range []string{"testdata/repo/repo.go", out} {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", path, err)
+		}
+		files = append(files, f)
+	}
+	abs, _ := filepath.Abs("testdata/repo")
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check(abs, fset, files, nil)
+	if err != nil {
+		t.Fatalf("Expected the output to type-check, got %v", err)
+	}
+	wrapper := types.NewPointer(pkg.Scope().Lookup("FaultyOrderRepository").Type())
+	iface := pkg.Scope().Lookup("OrderRepository").Type().Underlying().(*types.Interface)
+	if !types.Implements(wrapper, iface) {
+		t.Errorf("Expected *FaultyOrderRepository to implement OrderRepository")
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	var out strings.Builder
+	if err := run([]string{"-dir", "testdata/repo", "-type", "OrderRepository", "-name", "ChaosRepo", "-prefix", "orders", "-o", "-"}, &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "func NewChaosRepo(next OrderRepository) *ChaosRepo") || !strings.Contains(out.String(), `"orders.Get"`) {
+		t.Errorf("Expected the name and prefix to be used, got:\n%s", out.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "missing type", args: []string{"-dir", "testdata/repo"}},
+		{name: "unknown type", args: []string{"-dir", "testdata/repo", "-type", "Missing", "-o", "-"}},
+		{name: "not an interface", args: []string{"-dir", "testdata/repo", "-type", "Order", "-o", "-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := run(tt.args, &strings.Builder{}); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package repo is an interface for fi-gen's tests.
+package repo
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type Order struct {
+	ID    string
+	Total int
+}
+
+type Status int
+
+// OrderRepository is wrapped by the tests.
+type OrderRepository interface {
+	io.Closer
+	Get(ctx context.Context, id string) (*Order, error)
+	List(ctx context.Context, ids ...string) ([]Order, int, error)
+	Latest(context.Context) (Order, Status, bool, error)
+	Touch(id string, at time.Time) error
+	Count() int
+	Forget(string)
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Command fi-incident converts the timeline of a past incident into a replay
+// scenario, so "can we survive last month's outage now" becomes a test.
+//
+//	fi-incident -window 30s -speed 10 -map payments=payments-call \
+//	    incident.json > scenario.yaml
+//
+// The input is either {"name": ..., "events": [...]} or JSON lines of events,
+// each with a "time", a "dependency" and an optional "errors" count.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/talinashro/go-fi/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "fi-incident: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("fi-incident", flag.ContinueOnError)
+	window := fs.Duration("window", 0, "merge errors on a dependency this close together into one burst")
+	speed := fs.Float64("speed", 1, "replay speed-up factor")
+	mapping := fs.String("map", "", "comma-separated dependency=key pairs")
+	name := fs.String("name", "", "scenario name (default: the incident name)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	keys := make(map[string]string)
+	if *mapping != "" {
+		for _, pair := range strings.Split(*mapping, ",") {
+			dep, key, ok := strings.Cut(pair, "=")
+			if !ok || dep == "" || key == "" {
+				return fmt.Errorf("invalid -map pair %q", pair)
+			}
+			keys[strings.TrimSpace(dep)] = strings.TrimSpace(key)
+		}
+	}
+
+	in := stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	inc, err := scenario.ReadIncident(in)
+	if err != nil {
+		return err
+	}
+	s, err := scenario.FromIncident(inc, scenario.ImportOptions{Keys: keys, Window: *window, Speed: *speed})
+	if err != nil {
+		return err
+	}
+	if *name != "" {
+		s.Name = *name
+	}
+
+	enc := yaml.NewEncoder(stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/talinashro/go-fi/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRun(t *testing.T) {
+	input := `{"time": "2026-09-12T14:00:00Z", "dependency": "payments", "errors": 3}
+{"time": "2026-09-12T14:01:00Z", "dependency": "payments", "errors": 2}
+`
+	var out strings.Builder
+	args := []string{"-speed", "60", "-map", "payments=payments-call", "-name", "replay"}
+	if err := run(args, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	var s scenario.Scenario
+	if err := yaml.Unmarshal([]byte(out.String()), &s); err != nil {
+		t.Fatalf("Output is not a valid scenario: %v\n%s", err, out.String())
+	}
+	if s.Name != "replay" {
+		t.Errorf("Expected name replay, got %q", s.Name)
+	}
+	if len(s.Steps) != 2 || s.Steps[1].At != time.Second || s.Steps[1].Failures["payments-call"] != 2 {
+		t.Errorf("Unexpected steps: %+v", s.Steps)
+	}
+}
+
+func TestRunInvalidMap(t *testing.T) {
+	if err := run([]string{"-map", "payments"}, strings.NewReader(""), &strings.Builder{}); err == nil {
+		t.Error("Expected error for invalid -map")
+	}
+}
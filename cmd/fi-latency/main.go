@@ -0,0 +1,111 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Command fi-latency generates latency fault rules from a recorded
+// Prometheus histogram, so injected slowness reproduces a real incident.
+//
+//	fi-latency -metric http_client_request_duration_seconds \
+//	    -match 'service=payments' -key payments-call \
+//	    -baseline before.prom during.prom > latency.yaml
+//
+// The output is a spec fragment with a "latencies" section that LoadSpec accepts.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "fi-latency: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("fi-latency", flag.ContinueOnError)
+	metric := fs.String("metric", "", "histogram metric name, without the _bucket suffix")
+	match := fs.String("match", "", "comma-separated label=value pairs the series must carry")
+	key := fs.String("key", "", "fault key to generate the rule for")
+	baseline := fs.String("baseline", "", "earlier scrape to subtract, isolating the incident window")
+	infCap := fs.Duration("inf-cap", 0, "upper delay for observations in the +Inf bucket (default: twice the largest bound)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *metric == "" || *key == "" {
+		return fmt.Errorf("-metric and -key are required")
+	}
+
+	labels, err := parseMatch(*match)
+	if err != nil {
+		return err
+	}
+
+	in := stdin
+	if fs.NArg() > 0 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	counts, err := readHistogram(in, *metric, labels)
+	if err != nil {
+		return err
+	}
+
+	var base map[float64]float64
+	if *baseline != "" {
+		f, err := os.Open(*baseline)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if base, err = readHistogram(f, *metric, labels); err != nil {
+			return fmt.Errorf("baseline: %w", err)
+		}
+	}
+
+	dist, err := faultinject.LatencyFromHistogram(buckets(counts, base), *infCap)
+	if err != nil {
+		return err
+	}
+	for i := range dist {
+		dist[i].Min = dist[i].Min.Round(time.Microsecond)
+		dist[i].Max = dist[i].Max.Round(time.Microsecond)
+	}
+
+	out := faultinject.Spec{Latencies: map[string]faultinject.LatencyDistribution{*key: dist}}
+	enc := yaml.NewEncoder(stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func parseMatch(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	if s == "" {
+		return out, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid -match pair %q", pair)
+		}
+		out[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"`)
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+//go:build faultinject
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	faultinject "github.com/talinashro/go-fi"
+	"gopkg.in/yaml.v3"
+)
+
+const during = `# TYPE rpc_duration_seconds histogram
+rpc_duration_seconds_bucket{service="payments",instance="a",le="0.05"} 40
+rpc_duration_seconds_bucket{service="payments",instance="a",le="0.5"} 90
+rpc_duration_seconds_bucket{service="payments",instance="a",le="+Inf"} 100
+rpc_duration_seconds_bucket{service="payments",instance="b",le="0.05"} 20
+rpc_duration_seconds_bucket{service="payments",instance="b",le="0.5"} 30
+rpc_duration_seconds_bucket{service="payments",instance="b",le="+Inf"} 40
+rpc_duration_seconds_bucket{service="search",le="0.05"} 1000
+rpc_duration_seconds_count{service="payments"} 140
+`
+
+const before = `rpc_duration_seconds_bucket{service="payments",le="0.05"} 40
+rpc_duration_seconds_bucket{service="payments",le="0.5"} 40
+rpc_duration_seconds_bucket{service="payments",le="+Inf"} 40
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "before.prom")
+	if err := os.WriteFile(base, []byte(before), 0644); err != nil {
+		t.Fatalf("Failed to write baseline: %v", err)
+	}
+
+	var out strings.Builder
+	args := []string{"-metric", "rpc_duration_seconds", "-match", `service="payments"`, "-key", "pay", "-baseline", base, "-inf-cap", "2s"}
+	if err := run(args, strings.NewReader(during), &out); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	var spec faultinject.Spec
+	if err := yaml.Unmarshal([]byte(out.String()), &spec); err != nil {
+		t.Fatalf("Output is not a valid spec: %v\n%s", err, out.String())
+	}
+	dist := spec.Latencies["pay"]
+	// 100 observations remain after the baseline: 20 <= 50ms, 60 in (50ms, 500ms], 20 above.
+	weights := []float64{0.2, 0.6, 0.2}
+	if len(dist) != len(weights) {
+		t.Fatalf("Expected %d buckets, got %+v", len(weights), dist)
+	}
+	for i, w := range weights {
+		if diff := dist[i].Weight - w; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("Bucket %d: expected weight %v, got %v", i, w, dist[i].Weight)
+		}
+	}
+	if dist[2].Max.String() != "2s" {
+		t.Errorf("Expected +Inf bucket capped at 2s, got %v", dist[2].Max)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+	}{
+		{name: "missing flags", args: nil},
+		{name: "unknown metric", args: []string{"-metric", "nope", "-key", "k"}, input: during},
+		{name: "bad match", args: []string{"-metric", "rpc_duration_seconds", "-key", "k", "-match", "service"}, input: during},
+		{name: "malformed sample", args: []string{"-metric", "rpc_duration_seconds", "-key", "k"}, input: `rpc_duration_seconds_bucket{le="0.1" 3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := run(tt.args, strings.NewReader(tt.input), &out); err == nil {
+				t.Errorf("Expected error, got output %q", out.String())
+			}
+		})
+	}
+}
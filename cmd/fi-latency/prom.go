@@ -0,0 +1,150 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// readHistogram sums the <metric>_bucket series in Prometheus text exposition
+// format whose labels include every pair in match.
+func readHistogram(r io.Reader, metric string, match map[string]string) (map[float64]float64, error) {
+	want := metric + "_bucket"
+	counts := make(map[float64]float64)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, labels, value, err := parseSample(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if name != want || !matches(labels, match) {
+			continue
+		}
+		le, ok := labels["le"]
+		if !ok {
+			return nil, fmt.Errorf("line %d: bucket without le label", line)
+		}
+		bound, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid le %q", line, le)
+		}
+		counts[bound] += value
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("no %s series found", want)
+	}
+	return counts, nil
+}
+
+// buckets converts summed counts, minus an optional baseline scrape, into
+// sorted cumulative histogram buckets.
+func buckets(counts, baseline map[float64]float64) []faultinject.HistogramBucket {
+	out := make([]faultinject.HistogramBucket, 0, len(counts))
+	for bound, n := range counts {
+		out = append(out, faultinject.HistogramBucket{UpperBound: bound, Count: math.Max(0, n-baseline[bound])})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpperBound < out[j].UpperBound })
+	return out
+}
+
+// parseSample splits `name{a="b",...} value [timestamp]`.
+func parseSample(line string) (string, map[string]string, float64, error) {
+	labels := make(map[string]string)
+	var name, rest string
+	if i := strings.IndexByte(line, '{'); i >= 0 {
+		name = line[:i]
+		j, err := parseLabels(line[i+1:], labels)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		rest = line[i+1+j:]
+	} else {
+		f := strings.Fields(line)
+		if len(f) < 2 {
+			return "", nil, 0, fmt.Errorf("malformed sample %q", line)
+		}
+		name, rest = f[0], strings.Join(f[1:], " ")
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("missing value in %q", line)
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("invalid value %q", fields[0])
+	}
+	return strings.TrimSpace(name), labels, v, nil
+}
+
+// parseLabels reads `a="b",c="d"}` into labels and returns the index just past the closing brace.
+func parseLabels(s string, labels map[string]string) (int, error) {
+	i := 0
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated label set")
+		}
+		if s[i] == '}' {
+			return i + 1, nil
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 || i+eq+1 >= len(s) || s[i+eq+1] != '"' {
+			return 0, fmt.Errorf("malformed label set")
+		}
+		key := strings.TrimSpace(s[i : i+eq])
+		i += eq + 2
+
+		var b strings.Builder
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+				continue
+			}
+			b.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated label value")
+		}
+		labels[key] = b.String()
+		i++
+	}
+}
+
+func matches(labels, match map[string]string) bool {
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
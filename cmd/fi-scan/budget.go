@@ -0,0 +1,83 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/talinashro/go-fi/scan"
+)
+
+// budget limits how many injection points a module may carry. Zero disables a limit.
+type budget struct {
+	maxPoints     int
+	maxKeys       int
+	maxPerPackage int
+}
+
+func runBudget(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("budget", flag.ContinueOnError)
+	var b budget
+	fs.IntVar(&b.maxPoints, "max-points", 0, "maximum injection points in total (0: unlimited)")
+	fs.IntVar(&b.maxKeys, "max-keys", 0, "maximum distinct fault keys (0: unlimited)")
+	fs.IntVar(&b.maxPerPackage, "max-per-package", 0, "maximum injection points in any one package (0: unlimited)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	points, err := scan.Dir(dirArg(fs))
+	if err != nil {
+		return err
+	}
+	violations := b.check(points)
+	for _, v := range violations {
+		fmt.Fprintln(stdout, v)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("injection point budget exceeded (%d violations)", len(violations))
+	}
+	return nil
+}
+
+// check returns one message per exceeded limit. Calls in tests and calls
+// that only configure faults do not count.
+func (b budget) check(points []scan.Point) []string {
+	total := 0
+	keys := make(map[string]bool)
+	perPkg := make(map[string]int)
+	for _, p := range points {
+		if p.Test || p.Kind != scan.KindInject {
+			continue
+		}
+		total++
+		keys[p.Key] = true
+		perPkg[p.Package]++
+	}
+
+	var out []string
+	if b.maxPoints > 0 && total > b.maxPoints {
+		out = append(out, fmt.Sprintf("%d injection points, budget is %d", total, b.maxPoints))
+	}
+	if b.maxKeys > 0 && len(keys) > b.maxKeys {
+		out = append(out, fmt.Sprintf("%d distinct keys, budget is %d", len(keys), b.maxKeys))
+	}
+	if b.maxPerPackage > 0 {
+		pkgs := make([]string, 0, len(perPkg))
+		for pkg := range perPkg {
+			pkgs = append(pkgs, pkg)
+		}
+		sort.Strings(pkgs)
+		for _, pkg := range pkgs {
+			if n := perPkg[pkg]; n > b.maxPerPackage {
+				out = append(out, fmt.Sprintf("package %s: %d injection points, budget is %d", pkg, n, b.maxPerPackage))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+//go:build faultinject
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunBudget(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expectedOut string
+	}{
+		{name: "no limits", args: nil},
+		{name: "within limits", args: []string{"-max-points", "5", "-max-keys", "5", "-max-per-package", "2"}},
+		{name: "too many points", args: []string{"-max-points", "3"}, expectError: true, expectedOut: "5 injection points, budget is 3"},
+		{name: "too many keys", args: []string{"-max-keys", "2"}, expectError: true, expectedOut: "5 distinct keys, budget is 2"},
+		{
+			name:        "dense package",
+			args:        []string{"-max-per-package", "1"},
+			expectError: true,
+			expectedOut: "package .: 2 injection points, budget is 1\npackage store: 2 injection points, budget is 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			args := append(tt.args, "../../scan/testdata/checkout")
+			err := runBudget(args, &out)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("Expected error=%v, got %v", tt.expectError, err)
+			}
+			if got := strings.TrimSpace(out.String()); got != tt.expectedOut {
+				t.Errorf("Expected output %q, got %q", tt.expectedOut, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	faultinject "github.com/talinashro/go-fi"
+	"github.com/talinashro/go-fi/scan"
+	"gopkg.in/yaml.v3"
+)
+
+// simulates describes the failure of injection points that carry no error message.
+var simulates = map[string]string{
+	"Inject":                     "caller-defined failure branch",
+	"InjectWithContext":          "caller-defined failure branch",
+	"InjectWithFn":               "caller-supplied failure function",
+	"InjectWithFnContext":        "caller-supplied failure function",
+	"HTTPMiddleware":             "HTTP 500 response",
+	"HTTPMiddlewareWithResponse": "custom HTTP response",
+	"HTTPMiddlewareWithStatus":   "HTTP error response with a default status",
+	"SetRoute":                   "HTTP error response for routed requests",
+	"WithFaultInjection":         "decorated call returns an error",
+	"WithFaultInjectionContext":  "decorated call returns an error",
+	"InjectLatency":              "added latency",
+	"InjectContextCancel":        "context cancelled or its deadline shortened",
+	"UnaryServerInterceptor":     "gRPC call fails with a status code",
+	"StreamServerInterceptor":    "gRPC stream fails with a status code",
+	"UnaryClientInterceptor":     "outgoing gRPC call fails with a status code",
+	"StreamClientInterceptor":    "outgoing gRPC stream fails with a status code",
+	"Cleanup":                    "cleanup is delayed or fails",
+	"DrainMiddleware":            "in-flight request dropped during drain",
+	"SignalContext":              "shutdown signal ignored",
+	"HealthHandler":              "health check reports unhealthy",
+	"Now":                        "skewed clock",
+	"NowWithContext":             "skewed clock",
+	"SkewedClock":                "skewed clock",
+	"CorruptBytes":               "payload encoding corrupted",
+	"CorruptString":              "payload encoding corrupted",
+	"CorruptResponse":            "response body encoding corrupted",
+	"PayloadTransport":           "large transfer fails",
+	"SSEMiddleware":              "event stream dropped, stalled or sent a malformed event",
+	"WrapConn":                   "connection reset, timed out, throttled or closed mid-stream",
+	"WrapListener":               "accepted connections reset, timed out, throttled or closed mid-stream",
+	"WrapDialer":                 "dialed connections reset, timed out, throttled or closed mid-stream",
+	"InjectValue":                "boundary value substituted",
+	"Degrade":                    "degraded fallback result returned",
+	"InjectMemoryPressure":       "heap grows and garbage collection runs more often",
+	"InjectCPUBurn":              "CPU starvation",
+	"Paginate":                   "pagination cursor broken",
+	"IdempotencyTransport":       "request applied but reported failed, or sent twice",
+	"IdempotencyMiddleware":      "request applied but reported failed, or handled twice",
+	"QuotaTransport":             "upstream responds quota exceeded or payment required",
+	"Wrap":                       "database/sql connection, query or transaction fails",
+	"WrapDriver":                 "database/sql connection, query or transaction fails",
+	"WrapConnector":              "database/sql connection, query or transaction fails",
+	"NewConsumer":                "messages rejected, dropped, delayed, duplicated or delivered out of order",
+	"WrapConsumer":               "messages rejected, dropped, delayed or duplicated",
+	"NewMutex":                   "distributed lock not acquired, lost or held twice",
+	"NewCache":                   "cache timeouts, misses, stale reads or poisoned negative entries",
+	"Middleware":                 "HTTP error response, or AWS call throttled, failed or slowed",
+}
+
+func runKeys(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("keys", flag.ContinueOnError)
+	tests := fs.Bool("tests", false, "include calls from _test.go files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	points, err := scan.Dir(dirArg(fs))
+	if err != nil {
+		return err
+	}
+	for _, p := range points {
+		if p.Test && !*tests {
+			continue
+		}
+		fmt.Fprintf(stdout, "%s\t%s:%d\t%s\n", p.Key, p.File, p.Line, p.Func)
+	}
+	return nil
+}
+
+func runDocs(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+	specs := fs.String("spec", "", "comma-separated fault spec files to include")
+	service := fs.String("service", "", "service name for the document title (default: directory name)")
+	out := fs.String("o", "", "write the document to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dir := dirArg(fs)
+	points, err := scan.Dir(dir)
+	if err != nil {
+		return err
+	}
+
+	var spec faultinject.Spec
+	if *specs != "" {
+		for _, path := range strings.Split(*specs, ",") {
+			if err := mergeSpec(&spec, strings.TrimSpace(path)); err != nil {
+				return err
+			}
+		}
+	}
+
+	name := *service
+	if name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		name = filepath.Base(abs)
+	}
+
+	w, closeFn, err := output(*out, stdout)
+	if err != nil {
+		return err
+	}
+	renderDocs(w, name, points, spec)
+	return closeFn()
+}
+
+func dirArg(fs *flag.FlagSet) string {
+	if fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+	return "."
+}
+
+func mergeSpec(dst *faultinject.Spec, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var s faultinject.Spec
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if dst.Failures == nil {
+		dst.Failures = make(map[string]int)
+	}
+	if dst.PreciseFailures == nil {
+		dst.PreciseFailures = make(map[string]int)
+	}
+	for k, v := range s.Failures {
+		dst.Failures[k] = v
+	}
+	for k, v := range s.PreciseFailures {
+		dst.PreciseFailures[k] = v
+	}
+	return nil
+}
+
+// renderDocs writes a Markdown failure-modes document grouped by package.
+func renderDocs(w io.Writer, service string, points []scan.Point, spec faultinject.Spec) {
+	coverage := make(map[string][]string)
+	byPkg := make(map[string][]scan.Point)
+	instrumented := make(map[string]bool)
+	total := 0
+	for _, p := range points {
+		if p.Test {
+			ref := p.Caller
+			if p.Package != "." {
+				ref = p.Package + "." + ref
+			}
+			coverage[p.Key] = appendUnique(coverage[p.Key], ref)
+			continue
+		}
+		if p.Kind == scan.KindInject {
+			byPkg[p.Package] = append(byPkg[p.Package], p)
+			instrumented[p.Key] = true
+			total++
+		}
+	}
+
+	fmt.Fprintf(w, "# Failure modes: %s\n\n", service)
+	fmt.Fprintf(w, "Generated by fi-scan from %d injection points. Do not edit by hand.\n", total)
+
+	pkgs := make([]string, 0, len(byPkg))
+	for p := range byPkg {
+		pkgs = append(pkgs, p)
+	}
+	sort.Strings(pkgs)
+
+	for _, pkg := range pkgs {
+		fmt.Fprintf(w, "\n## Package `%s`\n\n", pkg)
+		fmt.Fprintln(w, "| Key | Location | Simulates | Spec | Tests |")
+		fmt.Fprintln(w, "|-----|----------|-----------|------|-------|")
+		for _, p := range byPkg[pkg] {
+			loc := fmt.Sprintf("%s:%d", p.File, p.Line)
+			if p.Caller != "" {
+				loc += " (" + p.Caller + ")"
+			}
+			tests := "none"
+			if refs := coverage[p.Key]; len(refs) > 0 {
+				tests = strings.Join(refs, ", ")
+			}
+			fmt.Fprintf(w, "| `%s` | %s | %s | %s | %s |\n", p.Key, loc, describe(p), specRule(spec, p.Key), tests)
+		}
+	}
+
+	var orphans []string
+	for k := range spec.Failures {
+		if !matchesAny(k, instrumented) {
+			orphans = appendUnique(orphans, k)
+		}
+	}
+	for k := range spec.PreciseFailures {
+		if !matchesAny(k, instrumented) {
+			orphans = appendUnique(orphans, k)
+		}
+	}
+	if len(orphans) > 0 {
+		sort.Strings(orphans)
+		fmt.Fprintln(w, "\n## Spec rules without injection points")
+		fmt.Fprintln(w)
+		for _, k := range orphans {
+			fmt.Fprintf(w, "- `%s`: %s\n", k, specRule(spec, k))
+		}
+	}
+}
+
+func describe(p scan.Point) string {
+	if p.Message != "" {
+		return "error: " + p.Message
+	}
+	if s, ok := simulates[p.Func]; ok {
+		return s
+	}
+	return "injected failure"
+}
+
+func specRule(spec faultinject.Spec, key string) string {
+	if n, ok := spec.PreciseFailures[key]; ok {
+		return fmt.Sprintf("fails call #%d", n)
+	}
+	if n, ok := spec.Failures[key]; ok {
+		return fmt.Sprintf("fails first %d calls", n)
+	}
+	// otherwise the longest matching pattern applies, as in Inject
+	var best string
+	for _, rules := range []map[string]int{spec.PreciseFailures, spec.Failures} {
+		for k := range rules {
+			if k != key && faultinject.MatchKey(k, key) && (len(k) > len(best) || len(k) == len(best) && k < best) {
+				best = k
+			}
+		}
+	}
+	if best != "" {
+		return specRule(spec, best) + " (via `" + best + "`)"
+	}
+	return "not configured"
+}
+
+// matchesAny reports whether the rule key applies to any of keys.
+func matchesAny(ruleKey string, keys map[string]bool) bool {
+	for k := range keys {
+		if faultinject.MatchKey(ruleKey, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
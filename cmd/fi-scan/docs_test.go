@@ -0,0 +1,73 @@
+//go:build faultinject
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDocs(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "faults.yaml")
+	content := `failures:
+  payment-charge: 3
+  stale-key: 1
+  "rpc-*": 1
+  "^unused-.*": 4
+precise-failures:
+  store-save: 2`
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create spec: %v", err)
+	}
+
+	var out strings.Builder
+	err := runDocs([]string{"-spec", spec, "-service", "checkout", "../../scan/testdata/checkout"}, &out)
+	if err != nil {
+		t.Fatalf("runDocs returned error: %v", err)
+	}
+
+	doc := out.String()
+	expected := []string{
+		"# Failure modes: checkout",
+		"from 5 injection points",
+		"| `rpc-charge` | rpc/server.go:9 (NewServer) | gRPC call fails with a status code | fails first 1 calls (via `rpc-*`) | none |",
+		"## Package `store`",
+		"| `payment-charge` | main.go:13 (charge) | caller-defined failure branch | fails first 3 calls | TestChargeFails |",
+		"| `payment-refund` | main.go:16 (charge) | error: card declined | not configured | none |",
+		"| `store-save` | store/store.go:8 (Store.Save) | error: disk full | fails call #2 | none |",
+		"- `stale-key`: fails first 1 calls",
+		"- `^unused-.*`: fails first 4 calls",
+	}
+	for _, e := range expected {
+		if !strings.Contains(doc, e) {
+			t.Errorf("Expected document to contain %q\n%s", e, doc)
+		}
+	}
+	if strings.Contains(doc, "- `rpc-*`") {
+		t.Errorf("Expected a pattern matching an injection point not to be listed as stale\n%s", doc)
+	}
+}
+
+func TestRunDocsMissingSpec(t *testing.T) {
+	var out strings.Builder
+	if err := runDocs([]string{"-spec", "missing.yaml", "../../scan/testdata/checkout"}, &out); err == nil {
+		t.Error("Expected error for missing spec file")
+	}
+}
+
+func TestRunKeys(t *testing.T) {
+	var out strings.Builder
+	if err := runKeys([]string{"../../scan/testdata/checkout"}, &out); err != nil {
+		t.Fatalf("runKeys returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 injection points, got %d:\n%s", len(lines), out.String())
+	}
+	if lines[0] != "payment-charge\tmain.go:13\tInjectWithContext" {
+		t.Errorf("Unexpected first line %q", lines[0])
+	}
+}
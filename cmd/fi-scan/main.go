@@ -0,0 +1,63 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Command fi-scan inspects Go source for go-fi injection points.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: fi-scan <command> [flags] [dir]
+
+Commands:
+  keys    list every injection point found under dir
+  docs    render a failure-modes document from injection points, spec rules and tests
+  budget  fail when injection points exceed configured limits
+
+Run 'fi-scan <command> -h' for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keys":
+		err = runKeys(os.Args[2:], os.Stdout)
+	case "docs":
+		err = runDocs(os.Args[2:], os.Stdout)
+	case "budget":
+		err = runBudget(os.Args[2:], os.Stdout)
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "fi-scan: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fi-scan: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// output returns w, or a newly created file when path is set.
+func output(path string, w io.Writer) (io.Writer, func() error, error) {
+	if path == "" {
+		return w, func() error { return nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/talinashro/go-fi/client"
+)
+
+func newSetCmd(s *servers) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set KEY COUNT",
+		Short: "Fail the next COUNT calls to KEY",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid count %q", args[1])
+			}
+			clients := s.clients()
+			err = fanOut(clients, func(_ int, c *client.Client) error {
+				return c.Set(args[0], n)
+			})
+			if err == nil {
+				cmd.Printf("%s set to %d on %d server(s)\n", args[0], n, len(clients))
+			}
+			return err
+		},
+	}
+}
+
+func newStatusCmd(s *servers) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status [KEY...]",
+		Short: "Show the remaining failures of every key, or of the given keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clients := s.clients()
+			states := make([]map[string]int, len(clients))
+			err := fanOut(clients, func(i int, c *client.Client) error {
+				st, _, err := c.StatusWithGeneration(args...)
+				states[i] = st
+				return err
+			})
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			if len(clients) > 1 {
+				fmt.Fprint(tw, "SERVER\t")
+			}
+			fmt.Fprintln(tw, "KEY\tREMAINING")
+			for i, c := range clients {
+				for _, k := range sortedKeys(states[i]) {
+					if len(clients) > 1 {
+						fmt.Fprintf(tw, "%s\t", c.Base)
+					}
+					fmt.Fprintf(tw, "%s\t%d\n", k, states[i][k])
+				}
+			}
+			tw.Flush()
+			return err
+		},
+	}
+}
+
+func newResetCmd(s *servers) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset",
+		Short: "Clear every fault",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clients := s.clients()
+			err := fanOut(clients, func(_ int, c *client.Client) error {
+				return c.Reset()
+			})
+			if err == nil {
+				cmd.Printf("reset %d server(s)\n", len(clients))
+			}
+			return err
+		},
+	}
+}
+
+func newWatchCmd(s *servers) *cobra.Command {
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "watch [KEY...]",
+		Short: "Print changes to every key, or to the given keys, until interrupted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			clients := s.clients()
+			var mu sync.Mutex
+			logf := func(c *client.Client, format string, args ...any) {
+				mu.Lock()
+				defer mu.Unlock()
+				cmd.Printf("%s  %s%s\n", time.Now().Format("15:04:05"), serverPrefix(clients, c), fmt.Sprintf(format, args...))
+			}
+			return fanOut(clients, func(_ int, c *client.Client) error {
+				st, gen, err := c.StatusWithGeneration(args...)
+				if err != nil {
+					return err
+				}
+				for _, e := range diffStatus(nil, st) {
+					logf(c, "%s", e)
+				}
+				for {
+					next, g, err := c.WaitForChange(ctx, gen, timeout)
+					if ctx.Err() != nil {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+					if g == gen {
+						continue
+					}
+					next = onlyKeys(next, args)
+					for _, e := range diffStatus(st, next) {
+						logf(c, "%s", e)
+					}
+					st, gen = next, g
+				}
+			})
+		},
+	}
+	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Second, "how long each long poll waits for a change")
+	return cmd
+}
+
+// diffStatus describes how the remaining failures changed from old to st, by
+// key.
+func diffStatus(old, st map[string]int) []string {
+	var events []string
+	for _, k := range sortedKeys(st) {
+		o, ok := old[k]
+		switch {
+		case !ok:
+			events = append(events, fmt.Sprintf("%s armed (%d remaining)", k, st[k]))
+		case o != st[k]:
+			events = append(events, fmt.Sprintf("%s %d -> %d", k, o, st[k]))
+		}
+	}
+	for _, k := range sortedKeys(old) {
+		if _, ok := st[k]; !ok {
+			events = append(events, k+" cleared")
+		}
+	}
+	return events
+}
+
+// onlyKeys returns the entries of st for keys, or st when keys is empty.
+func onlyKeys(st map[string]int, keys []string) map[string]int {
+	if len(keys) == 0 {
+		return st
+	}
+	out := make(map[string]int)
+	for _, k := range keys {
+		if v, ok := st[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+//go:build faultinject
+
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// newControlServers starts n control servers with injectors of their own.
+func newControlServers(t *testing.T, n int) ([]*faultinject.Injector, []string) {
+	t.Setenv("ENVIRONMENT", "development")
+	var injectors []*faultinject.Injector
+	var args []string
+	for i := 0; i < n; i++ {
+		in := faultinject.NewInjector()
+		srv := httptest.NewServer(in.ControlHandler(nil))
+		t.Cleanup(srv.Close)
+		injectors = append(injectors, in)
+		args = append(args, "--addr", srv.URL)
+	}
+	return injectors, args
+}
+
+func TestSetStatusReset(t *testing.T) {
+	injectors, addrs := newControlServers(t, 2)
+
+	var out syncBuffer
+	if err := execute(context.Background(), &out, append(addrs, "set", "db-connect", "3")...); err != nil {
+		t.Fatalf("set returned error: %v", err)
+	}
+	for i, in := range injectors {
+		if got := in.Status()["db-connect"]; got != 3 {
+			t.Errorf("Expected 3 failures on server %d, got %d", i, got)
+		}
+	}
+
+	out = syncBuffer{}
+	if err := execute(context.Background(), &out, append(addrs, "status")...); err != nil {
+		t.Fatalf("status returned error: %v", err)
+	}
+	if got := strings.Count(out.String(), "db-connect"); got != 2 {
+		t.Errorf("Expected db-connect listed for both servers, got %q", out.String())
+	}
+	if !strings.HasPrefix(out.String(), "SERVER") {
+		t.Errorf("Expected a SERVER column for several servers, got %q", out.String())
+	}
+
+	if err := execute(context.Background(), &out, append(addrs, "reset")...); err != nil {
+		t.Fatalf("reset returned error: %v", err)
+	}
+	for i, in := range injectors {
+		if st := in.Status(); len(st) != 0 {
+			t.Errorf("Expected server %d to be reset, got %v", i, st)
+		}
+	}
+}
+
+func TestStatusSingleServer(t *testing.T) {
+	injectors, addrs := newControlServers(t, 1)
+	injectors[0].SetFailures("db-connect", 2)
+	injectors[0].SetFailures("api-call", 1)
+
+	var out syncBuffer
+	if err := execute(context.Background(), &out, append(addrs, "status", "api-call")...); err != nil {
+		t.Fatalf("status returned error: %v", err)
+	}
+	want := "KEY       REMAINING\napi-call  1\n"
+	if out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	_, addrs := newControlServers(t, 1)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing count", []string{"set", "db-connect"}},
+		{"invalid count", []string{"set", "db-connect", "many"}},
+		{"negative count", []string{"set", "db-connect", "-1"}},
+		{"unreachable server", []string{"--addr", "127.0.0.1:1", "set", "db-connect", "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out syncBuffer
+			if err := execute(context.Background(), &out, append(addrs, tt.args...)...); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	injectors, addrs := newControlServers(t, 1)
+	injectors[0].SetFailures("db-connect", 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- execute(ctx, &out, append(addrs, "watch", "--timeout", "50ms")...)
+	}()
+
+	waitFor := func(s string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), s) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected output to contain %q, got %q", s, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitFor("db-connect armed (2 remaining)")
+	injectors[0].SetFailures("db-connect", 1)
+	waitFor("db-connect 2 -> 1")
+	injectors[0].Reset()
+	waitFor("db-connect cleared")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected watch to stop cleanly, got %v", err)
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	got := diffStatus(map[string]int{"a": 1, "b": 2}, map[string]int{"b": 1, "c": 3})
+	want := []string{"b 2 -> 1", "c armed (3 remaining)", "a cleared"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Command fictl manages go-fi control servers from the terminal. Every
+// command but tui acts on all the servers given with --addr at once:
+//
+//	fictl --addr fi.eu:8081 --addr fi.us:8081 set db-connect 3
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/talinashro/go-fi/client"
+)
+
+func main() {
+	if err := newRootCmd(os.Stdin, os.Stdout).Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "fictl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// servers holds the --addr flag shared by every command.
+type servers struct {
+	addrs []string
+}
+
+func (s *servers) clients() []*client.Client {
+	out := make([]*client.Client, len(s.addrs))
+	for i, addr := range s.addrs {
+		out[i] = client.New(addr)
+	}
+	return out
+}
+
+func newRootCmd(in io.Reader, out io.Writer) *cobra.Command {
+	s := &servers{}
+	root := &cobra.Command{
+		Use:           "fictl",
+		Short:         "Manage go-fi control servers",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.SetIn(in)
+	root.SetOut(out)
+	root.PersistentFlags().StringSliceVar(&s.addrs, "addr", []string{"localhost:8081"}, "control server address, repeated or comma-separated for several servers")
+	root.AddCommand(
+		newSetCmd(s),
+		newStatusCmd(s),
+		newResetCmd(s),
+		newWatchCmd(s),
+		newRunCmd(s),
+		newTUICmd(s),
+	)
+	return root
+}
+
+// fanOut calls fn for every client at once and joins the errors, each
+// prefixed with its server.
+func fanOut(clients []*client.Client, fn func(i int, c *client.Client) error) error {
+	errs := make([]error, len(clients))
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(i, c); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", c.Base, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// serverPrefix names c in output about several servers, and is empty for a
+// single one.
+func serverPrefix(clients []*client.Client, c *client.Client) string {
+	if len(clients) == 1 {
+		return ""
+	}
+	return c.Base + "  "
+}
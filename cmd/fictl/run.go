@@ -0,0 +1,42 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+	"github.com/talinashro/go-fi/client"
+	"github.com/talinashro/go-fi/scenario"
+)
+
+// newRunCmd replays a YAML scenario against every server at once, stopping
+// early on interrupt.
+func newRunCmd(s *servers) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run SCENARIO",
+		Short: "Replay a timed YAML scenario",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sc, err := scenario.Load(args[0])
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			clients := s.clients()
+			cmd.Printf("running %d steps against %d server(s)\n", len(sc.Steps), len(clients))
+			err = fanOut(clients, func(_ int, c *client.Client) error {
+				return scenario.Run(ctx, sc, c)
+			})
+			if err != nil {
+				return err
+			}
+			cmd.Println("done")
+			return nil
+		},
+	}
+}
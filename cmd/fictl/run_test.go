@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer safe to read while a command writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func execute(ctx context.Context, out *syncBuffer, args ...string) error {
+	cmd := newRootCmd(strings.NewReader(""), out)
+	cmd.SetArgs(args)
+	return cmd.ExecuteContext(ctx)
+}
+
+func TestRunScenario(t *testing.T) {
+	f, srv := newFakeServer(t)
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	content := "steps:\n  - at: 0s\n    failures: {db-connect: 3}\n  - at: 10ms\n    reset: true\n    failures: {api-call: 1}\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write scenario: %v", err)
+	}
+
+	var out syncBuffer
+	if err := execute(context.Background(), &out, "--addr", srv.URL, "run", path); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if _, ok := f.get("db-connect"); ok {
+		t.Error("Expected db-connect to be reset")
+	}
+	if got, _ := f.get("api-call"); got != 1 {
+		t.Errorf("Expected 1 api-call failure, got %d", got)
+	}
+}
+
+func TestRunScenarioUsage(t *testing.T) {
+	var out syncBuffer
+	if err := execute(context.Background(), &out, "run"); err == nil {
+		t.Error("Expected a usage error without a scenario file")
+	}
+}
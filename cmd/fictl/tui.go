@@ -0,0 +1,371 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"github.com/talinashro/go-fi/client"
+	"gopkg.in/yaml.v3"
+)
+
+const maxEvents = 10
+
+const tuiHelp = `↑/↓ select   space toggle   +/- bump   s set   n new key   1-9 scenario   r reset   q quit`
+
+// scenarioFile is the format accepted by -scenarios: named sets of first-N failures.
+type scenarioFile struct {
+	Scenarios map[string]map[string]int `yaml:"scenarios"`
+}
+
+// tui is a keyboard-driven terminal view of one control server. Changes are
+// sent to the server in the background and the status is refreshed every
+// interval, so the screen stays live while nothing is pressed.
+type tui struct {
+	c         *client.Client
+	interval  time.Duration
+	scenarios map[string]map[string]int
+	names     []string // scenario names, in the order they are numbered
+
+	state  map[string]int
+	keys   []string
+	cursor int
+	saved  map[string]int // counts remembered by toggle
+	events []string
+	err    error
+	synced time.Time
+
+	editing bool   // a "<key> <count>" line is being typed
+	input   string // the line typed so far
+}
+
+// statusMsg carries the status read from the server.
+type statusMsg struct {
+	state map[string]int
+	err   error
+}
+
+// doneMsg reports a change sent to the server, with the event to log if it
+// succeeded.
+type doneMsg struct {
+	event string
+	err   error
+}
+
+// tickMsg asks for the status to be refreshed.
+type tickMsg time.Time
+
+func newTUICmd(s *servers) *cobra.Command {
+	var (
+		interval  time.Duration
+		scenarios string
+	)
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Interactive terminal UI for a single control server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(s.addrs) != 1 {
+				return errors.New("tui takes a single --addr")
+			}
+			return runTUI(client.New(s.addrs[0]), interval, scenarios, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "status refresh interval")
+	cmd.Flags().StringVar(&scenarios, "scenarios", "", "YAML file of named scenarios to activate")
+	return cmd
+}
+
+func runTUI(c *client.Client, interval time.Duration, scenarios string, in io.Reader, out io.Writer) error {
+	t := newTUI(c, interval)
+	if scenarios != "" {
+		if err := t.loadScenarios(scenarios); err != nil {
+			return err
+		}
+	}
+	_, err := tea.NewProgram(t, tea.WithInput(in), tea.WithOutput(out), tea.WithAltScreen()).Run()
+	return err
+}
+
+func newTUI(c *client.Client, interval time.Duration) *tui {
+	return &tui{
+		c:        c,
+		interval: interval,
+		state:    make(map[string]int),
+		saved:    make(map[string]int),
+		events:   make([]string, 0, maxEvents),
+	}
+}
+
+func (t *tui) loadScenarios(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var f scenarioFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	t.scenarios = f.Scenarios
+	t.names = make([]string, 0, len(f.Scenarios))
+	for n := range f.Scenarios {
+		t.names = append(t.names, n)
+	}
+	sort.Strings(t.names)
+	return nil
+}
+
+func (t *tui) Init() tea.Cmd {
+	return tea.Batch(t.fetch, t.tick())
+}
+
+func (t *tui) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return t, tea.Batch(t.fetch, t.tick())
+	case statusMsg:
+		t.refresh(msg.state, msg.err)
+	case doneMsg:
+		if msg.err != nil {
+			t.logf("error: %v", msg.err)
+		} else if msg.event != "" {
+			t.logf("%s", msg.event)
+		}
+		return t, t.fetch
+	case tea.KeyMsg:
+		if msg.Type != tea.KeyRunes || len(msg.Runes) < 2 {
+			return t, t.key(msg)
+		}
+		// keys typed faster than they are read arrive as one message
+		cmds := make([]tea.Cmd, len(msg.Runes))
+		for i, r := range msg.Runes {
+			cmds[i] = t.key(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return t, tea.Sequence(cmds...)
+	}
+	return t, nil
+}
+
+// key handles a single key press.
+func (t *tui) key(msg tea.KeyMsg) tea.Cmd {
+	if t.editing {
+		return t.edit(msg)
+	}
+	return t.press(msg)
+}
+
+// fetch reads the status from the server.
+func (t *tui) fetch() tea.Msg {
+	st, err := t.c.Status()
+	return statusMsg{state: st, err: err}
+}
+
+func (t *tui) tick() tea.Cmd {
+	return tea.Tick(t.interval, func(now time.Time) tea.Msg { return tickMsg(now) })
+}
+
+// send runs fn against the server in the background, logging event once it
+// succeeds.
+func (t *tui) send(event string, fn func() error) tea.Cmd {
+	return func() tea.Msg { return doneMsg{event: event, err: fn()} }
+}
+
+// refresh records the status read from the server and every change in it as
+// an event.
+func (t *tui) refresh(st map[string]int, err error) {
+	if err != nil {
+		if t.err == nil || t.err.Error() != err.Error() {
+			t.logf("error: %v", err)
+		}
+		t.err = err
+		return
+	}
+	t.err = nil
+	t.synced = time.Now()
+
+	for _, e := range diffStatus(t.state, st) {
+		t.logf("%s", e)
+	}
+
+	// keep the cursor on the same key as keys come and go
+	selected, _ := t.selected()
+	t.state = st
+	t.keys = sortedKeys(st)
+	if i := sort.SearchStrings(t.keys, selected); i < len(t.keys) && t.keys[i] == selected {
+		t.cursor = i
+	}
+	t.cursor = max(0, min(t.cursor, len(t.keys)-1))
+}
+
+// press handles a key pressed outside of the input line.
+func (t *tui) press(msg tea.KeyMsg) tea.Cmd {
+	switch key := msg.String(); key {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		t.cursor = max(0, t.cursor-1)
+	case "down", "j":
+		t.cursor = max(0, min(t.cursor+1, len(t.keys)-1))
+	case " ", "t":
+		if k, ok := t.selected(); ok {
+			return t.toggle(k)
+		}
+	case "+", "=":
+		if k, ok := t.selected(); ok {
+			return t.set(k, t.state[k]+1)
+		}
+	case "-":
+		if k, ok := t.selected(); ok {
+			return t.set(k, max(0, t.state[k]-1))
+		}
+	case "s":
+		if k, ok := t.selected(); ok {
+			t.editing, t.input = true, k+" "
+		}
+	case "n":
+		t.editing, t.input = true, ""
+	case "r":
+		t.saved = make(map[string]int)
+		return t.send("reset", t.c.Reset)
+	default:
+		if i, err := strconv.Atoi(key); err == nil && i >= 1 && i <= len(t.names) {
+			return t.activate(t.names[i-1])
+		}
+	}
+	return nil
+}
+
+// edit handles a key pressed while a "<key> <count>" line is being typed.
+func (t *tui) edit(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		t.editing = false
+	case tea.KeyEnter:
+		t.editing = false
+		fields := strings.Fields(t.input)
+		if len(fields) != 2 {
+			t.logf("error: expected <key> <count>")
+			return nil
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			t.logf("error: invalid count %q", fields[1])
+			return nil
+		}
+		return t.set(fields[0], n)
+	case tea.KeyBackspace:
+		if r := []rune(t.input); len(r) > 0 {
+			t.input = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		t.input += " "
+	case tea.KeyRunes:
+		t.input += string(msg.Runes)
+	}
+	return nil
+}
+
+// selected returns the key under the cursor.
+func (t *tui) selected() (string, bool) {
+	if t.cursor < len(t.keys) {
+		return t.keys[t.cursor], true
+	}
+	return "", false
+}
+
+func (t *tui) set(key string, n int) tea.Cmd {
+	return t.send("", func() error { return t.c.Set(key, n) })
+}
+
+// toggle disarms key, remembering its count, or arms it again with the
+// count it had, or one.
+func (t *tui) toggle(key string) tea.Cmd {
+	if rem := t.state[key]; rem > 0 {
+		t.saved[key] = rem
+		return t.set(key, 0)
+	}
+	n := t.saved[key]
+	if n <= 0 {
+		n = 1
+	}
+	delete(t.saved, key)
+	return t.set(key, n)
+}
+
+// activate replaces the server state with the named scenario.
+func (t *tui) activate(name string) tea.Cmd {
+	sc := t.scenarios[name]
+	keys := sortedKeys(sc)
+	t.saved = make(map[string]int)
+	return t.send("scenario "+name+" activated", func() error {
+		if err := t.c.Reset(); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err := t.c.Set(k, sc[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (t *tui) logf(format string, args ...interface{}) {
+	msg := time.Now().Format("15:04:05") + "  " + fmt.Sprintf(format, args...)
+	if len(t.events) == maxEvents {
+		copy(t.events, t.events[1:])
+		t.events = t.events[:maxEvents-1]
+	}
+	t.events = append(t.events, msg)
+}
+
+func (t *tui) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "go-fi %s", t.c.Base)
+	if !t.synced.IsZero() {
+		fmt.Fprintf(&b, "   synced %s", t.synced.Format("15:04:05"))
+	}
+	b.WriteString("\n\n")
+
+	fmt.Fprintf(&b, "  %-40s %s\n", "KEY", "REMAINING")
+	if len(t.keys) == 0 {
+		b.WriteString("  (no faults configured)\n")
+	}
+	for i, k := range t.keys {
+		cursor := " "
+		if i == t.cursor {
+			cursor = ">"
+		}
+		fmt.Fprintf(&b, "%s %-40s %d\n", cursor, k, t.state[k])
+	}
+
+	if len(t.names) > 0 {
+		b.WriteString("\nScenarios:\n")
+		for i, n := range t.names {
+			fmt.Fprintf(&b, "  %d  %s\n", i+1, n)
+		}
+	}
+
+	b.WriteString("\nEvents:\n")
+	for _, e := range t.events {
+		b.WriteString("  " + e + "\n")
+	}
+
+	b.WriteString("\n")
+	if t.editing {
+		b.WriteString("set <key> <count>: " + t.input + "█\n")
+	} else {
+		b.WriteString(tuiHelp + "\n")
+	}
+	return b.String()
+}
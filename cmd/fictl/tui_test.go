@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/talinashro/go-fi/client"
+)
+
+// fakeServer mimics the control server's /set, /reset and /status endpoints.
+type fakeServer struct {
+	mu    sync.Mutex
+	state map[string]int
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *httptest.Server) {
+	f := &fakeServer{state: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		c, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		f.state[r.URL.Query().Get("key")] = c
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.state = make(map[string]int)
+		w.Write([]byte("OK"))
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.state)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeServer) get(key string) (int, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.state[key]
+	return v, ok
+}
+
+// keys returns the key presses that type s, with "\r" for enter.
+func keys(s string) []tea.KeyMsg {
+	var msgs []tea.KeyMsg
+	for _, r := range s {
+		switch r {
+		case ' ':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{r}})
+		case '\r':
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyEnter})
+		default:
+			msgs = append(msgs, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+	return msgs
+}
+
+// feed sends msg to ui and runs every command that follows from it, as the
+// program would, and reports whether ui asked to quit.
+func feed(ui *tui, msg tea.Msg) bool {
+	for msg != nil {
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return true
+		}
+		_, cmd := ui.Update(msg)
+		if cmd == nil {
+			return false
+		}
+		msg = cmd()
+	}
+	return false
+}
+
+func TestTUIKeys(t *testing.T) {
+	f, srv := newFakeServer(t)
+	ui := newTUI(client.New(srv.URL), time.Second)
+	ui.scenarios = map[string]map[string]int{
+		"outage": {"db-connect": 3, "api-call": 1},
+	}
+	ui.names = []string{"outage"}
+	feed(ui, ui.fetch())
+
+	tests := []struct {
+		name     string
+		keys     []tea.KeyMsg
+		key      string
+		expected int
+	}{
+		{name: "new key", keys: keys("ndb-connect 2\r"), key: "db-connect", expected: 2},
+		{name: "bump", keys: keys("+"), key: "db-connect", expected: 3},
+		{name: "bump again", keys: keys("="), key: "db-connect", expected: 4},
+		{name: "decrement", keys: keys("-"), key: "db-connect", expected: 3},
+		{name: "toggle off", keys: keys(" "), key: "db-connect", expected: 0},
+		{name: "toggle restores count", keys: keys("t"), key: "db-connect", expected: 3},
+		{name: "new key sorted first", keys: keys("napi-call 0\r"), key: "api-call", expected: 0},
+		{name: "cursor stays on its key", keys: keys("+"), key: "db-connect", expected: 4},
+		{name: "toggle on defaults to one", keys: append([]tea.KeyMsg{{Type: tea.KeyUp}}, keys("t")...), key: "api-call", expected: 1},
+		{name: "set selected", keys: keys("s5\r"), key: "api-call", expected: 5},
+		{name: "cancelled input", keys: append(keys("s9"), tea.KeyMsg{Type: tea.KeyEsc}), key: "api-call", expected: 5},
+		{name: "move down", keys: append([]tea.KeyMsg{{Type: tea.KeyDown}}, keys("-")...), key: "db-connect", expected: 3},
+		{name: "activate scenario", keys: keys("1"), key: "api-call", expected: 1},
+		{name: "keys read together", keys: []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("ncache")}, {Type: tea.KeySpace}, {Type: tea.KeyRunes, Runes: []rune("2")}, {Type: tea.KeyEnter}}, key: "cache", expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range tt.keys {
+				if feed(ui, k) {
+					t.Fatalf("Unexpected quit on %q", k)
+				}
+			}
+			if got, _ := f.get(tt.key); got != tt.expected {
+				t.Errorf("Expected %s=%d, got %d", tt.key, tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		feed(ui, keys("r")[0])
+		if _, ok := f.get("db-connect"); ok {
+			t.Error("Expected reset to clear db-connect")
+		}
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		for line, expected := range map[string]string{
+			"ndb-connect\r":   "error: expected <key> <count>",
+			"ndb-connect x\r": `error: invalid count "x"`,
+		} {
+			for _, k := range keys(line) {
+				feed(ui, k)
+			}
+			if e := ui.events[len(ui.events)-1]; !strings.HasSuffix(e, expected) {
+				t.Errorf("Expected %q for %q, got %q", expected, line, e)
+			}
+		}
+		if _, ok := f.get("db-connect"); ok {
+			t.Error("Expected invalid input to change nothing")
+		}
+	})
+
+	t.Run("quit", func(t *testing.T) {
+		if !feed(ui, keys("q")[0]) {
+			t.Error("Expected q to quit")
+		}
+	})
+}
+
+func TestTUIRefreshEvents(t *testing.T) {
+	f, srv := newFakeServer(t)
+	ui := newTUI(client.New(srv.URL), time.Second)
+
+	f.state["api-call"] = 2
+	feed(ui, ui.fetch())
+	f.state["api-call"] = 1
+	feed(ui, ui.fetch())
+	delete(f.state, "api-call")
+	feed(ui, ui.fetch())
+
+	want := []string{"api-call armed (2 remaining)", "api-call 2 -> 1", "api-call cleared"}
+	if len(ui.events) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %v", len(want), len(ui.events), ui.events)
+	}
+	for i, w := range want {
+		if !strings.HasSuffix(ui.events[i], w) {
+			t.Errorf("Expected event %d to end with %q, got %q", i, w, ui.events[i])
+		}
+	}
+}
+
+func TestTUIRunQuits(t *testing.T) {
+	f, srv := newFakeServer(t)
+	f.state["api-call"] = 1
+	var out strings.Builder
+	cmd := newRootCmd(strings.NewReader("q"), &out)
+	cmd.SetArgs([]string{"--addr", srv.URL, "tui"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("runTUI returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "go-fi "+srv.URL) {
+		t.Errorf("Expected the screen to be drawn, got %q", out.String())
+	}
+}
+
+func TestTUISingleServer(t *testing.T) {
+	var out strings.Builder
+	cmd := newRootCmd(strings.NewReader("q"), &out)
+	cmd.SetArgs([]string{"--addr", "a:8081", "--addr", "b:8081", "tui"})
+	if err := cmd.Execute(); err == nil {
+		t.Error("Expected an error for several servers")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sort"
+)
+
+// A key can end up with both a first-N and an Nth rule when a spec lists it
+// under both "failures" and "precise-failures", or under one of them and a
+// "rules" entry with the other mode, or when a spec merged onto the current
+// state (WatchOptions.Merge, SyncOptions.Merge) sets the other mode than
+// the key has. Rather than letting whichever is applied last win, the two are
+// merged: the key fails its first N calls and its Nth call. If the Nth call
+// is one of the first N, the first-N rule alone covers both. The resolution
+// is reported in KeyStatus.Conflict and logged at Warn with SetLogger, until
+// the key is configured again. Setters called directly still replace the
+// key's mode, as documented on each.
+
+// modeRules are the first-N count and Nth call a spec sets for one key.
+type modeRules struct {
+	count, nth int
+	base       Rule // the key's other settings, from "rules" or the current state
+}
+
+// resolveModeConflicts merges the first-N and Nth rules cfg sets for the same
+// key, taking the current rules into account if merge is set, and moves the
+// merged rules to cfg.Rules. It returns the resolution for each such key.
+func resolveModeConflicts(cfg *Spec, current map[string]Rule, merge bool) map[string]string {
+	found := make(map[string]*modeRules)
+	get := func(key string) *modeRules {
+		if m, ok := found[key]; ok {
+			return m
+		}
+		m := &modeRules{}
+		if r, ok := cfg.Rules[key]; ok {
+			m.base = r
+		} else if merge {
+			m.base = current[key]
+		}
+		switch m.base.Mode {
+		case ModeFirstN:
+			m.count = m.base.Count
+		case ModeNth:
+			if len(m.base.Ranges) == 0 && m.base.Every == 0 {
+				m.nth = m.base.Nth
+			}
+		}
+		found[key] = m
+		return m
+	}
+	for k, n := range cfg.Failures {
+		get(k).count = n
+	}
+	for k, n := range cfg.PreciseFailures {
+		get(k).nth = n
+	}
+
+	conflicts := make(map[string]string)
+	for k, m := range found {
+		if m.count > 0 && m.nth > 0 {
+			conflicts[k] = ""
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	// copy the maps, so a caller's Spec is left alone
+	rules := make(map[string]Rule, len(cfg.Rules)+len(conflicts))
+	maps.Copy(rules, cfg.Rules)
+	cfg.Rules = rules
+	cfg.Failures, cfg.PreciseFailures = maps.Clone(cfg.Failures), maps.Clone(cfg.PreciseFailures)
+	for k := range conflicts {
+		m := found[k]
+		cfg.Rules[k], conflicts[k] = mergeModes(m.base, m.count, m.nth)
+		delete(cfg.Failures, k)
+		delete(cfg.PreciseFailures, k)
+	}
+	return conflicts
+}
+
+// mergeModes returns base failing its first count calls and its nth call, and
+// a description of the merge.
+func mergeModes(base Rule, count, nth int) (Rule, string) {
+	r := base
+	r.Mode, r.Count, r.Nth, r.Rate, r.Flap, r.Actions = ModeFirstN, count, 0, 0, nil, nil
+	if nth <= count {
+		return r, fmt.Sprintf("first-n %d and nth %d merged: nth call is within the first %d", count, nth, count)
+	}
+	r.Mode, r.Count = ModeActions, 0
+	r.Actions = []Action{{Calls: count, Fail: true}}
+	if gap := nth - count - 1; gap > 0 {
+		r.Actions = append(r.Actions, Action{Calls: gap})
+	}
+	r.Actions = append(r.Actions, Action{Calls: 1, Fail: true})
+	first := "1"
+	if count > 1 {
+		first = fmt.Sprintf("1-%d", count)
+	}
+	return r, fmt.Sprintf("first-n %d and nth %d merged: calls %s and %d fail", count, nth, first, nth)
+}
+
+// setConflictsLocked records the resolutions of a spec's conflicts.
+func (in *Injector) setConflictsLocked(conflicts map[string]string) {
+	if len(conflicts) == 0 {
+		return
+	}
+	for k, c := range conflicts {
+		in.conflicts[k] = c
+	}
+	in.mu.dirty = true
+}
+
+// logConflicts logs how each of conflicts was resolved.
+func (in *Injector) logConflicts(conflicts map[string]string) {
+	l := in.logger.Load()
+	if len(conflicts) == 0 || l == nil {
+		return
+	}
+	keys := make([]string, 0, len(conflicts))
+	for k := range conflicts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l.LogAttrs(context.Background(), slog.LevelWarn, "fault rule conflict", slog.String("key", k), slog.String("resolution", conflicts[k]))
+	}
+}
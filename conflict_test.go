@@ -0,0 +1,103 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestModeConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected []bool
+		conflict string
+	}{
+		{
+			name:     "failures and precise-failures",
+			spec:     "failures:\n  db: 2\nprecise-failures:\n  db: 5\n",
+			expected: []bool{true, true, false, false, true, false},
+			conflict: "first-n 2 and nth 5 merged: calls 1-2 and 5 fail",
+		},
+		{
+			name:     "adjacent",
+			spec:     "failures:\n  db: 2\nprecise-failures:\n  db: 3\n",
+			expected: []bool{true, true, true, false},
+			conflict: "first-n 2 and nth 3 merged: calls 1-2 and 3 fail",
+		},
+		{
+			name:     "nth within first-n",
+			spec:     "failures:\n  db: 3\nprecise-failures:\n  db: 2\n",
+			expected: []bool{true, true, true, false},
+			conflict: "first-n 3 and nth 2 merged: nth call is within the first 3",
+		},
+		{
+			name:     "rules entry keeps its error",
+			spec:     "rules:\n  db: {nth: 4, error: refused}\nfailures:\n  db: 1\n",
+			expected: []bool{true, false, false, true, false},
+			conflict: "first-n 1 and nth 4 merged: calls 1 and 4 fail",
+		},
+		{
+			name:     "no conflict",
+			spec:     "failures:\n  db: 1\nprecise-failures:\n  cache: 2\n",
+			expected: []bool{true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if err := LoadSpecFromBytes([]byte(tt.spec), FormatYAML); err != nil {
+				t.Fatalf("LoadSpecFromBytes failed: %v", err)
+			}
+			if c := FullStatus()["db"].Conflict; c != tt.conflict {
+				t.Errorf("Expected conflict %q, got %q", tt.conflict, c)
+			}
+			for i, want := range tt.expected {
+				if got := Inject("db"); got != want {
+					t.Errorf("Call %d: expected %v, got %v", i+1, want, got)
+				}
+			}
+			if err := ValidateInvariants(); err != nil {
+				t.Errorf("Unexpected invariant violation: %v", err)
+			}
+		})
+	}
+}
+
+func TestModeConflictMerge(t *testing.T) {
+	resetState()
+	var buf bytes.Buffer
+	in := NewInjector()
+	in.SetLogger(testLogger(&buf, slog.LevelWarn))
+	in.SetFailures("db", 1)
+	in.applySpec(Spec{PreciseFailures: map[string]int{"db": 3}}, true)
+
+	if c := in.FullStatus()["db"].Conflict; c == "" {
+		t.Error("Expected a conflict with the current rule")
+	}
+	expected := `level=WARN msg="fault rule conflict" key=db resolution="first-n 1 and nth 3 merged: calls 1 and 3 fail"`
+	if got := strings.TrimSpace(buf.String()); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	in.SetNthFailure("db", 2) // set directly: replaces the mode
+	if s := in.FullStatus()["db"]; s.Conflict != "" || s.Mode != ModeNth {
+		t.Errorf("Expected a direct setter to replace the rule and clear the conflict, got %+v", s)
+	}
+}
+
+func TestResolveModeConflictsKeepsSpec(t *testing.T) {
+	cfg := Spec{Failures: map[string]int{"db": 1}, PreciseFailures: map[string]int{"db": 3}}
+	orig := cfg
+	resolveModeConflicts(&cfg, nil, false)
+	if orig.Failures["db"] != 1 || orig.PreciseFailures["db"] != 3 || orig.Rules != nil {
+		t.Errorf("Expected the caller's maps left alone, got %+v", orig)
+	}
+	if _, ok := cfg.Failures["db"]; ok || cfg.Rules["db"].Mode != ModeActions {
+		t.Errorf("Expected the key moved to a merged rule, got %+v", cfg)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ConnFault is a way of breaking a network connection, for testing the
+// keep-alives, retries and connection pools an application-level error never
+// reaches. Each Read and Write on a connection wrapped by WrapConn,
+// WrapListener or WrapDialer is one call to the key, with an "op" attribute
+// of "read" or "write", so SetNthFailure(key, 3) breaks the third of them.
+type ConnFault string
+
+const (
+	// ConnReset closes the connection so the peer sees a TCP reset, and fails
+	// the call with ECONNRESET.
+	ConnReset ConnFault = "reset"
+	// ConnTimeout stalls the call until the connection's deadline, or for a
+	// sample of the key's latency distribution without one, and fails it
+	// with a timeout, as with a peer that stopped responding.
+	ConnTimeout ConnFault = "timeout"
+	// ConnThrottle passes the call through at the key's bandwidth, set with
+	// SetBandwidth.
+	ConnThrottle ConnFault = "throttle"
+	// ConnClose closes the connection mid-stream: a write sends half of its
+	// bytes first, and a read returns io.EOF.
+	ConnClose ConnFault = "close"
+)
+
+// defaultBandwidth is the bandwidth of ConnThrottle, in bytes per second,
+// for keys without one.
+const defaultBandwidth = 16 << 10
+
+// defaultConnStall is how long ConnTimeout stalls without a deadline or a
+// latency distribution.
+const defaultConnStall = time.Second
+
+// SetConnFault sets how wrapped connections break for key when it fires. The
+// default is ConnReset. An empty ConnFault restores the default.
+func (in *Injector) SetConnFault(key string, fault ConnFault) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.connFaults, key, fault, fault == "")
+}
+
+// SetConnFault calls SetConnFault on the default Injector.
+func SetConnFault(key string, fault ConnFault) {
+	std.SetConnFault(key, fault)
+}
+
+// SetBandwidth sets the bytes per second ConnThrottle lets through for key.
+// Zero or less restores the default of 16 KiB/s.
+func (in *Injector) SetBandwidth(key string, bytesPerSecond int) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.bandwidths, key, bytesPerSecond, bytesPerSecond <= 0)
+}
+
+// SetBandwidth calls SetBandwidth on the default Injector.
+func SetBandwidth(key string, bytesPerSecond int) {
+	std.SetBandwidth(key, bytesPerSecond)
+}
+
+func (in *Injector) connFaultFor(key string) (ConnFault, int) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	k := in.ruleKeyLocked(key)
+	f, bw := in.connFaults[k], in.bandwidths[k]
+	if bw <= 0 {
+		bw = defaultBandwidth
+	}
+	return f, bw
+}
+
+// WrapConn returns c with the faults configured for key injected into its
+// reads and writes.
+func (in *Injector) WrapConn(key string, c net.Conn) net.Conn {
+	return &faultConn{Conn: c, in: in, key: key}
+}
+
+// WrapConn calls WrapConn on the default Injector.
+func WrapConn(key string, c net.Conn) net.Conn {
+	return std.WrapConn(key, c)
+}
+
+// WrapListener returns l with every connection it accepts wrapped by
+// WrapConn, for breaking the connections of a server.
+func (in *Injector) WrapListener(key string, l net.Listener) net.Listener {
+	return &faultListener{Listener: l, in: in, key: key}
+}
+
+// WrapListener calls WrapListener on the default Injector.
+func WrapListener(key string, l net.Listener) net.Listener {
+	return std.WrapListener(key, l)
+}
+
+// WrapDialer returns dial with every connection it makes wrapped by WrapConn,
+// for breaking the connections of a client. It suits
+// http.Transport.DialContext:
+//
+//	transport.DialContext = faultinject.WrapDialer("payments-conn", (&net.Dialer{}).DialContext)
+func (in *Injector) WrapDialer(key string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		c, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return in.WrapConn(key, c), nil
+	}
+}
+
+// WrapDialer calls WrapDialer on the default Injector.
+func WrapDialer(key string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return std.WrapDialer(key, dial)
+}
+
+type faultListener struct {
+	net.Listener
+	in  *Injector
+	key string
+}
+
+func (l *faultListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return l.in.WrapConn(l.key, c), nil
+}
+
+// faultConn remembers its deadlines, so that ConnTimeout stalls until them.
+type faultConn struct {
+	net.Conn
+	in  *Injector
+	key string
+
+	mu                          sync.Mutex
+	readDeadline, writeDeadline time.Time
+}
+
+func (c *faultConn) Read(b []byte) (int, error) {
+	if !c.fires("read") {
+		return c.Conn.Read(b)
+	}
+	fault, bw := c.in.connFaultFor(c.key)
+	switch fault {
+	case ConnTimeout:
+		return 0, c.stall("read", c.deadline(&c.readDeadline))
+	case ConnThrottle:
+		n, err := c.Conn.Read(b[:min(len(b), max(bw/10, 1))])
+		time.Sleep(time.Duration(n) * time.Second / time.Duration(bw))
+		return n, err
+	case ConnClose:
+		c.Conn.Close()
+		return 0, io.EOF
+	default:
+		return 0, c.reset("read")
+	}
+}
+
+func (c *faultConn) Write(b []byte) (int, error) {
+	if !c.fires("write") {
+		return c.Conn.Write(b)
+	}
+	fault, bw := c.in.connFaultFor(c.key)
+	switch fault {
+	case ConnTimeout:
+		return 0, c.stall("write", c.deadline(&c.writeDeadline))
+	case ConnThrottle:
+		chunk := max(bw/10, 1)
+		written := 0
+		for written < len(b) {
+			n, err := c.Conn.Write(b[written:min(written+chunk, len(b))])
+			written += n
+			if err != nil {
+				return written, err
+			}
+			time.Sleep(time.Duration(n) * time.Second / time.Duration(bw))
+		}
+		return written, nil
+	case ConnClose:
+		n, _ := c.Conn.Write(b[:len(b)/2])
+		c.Conn.Close()
+		return n, c.opError("write", net.ErrClosed)
+	default:
+		return 0, c.reset("write")
+	}
+}
+
+func (c *faultConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline, c.writeDeadline = t, t
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *faultConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *faultConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func (c *faultConn) fires(op string) bool {
+	ctx := WithAttributes(context.Background(), Attributes{"op": op})
+	return c.in.InjectWithContext(ctx, c.key)
+}
+
+func (c *faultConn) deadline(d *time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *d
+}
+
+// stall waits until deadline, or for a sample of the key's latency without
+// one, and returns a timeout error.
+func (c *faultConn) stall(op string, deadline time.Time) error {
+	d := time.Until(deadline)
+	if deadline.IsZero() {
+		d = defaultConnStall
+		s := c.in.snapshot()
+		if dist := s.rules[s.ruleKey(c.key)].Latency; len(dist) > 0 {
+			d = dist.Sample()
+		}
+	}
+	time.Sleep(d)
+	return c.opError(op, os.ErrDeadlineExceeded)
+}
+
+// reset closes the connection so that the peer sees a reset rather than an
+// orderly shutdown, where the connection is TCP.
+func (c *faultConn) reset(op string) error {
+	if tc, ok := c.Conn.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+	c.Conn.Close()
+	return c.opError(op, syscall.ECONNRESET)
+}
+
+func (c *faultConn) opError(op string, err error) error {
+	return &net.OpError{Op: op, Net: c.LocalAddr().Network(), Source: c.LocalAddr(), Addr: c.RemoteAddr(), Err: err}
+}
@@ -0,0 +1,167 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestConnFaults(t *testing.T) {
+	resetState()
+	payload := []byte("0123456789")
+	tests := []struct {
+		name  string
+		fault ConnFault
+		op    string
+		check func(t *testing.T, n int, err error, peer []byte, elapsed time.Duration)
+	}{
+		{name: "timeout read", fault: ConnTimeout, op: "read", check: func(t *testing.T, n int, err error, peer []byte, elapsed time.Duration) {
+			var ne net.Error
+			if !errors.As(err, &ne) || !ne.Timeout() {
+				t.Errorf("Expected a timeout, got %v", err)
+			}
+			if elapsed < 20*time.Millisecond {
+				t.Errorf("Expected the read to stall until the deadline, took %v", elapsed)
+			}
+		}},
+		{name: "close read", fault: ConnClose, op: "read", check: func(t *testing.T, n int, err error, peer []byte, elapsed time.Duration) {
+			if err != io.EOF {
+				t.Errorf("Expected io.EOF, got %v", err)
+			}
+		}},
+		{name: "close write", fault: ConnClose, op: "write", check: func(t *testing.T, n int, err error, peer []byte, elapsed time.Duration) {
+			if err == nil || string(peer) != "01234" {
+				t.Errorf("Expected half the bytes and an error, got %q, %v", peer, err)
+			}
+		}},
+		{name: "throttle write", fault: ConnThrottle, op: "write", check: func(t *testing.T, n int, err error, peer []byte, elapsed time.Duration) {
+			if err != nil || string(peer) != string(payload) {
+				t.Errorf("Expected every byte, got %q, %v", peer, err)
+			}
+			if elapsed < 50*time.Millisecond {
+				t.Errorf("Expected the write to be throttled, took %v", elapsed)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			in.SetFailures("conn", 1)
+			in.SetConnFault("conn", tt.fault)
+			in.SetBandwidth("conn", 100)
+			client, server := net.Pipe()
+			c := in.WrapConn("conn", client)
+			c.SetDeadline(time.Now().Add(20 * time.Millisecond))
+			defer c.Close()
+
+			received := make(chan []byte, 1)
+			go func() {
+				if tt.op == "write" {
+					b, _ := io.ReadAll(server)
+					received <- b
+					return
+				}
+				server.Close()
+				received <- nil
+			}()
+
+			start := time.Now()
+			var n int
+			var err error
+			if tt.op == "write" {
+				c.SetDeadline(time.Time{})
+				n, err = c.Write(payload)
+				c.Close()
+			} else {
+				n, err = c.Read(make([]byte, 16))
+			}
+			elapsed := time.Since(start)
+			tt.check(t, n, err, <-received, elapsed)
+		})
+	}
+}
+
+func TestWrapListenerReset(t *testing.T) {
+	resetState()
+	SetFailures("server-conn", 1)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	l = WrapListener("server-conn", l)
+	defer l.Close()
+
+	serverErr := make(chan error, 1)
+	dialed := make(chan struct{})
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		<-dialed
+		_, err = c.Read(make([]byte, 16))
+		serverErr <- err
+	}()
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer c.Close()
+	close(dialed)
+	c.Write([]byte("hello"))
+	if err := <-serverErr; !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("Expected ECONNRESET on the server, got %v", err)
+	}
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := c.Read(make([]byte, 16)); err == nil {
+		t.Errorf("Expected the client to see the connection broken")
+	}
+}
+
+func TestWrapDialer(t *testing.T) {
+	resetState()
+	SetFailures("client-conn", 1)
+	SetConnFault("client-conn", ConnClose)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: WrapDialer("client-conn", (&net.Dialer{}).DialContext),
+	}}
+
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Errorf("Expected the first request to fail on a closed connection")
+	}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected the second request to succeed, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestConnFaultSpec(t *testing.T) {
+	resetState()
+	err := LoadSpecFromBytes([]byte("conn-faults:\n  db: throttle\nbandwidths:\n  db: 0\n"), "yaml")
+	if err == nil {
+		t.Errorf("Expected a zero bandwidth to be rejected")
+	}
+	if err := LoadSpecFromBytes([]byte("conn-faults:\n  db: throttle\nbandwidths:\n  db: 1024\n"), "yaml"); err != nil {
+		t.Fatalf("Expected the spec to load, got %v", err)
+	}
+	if f, bw := std.connFaultFor("db"); f != ConnThrottle || bw != 1024 {
+		t.Errorf("Expected throttle at 1024 B/s, got %s at %d", f, bw)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Corruption is a way of mangling the encoding of a payload, for testing
+// parsers and storage layers that assume clean input.
+type Corruption string
+
+const (
+	// CorruptInvalidUTF8 inserts a byte that is never valid in UTF-8 in the
+	// middle of the payload.
+	CorruptInvalidUTF8 Corruption = "invalid-utf8"
+	// CorruptBOM prefixes a UTF-8 byte order mark.
+	CorruptBOM Corruption = "bom"
+	// CorruptMixedEncoding re-encodes the second half of the payload as
+	// Latin-1 where possible, as when two systems with different encodings
+	// write to the same field. Payloads without such characters get a Latin-1
+	// "é" appended.
+	CorruptMixedEncoding Corruption = "mixed-encoding"
+	// CorruptMojibake decodes the UTF-8 payload as Latin-1 and re-encodes it,
+	// turning "café" into "cafÃ©". The result is valid UTF-8.
+	CorruptMojibake Corruption = "mojibake"
+)
+
+// SetCorruption sets how CorruptBytes, CorruptString and CorruptResponse
+// mangle payloads for key when it fires. The default is CorruptInvalidUTF8.
+// An empty Corruption restores the default.
+func (in *Injector) SetCorruption(key string, c Corruption) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.corruptions, key, c, c == "")
+}
+
+// SetCorruption calls SetCorruption on the default Injector.
+func SetCorruption(key string, c Corruption) {
+	std.SetCorruption(key, c)
+}
+
+// CorruptBytes returns b corrupted as configured by SetCorruption if key
+// fires, and b unchanged otherwise. b itself is never modified.
+func (in *Injector) CorruptBytes(ctx context.Context, key string, b []byte) []byte {
+	if !in.InjectWithContext(ctx, key) {
+		return b
+	}
+	return in.corruptionFor(key).apply(b)
+}
+
+// CorruptBytes calls CorruptBytes on the default Injector.
+func CorruptBytes(ctx context.Context, key string, b []byte) []byte {
+	return std.CorruptBytes(ctx, key, b)
+}
+
+// CorruptString is CorruptBytes for strings.
+func (in *Injector) CorruptString(ctx context.Context, key string, s string) string {
+	if !in.InjectWithContext(ctx, key) {
+		return s
+	}
+	return string(in.corruptionFor(key).apply([]byte(s)))
+}
+
+// CorruptString calls CorruptString on the default Injector.
+func CorruptString(ctx context.Context, key string, s string) string {
+	return std.CorruptString(ctx, key, s)
+}
+
+// CorruptResponse creates middleware that corrupts the response body as
+// configured by SetCorruption when key fires. Corrupted responses are
+// buffered and sent without their original Content-Length.
+func (in *Injector) CorruptResponse(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithAttributes(r.Context(), in.requestAttributes(r))
+			r = r.WithContext(ctx)
+			if !in.InjectWithContext(ctx, key) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &corruptWriter{ResponseWriter: w, code: http.StatusOK}
+			next.ServeHTTP(cw, r)
+			body := in.corruptionFor(key).apply(cw.body.Bytes())
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.WriteHeader(cw.code)
+			w.Write(body)
+		})
+	}
+}
+
+// CorruptResponse calls CorruptResponse on the default Injector.
+func CorruptResponse(key string) func(http.Handler) http.Handler {
+	return std.CorruptResponse(key)
+}
+
+// corruptWriter buffers a response so it can be corrupted as a whole.
+type corruptWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *corruptWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code, w.wroteHeader = code, true
+	}
+}
+
+func (w *corruptWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
+
+func (in *Injector) corruptionFor(key string) Corruption {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if c, ok := in.corruptions[in.ruleKeyLocked(key)]; ok {
+		return c
+	}
+	return CorruptInvalidUTF8
+}
+
+// apply returns a corrupted copy of b. Unknown corruptions fall back to
+// CorruptInvalidUTF8.
+func (c Corruption) apply(b []byte) []byte {
+	switch c {
+	case CorruptBOM:
+		return append([]byte("\xef\xbb\xbf"), b...)
+	case CorruptMixedEncoding:
+		return mixEncoding(b)
+	case CorruptMojibake:
+		out := make([]byte, 0, len(b)*2)
+		for _, x := range b {
+			out = utf8.AppendRune(out, rune(x))
+		}
+		return out
+	default:
+		mid := runeBoundary(b, len(b)/2)
+		out := make([]byte, 0, len(b)+1)
+		out = append(out, b[:mid]...)
+		out = append(out, 0xff)
+		return append(out, b[mid:]...)
+	}
+}
+
+// mixEncoding re-encodes runes from the middle of b onwards as Latin-1.
+func mixEncoding(b []byte) []byte {
+	mid := runeBoundary(b, len(b)/2)
+	out := append([]byte(nil), b[:mid]...)
+	changed := false
+	for rest := b[mid:]; len(rest) > 0; {
+		r, size := utf8.DecodeRune(rest)
+		if r >= 0x80 && r <= 0xff && size > 1 {
+			out = append(out, byte(r))
+			changed = true
+		} else {
+			out = append(out, rest[:size]...)
+		}
+		rest = rest[size:]
+	}
+	if !changed {
+		out = append(out, 0xe9) // "é" in Latin-1
+	}
+	return out
+}
+
+// runeBoundary moves i back to the start of the rune containing it.
+func runeBoundary(b []byte, i int) int {
+	for i > 0 && i < len(b) && !utf8.RuneStart(b[i]) {
+		i--
+	}
+	return i
+}
@@ -0,0 +1,99 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCorruptString(t *testing.T) {
+	tests := []struct {
+		name       string
+		corruption Corruption
+		input      string
+		expected   string
+		valid      bool
+	}{
+		{name: "default", input: "café au lait", expected: "café \xffau lait"},
+		{name: "invalid utf8", corruption: CorruptInvalidUTF8, input: "日本語", expected: "日\xff本語"},
+		{name: "bom", corruption: CorruptBOM, input: "id,name", expected: "\xef\xbb\xbfid,name", valid: true},
+		{name: "mixed encoding", corruption: CorruptMixedEncoding, input: "naïve café", expected: "naïve caf\xe9"},
+		{name: "mixed encoding ascii", corruption: CorruptMixedEncoding, input: "plain", expected: "plain\xe9"},
+		{name: "mojibake", corruption: CorruptMojibake, input: "café", expected: "cafÃ©", valid: true},
+		{name: "empty", corruption: CorruptInvalidUTF8, input: "", expected: "\xff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetCorruption("payload", tt.corruption)
+			SetFailures("payload", 1)
+
+			got := CorruptString(context.Background(), "payload", tt.input)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if utf8.ValidString(got) != tt.valid {
+				t.Errorf("Expected valid UTF-8: %v, got %v", tt.valid, utf8.ValidString(got))
+			}
+			if got := CorruptString(context.Background(), "payload", tt.input); got != tt.input {
+				t.Errorf("Expected the input unchanged once the rule is exhausted, got %q", got)
+			}
+		})
+	}
+}
+
+func TestCorruptBytesDoesNotModifyInput(t *testing.T) {
+	resetState()
+	SetFailures("payload", 1)
+	in := []byte("hello world")
+	out := CorruptBytes(context.Background(), "payload", in)
+	if string(in) != "hello world" {
+		t.Errorf("Expected input unchanged, got %q", in)
+	}
+	if utf8.Valid(out) {
+		t.Errorf("Expected corrupted output, got %q", out)
+	}
+}
+
+func TestCorruptResponse(t *testing.T) {
+	resetState()
+	SetCorruption("api-body", CorruptBOM)
+	SetFailures("api-body", 1)
+
+	handler := CorruptResponse("api-body")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "11")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id":"42"}`)
+	}))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	tests := []struct {
+		expected string
+	}{
+		{expected: "\xef\xbb\xbf{\"id\":\"42\"}"},
+		{expected: `{"id":"42"}`},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != tt.expected {
+			t.Errorf("Expected body %q, got %q", tt.expected, body)
+		}
+		if resp.StatusCode != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+// Counter returns how many times key has been evaluated since its count was
+// last restarted, counting calls that did not fail.
+func (in *Injector) Counter(key string) int {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	return in.counters[key]
+}
+
+// Counter calls Counter on the default Injector.
+func Counter(key string) int {
+	return std.Counter(key)
+}
+
+// SetCounter sets key's call count to n, as if it had been evaluated n
+// times, so a test can skip to the calls an Nth rule fails without making
+// the calls before them. A negative n counts as zero. Fault injection is
+// disabled in production environments.
+func (in *Injector) SetCounter(key string, n int) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	in.counters[key] = max(n, 0)
+	in.lastSeen[key] = in.timeNow()
+}
+
+// SetCounter calls SetCounter on the default Injector.
+func SetCounter(key string, n int) {
+	std.SetCounter(key, n)
+}
+
+// ResetCounter restarts key's call count without changing its rule.
+// Fault injection is disabled in production environments.
+func (in *Injector) ResetCounter(key string) {
+	in.SetCounter(key, 0)
+}
+
+// ResetCounter calls ResetCounter on the default Injector.
+func ResetCounter(key string) {
+	std.ResetCounter(key)
+}
@@ -0,0 +1,85 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	resetState()
+	SetNthFailure("db", 3)
+	for range 2 {
+		Inject("db")
+	}
+	Inject("unconfigured")
+
+	tests := []struct {
+		key      string
+		expected int
+	}{
+		{key: "db", expected: 2},
+		{key: "unconfigured", expected: 1},
+		{key: "never-called", expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := Counter(tt.key); got != tt.expected {
+				t.Errorf("Expected %d calls, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetCounter(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      int
+		expected bool // whether the next call fails
+	}{
+		{name: "skip to nth", set: 99, expected: true},
+		{name: "past nth", set: 100, expected: false},
+		{name: "negative", set: -5, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetNthFailure("db", 100)
+			SetCounter("db", tt.set)
+			if got := Inject("db"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if got, expected := Counter("db"), max(tt.set, 0)+1; got != expected {
+				t.Errorf("Expected %d calls, got %d", expected, got)
+			}
+		})
+	}
+}
+
+func TestResetCounter(t *testing.T) {
+	resetState()
+	SetFailures("db", 2)
+	Inject("db")
+	Inject("db")
+	ResetCounter("db")
+
+	if got := Counter("db"); got != 0 {
+		t.Errorf("Expected the count to restart, got %d", got)
+	}
+	if !Inject("db") {
+		t.Error("Expected the rule to be kept and fail again")
+	}
+}
+
+func TestSetCounterProduction(t *testing.T) {
+	resetState()
+	Inject("db")
+	os.Setenv("ENVIRONMENT", "production")
+	defer os.Setenv("ENVIRONMENT", "development")
+	SetCounter("db", 10)
+
+	if got := Counter("db"); got != 1 {
+		t.Errorf("Expected the count to be left alone in production, got %d", got)
+	}
+}
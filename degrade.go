@@ -0,0 +1,76 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Degradation is the reduced-quality response HTTPMiddleware serves when a
+// key fires, in place of an error, such as a partial result, an empty list or
+// a cached placeholder. It lets tests reach the graceful-degradation branches
+// of clients separately from their error branches.
+type Degradation struct {
+	// Status is the response status; zero means 206 Partial Content.
+	Status int               `yaml:"status,omitempty" json:"status,omitempty"`
+	Header map[string]string `yaml:"header,omitempty" json:"header,omitempty"`
+	Body   string            `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+func (d Degradation) empty() bool {
+	return d.Status == 0 && len(d.Header) == 0 && d.Body == ""
+}
+
+// SetDegradation makes HTTPMiddleware degrade the response for key when it
+// fires, instead of failing it. A zero Degradation restores the failure.
+func (in *Injector) SetDegradation(key string, d Degradation) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.degradations, key, d, d.empty())
+}
+
+// SetDegradation calls SetDegradation on the default Injector.
+func SetDegradation(key string, d Degradation) {
+	std.SetDegradation(key, d)
+}
+
+// ServeHTTP writes d.
+func (d Degradation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for name, value := range d.Header {
+		w.Header().Set(name, value)
+	}
+	status := d.Status
+	if status == 0 {
+		status = http.StatusPartialContent
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, d.Body)
+}
+
+func (in *Injector) degradationFor(key string) (Degradation, bool) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	d, ok := in.degradations[in.ruleKeyLocked(key)]
+	return d, ok
+}
+
+// Degrade returns v, or the result of degraded when key fires, so value-level
+// call sites can exercise their fallbacks the way HTTPMiddleware does with
+// SetDegradation:
+//
+//	recs := faultinject.Degrade(ctx, "recommendations", recs, func() []Item { return nil })
+func Degrade[T any](ctx context.Context, key string, v T, degraded func() T) T {
+	if fired, _ := std.evaluateWithContext(ctx, key); fired {
+		return degraded()
+	}
+	return v
+}
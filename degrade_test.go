@@ -0,0 +1,81 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDegradationMiddleware(t *testing.T) {
+	resetState()
+	tests := []struct {
+		name         string
+		degradation  Degradation
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "no degradation fails", expectedCode: http.StatusInternalServerError, expectedBody: "Injected failure\n"},
+		{name: "default status", degradation: Degradation{Body: `{"items":[]}`}, expectedCode: http.StatusPartialContent, expectedBody: `{"items":[]}`},
+		{name: "custom status", degradation: Degradation{Status: http.StatusOK, Header: map[string]string{"X-Cache": "stale"}, Body: "cached"}, expectedCode: http.StatusOK, expectedBody: "cached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			in.SetFailures("recs", 1)
+			in.SetDegradation("recs", tt.degradation)
+			handler := in.HTTPMiddleware("recs")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("full"))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/recs", nil))
+			if rec.Code != tt.expectedCode || rec.Body.String() != tt.expectedBody {
+				t.Errorf("Expected %d %q, got %d %q", tt.expectedCode, tt.expectedBody, rec.Code, rec.Body.String())
+			}
+			for name, value := range tt.degradation.Header {
+				if got := rec.Header().Get(name); got != value {
+					t.Errorf("Expected header %s: %s, got %q", name, value, got)
+				}
+			}
+
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/recs", nil))
+			if rec.Body.String() != "full" {
+				t.Errorf("Expected the full response once the rule is exhausted, got %q", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestDegrade(t *testing.T) {
+	resetState()
+	SetNthFailure("recs", 2)
+	full := []string{"a", "b"}
+	empty := func() []string { return nil }
+	if got := Degrade(context.Background(), "recs", full, empty); len(got) != 2 {
+		t.Errorf("Expected the full result on call 1, got %v", got)
+	}
+	if got := Degrade(context.Background(), "recs", full, empty); got != nil {
+		t.Errorf("Expected the degraded result on call 2, got %v", got)
+	}
+}
+
+func TestDegradationSpec(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	spec := "failures:\n  recs: 1\ndegradations:\n  recs: {status: 203, body: cached}\n"
+	if err := in.LoadSpecFromBytes([]byte(spec), FormatYAML); err != nil {
+		t.Fatalf("LoadSpecFromBytes returned error: %v", err)
+	}
+	d, ok := in.degradationFor("recs")
+	if !ok || d.Status != 203 || d.Body != "cached" {
+		t.Errorf("Expected the degradation from the spec, got %+v", d)
+	}
+	in.SetDegradation("recs", Degradation{})
+	if _, ok := in.degradationFor("recs"); ok {
+		t.Error("Expected a zero Degradation to remove it")
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DirectiveHeader is the request header that carries fault directives
+// between services, whatever language or fault injection tool they use.
+//
+// The header value is a comma-separated list of directives. A directive is a
+// semicolon-separated list of name=value parameters:
+//
+//	X-Go-FI: key=db-insert;mode=nth;n=2;latency=200ms, key=cache-get;mode=off
+//
+// The parameters are:
+//
+//	key      the fault key; required
+//	mode     on, off, first-n, nth or rate; on if absent
+//	n        the number of calls to fail for first-n, or the call to fail for nth
+//	rate     the probability, from 0 to 1, that each call fails for rate
+//	latency  a delay such as 200ms or 1.5s, added by InjectLatency
+//	status   the HTTP status to respond with when the key fires
+//
+// Calls are counted per request, so "mode=nth;n=2" fails the second call to
+// the key made while handling the request. Names are case-insensitive and
+// whitespace around names, values and separators is ignored. Values
+// containing ",", ";", "=", "%" or whitespace are percent-encoded. Unknown
+// parameters are ignored, so newer senders can talk to older receivers.
+const DirectiveHeader = "X-Go-FI"
+
+// DirectiveMode selects which calls a directive fails.
+type DirectiveMode string
+
+const (
+	DirectiveOn     DirectiveMode = "on"      // every call fails
+	DirectiveOff    DirectiveMode = "off"     // no call fails, whatever the key's rule
+	DirectiveFirstN DirectiveMode = "first-n" // the first N calls fail
+	DirectiveNth    DirectiveMode = "nth"     // only call number N fails
+	DirectiveRate   DirectiveMode = "rate"    // each call fails with probability Rate
+)
+
+// Directive is one fault carried by DirectiveHeader.
+type Directive struct {
+	Key     string
+	Mode    DirectiveMode // DirectiveOn if empty
+	N       int
+	Rate    float64
+	Latency time.Duration
+	Status  int
+}
+
+// ParseDirectives parses a DirectiveHeader value. An empty value has no
+// directives.
+func ParseDirectives(value string) ([]Directive, error) {
+	var ds []Directive
+	for i, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		d, err := parseDirective(entry)
+		if err != nil {
+			return nil, fmt.Errorf("directive %d: %w", i+1, err)
+		}
+		ds = append(ds, d)
+	}
+	return ds, nil
+}
+
+func parseDirective(entry string) (Directive, error) {
+	var d Directive
+	for _, param := range strings.Split(entry, ";") {
+		if strings.TrimSpace(param) == "" {
+			continue
+		}
+		name, raw, ok := strings.Cut(param, "=")
+		if !ok {
+			return d, fmt.Errorf("parameter %q has no value", strings.TrimSpace(param))
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value, err := url.PathUnescape(strings.TrimSpace(raw))
+		if err != nil {
+			return d, fmt.Errorf("%s: %w", name, err)
+		}
+		switch name {
+		case "key":
+			d.Key = value
+		case "mode":
+			d.Mode = DirectiveMode(strings.ToLower(value))
+		case "n":
+			d.N, err = strconv.Atoi(value)
+		case "rate":
+			d.Rate, err = strconv.ParseFloat(value, 64)
+		case "latency":
+			d.Latency, err = time.ParseDuration(value)
+		case "status":
+			d.Status, err = strconv.Atoi(value)
+		}
+		if err != nil {
+			return d, fmt.Errorf("invalid %s %q", name, value)
+		}
+	}
+	return d, d.validate()
+}
+
+func (d Directive) validate() error {
+	if d.Key == "" {
+		return fmt.Errorf("missing key")
+	}
+	switch d.Mode {
+	case "", DirectiveOn, DirectiveOff, DirectiveFirstN, DirectiveNth:
+	case DirectiveRate:
+		if d.Rate < 0 || d.Rate > 1 {
+			return fmt.Errorf("rate %g is not between 0 and 1", d.Rate)
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", d.Mode)
+	}
+	if d.N < 0 || d.Latency < 0 || d.Status < 0 {
+		return fmt.Errorf("negative n, latency or status")
+	}
+	return nil
+}
+
+// String formats d as a DirectiveHeader entry, leaving out zero parameters.
+func (d Directive) String() string {
+	params := []string{"key=" + escapeDirective(d.Key)}
+	if d.Mode != "" {
+		params = append(params, "mode="+escapeDirective(string(d.Mode)))
+	}
+	if d.N != 0 {
+		params = append(params, "n="+strconv.Itoa(d.N))
+	}
+	if d.Rate != 0 {
+		params = append(params, "rate="+strconv.FormatFloat(d.Rate, 'g', -1, 64))
+	}
+	if d.Latency != 0 {
+		params = append(params, "latency="+d.Latency.String())
+	}
+	if d.Status != 0 {
+		params = append(params, "status="+strconv.Itoa(d.Status))
+	}
+	return strings.Join(params, ";")
+}
+
+// FormatDirectives formats ds as a DirectiveHeader value.
+func FormatDirectives(ds ...Directive) string {
+	entries := make([]string, len(ds))
+	for i, d := range ds {
+		entries[i] = d.String()
+	}
+	return strings.Join(entries, ", ")
+}
+
+// escapeDirective percent-encodes the characters that would end a value.
+func escapeDirective(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ',' || c == ';' || c == '=' || c == '%' || c <= ' ' || c == 0x7f:
+			fmt.Fprintf(&b, "%%%02X", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// directiveSet holds the directives of a context with their per-request
+// call counts.
+type directiveSet struct {
+	order []string
+	byKey map[string]*directiveState
+}
+
+type directiveState struct {
+	Directive
+	calls atomic.Int64
+}
+
+type directivesKey struct{}
+
+// WithDirectives returns a copy of ctx carrying ds, replacing any directives
+// already in ctx for the same keys. Context-aware checks of those keys are
+// decided by the directives instead of the keys' rules, counting calls made
+// with the returned context and contexts derived from it.
+func WithDirectives(ctx context.Context, ds ...Directive) context.Context {
+	set := &directiveSet{byKey: make(map[string]*directiveState)}
+	if prev := directivesFrom(ctx); prev != nil {
+		for _, key := range prev.order {
+			set.add(prev.byKey[key])
+		}
+	}
+	for _, d := range ds {
+		set.add(&directiveState{Directive: d})
+	}
+	return context.WithValue(ctx, directivesKey{}, set)
+}
+
+func (s *directiveSet) add(st *directiveState) {
+	if _, ok := s.byKey[st.Key]; !ok {
+		s.order = append(s.order, st.Key)
+	}
+	s.byKey[st.Key] = st
+}
+
+// DirectivesFromContext returns the directives carried by ctx, so they can
+// be passed on to downstream services with FormatDirectives.
+func DirectivesFromContext(ctx context.Context) []Directive {
+	set := directivesFrom(ctx)
+	if set == nil {
+		return nil
+	}
+	ds := make([]Directive, len(set.order))
+	for i, key := range set.order {
+		ds[i] = set.byKey[key].Directive
+	}
+	return ds
+}
+
+func directivesFrom(ctx context.Context) *directiveSet {
+	if ctx == nil {
+		return nil
+	}
+	set, _ := ctx.Value(directivesKey{}).(*directiveSet)
+	return set
+}
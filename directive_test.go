@@ -0,0 +1,154 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []Directive
+		wantErr  bool
+	}{
+		{name: "empty", value: ""},
+		{
+			name:     "single",
+			value:    "key=db-insert;mode=nth;n=2;latency=200ms",
+			expected: []Directive{{Key: "db-insert", Mode: DirectiveNth, N: 2, Latency: 200 * time.Millisecond}},
+		},
+		{
+			name:  "several with whitespace",
+			value: " key = db-insert ; MODE=rate; rate=0.25 ,key=cache-get;mode=off,",
+			expected: []Directive{
+				{Key: "db-insert", Mode: DirectiveRate, Rate: 0.25},
+				{Key: "cache-get", Mode: DirectiveOff},
+			},
+		},
+		{name: "escaped key", value: "key=a%2Cb%3Bc;status=503", expected: []Directive{{Key: "a,b;c", Status: 503}}},
+		{name: "unknown parameters ignored", value: "key=x;region=eu", expected: []Directive{{Key: "x"}}},
+		{name: "missing key", value: "mode=on", wantErr: true},
+		{name: "unknown mode", value: "key=x;mode=sometimes", wantErr: true},
+		{name: "bad number", value: "key=x;mode=nth;n=two", wantErr: true},
+		{name: "bad latency", value: "key=x;latency=200", wantErr: true},
+		{name: "rate out of range", value: "key=x;mode=rate;rate=2", wantErr: true},
+		{name: "parameter without value", value: "key=x;nth", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, err := ParseDirectives(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(ds, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, ds)
+			}
+		})
+	}
+}
+
+func TestFormatDirectives(t *testing.T) {
+	ds := []Directive{
+		{Key: "db-insert", Mode: DirectiveNth, N: 2, Latency: 200 * time.Millisecond},
+		{Key: "a,b; c=%", Mode: DirectiveRate, Rate: 0.5, Status: 503},
+		{Key: "cache-get"},
+	}
+	value := FormatDirectives(ds...)
+	expected := "key=db-insert;mode=nth;n=2;latency=200ms, key=a%2Cb%3B%20c%3D%25;mode=rate;rate=0.5;status=503, key=cache-get"
+	if value != expected {
+		t.Errorf("Expected %q, got %q", expected, value)
+	}
+	parsed, err := ParseDirectives(value)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, ds) {
+		t.Errorf("Expected the round trip to give %+v, got %+v", ds, parsed)
+	}
+}
+
+func TestWithDirectives(t *testing.T) {
+	resetState()
+	SetFailures("cache-get", 10)
+	ctx := WithDirectives(context.Background(),
+		Directive{Key: "db-insert", Mode: DirectiveNth, N: 2},
+		Directive{Key: "cache-get", Mode: DirectiveOff},
+	)
+
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{key: "db-insert", expected: false},
+		{key: "db-insert", expected: true},
+		{key: "db-insert", expected: false},
+		{key: "cache-get", expected: false},
+	}
+	for i, tt := range tests {
+		if got := InjectWithContext(ctx, tt.key); got != tt.expected {
+			t.Errorf("Call %d: expected %s to return %v, got %v", i+1, tt.key, tt.expected, got)
+		}
+	}
+	if !Inject("cache-get") {
+		t.Error("Expected calls without the directive to follow the rule")
+	}
+
+	ctx = WithDirectives(ctx, Directive{Key: "db-insert", Mode: DirectiveOn})
+	if ds := DirectivesFromContext(ctx); len(ds) != 2 || ds[0].Mode != DirectiveOn || ds[1].Key != "cache-get" {
+		t.Errorf("Expected db-insert replaced in place, got %+v", ds)
+	}
+}
+
+func TestDirectiveHeader(t *testing.T) {
+	resetState()
+	secret := []byte("s3cret")
+	var calls []bool
+	var latency time.Duration
+	handler := HTTPMiddleware("api", WithDirectiveHeader(secret))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, InjectWithContext(r.Context(), "db-insert"), InjectWithContext(r.Context(), "db-insert"))
+		latency, _ = InjectLatency(r.Context(), "db-insert")
+	}))
+
+	tests := []struct {
+		name     string
+		value    string
+		signed   bool
+		status   int
+		expected []bool
+		latency  time.Duration
+	}{
+		{name: "api fails with status", value: "key=api;status=503", signed: true, status: http.StatusServiceUnavailable},
+		{name: "nth call", value: "key=db-insert;mode=nth;n=2;latency=10ms", signed: true, status: http.StatusOK, expected: []bool{false, true}, latency: 10 * time.Millisecond},
+		{name: "unsigned ignored", value: "key=api", status: http.StatusOK, expected: []bool{false, false}},
+		{name: "malformed ignored", value: "key=api;mode=bogus", signed: true, status: http.StatusOK, expected: []bool{false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, latency = nil, 0
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(DirectiveHeader, tt.value)
+			if tt.signed {
+				r.Header.Set(DirectiveHeader+"-Signature", SignFaultHeader(secret, tt.value))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.expected != nil && !reflect.DeepEqual(calls, tt.expected) {
+				t.Errorf("Expected calls %v, got %v", tt.expected, calls)
+			}
+			if latency != tt.latency {
+				t.Errorf("Expected latency %v, got %v", tt.latency, latency)
+			}
+		})
+	}
+}
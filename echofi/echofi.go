@@ -0,0 +1,92 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package echofi injects faults into requests served by echo. Its
+// middleware responds like faultinject.HTTPMiddleware, including the
+// degradations and broken responses set for the key, and exposes the
+// request's route, as echo's Path, to selectors as the "route" attribute
+// and its path parameters as "param.<name>" attributes. Pass
+// faultinject.WithInjector to decide requests with an Injector other than
+// the default one.
+package echofi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Middleware injects the faults of key into every request.
+func Middleware(key string, opts ...faultinject.MiddlewareOption) echo.MiddlewareFunc {
+	return middleware(func(echo.Context) string { return key }, opts)
+}
+
+// RouteMiddleware injects faults into each request under a key of its own,
+// its method and route, such as "GET /users/:id", so one middleware on the
+// server covers every endpoint. Requests that match no route are passed on
+// untouched.
+func RouteMiddleware(opts ...faultinject.MiddlewareOption) echo.MiddlewareFunc {
+	return middleware(func(c echo.Context) string {
+		route := routeOf(c)
+		if route == "" {
+			return ""
+		}
+		return c.Request().Method + " " + route
+	}, opts)
+}
+
+type requestKey struct{}
+
+// request is the echo request the wrapped handler continues.
+type request struct {
+	c    echo.Context
+	next echo.HandlerFunc
+}
+
+func middleware(key func(echo.Context) string, opts []faultinject.MiddlewareOption) echo.MiddlewareFunc {
+	inject := faultinject.HTTPMiddlewareFunc(func(r *http.Request) string {
+		return key(r.Context().Value(requestKey{}).(*request).c)
+	}, opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := r.Context().Value(requestKey{}).(*request)
+		c := req.c
+		c.SetRequest(r)
+		if orig := c.Response(); w != orig {
+			// the handler's response is to be broken, so it must reach w
+			c.SetResponse(echo.NewResponse(w, c.Echo()))
+			defer c.SetResponse(orig)
+		}
+		// handle errors here, while the response still reaches w
+		if err := req.next(c); err != nil {
+			c.Error(err)
+		}
+	}))
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			attrs := faultinject.Attributes{}
+			if route := routeOf(c); route != "" {
+				attrs["route"] = route
+			}
+			values := c.ParamValues()
+			for i, name := range c.ParamNames() {
+				if name != "*" && i < len(values) {
+					attrs["param."+name] = values[i]
+				}
+			}
+			ctx := faultinject.WithAttributes(c.Request().Context(), attrs)
+			ctx = context.WithValue(ctx, requestKey{}, &request{c: c, next: next})
+			inject.ServeHTTP(c.Response(), c.Request().WithContext(ctx))
+			return nil
+		}
+	}
+}
+
+// routeOf returns the route c matched, or "" if it matched none, as with
+// requests echo answers with 404 or 405.
+func routeOf(c echo.Context) string {
+	if c.Get(echo.ContextKeyHeaderAllow) != nil {
+		return ""
+	}
+	return c.Path()
+}
@@ -0,0 +1,154 @@
+//go:build faultinject
+
+package echofi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func resetState() {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+}
+
+func newServer(mw echo.MiddlewareFunc, handled *int) *echo.Echo {
+	e := echo.New()
+	e.Use(mw)
+	e.GET("/users/:id", func(c echo.Context) error {
+		*handled++
+		return c.JSON(http.StatusOK, map[string]string{"id": c.Param("id")})
+	})
+	e.GET("/fail", func(c echo.Context) error {
+		*handled++
+		return echo.NewHTTPError(http.StatusTeapot, "no coffee")
+	})
+	return e
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	resetState()
+	var handled int
+	e := newServer(RouteMiddleware(), &handled)
+	faultinject.SetRule("GET /users/:id", faultinject.Rule{Mode: faultinject.ModeFirstN, Count: 1, Status: http.StatusServiceUnavailable})
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		expectedStatus  int
+		expectedHandled int
+	}{
+		{name: "route fires", method: http.MethodGet, path: "/users/1", expectedStatus: http.StatusServiceUnavailable, expectedHandled: 0},
+		{name: "route used up", method: http.MethodGet, path: "/users/2", expectedStatus: http.StatusOK, expectedHandled: 1},
+		{name: "handler error", method: http.MethodGet, path: "/fail", expectedStatus: http.StatusTeapot, expectedHandled: 2},
+		{name: "other method", method: http.MethodPost, path: "/users/1", expectedStatus: http.StatusMethodNotAllowed, expectedHandled: 2},
+		{name: "no route", method: http.MethodGet, path: "/missing", expectedStatus: http.StatusNotFound, expectedHandled: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+			if handled != tt.expectedHandled {
+				t.Errorf("Expected the handlers to have run %d times, got %d", tt.expectedHandled, handled)
+			}
+		})
+	}
+
+	status := faultinject.FullStatus()
+	for _, key := range []string{"POST /users/:id", "GET /missing", "GET "} {
+		if _, ok := status[key]; ok {
+			t.Errorf("Expected requests without a route not to be evaluated, got %q", key)
+		}
+	}
+}
+
+func TestMiddlewareAttributes(t *testing.T) {
+	resetState()
+	faultinject.RegisterSelector("user-7", faultinject.AttributeSelector("param.id", "7"))
+	faultinject.RegisterSelector("users-route", faultinject.AttributeSelector("route", "/users/:id"))
+	faultinject.SetRule("users", faultinject.Rule{Mode: faultinject.ModeRate, Rate: 1, Status: http.StatusBadGateway})
+	faultinject.SetSelectors("users", "user-7", "users-route")
+
+	var handled int
+	e := newServer(Middleware("users"), &handled)
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/users/7", expectedStatus: http.StatusBadGateway},
+		{path: "/users/8", expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareResponseFault(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("users", 2)
+	faultinject.SetResponseFault("users", faultinject.ResponseTruncate)
+
+	var handled int
+	e := newServer(Middleware("users"), &handled)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/users/7")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the handler's status, got %d", resp.StatusCode)
+	}
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected a truncated body, got %v", err)
+	}
+
+	// an error returned by the handler is broken too
+	resp, err = http.Get(srv.URL + "/fail")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTeapot {
+		t.Errorf("Expected the error's status, got %d", resp.StatusCode)
+	}
+	if _, err := io.ReadAll(resp.Body); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected a truncated body, got %v", err)
+	}
+}
+
+func TestWithInjector(t *testing.T) {
+	resetState()
+	in := faultinject.NewInjector()
+	var handled int
+	e := newServer(RouteMiddleware(faultinject.WithInjector(in)), &handled)
+	in.SetFailures("GET /users/:id", 1)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if rec.Code != http.StatusInternalServerError || handled != 0 {
+		t.Errorf("Expected status %d without the handler, got %d after %d runs", http.StatusInternalServerError, rec.Code, handled)
+	}
+	if _, ok := faultinject.FullStatus()["GET /users/:id"]; ok {
+		t.Error("Expected the default Injector not to be used")
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package faultinject
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInjected matches every error returned for an injected failure, so tests
+// can tell synthetic errors from real ones with errors.Is(err, ErrInjected).
+var ErrInjected = errors.New("injected failure")
+
+// InjectedError is returned by InjectWithError, InjectWithErrorf,
+// InjectWithContextError, Cleanup and the decorators when a failure is
+// injected. Use errors.As to inspect it.
+type InjectedError struct {
+	Key       string
+	Message   string
+	Code      string    // error code from the key's rule, such as "ECONNREFUSED"
+	Count     int       // call count the failure fired at, 0 for context overrides
+	Timestamp time.Time // when the failure was injected
+}
+
+// Error returns "injected failure: <message>", or "injected failure" when
+// there is no message.
+func (e *InjectedError) Error() string {
+	if e.Message == "" {
+		return ErrInjected.Error()
+	}
+	return ErrInjected.Error() + ": " + e.Message
+}
+
+// Is reports whether target is ErrInjected.
+func (e *InjectedError) Is(target error) bool {
+	return target == ErrInjected
+}
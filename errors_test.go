@@ -0,0 +1,102 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInjectedError(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		name    string
+		call    func() error
+		message string
+		count   int
+	}{
+		{
+			name:    "InjectWithError",
+			call:    func() error { return InjectWithError("db", "timeout") },
+			message: "injected failure: timeout",
+			count:   2,
+		},
+		{
+			name:    "InjectWithErrorf",
+			call:    func() error { return InjectWithErrorf("db", "timeout after %ds", 5) },
+			message: "injected failure: timeout after 5s",
+			count:   2,
+		},
+		{
+			name:    "InjectWithContextError",
+			call:    func() error { return InjectWithContextError(context.Background(), "db", "timeout") },
+			message: "injected failure: timeout",
+			count:   2,
+		},
+		{
+			name: "context override",
+			call: func() error {
+				ctx := WithFaultOverride(context.Background(), "db", true)
+				return InjectWithContextError(ctx, "db", "timeout")
+			},
+			message: "injected failure: timeout",
+			count:   0,
+		},
+		{
+			name:    "WithFaultInjection",
+			call:    func() error { return WithFaultInjection("db", func(int) error { return nil })(1) },
+			message: "injected failure",
+			count:   2,
+		},
+		{
+			name: "WithFaultInjectionContext",
+			call: func() error {
+				return WithFaultInjectionContext("db", func(int) error { return nil })(context.Background(), 1)
+			},
+			message: "injected failure",
+			count:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetNthFailure("db", 2)
+			Inject("db")
+
+			err := tt.call()
+			if !errors.Is(err, ErrInjected) {
+				t.Fatalf("Expected errors.Is(err, ErrInjected), got %v", err)
+			}
+			var injected *InjectedError
+			if !errors.As(err, &injected) {
+				t.Fatalf("Expected an *InjectedError, got %T", err)
+			}
+			if err.Error() != tt.message {
+				t.Errorf("Expected message %q, got %q", tt.message, err.Error())
+			}
+			if injected.Key != "db" || injected.Count != tt.count || !injected.Timestamp.Equal(now) {
+				t.Errorf("Expected key db, count %d and timestamp %s, got %+v", tt.count, now, injected)
+			}
+		})
+	}
+}
+
+func TestInjectedErrorWrapped(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+
+	err := InjectWithError("db", "timeout")
+	wrapped := errors.Join(errors.New("saving order"), err)
+	if !errors.Is(wrapped, ErrInjected) {
+		t.Error("Expected a wrapped injected error to match ErrInjected")
+	}
+	if errors.Is(errors.New("injected failure"), ErrInjected) {
+		t.Error("Expected a real error with the same text not to match ErrInjected")
+	}
+}
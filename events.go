@@ -0,0 +1,165 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+)
+
+// defaultEventLogLen is how many events the event log keeps unless changed by
+// SetEventLogSize.
+const defaultEventLogLen = 1024
+
+// Event is one decision in the event log, with where it was made.
+type Event struct {
+	Seq uint64 `json:"seq"` // position in the log, starting at 1; 0 if the log is disabled
+	Decision
+	Goroutine uint64 `json:"goroutine"`
+	// TraceID is the trace the call belonged to, set when a trace ID func
+	// is installed with SetTraceIDFunc, such as by otelfi.Install.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// EventQuery selects events from the event log. The zero EventQuery selects
+// every event.
+type EventQuery struct {
+	Key       string // only events for this key, if set
+	FiredOnly bool   // only events that fired
+	Since     uint64 // only events with a Seq after this one
+	Limit     int    // at most this many of the most recent matches, if set
+}
+
+// eventLog is a ring of the most recent events.
+type eventLog struct {
+	events []Event
+	size   int
+	oldest int    // index of the oldest event once the log is full
+	seq    uint64 // Seq of the last event
+}
+
+// add sets the Seq of e and adds it, unless the log is disabled.
+func (l *eventLog) add(e *Event) {
+	if l.size == 0 {
+		return
+	}
+	l.seq++
+	e.Seq = l.seq
+	if len(l.events) < l.size {
+		l.events = append(l.events, *e)
+		return
+	}
+	l.events[l.oldest] = *e
+	l.oldest = (l.oldest + 1) % l.size
+}
+
+// ordered returns the events oldest first.
+func (l *eventLog) ordered() []Event {
+	return append(append([]Event(nil), l.events[l.oldest:]...), l.events[:l.oldest]...)
+}
+
+// QueryEvents returns the events in the event log matching q, oldest first.
+// The log holds every decision for keys with a rule and every fault that
+// fired, including context overrides, across all keys, so the faults of an
+// experiment can be reconstructed afterwards in the order they happened.
+// Like Metrics, it survives Reset.
+func (in *Injector) QueryEvents(q EventQuery) []Event {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	var out []Event
+	for _, e := range in.events.ordered() {
+		if e.Seq <= q.Since || (q.Key != "" && e.Key != q.Key) || (q.FiredOnly && !e.Fired) {
+			continue
+		}
+		out = append(out, e)
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out
+}
+
+// QueryEvents calls QueryEvents on the default Injector.
+func QueryEvents(q EventQuery) []Event {
+	return std.QueryEvents(q)
+}
+
+// Events returns every event in the event log, oldest first.
+func (in *Injector) Events() []Event {
+	return in.QueryEvents(EventQuery{})
+}
+
+// Events calls Events on the default Injector.
+func Events() []Event {
+	return std.Events()
+}
+
+// SetEventLogSize sets how many events the event log keeps, dropping the
+// oldest beyond that. Zero disables the log.
+func (in *Injector) SetEventLogSize(n int) {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	events := in.events.ordered()
+	if len(events) > n {
+		events = events[len(events)-n:]
+	}
+	in.events.events = append(make([]Event, 0, n), events...)
+	in.events.size, in.events.oldest = n, 0
+}
+
+// SetEventLogSize calls SetEventLogSize on the default Injector.
+func SetEventLogSize(n int) {
+	std.SetEventLogSize(n)
+}
+
+// SetTraceIDFunc sets the func that returns the trace ID of a call's
+// context, recorded in Event.TraceID. A nil fn stops recording trace IDs.
+func (in *Injector) SetTraceIDFunc(fn func(ctx context.Context) string) {
+	if fn == nil {
+		in.traceID.Store(nil)
+		return
+	}
+	in.traceID.Store(&fn)
+}
+
+// SetTraceIDFunc calls SetTraceIDFunc on the default Injector.
+func SetTraceIDFunc(fn func(ctx context.Context) string) {
+	std.SetTraceIDFunc(fn)
+}
+
+// logEventLocked adds d to the event log and returns its Event. The
+// goroutine and trace are only looked up if the event is logged or passed to
+// OnInject hooks. It must be called with callsMu held.
+func (in *Injector) logEventLocked(ctx context.Context, d Decision) Event {
+	e := Event{Decision: d}
+	if in.events.size == 0 && !(d.Fired && in.hasInjectHooks()) {
+		return e
+	}
+	e.Goroutine = goroutineID()
+	if fn := in.traceID.Load(); fn != nil && ctx != nil {
+		e.TraceID = (*fn)(ctx)
+	}
+	in.events.add(&e)
+	return e
+}
+
+// goroutineID returns the ID of the calling goroutine, as printed in stack
+// traces, or 0 if it cannot be read.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,126 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-insert", 1)
+	in.Inject("db-insert")
+	in.Inject("db-insert")
+	in.Inject("no-rule")
+	in.InjectWithContext(WithFaultOverride(context.Background(), "cache-get", true), "cache-get")
+	in.Reset()
+
+	events := in.Events()
+	expected := []Decision{
+		{Key: "db-insert", Fired: true, Reason: ReasonFired, Count: 1},
+		{Key: "db-insert", Fired: false, Reason: ReasonExhausted, Count: 2},
+		{Key: "cache-get", Fired: true, Reason: ReasonOverride},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %+v", len(expected), events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		if got.Seq != uint64(i+1) || got.Key != e.Key || got.Fired != e.Fired || got.Reason != e.Reason || got.Count != e.Count {
+			t.Errorf("Expected event %d to be %+v, got %+v", i+1, e, got)
+		}
+		if got.Goroutine == 0 || got.Time.IsZero() {
+			t.Errorf("Expected a goroutine and time on event %d, got %+v", i+1, got)
+		}
+	}
+}
+
+func TestQueryEvents(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("a", 2)
+	in.SetFailures("b", 1)
+	for i := 0; i < 3; i++ {
+		in.Inject("a")
+		in.Inject("b")
+	}
+	// a1 b1 a2 b2 a3 b3, with a1, b1 and a2 fired
+
+	tests := []struct {
+		name     string
+		query    EventQuery
+		expected []uint64
+	}{
+		{name: "all", query: EventQuery{}, expected: []uint64{1, 2, 3, 4, 5, 6}},
+		{name: "key", query: EventQuery{Key: "b"}, expected: []uint64{2, 4, 6}},
+		{name: "fired", query: EventQuery{FiredOnly: true}, expected: []uint64{1, 2, 3}},
+		{name: "since", query: EventQuery{Since: 4}, expected: []uint64{5, 6}},
+		{name: "limit", query: EventQuery{Key: "a", Limit: 2}, expected: []uint64{3, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint64
+			for _, e := range in.QueryEvents(tt.query) {
+				got = append(got, e.Seq)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+					break
+				}
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	in.ControlHandler(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/events?key=a&fired=true", nil))
+	var events []Event
+	if err := json.NewDecoder(rec.Body).Decode(&events); err != nil || len(events) != 2 || events[1].Seq != 3 {
+		t.Errorf("Expected the fired events of a from /events, got %+v (%v)", events, err)
+	}
+	rec = httptest.NewRecorder()
+	in.ControlHandler(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/events?since=x", nil))
+	if rec.Code != 400 {
+		t.Errorf("Expected 400 for an invalid query, got %d", rec.Code)
+	}
+}
+
+func TestSetEventLogSize(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("a", 10)
+	in.SetEventLogSize(3)
+	for i := 0; i < 5; i++ {
+		in.Inject("a")
+	}
+	check := func(expected ...uint64) {
+		t.Helper()
+		events := in.Events()
+		if len(events) != len(expected) {
+			t.Fatalf("Expected seqs %v, got %+v", expected, events)
+		}
+		for i, e := range events {
+			if e.Seq != expected[i] {
+				t.Errorf("Expected seqs %v, got %+v", expected, events)
+				return
+			}
+		}
+	}
+	check(3, 4, 5)
+
+	in.SetEventLogSize(2)
+	check(4, 5)
+	in.Inject("a")
+	check(5, 6)
+
+	in.SetEventLogSize(0)
+	in.Inject("a")
+	check()
+}
@@ -47,7 +47,7 @@ func main() {
 
 	// Example 5: Context-aware injection
 	log.Println("5. Context-aware injection:")
-	ctx := context.WithValue(context.Background(), "faultinject:email-send", true)
+	ctx := faultinject.WithFaultOverride(context.Background(), "email-send", true)
 	if err := sendEmail(ctx, "test@example.com"); err != nil {
 		log.Printf("   Error: %v", err)
 	}
@@ -100,7 +100,7 @@ func processPayment() error {
 // Example 5: Context-aware injection
 func sendEmail(ctx context.Context, email string) error {
 	// Check context override first, then use Inject
-	if ctx.Value("faultinject:email-send") == true || faultinject.Inject("email-send") {
+	if faultinject.InjectWithContext(ctx, "email-send") {
 		return fmt.Errorf("email sending failed")
 	}
 	log.Printf("   Email sent to %s successfully", email)
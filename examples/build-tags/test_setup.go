@@ -1,7 +1,7 @@
 // Copyright 2025 Talina Shrotriya
 // SPDX-License-Identifier: Apache-2.0
 
-//go:build testing
+//go:build faultinject
 
 package main
 
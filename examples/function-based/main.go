@@ -65,9 +65,9 @@ func main() {
 		log.Printf("   Error: %v", err)
 	}
 
-	// Example 6: Build tag helpers with functions
+	// Example 6: InjectWithFn never calls fn when built without -tags faultinject
 	log.Println("6. Build tag helpers with functions:")
-	if err := faultinject.NoOpInjectWithFn("user-create", func() error {
+	if err := faultinject.InjectWithFn("user-create", func() error {
 		return fmt.Errorf("user creation failed")
 	}); err != nil {
 		log.Printf("   Error: %v", err)
@@ -65,7 +65,7 @@ func demonstrateSimplifiedApproaches() {
 
 	// 4. Context-based overrides
 	log.Println("4. Context-based overrides:")
-	ctx := context.WithValue(context.Background(), "faultinject:db-insert", true)
+	ctx := faultinject.WithFaultOverride(context.Background(), "db-insert", true)
 	if err := faultinject.InjectWithContextError(ctx, "db-insert", "database failure"); err != nil {
 		log.Printf("   Error: %v", err)
 	}
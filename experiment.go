@@ -0,0 +1,274 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Experiments keep teams sharing an environment from running chaos on the
+// same keys at once. Starting one leases its keys, exact or patterns, under a
+// name for a while; other experiments whose keys overlap cannot start until
+// it ends or its lease runs out, and the control server rejects updates to
+// its keys that do not name it in an X-Experiment header. Keys overlap when
+// they are equal or one is a pattern matching the other.
+
+// ErrExperimentLocked is wrapped by the errors StartExperiment returns when
+// another experiment holds overlapping keys.
+var ErrExperimentLocked = errors.New("faultinject: keys locked by another experiment")
+
+// Experiment is a lease on a set of keys.
+type Experiment struct {
+	Name    string    `json:"name"`
+	Keys    []string  `json:"keys"`
+	Expires time.Time `json:"expires"`
+}
+
+// ExperimentLockedError reports the experiment holding the keys another one
+// tried to lease.
+type ExperimentLockedError struct {
+	Holder Experiment
+}
+
+func (e *ExperimentLockedError) Error() string {
+	return fmt.Sprintf("faultinject: keys locked by experiment %q until %s", e.Holder.Name, e.Holder.Expires.Format(time.RFC3339))
+}
+
+func (e *ExperimentLockedError) Unwrap() error {
+	return ErrExperimentLocked
+}
+
+// StartExperiment leases keys to the experiment name for ttl. Starting an
+// experiment that is running again renews its lease with the new keys and
+// ttl. It returns an *ExperimentLockedError if another running experiment
+// holds overlapping keys.
+func (in *Injector) StartExperiment(name string, keys []string, ttl time.Duration) (Experiment, error) {
+	e, _, err := in.startExperiment(name, keys, ttl)
+	return e, err
+}
+
+// StartExperiment calls StartExperiment on the default Injector.
+func StartExperiment(name string, keys []string, ttl time.Duration) (Experiment, error) {
+	return std.StartExperiment(name, keys, ttl)
+}
+
+// WaitExperiment is StartExperiment, but queues behind the experiments
+// holding overlapping keys until they end, their leases run out, or ctx is
+// done.
+func (in *Injector) WaitExperiment(ctx context.Context, name string, keys []string, ttl time.Duration) (Experiment, error) {
+	for {
+		e, freed, err := in.startExperiment(name, keys, ttl)
+		var locked *ExperimentLockedError
+		if !errors.As(err, &locked) {
+			return e, err
+		}
+		t := time.NewTimer(locked.Holder.Expires.Sub(in.timeNow()))
+		select {
+		case <-freed:
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return Experiment{}, err
+		}
+		t.Stop()
+	}
+}
+
+// WaitExperiment calls WaitExperiment on the default Injector.
+func WaitExperiment(ctx context.Context, name string, keys []string, ttl time.Duration) (Experiment, error) {
+	return std.WaitExperiment(ctx, name, keys, ttl)
+}
+
+// EndExperiment releases the keys of the experiment name, leaving its faults
+// in place.
+func (in *Injector) EndExperiment(name string) {
+	in.experimentsMu.Lock()
+	defer in.experimentsMu.Unlock()
+	if _, ok := in.experiments[name]; ok {
+		delete(in.experiments, name)
+		in.freeExperimentsLocked()
+	}
+}
+
+// EndExperiment calls EndExperiment on the default Injector.
+func EndExperiment(name string) {
+	std.EndExperiment(name)
+}
+
+// Experiments returns the running experiments, by name.
+func (in *Injector) Experiments() []Experiment {
+	in.experimentsMu.Lock()
+	defer in.experimentsMu.Unlock()
+	in.expireExperimentsLocked()
+	out := make([]Experiment, 0, len(in.experiments))
+	for _, e := range in.experiments {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Experiments calls Experiments on the default Injector.
+func Experiments() []Experiment {
+	return std.Experiments()
+}
+
+// startExperiment leases keys to name, or returns the error naming the
+// holder and a channel closed when an experiment next ends.
+func (in *Injector) startExperiment(name string, keys []string, ttl time.Duration) (Experiment, <-chan struct{}, error) {
+	if name == "" || len(keys) == 0 || ttl <= 0 {
+		return Experiment{}, nil, errors.New("faultinject: an experiment needs a name, keys and a positive TTL")
+	}
+	in.experimentsMu.Lock()
+	defer in.experimentsMu.Unlock()
+	if err := in.experimentLockedLocked(name, keys); err != nil {
+		return Experiment{}, in.experimentsFreed, err
+	}
+	e := Experiment{Name: name, Keys: append([]string(nil), keys...), Expires: in.timeNow().Add(ttl)}
+	in.experiments[name] = e
+	return e, nil, nil
+}
+
+// experimentLocked returns an *ExperimentLockedError if an experiment other
+// than name holds keys overlapping keys.
+func (in *Injector) experimentLocked(name string, keys []string) error {
+	in.experimentsMu.Lock()
+	defer in.experimentsMu.Unlock()
+	return in.experimentLockedLocked(name, keys)
+}
+
+func (in *Injector) experimentLockedLocked(name string, keys []string) error {
+	in.expireExperimentsLocked()
+	for _, e := range in.experiments {
+		if e.Name != name && keysOverlap(e.Keys, keys) {
+			return &ExperimentLockedError{Holder: e}
+		}
+	}
+	return nil
+}
+
+// expireExperimentsLocked ends the experiments whose leases have run out.
+func (in *Injector) expireExperimentsLocked() {
+	now := in.timeNow()
+	for name, e := range in.experiments {
+		if !now.Before(e.Expires) {
+			delete(in.experiments, name)
+			in.freeExperimentsLocked()
+		}
+	}
+}
+
+func (in *Injector) freeExperimentsLocked() {
+	close(in.experimentsFreed)
+	in.experimentsFreed = make(chan struct{})
+}
+
+// keysOverlap reports whether a key or pattern in a could apply to the same
+// calls as one in b.
+func keysOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if keyOverlaps(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyOverlaps reports whether keys or patterns x and y could apply to the
+// same calls. Two patterns are taken to overlap unless their literal
+// prefixes or suffixes rule it out, so "api-*" and "*-db" overlap while
+// "payments.*" and "orders.*" do not; regular expressions always overlap
+// another pattern.
+func keyOverlaps(x, y string) bool {
+	if MatchKey(x, y) || MatchKey(y, x) {
+		return true
+	}
+	if compilePattern(x) == nil || compilePattern(y) == nil {
+		return false
+	}
+	if strings.HasPrefix(x, "^") || strings.HasPrefix(y, "^") {
+		return true
+	}
+	px, sx := globAffixes(x)
+	py, sy := globAffixes(y)
+	return (strings.HasPrefix(px, py) || strings.HasPrefix(py, px)) &&
+		(strings.HasSuffix(sx, sy) || strings.HasSuffix(sy, sx))
+}
+
+// globAffixes returns the literal text of glob before its first wildcard
+// and after its last.
+func globAffixes(glob string) (prefix, suffix string) {
+	return glob[:strings.IndexAny(glob, "*?")], glob[strings.LastIndexAny(glob, "*?")+1:]
+}
+
+// allowExperiment replies 409 Conflict and returns false if an experiment
+// other than the one named by r's X-Experiment header holds keys
+// overlapping keys.
+func (in *Injector) allowExperiment(w http.ResponseWriter, r *http.Request, keys ...string) bool {
+	err := in.experimentLocked(r.Header.Get("X-Experiment"), keys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// serveExperiments lists the running experiments on GET, starts one on POST
+// with ?name=, ?keys= and ?ttl=, queueing for up to ?wait=, and ends one on
+// DELETE with ?name=.
+func (in *Injector) serveExperiments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(in.Experiments())
+	case http.MethodPost:
+		var keys []string
+		if k := q.Get("keys"); k != "" {
+			keys = strings.Split(k, ",")
+		}
+		ttl, err := time.ParseDuration(q.Get("ttl"))
+		if err != nil {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return
+		}
+		if q.Get("name") == "" || len(keys) == 0 || ttl <= 0 {
+			http.Error(w, "an experiment needs a name, keys and a positive ttl", http.StatusBadRequest)
+			return
+		}
+		var wait time.Duration
+		if v := q.Get("wait"); v != "" {
+			if wait, err = time.ParseDuration(v); err != nil || wait < 0 {
+				http.Error(w, "invalid wait", http.StatusBadRequest)
+				return
+			}
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), min(wait, maxWatchTimeout))
+		defer cancel()
+		e, err := in.WaitExperiment(ctx, q.Get("name"), keys, ttl)
+		var locked *ExperimentLockedError
+		if errors.As(err, &locked) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(locked.Holder)
+			return
+		}
+		json.NewEncoder(w).Encode(e)
+	case http.MethodDelete:
+		in.EndExperiment(q.Get("name"))
+		w.Write([]byte("OK"))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
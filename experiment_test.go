@@ -0,0 +1,166 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStartExperiment(t *testing.T) {
+	resetState()
+	tests := []struct {
+		name        string
+		experiment  string
+		keys        []string
+		expectedErr bool
+	}{
+		{name: "same key", experiment: "team-b", keys: []string{"payments.charge"}, expectedErr: true},
+		{name: "pattern over held pattern", experiment: "team-b", keys: []string{"payments.*"}, expectedErr: true},
+		{name: "pattern over held key", experiment: "team-b", keys: []string{"*"}, expectedErr: true},
+		{name: "other keys", experiment: "team-b", keys: []string{"users.lookup"}, expectedErr: false},
+		{name: "renewal", experiment: "team-a", keys: []string{"payments.refund"}, expectedErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			if _, err := in.StartExperiment("team-a", []string{"payments.*"}, time.Minute); err != nil {
+				t.Fatalf("Expected the first experiment to start, got %v", err)
+			}
+			_, err := in.StartExperiment(tt.experiment, tt.keys, time.Minute)
+			if (err != nil) != tt.expectedErr {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrExperimentLocked) {
+				t.Errorf("Expected ErrExperimentLocked, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExperimentExpires(t *testing.T) {
+	resetState()
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	in := NewInjector()
+	if _, err := in.StartExperiment("team-a", []string{"db"}, time.Minute); err != nil {
+		t.Fatalf("Expected the experiment to start, got %v", err)
+	}
+	if _, err := in.StartExperiment("team-b", []string{"db"}, time.Minute); err == nil {
+		t.Errorf("Expected db to be locked by team-a")
+	}
+	now = now.Add(time.Minute)
+	if got := in.Experiments(); len(got) != 0 {
+		t.Errorf("Expected the lease to have run out, got %v", got)
+	}
+	if _, err := in.StartExperiment("team-b", []string{"db"}, time.Minute); err != nil {
+		t.Errorf("Expected db to be free once the lease ran out, got %v", err)
+	}
+}
+
+func TestWaitExperiment(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.StartExperiment("team-a", []string{"db"}, time.Minute)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := in.WaitExperiment(context.Background(), "team-b", []string{"db"}, time.Minute)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("Expected team-b to queue behind team-a, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	in.EndExperiment("team-a")
+	if err := <-done; err != nil {
+		t.Errorf("Expected team-b to start once team-a ended, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := in.WaitExperiment(ctx, "team-c", []string{"db"}, time.Minute); !errors.Is(err, ErrExperimentLocked) {
+		t.Errorf("Expected ErrExperimentLocked once ctx is done, got %v", err)
+	}
+}
+
+func TestExperimentControlServer(t *testing.T) {
+	resetState()
+	srv := httptest.NewServer(ControlHandler(nil))
+	defer srv.Close()
+	defer EndExperiment("team-a")
+
+	do := func(method, path, experiment string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		if experiment != "" {
+			req.Header.Set("X-Experiment", experiment)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		experiment   string
+		expectedCode int
+	}{
+		{name: "start", method: http.MethodPost, path: "/experiments?name=team-a&keys=payments.*&ttl=1m", expectedCode: http.StatusOK},
+		{name: "overlapping start", method: http.MethodPost, path: "/experiments?name=team-b&keys=payments.charge&ttl=1m", expectedCode: http.StatusConflict},
+		{name: "missing ttl", method: http.MethodPost, path: "/experiments?name=team-b&keys=users", expectedCode: http.StatusBadRequest},
+		{name: "set without experiment", method: http.MethodPost, path: "/set?key=payments.charge&count=1", expectedCode: http.StatusConflict},
+		{name: "set by holder", method: http.MethodPost, path: "/set?key=payments.charge&count=1", experiment: "team-a", expectedCode: http.StatusOK},
+		{name: "set other key", method: http.MethodPost, path: "/set?key=users&count=1", expectedCode: http.StatusOK},
+		{name: "reset without experiment", method: http.MethodPost, path: "/reset", expectedCode: http.StatusConflict},
+		{name: "end", method: http.MethodDelete, path: "/experiments?name=team-a", expectedCode: http.StatusOK},
+		{name: "set once ended", method: http.MethodPost, path: "/set?key=payments.charge&count=1", expectedCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		if got := do(tt.method, tt.path, tt.experiment); got != tt.expectedCode {
+			t.Errorf("%s: Expected %d, got %d", tt.name, tt.expectedCode, got)
+		}
+	}
+	if got := Status()["payments.charge"]; got != 1 {
+		t.Errorf("Expected payments.charge: 1, got %d", got)
+	}
+}
+
+func TestKeysOverlap(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "payments.charge", b: "payments.charge", expected: true},
+		{a: "payments.charge", b: "payments.refund", expected: false},
+		{a: "payments.*", b: "payments.charge", expected: true},
+		{a: "api-*", b: "*-db", expected: true},
+		{a: "payments.*", b: "orders.*", expected: false},
+		{a: "*.charge", b: "*.refund", expected: false},
+		{a: "pay*", b: "payments.?harge", expected: true},
+		{a: "api-*-v1", b: "api-*-v2", expected: false},
+		{a: `^orders\.`, b: "payments.*", expected: true},
+		{a: `^orders\.`, b: "payments.charge", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := keysOverlap([]string{tt.a}, []string{tt.b}); got != tt.expected {
+				t.Errorf("Expected overlap=%v, got %v", tt.expected, got)
+			}
+			if got := keysOverlap([]string{tt.b}, []string{tt.a}); got != tt.expected {
+				t.Errorf("Expected overlap=%v both ways, got %v", tt.expected, got)
+			}
+		})
+	}
+}
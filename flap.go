@@ -0,0 +1,79 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"net/http"
+	"time"
+)
+
+// FlapCycle describes a key that is unhealthy for the first Unhealthy of
+// every Period, e.g. unhealthy 20s of every 60s.
+type FlapCycle struct {
+	Period    time.Duration `yaml:"period" json:"period"`
+	Unhealthy time.Duration `yaml:"unhealthy" json:"unhealthy"`
+}
+
+// SetFlapping makes key fail for the first cycle.Unhealthy of every
+// cycle.Period, starting now. It drives load balancer and orchestrator
+// behavior that a single failure never triggers, such as instances being
+// ejected and readmitted. It replaces any other mode for the key, and a zero
+// Period removes it. Fault injection is disabled in production environments.
+func (in *Injector) SetFlapping(key string, cycle FlapCycle) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setFlappingLocked(key, cycle)
+}
+
+// SetFlapping calls SetFlapping on the default Injector.
+func SetFlapping(key string, cycle FlapCycle) {
+	std.SetFlapping(key, cycle)
+}
+
+func (in *Injector) setFlappingLocked(key string, cycle FlapCycle) {
+	in.updateRuleLocked(key, func(r *Rule) {
+		if cycle.Period <= 0 {
+			if r.Mode == ModeFlapping {
+				r.setMode(ModeNone, in.timeNow())
+			}
+			return
+		}
+		r.setMode(ModeFlapping, in.timeNow())
+		r.Flap = &cycle
+	})
+}
+
+// unhealthy reports whether r's cycle is in its unhealthy phase at now.
+func (r Rule) unhealthy(now time.Time) bool {
+	elapsed := now.Sub(r.start)
+	if r.Flap == nil || r.Flap.Period <= 0 || elapsed < 0 {
+		return false
+	}
+	return elapsed%r.Flap.Period < r.Flap.Unhealthy
+}
+
+// HealthHandler wraps a health check endpoint, answering 503 Service
+// Unavailable whenever key fires. Combined with SetFlapping it gives a health
+// check that flaps on a duty cycle.
+func (in *Injector) HealthHandler(key string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithAttributes(r.Context(), in.requestAttributes(r))
+		if in.InjectWithContext(ctx, key) {
+			http.Error(w, "unhealthy: injected failure", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// HealthHandler calls HealthHandler on the default Injector.
+func HealthHandler(key string, next http.Handler) http.Handler {
+	return std.HealthHandler(key, next)
+}
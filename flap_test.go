@@ -0,0 +1,108 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetFlapping(t *testing.T) {
+	start := time.Now()
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		elapsed  time.Duration
+		expected bool
+	}{
+		{elapsed: 0, expected: true},
+		{elapsed: 19 * time.Second, expected: true},
+		{elapsed: 20 * time.Second, expected: false},
+		{elapsed: 59 * time.Second, expected: false},
+		{elapsed: 60 * time.Second, expected: true},
+		{elapsed: 85 * time.Second, expected: false},
+		{elapsed: 10*time.Minute + 5*time.Second, expected: true},
+	}
+
+	resetState()
+	SetFlapping("health", FlapCycle{Period: time.Minute, Unhealthy: 20 * time.Second})
+	for _, tt := range tests {
+		now = start.Add(tt.elapsed)
+		if got := Inject("health"); got != tt.expected {
+			t.Errorf("At %s: expected %v, got %v", tt.elapsed, tt.expected, got)
+		}
+	}
+
+	h := History("health")
+	if h[2].Reason != ReasonHealthy || h[0].Reason != ReasonFired {
+		t.Errorf("Expected fired and healthy-phase reasons, got %v", h)
+	}
+
+	SetFlapping("health", FlapCycle{})
+	now = start.Add(time.Minute)
+	if Inject("health") {
+		t.Error("Expected a zero period to remove the flapping rule")
+	}
+}
+
+func TestSetFlappingProduction(t *testing.T) {
+	resetState()
+	t.Setenv("ENVIRONMENT", "production")
+	SetFlapping("health", FlapCycle{Period: time.Minute, Unhealthy: time.Minute})
+	t.Setenv("ENVIRONMENT", "development")
+	if Inject("health") {
+		t.Error("Expected SetFlapping to be ignored in production")
+	}
+}
+
+func TestFlappingSpec(t *testing.T) {
+	resetState()
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	content := "flapping:\n  health:\n    period: 60s\n    unhealthy: 20s\n"
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := LoadSpec(spec); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	cycle := Rules()["health"].Flap
+	if cycle == nil || *cycle != (FlapCycle{Period: time.Minute, Unhealthy: 20 * time.Second}) {
+		t.Errorf("Expected 20s of every 60s, got %+v", cycle)
+	}
+}
+
+func TestHealthHandler(t *testing.T) {
+	start := time.Now()
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	resetState()
+	SetFlapping("health", FlapCycle{Period: time.Minute, Unhealthy: 20 * time.Second})
+	handler := HealthHandler("health", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		elapsed  time.Duration
+		expected int
+	}{
+		{elapsed: 5 * time.Second, expected: http.StatusServiceUnavailable},
+		{elapsed: 30 * time.Second, expected: http.StatusOK},
+		{elapsed: 65 * time.Second, expected: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		now = start.Add(tt.elapsed)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if rec.Code != tt.expected {
+			t.Errorf("At %s: expected status %d, got %d", tt.elapsed, tt.expected, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"sort"
+	"time"
+)
+
+// GCOptions controls what Prune removes.
+type GCOptions struct {
+	// PruneExhausted removes rules that can no longer fire: first-N rules whose
+	// failures are used up, precise rules past their Nth call, rules with a
+	// count of zero or less, and rules whose TTL has passed. Rules with
+	// latency are kept until their TTL passes.
+	PruneExhausted bool
+	// MaxIdle removes counters of keys without a rule that have not been called for this long. Zero disables.
+	MaxIdle time.Duration
+	// MaxCounters keeps at most this many counters of keys without a rule,
+	// evicting the least recently called. Zero means unlimited.
+	MaxCounters int
+}
+
+// touchLocked records that key was configured at now.
+func (in *Injector) touchLocked(key string, now time.Time) {
+	in.markKnownLocked(key)
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	in.lastSeen[key] = now
+}
+
+// setKeyedLocked sets key's entry of m, one of the per-key fault maps, to v,
+// or removes it if remove is set.
+func setKeyedLocked[V any](in *Injector, m map[string]V, key string, v V, remove bool) {
+	in.bumpGenerationLocked()
+	if remove {
+		delete(m, key)
+		return
+	}
+	m[key] = v
+	in.touchLocked(key, in.timeNow())
+}
+
+// Prune removes dead rules and stale counters according to opts and returns
+// how many keys were removed. Active rules are never removed, nor are a key's
+// other settings, such as its corruption or selectors.
+func (in *Injector) Prune(opts GCOptions) int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+
+	removed := 0
+	if opts.PruneExhausted {
+		for key := range in.rules {
+			if in.exhaustedLocked(key) {
+				in.pruneRuleLocked(key)
+				removed++
+			}
+		}
+	}
+
+	// candidates are counters that no rule depends on
+	var idle []string
+	for key := range in.counters {
+		if !in.hasFaultRuleLocked(in.ruleKeyLocked(key)) {
+			idle = append(idle, key)
+		}
+	}
+
+	if opts.MaxIdle > 0 {
+		cutoff := in.timeNow().Add(-opts.MaxIdle)
+		kept := idle[:0]
+		for _, key := range idle {
+			if in.lastSeen[key].Before(cutoff) {
+				in.pruneCallsLocked(key)
+				removed++
+				continue
+			}
+			kept = append(kept, key)
+		}
+		idle = kept
+	}
+
+	if opts.MaxCounters > 0 && len(idle) > opts.MaxCounters {
+		sort.Slice(idle, func(i, j int) bool {
+			return in.lastSeen[idle[i]].Before(in.lastSeen[idle[j]])
+		})
+		for _, key := range idle[:len(idle)-opts.MaxCounters] {
+			in.pruneCallsLocked(key)
+			removed++
+		}
+	}
+	if removed > 0 {
+		in.bumpGenerationLocked()
+	}
+	return removed
+}
+
+// Prune calls Prune on the default Injector.
+func Prune(opts GCOptions) int {
+	return std.Prune(opts)
+}
+
+// StartGC runs Prune every interval until the returned stop func is called.
+func (in *Injector) StartGC(interval time.Duration, opts GCOptions) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				in.Prune(opts)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// StartGC calls StartGC on the default Injector.
+func StartGC(interval time.Duration, opts GCOptions) (stop func()) {
+	return std.StartGC(interval, opts)
+}
+
+// exhaustedLocked reports whether key's rule can no longer fire. A rule
+// with latency is live until it expires, as InjectLatency does not depend on
+// its failure mode.
+func (in *Injector) exhaustedLocked(key string) bool {
+	r, ok := in.rules[key]
+	if !ok {
+		return false
+	}
+	if r.expired(in.timeNow()) {
+		return true
+	}
+	if len(r.Latency) > 0 {
+		return false
+	}
+	cnt := in.counters[key]
+	switch r.Mode {
+	case ModeNth:
+		last := r.lastNthCall()
+		return last == 0 || last > 0 && cnt >= last
+	case ModeFirstN:
+		return r.Count <= 0 || cnt >= r.Count
+	}
+	return false
+}
+
+// pruneRuleLocked removes key's rule and its call state, keeping the other
+// settings held for key. callsMu must be held as well as mu.
+func (in *Injector) pruneRuleLocked(key string) {
+	delete(in.rules, key)
+	delete(in.conflicts, key)
+	if _, ok := in.skews[key]; !ok {
+		in.unregisterPatternLocked(key)
+	}
+	in.pruneCallsLocked(key)
+}
+
+// pruneCallsLocked removes the call state of key: its counter, history, when
+// it was last called and its budget spending. callsMu must be held.
+func (in *Injector) pruneCallsLocked(key string) {
+	delete(in.counters, key)
+	delete(in.history, key)
+	delete(in.lastSeen, key)
+	delete(in.budgetFires, key)
+}
+
+// deleteKeyLocked removes every piece of state held for key. callsMu must be
+// held as well as mu.
+func (in *Injector) deleteKeyLocked(key string) {
+	delete(in.rules, key)
+	delete(in.counters, key)
+	delete(in.selectors, key)
+	delete(in.tenants, key)
+	delete(in.excludeSynthetic, key)
+	delete(in.history, key)
+	delete(in.lastSeen, key)
+	delete(in.budgetFires, key)
+	delete(in.skews, key)
+	delete(in.corruptions, key)
+	delete(in.numericEdges, key)
+	delete(in.pageFaults, key)
+	delete(in.idempotencyFaults, key)
+	delete(in.quotaResponses, key)
+	delete(in.sseFaults, key)
+	delete(in.degradations, key)
+	delete(in.responseFaults, key)
+	delete(in.connFaults, key)
+	delete(in.bandwidths, key)
+	delete(in.conflicts, key)
+	in.unregisterPatternLocked(key)
+}
@@ -0,0 +1,155 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPruneExhausted(t *testing.T) {
+	resetState()
+
+	SetFailures("used-up", 1)
+	Inject("used-up")
+	SetFailures("active", 2)
+	Inject("active")
+	SetFailures("disabled", 0)
+	SetNthFailure("nth-passed", 1)
+	Inject("nth-passed")
+	SetNthFailure("nth-pending", 3)
+	Inject("nth-pending")
+
+	if removed := Prune(GCOptions{PruneExhausted: true}); removed != 3 {
+		t.Errorf("Expected 3 rules removed, got %d", removed)
+	}
+
+	status := Status()
+	if _, ok := status["used-up"]; ok {
+		t.Error("Expected used-up rule to be pruned")
+	}
+	if status["active"] != 1 {
+		t.Errorf("Expected active rule to be kept, got %v", status)
+	}
+	Inject("nth-pending")
+	if !Inject("nth-pending") {
+		t.Error("Expected pending precise rule to be kept and fire on its 3rd call")
+	}
+}
+
+func TestPruneKeepsLatency(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	SetLatency("db", FixedLatency(5*time.Millisecond))
+	Inject("db")
+	Inject("db")
+
+	if removed := Prune(GCOptions{PruneExhausted: true}); removed != 0 {
+		t.Errorf("Expected no rules removed, got %d", removed)
+	}
+	if d, _ := InjectLatency(context.Background(), "db"); d != 5*time.Millisecond {
+		t.Errorf("Expected the latency to outlast the used-up failures, got %v", d)
+	}
+}
+
+func TestPruneKeepsSettings(t *testing.T) {
+	resetState()
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	SetFailures("db", 1)
+	SetCorruption("db", CorruptBOM)
+	Inject("db")
+	SetCorruption("cache", CorruptMojibake)
+	Inject("cache")
+	now = now.Add(time.Hour)
+
+	if removed := Prune(GCOptions{PruneExhausted: true, MaxIdle: time.Minute}); removed != 2 {
+		t.Errorf("Expected 2 keys removed, got %d", removed)
+	}
+	if _, ok := Status()["db"]; ok {
+		t.Error("Expected the used-up rule to be pruned")
+	}
+	corruptions := Snapshot().Corruptions
+	for key, expected := range map[string]Corruption{"db": CorruptBOM, "cache": CorruptMojibake} {
+		if got := corruptions[key]; got != expected {
+			t.Errorf("Expected %s to keep corruption %q, got %q", key, expected, got)
+		}
+	}
+}
+
+func TestPruneIdleCounters(t *testing.T) {
+	resetState()
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	Inject("old-unconfigured")
+	SetFailures("old-configured", 5)
+	Inject("old-configured")
+
+	now = now.Add(time.Hour)
+	Inject("recent-unconfigured")
+
+	if removed := Prune(GCOptions{MaxIdle: 30 * time.Minute}); removed != 1 {
+		t.Errorf("Expected 1 counter removed, got %d", removed)
+	}
+
+	std.callsMu.Lock()
+	_, oldKept := std.counters["old-unconfigured"]
+	_, recentKept := std.counters["recent-unconfigured"]
+	_, configuredKept := std.counters["old-configured"]
+	std.callsMu.Unlock()
+
+	if oldKept || !recentKept || !configuredKept {
+		t.Errorf("Unexpected counters kept: old=%v recent=%v configured=%v", oldKept, recentKept, configuredKept)
+	}
+}
+
+func TestPruneMaxCounters(t *testing.T) {
+	resetState()
+	defer func() { timeNow = time.Now }()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		now = now.Add(time.Second)
+		Inject(fmt.Sprintf("key-%d", i))
+	}
+
+	if removed := Prune(GCOptions{MaxCounters: 4}); removed != 6 {
+		t.Errorf("Expected 6 counters evicted, got %d", removed)
+	}
+
+	std.callsMu.Lock()
+	defer std.callsMu.Unlock()
+	for i := 0; i < 10; i++ {
+		_, ok := std.counters[fmt.Sprintf("key-%d", i)]
+		if ok != (i >= 6) {
+			t.Errorf("key-%d kept=%v, expected only the 4 most recent to be kept", i, ok)
+		}
+	}
+}
+
+func TestStartGC(t *testing.T) {
+	resetState()
+	SetFailures("gc-fault", 1)
+	Inject("gc-fault")
+
+	stop := StartGC(5*time.Millisecond, GCOptions{PruneExhausted: true})
+	defer stop()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, ok := Status()["gc-fault"]; !ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected background GC to prune the exhausted rule")
+}
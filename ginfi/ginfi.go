@@ -0,0 +1,134 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ginfi injects faults into requests served by gin. Its middleware
+// responds like faultinject.HTTPMiddleware, including the degradations and
+// broken responses set for the key, and exposes the request's route, as
+// gin's FullPath, to selectors as the "route" attribute and its path
+// parameters as "param.<name>" attributes. Pass faultinject.WithInjector to
+// decide requests with an Injector other than the default one.
+package ginfi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Middleware injects the faults of key into every request.
+func Middleware(key string, opts ...faultinject.MiddlewareOption) gin.HandlerFunc {
+	return middleware(func(*gin.Context) string { return key }, opts)
+}
+
+// RouteMiddleware injects faults into each request under a key of its own,
+// its method and route, such as "GET /users/:id", so one middleware on the
+// engine covers every endpoint. Requests that match no route are passed on
+// untouched.
+func RouteMiddleware(opts ...faultinject.MiddlewareOption) gin.HandlerFunc {
+	return middleware(func(c *gin.Context) string {
+		if c.FullPath() == "" {
+			return ""
+		}
+		return c.Request.Method + " " + c.FullPath()
+	}, opts)
+}
+
+type requestKey struct{}
+
+// request is the gin request the wrapped handler continues.
+type request struct {
+	c    *gin.Context
+	next bool // the fault did not fire, and the handlers ran
+}
+
+func middleware(key func(*gin.Context) string, opts []faultinject.MiddlewareOption) gin.HandlerFunc {
+	inject := faultinject.HTTPMiddlewareFunc(func(r *http.Request) string {
+		return key(r.Context().Value(requestKey{}).(*request).c)
+	}, opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := r.Context().Value(requestKey{}).(*request)
+		req.next = true
+		c := req.c
+		c.Request = r
+		if w != c.Writer {
+			// the handler's response is to be broken, so it must reach w
+			orig := c.Writer
+			c.Writer = &responseWriter{ResponseWriter: orig, w: w, status: http.StatusOK, size: -1}
+			defer func() { c.Writer = orig }()
+		}
+		c.Next()
+	}))
+	return func(c *gin.Context) {
+		attrs := faultinject.Attributes{}
+		if route := c.FullPath(); route != "" {
+			attrs["route"] = route
+		}
+		for _, p := range c.Params {
+			attrs["param."+p.Key] = p.Value
+		}
+		req := &request{c: c}
+		ctx := context.WithValue(faultinject.WithAttributes(c.Request.Context(), attrs), requestKey{}, req)
+		inject.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+		if !req.next {
+			c.Abort()
+		}
+	}
+}
+
+// responseWriter is a gin.ResponseWriter writing to w, which delays the
+// status until the body is written as gin's own does, so handlers can set
+// headers after the status.
+type responseWriter struct {
+	gin.ResponseWriter
+	w      http.ResponseWriter
+	status int
+	size   int
+}
+
+func (rw *responseWriter) Header() http.Header {
+	return rw.w.Header()
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if code > 0 && !rw.Written() {
+		rw.status = code
+	}
+}
+
+func (rw *responseWriter) WriteHeaderNow() {
+	if !rw.Written() {
+		rw.size = 0
+		rw.w.WriteHeader(rw.status)
+	}
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.WriteHeaderNow()
+	n, err := rw.w.Write(b)
+	rw.size += n
+	return n, err
+}
+
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	return rw.Write([]byte(s))
+}
+
+func (rw *responseWriter) Status() int {
+	return rw.status
+}
+
+func (rw *responseWriter) Size() int {
+	return rw.size
+}
+
+func (rw *responseWriter) Written() bool {
+	return rw.size != -1
+}
+
+func (rw *responseWriter) Flush() {
+	rw.WriteHeaderNow()
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
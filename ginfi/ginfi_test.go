@@ -0,0 +1,127 @@
+//go:build faultinject
+
+package ginfi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func resetState() {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+}
+
+func newEngine(mw gin.HandlerFunc, handled *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.Use(mw)
+	e.GET("/users/:id", func(c *gin.Context) {
+		*handled++
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "name": "alice"})
+	})
+	return e
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	resetState()
+	var handled int
+	e := newEngine(RouteMiddleware(), &handled)
+	faultinject.SetRule("GET /users/:id", faultinject.Rule{Mode: faultinject.ModeFirstN, Count: 1, Status: http.StatusServiceUnavailable})
+
+	tests := []struct {
+		name            string
+		path            string
+		expectedStatus  int
+		expectedHandled int
+	}{
+		{name: "route fires", path: "/users/1", expectedStatus: http.StatusServiceUnavailable, expectedHandled: 0},
+		{name: "route used up", path: "/users/2", expectedStatus: http.StatusOK, expectedHandled: 1},
+		{name: "no route", path: "/missing", expectedStatus: http.StatusNotFound, expectedHandled: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+			if handled != tt.expectedHandled {
+				t.Errorf("Expected the handler to have run %d times, got %d", tt.expectedHandled, handled)
+			}
+		})
+	}
+}
+
+func TestMiddlewareAttributes(t *testing.T) {
+	resetState()
+	faultinject.RegisterSelector("user-7", faultinject.AttributeSelector("param.id", "7"))
+	faultinject.RegisterSelector("users-route", faultinject.AttributeSelector("route", "/users/:id"))
+	faultinject.SetRule("users", faultinject.Rule{Mode: faultinject.ModeRate, Rate: 1, Status: http.StatusBadGateway})
+	faultinject.SetSelectors("users", "user-7", "users-route")
+
+	var handled int
+	e := newEngine(Middleware("users"), &handled)
+	tests := []struct {
+		path           string
+		expectedStatus int
+	}{
+		{path: "/users/7", expectedStatus: http.StatusBadGateway},
+		{path: "/users/8", expectedStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareResponseFault(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("users", 1)
+	faultinject.SetResponseFault("users", faultinject.ResponseDropHeaders)
+
+	var handled int
+	e := newEngine(Middleware("users"), &handled)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/7", nil))
+
+	if handled != 1 {
+		t.Errorf("Expected the handler to run once, got %d", handled)
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the handler's status, got %d", rec.Code)
+	}
+	if got := rec.Body.String(); got != `{"id":"7","name":"alice"}` {
+		t.Errorf("Expected the handler's body, got %q", got)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "" {
+		t.Errorf("Expected no Content-Type, got %q", ct)
+	}
+}
+
+func TestWithInjector(t *testing.T) {
+	resetState()
+	in := faultinject.NewInjector()
+	var handled int
+	e := newEngine(RouteMiddleware(faultinject.WithInjector(in)), &handled)
+	in.SetFailures("GET /users/:id", 1)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	if rec.Code != http.StatusInternalServerError || handled != 0 {
+		t.Errorf("Expected status %d without the handler, got %d after %d runs", http.StatusInternalServerError, rec.Code, handled)
+	}
+	if _, ok := faultinject.FullStatus()["GET /users/:id"]; ok {
+		t.Error("Expected the default Injector not to be used")
+	}
+}
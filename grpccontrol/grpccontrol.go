@@ -0,0 +1,181 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Package grpccontrol serves the go-fi control plane as the gRPC service
+// gofi.control.v1.Control, defined in control.proto, so tooling in any
+// language with gRPC support can set rules, reset and watch status without
+// the HTTP control server.
+//
+//	srv := grpc.NewServer()
+//	grpccontrol.Register(srv, faultinject.Default())
+//
+// The generated client, NewControlClient, is in this package too.
+package grpccontrol
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
+
+import (
+	"context"
+	"fmt"
+
+	faultinject "github.com/talinashro/go-fi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements ControlServer on top of an Injector.
+type Server struct {
+	UnimplementedControlServer
+	in *faultinject.Injector
+}
+
+// NewServer returns a Server controlling in.
+func NewServer(in *faultinject.Injector) *Server {
+	return &Server{in: in}
+}
+
+// Register registers a Server controlling in with s.
+func Register(s grpc.ServiceRegistrar, in *faultinject.Injector) {
+	RegisterControlServer(s, NewServer(in))
+}
+
+// Set replaces the rule of a key. It fails with FailedPrecondition in
+// production environments, where rules cannot be set, and with
+// InvalidArgument for a missing key or an unknown mode.
+func (s *Server) Set(ctx context.Context, req *SetRequest) (*SetResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing key")
+	}
+	if s.in.Production() {
+		return nil, status.Error(codes.FailedPrecondition, "fault injection is disabled in production environments")
+	}
+	rule, err := ruleFromProto(req.GetRule())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	s.in.SetRule(req.GetKey(), rule)
+	if req.GetTtl() != nil {
+		s.in.SetTTL(req.GetKey(), req.GetTtl().AsDuration())
+	}
+	return &SetResponse{Generation: s.in.Generation()}, nil
+}
+
+// Reset removes every rule and call count.
+func (s *Server) Reset(ctx context.Context, req *ResetRequest) (*ResetResponse, error) {
+	s.in.Reset()
+	return &ResetResponse{Generation: s.in.Generation()}, nil
+}
+
+// Status returns the state of the requested keys.
+func (s *Server) Status(ctx context.Context, req *StatusRequest) (*StatusResponse, error) {
+	return statusToProto(s.in.StatusDetailed(), req.GetKeys()), nil
+}
+
+// Watch streams the status after every configuration change until the
+// client cancels the call.
+func (s *Server) Watch(req *WatchRequest, stream grpc.ServerStreamingServer[StatusResponse]) error {
+	ctx := stream.Context()
+	gen := req.GetGeneration()
+	for {
+		if gen != 0 {
+			if _, err := s.in.WaitForChange(ctx, gen); err != nil {
+				return nil // the client went away
+			}
+		}
+		st := s.in.StatusDetailed()
+		if err := stream.Send(statusToProto(st, req.GetKeys())); err != nil {
+			return err
+		}
+		gen = st.Generation
+	}
+}
+
+func ruleFromProto(r *Rule) (faultinject.Rule, error) {
+	out := faultinject.Rule{
+		Mode:   faultinject.Mode(r.GetMode()),
+		Count:  int(r.GetCount()),
+		Nth:    int(r.GetNth()),
+		Rate:   r.GetRate(),
+		Error:  r.GetError(),
+		Code:   r.GetCode(),
+		Status: int(r.GetHttpStatus()),
+	}
+	switch out.Mode {
+	case faultinject.ModeNone, faultinject.ModeFirstN, faultinject.ModeNth, faultinject.ModeRate:
+	case faultinject.ModeFlapping:
+		if r.GetFlap() == nil {
+			return out, fmt.Errorf("mode %q needs a flap cycle", out.Mode)
+		}
+	default:
+		return out, fmt.Errorf("unknown mode %q", out.Mode)
+	}
+	if f := r.GetFlap(); f != nil {
+		out.Flap = &faultinject.FlapCycle{Period: f.GetPeriod().AsDuration(), Unhealthy: f.GetUnhealthy().AsDuration()}
+	}
+	for _, b := range r.GetLatency() {
+		out.Latency = append(out.Latency, faultinject.LatencyBucket{
+			Min:    b.GetMin().AsDuration(),
+			Max:    b.GetMax().AsDuration(),
+			Weight: b.GetWeight(),
+		})
+	}
+	return out, nil
+}
+
+func ruleToProto(r faultinject.Rule) *Rule {
+	out := &Rule{
+		Mode:       string(r.Mode),
+		Count:      int32(r.Count),
+		Nth:        int32(r.Nth),
+		Rate:       r.Rate,
+		Error:      r.Error,
+		Code:       r.Code,
+		HttpStatus: int32(r.Status),
+	}
+	if r.Flap != nil {
+		out.Flap = &FlapCycle{Period: durationpb.New(r.Flap.Period), Unhealthy: durationpb.New(r.Flap.Unhealthy)}
+	}
+	for _, b := range r.Latency {
+		out.Latency = append(out.Latency, &LatencyBucket{
+			Min:    durationpb.New(b.Min),
+			Max:    durationpb.New(b.Max),
+			Weight: b.Weight,
+		})
+	}
+	return out
+}
+
+// statusToProto converts st, keeping only keys if any are given.
+func statusToProto(st faultinject.DetailedStatus, keys []string) *StatusResponse {
+	out := &StatusResponse{Generation: st.Generation, Keys: make(map[string]*KeyStatus, len(st.Keys))}
+	for key, ks := range st.Keys {
+		if len(keys) > 0 && !contains(keys, key) {
+			continue
+		}
+		out.Keys[key] = &KeyStatus{
+			Rule:      ruleToProto(ks.Rule),
+			Pattern:   ks.Pattern,
+			Calls:     int64(ks.Calls),
+			Remaining: int64(ks.Remaining),
+			Expired:   ks.Expired,
+		}
+		if !ks.Expires.IsZero() {
+			out.Keys[key].Expires = timestamppb.New(ks.Expires)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+//go:build faultinject
+
+package grpccontrol
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// dial serves in over an in-memory connection and returns a client for it.
+func dial(t *testing.T, in *faultinject.Injector) ControlClient {
+	os.Setenv("ENVIRONMENT", "development")
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	Register(srv, in)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewControlClient(conn)
+}
+
+func TestSetAndStatus(t *testing.T) {
+	in := faultinject.NewInjector()
+	client := dial(t, in)
+	ctx := context.Background()
+
+	_, err := client.Set(ctx, &SetRequest{
+		Key: "db-insert",
+		Rule: &Rule{
+			Mode:       "first-n",
+			Count:      2,
+			Latency:    []*LatencyBucket{{Min: durationpb.New(time.Millisecond), Max: durationpb.New(time.Millisecond), Weight: 1}},
+			Code:       "ECONNREFUSED",
+			HttpStatus: 503,
+		},
+		Ttl: durationpb.New(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if !in.Inject("db-insert") {
+		t.Error("Expected the rule set over gRPC to fire")
+	}
+
+	resp, err := client.Status(ctx, &StatusRequest{Keys: []string{"db-insert"}})
+	if err != nil {
+		t.Fatalf("Status returned error: %v", err)
+	}
+	ks := resp.GetKeys()["db-insert"]
+	if ks.GetCalls() != 1 || ks.GetRemaining() != 1 || ks.GetRule().GetMode() != "first-n" || ks.GetRule().GetHttpStatus() != 503 {
+		t.Errorf("Expected 1 call and 1 failure left, got %v", ks)
+	}
+	if ks.GetExpires() == nil || len(ks.GetRule().GetLatency()) != 1 {
+		t.Errorf("Expected an expiry and latency, got %v", ks)
+	}
+	if resp.GetGeneration() != in.Generation() {
+		t.Errorf("Expected generation %d, got %d", in.Generation(), resp.GetGeneration())
+	}
+
+	if _, err := client.Reset(ctx, &ResetRequest{}); err != nil {
+		t.Fatalf("Reset returned error: %v", err)
+	}
+	if in.Inject("db-insert") {
+		t.Error("Expected no fault after Reset")
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	client := dial(t, faultinject.NewInjector())
+	tests := []struct {
+		name     string
+		req      *SetRequest
+		expected codes.Code
+	}{
+		{name: "missing key", req: &SetRequest{Rule: &Rule{Mode: "first-n", Count: 1}}, expected: codes.InvalidArgument},
+		{name: "unknown mode", req: &SetRequest{Key: "db", Rule: &Rule{Mode: "sometimes"}}, expected: codes.InvalidArgument},
+		{name: "flapping without cycle", req: &SetRequest{Key: "db", Rule: &Rule{Mode: "flapping"}}, expected: codes.InvalidArgument},
+		{name: "empty rule removes", req: &SetRequest{Key: "db"}, expected: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.Set(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.expected {
+				t.Errorf("Expected code %s, got %s (%v)", tt.expected, got, err)
+			}
+		})
+	}
+
+	os.Setenv("ENVIRONMENT", "production")
+	defer os.Setenv("ENVIRONMENT", "development")
+	_, err := client.Set(context.Background(), &SetRequest{Key: "db", Rule: &Rule{Mode: "first-n", Count: 1}})
+	if got := status.Code(err); got != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition in production, got %s", got)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	in := faultinject.NewInjector()
+	client := dial(t, in)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &WatchRequest{Keys: []string{"db-insert"}})
+	if err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv returned error: %v", err)
+	}
+	if len(first.GetKeys()) != 0 {
+		t.Errorf("Expected no keys yet, got %v", first.GetKeys())
+	}
+
+	in.SetFailures("db-insert", 3)
+	in.SetFailures("cache-get", 1)
+	next, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv returned error: %v", err)
+	}
+	if next.GetGeneration() <= first.GetGeneration() {
+		t.Errorf("Expected a later generation than %d, got %d", first.GetGeneration(), next.GetGeneration())
+	}
+	if _, ok := next.GetKeys()["cache-get"]; ok {
+		t.Error("Expected keys outside the request to be left out")
+	}
+	for next.GetKeys()["db-insert"].GetRemaining() != 3 {
+		if next, err = stream.Recv(); err != nil {
+			t.Fatalf("Recv returned error: %v", err)
+		}
+	}
+}
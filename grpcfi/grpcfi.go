@@ -0,0 +1,125 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package grpcfi injects faults into gRPC calls with server and client
+// interceptors. A call whose key fires fails with a configurable status code
+// instead of reaching the handler or the network.
+package grpcfi
+
+import (
+	"context"
+
+	faultinject "github.com/talinashro/go-fi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Option configures the status returned when a fault fires.
+type Option func(*config)
+
+type config struct {
+	code    codes.Code
+	message string
+}
+
+// WithCode sets the status code of injected failures. The default is Unavailable.
+func WithCode(code codes.Code) Option {
+	return func(c *config) { c.code = code }
+}
+
+// WithMessage sets the status message of injected failures.
+func WithMessage(msg string) Option {
+	return func(c *config) { c.message = msg }
+}
+
+func newConfig(opts []Option) config {
+	c := config{code: codes.Unavailable, message: "injected failure"}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func (c config) err() error {
+	return status.Error(c.code, c.message)
+}
+
+// UnaryServerInterceptor fails unary calls while key fires. The context
+// passed to selectors carries the full method name as "method" and the
+// incoming metadata as "header.<name>" attributes, plus "tenant" and
+// "synthetic" like HTTPMiddleware.
+func UnaryServerInterceptor(key string, opts ...Option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = withCallAttributes(ctx, info.FullMethod)
+		if faultinject.InjectWithContext(ctx, key) {
+			return nil, cfg.err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor fails streams while key fires, before the handler runs.
+func StreamServerInterceptor(key string, opts ...Option) grpc.StreamServerInterceptor {
+	cfg := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := withCallAttributes(ss.Context(), info.FullMethod)
+		if faultinject.InjectWithContext(ctx, key) {
+			return cfg.err()
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// UnaryClientInterceptor fails outgoing unary calls while key fires, without
+// sending them.
+func UnaryClientInterceptor(key string, opts ...Option) grpc.UnaryClientInterceptor {
+	cfg := newConfig(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		if faultinject.InjectWithContext(faultinject.WithAttributes(ctx, faultinject.Attributes{"method": method}), key) {
+			return cfg.err()
+		}
+		return invoker(ctx, method, req, reply, cc, callOpts...)
+	}
+}
+
+// StreamClientInterceptor fails opening outgoing streams while key fires.
+func StreamClientInterceptor(key string, opts ...Option) grpc.StreamClientInterceptor {
+	cfg := newConfig(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, callOpts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if faultinject.InjectWithContext(faultinject.WithAttributes(ctx, faultinject.Attributes{"method": method}), key) {
+			return nil, cfg.err()
+		}
+		return streamer(ctx, desc, cc, method, callOpts...)
+	}
+}
+
+// withCallAttributes exposes an incoming call to selectors.
+func withCallAttributes(ctx context.Context, method string) context.Context {
+	attrs := faultinject.Attributes{"method": method}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for name, values := range md {
+		if len(values) > 0 {
+			attrs["header."+name] = values[0]
+		}
+	}
+	if t := md.Get(faultinject.TenantHeader()); len(t) > 0 {
+		attrs["tenant"] = t[0]
+	}
+	if len(md.Get(faultinject.SyntheticHeader)) > 0 {
+		attrs["synthetic"] = "true"
+	}
+	return faultinject.WithAttributes(ctx, attrs)
+}
+
+// serverStream passes the attribute-carrying context on to the handler.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,151 @@
+//go:build faultinject
+
+package grpcfi
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+
+	faultinject "github.com/talinashro/go-fi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func resetState() {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+}
+
+// dial starts a health server with the given server options and returns a
+// client connection to it.
+func dial(t *testing.T, serverOpts []grpc.ServerOption, dialOpts ...grpc.DialOption) healthpb.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(serverOpts...)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dialOpts = append(dialOpts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	conn, err := grpc.NewClient("passthrough:///bufnet", dialOpts...)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		setup    func()
+		md       metadata.MD
+		expected codes.Code
+	}{
+		{name: "no fault", setup: func() {}, expected: codes.OK},
+		{name: "default code", setup: func() { faultinject.SetFailures("health", 1) }, expected: codes.Unavailable},
+		{
+			name:     "custom code",
+			opts:     []Option{WithCode(codes.ResourceExhausted), WithMessage("quota")},
+			setup:    func() { faultinject.SetFailures("health", 1) },
+			expected: codes.ResourceExhausted,
+		},
+		{
+			name: "tenant from metadata",
+			setup: func() {
+				faultinject.SetFailures("health", 1)
+				faultinject.SetTenants("health", "acme")
+			},
+			md:       metadata.Pairs("x-tenant-id", "acme"),
+			expected: codes.Unavailable,
+		},
+		{
+			name: "other tenant",
+			setup: func() {
+				faultinject.SetFailures("health", 1)
+				faultinject.SetTenants("health", "acme")
+			},
+			md:       metadata.Pairs("x-tenant-id", "globex"),
+			expected: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			client := dial(t, []grpc.ServerOption{grpc.UnaryInterceptor(UnaryServerInterceptor("health", tt.opts...))})
+
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewOutgoingContext(ctx, tt.md)
+			}
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+			if got := status.Code(err); got != tt.expected {
+				t.Errorf("Expected code %s, got %s (%v)", tt.expected, got, err)
+			}
+		})
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("health-watch", 1)
+	client := dial(t, []grpc.ServerOption{grpc.StreamInterceptor(StreamServerInterceptor("health-watch"))})
+
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable from first stream, got %v", err)
+	}
+
+	stream, err = client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Errorf("Expected second stream to succeed, got %v", err)
+	}
+}
+
+func TestClientInterceptors(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("health-client", 1)
+	faultinject.RegisterSelector("check-only", faultinject.AttributeSelector("method", healthpb.Health_Check_FullMethodName))
+	defer faultinject.RegisterSelector("check-only", nil)
+	faultinject.SetSelectors("health-client", "check-only")
+
+	client := dial(t, nil,
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor("health-client", WithCode(codes.DeadlineExceeded))),
+		grpc.WithStreamInterceptor(StreamClientInterceptor("health-client")),
+	)
+
+	// the selector skips streams, so the unary call still gets the fault
+	stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch returned error: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Errorf("Expected stream to succeed, got %v", err)
+	}
+
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Expected second call to succeed, got %v", err)
+	}
+}
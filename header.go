@@ -0,0 +1,167 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FaultHeader is the default request header read by middleware created with
+// WithFaultHeader. Its value lists overrides such as "db-insert=on, cache=off".
+const FaultHeader = "X-Fault-Inject"
+
+// WithFaultHeader makes the middleware honor per-request overrides listed in
+// the request header name (FaultHeader if empty), so a single test request
+// can force faults on or off without changing any rule. The overrides apply
+// to every context-aware check made with the request's context.
+//
+// If secret is set, the header is only honored when the name+"-Signature"
+// header carries a signature of its value made in the last five minutes, as
+// computed by SignFaultHeader. The header is always ignored in production
+// environments.
+func WithFaultHeader(name string, secret []byte) MiddlewareOption {
+	if name == "" {
+		name = FaultHeader
+	}
+	return func(c *middlewareConfig) {
+		c.faultHeader = name
+		c.faultSecret = append([]byte(nil), secret...)
+	}
+}
+
+// WithDirectiveHeader makes the middleware apply the directives in the
+// request's DirectiveHeader to the request, as WithDirectives would. A header
+// that does not parse is ignored. As with WithFaultHeader, a secret requires
+// the DirectiveHeader+"-Signature" header to carry the value's
+// SignFaultHeader signature, and the header is ignored in production
+// environments.
+func WithDirectiveHeader(secret []byte) MiddlewareOption {
+	return func(c *middlewareConfig) {
+		c.directives = true
+		c.directiveSecret = append([]byte(nil), secret...)
+	}
+}
+
+// maxHeaderAge bounds how old a signed header may be, to limit replays.
+const maxHeaderAge = 5 * time.Minute
+
+// SignFaultHeader returns the signature of a fault header value for now, as
+// "t=<unix seconds>,v0=<hex>", where hex is the HMAC-SHA256 with secret of
+// "v0:<unix seconds>:" followed by value. Signatures are accepted for five
+// minutes either side of the time they were made, so sign each request.
+func SignFaultHeader(secret []byte, value string) string {
+	return signHeaderAt(secret, value, time.Now())
+}
+
+func signHeaderAt(secret []byte, value string, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write([]byte(value))
+	return "t=" + ts + ",v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyHeader reports whether sig is a signature of value with secret made
+// within maxHeaderAge of now.
+func verifyHeader(secret []byte, value, sig string, now time.Time) bool {
+	ts, _, ok := strings.Cut(strings.TrimPrefix(sig, "t="), ",")
+	if !ok {
+		return false
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return false
+	}
+	at := time.Unix(sec, 0)
+	if d := now.Sub(at); d > maxHeaderAge || d < -maxHeaderAge {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(signHeaderAt(secret, value, at)))
+}
+
+// signedHeader returns the value of r's header name, or "" if it is absent,
+// not validly signed with secret, signed too long ago, or the environment is
+// a production one.
+func (in *Injector) signedHeader(r *http.Request, name string, secret []byte) string {
+	value := r.Header.Get(name)
+	if value == "" || in.isProductionEnvironment() {
+		return ""
+	}
+	if len(secret) > 0 && !verifyHeader(secret, value, r.Header.Get(name+"-Signature"), in.timeNow()) {
+		return ""
+	}
+	return value
+}
+
+// headerDirectives returns ctx carrying the directives from r's
+// DirectiveHeader, or ctx unchanged if there are none.
+func (in *Injector) headerDirectives(ctx context.Context, r *http.Request, secret []byte) context.Context {
+	value := in.signedHeader(r, DirectiveHeader, secret)
+	if value == "" {
+		return ctx
+	}
+	ds, err := ParseDirectives(value)
+	if err != nil || len(ds) == 0 {
+		return ctx
+	}
+	return WithDirectives(ctx, ds...)
+}
+
+// decideDirective decides a call to key by the directive ctx carries for it.
+// ok is false if there is none.
+func (in *Injector) decideDirective(ctx context.Context, key string) (fired bool, reason Reason, count int, ok bool) {
+	st := directiveFor(ctx, key)
+	if st == nil {
+		return false, "", 0, false
+	}
+	count = int(st.calls.Add(1))
+	switch st.Mode {
+	case "", DirectiveOn:
+		return true, ReasonOverride, count, true
+	case DirectiveOff:
+		return false, ReasonOverride, count, true
+	}
+	r := Rule{Mode: Mode(st.Mode), Count: st.N, Nth: st.N, Rate: st.Rate}
+	fired, reason = r.decide(count, in.timeNow())
+	return fired, reason, count, true
+}
+
+// directiveFor returns the directive ctx carries for key, or nil.
+func directiveFor(ctx context.Context, key string) *directiveState {
+	if set := directivesFrom(ctx); set != nil {
+		return set.byKey[key]
+	}
+	return nil
+}
+
+// headerOverrides returns ctx carrying the overrides from r's fault header,
+// or ctx unchanged if the header is absent or not validly signed.
+func (in *Injector) headerOverrides(ctx context.Context, r *http.Request, name string, secret []byte) context.Context {
+	value := in.signedHeader(r, name, secret)
+	if value == "" {
+		return ctx
+	}
+	for _, entry := range strings.Split(value, ",") {
+		key, state, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key == "" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(state)) {
+		case "on", "true", "1":
+			ctx = WithFaultOverride(ctx, key, true)
+		case "off", "false", "0":
+			ctx = WithFaultOverride(ctx, key, false)
+		}
+	}
+	return ctx
+}
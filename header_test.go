@@ -0,0 +1,82 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithFaultHeader(t *testing.T) {
+	secret := []byte("s3cret")
+
+	tests := []struct {
+		name      string
+		option    MiddlewareOption
+		header    string
+		value     string
+		signature string
+		failures  int
+		expected  int
+	}{
+		{name: "forced on", option: WithFaultHeader("", nil), header: FaultHeader, value: "api=on", expected: http.StatusInternalServerError},
+		{name: "forced off", option: WithFaultHeader("", nil), header: FaultHeader, value: "api=off", failures: 1, expected: http.StatusOK},
+		{name: "other key", option: WithFaultHeader("", nil), header: FaultHeader, value: "db=on", expected: http.StatusOK},
+		{name: "several keys", option: WithFaultHeader("", nil), header: FaultHeader, value: "db=off, api=on", expected: http.StatusInternalServerError},
+		{name: "custom name", option: WithFaultHeader("X-Test-Faults", nil), header: "X-Test-Faults", value: "api=on", expected: http.StatusInternalServerError},
+		{name: "not enabled", header: FaultHeader, value: "api=on", expected: http.StatusOK},
+		{name: "signed", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: SignFaultHeader(secret, "api=on"), expected: http.StatusInternalServerError},
+		{name: "unsigned", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", expected: http.StatusOK},
+		{name: "wrong signature", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: SignFaultHeader([]byte("other"), "api=on"), expected: http.StatusOK},
+		{name: "other value", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: SignFaultHeader(secret, "db=on"), expected: http.StatusOK},
+		{name: "stale signature", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: signHeaderAt(secret, "api=on", time.Now().Add(-6*time.Minute)), expected: http.StatusOK},
+		{name: "future signature", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: signHeaderAt(secret, "api=on", time.Now().Add(6*time.Minute)), expected: http.StatusOK},
+		{name: "recent signature", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: signHeaderAt(secret, "api=on", time.Now().Add(-4*time.Minute)), expected: http.StatusInternalServerError},
+		{name: "malformed signature", option: WithFaultHeader("", secret), header: FaultHeader, value: "api=on", signature: "t=now,v0=00", expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if tt.failures > 0 {
+				SetFailures("api", tt.failures)
+			}
+			var opts []MiddlewareOption
+			if tt.option != nil {
+				opts = append(opts, tt.option)
+			}
+			handler := HTTPMiddleware("api", opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(tt.header, tt.value)
+			if tt.signature != "" {
+				req.Header.Set(tt.header+"-Signature", tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+		})
+	}
+}
+
+func TestWithFaultHeaderProduction(t *testing.T) {
+	resetState()
+	t.Setenv("ENVIRONMENT", "production")
+	handler := HTTPMiddleware("api", WithFaultHeader("", nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(FaultHeader, "api=on")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the header to be ignored in production, got %d", rec.Code)
+	}
+}
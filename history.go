@@ -0,0 +1,126 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"time"
+)
+
+// Reason explains why an injection point did or did not fire.
+type Reason string
+
+const (
+	ReasonFired         Reason = "fired"
+	ReasonOverride      Reason = "context-override"
+	ReasonProduction    Reason = "production-environment"
+	ReasonCancelled     Reason = "context-cancelled"
+	ReasonSynthetic     Reason = "synthetic-excluded"
+	ReasonTenant        Reason = "tenant-mismatch"
+	ReasonSelector      Reason = "selector-mismatch"
+	ReasonDisabled      Reason = "disabled"
+	ReasonExhausted     Reason = "exhausted"
+	ReasonNotNthCall    Reason = "not-nth-call"
+	ReasonHealthy       Reason = "healthy-phase"      // flapping key outside its unhealthy window
+	ReasonExpired       Reason = "expired"            // the key's TTL has passed
+	ReasonNotSampled    Reason = "not-sampled"        // a rate rule did not pick this call
+	ReasonInstance      Reason = "instance-excluded"  // the rule's Instances left out this instance
+	ReasonBuild         Reason = "build-excluded"     // the rule's Build does not match this binary
+	ReasonMatch         Reason = "attribute-mismatch" // the call's attributes do not satisfy the rule's Match
+	ReasonCaller        Reason = "caller-mismatch"    // the call was not reached through the rule's OnlyFrom or InjectWhen
+	ReasonPassingAction Reason = "passing-action"     // the current phase of a composite rule does not fail
+	ReasonBudget        Reason = "budget-exhausted"   // the rule's or the injector's Budget allowed no more faults in its window
+)
+
+// defaultHistoryLen is how many decisions History keeps per key unless changed by SetHistorySize.
+const defaultHistoryLen = 32
+
+// Decision records one evaluation of a key that has a rule configured.
+type Decision struct {
+	Key    string    `json:"key"`
+	Fired  bool      `json:"fired"`
+	Reason Reason    `json:"reason"`
+	Count  int       `json:"count"` // call counter after this call; 0 if the call was not counted
+	Time   time.Time `json:"time"`
+}
+
+// History returns the most recent decisions for key, oldest first. Only keys
+// with a rule configured are recorded, so a key missing here was either
+// never called or has no rule.
+func (in *Injector) History(key string) []Decision {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	return append([]Decision(nil), in.history[key]...)
+}
+
+// History calls History on the default Injector.
+func History(key string) []Decision {
+	return std.History(key)
+}
+
+// SetHistorySize sets how many decisions History keeps per key, trimming
+// what is already recorded. Zero disables recording.
+func (in *Injector) SetHistorySize(n int) {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	in.historyLen = n
+	for k, h := range in.history {
+		if len(h) > n {
+			in.history[k] = append([]Decision(nil), h[len(h)-n:]...)
+		}
+	}
+}
+
+// SetHistorySize calls SetHistorySize on the default Injector.
+func SetHistorySize(n int) {
+	std.SetHistorySize(n)
+}
+
+// record stores a decision for key and returns it as an Event, so call sites
+// can "return record(...).Fired". Only the Decision's key, outcome, reason
+// and count are set if the decision was not recorded.
+func (in *Injector) record(ctx context.Context, key string, fired bool, reason Reason, count int) Event {
+	in.callsMu.Lock()
+	e := in.recordLocked(ctx, in.snapshot(), key, fired, reason, count)
+	in.callsMu.Unlock()
+	in.logDecision(ctx, e)
+	return e
+}
+
+// recordLocked is record for a call decided by the configuration s. It must
+// be called with callsMu held.
+func (in *Injector) recordLocked(ctx context.Context, s *ruleSnapshot, key string, fired bool, reason Reason, count int) Event {
+	in.traceDecision(ctx, key, fired, reason, count)
+	in.countDecisionLocked(key, fired, reason)
+	hasRule := s.hasRule(key)
+	if !hasRule && !fired {
+		return Event{Decision: Decision{Key: key, Reason: reason, Count: count}}
+	}
+	d := Decision{
+		Key:    key,
+		Fired:  fired,
+		Reason: reason,
+		Count:  count,
+		Time:   in.timeNow(),
+	}
+	e := in.logEventLocked(ctx, d)
+	if !hasRule {
+		return e
+	}
+	in.publishDecisionLocked(d)
+	if in.historyLen == 0 {
+		return e
+	}
+	h := in.history[key]
+	if len(h) >= in.historyLen {
+		h = append(h[:0], h[len(h)-in.historyLen+1:]...)
+	}
+	in.history[key] = append(h, d)
+	return e
+}
@@ -0,0 +1,162 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestHistoryReasons(t *testing.T) {
+	resetState()
+	defer RegisterSelector("never", nil)
+	RegisterSelector("never", SelectorFunc(func(context.Context, string) bool { return false }))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		setup    func()
+		call     func() bool
+		expected Reason
+		fired    bool
+	}{
+		{
+			name:     "fired",
+			setup:    func() { SetFailures("h", 1) },
+			call:     func() bool { return Inject("h") },
+			expected: ReasonFired,
+			fired:    true,
+		},
+		{
+			name:     "exhausted",
+			setup:    func() { SetFailures("h", 1); Inject("h") },
+			call:     func() bool { return Inject("h") },
+			expected: ReasonExhausted,
+		},
+		{
+			name:     "disabled with zero count",
+			setup:    func() { SetFailures("h", 0) },
+			call:     func() bool { return Inject("h") },
+			expected: ReasonDisabled,
+		},
+		{
+			name:     "not the nth call",
+			setup:    func() { SetNthFailure("h", 2) },
+			call:     func() bool { return Inject("h") },
+			expected: ReasonNotNthCall,
+		},
+		{
+			name:     "cancelled context",
+			setup:    func() { SetFailures("h", 1) },
+			call:     func() bool { return InjectWithContext(cancelled, "h") },
+			expected: ReasonCancelled,
+		},
+		{
+			name:     "tenant mismatch",
+			setup:    func() { SetFailures("h", 1); SetTenants("h", "acme") },
+			call:     func() bool { return Inject("h") },
+			expected: ReasonTenant,
+		},
+		{
+			name:     "selector mismatch",
+			setup:    func() { SetFailures("h", 1); SetSelectors("h", "never") },
+			call:     func() bool { return Inject("h") },
+			expected: ReasonSelector,
+		},
+		{
+			name:     "synthetic excluded",
+			setup:    func() { SetFailures("h", 1); SetExcludeSynthetic("h", true) },
+			call:     func() bool { return InjectWithContext(MarkSynthetic(context.Background()), "h") },
+			expected: ReasonSynthetic,
+		},
+		{
+			name:  "production environment",
+			setup: func() { SetFailures("h", 1); os.Setenv("ENVIRONMENT", "production") },
+			call: func() bool {
+				defer os.Setenv("ENVIRONMENT", "development")
+				return Inject("h")
+			},
+			expected: ReasonProduction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			if got := tt.call(); got != tt.fired {
+				t.Fatalf("Expected fired=%v, got %v", tt.fired, got)
+			}
+			h := History("h")
+			if len(h) == 0 {
+				t.Fatal("Expected a recorded decision")
+			}
+			last := h[len(h)-1]
+			if last.Reason != tt.expected || last.Fired != tt.fired {
+				t.Errorf("Expected %s (fired=%v), got %s (fired=%v)", tt.expected, tt.fired, last.Reason, last.Fired)
+			}
+		})
+	}
+}
+
+func TestHistoryOnlyRecordsConfiguredKeys(t *testing.T) {
+	resetState()
+	Inject("unconfigured")
+	if h := History("unconfigured"); len(h) != 0 {
+		t.Errorf("Expected no history for a key without a rule, got %v", h)
+	}
+}
+
+func TestHistorySize(t *testing.T) {
+	resetState()
+	defer SetHistorySize(defaultHistoryLen)
+
+	SetHistorySize(3)
+	SetFailures("bounded", 2)
+	for i := 0; i < 5; i++ {
+		Inject("bounded")
+	}
+	h := History("bounded")
+	if len(h) != 3 {
+		t.Fatalf("Expected 3 decisions, got %d", len(h))
+	}
+	if h[0].Count != 3 || h[2].Count != 5 {
+		t.Errorf("Expected the most recent calls 3..5, got counts %d..%d", h[0].Count, h[2].Count)
+	}
+
+	SetHistorySize(0)
+	Inject("bounded")
+	if len(History("bounded")) != 0 {
+		t.Error("Expected history to be dropped and recording to stop when size is zero")
+	}
+}
+
+func TestServerHistoryEndpoint(t *testing.T) {
+	resetState()
+	SetFailures("served", 1)
+	Inject("served")
+	Inject("served")
+
+	server := httptest.NewServer(ControlHandler(nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/history?key=served")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var h []Decision
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(h) != 2 || h[1].Reason != ReasonExhausted {
+		t.Errorf("Unexpected history %+v", h)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"time"
+)
+
+// Fault describes an injected fault, as passed to OnFault hooks.
+type Fault struct {
+	Key string
+	// Type is the failure mode of the rule that fired, such as "first-n",
+	// or "override" for context overrides, "directive" for directives, or
+	// "latency" for InjectLatency.
+	Type    string
+	Count   int           // call count the fault fired at, 0 if not counted
+	Latency time.Duration // delay added, for latency faults
+}
+
+type faultHook struct {
+	fn func(ctx context.Context, f Fault)
+}
+
+// OnFault registers fn to be called with the call's context every time a
+// fault is injected, after the decision is made and outside the injector's
+// lock. It returns a function that removes the hook. Hooks run on the
+// caller's goroutine, so they should be fast.
+func (in *Injector) OnFault(fn func(ctx context.Context, f Fault)) (remove func()) {
+	h := &faultHook{fn: fn}
+	in.hooksMu.Lock()
+	defer in.hooksMu.Unlock()
+	var list []*faultHook
+	if cur := in.hooks.Load(); cur != nil {
+		list = append(list, *cur...)
+	}
+	list = append(list, h)
+	in.hooks.Store(&list)
+
+	return func() {
+		in.hooksMu.Lock()
+		defer in.hooksMu.Unlock()
+		cur := in.hooks.Load()
+		if cur == nil {
+			return
+		}
+		var list []*faultHook
+		for _, other := range *cur {
+			if other != h {
+				list = append(list, other)
+			}
+		}
+		in.hooks.Store(&list)
+	}
+}
+
+// OnFault calls OnFault on the default Injector.
+func OnFault(fn func(ctx context.Context, f Fault)) (remove func()) {
+	return std.OnFault(fn)
+}
+
+type injectHook struct {
+	fn func(e Event)
+}
+
+// OnInject registers fn to be called with the Event of every fault that
+// fires, after the decision is made and outside the injector's lock, like
+// OnFault. The event carries the key, reason, call count, time, goroutine and
+// trace ID, and its Seq if the event log is enabled. Faults from
+// InjectLatency are not decisions, so they only reach OnFault hooks. It
+// returns a function that removes the hook.
+func (in *Injector) OnInject(fn func(e Event)) (remove func()) {
+	h := &injectHook{fn: fn}
+	in.hooksMu.Lock()
+	defer in.hooksMu.Unlock()
+	var list []*injectHook
+	if cur := in.injectHooks.Load(); cur != nil {
+		list = append(list, *cur...)
+	}
+	list = append(list, h)
+	in.injectHooks.Store(&list)
+
+	return func() {
+		in.hooksMu.Lock()
+		defer in.hooksMu.Unlock()
+		cur := in.injectHooks.Load()
+		if cur == nil {
+			return
+		}
+		var list []*injectHook
+		for _, other := range *cur {
+			if other != h {
+				list = append(list, other)
+			}
+		}
+		in.injectHooks.Store(&list)
+	}
+}
+
+// OnInject calls OnInject on the default Injector.
+func OnInject(fn func(e Event)) (remove func()) {
+	return std.OnInject(fn)
+}
+
+// hasInjectHooks reports whether any OnInject hooks are registered.
+func (in *Injector) hasInjectHooks() bool {
+	list := in.injectHooks.Load()
+	return list != nil && len(*list) > 0
+}
+
+// notifyInject calls the OnInject hooks with e. It must not be called with
+// mu held.
+func (in *Injector) notifyInject(e Event) {
+	list := in.injectHooks.Load()
+	if list == nil {
+		return
+	}
+	for _, h := range *list {
+		h.fn(e)
+	}
+}
+
+// notifyFault calls the OnFault hooks and logs f to the runtime trace. It
+// must not be called with mu held.
+func (in *Injector) notifyFault(ctx context.Context, f Fault) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	in.logRuntimeTrace(ctx, f)
+	list := in.hooks.Load()
+	if list == nil || len(*list) == 0 {
+		return
+	}
+	for _, h := range *list {
+		h.fn(ctx, f)
+	}
+}
@@ -0,0 +1,173 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOnFault(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func()
+		call     func(ctx context.Context)
+		expected []Fault
+	}{
+		{
+			name:     "first-n",
+			setup:    func() { SetFailures("db", 1) },
+			call:     func(ctx context.Context) { InjectWithContext(ctx, "db"); InjectWithContext(ctx, "db") },
+			expected: []Fault{{Key: "db", Type: "first-n", Count: 1}},
+		},
+		{
+			name:     "nth",
+			setup:    func() { SetNthFailure("db", 2) },
+			call:     func(ctx context.Context) { Inject("db"); Inject("db") },
+			expected: []Fault{{Key: "db", Type: "nth", Count: 2}},
+		},
+		{
+			name:     "override",
+			setup:    func() {},
+			call:     func(ctx context.Context) { InjectWithContext(WithFaultOverride(ctx, "db", true), "db") },
+			expected: []Fault{{Key: "db", Type: "override"}},
+		},
+		{
+			name:     "override off",
+			setup:    func() { SetFailures("db", 1) },
+			call:     func(ctx context.Context) { InjectWithContext(WithFaultOverride(ctx, "db", false), "db") },
+			expected: nil,
+		},
+		{
+			name:     "latency",
+			setup:    func() { SetLatency("db", FixedLatency(time.Millisecond)) },
+			call:     func(ctx context.Context) { InjectLatency(ctx, "db") },
+			expected: []Fault{{Key: "db", Type: "latency", Latency: time.Millisecond}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			var got []Fault
+			remove := OnFault(func(ctx context.Context, f Fault) {
+				if ctx == nil {
+					t.Error("Expected a context")
+				}
+				got = append(got, f)
+			})
+			defer remove()
+
+			tt.call(context.Background())
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOnFaultRemove(t *testing.T) {
+	resetState()
+	SetFailures("db", 2)
+	calls := 0
+	remove := OnFault(func(context.Context, Fault) { calls++ })
+	Inject("db")
+	remove()
+	Inject("db")
+	if calls != 1 {
+		t.Errorf("Expected 1 call before removal, got %d", calls)
+	}
+}
+
+func TestOnFaultCanUseInjector(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	remove := OnFault(func(context.Context, Fault) { Status() })
+	defer remove()
+
+	done := make(chan struct{})
+	go func() {
+		Inject("db")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected hooks to run outside the injector's lock")
+	}
+}
+
+func TestOnInject(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func()
+		call     func(ctx context.Context)
+		expected []Decision
+	}{
+		{
+			name:     "first-n",
+			setup:    func() { SetFailures("db", 1) },
+			call:     func(ctx context.Context) { Inject("db"); Inject("db") },
+			expected: []Decision{{Key: "db", Fired: true, Reason: ReasonFired, Count: 1}},
+		},
+		{
+			name:     "override",
+			setup:    func() {},
+			call:     func(ctx context.Context) { InjectWithContext(WithFaultOverride(ctx, "db", true), "db") },
+			expected: []Decision{{Key: "db", Fired: true, Reason: ReasonOverride}},
+		},
+		{
+			name:     "latency",
+			setup:    func() { SetLatency("db", FixedLatency(time.Millisecond)) },
+			call:     func(ctx context.Context) { InjectLatency(ctx, "db") },
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			var got []Decision
+			remove := OnInject(func(e Event) {
+				if e.Goroutine == 0 || e.Seq == 0 || e.Time.IsZero() {
+					t.Errorf("Expected a goroutine, seq and time, got %+v", e)
+				}
+				e.Time = time.Time{}
+				got = append(got, e.Decision)
+			})
+			defer remove()
+
+			tt.call(context.Background())
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOnInjectWithoutEventLog(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetEventLogSize(0)
+	in.SetTraceIDFunc(func(context.Context) string { return "trace-1" })
+	in.SetFailures("db", 2)
+	var got []Event
+	remove := in.OnInject(func(e Event) {
+		in.Status() // runs outside the lock
+		got = append(got, e)
+	})
+	in.Inject("db")
+	remove()
+	in.Inject("db")
+
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 event before removal, got %d", len(got))
+	}
+	if got[0].Seq != 0 || got[0].Goroutine == 0 || got[0].TraceID != "trace-1" {
+		t.Errorf("Expected no seq with a goroutine and trace ID, got %+v", got[0])
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// IdempotencyFault is a way of making a request take effect while the caller
+// cannot tell that it did, for testing idempotency keys and double-charge
+// protection.
+type IdempotencyFault string
+
+const (
+	// IdempotencyLostResponse lets the request succeed but reports a failure
+	// to the caller, as when the connection drops before the response
+	// arrives. A retrying caller sends the request again.
+	IdempotencyLostResponse IdempotencyFault = "lost-response"
+	// IdempotencyDuplicate delivers the request twice, as an at-least-once
+	// network or a retrying proxy would. The caller sees the second response.
+	IdempotencyDuplicate IdempotencyFault = "duplicate"
+)
+
+// SetIdempotencyFault sets how IdempotencyTransport and IdempotencyMiddleware
+// break requests for key when it fires. The default is
+// IdempotencyLostResponse. An empty IdempotencyFault restores the default.
+func (in *Injector) SetIdempotencyFault(key string, fault IdempotencyFault) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.idempotencyFaults, key, fault, fault == "")
+}
+
+// SetIdempotencyFault calls SetIdempotencyFault on the default Injector.
+func SetIdempotencyFault(key string, fault IdempotencyFault) {
+	std.SetIdempotencyFault(key, fault)
+}
+
+// IdempotencyTransport wraps an HTTP client transport so that when key fires,
+// the request is sent and then reported as failed, or sent twice, as
+// configured by SetIdempotencyFault. A nil next uses http.DefaultTransport.
+func (in *Injector) IdempotencyTransport(key string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		fired, count := in.evaluateWithContext(req.Context(), key)
+		if !fired {
+			return next.RoundTrip(req)
+		}
+
+		if in.idempotencyFaultFor(key) == IdempotencyDuplicate {
+			body, err := readBody(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			send := func() (*http.Response, error) {
+				r := req.Clone(req.Context())
+				if req.Body != nil {
+					r.Body = io.NopCloser(bytes.NewReader(body))
+				}
+				return next.RoundTrip(r)
+			}
+			if resp, err := send(); err == nil {
+				discard(resp)
+			}
+			return send()
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		discard(resp)
+		return nil, in.newInjectedError(key, count, "response lost after the request succeeded")
+	})
+}
+
+// IdempotencyTransport calls IdempotencyTransport on the default Injector.
+func IdempotencyTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return std.IdempotencyTransport(key, next)
+}
+
+// IdempotencyMiddleware creates middleware that, when key fires, runs the
+// handler and then responds 502 Bad Gateway in place of its response, or runs
+// the handler twice and sends the second response, as configured by
+// SetIdempotencyFault.
+func (in *Injector) IdempotencyMiddleware(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithAttributes(r.Context(), in.requestAttributes(r))
+			r = r.WithContext(ctx)
+			if !in.InjectWithContext(ctx, key) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := readBody(r.Body)
+			if err != nil {
+				http.Error(w, "Injected failure", http.StatusBadGateway)
+				return
+			}
+			first := r.Clone(ctx)
+			first.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(&discardWriter{header: make(http.Header)}, first)
+
+			if in.idempotencyFaultFor(key) != IdempotencyDuplicate {
+				http.Error(w, "Injected failure", http.StatusBadGateway)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IdempotencyMiddleware calls IdempotencyMiddleware on the default Injector.
+func IdempotencyMiddleware(key string) func(http.Handler) http.Handler {
+	return std.IdempotencyMiddleware(key)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// discardWriter is a ResponseWriter that drops the response.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) WriteHeader(int)             {}
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// readBody reads and closes body, which may be nil.
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// discard drains and closes resp's body so its connection can be reused.
+func discard(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func (in *Injector) idempotencyFaultFor(key string) IdempotencyFault {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if f, ok := in.idempotencyFaults[in.ruleKeyLocked(key)]; ok {
+		return f
+	}
+	return IdempotencyLostResponse
+}
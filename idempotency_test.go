@@ -0,0 +1,108 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// chargeServer counts the charges it receives and echoes the request body.
+func chargeServer(charges *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		charges.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+}
+
+func TestIdempotencyTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		fault    IdempotencyFault
+		failures int
+		charges  int32
+		wantErr  bool
+	}{
+		{name: "no fault", charges: 1},
+		{name: "lost response", fault: IdempotencyLostResponse, failures: 1, charges: 1, wantErr: true},
+		{name: "default loses response", failures: 1, charges: 1, wantErr: true},
+		{name: "duplicate", fault: IdempotencyDuplicate, failures: 1, charges: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetIdempotencyFault("charge", tt.fault)
+			if tt.failures > 0 {
+				SetFailures("charge", tt.failures)
+			}
+			var charges atomic.Int32
+			server := httptest.NewServer(chargeServer(&charges))
+			defer server.Close()
+
+			client := &http.Client{Transport: IdempotencyTransport("charge", nil)}
+			resp, err := client.Post(server.URL, "text/plain", strings.NewReader("42"))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInjected) {
+					t.Errorf("Expected an injected error, got %v", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if string(body) != "42" {
+					t.Errorf("Expected body 42, got %q", body)
+				}
+			}
+			if got := charges.Load(); got != tt.charges {
+				t.Errorf("Expected %d charges, got %d", tt.charges, got)
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		fault    IdempotencyFault
+		failures int
+		status   int
+		charges  int32
+	}{
+		{name: "no fault", status: http.StatusOK, charges: 1},
+		{name: "lost response", failures: 1, status: http.StatusBadGateway, charges: 1},
+		{name: "duplicate", fault: IdempotencyDuplicate, failures: 1, status: http.StatusOK, charges: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetIdempotencyFault("charge", tt.fault)
+			if tt.failures > 0 {
+				SetFailures("charge", tt.failures)
+			}
+			var charges atomic.Int32
+			handler := IdempotencyMiddleware("charge")(chargeServer(&charges))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/charge", strings.NewReader("42")))
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusOK && rec.Body.String() != "42" {
+				t.Errorf("Expected body 42, got %q", rec.Body.String())
+			}
+			if got := charges.Load(); got != tt.charges {
+				t.Errorf("Expected %d charges, got %d", tt.charges, got)
+			}
+		})
+	}
+}
@@ -1,43 +1,203 @@
 // Copyright 2025 Talina Shrotriya
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build faultinject
+
 package faultinject
 
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var (
-	mu       sync.Mutex
-	limits   = make(map[string]int) // old "fail first N" behavior
-	precise  = make(map[string]int) // new "fail only on Nth call" behavior
-	counters = make(map[string]int)
+// An Injector holds fault rules and the call counts they are decided on.
+// The package-level functions use a default Injector; NewInjector returns an
+// isolated one, so parallel tests and independent components do not share
+// rules, counters or history. An Injector is safe for concurrent use.
+// Generic helpers such as Paginate, InjectValue and WithFaultInjection
+// cannot be methods and use the default Injector.
+type Injector struct {
+	mu      configMutex // guards the configuration
+	callsMu sync.Mutex  // guards counters, lastSeen, history, metrics, origins and events; taken after mu
+	current atomic.Pointer[ruleSnapshot]
+
+	rules    map[string]Rule
+	counters map[string]int // calls per key, including keys matched by a pattern rule
+	// patterns holds every pattern a rule has been set for, longest first.
+	// Rules removed since are skipped by ruleKeyLocked.
+	patterns []pattern
+	// lastSeen records when each key was last called or configured.
+	lastSeen map[string]time.Time
+
+	selectorRegistry        map[string]Selector
+	selectors               map[string][]string // key -> selector names
+	tenants                 map[string][]string // key -> tenants the rule is scoped to
+	tenantHeader            string
+	excludeSynthetic        map[string]bool // per-key override of excludeSyntheticDefault
+	excludeSyntheticDefault bool
+
+	skews             map[string]skewRule
+	corruptions       map[string]Corruption
+	numericEdges      map[string]NumericEdge
+	pageFaults        map[string]PageFault
+	idempotencyFaults map[string]IdempotencyFault
+	quotaResponses    map[string]QuotaResponse
+	sseFaults         map[string]SSEFault
+	degradations      map[string]Degradation
+	responseFaults    map[string]ResponseFault
+	connFaults        map[string]ConnFault
+	bandwidths        map[string]int    // key -> bytes per second of ConnThrottle
+	conflicts         map[string]string // key -> how a spec's mode conflict was resolved
+	routes            map[string]string // request pattern -> key, replaced rather than modified
+	routeMux          *http.ServeMux    // matches routes' patterns; nil without routes
+
+	history    map[string][]Decision
+	historyLen int
+	metrics    map[string]*KeyMetrics
+	origins    map[string]keyOrigin
+	events     eventLog // not cleared by Reset
+
+	experimentsMu sync.Mutex
+	experiments   map[string]Experiment // name -> lease, not cleared by Reset
+	// experimentsFreed is closed and replaced whenever an experiment ends.
+	experimentsFreed chan struct{}
+
+	hooksMu sync.Mutex
+	hooks   atomic.Pointer[[]*faultHook]
+	// injectHooks are the OnInject hooks, guarded like hooks.
+	injectHooks atomic.Pointer[[]*injectHook]
+	// decisionSubs are the WatchDecisions channels, sent to with callsMu held.
+	decisionSubs atomic.Pointer[[]chan Decision]
+	traceID      atomic.Pointer[func(ctx context.Context) string]
+	logger       atomic.Pointer[slog.Logger]
+	loggedConfig atomic.Pointer[ruleSnapshot] // the configuration logConfig last saw
+	// shared is the state served by another process, set by UseShared.
+	shared atomic.Pointer[sharedClient]
+
+	strictMode    StrictMode
+	knownKeys     map[string]bool // not cleared by Reset
+	knownPatterns []pattern
+	warnedKeys    sync.Map
+
+	instanceID     string
+	instanceBucket float64 // instanceBucket(instanceID)
+	build          Build
+
+	readyAt  time.Time
+	draining bool
+
+	budget      *Budget                // caps the faults of every rule, not cleared by Reset
+	budgetFires map[string][]time.Time // rule key -> when its rule fired within its Budget's window
+	globalFires []time.Time            // when rules fired within budget's window
+
+	runtimeTrace  atomic.Bool
+	profileLabels atomic.Bool
+
+	callSites     sync.Map // callSiteID -> CallSite, not cleared by Reset
+	callSiteLines atomic.Bool
+
+	// generation counts configuration changes. It starts at 1 so that a zero
+	// generation can mean "any" in conditional updates.
+	generation uint64
+	// changed is closed and replaced whenever the generation changes.
+	changed chan struct{}
+
+	clock      Clock       // tells the time of rules, TTLs and events
+	envGuard   func() bool // replaces the environment check if set
+	metricsOff bool        // Metrics are not kept
 
 	// Environment control
-	allowedEnvironments    = []string{"development", "staging", "testing"}
-	productionEnvironments = []string{"production", "prod"}
-)
+	allowedEnvironments    []string
+	productionEnvironments []string
+}
+
+// std is the Injector used by the package-level functions.
+var std = NewInjector()
+
+// NewInjector returns an Injector with no rules, independent of the default
+// one and of every other Injector.
+func NewInjector() *Injector {
+	in := &Injector{
+		knownKeys:              make(map[string]bool),
+		tenantHeader:           "X-Tenant-ID",
+		historyLen:             defaultHistoryLen,
+		metrics:                make(map[string]*KeyMetrics),
+		origins:                make(map[string]keyOrigin),
+		events:                 eventLog{size: defaultEventLogLen},
+		selectorRegistry:       make(map[string]Selector),
+		generation:             1,
+		changed:                make(chan struct{}),
+		experiments:            make(map[string]Experiment),
+		experimentsFreed:       make(chan struct{}),
+		allowedEnvironments:    []string{"development", "staging", "testing"},
+		productionEnvironments: []string{"production", "prod"},
+		clock:                  systemClock{},
+	}
+	in.mu.publish = in.publishLocked
+	in.mu.published = in.logConfig
+	in.instanceID = defaultInstanceID()
+	in.instanceBucket = instanceBucket(in.instanceID)
+	in.build = defaultBuild()
+	// resetLocked creates the maps; a new Injector is at generation 1
+	in.resetLocked()
+	in.generation = 1
+	in.mu.dirty = false
+	in.publishLocked()
+	return in
+}
+
+// Default returns the Injector used by the package-level functions.
+func Default() *Injector {
+	return std
+}
 
 // SetAllowedEnvironments configures which environments allow fault injection
+func (in *Injector) SetAllowedEnvironments(envs []string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.allowedEnvironments = envs
+}
+
+// SetAllowedEnvironments calls SetAllowedEnvironments on the default Injector.
 func SetAllowedEnvironments(envs []string) {
-	mu.Lock()
-	defer mu.Unlock()
-	allowedEnvironments = envs
+	std.SetAllowedEnvironments(envs)
 }
 
 // SetProductionEnvironments configures which environments are considered production
+func (in *Injector) SetProductionEnvironments(envs []string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.productionEnvironments = envs
+}
+
+// SetProductionEnvironments calls SetProductionEnvironments on the default Injector.
 func SetProductionEnvironments(envs []string) {
-	mu.Lock()
-	defer mu.Unlock()
-	productionEnvironments = envs
+	std.SetProductionEnvironments(envs)
+}
+
+// Production reports whether the current environment counts as production,
+// in which case the setters do nothing and no fault is ever injected.
+func (in *Injector) Production() bool {
+	return in.isProductionEnvironment()
+}
+
+// Production calls Production on the default Injector.
+func Production() bool {
+	return std.Production()
 }
 
 // isProductionEnvironment checks if the current environment is production
-func isProductionEnvironment() bool {
+func (in *Injector) isProductionEnvironment() bool {
+	if in.envGuard != nil {
+		return in.envGuard()
+	}
 	env := strings.ToLower(os.Getenv("ENVIRONMENT"))
 	if env == "" {
 		env = strings.ToLower(os.Getenv("ENV"))
@@ -47,14 +207,14 @@ func isProductionEnvironment() bool {
 	}
 
 	// Check if it's explicitly marked as production
-	for _, prodEnv := range productionEnvironments {
+	for _, prodEnv := range in.productionEnvironments {
 		if env == prodEnv {
 			return true
 		}
 	}
 
 	// Check if it's in allowed environments
-	for _, allowedEnv := range allowedEnvironments {
+	for _, allowedEnv := range in.allowedEnvironments {
 		if env == allowedEnv {
 			return false
 		}
@@ -65,139 +225,311 @@ func isProductionEnvironment() bool {
 }
 
 // Inject returns true if this key should fail.
-//   - If precise[key] > 0, it fails *only* when counters[key] == precise[key].
-//   - Otherwise if limits[key] > 0, it fails while counters[key] ≤ limits[key].
+//   - A first-N rule fails while the key's call count ≤ Count; an Nth rule
+//     fails *only* when the call count == Nth (see Rule for the other modes).
 //   - Fault injection is disabled in production environments.
-func Inject(key string) bool {
+//   - Keys restricted by SetSelectors, SetTenants or SetExcludeSynthetic only fail
+//     (and count) for matching calls.
+//   - Decisions for keys with a rule are recorded with their Reason in History.
+//   - Options such as WithAttempt, WithAttrs and WithoutCount pass per-call
+//     context to the decision.
+func (in *Injector) Inject(key string, opts ...InjectOption) bool {
+	return in.inject(withInjectOptions(context.Background(), opts), key)
+}
+
+// Inject calls Inject on the default Injector.
+func Inject(key string, opts ...InjectOption) bool {
+	return std.Inject(key, opts...)
+}
+
+func (in *Injector) inject(ctx context.Context, key string) bool {
+	fired, _ := in.evaluate(ctx, key)
+	return fired
+}
+
+// evaluate decides whether the call to key fails and returns the call count
+// the decision was made at, or 0 if the call was not counted.
+func (in *Injector) evaluate(ctx context.Context, key string) (bool, int) {
+	e, _ := in.evaluateEvent(ctx, key)
+	return e.Fired, e.Count
+}
+
+// evaluateEvent is evaluate returning the whole decision, and the mode of the
+// rule that made it.
+func (in *Injector) evaluateEvent(ctx context.Context, key string) (Event, Mode) {
 	// Disable fault injection in production
-	if isProductionEnvironment() {
-		return false
+	if in.isProductionEnvironment() {
+		return in.record(ctx, key, false, ReasonProduction, 0), ""
 	}
+	in.checkKnown(key)
 
-	mu.Lock()
-	defer mu.Unlock()
-
-	// bump attempt count
-	cnt := counters[key] + 1
-	counters[key] = cnt
+	switch {
+	case !in.syntheticAllowed(ctx, key):
+		return in.record(ctx, key, false, ReasonSynthetic, 0), ""
+	case !in.tenantAllowed(ctx, key):
+		return in.record(ctx, key, false, ReasonTenant, 0), ""
+	case !in.selected(ctx, key):
+		return in.record(ctx, key, false, ReasonSelector, 0), ""
+	}
+	if e, mode, ok := in.evaluateShared(ctx, key); ok {
+		return e, mode
+	}
 
-	// precise-nth behavior takes priority
-	if nth, ok := precise[key]; ok && nth > 0 {
-		return cnt == nth
+	s := in.snapshot()
+	r, ok := s.rules[s.ruleKey(key)]
+	if ok && !s.onInstance(r) {
+		return in.record(ctx, key, false, ReasonInstance, 0), r.Mode
+	}
+	if ok && !s.onBuild(r) {
+		return in.record(ctx, key, false, ReasonBuild, 0), r.Mode
+	}
+	if ok && !r.matched(ctx) {
+		return in.record(ctx, key, false, ReasonMatch, 0), r.Mode
+	}
+	if ok && !r.calledFrom(ctx, key) {
+		return in.record(ctx, key, false, ReasonCaller, 0), r.Mode
 	}
 
-	// fallback: first-N failures
-	if lim, ok := limits[key]; ok && lim > 0 {
-		return cnt <= lim
+	in.callsMu.Lock()
+	// bump attempt count
+	cnt := in.counters[key] + 1
+	if !uncounted(ctx) {
+		in.counters[key] = cnt
+		in.lastSeen[key] = in.timeNow()
 	}
+	if !ok {
+		e := in.recordLocked(ctx, s, key, false, ReasonDisabled, cnt)
+		in.callsMu.Unlock()
+		in.logDecision(ctx, e)
+		return e, ""
+	}
+	fired, reason := r.decide(cnt, in.timeNow())
+	if fired && !in.spendBudgetLocked(s, s.ruleKey(key), r, in.timeNow()) {
+		fired, reason = false, ReasonBudget
+	}
+	e := in.recordLocked(ctx, s, key, fired, reason, cnt)
+	in.callsMu.Unlock()
+	in.logDecision(ctx, e)
 
-	return false
+	if fired {
+		in.notifyFault(ctx, Fault{Key: key, Type: string(r.Mode), Count: cnt})
+		in.notifyInject(e)
+	}
+	return e, r.Mode
 }
 
 // InjectWithFn executes the provided function if fault injection should occur
-func InjectWithFn(key string, fn func() error) error {
-	if Inject(key) {
+func (in *Injector) InjectWithFn(key string, fn func() error) error {
+	if in.Inject(key) {
 		return fn()
 	}
 	return nil
 }
 
+// InjectWithFn calls InjectWithFn on the default Injector.
+func InjectWithFn(key string, fn func() error) error {
+	return std.InjectWithFn(key, fn)
+}
+
 // InjectWithFnContext executes the provided function if fault injection should occur (context-aware)
-func InjectWithFnContext(ctx context.Context, key string, fn func() error) error {
-	if InjectWithContext(ctx, key) {
+func (in *Injector) InjectWithFnContext(ctx context.Context, key string, fn func() error) error {
+	if in.InjectWithContext(ctx, key) {
 		return fn()
 	}
 	return nil
 }
 
+// InjectWithFnContext calls InjectWithFnContext on the default Injector.
+func InjectWithFnContext(ctx context.Context, key string, fn func() error) error {
+	return std.InjectWithFnContext(ctx, key, fn)
+}
+
 // InjectWithError is a convenience function that returns an error if injection should occur
-func InjectWithError(key string, message string) error {
-	if Inject(key) {
-		return fmt.Errorf("injected failure: %s", message)
+func (in *Injector) InjectWithError(key string, message string) error {
+	if fired, count := in.evaluate(context.Background(), key); fired {
+		return in.newInjectedError(key, count, message)
 	}
 	return nil
 }
 
+// InjectWithError calls InjectWithError on the default Injector.
+func InjectWithError(key string, message string) error {
+	return std.InjectWithError(key, message)
+}
+
 // InjectWithErrorf is a convenience function that returns a formatted error if injection should occur
-func InjectWithErrorf(key string, format string, args ...interface{}) error {
-	if Inject(key) {
-		return fmt.Errorf("injected failure: %s", fmt.Sprintf(format, args...))
+func (in *Injector) InjectWithErrorf(key string, format string, args ...interface{}) error {
+	if fired, count := in.evaluate(context.Background(), key); fired {
+		return in.newInjectedError(key, count, fmt.Sprintf(format, args...))
 	}
 	return nil
 }
 
+// InjectWithErrorf calls InjectWithErrorf on the default Injector.
+func InjectWithErrorf(key string, format string, args ...interface{}) error {
+	return std.InjectWithErrorf(key, format, args...)
+}
+
 // InjectWithContext checks for fault injection override in context
-func InjectWithContext(ctx context.Context, key string) bool {
+func (in *Injector) InjectWithContext(ctx context.Context, key string, opts ...InjectOption) bool {
+	fired, _ := in.evaluateWithContext(withInjectOptions(ctx, opts), key)
+	return fired
+}
+
+// InjectWithContext calls InjectWithContext on the default Injector.
+func InjectWithContext(ctx context.Context, key string, opts ...InjectOption) bool {
+	return std.InjectWithContext(ctx, key, opts...)
+}
+
+func (in *Injector) evaluateWithContext(ctx context.Context, key string) (bool, int) {
 	// Check if context has fault injection override
 	if ctx != nil {
 		if ctx.Err() != nil {
-			return false // Do not inject if context is cancelled
+			return in.record(ctx, key, false, ReasonCancelled, 0).Fired, 0 // Do not inject if context is cancelled
+		}
+		if override, ok := OverrideFromContext(ctx, key); ok {
+			in.checkKnown(key)
+			if e := in.record(ctx, key, override, ReasonOverride, 0); e.Fired {
+				in.notifyFault(ctx, Fault{Key: key, Type: "override"})
+				in.notifyInject(e)
+			}
+			return override, 0
 		}
-		if override, ok := ctx.Value("faultinject:" + key).(bool); ok {
-			return override
+		if fired, reason, count, ok := in.decideDirective(ctx, key); ok {
+			in.checkKnown(key)
+			if e := in.record(ctx, key, fired, reason, count); e.Fired {
+				in.notifyFault(ctx, Fault{Key: key, Type: "directive", Count: count})
+				in.notifyInject(e)
+			}
+			return fired, count
 		}
 	}
-	return Inject(key)
+	return in.evaluate(ctx, key)
 }
 
 // InjectWithContextError combines context checking with error return
-func InjectWithContextError(ctx context.Context, key string, message string) error {
-	if InjectWithContext(ctx, key) {
-		return fmt.Errorf("injected failure: %s", message)
+func (in *Injector) InjectWithContextError(ctx context.Context, key string, message string) error {
+	if fired, count := in.evaluateWithContext(ctx, key); fired {
+		return in.newInjectedError(key, count, message)
 	}
 	return nil
 }
 
+// InjectWithContextError calls InjectWithContextError on the default Injector.
+func InjectWithContextError(ctx context.Context, key string, message string) error {
+	return std.InjectWithContextError(ctx, key, message)
+}
+
 // SetFailures is the old API: fail the first `count` calls to key.
 // Fault injection is disabled in production environments.
-func SetFailures(key string, count int) {
+func (in *Injector) SetFailures(key string, count int) {
 	// Disable fault injection in production
-	if isProductionEnvironment() {
+	if in.isProductionEnvironment() {
 		return
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-	limits[key] = count
-	// clear any precise setting for this key
-	delete(precise, key)
-	counters[key] = 0
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setFailuresLocked(key, count)
+}
+
+// SetFailures calls SetFailures on the default Injector.
+func SetFailures(key string, count int) {
+	std.SetFailures(key, count)
+}
+
+func (in *Injector) setFailuresLocked(key string, count int) {
+	// replaces any other mode and TTL for this key
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.setMode(ModeFirstN, in.timeNow())
+		r.Count = count
+	})
+	in.restartCountsLocked(key)
 }
 
 // SetNthFailure makes Inject(key) return true *only* on the Nth call.
 // Fault injection is disabled in production environments.
-func SetNthFailure(key string, nth int) {
+func (in *Injector) SetNthFailure(key string, nth int) {
 	// Disable fault injection in production
-	if isProductionEnvironment() {
+	if in.isProductionEnvironment() {
 		return
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-	precise[key] = nth
-	// clear any first-N setting for this key
-	delete(limits, key)
-	counters[key] = 0
+	in.setNthPattern(key, func(r *Rule) { r.Nth = nth })
+}
+
+// SetNthFailure calls SetNthFailure on the default Injector.
+func SetNthFailure(key string, nth int) {
+	std.SetNthFailure(key, nth)
+}
+
+// Reset clears all configured behaviors and counters, including a readiness delay and
+// drain state.
+func (in *Injector) Reset() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.resetLocked()
 }
 
-// Reset clears all configured behaviors and counters.
+// Reset calls Reset on the default Injector.
 func Reset() {
-	mu.Lock()
-	defer mu.Unlock()
-	limits = make(map[string]int)
-	precise = make(map[string]int)
-	counters = make(map[string]int)
+	std.Reset()
+}
+
+func (in *Injector) resetLocked() {
+	in.rules = make(map[string]Rule)
+	in.selectors = make(map[string][]string)
+	in.tenants = make(map[string][]string)
+	in.excludeSynthetic = make(map[string]bool)
+	in.skews = make(map[string]skewRule)
+	in.corruptions = make(map[string]Corruption)
+	in.numericEdges = make(map[string]NumericEdge)
+	in.pageFaults = make(map[string]PageFault)
+	in.idempotencyFaults = make(map[string]IdempotencyFault)
+	in.quotaResponses = make(map[string]QuotaResponse)
+	in.sseFaults = make(map[string]SSEFault)
+	in.degradations = make(map[string]Degradation)
+	in.responseFaults = make(map[string]ResponseFault)
+	in.connFaults = make(map[string]ConnFault)
+	in.bandwidths = make(map[string]int)
+	in.conflicts = make(map[string]string)
+	in.routes, in.routeMux = nil, nil
+	in.patterns = nil
+	in.readyAt = time.Time{}
+	in.draining = false
+	in.bumpGenerationLocked()
+
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	in.counters = make(map[string]int)
+	in.history = make(map[string][]Decision)
+	in.lastSeen = make(map[string]time.Time)
+	in.budgetFires = make(map[string][]time.Time)
+	in.globalFires = nil
+}
+
+// Status returns remaining "first-N" failures per key, or 0 once the key's TTL has passed.
+func (in *Injector) Status() map[string]int {
+	return in.statusOf(in.snapshot())
 }
 
-// Status returns remaining "first-N" failures per key.
+// Status calls Status on the default Injector.
 func Status() map[string]int {
-	mu.Lock()
-	defer mu.Unlock()
-	out := make(map[string]int, len(limits))
-	for k, lim := range limits {
-		used := counters[k]
-		rem := lim - used
-		if rem < 0 {
+	return std.Status()
+}
+
+// statusOf is Status for the configuration s.
+func (in *Injector) statusOf(s *ruleSnapshot) map[string]int {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	out := make(map[string]int)
+	now := in.timeNow()
+	for k, r := range s.rules {
+		if r.Mode != ModeFirstN {
+			continue
+		}
+		rem := r.Count - in.counters[k]
+		if rem < 0 || r.expired(now) {
 			rem = 0
 		}
 		out[k] = rem
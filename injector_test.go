@@ -1,8 +1,12 @@
+//go:build faultinject
+
 package faultinject
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -313,6 +317,9 @@ func TestEnvironmentControl(t *testing.T) {
 			if result != tt.expectedResult {
 				t.Errorf("Inject() in %s environment = %v, want %v", tt.environment, result, tt.expectedResult)
 			}
+			if Production() == tt.expectedResult {
+				t.Errorf("Production() in %s environment = %v, want %v", tt.environment, Production(), !tt.expectedResult)
+			}
 		})
 	}
 }
@@ -447,3 +454,78 @@ func TestContextTimeout(t *testing.T) {
 		}
 	})
 }
+
+func TestNewInjectorIsolation(t *testing.T) {
+	resetState()
+
+	a := NewInjector()
+	b := NewInjector()
+	a.SetFailures("db", 2)
+	SetFailures("db", 1)
+
+	if b.Inject("db") {
+		t.Error("Expected an unconfigured Injector not to fail")
+	}
+	if !a.Inject("db") || !a.Inject("db") || a.Inject("db") {
+		t.Error("Expected the Injector to fail exactly its first 2 calls")
+	}
+	if got := FullStatus()["db"].Calls; got != 0 {
+		t.Errorf("Expected the default Injector's count to be 0, got %d", got)
+	}
+	if !Inject("db") {
+		t.Error("Expected the default Injector to keep its own rule")
+	}
+
+	a.Reset()
+	if a.Inject("db") {
+		t.Error("Expected Reset to clear only the Injector's rules")
+	}
+	if got := FullStatus()["db"].Calls; got != 1 {
+		t.Errorf("Expected the default Injector's count to be 1, got %d", got)
+	}
+	if Default() != std {
+		t.Error("Expected Default to return the package-level Injector")
+	}
+}
+
+func TestNewInjectorParallel(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4} {
+		t.Run(fmt.Sprintf("first %d", n), func(t *testing.T) {
+			t.Parallel()
+			in := NewInjector()
+			in.SetFailures("shared-key", n)
+
+			failed := 0
+			for i := 0; i < 10; i++ {
+				if in.Inject("shared-key") {
+					failed++
+				}
+			}
+			if failed != n {
+				t.Errorf("Expected %d failures, got %d", n, failed)
+			}
+			if got := in.FullStatus()["shared-key"].Calls; got != 10 {
+				t.Errorf("Expected 10 calls, got %d", got)
+			}
+		})
+	}
+}
+
+func TestNewInjectorLoadSpec(t *testing.T) {
+	resetState()
+
+	path := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(path, []byte("failures:\n  checkout: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	in := NewInjector()
+	if err := in.LoadSpec(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !in.Inject("checkout") {
+		t.Error("Expected the spec to configure the Injector")
+	}
+	if Inject("checkout") {
+		t.Error("Expected the spec not to configure the default Injector")
+	}
+}
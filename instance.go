@@ -0,0 +1,84 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"os"
+)
+
+// SetInstanceID sets the ID rules with Instances hash to decide whether they
+// apply to this process. It defaults to the host name, which is the pod
+// name on Kubernetes, and SyncFromRemote sets it to SyncOptions.Instance.
+// Like the environment lists, it survives Reset.
+func (in *Injector) SetInstanceID(id string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.instanceID = id
+	in.instanceBucket = instanceBucket(id)
+	in.mu.dirty = true
+}
+
+// SetInstanceID calls SetInstanceID on the default Injector.
+func SetInstanceID(id string) {
+	std.SetInstanceID(id)
+}
+
+// InstanceID returns the ID set by SetInstanceID.
+func (in *Injector) InstanceID() string {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.instanceID
+}
+
+// InstanceID calls InstanceID on the default Injector.
+func InstanceID() string {
+	return std.InstanceID()
+}
+
+// SetInstances limits key's rule to fraction of the fleet, from 0 to 1, as
+// Rule.Instances does. A fraction of zero or less, or of 1 or more, applies
+// it to every instance again. Fault injection is disabled in production
+// environments.
+func (in *Injector) SetInstances(key string, fraction float64) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Instances = fraction
+		if fraction <= 0 || fraction >= 1 {
+			r.Instances = 0
+		}
+	})
+}
+
+// SetInstances calls SetInstances on the default Injector.
+func SetInstances(key string, fraction float64) {
+	std.SetInstances(key, fraction)
+}
+
+// defaultInstanceID is the host name, or "" if it is unknown.
+func defaultInstanceID() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+// instanceBucket maps id to a stable position in [0, 1). A rule with
+// Instances f applies to the instances below f, so every rule picks the same
+// instances and raising f only adds to them.
+func instanceBucket(id string) float64 {
+	sum := sha256.Sum256([]byte(id))
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+}
+
+// onInstance reports whether r applies to this instance.
+func (s *ruleSnapshot) onInstance(r Rule) bool {
+	return r.Instances <= 0 || r.Instances >= 1 || s.instanceBucket < r.Instances
+}
@@ -0,0 +1,121 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestInstanceBucket(t *testing.T) {
+	const fleet = 2000
+	picked := 0
+	for i := 0; i < fleet; i++ {
+		id := fmt.Sprintf("orders-%d", i)
+		b := instanceBucket(id)
+		if b < 0 || b >= 1 {
+			t.Fatalf("Expected a bucket in [0, 1) for %s, got %g", id, b)
+		}
+		if b != instanceBucket(id) {
+			t.Fatalf("Expected a stable bucket for %s", id)
+		}
+		if b < 0.1 {
+			picked++
+		}
+	}
+	if picked < fleet/20 || picked > fleet*3/20 {
+		t.Errorf("Expected about 10%% of %d instances to be picked, got %d", fleet, picked)
+	}
+}
+
+func TestInstances(t *testing.T) {
+	resetState()
+	// find an instance inside and one outside the first 10% of the fleet
+	var inside, outside string
+	for i := 0; inside == "" || outside == ""; i++ {
+		id := fmt.Sprintf("orders-%d", i)
+		if instanceBucket(id) < 0.1 {
+			inside = id
+		} else {
+			outside = id
+		}
+	}
+
+	tests := []struct {
+		name     string
+		instance string
+		fraction float64
+		expected bool
+	}{
+		{name: "picked instance", instance: inside, fraction: 0.1, expected: true},
+		{name: "other instance", instance: outside, fraction: 0.1, expected: false},
+		{name: "whole fleet", instance: outside, fraction: 0, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			in.SetInstanceID(tt.instance)
+			in.SetRule("db-connect", Rule{Mode: ModeFirstN, Count: 1, Instances: tt.fraction, Latency: FixedLatency(time.Millisecond)})
+			if got := in.Inject("db-connect"); got != tt.expected {
+				t.Errorf("Expected Inject to return %v on %s, got %v", tt.expected, tt.instance, got)
+			}
+			d, _ := in.InjectLatency(context.Background(), "db-connect")
+			if got := d > 0; got != tt.expected {
+				t.Errorf("Expected latency %v on %s, got %v", tt.expected, tt.instance, d)
+			}
+			if !tt.expected {
+				h := in.History("db-connect")
+				if len(h) != 1 || h[0].Reason != ReasonInstance {
+					t.Errorf("Expected a %s decision, got %+v", ReasonInstance, h)
+				}
+				if in.Status()["db-connect"] != 1 {
+					t.Errorf("Expected the excluded call not to be counted, got %v", in.Status())
+				}
+			}
+		})
+	}
+}
+
+func TestSetInstances(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-connect", 1)
+	in.SetInstances("db-connect", 0.25)
+	if got := in.Rules()["db-connect"].Instances; got != 0.25 {
+		t.Errorf("Expected Instances 0.25, got %g", got)
+	}
+	in.SetInstances("db-connect", 1)
+	if got := in.Rules()["db-connect"].Instances; got != 0 {
+		t.Errorf("Expected a fraction of 1 to clear Instances, got %g", got)
+	}
+
+	if err := in.LoadSpecFromBytes([]byte("rules:\n  cache-get: {count: 1, instances: 0.5}\n"), FormatYAML); err != nil {
+		t.Fatalf("LoadSpecFromBytes returned error: %v", err)
+	}
+	if got := in.Rules()["cache-get"].Instances; got != 0.5 {
+		t.Errorf("Expected Instances 0.5 from the spec, got %g", got)
+	}
+}
+
+func TestSetInstancesBeforeRule(t *testing.T) {
+	resetState()
+	var outside string
+	for i := 0; outside == ""; i++ {
+		if id := fmt.Sprintf("orders-%d", i); instanceBucket(id) >= 0.1 {
+			outside = id
+		}
+	}
+	in := NewInjector()
+	in.SetInstanceID(outside)
+	in.SetInstances("db-connect", 0.1)
+	in.SetFailures("db-connect", 1)
+
+	if in.Inject("db-connect") {
+		t.Error("Expected an instance outside the fraction set before the rule not to fire")
+	}
+	if got := in.Rules()["db-connect"].Instances; got != 0.1 {
+		t.Errorf("Expected Instances 0.1, got %g", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ValidateInvariants checks the consistency of the injector's internal state
+// and returns every violation found, joined, or nil. It is meant for stress
+// tests and debugging: a violation comes from a bug in this package or from an
+// inconsistent Rule passed to SetRule.
+func (in *Injector) ValidateInvariants() error {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+
+	var errs []error
+	for _, k := range sortedKeys(in.counters) {
+		if in.counters[k] < 0 {
+			errs = append(errs, fmt.Errorf("%s: negative call count %d", k, in.counters[k]))
+		}
+	}
+
+	for _, k := range sortedKeys(in.rules) {
+		r := in.rules[k]
+		if r.empty() {
+			errs = append(errs, fmt.Errorf("%s: empty rule stored", k))
+		}
+		if err := r.validateMode(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", k, err))
+		}
+		if compilePattern(k) != nil && !in.patternRegisteredLocked(k) {
+			errs = append(errs, fmt.Errorf("%s: pattern rule not registered", k))
+		}
+	}
+
+	for i, p := range in.patterns {
+		if i > 0 && (len(in.patterns[i-1].key) < len(p.key) || in.patterns[i-1].key == p.key) {
+			errs = append(errs, fmt.Errorf("%s: patterns out of order or duplicated", p.key))
+		}
+	}
+
+	for _, k := range sortedKeys(in.metrics) {
+		m := in.metrics[k]
+		var total uint64
+		for _, n := range m.Evaluations {
+			total += n
+		}
+		if m.Injected > total {
+			errs = append(errs, fmt.Errorf("%s: %d injections out of %d evaluations", k, m.Injected, total))
+		}
+	}
+
+	for _, k := range sortedKeys(in.history) {
+		if len(in.history[k]) > in.historyLen {
+			errs = append(errs, fmt.Errorf("%s: %d decisions kept, limit is %d", k, len(in.history[k]), in.historyLen))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateInvariants calls ValidateInvariants on the default Injector.
+func ValidateInvariants() error {
+	return std.ValidateInvariants()
+}
+
+// validateMode reports settings left over from a mode other than r's.
+func (r Rule) validateMode() error {
+	if r.Count != 0 && r.Mode != ModeFirstN {
+		return fmt.Errorf("count %d set for mode %q", r.Count, r.Mode)
+	}
+	if r.Nth != 0 && r.Mode != ModeNth {
+		return fmt.Errorf("nth %d set for mode %q", r.Nth, r.Mode)
+	}
+	if (len(r.Ranges) > 0 || r.Every != 0) && r.Mode != ModeNth {
+		return fmt.Errorf("nth calls set for mode %q", r.Mode)
+	}
+	if r.Rate != 0 && r.Mode != ModeRate {
+		return fmt.Errorf("rate %g set for mode %q", r.Rate, r.Mode)
+	}
+	if (r.Flap != nil) != (r.Mode == ModeFlapping) {
+		return fmt.Errorf("flap cycle does not match mode %q", r.Mode)
+	}
+	if (len(r.Actions) > 0) != (r.Mode == ModeActions) {
+		return fmt.Errorf("actions do not match mode %q", r.Mode)
+	}
+	return nil
+}
+
+func (in *Injector) patternRegisteredLocked(key string) bool {
+	for _, p := range in.patterns {
+		if p.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
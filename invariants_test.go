@@ -0,0 +1,148 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// hammer calls fn from workers goroutines, calls times each, and returns how
+// many calls returned true.
+func hammer(workers, calls int, fn func() bool) int64 {
+	var fired atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				if fn() {
+					fired.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return fired.Load()
+}
+
+func TestConcurrentCounting(t *testing.T) {
+	const workers, calls = 16, 500
+
+	tests := []struct {
+		name      string
+		configure func()
+		inject    func() bool
+		expected  int64
+	}{
+		{name: "first-n", configure: func() { SetFailures("db", 100) }, inject: func() bool { return Inject("db") }, expected: 100},
+		{name: "nth", configure: func() { SetNthFailure("db", 4000) }, inject: func() bool { return Inject("db") }, expected: 1},
+		{name: "context", configure: func() { SetFailures("db", 250) }, inject: func() bool { return InjectWithContext(context.Background(), "db") }, expected: 250},
+		{name: "pattern", configure: func() { SetFailures("d*", 100) }, inject: func() bool { return Inject("db") }, expected: 100},
+		{name: "error", configure: func() { SetNthFailure("db", 1) }, inject: func() bool { return InjectWithError("db", "boom") != nil }, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.configure()
+			before := Metrics()["db"].Injected
+
+			if got := hammer(workers, calls, tt.inject); got != tt.expected {
+				t.Errorf("Expected %d injections, got %d", tt.expected, got)
+			}
+			if got := FullStatus()["db"].Calls; got != workers*calls {
+				t.Errorf("Expected %d calls counted, got %d", workers*calls, got)
+			}
+			if got := Metrics()["db"].Injected - before; got != uint64(tt.expected) {
+				t.Errorf("Expected %d injections in metrics, got %d", tt.expected, got)
+			}
+			if err := ValidateInvariants(); err != nil {
+				t.Errorf("Invariants violated: %v", err)
+			}
+		})
+	}
+}
+
+func TestConcurrentReconfiguration(t *testing.T) {
+	resetState()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			switch i % 6 {
+			case 0:
+				SetFailures("db", 3)
+			case 1:
+				SetNthFailure("db", 2)
+			case 2:
+				SetFailureRate("db", 0.5)
+			case 3:
+				SetFlapping("db", FlapCycle{Period: time.Second, Unhealthy: time.Millisecond})
+			case 4:
+				SetFailures("d?", 1)
+			case 5:
+				SetLatency("db", FixedLatency(0))
+			}
+		}
+	}()
+
+	hammer(8, 2000, func() bool { return Inject("db") })
+	close(done)
+	wg.Wait()
+	if err := ValidateInvariants(); err != nil {
+		t.Errorf("Invariants violated: %v", err)
+	}
+}
+
+func TestValidateInvariants(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func()
+	}{
+		{name: "negative count", corrupt: func() { std.counters["db"] = -1 }},
+		{name: "empty rule", corrupt: func() { std.rules["db"] = Rule{} }},
+		{name: "leftover nth", corrupt: func() { std.rules["db"] = Rule{Mode: ModeFirstN, Count: 1, Nth: 2} }},
+		{name: "flapping without cycle", corrupt: func() { std.rules["db"] = Rule{Mode: ModeFlapping} }},
+		{name: "unregistered pattern", corrupt: func() { std.rules["db-*"] = Rule{Mode: ModeFirstN, Count: 1} }},
+		{name: "injections exceed evaluations", corrupt: func() {
+			std.metrics["corrupt"] = &KeyMetrics{Evaluations: map[Reason]uint64{}, Injected: 1}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailures("api-*", 1)
+			Inject("api-call")
+			if err := ValidateInvariants(); err != nil {
+				t.Fatalf("Expected a consistent state, got %v", err)
+			}
+
+			std.mu.Lock()
+			std.callsMu.Lock()
+			tt.corrupt()
+			std.callsMu.Unlock()
+			std.mu.Unlock()
+			if err := ValidateInvariants(); err == nil {
+				t.Error("Expected a violation")
+			}
+
+			// metrics outlive Reset
+			std.callsMu.Lock()
+			delete(std.metrics, "corrupt")
+			std.callsMu.Unlock()
+		})
+	}
+}
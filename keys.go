@@ -0,0 +1,106 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyInfo describes a key that has been evaluated, for discovering which
+// keys a running program can inject without reading its source.
+type KeyInfo struct {
+	Key       string    `json:"key"`
+	Calls     uint64    `json:"calls"`    // evaluations, whatever their outcome
+	Injected  uint64    `json:"injected"` // evaluations that fired
+	FirstSeen time.Time `json:"first_seen"`
+	// File and Line locate the first call that evaluated the key, in the
+	// nearest caller outside this module and the standard library. They are
+	// empty for calls made only from those, such as by HTTPMiddleware
+	// mounted directly on an http.Server.
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// keyOrigin is where and when a key was first evaluated.
+type keyOrigin struct {
+	firstSeen time.Time
+	file      string
+	line      int
+}
+
+// modulePath prefixes the functions of this module and its integrations,
+// which are skipped when locating a key's caller.
+const modulePath = "github.com/talinashro/go-fi"
+
+// Keys returns every key evaluated so far, sorted by key. Like Metrics, it
+// survives Reset and Prune, so a key stays listed for the life of the
+// Injector once it has been called.
+func (in *Injector) Keys() []KeyInfo {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	keys := make([]KeyInfo, 0, len(in.metrics))
+	for key, m := range in.metrics {
+		info := KeyInfo{Key: key, Injected: m.Injected}
+		for _, n := range m.Evaluations {
+			info.Calls += n
+		}
+		if o, ok := in.origins[key]; ok {
+			info.FirstSeen, info.File, info.Line = o.firstSeen, o.file, o.line
+		}
+		keys = append(keys, info)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// Keys calls Keys on the default Injector.
+func Keys() []KeyInfo {
+	return std.Keys()
+}
+
+// recordOriginLocked notes when and where key was first seen. It must be
+// called with callsMu held.
+func (in *Injector) recordOriginLocked(key string) {
+	if _, ok := in.origins[key]; ok {
+		return
+	}
+	o := keyOrigin{firstSeen: in.timeNow()}
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	for {
+		frame, more := frames.Next()
+		if !inModule(frame) && !inStdlib(frame.Function) {
+			o.file, o.line = frame.File, frame.Line
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	in.origins[key] = o
+}
+
+// inModule reports whether frame belongs to this module, other than its
+// tests.
+func inModule(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, modulePath+".") || strings.HasPrefix(frame.Function, modulePath+"/")
+}
+
+// inStdlib reports whether fn, a runtime function name, is in the standard
+// library, whose import paths have no dot in their first element.
+func inStdlib(fn string) bool {
+	first, _, _ := strings.Cut(fn, "/")
+	if !strings.Contains(fn, "/") {
+		first, _, _ = strings.Cut(fn, ".")
+	}
+	return fn != "" && first != "main" && !strings.Contains(first, ".")
+}
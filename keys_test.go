@@ -0,0 +1,98 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-insert", 1)
+	in.Inject("db-insert")
+	in.Inject("db-insert")
+	in.Inject("cache-get")
+	in.Reset()
+	in.Inject("cache-get")
+
+	keys := in.Keys()
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %+v", keys)
+	}
+	tests := []struct {
+		key      string
+		calls    uint64
+		injected uint64
+	}{
+		{key: "cache-get", calls: 2},
+		{key: "db-insert", calls: 2, injected: 1},
+	}
+	for i, tt := range tests {
+		k := keys[i]
+		if k.Key != tt.key || k.Calls != tt.calls || k.Injected != tt.injected {
+			t.Errorf("Expected %s with %d calls and %d injected, got %+v", tt.key, tt.calls, tt.injected, k)
+		}
+		if filepath.Base(k.File) != "keys_test.go" || k.Line == 0 {
+			t.Errorf("Expected %s to be first seen in keys_test.go, got %s:%d", tt.key, k.File, k.Line)
+		}
+		if k.FirstSeen.IsZero() {
+			t.Errorf("Expected %s to have a first-seen time", tt.key)
+		}
+	}
+}
+
+func TestKeysSkipsStdlibCallers(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	server := httptest.NewServer(in.HTTPMiddleware("api")(http.NotFoundHandler()))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	keys := in.Keys()
+	if len(keys) != 1 || keys[0].Key != "api" || keys[0].File != "" {
+		t.Errorf("Expected api without a location, got %+v", keys)
+	}
+}
+
+func TestControlHandlerKeys(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.Inject("db-insert")
+
+	rec := httptest.NewRecorder()
+	in.ControlHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/keys", nil))
+	var keys []KeyInfo
+	if err := json.NewDecoder(rec.Body).Decode(&keys); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].Key != "db-insert" || keys[0].Calls != 1 {
+		t.Errorf("Expected db-insert with 1 call, got %+v", keys)
+	}
+}
+
+func TestInStdlib(t *testing.T) {
+	tests := []struct {
+		fn       string
+		expected bool
+	}{
+		{fn: "net/http.HandlerFunc.ServeHTTP", expected: true},
+		{fn: "runtime.goexit", expected: true},
+		{fn: "main.main", expected: false},
+		{fn: "github.com/acme/shop/store.(*DB).Save", expected: false},
+		{fn: "", expected: false},
+	}
+	for _, tt := range tests {
+		if got := inStdlib(tt.fn); got != tt.expected {
+			t.Errorf("Expected inStdlib(%q) = %v, got %v", tt.fn, tt.expected, got)
+		}
+	}
+}
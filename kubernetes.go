@@ -0,0 +1,186 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configMapFile is one key of a mounted ConfigMap.
+type configMapFile struct {
+	name string
+	data []byte
+}
+
+// WatchConfigMap is WatchSpec for a Kubernetes ConfigMap mounted as a
+// volume at dir, so a chaos operator can manage faults across a cluster with
+// kubectl apply. Every key of the ConfigMap is a spec, in any format
+// LoadSpec accepts, and they are applied in name order, the first replacing
+// the current state unless opts.Merge is set and the others merged on top.
+// The kubelet swaps every key at once when the ConfigMap changes, so a
+// change is applied as soon as it is seen, without the wait WatchSpec makes
+// for writes to settle. Removing every key clears every rule, even with
+// opts.Merge set. Files whose
+// names start with a dot are ignored.
+func (in *Injector) WatchConfigMap(dir string, opts WatchOptions) (stop func(), err error) {
+	files, err := readConfigMap(dir)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := parseConfigMap(dir, files)
+	if err != nil {
+		return nil, err
+	}
+	in.applyConfigMap(specs, opts.Merge)
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		last := configMapVersion(files)
+		bad, failed := "", false // invalid version already reported
+		missing := false         // read failure already reported
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			files, err := readConfigMap(dir)
+			if err != nil {
+				if !missing && opts.OnError != nil {
+					opts.OnError(err)
+				}
+				missing = true
+				continue
+			}
+			missing = false
+			v := configMapVersion(files)
+			if v == last || (failed && v == bad) {
+				continue
+			}
+			specs, err := parseConfigMap(dir, files)
+			if err != nil {
+				bad, failed = v, true
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+				continue
+			}
+			last, bad, failed = v, "", false
+			in.applyConfigMap(specs, opts.Merge)
+			if opts.OnReload != nil {
+				opts.OnReload()
+			}
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
+// WatchConfigMap calls WatchConfigMap on the default Injector.
+func WatchConfigMap(dir string, opts WatchOptions) (stop func(), err error) {
+	return std.WatchConfigMap(dir, opts)
+}
+
+// readConfigMap returns the keys of the ConfigMap mounted at dir, by name.
+// The kubelet keeps the keys in a directory "..data" links to and swaps the
+// link in one rename, so reading through the link sees every key of one
+// version.
+func readConfigMap(dir string) ([]configMapFile, error) {
+	if fi, err := os.Stat(filepath.Join(dir, "..data")); err == nil && fi.IsDir() {
+		dir = filepath.Join(dir, "..data")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []configMapFile
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, configMapFile{name: e.Name(), data: data})
+	}
+	return files, nil
+}
+
+// parseConfigMap parses every key of a ConfigMap, failing on the first
+// invalid one.
+func parseConfigMap(dir string, files []configMapFile) ([]Spec, error) {
+	specs := make([]Spec, len(files))
+	for i, f := range files {
+		cfg, err := parseSpec(f.data, formatOf(f.name))
+		if err != nil {
+			return nil, inFile(err, filepath.Join(dir, f.name))
+		}
+		specs[i] = cfg
+	}
+	return specs, nil
+}
+
+// applyConfigMap applies every spec of a ConfigMap under one lock, so Inject
+// never sees some of its keys applied and others not. An empty ConfigMap
+// clears every rule, as merging nothing would keep them all.
+func (in *Injector) applyConfigMap(specs []Spec, merge bool) {
+	if len(specs) == 0 {
+		in.applySpec(Spec{}, false)
+		return
+	}
+	conflicts := make(map[string]string)
+	in.mu.Lock()
+	for i, cfg := range specs {
+		maps.Copy(conflicts, in.applySpecLocked(cfg, merge || i > 0))
+	}
+	in.mu.Unlock()
+	in.logConflicts(conflicts)
+}
+
+// configMapVersion identifies the contents of a ConfigMap, to tell when it
+// changes.
+func configMapVersion(files []configMapFile) string {
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "%s\x00%d\x00", f.name, len(f.data))
+		b.Write(f.data)
+	}
+	return b.String()
+}
+
+// serveLivez answers liveness probes: the control server is alive as long
+// as it answers.
+func (in *Injector) serveLivez(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+// serveReadyz answers readiness probes, failing them with 503 until the
+// delay set by DelayReadiness has passed and once StartDrain has been called,
+// so Kubernetes only routes to a pod that has started and is not shutting
+// down.
+func (in *Injector) serveReadyz(w http.ResponseWriter, r *http.Request) {
+	if in.Draining() {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	if !in.Ready() {
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,171 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeConfigMap lays out keys in dir as the kubelet does: the files live in
+// a timestamped directory that "..data" links to, every key links through
+// "..data", and an update swaps the "..data" link in one rename before
+// adding and removing key links.
+func writeConfigMap(t *testing.T, dir string, keys map[string]string) {
+	t.Helper()
+	ts := fmt.Sprintf("..%d", time.Now().UnixNano())
+	if err := os.Mkdir(filepath.Join(dir, ts), 0755); err != nil {
+		t.Fatalf("Failed to create data directory: %v", err)
+	}
+	for k, v := range keys {
+		if err := os.WriteFile(filepath.Join(dir, ts, k), []byte(v), 0644); err != nil {
+			t.Fatalf("Failed to write key: %v", err)
+		}
+	}
+	if err := os.Symlink(ts, filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatalf("Failed to link data directory: %v", err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("Failed to swap data directory: %v", err)
+	}
+	for k := range keys {
+		os.Symlink(filepath.Join("..data", k), filepath.Join(dir, k)) // kept if it exists
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if _, ok := keys[e.Name()]; !ok && e.Name()[0] != '.' {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+func TestWatchConfigMap(t *testing.T) {
+	resetState()
+	dir := t.TempDir()
+	writeConfigMap(t, dir, map[string]string{
+		"a.yaml": "failures:\n  db: 2\n",
+		"b.json": `{"failures": {"cache": 1}}`,
+	})
+
+	var reloads, errs atomic.Int32
+	stop, err := WatchConfigMap(dir, WatchOptions{
+		Interval: 5 * time.Millisecond,
+		OnError:  func(error) { errs.Add(1) },
+		OnReload: func() { reloads.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("WatchConfigMap returned error: %v", err)
+	}
+	defer stop()
+	if st := Status(); st["db"] != 2 || st["cache"] != 1 {
+		t.Errorf("Expected every key to be applied, got %v", st)
+	}
+
+	// an invalid key is reported once and leaves the state alone
+	writeConfigMap(t, dir, map[string]string{"a.yaml": "failures: [\n"})
+	waitFor(t, func() bool { return errs.Load() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	if errs.Load() != 1 || Status()["db"] != 2 {
+		t.Errorf("Expected one error and unchanged state, got %d errors and %v", errs.Load(), Status())
+	}
+
+	writeConfigMap(t, dir, map[string]string{"a.yaml": "failures:\n  db: 5\n"})
+	waitFor(t, func() bool { return reloads.Load() == 1 })
+	if st := Status(); st["db"] != 5 || st["cache"] != 0 {
+		t.Errorf("Expected only db to be set after the update, got %v", st)
+	}
+
+	writeConfigMap(t, dir, map[string]string{})
+	waitFor(t, func() bool { return reloads.Load() == 2 })
+	if st := Status(); len(st) != 0 {
+		t.Errorf("Expected an empty ConfigMap to clear every rule, got %v", st)
+	}
+}
+
+func TestWatchConfigMapEmptyMerge(t *testing.T) {
+	resetState()
+	SetFailures("api", 1) // set in code, kept by the merge
+	dir := t.TempDir()
+	writeConfigMap(t, dir, map[string]string{"a.yaml": "failures:\n  db: 2\n"})
+
+	var reloads atomic.Int32
+	stop, err := WatchConfigMap(dir, WatchOptions{
+		Interval: 5 * time.Millisecond,
+		Merge:    true,
+		OnReload: func() { reloads.Add(1) },
+	})
+	if err != nil {
+		t.Fatalf("WatchConfigMap returned error: %v", err)
+	}
+	defer stop()
+	if st := Status(); st["db"] != 2 || st["api"] != 1 {
+		t.Errorf("Expected the ConfigMap merged, got %v", st)
+	}
+
+	writeConfigMap(t, dir, map[string]string{})
+	waitFor(t, func() bool { return reloads.Load() == 1 })
+	if st := Status(); len(st) != 0 {
+		t.Errorf("Expected an empty ConfigMap to clear every rule, got %v", st)
+	}
+}
+
+func TestWatchConfigMapInvalid(t *testing.T) {
+	resetState()
+	if _, err := WatchConfigMap(filepath.Join(t.TempDir(), "missing"), WatchOptions{}); err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+
+	dir := t.TempDir()
+	writeConfigMap(t, dir, map[string]string{"a.yaml": "failures: [\n"})
+	if _, err := WatchConfigMap(dir, WatchOptions{}); err == nil {
+		t.Error("Expected an error for an invalid key")
+	}
+}
+
+func TestProbes(t *testing.T) {
+	resetState()
+	srv := httptest.NewServer(ControlHandler(nil))
+	defer srv.Close()
+
+	probe := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if got := probe("/livez"); got != http.StatusOK {
+		t.Errorf("Expected /livez to return 200, got %d", got)
+	}
+	if got := probe("/readyz"); got != http.StatusOK {
+		t.Errorf("Expected /readyz to return 200, got %d", got)
+	}
+
+	DelayReadiness(time.Hour)
+	if got := probe("/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected /readyz to return 503 while starting, got %d", got)
+	}
+	if got := probe("/livez"); got != http.StatusOK {
+		t.Errorf("Expected /livez to return 200 while starting, got %d", got)
+	}
+	DelayReadiness(0)
+	if got := probe("/readyz"); got != http.StatusOK {
+		t.Errorf("Expected /readyz to return 200 once started, got %d", got)
+	}
+
+	StartDrain()
+	if got := probe("/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("Expected /readyz to return 503 while draining, got %d", got)
+	}
+	if got := probe("/livez"); got != http.StatusOK {
+		t.Errorf("Expected /livez to return 200 while draining, got %d", got)
+	}
+}
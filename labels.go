@@ -0,0 +1,117 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// A rule's Labels group it with other rules, such as those of one team or
+// scenario, so experiments sharing an injector can be listed and cleared
+// on their own:
+//
+//	rules:
+//	  db-query:
+//	    count: 1
+//	    labels: {team: payments, scenario: checkout}
+//
+// Label selectors are comma-separated requirements that must all hold:
+// "name=value", with the value matched like a rule key, or "name" for any
+// value, as in "team=payments,scenario=check*".
+
+// SetLabels replaces the labels of key's rule. Empty labels remove them.
+// Fault injection is disabled in production environments.
+func (in *Injector) SetLabels(key string, labels map[string]string) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Labels = nil
+		if len(labels) > 0 {
+			r.Labels = maps.Clone(labels)
+		}
+	})
+}
+
+// SetLabels calls SetLabels on the default Injector.
+func SetLabels(key string, labels map[string]string) {
+	std.SetLabels(key, labels)
+}
+
+// KeysByLabel returns the sorted keys whose rules match selector. An empty
+// selector matches none.
+func (in *Injector) KeysByLabel(selector string) []string {
+	return keysByLabel(in.snapshot().rules, selector)
+}
+
+// KeysByLabel calls KeysByLabel on the default Injector.
+func KeysByLabel(selector string) []string {
+	return std.KeysByLabel(selector)
+}
+
+// ResetByLabel clears every key whose rule matches selector, as if it had
+// never been set, leaving the rest untouched.
+func (in *Injector) ResetByLabel(selector string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.resetByLabelLocked(selector)
+}
+
+// ResetByLabel calls ResetByLabel on the default Injector.
+func ResetByLabel(selector string) {
+	std.ResetByLabel(selector)
+}
+
+func (in *Injector) resetByLabelLocked(selector string) {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	in.bumpGenerationLocked()
+	for _, key := range keysByLabel(in.rules, selector) {
+		in.deleteKeyLocked(key)
+	}
+}
+
+func keysByLabel(rules map[string]Rule, selector string) []string {
+	var keys []string
+	for key, r := range rules {
+		if r.labelled(selector) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// labelled reports whether r's labels satisfy every requirement of selector.
+func (r Rule) labelled(selector string) bool {
+	if selector == "" {
+		return false
+	}
+	for _, req := range strings.Split(selector, ",") {
+		name, want, hasValue := strings.Cut(strings.TrimSpace(req), "=")
+		got, ok := r.Labels[name]
+		if name == "" || !ok || hasValue && !MatchKey(want, got) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateLabels reports label names that a selector could not name.
+func (r Rule) validateLabels() error {
+	for name := range r.Labels {
+		if name == "" || strings.ContainsAny(name, "=, ") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func setLabelledRules() {
+	SetFailures("db-query", 3)
+	SetLabels("db-query", map[string]string{"team": "payments", "scenario": "checkout"})
+	SetFailures("cache-get", 3)
+	SetLabels("cache-get", map[string]string{"team": "payments", "scenario": "refund"})
+	SetFailures("search", 3)
+	SetLabels("search", map[string]string{"team": "discovery"})
+	SetFailures("queue", 3)
+}
+
+func TestKeysByLabel(t *testing.T) {
+	resetState()
+	setLabelledRules()
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{selector: "team=payments", expected: []string{"cache-get", "db-query"}},
+		{selector: "team=payments,scenario=checkout", expected: []string{"db-query"}},
+		{selector: "scenario", expected: []string{"cache-get", "db-query"}},
+		{selector: "scenario=re*", expected: []string{"cache-get"}},
+		{selector: "team=ops", expected: nil},
+		{selector: "", expected: nil},
+		{selector: "=payments", expected: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			if got := KeysByLabel(tt.selector); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestResetByLabel(t *testing.T) {
+	resetState()
+	setLabelledRules()
+	Inject("db-query")
+
+	ResetByLabel("team=payments")
+	if got := Status(); len(got) != 2 || got["search"] != 3 || got["queue"] != 3 {
+		t.Errorf("Expected only search and queue to remain, got %v", got)
+	}
+	if got := Counter("db-query"); got != 0 {
+		t.Errorf("Expected the reset key's count to be cleared, got %d", got)
+	}
+}
+
+func TestSetLabels(t *testing.T) {
+	resetState()
+	SetLabels("db", map[string]string{"team": "payments"})
+	SetFailures("db", 1)
+
+	if got := KeysByLabel("team=payments"); !slices.Equal(got, []string{"db"}) {
+		t.Errorf("Expected labels set before the rule to be kept, got %v", got)
+	}
+	SetLabels("db", nil)
+	if r := Rules()["db"]; r.Labels != nil {
+		t.Errorf("Expected no labels, got %v", r.Labels)
+	}
+}
+
+func TestLabelsSpec(t *testing.T) {
+	resetState()
+	err := LoadSpecFromBytes([]byte("rules:\n  db: {count: 1, labels: {team: payments}}\n"), FormatYAML)
+	if err != nil {
+		t.Fatalf("Expected the spec to load, got %v", err)
+	}
+	if got := KeysByLabel("team=payments"); !slices.Equal(got, []string{"db"}) {
+		t.Errorf("Expected db to be labelled, got %v", got)
+	}
+
+	err = LoadSpecFromBytes([]byte("rules:\n  db: {count: 1, labels: {\"a=b\": c}}\n"), FormatYAML)
+	if err == nil || !strings.Contains(err.Error(), "label") {
+		t.Errorf("Expected a label error, got %v", err)
+	}
+}
+
+func TestLabelEndpoints(t *testing.T) {
+	resetState()
+	setLabelledRules()
+	server := httptest.NewServer(ControlHandler(nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/faults?label=scenario=checkout")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	var rules map[string]Rule
+	err = json.NewDecoder(resp.Body).Decode(&rules)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(rules) != 1 || rules["db-query"].Labels["team"] != "payments" {
+		t.Errorf("Expected only db-query, got %v", rules)
+	}
+
+	resp, err = http.Post(server.URL+"/reset?label=team%3Ddiscovery", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if _, ok := Status()["search"]; ok || len(Status()) != 3 {
+		t.Errorf("Expected only search to be reset, got %v", Status())
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+)
+
+// LatencyBucket is a range of delays chosen with probability proportional to Weight.
+type LatencyBucket struct {
+	Min    time.Duration `yaml:"min" json:"min"`
+	Max    time.Duration `yaml:"max" json:"max"`
+	Weight float64       `yaml:"weight" json:"weight"`
+}
+
+// LatencyDistribution describes the delays injected for a key. A bucket is
+// picked by weight and the delay is drawn uniformly from [Min, Max].
+type LatencyDistribution []LatencyBucket
+
+// FixedLatency returns a distribution that always delays by d.
+func FixedLatency(d time.Duration) LatencyDistribution {
+	return LatencyDistribution{{Min: d, Max: d, Weight: 1}}
+}
+
+// Sample draws one delay from the distribution.
+func (dist LatencyDistribution) Sample() time.Duration {
+	total := 0.0
+	for _, b := range dist {
+		total += b.Weight
+	}
+	if total <= 0 {
+		return 0
+	}
+	r := rand.Float64() * total
+	for _, b := range dist {
+		if r < b.Weight {
+			return b.Min + time.Duration(rand.Float64()*float64(b.Max-b.Min))
+		}
+		r -= b.Weight
+	}
+	last := dist[len(dist)-1]
+	return last.Max
+}
+
+// HistogramBucket is one cumulative bucket of a Prometheus-style histogram.
+type HistogramBucket struct {
+	UpperBound float64 // in seconds; math.Inf(1) for the +Inf bucket
+	Count      float64 // cumulative count of observations <= UpperBound
+}
+
+// LatencyFromHistogram converts cumulative histogram buckets into a
+// distribution that reproduces them. Observations in the +Inf bucket are
+// spread up to infCap; if infCap is zero they are capped at twice the
+// largest finite bound.
+func LatencyFromHistogram(buckets []HistogramBucket, infCap time.Duration) (LatencyDistribution, error) {
+	sorted := append([]HistogramBucket(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].UpperBound < sorted[j].UpperBound })
+
+	var dist LatencyDistribution
+	var prevBound, prevCount float64
+	for _, b := range sorted {
+		if b.Count < prevCount {
+			return nil, fmt.Errorf("histogram counts must be cumulative: %g after %g", b.Count, prevCount)
+		}
+		upper := b.UpperBound
+		if math.IsInf(upper, 1) {
+			upper = infCap.Seconds()
+			if infCap <= 0 {
+				upper = 2 * prevBound
+			}
+		}
+		if n := b.Count - prevCount; n > 0 {
+			dist = append(dist, LatencyBucket{
+				Min:    secondsToDuration(prevBound),
+				Max:    secondsToDuration(math.Max(upper, prevBound)),
+				Weight: n,
+			})
+		}
+		prevBound, prevCount = upper, b.Count
+	}
+	if prevCount == 0 {
+		return nil, fmt.Errorf("histogram has no observations")
+	}
+	for i := range dist {
+		dist[i].Weight /= prevCount
+	}
+	return dist, nil
+}
+
+// SetLatency makes InjectLatency(ctx, key) delay by a sample of dist.
+// Passing an empty distribution removes the latency fault.
+func (in *Injector) SetLatency(key string, dist LatencyDistribution) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setLatencyLocked(key, dist)
+}
+
+// SetLatency calls SetLatency on the default Injector.
+func SetLatency(key string, dist LatencyDistribution) {
+	std.SetLatency(key, dist)
+}
+
+func (in *Injector) setLatencyLocked(key string, dist LatencyDistribution) {
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Latency = append(LatencyDistribution(nil), dist...)
+	})
+}
+
+// InjectLatency sleeps for a delay sampled from key's latency distribution
+// and returns how long it slept. It returns early with ctx's error if ctx is
+// done first. Latency applies to every matching call; it does not consume
+// the key's failure count. A directive for key carried by ctx replaces the
+// key's rule with its fixed latency. Fault injection is disabled in
+// production environments.
+func (in *Injector) InjectLatency(ctx context.Context, key string) (time.Duration, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if in.isProductionEnvironment() || ctx.Err() != nil {
+		return 0, nil
+	}
+	var d time.Duration
+	if st := directiveFor(ctx, key); st != nil {
+		d = st.Latency
+	} else {
+		if !in.syntheticAllowed(ctx, key) || !in.tenantAllowed(ctx, key) || !in.selected(ctx, key) {
+			return 0, nil
+		}
+		s := in.snapshot()
+		r := s.rules[s.ruleKey(key)]
+		if r.expired(in.timeNow()) || !s.onInstance(r) || !s.onBuild(r) || !r.matched(ctx) || !r.calledFrom(ctx, key) {
+			return 0, nil
+		}
+		if a, ok := r.action(in.nextCall(key)); ok && a.Latency > 0 {
+			d = a.Latency
+		} else if len(r.Latency) > 0 {
+			d = r.Latency.Sample()
+		}
+	}
+	if d <= 0 {
+		return 0, nil
+	}
+	region := in.startLatencyRegion(ctx)
+	var err error
+	in.withProfileLabels(ctx, key, "latency", func(ctx context.Context) {
+		d, err = in.sleep(ctx, d)
+	})
+	if region != nil {
+		region.End()
+	}
+	in.observeLatency(key, d)
+	in.notifyFault(ctx, Fault{Key: key, Type: "latency", Latency: d})
+	return d, err
+}
+
+// InjectLatency calls InjectLatency on the default Injector.
+func InjectLatency(ctx context.Context, key string) (time.Duration, error) {
+	return std.InjectLatency(ctx, key)
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
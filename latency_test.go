@@ -0,0 +1,122 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLatencyFromHistogram(t *testing.T) {
+	buckets := []HistogramBucket{
+		{UpperBound: 0.1, Count: 80},
+		{UpperBound: 0.05, Count: 60},
+		{UpperBound: 0.5, Count: 95},
+		{UpperBound: math.Inf(1), Count: 100},
+	}
+
+	dist, err := LatencyFromHistogram(buckets, 2*time.Second)
+	if err != nil {
+		t.Fatalf("LatencyFromHistogram returned error: %v", err)
+	}
+
+	expected := LatencyDistribution{
+		{Min: 0, Max: 50 * time.Millisecond, Weight: 0.6},
+		{Min: 50 * time.Millisecond, Max: 100 * time.Millisecond, Weight: 0.2},
+		{Min: 100 * time.Millisecond, Max: 500 * time.Millisecond, Weight: 0.15},
+		{Min: 500 * time.Millisecond, Max: 2 * time.Second, Weight: 0.05},
+	}
+	if len(dist) != len(expected) {
+		t.Fatalf("Expected %d buckets, got %d: %+v", len(expected), len(dist), dist)
+	}
+	for i, b := range dist {
+		e := expected[i]
+		if b.Min != e.Min || b.Max != e.Max || math.Abs(b.Weight-e.Weight) > 1e-9 {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, e, b)
+		}
+	}
+
+	dist, _ = LatencyFromHistogram(buckets, 0)
+	if last := dist[len(dist)-1]; last.Max != time.Second {
+		t.Errorf("Expected +Inf bucket to default to twice the largest bound, got %v", last.Max)
+	}
+}
+
+func TestLatencyFromHistogramErrors(t *testing.T) {
+	if _, err := LatencyFromHistogram([]HistogramBucket{{UpperBound: 1, Count: 0}}, 0); err == nil {
+		t.Error("Expected error for empty histogram")
+	}
+	if _, err := LatencyFromHistogram([]HistogramBucket{{UpperBound: 1, Count: 5}, {UpperBound: 2, Count: 3}}, 0); err == nil {
+		t.Error("Expected error for non-cumulative counts")
+	}
+}
+
+func TestLatencySample(t *testing.T) {
+	dist := LatencyDistribution{
+		{Min: 10 * time.Millisecond, Max: 20 * time.Millisecond, Weight: 1},
+		{Min: time.Second, Max: time.Second, Weight: 0},
+	}
+	for i := 0; i < 100; i++ {
+		d := dist.Sample()
+		if d < 10*time.Millisecond || d > 20*time.Millisecond {
+			t.Fatalf("Sample %v outside the only weighted bucket", d)
+		}
+	}
+	if got := FixedLatency(5 * time.Millisecond).Sample(); got != 5*time.Millisecond {
+		t.Errorf("Expected fixed latency of 5ms, got %v", got)
+	}
+	if got := (LatencyDistribution{}).Sample(); got != 0 {
+		t.Errorf("Expected empty distribution to sample 0, got %v", got)
+	}
+}
+
+func TestInjectLatency(t *testing.T) {
+	resetState()
+
+	if d, err := InjectLatency(context.Background(), "slow"); d != 0 || err != nil {
+		t.Errorf("Expected no delay without a rule, got %v, %v", d, err)
+	}
+
+	SetLatency("slow", FixedLatency(20*time.Millisecond))
+	start := time.Now()
+	d, err := InjectLatency(context.Background(), "slow")
+	if err != nil || d != 20*time.Millisecond || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("Expected a 20ms delay, got %v, %v after %v", d, err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	SetLatency("slow", FixedLatency(time.Second))
+	if _, err := InjectLatency(ctx, "slow"); err != context.DeadlineExceeded {
+		t.Errorf("Expected deadline error, got %v", err)
+	}
+
+	SetLatency("slow", nil)
+	if d, _ := InjectLatency(context.Background(), "slow"); d != 0 {
+		t.Errorf("Expected latency to be removed, got %v", d)
+	}
+}
+
+func TestLoadSpecLatencies(t *testing.T) {
+	resetState()
+	content := `latencies:
+  spec-slow:
+    - min: 5ms
+      max: 5ms
+      weight: 1`
+	filename := "test-latency.yaml"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(filename)
+
+	if err := LoadSpec(filename); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	if d, _ := InjectLatency(context.Background(), "spec-slow"); d != 5*time.Millisecond {
+		t.Errorf("Expected 5ms from spec, got %v", d)
+	}
+}
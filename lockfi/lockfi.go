@@ -0,0 +1,134 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lockfi injects faults into distributed locks and leader election,
+// so failover logic can be tested against lost leases and split brain.
+//
+// A wrapped lock evaluates three keys per Lock call, formed from the lock
+// name passed to NewMutex:
+//
+//	<name>.acquire      acquiring fails with a *faultinject.InjectedError
+//	<name>.split-brain  Lock succeeds without taking the underlying lock, so
+//	                    another node can hold it at the same time
+//	<name>.lose         the lease is lost after the key's latency (see
+//	                    faultinject.SetLatency), at once by default
+//
+// Losing the lease releases the underlying lock, so another node can take
+// it, and closes the channel returned by Done. Holders that keep working
+// after Done is closed are in split brain too.
+package lockfi
+
+import (
+	"context"
+	"sync"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Locker is a distributed lock client, such as etcd's concurrency.Mutex or
+// an adapter around a Redis lock.
+type Locker interface {
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+}
+
+// released is the Done channel of a Mutex that is not held.
+var released = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// Mutex is a Locker with lock faults for one lock name. Like the lock it
+// wraps, it must be unlocked before it is locked again.
+type Mutex struct {
+	name string
+	l    Locker
+
+	mu   sync.Mutex
+	held bool          // whether the underlying lock is held
+	done chan struct{} // closed when the lock is lost or released
+	stop context.CancelFunc
+}
+
+// NewMutex returns a Mutex that injects faults for name into l.
+func NewMutex(name string, l Locker) *Mutex {
+	return &Mutex{name: name, l: l, done: released}
+}
+
+// Lock acquires the lock, unless <name>.acquire fires. When <name>.lose
+// fires, the lease is lost later on, as described in the package
+// documentation.
+func (m *Mutex) Lock(ctx context.Context) error {
+	if err := faultinject.InjectWithContextError(ctx, m.name+".acquire", "lock "+m.name+" not acquired"); err != nil {
+		return err
+	}
+	split := faultinject.InjectWithContext(ctx, m.name+".split-brain")
+	if !split {
+		if err := m.l.Lock(ctx); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.held = !split
+	m.done = make(chan struct{})
+	m.stop = nil
+	if faultinject.InjectWithContext(ctx, m.name+".lose") {
+		lctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		m.stop = cancel
+		go m.lose(lctx, m.done)
+	}
+	return nil
+}
+
+// Unlock releases the lock. After the lease was lost it does nothing.
+func (m *Mutex) Unlock(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		m.stop()
+		m.stop = nil
+	}
+	if m.done == released {
+		return nil
+	}
+	var err error
+	if m.held {
+		err = m.l.Unlock(ctx)
+		m.held = false
+	}
+	close(m.done)
+	m.done = released
+	return err
+}
+
+// Done returns a channel that is closed when the lock is lost or released.
+// It is closed while the lock is not held.
+func (m *Mutex) Done() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.done
+}
+
+// lose releases the lock held with done after <name>.lose's latency, unless
+// ctx is cancelled by Unlock first.
+func (m *Mutex) lose(ctx context.Context, done chan struct{}) {
+	if _, err := faultinject.InjectLatency(ctx, m.name+".lose"); err != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done != done {
+		return
+	}
+	if m.held {
+		m.l.Unlock(context.Background())
+		m.held = false
+	}
+	close(m.done)
+	m.done = released
+	m.stop = nil
+}
@@ -0,0 +1,142 @@
+//go:build faultinject
+
+package lockfi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// fakeLock is an in-memory lock shared by several nodes.
+type fakeLock struct {
+	mu     sync.Mutex
+	holder string
+}
+
+// node is one client of a fakeLock.
+type node struct {
+	lock *fakeLock
+	name string
+}
+
+var errHeld = errors.New("lock held by another node")
+
+func (n node) Lock(context.Context) error {
+	n.lock.mu.Lock()
+	defer n.lock.mu.Unlock()
+	if n.lock.holder != "" {
+		return errHeld
+	}
+	n.lock.holder = n.name
+	return nil
+}
+
+func (n node) Unlock(context.Context) error {
+	n.lock.mu.Lock()
+	defer n.lock.mu.Unlock()
+	if n.lock.holder == n.name {
+		n.lock.holder = ""
+	}
+	return nil
+}
+
+func resetState() {
+	faultinject.Reset()
+	os.Setenv("ENVIRONMENT", "development")
+}
+
+func TestAcquireFailure(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("leader.acquire", 1)
+
+	m := NewMutex("leader", node{&fakeLock{}, "a"})
+	if err := m.Lock(context.Background()); !errors.Is(err, faultinject.ErrInjected) {
+		t.Errorf("Expected an injected error, got %v", err)
+	}
+	if err := m.Lock(context.Background()); err != nil {
+		t.Errorf("Expected the second attempt to succeed, got %v", err)
+	}
+}
+
+func TestSplitBrain(t *testing.T) {
+	resetState()
+	lock := &fakeLock{}
+	a := NewMutex("leader", node{lock, "a"})
+	b := NewMutex("leader", node{lock, "b"})
+
+	if err := a.Lock(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := b.Lock(context.Background()); !errors.Is(err, errHeld) {
+		t.Fatalf("Expected the lock to be held, got %v", err)
+	}
+
+	faultinject.SetFailures("leader.split-brain", 1)
+	if err := b.Lock(context.Background()); err != nil {
+		t.Errorf("Expected both nodes to hold the lock, got %v", err)
+	}
+	b.Unlock(context.Background())
+	if lock.holder != "a" {
+		t.Errorf("Expected a to keep the lock after b unlocks, got %q", lock.holder)
+	}
+}
+
+func TestLostLease(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("leader.lose", 1)
+	faultinject.SetLatency("leader.lose", faultinject.FixedLatency(10*time.Millisecond))
+	lock := &fakeLock{}
+	a := NewMutex("leader", node{lock, "a"})
+
+	if err := a.Lock(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case <-a.Done():
+		t.Fatal("Expected the lease to be held before the latency passed")
+	default:
+	}
+	select {
+	case <-a.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the lease to be lost")
+	}
+
+	if err := NewMutex("leader", node{lock, "b"}).Lock(context.Background()); err != nil {
+		t.Errorf("Expected another node to take the lost lock, got %v", err)
+	}
+	if err := a.Unlock(context.Background()); err != nil {
+		t.Errorf("Expected unlocking a lost lock to do nothing, got %v", err)
+	}
+	if lock.holder != "b" {
+		t.Errorf("Expected b to hold the lock, got %q", lock.holder)
+	}
+}
+
+func TestUnlockBeforeLoss(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("leader.lose", 1)
+	faultinject.SetLatency("leader.lose", faultinject.FixedLatency(time.Hour))
+	lock := &fakeLock{}
+	a := NewMutex("leader", node{lock, "a"})
+
+	a.Lock(context.Background())
+	done := a.Done()
+	if err := a.Unlock(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	select {
+	case <-done:
+	default:
+		t.Error("Expected Done to be closed after Unlock")
+	}
+	if lock.holder != "" {
+		t.Errorf("Expected the lock to be free, got %q", lock.holder)
+	}
+}
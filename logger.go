@@ -0,0 +1,127 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sort"
+)
+
+// SetLogger makes the injector log to l: faults that fire at Info, every
+// other evaluation at Debug, and changes to the configuration at Info, so
+// the handler's level picks the verbosity. Evaluations carry the key, the
+// pattern rule that applied if any, the rule's mode, the decision's reason
+// and the call count. Records are logged on the caller's goroutine, outside
+// the injector's locks. A nil l stops logging. Like the environment lists,
+// it survives Reset.
+func (in *Injector) SetLogger(l *slog.Logger) {
+	in.loggedConfig.Store(in.snapshot())
+	in.logger.Store(l)
+}
+
+// SetLogger calls SetLogger on the default Injector.
+func SetLogger(l *slog.Logger) {
+	std.SetLogger(l)
+}
+
+// logDecision logs e if a logger is set. It must not be called with callsMu
+// held.
+func (in *Injector) logDecision(ctx context.Context, e Event) {
+	l := in.logger.Load()
+	if l == nil {
+		return
+	}
+	level, msg := slog.LevelDebug, "fault not injected"
+	if e.Fired {
+		level, msg = slog.LevelInfo, "fault injected"
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{slog.String("key", e.Key)}
+	s := in.snapshot()
+	if rk := s.ruleKey(e.Key); s.hasRule(e.Key) {
+		if rk != e.Key {
+			attrs = append(attrs, slog.String("rule", rk))
+		}
+		attrs = append(attrs, slog.String("mode", string(s.rules[rk].Mode)))
+	}
+	attrs = append(attrs, slog.String("reason", string(e.Reason)))
+	if e.Count > 0 {
+		attrs = append(attrs, slog.Int("count", e.Count))
+	}
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logConfig logs the rules that changed since it was last called, or the
+// generation if only other settings changed. It must not be called with mu
+// held.
+func (in *Injector) logConfig() {
+	next := in.snapshot()
+	prev := in.loggedConfig.Swap(next)
+	l := in.logger.Load()
+	ctx := context.Background()
+	if l == nil || prev == nil || prev.generation == next.generation || !l.Enabled(ctx, slog.LevelInfo) {
+		return
+	}
+	var keys []string
+	for k, r := range next.rules {
+		if old, ok := prev.rules[k]; !ok || !reflect.DeepEqual(old, r) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range prev.rules {
+		if _, ok := next.rules[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		l.LogAttrs(ctx, slog.LevelInfo, "fault configuration changed", slog.Uint64("generation", next.generation))
+		return
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		r, ok := next.rules[k]
+		if !ok {
+			l.LogAttrs(ctx, slog.LevelInfo, "fault rule removed", slog.String("key", k), slog.Uint64("generation", next.generation))
+			continue
+		}
+		l.LogAttrs(ctx, slog.LevelInfo, "fault rule set", slog.String("key", k), slog.Any("rule", r), slog.Uint64("generation", next.generation))
+	}
+}
+
+// LogValue logs the fields of r that are set.
+func (r Rule) LogValue() slog.Value {
+	var attrs []slog.Attr
+	add := func(set bool, a slog.Attr) {
+		if set {
+			attrs = append(attrs, a)
+		}
+	}
+	add(r.Mode != ModeNone, slog.String("mode", string(r.Mode)))
+	add(r.Count != 0, slog.Int("count", r.Count))
+	add(r.Nth != 0, slog.Int("nth", r.Nth))
+	add(r.Rate != 0, slog.Float64("rate", r.Rate))
+	if r.Flap != nil {
+		attrs = append(attrs, slog.Duration("period", r.Flap.Period), slog.Duration("unhealthy", r.Flap.Unhealthy))
+	}
+	add(len(r.Latency) > 0, slog.Any("latency", r.Latency))
+	add(r.Error != "", slog.String("error", r.Error))
+	add(r.Code != "", slog.String("code", r.Code))
+	add(r.Status != 0, slog.Int("status", r.Status))
+	add(len(r.Header) > 0, slog.Any("header", r.Header))
+	add(r.Body != "", slog.String("body", r.Body))
+	add(r.Instances != 0, slog.Float64("instances", r.Instances))
+	add(r.Build != Build{}, slog.Any("build", r.Build))
+	add(len(r.Match) > 0, slog.Any("match", r.Match))
+	add(!r.Expires.IsZero(), slog.Time("expires", r.Expires))
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,87 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// testLogger returns a logger that writes lines without times to buf.
+func testLogger(buf *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestSetLogger(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    slog.Level
+		expected []string
+	}{
+		{
+			name:  "info",
+			level: slog.LevelInfo,
+			expected: []string{
+				`level=INFO msg="fault rule set" key=api-* rule.mode=first-n rule.count=1 generation=2`,
+				`level=INFO msg="fault injected" key=api-users rule=api-* mode=first-n reason=fired count=1`,
+				`level=INFO msg="fault rule removed" key=api-* generation=3`,
+			},
+		},
+		{
+			name:  "debug",
+			level: slog.LevelDebug,
+			expected: []string{
+				`level=INFO msg="fault rule set" key=api-* rule.mode=first-n rule.count=1 generation=2`,
+				`level=INFO msg="fault injected" key=api-users rule=api-* mode=first-n reason=fired count=1`,
+				`level=DEBUG msg="fault not injected" key=api-users rule=api-* mode=first-n reason=exhausted count=2`,
+				`level=DEBUG msg="fault not injected" key=other reason=disabled count=1`,
+				`level=INFO msg="fault rule removed" key=api-* generation=3`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			var buf bytes.Buffer
+			in := NewInjector()
+			in.SetLogger(testLogger(&buf, tt.level))
+			in.SetFailures("api-*", 1)
+			in.Inject("api-users")
+			in.Inject("api-users")
+			in.Inject("other")
+			in.SetRule("api-*", Rule{})
+
+			got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("Expected:\n%s\ngot:\n%s", strings.Join(tt.expected, "\n"), buf.String())
+			}
+		})
+	}
+}
+
+func TestSetLoggerOtherChanges(t *testing.T) {
+	resetState()
+	var buf bytes.Buffer
+	in := NewInjector()
+	in.SetLogger(testLogger(&buf, slog.LevelInfo))
+	in.SetTenants("db", "acme")
+	in.SetLogger(nil)
+	in.SetFailures("db", 1)
+	in.Inject("db")
+
+	expected := `level=INFO msg="fault configuration changed" generation=2`
+	if got := strings.TrimSpace(buf.String()); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SleepClock is a Clock that can also wait. An Injector built with
+// WithClock(clk) for a SleepClock waits on it for latency faults, and
+// scenario.RunWithClock waits on one between steps, so tests of slow calls
+// and schedules need no real sleeps.
+type SleepClock interface {
+	Clock
+	// Sleep waits until d has passed on the clock, or until ctx is done,
+	// in which case it returns ctx's error.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// ManualClock is a SleepClock that only moves when Advance or Set is
+// called. It is safe for concurrent use.
+type ManualClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	sleepers map[*sleeper]struct{}
+	// changed is closed and replaced whenever a sleeper starts or stops.
+	changed chan struct{}
+}
+
+type sleeper struct {
+	until time.Time
+	done  chan struct{}
+}
+
+// NewManualClock returns a ManualClock set to start.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start, sleepers: make(map[*sleeper]struct{}), changed: make(chan struct{})}
+}
+
+// Now returns the clock's time.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d, waking the sleepers it passes.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+// Set moves the clock to t, waking the sleepers it passes. The clock can be
+// set back, which wakes nobody.
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(t)
+}
+
+func (c *ManualClock) setLocked(t time.Time) {
+	c.now = t
+	for s := range c.sleepers {
+		if !t.Before(s.until) {
+			close(s.done)
+			delete(c.sleepers, s)
+		}
+	}
+	c.notifyLocked()
+}
+
+// Sleep waits until the clock has been moved d past the time it was
+// called, or until ctx is done.
+func (c *ManualClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	if d <= 0 {
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+	s := &sleeper{until: c.now.Add(d), done: make(chan struct{})}
+	c.sleepers[s] = struct{}{}
+	c.notifyLocked()
+	c.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if _, ok := c.sleepers[s]; !ok {
+			return nil // woken at the same time
+		}
+		delete(c.sleepers, s)
+		c.notifyLocked()
+		return ctx.Err()
+	}
+}
+
+// Sleepers returns how many goroutines are sleeping on the clock.
+func (c *ManualClock) Sleepers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sleepers)
+}
+
+// WaitForSleepers waits until n goroutines are sleeping on the clock, so a
+// test can Advance it only once the code under test is waiting, or until
+// ctx is done.
+func (c *ManualClock) WaitForSleepers(ctx context.Context, n int) error {
+	for {
+		c.mu.Lock()
+		count, changed := len(c.sleepers), c.changed
+		c.mu.Unlock()
+		if count >= n {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *ManualClock) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// sleep waits d on in's clock, or until ctx is done. It returns how long it
+// waited, and ctx's error if the wait was cut short.
+func (in *Injector) sleep(ctx context.Context, d time.Duration) (time.Duration, error) {
+	if clk, ok := in.clock.(SleepClock); ok {
+		start := clk.Now()
+		if err := clk.Sleep(ctx, d); err != nil {
+			return clk.Now().Sub(start), err
+		}
+		return d, nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	start := time.Now()
+	select {
+	case <-t.C:
+		return d, nil
+	case <-ctx.Done():
+		return time.Since(start), ctx.Err()
+	}
+}
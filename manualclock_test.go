@@ -0,0 +1,118 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var clockStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestManualClockLatency(t *testing.T) {
+	clk := NewManualClock(clockStart)
+	in, err := New(WithClock(clk), WithEnvGuard(func() bool { return false }))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	in.SetLatency("db", FixedLatency(time.Hour))
+
+	type result struct {
+		d   time.Duration
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		d, err := in.InjectLatency(context.Background(), "db")
+		done <- result{d, err}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := clk.WaitForSleepers(ctx, 1); err != nil {
+		t.Fatalf("Expected InjectLatency to sleep on the clock, got %v", err)
+	}
+	clk.Advance(30 * time.Minute)
+	if n := clk.Sleepers(); n != 1 {
+		t.Errorf("Expected the call to sleep until the hour passes, got %d sleepers", n)
+	}
+	clk.Advance(30 * time.Minute)
+	if r := <-done; r.d != time.Hour || r.err != nil {
+		t.Errorf("Expected an hour's latency, got %v, %v", r.d, r.err)
+	}
+}
+
+func TestManualClockLatencyCancelled(t *testing.T) {
+	clk := NewManualClock(clockStart)
+	in, _ := New(WithClock(clk), WithEnvGuard(func() bool { return false }))
+	in.SetLatency("db", FixedLatency(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan time.Duration, 1)
+	go func() {
+		d, err := in.InjectLatency(ctx, "db")
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		done <- d
+	}()
+	wait, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	clk.WaitForSleepers(wait, 1)
+	clk.Advance(10 * time.Minute)
+	cancel()
+	if d := <-done; d != 10*time.Minute {
+		t.Errorf("Expected the 10 minutes slept, got %v", d)
+	}
+	if n := clk.Sleepers(); n != 0 {
+		t.Errorf("Expected no sleepers left, got %d", n)
+	}
+}
+
+func TestManualClockTimeWindows(t *testing.T) {
+	clk := NewManualClock(clockStart)
+	in, _ := New(WithClock(clk), WithEnvGuard(func() bool { return false }))
+	in.SetFailuresFor("ttl", 100, time.Minute)
+	in.SetFlapping("flap", FlapCycle{Period: time.Minute, Unhealthy: 10 * time.Second})
+
+	tests := []struct {
+		advance time.Duration
+		ttl     bool
+		flap    bool
+	}{
+		{advance: 0, ttl: true, flap: true},
+		{advance: 30 * time.Second, ttl: true, flap: false},
+		{advance: 35 * time.Second, ttl: false, flap: true},
+	}
+	for _, tt := range tests {
+		clk.Advance(tt.advance)
+		if got := in.Inject("ttl"); got != tt.ttl {
+			t.Errorf("At %v: expected ttl %v, got %v", clk.Now().Sub(clockStart), tt.ttl, got)
+		}
+		if got := in.Inject("flap"); got != tt.flap {
+			t.Errorf("At %v: expected flap %v, got %v", clk.Now().Sub(clockStart), tt.flap, got)
+		}
+	}
+}
+
+func TestManualClockSet(t *testing.T) {
+	clk := NewManualClock(clockStart)
+	done := make(chan error, 1)
+	go func() { done <- clk.Sleep(context.Background(), time.Minute) }()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	clk.WaitForSleepers(ctx, 1)
+
+	clk.Set(clockStart.Add(-time.Hour))
+	if n := clk.Sleepers(); n != 1 {
+		t.Errorf("Expected setting the clock back to wake nobody, got %d sleepers", n)
+	}
+	clk.Set(clockStart.Add(time.Minute))
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if got := clk.Now(); !got.Equal(clockStart.Add(time.Minute)) {
+		t.Errorf("Expected the clock at %v, got %v", clockStart.Add(time.Minute), got)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxMatchBody is how much of a request body is read for a "body" match.
+const maxMatchBody = 64 << 10
+
+// A rule's Match lists attributes a call must have for the rule to apply,
+// each matched like a rule key, so "*" globs and "^" regular expressions
+// work. The HTTP middlewares expose requests as attributes:
+//
+//	rules:
+//	  checkout-api:
+//	    count: 1
+//	    match:
+//	      header.X-Tenant-ID: acme      # header names are case-insensitive
+//	      query.debug: "1"              # first value of a query parameter
+//	      param.id: "42"                # path wildcard of the route, such as "/orders/{id}"
+//	      body: '*"plan":"trial"*'      # the first 64 KiB of the body
+//
+// Calls whose attributes do not match neither fail nor count towards the
+// rule. Other attributes, such as "method", "tenant" or those passed with
+// WithAttrs, can be matched too.
+
+// SetMatch limits key's rule to calls whose attributes match, as Rule.Match
+// does. An empty match applies it to every call again. Fault injection is
+// disabled in production environments.
+func (in *Injector) SetMatch(key string, match map[string]string) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Match = nil
+		if len(match) > 0 {
+			r.Match = maps.Clone(match)
+		}
+	})
+}
+
+// SetMatch calls SetMatch on the default Injector.
+func SetMatch(key string, match map[string]string) {
+	std.SetMatch(key, match)
+}
+
+// matched reports whether the attributes of ctx satisfy r's Match.
+func (r Rule) matched(ctx context.Context) bool {
+	if len(r.Match) == 0 {
+		return true
+	}
+	attrs := AttributesFromContext(ctx)
+	for name, want := range r.Match {
+		if n, ok := strings.CutPrefix(name, "header."); ok {
+			name = headerAttribute(n)
+		}
+		got, ok := attrs[name]
+		if !ok || !MatchKey(want, got) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesBody reports whether r matches on the request body.
+func (r Rule) matchesBody() bool {
+	_, ok := r.Match["body"]
+	return ok
+}
+
+// bodyAttribute reads the start of req's body for a "body" match, leaving
+// the body whole for the handler.
+func bodyAttribute(req *http.Request) (string, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", true
+	}
+	buf, err := io.ReadAll(io.LimitReader(req.Body, maxMatchBody))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+	return string(buf), err == nil
+}
+
+// routeWildcard matches a wildcard in an http.ServeMux pattern.
+var routeWildcard = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)(?:\.\.\.)?\}`)
+
+// addRouteAttributes exposes the path wildcards of the route r was matched
+// to as "param.<name>" attributes.
+func addRouteAttributes(attrs Attributes, r *http.Request) {
+	if r.Pattern == "" {
+		return
+	}
+	for _, m := range routeWildcard.FindAllStringSubmatch(r.Pattern, -1) {
+		attrs["param."+m[1]] = r.PathValue(m[1])
+	}
+}
+
+// addQueryAttributes exposes the first value of each query parameter of r as
+// "query.<name>" attributes.
+func addQueryAttributes(attrs Attributes, r *http.Request) {
+	for name, values := range r.URL.Query() {
+		if len(values) > 0 {
+			attrs["query."+name] = values[0]
+		}
+	}
+}
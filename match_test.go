@@ -0,0 +1,184 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		match    map[string]string
+		url      string
+		header   map[string]string
+		body     string
+		expected int
+	}{
+		{
+			name:     "header",
+			match:    map[string]string{"header.X-Tenant-ID": "acme"},
+			url:      "/orders/7",
+			header:   map[string]string{"X-Tenant-Id": "acme"},
+			expected: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "other tenant",
+			match:    map[string]string{"header.X-Tenant-ID": "acme"},
+			url:      "/orders/7",
+			header:   map[string]string{"X-Tenant-ID": "globex"},
+			expected: http.StatusOK,
+		},
+		{
+			name:     "missing header",
+			match:    map[string]string{"header.X-Tenant-ID": "*"},
+			url:      "/orders/7",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "query",
+			match:    map[string]string{"query.debug": "1"},
+			url:      "/orders/7?debug=1",
+			expected: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "query mismatch",
+			match:    map[string]string{"query.debug": "1"},
+			url:      "/orders/7?debug=0",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "path param",
+			match:    map[string]string{"param.id": "4*"},
+			url:      "/orders/42",
+			expected: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "path param mismatch",
+			match:    map[string]string{"param.id": "4*"},
+			url:      "/orders/7",
+			expected: http.StatusOK,
+		},
+		{
+			name:     "body",
+			match:    map[string]string{"body": `*"plan":"trial"*`},
+			url:      "/orders/7",
+			body:     `{"plan":"trial","items":3}`,
+			expected: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "body mismatch",
+			match:    map[string]string{"body": `*"plan":"trial"*`},
+			url:      "/orders/7",
+			body:     `{"plan":"gold"}`,
+			expected: http.StatusOK,
+		},
+		{
+			name:     "every condition",
+			match:    map[string]string{"method": "POST", "query.debug": "^[12]$", "header.X-Tenant-ID": "acme"},
+			url:      "/orders/7?debug=2",
+			header:   map[string]string{"X-Tenant-ID": "acme"},
+			expected: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetRule("orders", Rule{Mode: ModeRate, Rate: 1, Status: http.StatusServiceUnavailable, Match: tt.match})
+			var received string
+			mux := http.NewServeMux()
+			mux.Handle("POST /orders/{id}", HTTPMiddleware("orders")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				received = string(b)
+			})))
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+			if rec.Code == http.StatusOK && received != tt.body {
+				t.Errorf("Expected the handler to receive %q, got %q", tt.body, received)
+			}
+			calls := FullStatus()["orders"].Calls
+			if (rec.Code == http.StatusOK) != (calls == 0) {
+				t.Errorf("Expected only matching requests to count, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestRuleMatchFromSpec(t *testing.T) {
+	resetState()
+	err := LoadSpecFromReader(strings.NewReader(`
+routes:
+  "GET /orders/{id}": orders
+rules:
+  orders:
+    count: 5
+    match: {param.id: "42"}
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := HTTPMiddlewareFromSpec()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tt := range []struct {
+		path     string
+		expected int
+	}{
+		{path: "/orders/7", expected: http.StatusOK},
+		{path: "/orders/42", expected: http.StatusInternalServerError},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.expected {
+			t.Errorf("%s: expected status %d, got %d", tt.path, tt.expected, rec.Code)
+		}
+	}
+}
+
+func TestSetMatch(t *testing.T) {
+	resetState()
+	SetFailures("db", 5)
+	SetMatch("db", map[string]string{"region": "eu"})
+
+	if Inject("db") {
+		t.Error("Expected a call without the attribute not to fire")
+	}
+	if !Inject("db", WithAttrs(Attributes{"region": "eu"})) {
+		t.Error("Expected a matching call to fire")
+	}
+	if got := History("db"); len(got) == 0 || got[0].Reason != ReasonMatch {
+		t.Errorf("Expected the first decision to be %s, got %+v", ReasonMatch, got)
+	}
+
+	SetMatch("db", nil)
+	if !Inject("db") {
+		t.Error("Expected every call to fire once the match is removed")
+	}
+	if r := Rules()["db"]; r.Match != nil {
+		t.Errorf("Expected no match, got %v", r.Match)
+	}
+}
+
+func TestSetMatchBeforeRule(t *testing.T) {
+	resetState()
+	SetMatch("db", map[string]string{"region": "eu"})
+	SetFailures("db", 5)
+
+	if Inject("db") {
+		t.Error("Expected a call without the attribute not to fire")
+	}
+	if !Inject("db", WithAttrs(Attributes{"region": "eu"})) {
+		t.Error("Expected a matching call to fire")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatrixRule is a rule template in a spec's "matrix" section, expanded into
+// the same rule for every key its key templates produce:
+//
+//	matrix:
+//	  - keys: ["create-ec2-{region}", "delete-ec2-{region}"]
+//	    with: {region: [us-east-1, eu-west-1]}
+//	    rule: {count: 2, error: "InsufficientInstanceCapacity"}
+//
+// Each "{name}" in a key template is replaced by the values of that variable
+// in With, over every combination of the variables it uses. A template
+// without variables is a key as is, so a plain list of keys shares one rule.
+type MatrixRule struct {
+	Keys []string            `yaml:"keys"`
+	With map[string][]string `yaml:"with,omitempty"`
+	Rule Rule                `yaml:"rule"`
+}
+
+// matrixVar matches a "{name}" placeholder in a key template.
+var matrixVar = regexp.MustCompile(`\{([A-Za-z0-9_-]+)\}`)
+
+// expand returns the keys m produces, in order.
+func (m MatrixRule) expand() ([]string, error) {
+	if len(m.Keys) == 0 {
+		return nil, fmt.Errorf("no keys")
+	}
+	if m.Rule.empty() {
+		return nil, fmt.Errorf("%s: empty rule", m.Keys[0])
+	}
+	if err := m.Rule.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Keys[0], err)
+	}
+	used := make(map[string]bool)
+	var keys []string
+	for _, tmpl := range m.Keys {
+		expanded := []string{tmpl}
+		seen := make(map[string]bool)
+		for _, match := range matrixVar.FindAllStringSubmatch(tmpl, -1) {
+			name := match[1]
+			if seen[name] {
+				continue // every occurrence was replaced at once
+			}
+			seen[name] = true
+			values, ok := m.With[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: undefined variable %q", tmpl, name)
+			}
+			if len(values) == 0 {
+				return nil, fmt.Errorf("%s: variable %q has no values", tmpl, name)
+			}
+			used[name] = true
+			var next []string
+			for _, key := range expanded {
+				for _, v := range values {
+					next = append(next, strings.ReplaceAll(key, match[0], v))
+				}
+			}
+			expanded = next
+		}
+		keys = append(keys, expanded...)
+	}
+	for name := range m.With {
+		if !used[name] {
+			return nil, fmt.Errorf("%s: variable %q is not used", m.Keys[0], name)
+		}
+	}
+	return keys, nil
+}
+
+// expandMatrix adds the rules of cfg.Matrix to cfg.Rules and clears it. It
+// returns a *SpecError for the entry if a template is invalid or two rules
+// end up with the same key.
+func (cfg *Spec) expandMatrix() error {
+	if len(cfg.Matrix) == 0 {
+		return nil
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = make(map[string]Rule)
+	}
+	from := make(map[string]int) // key -> matrix entry that produced it
+	for i, m := range cfg.Matrix {
+		keys, err := m.expand()
+		if err != nil {
+			return &SpecError{Section: "matrix", Key: strconv.Itoa(i + 1), Err: err}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if j, ok := from[key]; ok {
+				return &SpecError{Section: "matrix", Key: strconv.Itoa(i + 1), Err: fmt.Errorf("key %q already produced by entry %d", key, j+1)}
+			}
+			if _, ok := cfg.Rules[key]; ok {
+				return &SpecError{Section: "matrix", Key: strconv.Itoa(i + 1), Err: fmt.Errorf("key %q already has a rule", key)}
+			}
+			from[key] = i
+		}
+		for _, key := range keys {
+			cfg.Rules[key] = m.Rule
+		}
+	}
+	cfg.Matrix = nil
+	return nil
+}
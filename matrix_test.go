@@ -0,0 +1,130 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMatrixExpansion(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected []string
+		err      string
+	}{
+		{
+			name:     "one variable",
+			spec:     "matrix:\n  - keys: [\"create-ec2-{region}\"]\n    with: {region: [us-east-1, eu-west-1]}\n    rule: {count: 2}\n",
+			expected: []string{"create-ec2-eu-west-1", "create-ec2-us-east-1"},
+		},
+		{
+			name:     "combinations",
+			spec:     "matrix:\n  - keys: [\"ebs-{region}-{zone}\", \"ec2-{region}\"]\n    with: {region: [us, eu], zone: [a, b]}\n    rule: {count: 1}\n",
+			expected: []string{"ebs-eu-a", "ebs-eu-b", "ebs-us-a", "ebs-us-b", "ec2-eu", "ec2-us"},
+		},
+		{
+			name:     "repeated variable",
+			spec:     "matrix:\n  - keys: [\"{r}-to-{r}\"]\n    with: {r: [us, eu]}\n    rule: {count: 1}\n",
+			expected: []string{"eu-to-eu", "us-to-us"},
+		},
+		{
+			name:     "plain keys",
+			spec:     "matrix:\n  - keys: [orders-db, billing-db]\n    rule: {count: 1}\n",
+			expected: []string{"billing-db", "orders-db"},
+		},
+		{
+			name: "undefined variable",
+			spec: "matrix:\n  - keys: [\"ec2-{region}\"]\n    rule: {count: 1}\n",
+			err:  `undefined variable "region"`,
+		},
+		{
+			name: "unused variable",
+			spec: "matrix:\n  - keys: [ec2]\n    with: {region: [us]}\n    rule: {count: 1}\n",
+			err:  `variable "region" is not used`,
+		},
+		{
+			name: "no values",
+			spec: "matrix:\n  - keys: [\"ec2-{region}\"]\n    with: {region: []}\n    rule: {count: 1}\n",
+			err:  "has no values",
+		},
+		{
+			name: "empty rule",
+			spec: "matrix:\n  - keys: [ec2]\n",
+			err:  "empty rule",
+		},
+		{
+			name: "duplicate across entries",
+			spec: "matrix:\n  - keys: [ec2]\n    rule: {count: 1}\n  - keys: [ec2]\n    rule: {nth: 2}\n",
+			err:  `matrix[2]: key "ec2" already produced by entry 1`,
+		},
+		{
+			name: "conflicts with rules",
+			spec: "rules:\n  ec2: {count: 1}\nmatrix:\n  - keys: [ec2]\n    rule: {count: 3}\n",
+			err:  `key "ec2" already has a rule`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseSpec([]byte(tt.spec), FormatYAML)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("Expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var keys []string
+			for k := range cfg.Rules {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, keys)
+			}
+			if cfg.Matrix != nil {
+				t.Errorf("Expected the matrix cleared, got %+v", cfg.Matrix)
+			}
+		})
+	}
+}
+
+func TestMatrixLoad(t *testing.T) {
+	resetState()
+	spec := `{"matrix": [{"keys": ["create-ec2-{region}"], "with": {"region": ["us-east-1", "eu-west-1"]}, "rule": {"count": 1, "error": "capacity"}}]}`
+	if err := LoadSpecFromBytes([]byte(spec), ""); err != nil {
+		t.Fatalf("LoadSpecFromBytes failed: %v", err)
+	}
+	for _, key := range []string{"create-ec2-us-east-1", "create-ec2-eu-west-1"} {
+		if err := InjectWithError(key, "boom"); err == nil || !strings.Contains(err.Error(), "capacity") {
+			t.Errorf("Expected %s to fail with the template's error, got %v", key, err)
+		}
+		if Inject(key) {
+			t.Errorf("Expected %s to keep its own count", key)
+		}
+	}
+
+	if err := LoadSpecFromBytes([]byte("matrix:\n  - keys: [\"x-{y}\"]\n    rule: {count: 1}\n"), FormatYAML); err == nil {
+		t.Error("Expected an invalid matrix to be rejected")
+	}
+	if Status()["create-ec2-us-east-1"] != 0 || len(Status()) != 2 {
+		t.Errorf("Expected a rejected spec to leave the rules alone, got %v", Status())
+	}
+}
+
+func TestSpecAnchors(t *testing.T) {
+	spec := "base: &base {count: 2, error: capacity}\nrules:\n  a: *base\n  b: {<<: *base, count: 5}\n"
+	cfg, err := parseSpec([]byte(spec), FormatYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Rules["a"].Count != 2 || cfg.Rules["b"].Count != 5 || cfg.Rules["b"].Error != "capacity" {
+		t.Errorf("Expected anchors and merge keys to apply, got %+v", cfg.Rules)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the injected latency histogram.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// KeyMetrics are the cumulative metrics of one key. They survive Reset and
+// Prune so they can be exported as monotonic counters, and are kept for every
+// key ever evaluated.
+type KeyMetrics struct {
+	Evaluations map[Reason]uint64 // calls by decision reason
+	Injected    uint64            // calls that fired
+	Latency     LatencyHistogram  // delays added by InjectLatency
+}
+
+// LatencyHistogram is a cumulative histogram of injected delays.
+type LatencyHistogram struct {
+	Count   uint64
+	Sum     float64            // seconds
+	Buckets map[float64]uint64 // upper bound in seconds -> cumulative count
+}
+
+// Metrics returns a snapshot of the metrics of every key evaluated so far.
+func (in *Injector) Metrics() map[string]KeyMetrics {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	out := make(map[string]KeyMetrics, len(in.metrics))
+	for key, m := range in.metrics {
+		c := KeyMetrics{
+			Evaluations: make(map[Reason]uint64, len(m.Evaluations)),
+			Injected:    m.Injected,
+			Latency:     LatencyHistogram{Count: m.Latency.Count, Sum: m.Latency.Sum},
+		}
+		for r, n := range m.Evaluations {
+			c.Evaluations[r] = n
+		}
+		if m.Latency.Buckets != nil {
+			c.Latency.Buckets = make(map[float64]uint64, len(m.Latency.Buckets))
+			for b, n := range m.Latency.Buckets {
+				c.Latency.Buckets[b] = n
+			}
+		}
+		out[key] = c
+	}
+	return out
+}
+
+// Metrics calls Metrics on the default Injector.
+func Metrics() map[string]KeyMetrics {
+	return std.Metrics()
+}
+
+func (in *Injector) metricsLocked(key string) *KeyMetrics {
+	m := in.metrics[key]
+	if m == nil {
+		m = &KeyMetrics{Evaluations: make(map[Reason]uint64)}
+		in.metrics[key] = m
+		in.recordOriginLocked(key)
+	}
+	return m
+}
+
+func (in *Injector) countDecisionLocked(key string, fired bool, reason Reason) {
+	if in.metricsOff {
+		return
+	}
+	m := in.metricsLocked(key)
+	m.Evaluations[reason]++
+	if fired {
+		m.Injected++
+	}
+}
+
+func (in *Injector) observeLatency(key string, d time.Duration) {
+	if in.metricsOff {
+		return
+	}
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	h := &in.metricsLocked(key).Latency
+	if h.Buckets == nil {
+		h.Buckets = make(map[float64]uint64, len(latencyBuckets))
+	}
+	s := d.Seconds()
+	h.Count++
+	h.Sum += s
+	for _, b := range latencyBuckets {
+		if s <= b {
+			h.Buckets[b]++
+		}
+	}
+}
+
+// MetricsHandler serves Metrics in the Prometheus text exposition format:
+//
+//	faultinject_evaluations_total{key,reason}  counter
+//	faultinject_injected_total{key}             counter
+//	faultinject_injected_latency_seconds{key}   histogram
+func (in *Injector) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writeMetrics(w, in.Metrics())
+	})
+}
+
+// MetricsHandler calls MetricsHandler on the default Injector.
+func MetricsHandler() http.Handler {
+	return std.MetricsHandler()
+}
+
+func writeMetrics(w io.Writer, snapshot map[string]KeyMetrics) {
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	b := bufio.NewWriter(w)
+	defer b.Flush()
+
+	fmt.Fprintln(b, "# HELP faultinject_evaluations_total Injection point evaluations by key and decision reason.")
+	fmt.Fprintln(b, "# TYPE faultinject_evaluations_total counter")
+	for _, key := range keys {
+		reasons := make([]string, 0, len(snapshot[key].Evaluations))
+		for r := range snapshot[key].Evaluations {
+			reasons = append(reasons, string(r))
+		}
+		sort.Strings(reasons)
+		for _, r := range reasons {
+			fmt.Fprintf(b, "faultinject_evaluations_total{key=%s,reason=%s} %d\n", labelValue(key), labelValue(r), snapshot[key].Evaluations[Reason(r)])
+		}
+	}
+
+	fmt.Fprintln(b, "# HELP faultinject_injected_total Faults injected by key.")
+	fmt.Fprintln(b, "# TYPE faultinject_injected_total counter")
+	for _, key := range keys {
+		if len(snapshot[key].Evaluations) > 0 {
+			fmt.Fprintf(b, "faultinject_injected_total{key=%s} %d\n", labelValue(key), snapshot[key].Injected)
+		}
+	}
+
+	fmt.Fprintln(b, "# HELP faultinject_injected_latency_seconds Latency injected by key.")
+	fmt.Fprintln(b, "# TYPE faultinject_injected_latency_seconds histogram")
+	for _, key := range keys {
+		h := snapshot[key].Latency
+		if h.Count == 0 {
+			continue
+		}
+		k := labelValue(key)
+		for _, bound := range latencyBuckets {
+			fmt.Fprintf(b, "faultinject_injected_latency_seconds_bucket{key=%s,le=%q} %d\n", k, strconv.FormatFloat(bound, 'g', -1, 64), h.Buckets[bound])
+		}
+		fmt.Fprintf(b, "faultinject_injected_latency_seconds_bucket{key=%s,le=\"+Inf\"} %d\n", k, h.Count)
+		fmt.Fprintf(b, "faultinject_injected_latency_seconds_sum{key=%s} %s\n", k, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+		fmt.Fprintf(b, "faultinject_injected_latency_seconds_count{key=%s} %d\n", k, h.Count)
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue quotes s as a Prometheus label value.
+func labelValue(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
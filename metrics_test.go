@@ -0,0 +1,73 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	resetState()
+	before := Metrics()["metrics-db"]
+
+	SetFailures("metrics-db", 1)
+	Inject("metrics-db")
+	Inject("metrics-db")
+	Reset() // metrics are cumulative and survive Reset
+	Inject("metrics-db")
+
+	m := Metrics()["metrics-db"]
+	tests := []struct {
+		name     string
+		got      uint64
+		expected uint64
+	}{
+		{name: "fired", got: m.Evaluations[ReasonFired] - before.Evaluations[ReasonFired], expected: 1},
+		{name: "exhausted", got: m.Evaluations[ReasonExhausted] - before.Evaluations[ReasonExhausted], expected: 1},
+		{name: "disabled", got: m.Evaluations[ReasonDisabled] - before.Evaluations[ReasonDisabled], expected: 1},
+		{name: "injected", got: m.Injected - before.Injected, expected: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	resetState()
+	SetFailures(`metrics "quoted"`, 1)
+	Inject(`metrics "quoted"`)
+	SetLatency("metrics-api", FixedLatency(20*time.Millisecond))
+	InjectLatency(context.Background(), "metrics-api")
+
+	rec := httptest.NewRecorder()
+	MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	expected := []string{
+		"# TYPE faultinject_evaluations_total counter",
+		`faultinject_evaluations_total{key="metrics \"quoted\"",reason="fired"} `,
+		`faultinject_injected_total{key="metrics \"quoted\""} `,
+		"# TYPE faultinject_injected_latency_seconds histogram",
+		`faultinject_injected_latency_seconds_bucket{key="metrics-api",le="0.01"} 0`,
+		`faultinject_injected_latency_seconds_bucket{key="metrics-api",le="0.025"} 1`,
+		`faultinject_injected_latency_seconds_bucket{key="metrics-api",le="+Inf"} 1`,
+		`faultinject_injected_latency_seconds_count{key="metrics-api"} 1`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(body, e) {
+			t.Errorf("Expected output to contain %q\n%s", e, body)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", ct)
+	}
+}
@@ -1,28 +1,186 @@
 // Copyright 2025 Talina Shrotriya
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build faultinject
+
 package faultinject
 
 import (
 	"context"
-	"fmt"
+	"io"
 	"net/http"
+	"strings"
 )
 
-// HTTPMiddleware creates middleware that injects failures for HTTP requests
-// Returns 500 status code by default when fault injection triggers
-func HTTPMiddleware(key string) func(http.Handler) http.Handler {
-	return HTTPMiddlewareWithResponse(key, func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, "Injected failure", http.StatusInternalServerError)
-	})
+// MiddlewareOption configures HTTPMiddleware and HTTPMiddlewareWithResponse.
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	decisionHeader  bool
+	faultHeader     string
+	faultSecret     []byte
+	directives      bool
+	directiveSecret []byte
+	injector        *Injector
+}
+
+// WithInjector makes the package-level middleware constructors, and the
+// chifi, ginfi and echofi middleware built on them, decide requests with in
+// instead of the default Injector. Middleware created with an Injector's
+// methods always uses that Injector.
+func WithInjector(in *Injector) MiddlewareOption {
+	return func(c *middlewareConfig) { c.injector = in }
 }
 
-// HTTPMiddlewareWithResponse creates middleware with custom response handling
-func HTTPMiddlewareWithResponse(key string, responseFn func(http.ResponseWriter, *http.Request)) func(http.Handler) http.Handler {
+// injectorFor returns the Injector set in opts with WithInjector, or the
+// default Injector.
+func injectorFor(opts []MiddlewareOption) *Injector {
+	var cfg middlewareConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.injector != nil {
+		return cfg.injector
+	}
+	return std
+}
+
+// WithDecisionHeader makes the middleware set DecisionHeader on every
+// response, listing each key evaluated with the request's context and whether
+// it fired. Decisions made after the response headers are written are not
+// included.
+func WithDecisionHeader() MiddlewareOption {
+	return func(c *middlewareConfig) { c.decisionHeader = true }
+}
+
+// HTTPMiddleware creates middleware that injects failures for HTTP requests.
+// It responds with the Status of key's rule, 500 by default, the rule's
+// Header, and its Body or else a body from its error template, "Injected
+// failure" by default, or with the Degradation set for key by
+// SetDegradation, or with the handler's own response broken as set by
+// SetResponseFault. The whole response can be declared in a spec, so the
+// middleware needs only the key.
+func (in *Injector) HTTPMiddleware(key string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return in.middleware(key, in.faultResponse(key, http.StatusInternalServerError), opts...)
+}
+
+// HTTPMiddleware calls HTTPMiddleware on the default Injector, or the one
+// set with WithInjector.
+func HTTPMiddleware(key string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return injectorFor(opts).HTTPMiddleware(key, opts...)
+}
+
+// HTTPMiddlewareWithStatus is HTTPMiddleware responding with status instead
+// of 500 when key's rule sets no Status.
+func (in *Injector) HTTPMiddlewareWithStatus(key string, status int, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return in.middleware(key, in.faultResponse(key, status), opts...)
+}
+
+// HTTPMiddlewareWithStatus calls HTTPMiddlewareWithStatus on the default
+// Injector, or the one set with WithInjector.
+func HTTPMiddlewareWithStatus(key string, status int, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return injectorFor(opts).HTTPMiddlewareWithStatus(key, status, opts...)
+}
+
+// faultResponse returns the responder of HTTPMiddleware, with status as the
+// default.
+func (in *Injector) faultResponse(key string, status int) func(w http.ResponseWriter, r *http.Request, next http.Handler, count int) {
+	return func(w http.ResponseWriter, r *http.Request, next http.Handler, count int) {
+		if d, ok := in.degradationFor(key); ok {
+			d.ServeHTTP(w, r)
+			return
+		}
+		if f, ok := in.responseFaultFor(key); ok {
+			in.breakResponse(w, r, next, key, f)
+			return
+		}
+		s := in.snapshot()
+		rule := s.rules[s.ruleKey(key)]
+		code := rule.Status
+		if st := directiveFor(r.Context(), key); st != nil {
+			code = st.Status
+		}
+		if code == 0 {
+			code = status
+		}
+		message := rule.errorMessage(key, count, "Injected failure")
+		for name, value := range rule.Header {
+			w.Header().Set(name, expandTemplate(value, key, count, message))
+		}
+		if rule.Body == "" {
+			http.Error(w, message, code)
+			return
+		}
+		body := expandTemplate(rule.Body, key, count, message)
+		if w.Header().Get("Content-Type") == "" {
+			if t := strings.TrimSpace(body); strings.HasPrefix(t, "{") || strings.HasPrefix(t, "[") {
+				w.Header().Set("Content-Type", "application/json")
+			} else {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			}
+		}
+		w.WriteHeader(code)
+		io.WriteString(w, body)
+	}
+}
+
+// HTTPMiddlewareWithResponse creates middleware with custom response handling.
+// The request context passed on carries the request's method, path and headers
+// as Attributes, so selectors can target individual requests.
+func (in *Injector) HTTPMiddlewareWithResponse(key string, responseFn func(http.ResponseWriter, *http.Request), opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return in.middleware(key, func(w http.ResponseWriter, r *http.Request, _ http.Handler, _ int) {
+		responseFn(w, r)
+	}, opts...)
+}
+
+// middleware is HTTPMiddlewareWithResponse with the wrapped handler and the
+// call count the fault fired at passed to respond.
+func (in *Injector) middleware(key string, respond func(w http.ResponseWriter, r *http.Request, next http.Handler, count int), opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return in.routeMiddleware(func(r *http.Request) (string, *http.Request, bool) {
+		return key, r, true
+	}, func(w http.ResponseWriter, r *http.Request, next http.Handler, _ string, count int) {
+		respond(w, r, next, count)
+	}, opts...)
+}
+
+// routeMiddleware is middleware for the key route returns for each request,
+// passing requests it returns false for on untouched. route also returns
+// the request to take attributes from, matched to its route.
+func (in *Injector) routeMiddleware(route func(*http.Request) (string, *http.Request, bool), respond func(w http.ResponseWriter, r *http.Request, next http.Handler, key string, count int), opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	var cfg middlewareConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if Inject(key) {
-				responseFn(w, r)
+			key, matched, ok := route(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			attrs := in.requestAttributes(matched)
+			if s := in.snapshot(); s.rules[s.ruleKey(key)].matchesBody() {
+				if body, ok := bodyAttribute(r); ok {
+					attrs["body"] = body
+				}
+			}
+			ctx := WithAttributes(r.Context(), attrs)
+			if cfg.faultHeader != "" {
+				ctx = in.headerOverrides(ctx, r, cfg.faultHeader, cfg.faultSecret)
+			}
+			if cfg.directives {
+				ctx = in.headerDirectives(ctx, r, cfg.directiveSecret)
+			}
+			// when an outer middleware already traces the request, it sets the header
+			if cfg.decisionHeader && traceFrom(ctx) == nil {
+				ctx = WithDecisionTrace(ctx)
+				dw := &decisionWriter{ResponseWriter: w, ctx: ctx}
+				defer dw.annotate()
+				w = dw
+			}
+			r = r.WithContext(ctx)
+			if fired, count := in.evaluateWithContext(ctx, key); fired {
+				respond(w, r, next, key, count)
 				return
 			}
 			next.ServeHTTP(w, r)
@@ -30,14 +188,46 @@ func HTTPMiddlewareWithResponse(key string, responseFn func(http.ResponseWriter,
 	}
 }
 
+// HTTPMiddlewareWithResponse calls HTTPMiddlewareWithResponse on the
+// default Injector, or the one set with WithInjector.
+func HTTPMiddlewareWithResponse(key string, responseFn func(http.ResponseWriter, *http.Request), opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return injectorFor(opts).HTTPMiddlewareWithResponse(key, responseFn, opts...)
+}
+
+// requestAttributes exposes r to selectors as "method", "path",
+// "header.<name>", "query.<name>", "param.<name>" and "size" or "chunked"
+// attributes, plus "tenant" when the tenant header is set and "synthetic"
+// when SyntheticHeader is.
+func (in *Injector) requestAttributes(r *http.Request) Attributes {
+	attrs := Attributes{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}
+	for name, values := range r.Header {
+		if len(values) > 0 {
+			attrs[headerAttribute(name)] = values[0]
+		}
+	}
+	addQueryAttributes(attrs, r)
+	addRouteAttributes(attrs, r)
+	addSizeAttributes(attrs, r)
+	if t := r.Header.Get(in.snapshot().tenantHeader); t != "" {
+		attrs["tenant"] = t
+	}
+	if r.Header.Get(SyntheticHeader) != "" {
+		attrs["synthetic"] = "true"
+	}
+	return attrs
+}
+
 // Decorator is a generic function decorator that injects failures
 type Decorator[T any] func(T) error
 
 // WithFaultInjection decorates a function with fault injection
 func WithFaultInjection[T any](key string, fn func(T) error) Decorator[T] {
 	return func(input T) error {
-		if Inject(key) {
-			return fmt.Errorf("injected failure")
+		if fired, count := std.evaluate(context.Background(), key); fired {
+			return std.newInjectedError(key, count, "")
 		}
 		return fn(input)
 	}
@@ -46,8 +236,8 @@ func WithFaultInjection[T any](key string, fn func(T) error) Decorator[T] {
 // WithFaultInjectionContext decorates a function with context-aware fault injection
 func WithFaultInjectionContext[T any](key string, fn func(T) error) func(context.Context, T) error {
 	return func(ctx context.Context, input T) error {
-		if InjectWithContext(ctx, key) {
-			return fmt.Errorf("injected failure")
+		if fired, count := std.evaluateWithContext(ctx, key); fired {
+			return std.newInjectedError(key, count, "")
 		}
 		return fn(input)
 	}
@@ -1,3 +1,5 @@
+//go:build faultinject
+
 package faultinject
 
 import (
@@ -465,3 +467,143 @@ func TestMiddlewareRequestContext(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTPMiddlewareWithStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		rule           Rule
+		expectedStatus int
+		expectedBody   string
+		expectedType   string
+	}{
+		{
+			name:           "default status",
+			rule:           Rule{Mode: ModeFirstN, Count: 1},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "Injected failure\n",
+			expectedType:   "text/plain; charset=utf-8",
+		},
+		{
+			name:           "rule status wins",
+			rule:           Rule{Mode: ModeFirstN, Count: 1, Status: http.StatusTooManyRequests},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   "Injected failure\n",
+			expectedType:   "text/plain; charset=utf-8",
+		},
+		{
+			name:           "json body",
+			rule:           Rule{Mode: ModeFirstN, Count: 1, Error: "{key} down", Body: `{"error":"{message}","attempt":{count}}`},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"error":"status down","attempt":1}`,
+			expectedType:   "application/json",
+		},
+		{
+			name:           "text body",
+			rule:           Rule{Mode: ModeFirstN, Count: 1, Body: "try later"},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "try later",
+			expectedType:   "text/plain; charset=utf-8",
+		},
+		{
+			name:           "header content type",
+			rule:           Rule{Mode: ModeFirstN, Count: 1, Header: map[string]string{"Content-Type": "application/xml"}, Body: "<error/>"},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "<error/>",
+			expectedType:   "application/xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetRule("status", tt.rule)
+			handler := HTTPMiddlewareWithStatus("status", http.StatusServiceUnavailable)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("success"))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+			if rec.Body.String() != tt.expectedBody {
+				t.Errorf("Expected body %q, got %q", tt.expectedBody, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.expectedType {
+				t.Errorf("Expected Content-Type %q, got %q", tt.expectedType, got)
+			}
+
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("Expected status 200 after the fault, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestHTTPMiddlewareSpecResponse(t *testing.T) {
+	resetState()
+	err := LoadSpecFromReader(strings.NewReader(`
+rules:
+  payments-api:
+    count: 1
+    status: 429
+    header: {Retry-After: "30", X-Fault: "{key}"}
+    body: '{"error": "{key} throttled", "attempt": {count}}'
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := HTTPMiddleware("payments-api")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("success"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", got)
+	}
+	if got := rec.Header().Get("X-Fault"); got != "payments-api" {
+		t.Errorf("Expected X-Fault payments-api, got %q", got)
+	}
+	if want := `{"error": "payments-api throttled", "attempt": 1}`; rec.Body.String() != want {
+		t.Errorf("Expected body %q, got %q", want, rec.Body.String())
+	}
+}
+
+func TestWithInjector(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("api", 5)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("success"))
+	})
+
+	tests := []struct {
+		name       string
+		middleware func(http.Handler) http.Handler
+	}{
+		{name: "HTTPMiddleware", middleware: HTTPMiddleware("api", WithInjector(in))},
+		{name: "HTTPMiddlewareWithStatus", middleware: HTTPMiddlewareWithStatus("api", http.StatusBadGateway, WithInjector(in))},
+		{name: "HTTPMiddlewareWithResponse", middleware: HTTPMiddlewareWithResponse("api", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, WithInjector(in))},
+		{name: "HTTPMiddlewareFunc", middleware: HTTPMiddlewareFunc(func(*http.Request) string { return "api" }, WithInjector(in))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.middleware(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code < 500 {
+				t.Errorf("Expected the injector's fault, got status %d", rec.Code)
+			}
+		})
+	}
+	if _, ok := FullStatus()["api"]; ok {
+		t.Error("Expected the default Injector not to be used")
+	}
+}
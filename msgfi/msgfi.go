@@ -0,0 +1,125 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package msgfi injects delivery faults into message consumers, so ordering
+// and exactly-once assumptions can be tested against any broker client
+// (Kafka, NATS, SQS, ...).
+//
+// A Consumer evaluates these keys per message, formed from its topic:
+//
+//	<topic>.nack       reject the message with a *faultinject.InjectedError
+//	                   without handling it, so the broker redelivers it
+//	<topic>.drop       acknowledge the message without handling it
+//	<topic>.delay      wait for the key's latency (see faultinject.SetLatency)
+//	                   before handling the message
+//	<topic>.reorder    hold the message back and deliver it after the next one
+//	<topic>.duplicate  deliver the message twice
+//
+// Use faultinject.SetFailureRate to fault a fraction of messages, for example
+// faultinject.SetFailureRate("orders.duplicate", 0.05). Each message's
+// context is passed to the keys, so selectors and tenant scoping apply.
+package msgfi
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Handler processes one message.
+type Handler[M any] func(ctx context.Context, msg M) error
+
+// Consumer delivers messages to a Handler with duplicate and reorder faults.
+// Deliveries are serialized, as on an ordered partition or subscription.
+type Consumer[M any] struct {
+	topic  string
+	handle Handler[M]
+
+	mu   sync.Mutex
+	held *M
+}
+
+// NewConsumer returns a Consumer that delivers messages for topic to h.
+// Pass its Handle method to the broker client in place of h.
+func NewConsumer[M any](topic string, h Handler[M]) *Consumer[M] {
+	return &Consumer[M]{topic: topic, handle: h}
+}
+
+// WrapConsumer returns a Handler that delivers messages for topic to h with
+// the nack, drop, delay and duplicate faults. It keeps no state and so never
+// reorders; use NewConsumer for that. Calls are not serialized.
+func WrapConsumer[M any](topic string, h Handler[M]) Handler[M] {
+	c := NewConsumer(topic, h)
+	return func(ctx context.Context, msg M) error {
+		if ok, err := c.arrive(ctx); !ok {
+			return err
+		}
+		return c.deliver(ctx, msg)
+	}
+}
+
+// Handle delivers msg to the handler, unless it is rejected or dropped.
+// When <topic>.reorder fires, msg is held
+// back and Handle returns nil at once, so the broker considers it processed;
+// it is delivered right after the next message, or by Flush. At most one
+// message is held at a time. When <topic>.duplicate fires, the message is
+// delivered a second time after a successful first delivery.
+func (c *Consumer[M]) Handle(ctx context.Context, msg M) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ok, err := c.arrive(ctx); !ok {
+		return err
+	}
+	if c.held == nil && faultinject.InjectWithContext(ctx, c.topic+".reorder") {
+		c.held = &msg
+		return nil
+	}
+	err := c.deliver(ctx, msg)
+	if held := c.held; held != nil {
+		c.held = nil
+		err = errors.Join(err, c.deliver(ctx, *held))
+	}
+	return err
+}
+
+// Flush delivers the held-back message, if any. Call it when the stream ends
+// or the consumer stops, so no message is lost.
+func (c *Consumer[M]) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	held := c.held
+	if held == nil {
+		return nil
+	}
+	c.held = nil
+	return c.deliver(ctx, *held)
+}
+
+// arrive applies the nack, drop and delay faults to an arriving message and
+// reports whether it should be delivered.
+func (c *Consumer[M]) arrive(ctx context.Context) (bool, error) {
+	if err := faultinject.InjectWithContextError(ctx, c.topic+".nack", "message on "+c.topic+" rejected"); err != nil {
+		return false, err
+	}
+	if faultinject.InjectWithContext(ctx, c.topic+".drop") {
+		return false, nil
+	}
+	if _, err := faultinject.InjectLatency(ctx, c.topic+".delay"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Consumer[M]) deliver(ctx context.Context, msg M) error {
+	if err := c.handle(ctx, msg); err != nil {
+		return err
+	}
+	if faultinject.InjectWithContext(ctx, c.topic+".duplicate") {
+		return c.handle(ctx, msg)
+	}
+	return nil
+}
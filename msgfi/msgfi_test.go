@@ -0,0 +1,163 @@
+//go:build faultinject
+
+package msgfi
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func resetState() {
+	faultinject.Reset()
+	os.Setenv("ENVIRONMENT", "development")
+}
+
+func TestConsumer(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func()
+		expected  []int
+	}{
+		{name: "no faults", configure: func() {}, expected: []int{1, 2, 3}},
+		{name: "duplicate", configure: func() { faultinject.SetNthFailure("orders.duplicate", 2) }, expected: []int{1, 2, 2, 3}},
+		{name: "reorder", configure: func() { faultinject.SetFailures("orders.reorder", 1) }, expected: []int{2, 1, 3}},
+		{name: "reorder last", configure: func() { faultinject.SetNthFailure("orders.reorder", 3) }, expected: []int{1, 2, 3}},
+		{name: "every message", configure: func() { faultinject.SetFailureRate(`^orders\.(duplicate|reorder)$`, 1) }, expected: []int{2, 2, 1, 1, 3, 3}},
+		{name: "drop", configure: func() { faultinject.SetNthFailure("orders.drop", 2) }, expected: []int{1, 3}},
+		{name: "drop held back", configure: func() {
+			faultinject.SetFailures("orders.reorder", 1)
+			faultinject.SetNthFailure("orders.drop", 2)
+		}, expected: []int{3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.configure()
+
+			var got []int
+			c := NewConsumer("orders", func(ctx context.Context, msg int) error {
+				got = append(got, msg)
+				return nil
+			})
+			for _, msg := range []int{1, 2, 3} {
+				if err := c.Handle(context.Background(), msg); err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+			}
+			if err := c.Flush(context.Background()); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestConsumerError(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("orders.reorder", 1)
+	faultinject.SetFailures("orders.duplicate", 1)
+
+	failed := errors.New("handler failed")
+	calls := 0
+	c := NewConsumer("orders", func(ctx context.Context, msg int) error {
+		calls++
+		if msg == 1 {
+			return failed
+		}
+		return nil
+	})
+	c.Handle(context.Background(), 1)
+	if err := c.Handle(context.Background(), 2); !errors.Is(err, failed) {
+		t.Errorf("Expected the held message's error, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected message 2 twice and message 1 once, got %d calls", calls)
+	}
+}
+
+func TestConsumerNack(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("orders.nack", 1)
+
+	calls := 0
+	c := NewConsumer("orders", func(ctx context.Context, msg int) error {
+		calls++
+		return nil
+	})
+	if err := c.Handle(context.Background(), 1); !errors.Is(err, faultinject.ErrInjected) {
+		t.Errorf("Expected an injected error, got %v", err)
+	}
+	// the broker redelivers
+	if err := c.Handle(context.Background(), 1); err != nil {
+		t.Errorf("Expected the redelivery to succeed, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the handler to run once, got %d calls", calls)
+	}
+}
+
+func TestConsumerDelay(t *testing.T) {
+	resetState()
+	faultinject.SetLatency("orders.delay", faultinject.FixedLatency(20*time.Millisecond))
+
+	c := NewConsumer("orders", func(ctx context.Context, msg int) error { return nil })
+	start := time.Now()
+	if err := c.Handle(context.Background(), 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected delivery to be delayed by 20ms, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := c.Handle(ctx, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the interrupted delay's error, got %v", err)
+	}
+}
+
+func TestWrapConsumer(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func()
+		expected  []int
+		failed    int
+	}{
+		{name: "no faults", configure: func() {}, expected: []int{1, 2, 3}},
+		{name: "drop", configure: func() { faultinject.SetNthFailure("orders.drop", 1) }, expected: []int{2, 3}},
+		{name: "nack", configure: func() { faultinject.SetNthFailure("orders.nack", 3) }, expected: []int{1, 2}, failed: 1},
+		{name: "duplicate", configure: func() { faultinject.SetNthFailure("orders.duplicate", 2) }, expected: []int{1, 2, 2, 3}},
+		{name: "reorder ignored", configure: func() { faultinject.SetFailures("orders.reorder", 1) }, expected: []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.configure()
+
+			var got []int
+			h := WrapConsumer("orders", func(ctx context.Context, msg int) error {
+				got = append(got, msg)
+				return nil
+			})
+			failed := 0
+			for _, msg := range []int{1, 2, 3} {
+				if err := h(context.Background(), msg); err != nil {
+					failed++
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) || failed != tt.failed {
+				t.Errorf("Expected %v with %d failed, got %v with %d failed", tt.expected, tt.failed, got, failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"log/slog"
+)
+
+// Option configures an Injector built by New.
+type Option func(*config)
+
+type config struct {
+	specFile string
+	envGuard func() bool
+	logger   *slog.Logger
+	clock    Clock
+	metrics  bool
+}
+
+// New returns an Injector, independent of Default like one from
+// NewInjector, configured by opts. Options only take effect while New
+// builds the Injector, so it is fully set up before any other goroutine can
+// see it. It returns an error if a spec file cannot be loaded.
+func New(opts ...Option) (*Injector, error) {
+	c := config{metrics: true}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	in := NewInjector()
+	in.envGuard = c.envGuard
+	in.metricsOff = !c.metrics
+	if c.clock != nil {
+		in.clock = c.clock
+	}
+	if c.logger != nil {
+		in.SetLogger(c.logger)
+	}
+	if c.specFile != "" {
+		if err := in.LoadSpec(c.specFile); err != nil {
+			return nil, err
+		}
+	}
+	return in, nil
+}
+
+// WithSpecFile loads the spec at path into the Injector, as LoadSpec does.
+func WithSpecFile(path string) Option {
+	return func(c *config) {
+		c.specFile = path
+	}
+}
+
+// WithEnvGuard decides whether the Injector runs in production, where fault
+// injection is disabled, by calling production instead of reading the
+// ENVIRONMENT, ENV and GO_ENV variables. Tests can pass a func returning
+// false to inject faults whatever the environment, and services one that
+// consults their own configuration.
+func WithEnvGuard(production func() bool) Option {
+	return func(c *config) {
+		c.envGuard = production
+	}
+}
+
+// WithLogger makes the Injector log to l, as SetLogger does.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// WithClock makes the Injector tell the time with clk rather than the
+// system clock, for rule expiry, flapping cycles, idle keys and the times
+// recorded on events and errors. If clk is a SleepClock, such as a
+// ManualClock, latency faults wait on it too.
+func WithClock(clk Clock) Option {
+	return func(c *config) {
+		c.clock = clk
+	}
+}
+
+// WithMetrics sets whether the Injector keeps per-key metrics, which
+// Metrics, MetricsHandler, Keys and KeyAnalytics report. They are kept
+// unless turned off, which saves their upkeep on every call where nothing
+// reads them.
+func WithMetrics(enabled bool) Option {
+	return func(c *config) {
+		c.metrics = enabled
+	}
+}
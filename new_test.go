@@ -0,0 +1,118 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedClock struct{ t time.Time }
+
+func (c *fixedClock) Now() time.Time { return c.t }
+
+func TestNewEnvGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        string
+		production bool
+		expected   bool
+	}{
+		{name: "guard allows in production env", env: "production", production: false, expected: true},
+		{name: "guard blocks in development env", env: "development", production: true, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv("ENVIRONMENT", tt.env)
+			defer os.Setenv("ENVIRONMENT", "development")
+			in, err := New(WithEnvGuard(func() bool { return tt.production }))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			in.SetFailures("db", 1)
+			if got := in.Inject("db"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewClock(t *testing.T) {
+	resetState()
+	clk := &fixedClock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	in, err := New(WithClock(clk))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	in.SetFailuresFor("db", 10, time.Minute)
+
+	if !in.Inject("db") {
+		t.Error("Expected the rule to fire before its TTL")
+	}
+	clk.t = clk.t.Add(time.Minute)
+	if in.Inject("db") {
+		t.Error("Expected the rule to expire on the injector's clock")
+	}
+	if got := in.Rules()["db"].Expires; !got.Equal(time.Date(2025, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Errorf("Expected the expiry on the injector's clock, got %v", got)
+	}
+}
+
+func TestNewMetrics(t *testing.T) {
+	resetState()
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected int
+	}{
+		{name: "default", expected: 1},
+		{name: "off", opts: []Option{WithMetrics(false)}, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, err := New(tt.opts...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			in.SetFailures("db", 1)
+			in.Inject("db")
+			if got := len(in.Metrics()); got != tt.expected {
+				t.Errorf("Expected metrics for %d keys, got %d", tt.expected, got)
+			}
+			if got := len(in.Keys()); got != tt.expected {
+				t.Errorf("Expected %d keys, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewSpecFileAndLogger(t *testing.T) {
+	resetState()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "faults.yaml")
+	os.WriteFile(path, []byte("failures:\n  db: 1\n"), 0o644)
+	var buf bytes.Buffer
+
+	in, err := New(WithSpecFile(path), WithLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !in.Inject("db") {
+		t.Error("Expected the spec's rule to fire")
+	}
+	if !strings.Contains(buf.String(), "key=db") {
+		t.Errorf("Expected the fault to be logged, got %q", buf.String())
+	}
+	if Status()["db"] != 0 {
+		t.Error("Expected the default Injector to be left alone")
+	}
+
+	if _, err := New(WithSpecFile(filepath.Join(dir, "missing.yaml"))); err == nil {
+		t.Error("Expected an error for a missing spec file")
+	}
+}
@@ -0,0 +1,625 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !faultinject
+
+package faultinject
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// This file is the whole package unless built with -tags faultinject, so
+// production binaries carry no fault injection. It keeps the functions
+// production code calls at injection points, with bodies that never inject
+// anything, so the compiler can inline them away. Configuration beyond the
+// basic setters, specs, the control server, metrics and history are left
+// out: code that uses them belongs in builds with the tag and fails to
+// compile here rather than silently doing nothing.
+
+// An Injector never injects faults in this build.
+type Injector struct{}
+
+var std = &Injector{}
+
+// NewInjector returns an Injector that never injects faults.
+func NewInjector() *Injector { return &Injector{} }
+
+// Default returns the Injector used by the package-level functions.
+func Default() *Injector { return std }
+
+// Option configures an Injector built by New.
+type Option func(*config)
+
+type config struct{}
+
+// New returns an Injector that never injects faults. WithSpecFile is left
+// out, as specs are.
+func New(opts ...Option) (*Injector, error) { return &Injector{}, nil }
+
+// WithEnvGuard does nothing.
+func WithEnvGuard(production func() bool) Option { return func(*config) {} }
+
+// WithLogger does nothing.
+func WithLogger(l *slog.Logger) Option { return func(*config) {} }
+
+// WithClock does nothing.
+func WithClock(clk Clock) Option { return func(*config) {} }
+
+// WithMetrics does nothing.
+func WithMetrics(enabled bool) Option { return func(*config) {} }
+
+// Inject always returns false.
+func (in *Injector) Inject(key string, opts ...InjectOption) bool { return false }
+
+// Inject always returns false.
+func Inject(key string, opts ...InjectOption) bool { return false }
+
+// InjectWithContext always returns false.
+func (in *Injector) InjectWithContext(ctx context.Context, key string, opts ...InjectOption) bool {
+	return false
+}
+
+// InjectWithContext always returns false.
+func InjectWithContext(ctx context.Context, key string, opts ...InjectOption) bool { return false }
+
+// InjectHere always returns false.
+func (in *Injector) InjectHere() bool { return false }
+
+// InjectHere always returns false.
+func InjectHere() bool { return false }
+
+// InjectHereWithContext always returns false.
+func (in *Injector) InjectHereWithContext(ctx context.Context) bool { return false }
+
+// InjectHereWithContext always returns false.
+func InjectHereWithContext(ctx context.Context) bool { return false }
+
+// InjectWithFn never calls fn and always returns nil.
+func (in *Injector) InjectWithFn(key string, fn func() error) error { return nil }
+
+// InjectWithFn never calls fn and always returns nil.
+func InjectWithFn(key string, fn func() error) error { return nil }
+
+// InjectWithFnContext never calls fn and always returns nil.
+func (in *Injector) InjectWithFnContext(ctx context.Context, key string, fn func() error) error {
+	return nil
+}
+
+// InjectWithFnContext never calls fn and always returns nil.
+func InjectWithFnContext(ctx context.Context, key string, fn func() error) error { return nil }
+
+// InjectWithError always returns nil.
+func (in *Injector) InjectWithError(key string, message string) error { return nil }
+
+// InjectWithError always returns nil.
+func InjectWithError(key string, message string) error { return nil }
+
+// InjectWithErrorf always returns nil.
+func (in *Injector) InjectWithErrorf(key string, format string, args ...interface{}) error {
+	return nil
+}
+
+// InjectWithErrorf always returns nil.
+func InjectWithErrorf(key string, format string, args ...interface{}) error { return nil }
+
+// InjectWithContextError always returns nil.
+func (in *Injector) InjectWithContextError(ctx context.Context, key string, message string) error {
+	return nil
+}
+
+// InjectWithContextError always returns nil.
+func InjectWithContextError(ctx context.Context, key string, message string) error { return nil }
+
+// InjectContextCancel returns context.WithCancel(ctx).
+func (in *Injector) InjectContextCancel(ctx context.Context, key string) (context.Context, context.CancelFunc) {
+	return context.WithCancel(ctx)
+}
+
+// InjectContextCancel returns context.WithCancel(ctx).
+func InjectContextCancel(ctx context.Context, key string) (context.Context, context.CancelFunc) {
+	return context.WithCancel(ctx)
+}
+
+// LatencyBucket is a range of delays chosen with probability proportional to Weight.
+type LatencyBucket struct {
+	Min    time.Duration `yaml:"min" json:"min"`
+	Max    time.Duration `yaml:"max" json:"max"`
+	Weight float64       `yaml:"weight" json:"weight"`
+}
+
+// LatencyDistribution describes the delays injected for a key.
+type LatencyDistribution []LatencyBucket
+
+// FixedLatency returns a distribution that always delays by d.
+func FixedLatency(d time.Duration) LatencyDistribution {
+	return LatencyDistribution{{Min: d, Max: d, Weight: 1}}
+}
+
+// InjectLatency never delays.
+func (in *Injector) InjectLatency(ctx context.Context, key string) (time.Duration, error) {
+	return 0, nil
+}
+
+// InjectLatency never delays.
+func InjectLatency(ctx context.Context, key string) (time.Duration, error) { return 0, nil }
+
+// InjectMemoryPressure never allocates and returns false.
+func (in *Injector) InjectMemoryPressure(key string, bytes int, hold time.Duration) bool {
+	return false
+}
+
+// InjectMemoryPressure never allocates and returns false.
+func InjectMemoryPressure(key string, bytes int, hold time.Duration) bool { return false }
+
+// InjectCPUBurn never burns CPU and returns false.
+func (in *Injector) InjectCPUBurn(key string, duration time.Duration) bool { return false }
+
+// InjectCPUBurn never burns CPU and returns false.
+func InjectCPUBurn(key string, duration time.Duration) bool { return false }
+
+// The setters below are kept for the helpers in the integration packages,
+// such as cachefi and msgfi, and do nothing.
+
+// SetFailures does nothing.
+func (in *Injector) SetFailures(key string, count int) {}
+
+// SetFailures does nothing.
+func SetFailures(key string, count int) {}
+
+// SetNthFailure does nothing.
+func (in *Injector) SetNthFailure(key string, nth int) {}
+
+// SetNthFailure does nothing.
+func SetNthFailure(key string, nth int) {}
+
+// SetFailureRate does nothing.
+func (in *Injector) SetFailureRate(key string, rate float64) {}
+
+// SetFailureRate does nothing.
+func SetFailureRate(key string, rate float64) {}
+
+// SetLatency does nothing.
+func (in *Injector) SetLatency(key string, dist LatencyDistribution) {}
+
+// SetLatency does nothing.
+func SetLatency(key string, dist LatencyDistribution) {}
+
+// SetTTL does nothing.
+func (in *Injector) SetTTL(key string, ttl time.Duration) {}
+
+// SetTTL does nothing.
+func SetTTL(key string, ttl time.Duration) {}
+
+// Reset does nothing.
+func (in *Injector) Reset() {}
+
+// Reset does nothing.
+func Reset() {}
+
+// HasRule always returns false.
+func (in *Injector) HasRule(key string) bool { return false }
+
+// HasRule always returns false.
+func HasRule(key string) bool { return false }
+
+// Status always returns an empty map.
+func (in *Injector) Status() map[string]int { return map[string]int{} }
+
+// Status always returns an empty map.
+func Status() map[string]int { return map[string]int{} }
+
+// Fault describes an injected fault, as passed to OnFault hooks.
+type Fault struct {
+	Key string
+	// Type is the failure mode of the rule that fired, such as "first-n",
+	// or "override" for context overrides, or "latency" for InjectLatency.
+	Type    string
+	Count   int           // call count the fault fired at, 0 if not counted
+	Latency time.Duration // delay added, for latency faults
+}
+
+// OnFault does nothing, since no fault is ever injected.
+func (in *Injector) OnFault(fn func(ctx context.Context, f Fault)) (remove func()) {
+	return func() {}
+}
+
+// OnFault does nothing, since no fault is ever injected.
+func OnFault(fn func(ctx context.Context, f Fault)) (remove func()) { return func() {} }
+
+// SetTraceIDFunc does nothing, since no event is ever logged.
+func (in *Injector) SetTraceIDFunc(fn func(ctx context.Context) string) {}
+
+// SetTraceIDFunc does nothing, since no event is ever logged.
+func SetTraceIDFunc(fn func(ctx context.Context) string) {}
+
+// Attributes are per-call values that selectors match against.
+type Attributes map[string]string
+
+// Selector decides whether a call to key is targeted by its rule.
+type Selector interface {
+	Select(ctx context.Context, key string) bool
+}
+
+// SelectorFunc adapts a function to the Selector interface.
+type SelectorFunc func(ctx context.Context, key string) bool
+
+// Select implements Selector.
+func (f SelectorFunc) Select(ctx context.Context, key string) bool {
+	return f(ctx, key)
+}
+
+// RegisterSelector does nothing.
+func (in *Injector) RegisterSelector(name string, s Selector) {}
+
+// RegisterSelector does nothing.
+func RegisterSelector(name string, s Selector) {}
+
+// WithAttributes returns ctx.
+func WithAttributes(ctx context.Context, attrs Attributes) context.Context { return ctx }
+
+// AttributesFromContext always returns nil.
+func AttributesFromContext(ctx context.Context) Attributes { return nil }
+
+// InjectOption passes per-call context to Inject and InjectWithContext.
+type InjectOption func(*injectOptions)
+
+type injectOptions struct{}
+
+// WithAttempt returns an option that does nothing.
+func WithAttempt(n int) InjectOption { return func(*injectOptions) {} }
+
+// WithAttrs returns an option that does nothing.
+func WithAttrs(attrs Attributes) InjectOption { return func(*injectOptions) {} }
+
+// WithoutCount returns an option that does nothing.
+func WithoutCount() InjectOption { return func(*injectOptions) {} }
+
+// WithTenant returns ctx.
+func WithTenant(ctx context.Context, tenant string) context.Context { return ctx }
+
+// TenantFromContext always returns "".
+func TenantFromContext(ctx context.Context) string { return "" }
+
+// TenantHeader returns the default tenant header, X-Tenant-ID.
+func (in *Injector) TenantHeader() string { return "X-Tenant-ID" }
+
+// TenantHeader returns the default tenant header, X-Tenant-ID.
+func TenantHeader() string { return "X-Tenant-ID" }
+
+// SyntheticHeader marks requests as synthetic traffic.
+const SyntheticHeader = "X-Go-FI-Synthetic"
+
+// MarkSynthetic returns ctx.
+func MarkSynthetic(ctx context.Context) context.Context { return ctx }
+
+// IsSynthetic always returns false.
+func IsSynthetic(ctx context.Context) bool { return false }
+
+// MarkSyntheticRequest does nothing.
+func MarkSyntheticRequest(r *http.Request) {}
+
+// MiddlewareOption configures HTTPMiddleware and HTTPMiddlewareWithResponse.
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct{}
+
+// WithDecisionHeader returns an option that does nothing.
+func WithDecisionHeader() MiddlewareOption { return func(*middlewareConfig) {} }
+
+// WithDirectiveHeader returns an option that does nothing.
+func WithDirectiveHeader(secret []byte) MiddlewareOption { return func(*middlewareConfig) {} }
+
+// WithInjector returns an option that does nothing.
+func WithInjector(in *Injector) MiddlewareOption { return func(*middlewareConfig) {} }
+
+// WithFaultHeader returns an option that does nothing.
+func WithFaultHeader(name string, secret []byte) MiddlewareOption {
+	return func(*middlewareConfig) {}
+}
+
+// HTTPMiddleware returns next unchanged.
+func (in *Injector) HTTPMiddleware(key string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddleware returns next unchanged.
+func HTTPMiddleware(key string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareWithStatus returns next unchanged.
+func (in *Injector) HTTPMiddlewareWithStatus(key string, status int, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareWithStatus returns next unchanged.
+func HTTPMiddlewareWithStatus(key string, status int, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareFromSpec returns next unchanged.
+func (in *Injector) HTTPMiddlewareFromSpec(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareFromSpec returns next unchanged.
+func HTTPMiddlewareFromSpec(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareFunc returns next unchanged.
+func (in *Injector) HTTPMiddlewareFunc(keyFunc func(*http.Request) string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareFunc returns next unchanged.
+func HTTPMiddlewareFunc(keyFunc func(*http.Request) string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareWithResponse returns next unchanged.
+func (in *Injector) HTTPMiddlewareWithResponse(key string, responseFn func(http.ResponseWriter, *http.Request), opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// HTTPMiddlewareWithResponse returns next unchanged.
+func HTTPMiddlewareWithResponse(key string, responseFn func(http.ResponseWriter, *http.Request), opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// CorruptResponse returns next unchanged.
+func (in *Injector) CorruptResponse(key string) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// CorruptResponse returns next unchanged.
+func CorruptResponse(key string) func(http.Handler) http.Handler { return passThrough }
+
+// SSEMiddleware returns next unchanged.
+func (in *Injector) SSEMiddleware(key string) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// SSEMiddleware returns next unchanged.
+func SSEMiddleware(key string) func(http.Handler) http.Handler { return passThrough }
+
+// WrapConn returns c unchanged.
+func (in *Injector) WrapConn(key string, c net.Conn) net.Conn { return c }
+
+// WrapConn returns c unchanged.
+func WrapConn(key string, c net.Conn) net.Conn { return c }
+
+// WrapListener returns l unchanged.
+func (in *Injector) WrapListener(key string, l net.Listener) net.Listener { return l }
+
+// WrapListener returns l unchanged.
+func WrapListener(key string, l net.Listener) net.Listener { return l }
+
+// WrapDialer returns dial unchanged.
+func (in *Injector) WrapDialer(key string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return dial
+}
+
+// WrapDialer returns dial unchanged.
+func WrapDialer(key string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return dial
+}
+
+// IdempotencyMiddleware returns next unchanged.
+func (in *Injector) IdempotencyMiddleware(key string) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// IdempotencyMiddleware returns next unchanged.
+func IdempotencyMiddleware(key string) func(http.Handler) http.Handler { return passThrough }
+
+// DrainMiddleware returns next unchanged.
+func (in *Injector) DrainMiddleware(key string) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// DrainMiddleware returns next unchanged.
+func DrainMiddleware(key string) func(http.Handler) http.Handler { return passThrough }
+
+// HealthHandler returns next.
+func (in *Injector) HealthHandler(key string, next http.Handler) http.Handler { return next }
+
+// HealthHandler returns next.
+func HealthHandler(key string, next http.Handler) http.Handler { return next }
+
+// ReadinessHandler returns next.
+func (in *Injector) ReadinessHandler(next http.Handler) http.Handler { return next }
+
+// ReadinessHandler returns next.
+func ReadinessHandler(next http.Handler) http.Handler { return next }
+
+func passThrough(next http.Handler) http.Handler { return next }
+
+// QuotaTransport returns next, or http.DefaultTransport if next is nil.
+func (in *Injector) QuotaTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return transportOrDefault(next)
+}
+
+// QuotaTransport returns next, or http.DefaultTransport if next is nil.
+func QuotaTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return transportOrDefault(next)
+}
+
+// PayloadTransport returns next, or http.DefaultTransport if next is nil.
+func (in *Injector) PayloadTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return transportOrDefault(next)
+}
+
+// PayloadTransport returns next, or http.DefaultTransport if next is nil.
+func PayloadTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return transportOrDefault(next)
+}
+
+// IdempotencyTransport returns next, or http.DefaultTransport if next is nil.
+func (in *Injector) IdempotencyTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return transportOrDefault(next)
+}
+
+// IdempotencyTransport returns next, or http.DefaultTransport if next is nil.
+func IdempotencyTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return transportOrDefault(next)
+}
+
+func transportOrDefault(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		return http.DefaultTransport
+	}
+	return next
+}
+
+// CorruptBytes returns b.
+func (in *Injector) CorruptBytes(ctx context.Context, key string, b []byte) []byte { return b }
+
+// CorruptBytes returns b.
+func CorruptBytes(ctx context.Context, key string, b []byte) []byte { return b }
+
+// CorruptString returns s.
+func (in *Injector) CorruptString(ctx context.Context, key string, s string) string { return s }
+
+// CorruptString returns s.
+func CorruptString(ctx context.Context, key string, s string) string { return s }
+
+// Clock tells the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// SleepClock is a Clock that can also wait.
+type SleepClock interface {
+	Clock
+	// Sleep waits until d has passed on the clock, or until ctx is done,
+	// in which case it returns ctx's error.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// Now returns time.Now().
+func (in *Injector) Now(key string) time.Time { return time.Now() }
+
+// Now returns time.Now().
+func Now(key string) time.Time { return time.Now() }
+
+// NowWithContext returns time.Now().
+func (in *Injector) NowWithContext(ctx context.Context, key string) time.Time { return time.Now() }
+
+// NowWithContext returns time.Now().
+func NowWithContext(ctx context.Context, key string) time.Time { return time.Now() }
+
+// SkewedClock returns the system clock.
+func (in *Injector) SkewedClock(key string) Clock { return systemClock{} }
+
+// SkewedClock returns the system clock.
+func SkewedClock(key string) Clock { return systemClock{} }
+
+// Cleanup returns fn.
+func (in *Injector) Cleanup(key string, fn func(context.Context) error) func(context.Context) error {
+	return fn
+}
+
+// Cleanup returns fn.
+func Cleanup(key string, fn func(context.Context) error) func(context.Context) error { return fn }
+
+// Draining always returns false.
+func (in *Injector) Draining() bool { return false }
+
+// Draining always returns false.
+func Draining() bool { return false }
+
+// Ready always returns true.
+func (in *Injector) Ready() bool { return true }
+
+// Ready always returns true.
+func Ready() bool { return true }
+
+// Decorator is a generic function decorator that injects failures
+type Decorator[T any] func(T) error
+
+// WithFaultInjection returns fn as a Decorator.
+func WithFaultInjection[T any](key string, fn func(T) error) Decorator[T] { return fn }
+
+// WithFaultInjectionContext returns fn with a context parameter it ignores.
+func WithFaultInjectionContext[T any](key string, fn func(T) error) func(context.Context, T) error {
+	return func(ctx context.Context, input T) error { return fn(input) }
+}
+
+// PageFunc fetches one page of a paginated listing.
+type PageFunc[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+// Paginate returns fetch.
+func Paginate[T any](key string, fetch PageFunc[T]) PageFunc[T] { return fetch }
+
+// Number is any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// InjectValue returns v.
+func InjectValue[T Number](ctx context.Context, key string, v T) T { return v }
+
+// Degrade returns v.
+func Degrade[T any](ctx context.Context, key string, v T, degraded func() T) T { return v }
+
+// Scope is a view of an Injector whose keys share a prefix.
+type Scope struct{ prefix string }
+
+// Scoped returns a Scope that never injects faults.
+func (in *Injector) Scoped(prefix string) *Scope { return &Scope{prefix: prefix} }
+
+// Scoped returns a Scope that never injects faults.
+func Scoped(prefix string) *Scope { return &Scope{prefix: prefix} }
+
+// Scoped returns a nested Scope that never injects faults.
+func (sc *Scope) Scoped(prefix string) *Scope { return &Scope{prefix: sc.prefix + prefix} }
+
+// Prefix returns the prefix of sc's keys.
+func (sc *Scope) Prefix() string { return sc.prefix }
+
+// Key returns the full name of sc's key.
+func (sc *Scope) Key(key string) string { return sc.prefix + key }
+
+// Inject always returns false.
+func (sc *Scope) Inject(key string, opts ...InjectOption) bool { return false }
+
+// InjectWithContext always returns false.
+func (sc *Scope) InjectWithContext(ctx context.Context, key string, opts ...InjectOption) bool {
+	return false
+}
+
+// InjectWithError always returns nil.
+func (sc *Scope) InjectWithError(key string, message string) error { return nil }
+
+// InjectWithContextError always returns nil.
+func (sc *Scope) InjectWithContextError(ctx context.Context, key string, message string) error {
+	return nil
+}
+
+// InjectLatency never delays.
+func (sc *Scope) InjectLatency(ctx context.Context, key string) (time.Duration, error) {
+	return 0, nil
+}
+
+// HTTPMiddleware returns next unchanged.
+func (sc *Scope) HTTPMiddleware(key string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return passThrough
+}
+
+// Status always returns an empty map.
+func (sc *Scope) Status() map[string]int { return map[string]int{} }
+
+// Reset does nothing.
+func (sc *Scope) Reset() {}
@@ -0,0 +1,248 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+)
+
+// noopOmitted lists, by file, the exported declarations deliberately left
+// out of noop.go: configuration beyond the basic setters, specs, the control
+// server, metrics, history and tooling, which belong in test builds.
+// Anything else exported must have a no-op, so a new injection point cannot
+// be forgotten there.
+var noopOmitted = map[string][]string{
+	"actions.go":       {"*Injector.SetActions", "Action", "SetActions"},
+	"analytics.go":     {"*Injector.KeyAnalytics", "KeyAnalytics", "KeyReport", "RuleUsage"},
+	"budget.go":        {"*Injector.SetBudget", "*Injector.SetGlobalBudget", "Budget", "SetBudget", "SetGlobalBudget"},
+	"build.go":         {"*Injector.CurrentBuild", "*Injector.SetBuild", "*Injector.SetBuildFilter", "Build", "CurrentBuild", "SetBuild", "SetBuildFilter"},
+	"caller.go":        {"*Injector.InjectWhen", "*Injector.SetOnlyFrom", "CallInfo", "CallInfo.CalledFrom", "InjectWhen", "SetOnlyFrom"},
+	"callsite.go":      {"*Injector.CallSites", "*Injector.SetCallSiteLines", "CallSite", "CallSites", "SetCallSiteLines"},
+	"clock.go":         {"*Injector.SetClockSkew", "ClockSkew", "DriftingSkew", "FixedSkew", "FrozenClock", "SetClockSkew"},
+	"conn.go":          {"*Injector.SetBandwidth", "*Injector.SetConnFault", "ConnClose", "ConnFault", "ConnReset", "ConnThrottle", "ConnTimeout", "SetBandwidth", "SetConnFault"},
+	"corrupt.go":       {"*Injector.SetCorruption", "CorruptBOM", "CorruptInvalidUTF8", "CorruptMixedEncoding", "CorruptMojibake", "Corruption", "SetCorruption"},
+	"counter.go":       {"*Injector.Counter", "*Injector.ResetCounter", "*Injector.SetCounter", "Counter", "ResetCounter", "SetCounter"},
+	"degrade.go":       {"*Injector.SetDegradation", "Degradation", "Degradation.ServeHTTP", "SetDegradation"},
+	"events.go":        {"*Injector.Events", "*Injector.QueryEvents", "*Injector.SetEventLogSize", "Event", "EventQuery", "Events", "QueryEvents", "SetEventLogSize"},
+	"experiment.go":    {"*ExperimentLockedError.Error", "*ExperimentLockedError.Unwrap", "*Injector.EndExperiment", "*Injector.Experiments", "*Injector.StartExperiment", "*Injector.WaitExperiment", "EndExperiment", "ErrExperimentLocked", "Experiment", "ExperimentLockedError", "Experiments", "StartExperiment", "WaitExperiment"},
+	"flap.go":          {"*Injector.SetFlapping", "FlapCycle", "SetFlapping"},
+	"gc.go":            {"*Injector.Prune", "*Injector.StartGC", "GCOptions", "Prune", "StartGC"},
+	"header.go":        {"FaultHeader", "SignFaultHeader"},
+	"history.go":       {"*Injector.History", "*Injector.SetHistorySize", "Decision", "History", "Reason", "ReasonBudget", "ReasonBuild", "ReasonCaller", "ReasonCancelled", "ReasonDisabled", "ReasonExhausted", "ReasonExpired", "ReasonFired", "ReasonHealthy", "ReasonInstance", "ReasonMatch", "ReasonNotNthCall", "ReasonNotSampled", "ReasonOverride", "ReasonPassingAction", "ReasonProduction", "ReasonSelector", "ReasonSynthetic", "ReasonTenant", "SetHistorySize"},
+	"hook.go":          {"*Injector.OnInject", "OnInject"},
+	"idempotency.go":   {"*Injector.SetIdempotencyFault", "IdempotencyDuplicate", "IdempotencyFault", "IdempotencyLostResponse", "SetIdempotencyFault"},
+	"injector.go":      {"*Injector.Production", "*Injector.SetAllowedEnvironments", "*Injector.SetProductionEnvironments", "Production", "SetAllowedEnvironments", "SetProductionEnvironments"},
+	"instance.go":      {"*Injector.InstanceID", "*Injector.SetInstanceID", "*Injector.SetInstances", "InstanceID", "SetInstanceID", "SetInstances"},
+	"invariants.go":    {"*Injector.ValidateInvariants", "ValidateInvariants"},
+	"keys.go":          {"*Injector.Keys", "KeyInfo", "Keys"},
+	"kubernetes.go":    {"*Injector.WatchConfigMap", "WatchConfigMap"},
+	"labels.go":        {"*Injector.KeysByLabel", "*Injector.ResetByLabel", "*Injector.SetLabels", "KeysByLabel", "ResetByLabel", "SetLabels"},
+	"latency.go":       {"HistogramBucket", "LatencyDistribution.Sample", "LatencyFromHistogram"},
+	"logger.go":        {"*Injector.SetLogger", "Rule.LogValue", "SetLogger"},
+	"manualclock.go":   {"*ManualClock.Advance", "*ManualClock.Now", "*ManualClock.Set", "*ManualClock.Sleep", "*ManualClock.Sleepers", "*ManualClock.WaitForSleepers", "ManualClock", "NewManualClock"},
+	"match.go":         {"*Injector.SetMatch", "SetMatch"},
+	"matrix.go":        {"MatrixRule"},
+	"metrics.go":       {"*Injector.Metrics", "*Injector.MetricsHandler", "KeyMetrics", "LatencyHistogram", "Metrics", "MetricsHandler"},
+	"new.go":           {"WithSpecFile"},
+	"nth.go":           {"*CallRange.UnmarshalYAML", "*Injector.SetEveryNthFailure", "*Injector.SetNthFailureRange", "*Injector.SetNthFailures", "CallRange", "SetEveryNthFailure", "SetNthFailureRange", "SetNthFailures"},
+	"overhead.go":      {"*Injector.MeasureOverhead", "MeasureOverhead", "OverheadOptions", "OverheadReport", "OverheadReport.String", "OverheadResult"},
+	"paginate.go":      {"*Injector.SetPageFault", "PageEndless", "PageFault", "PageRepeatCursor", "PageSkip", "SetPageFault"},
+	"pattern.go":       {"MatchKey"},
+	"payload.go":       {"SizeSelector"},
+	"profilelabels.go": {"*Injector.SetProfileLabels", "ProfileLabelKey", "ProfileLabelType", "SetProfileLabels"},
+	"quota.go":         {"*Injector.SetQuotaResponse", "*QuotaResponse.UnmarshalYAML", "OpenAIInsufficientQuota", "PaymentRequired", "QuotaExceeded", "QuotaResponse", "QuotaResponse.ServeHTTP", "SetQuotaResponse", "StripeRateLimit"},
+	"remote.go":        {"*Injector.SyncFromRemote", "SyncFromRemote", "SyncOptions"},
+	"response.go":      {"*Injector.SetResponseFault", "ResponseDropHeaders", "ResponseFault", "ResponseGarbage", "ResponseSlowDrip", "ResponseTruncate", "SetResponseFault"},
+	"retry.go":         {"*Injector.RecordRetries", "*RetryRecorder.Attempts", "*RetryRecorder.Call", "*RetryRecorder.Verify", "RecordRetries", "RetryAttempt", "RetryPolicy", "RetryRecorder"},
+	"routes.go":        {"*Injector.SetRoute", "SetRoute"},
+	"rule.go":          {"*Injector.Rules", "*Injector.SetRule", "*Rule.UnmarshalYAML", "Mode", "ModeActions", "ModeFirstN", "ModeFlapping", "ModeNone", "ModeNth", "ModeRate", "Rule", "Rules", "SetRule"},
+	"runtimetrace.go":  {"*Injector.SetRuntimeTrace", "RuntimeTraceCategory", "SetRuntimeTrace"},
+	"scope.go":         {"*Scope.FullStatus", "*Scope.Rules", "*Scope.SetFailureRate", "*Scope.SetFailures", "*Scope.SetLatency", "*Scope.SetNthFailure", "*Scope.SetRule"},
+	"selector.go":      {"*Injector.SetSelectors", "AttributeSelector", "HeaderSelector", "PercentSelector", "SetSelectors", "TenantSelector", "TimeOfDaySelector"},
+	"server.go":        {"*Injector.ControlHandler", "*Injector.StartControlServer", "ControlHandler", "StartControlServer"},
+	"shared.go":        {"*Injector.ServeShared", "*Injector.UseShared", "ServeShared", "UseShared"},
+	"shutdown.go":      {"*Injector.SignalContext", "*Injector.StartDrain", "SignalContext", "StartDrain"},
+	"spec.go":          {"*Injector.LoadSpec", "*Injector.LoadSpecFS", "*Injector.LoadSpecFromBytes", "*Injector.LoadSpecFromReader", "FormatJSON", "FormatTOML", "FormatYAML", "LoadSpec", "LoadSpecFS", "LoadSpecFromBytes", "LoadSpecFromReader", "Spec"},
+	"specerror.go":     {"*SpecError.Error", "*SpecError.Unwrap", "SpecError"},
+	"sse.go":           {"*Injector.SetSSEFault", "SSEDelay", "SSEDrop", "SSEFault", "SSEMalformed", "SetSSEFault"},
+	"startup.go":       {"*Injector.CrashAfter", "*Injector.DelayReadiness", "*Injector.LoadEnv", "CrashAfter", "DelayReadiness", "EnvCrashAfter", "EnvCrashCode", "EnvFailures", "EnvLatency", "EnvLog", "EnvNthFailures", "EnvProfileLabels", "EnvReadyDelay", "EnvRuntimeTrace", "EnvShared", "EnvSpec", "EnvStrict", "LoadEnv"},
+	"state.go":         {"*Injector.Restore", "*Injector.RestoreIfMatch", "*Injector.Snapshot", "Restore", "RestoreIfMatch", "Snapshot", "State"},
+	"status.go":        {"*Injector.FullStatus", "*Injector.StatusDetailed", "DetailedStatus", "FullStatus", "KeyStatus", "StatusDetailed"},
+	"stream.go":        {"*Injector.WatchDecisions", "WatchDecisions"},
+	"strict.go":        {"*Injector.RegisterKeys", "*Injector.SetStrictMode", "RegisterKeys", "SetStrictMode", "StrictMode", "StrictOff", "StrictPanic", "StrictWarn"},
+	"synthetic.go":     {"*Injector.SetExcludeSynthetic", "*Injector.SetExcludeSyntheticByDefault", "SetExcludeSynthetic", "SetExcludeSyntheticByDefault"},
+	"tenant.go":        {"*Injector.ResetTenant", "*Injector.SetTenantHeader", "*Injector.SetTenants", "*Injector.TenantStatus", "*Injector.Tenants", "ResetTenant", "SetTenantHeader", "SetTenants", "TenantStatus", "Tenants"},
+	"trace.go":         {"DecisionHeader", "FormatDecisions", "ParseDecisionHeader", "TracedDecisions", "WithDecisionTrace"},
+	"ttl.go":           {"*Injector.SetFailuresFor", "SetFailuresFor"},
+	"value.go":         {"*Injector.SetNumericEdge", "EdgeHuge", "EdgeInf", "EdgeMax", "EdgeMin", "EdgeNaN", "EdgeNegative", "EdgeZero", "NumericEdge", "SetNumericEdge"},
+	"version.go":       {"*Injector.Generation", "*Injector.ResetIfMatch", "*Injector.SetFailuresIfMatch", "*Injector.StatusWithGeneration", "*Injector.WaitForChange", "ErrConflict", "Generation", "ResetIfMatch", "SetFailuresIfMatch", "StatusWithGeneration", "WaitForChange"},
+	"watch.go":         {"*Injector.WatchSpec", "WatchOptions", "WatchSpec"},
+}
+
+// TestNoopAPI checks that every exported declaration of the no-op build
+// exists in this build with the same signature, so code written
+// against one compiles against the other, and that every exported
+// declaration of this build has a no-op or is listed in noopOmitted.
+func TestNoopAPI(t *testing.T) {
+	fset := token.NewFileSet()
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	real := make(map[string]string)
+	files := make(map[string]string) // declaration -> file, for files only in this build
+	var noop map[string]string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decls := exportedDecls(fset, f)
+		if name == "noop.go" {
+			noop = decls
+			continue
+		}
+		for k, v := range decls {
+			real[k] = v
+			if tagged(f) {
+				files[k] = name
+			}
+		}
+	}
+	if len(noop) == 0 {
+		t.Fatal("Expected noop.go to declare an API")
+	}
+	for name, sig := range noop {
+		got, ok := real[name]
+		if !ok {
+			t.Errorf("Expected %s to exist in the faultinject build", name)
+			continue
+		}
+		if got != sig {
+			t.Errorf("Expected %s to be %s, got %s", name, got, sig)
+		}
+	}
+
+	omitted := make(map[string]string)
+	for file, names := range noopOmitted {
+		for _, name := range names {
+			omitted[name] = file
+		}
+	}
+	for name, file := range files {
+		_, ok := noop[name]
+		switch {
+		case ok && omitted[name] != "":
+			t.Errorf("Expected %s to be left out of noopOmitted, as noop.go declares it", name)
+		case !ok && omitted[name] != file:
+			t.Errorf("Expected %s from %s to have a no-op in noop.go or be listed in noopOmitted", name, file)
+		}
+	}
+	for name, file := range omitted {
+		if files[name] != file {
+			t.Errorf("Expected %s, listed in noopOmitted, to be declared in %s", name, file)
+		}
+	}
+}
+
+// tagged reports whether f is built only with the faultinject tag, as
+// opposed to in both builds.
+func tagged(f *ast.File) bool {
+	for _, c := range f.Comments {
+		if c.Pos() > f.Package {
+			break
+		}
+		for _, line := range c.List {
+			if strings.HasPrefix(line.Text, "//go:build") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeString prints t, keeping only the exported fields of a struct.
+func typeString(t ast.Expr, print func(ast.Node) string) string {
+	st, ok := t.(*ast.StructType)
+	if !ok {
+		return print(t)
+	}
+	var exported []string
+	for _, field := range st.Fields.List {
+		for _, n := range field.Names {
+			if n.IsExported() {
+				exported = append(exported, n.Name+" "+print(field.Type))
+			}
+		}
+	}
+	return "struct{" + strings.Join(exported, "; ") + "}"
+}
+
+// exportedDecls returns the exported functions, methods, types, constants and
+// variables of f, keyed by name, with their types printed without parameter
+// names.
+func exportedDecls(fset *token.FileSet, f *ast.File) map[string]string {
+	decls := make(map[string]string)
+	print := func(n ast.Node) string {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, fset, n)
+		return buf.String()
+	}
+	fields := func(l *ast.FieldList) string {
+		if l == nil {
+			return ""
+		}
+		var types []string
+		for _, field := range l.List {
+			n := max(len(field.Names), 1)
+			for i := 0; i < n; i++ {
+				types = append(types, print(field.Type))
+			}
+		}
+		return "(" + strings.Join(types, ", ") + ")"
+	}
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			name := d.Name.Name
+			if d.Recv != nil {
+				recv := print(d.Recv.List[0].Type)
+				if !ast.IsExported(strings.TrimPrefix(recv, "*")) {
+					continue
+				}
+				name = recv + "." + name
+			}
+			decls[name] = fields(d.Type.TypeParams) + fields(d.Type.Params) + fields(d.Type.Results)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if spec.Name.IsExported() {
+						decls[spec.Name.Name] = fields(spec.TypeParams) + typeString(spec.Type, print)
+					}
+				case *ast.ValueSpec:
+					for _, n := range spec.Names {
+						if n.IsExported() {
+							decls[n.Name] = d.Tok.String()
+						}
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
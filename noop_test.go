@@ -0,0 +1,85 @@
+//go:build !faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNoopNeverInjects(t *testing.T) {
+	SetFailures("db", 5)
+	SetFailureRate("cache", 1)
+	ctx := WithFaultOverride(context.Background(), "db", true)
+
+	tests := []struct {
+		name string
+		got  bool
+	}{
+		{name: "Inject", got: Inject("db")},
+		{name: "InjectWithContext", got: InjectWithContext(ctx, "db")},
+		{name: "Scope.Inject", got: Scoped("lib.").Inject("db")},
+		{name: "Inject with options", got: Inject("db", WithAttempt(2), WithAttrs(Attributes{"region": "eu"}), WithoutCount())},
+		{name: "Injector.Inject", got: NewInjector().Inject("cache")},
+		{name: "InjectWithError", got: InjectWithError("db", "boom") != nil},
+		{name: "InjectWithContextError", got: InjectWithContextError(ctx, "db", "boom") != nil},
+		{name: "InjectWithFn", got: InjectWithFn("db", func() error { return errors.New("boom") }) != nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got {
+				t.Errorf("Expected %s not to inject", tt.name)
+			}
+		})
+	}
+	if n := len(Status()); n != 0 {
+		t.Errorf("Expected an empty status, got %d keys", n)
+	}
+}
+
+func TestNoopPassesThrough(t *testing.T) {
+	sentinel := errors.New("real")
+	if err := WithFaultInjection("db", func(int) error { return sentinel })(1); err != sentinel {
+		t.Errorf("Expected fn's error, got %v", err)
+	}
+	if got := InjectValue(context.Background(), "n", 42); got != 42 {
+		t.Errorf("Expected 42, got %d", got)
+	}
+	if got := Degrade(context.Background(), "d", "ok", func() string { return "" }); got != "ok" {
+		t.Errorf("Expected ok, got %q", got)
+	}
+	if got := CorruptString(context.Background(), "s", "ok"); got != "ok" {
+		t.Errorf("Expected ok, got %q", got)
+	}
+
+	handler := HTTPMiddleware("http")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, rec.Code)
+	}
+	handler = HTTPMiddlewareWithStatus("http", http.StatusServiceUnavailable)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, rec.Code)
+	}
+	handler = HTTPMiddlewareFromSpec()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, rec.Code)
+	}
+	if QuotaTransport("api", nil) != http.DefaultTransport {
+		t.Error("Expected a nil transport to become http.DefaultTransport")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CallRange is calls From to To of a key, inclusive and counted from 1.
+type CallRange struct {
+	From int `yaml:"from" json:"from"`
+	To   int `yaml:"to" json:"to"`
+}
+
+// UnmarshalYAML accepts a single call, such as 7, a range of calls, such as
+// "3-5", or a mapping with from and to.
+func (c *CallRange) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		type plain CallRange
+		return value.Decode((*plain)(c))
+	}
+	from, to, isRange := strings.Cut(value.Value, "-")
+	var err error
+	if c.From, err = strconv.Atoi(strings.TrimSpace(from)); err != nil {
+		return fmt.Errorf("invalid call %q", value.Value)
+	}
+	c.To = c.From
+	if isRange {
+		if c.To, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+			return fmt.Errorf("invalid call range %q", value.Value)
+		}
+	}
+	return nil
+}
+
+// splitNth removes nth from a rule's mapping if it is a list or a range
+// rather than a single call, and returns it separately.
+func splitNth(value *yaml.Node) (*yaml.Node, *yaml.Node) {
+	if value.Kind != yaml.MappingNode {
+		return value, nil
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		v := value.Content[i+1]
+		if value.Content[i].Value != "nth" || (v.Kind == yaml.ScalarNode && v.Tag == "!!int") {
+			continue
+		}
+		rest := *value
+		rest.Content = slices.Delete(slices.Clone(value.Content), i, i+2)
+		return &rest, v
+	}
+	return value, nil
+}
+
+// decodeRanges decodes a list of calls and ranges, or a single range.
+func decodeRanges(value *yaml.Node) ([]CallRange, error) {
+	if value.Kind == yaml.SequenceNode {
+		var ranges []CallRange
+		err := value.Decode(&ranges)
+		return ranges, err
+	}
+	var c CallRange
+	if err := value.Decode(&c); err != nil {
+		return nil, err
+	}
+	return []CallRange{c}, nil
+}
+
+// nthCall reports whether call number cnt is one of the calls a ModeNth rule
+// fails.
+func (r Rule) nthCall(cnt int) bool {
+	if r.Every > 0 {
+		if r.Nth <= 0 && len(r.Ranges) == 0 {
+			return cnt%r.Every == 0
+		}
+		cnt = (cnt-1)%r.Every + 1
+	}
+	if cnt == r.Nth {
+		return true
+	}
+	for _, c := range r.Ranges {
+		if cnt >= c.From && cnt <= c.To {
+			return true
+		}
+	}
+	return false
+}
+
+// nthCalls returns the calls a ModeNth rule without Every fails, sorted
+// and merged.
+func (r Rule) nthCalls() []CallRange {
+	ranges := slices.Clone(r.Ranges)
+	if r.Nth > 0 {
+		ranges = append(ranges, CallRange{From: r.Nth, To: r.Nth})
+	}
+	slices.SortFunc(ranges, func(a, b CallRange) int { return a.From - b.From })
+	var merged []CallRange
+	for _, c := range ranges {
+		if c.To < c.From {
+			continue
+		}
+		if n := len(merged); n > 0 && c.From <= merged[n-1].To+1 {
+			merged[n-1].To = max(merged[n-1].To, c.To)
+			continue
+		}
+		merged = append(merged, c)
+	}
+	return merged
+}
+
+// lastNthCall returns the last call a ModeNth rule fails, or -1 if it keeps
+// failing calls for good.
+func (r Rule) lastNthCall() int {
+	if r.Every > 0 {
+		return -1
+	}
+	last := 0
+	for _, c := range r.nthCalls() {
+		last = max(last, c.To)
+	}
+	return last
+}
+
+// remainingNthFailures returns how many calls after calls a ModeNth rule
+// still fails, or -1 if it keeps failing calls for good.
+func (r Rule) remainingNthFailures(calls int) int {
+	if r.Every > 0 {
+		return -1
+	}
+	n := 0
+	for _, c := range r.nthCalls() {
+		n += max(c.To-max(c.From, calls+1)+1, 0)
+	}
+	return n
+}
+
+// validateNth reports calls of a ModeNth rule that can never be reached.
+func (r Rule) validateNth() error {
+	if r.Every < 0 {
+		return fmt.Errorf("negative every %d", r.Every)
+	}
+	for _, c := range r.Ranges {
+		switch {
+		case c.From < 1 || c.To < c.From:
+			return fmt.Errorf("invalid call range %d-%d", c.From, c.To)
+		case r.Every > 0 && c.To > r.Every:
+			return fmt.Errorf("call range %d-%d does not fit in every %d", c.From, c.To, r.Every)
+		}
+	}
+	if r.Every > 0 && r.Nth > r.Every {
+		return fmt.Errorf("nth %d does not fit in every %d", r.Nth, r.Every)
+	}
+	return nil
+}
+
+// setNthPattern switches key to ModeNth with the calls fn sets and restarts
+// its call count, replacing any other mode and TTL.
+func (in *Injector) setNthPattern(key string, fn func(r *Rule)) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setNthPatternLocked(key, fn)
+}
+
+func (in *Injector) setNthPatternLocked(key string, fn func(r *Rule)) {
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.setMode(ModeNth, in.timeNow())
+		fn(r)
+	})
+	in.restartCountsLocked(key)
+}
+
+// SetNthFailures makes Inject(key) return true only on the listed calls,
+// such as the 3rd, 7th and 9th. Fault injection is disabled in production
+// environments.
+func (in *Injector) SetNthFailures(key string, calls ...int) {
+	if in.isProductionEnvironment() {
+		return
+	}
+	ranges := make([]CallRange, 0, len(calls))
+	for _, c := range calls {
+		ranges = append(ranges, CallRange{From: c, To: c})
+	}
+	in.setNthPattern(key, func(r *Rule) { r.Ranges = ranges })
+}
+
+// SetNthFailures calls SetNthFailures on the default Injector.
+func SetNthFailures(key string, calls ...int) {
+	std.SetNthFailures(key, calls...)
+}
+
+// SetNthFailureRange makes Inject(key) return true only on calls from to
+// to, inclusive, such as calls 3 to 5 of a retry loop. Fault injection is disabled in production environments.
+func (in *Injector) SetNthFailureRange(key string, from, to int) {
+	if in.isProductionEnvironment() {
+		return
+	}
+	in.setNthPattern(key, func(r *Rule) { r.Ranges = []CallRange{{From: from, To: to}} })
+}
+
+// SetNthFailureRange calls SetNthFailureRange on the default Injector.
+func SetNthFailureRange(key string, from, to int) {
+	std.SetNthFailureRange(key, from, to)
+}
+
+// SetEveryNthFailure makes Inject(key) return true on every call that is a
+// multiple of every, such as every 10th call. Fault injection is disabled
+// in production environments.
+func (in *Injector) SetEveryNthFailure(key string, every int) {
+	if in.isProductionEnvironment() {
+		return
+	}
+	in.setNthPattern(key, func(r *Rule) { r.Every = every })
+}
+
+// SetEveryNthFailure calls SetEveryNthFailure on the default Injector.
+func SetEveryNthFailure(key string, every int) {
+	std.SetEveryNthFailure(key, every)
+}
@@ -0,0 +1,155 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNthPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func()
+		expected []int // calls out of the first 12 that fail
+	}{
+		{name: "single call", setup: func() { SetNthFailure("db", 3) }, expected: []int{3}},
+		{name: "list", setup: func() { SetNthFailures("db", 3, 7, 9) }, expected: []int{3, 7, 9}},
+		{name: "range", setup: func() { SetNthFailureRange("db", 3, 5) }, expected: []int{3, 4, 5}},
+		{name: "every", setup: func() { SetEveryNthFailure("db", 5) }, expected: []int{5, 10}},
+		{
+			name:     "repeating pattern",
+			setup:    func() { SetRule("db", Rule{Mode: ModeNth, Nth: 1, Ranges: []CallRange{{From: 3, To: 4}}, Every: 5}) },
+			expected: []int{1, 3, 4, 6, 8, 9, 11},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			var failed []int
+			for call := 1; call <= 12; call++ {
+				if Inject("db") {
+					failed = append(failed, call)
+				}
+			}
+			if !slices.Equal(failed, tt.expected) {
+				t.Errorf("Expected calls %v to fail, got %v", tt.expected, failed)
+			}
+		})
+	}
+}
+
+func TestNthPatternSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		expected []int
+	}{
+		{name: "list", rule: "{nth: [3, 7, 9]}", expected: []int{3, 7, 9}},
+		{name: "list with ranges", rule: `{nth: [2, "5-6"]}`, expected: []int{2, 5, 6}},
+		{name: "range", rule: `{nth: "3-5"}`, expected: []int{3, 4, 5}},
+		{name: "ranges field", rule: "{ranges: [{from: 4, to: 5}]}", expected: []int{4, 5}},
+		{name: "every", rule: "{every: 4}", expected: []int{4, 8, 12}},
+		{name: "nth every", rule: "{nth: 2, every: 4}", expected: []int{2, 6, 10}},
+		{name: "range every", rule: `{nth: "1-2", every: 6}`, expected: []int{1, 2, 7, 8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if err := LoadSpecFromBytes([]byte("rules:\n  db: "+tt.rule+"\n"), FormatYAML); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := Rules()["db"].Mode; got != ModeNth {
+				t.Errorf("Expected mode %q, got %q", ModeNth, got)
+			}
+			var failed []int
+			for call := 1; call <= 12; call++ {
+				if Inject("db") {
+					failed = append(failed, call)
+				}
+			}
+			if !slices.Equal(failed, tt.expected) {
+				t.Errorf("Expected calls %v to fail, got %v", tt.expected, failed)
+			}
+		})
+	}
+}
+
+func TestNthPatternSpecJSON(t *testing.T) {
+	resetState()
+	if err := LoadSpecFromBytes([]byte(`{"rules": {"db": {"nth": [2, "4-5"]}}}`), FormatJSON); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	r := Rules()["db"]
+	expected := []CallRange{{From: 2, To: 2}, {From: 4, To: 5}}
+	if len(r.Ranges) != len(expected) || r.Ranges[0] != expected[0] || r.Ranges[1] != expected[1] {
+		t.Errorf("Expected ranges %v, got %v", expected, r.Ranges)
+	}
+}
+
+func TestNthPatternSpecInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{name: "not a call", rule: `{nth: [abc]}`},
+		{name: "backwards range", rule: `{nth: "5-3"}`},
+		{name: "zero call", rule: `{nth: [0]}`},
+		{name: "negative every", rule: "{every: -1}"},
+		{name: "nth beyond every", rule: "{nth: 7, every: 5}"},
+		{name: "range beyond every", rule: `{nth: "4-6", every: 5}`},
+		{name: "every for another mode", rule: "{mode: first-n, count: 1, every: 5}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if err := LoadSpecFromBytes([]byte("rules:\n  db: "+tt.rule+"\n"), FormatYAML); err == nil {
+				t.Errorf("Expected an error for %s", tt.rule)
+			}
+		})
+	}
+}
+
+func TestNthPatternStatus(t *testing.T) {
+	resetState()
+	SetNthFailures("list", 2, 4, 5)
+	SetNthFailureRange("range", 2, 4)
+	SetEveryNthFailure("every", 3)
+	for _, key := range []string{"list", "range", "every"} {
+		Inject(key)
+		Inject(key)
+	}
+
+	tests := []struct {
+		key       string
+		remaining int
+	}{
+		{key: "list", remaining: 2},
+		{key: "range", remaining: 2},
+		{key: "every", remaining: -1},
+	}
+	st := FullStatus()
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := st[tt.key].Remaining; got != tt.remaining {
+				t.Errorf("Expected %d remaining, got %d", tt.remaining, got)
+			}
+		})
+	}
+
+	for range 3 {
+		Inject("list")
+		Inject("range")
+	}
+	if got := FullStatus()["list"].Remaining; got != 0 {
+		t.Errorf("Expected no failures left, got %d", got)
+	}
+	std.mu.Lock()
+	defer std.mu.Unlock()
+	for key, expected := range map[string]bool{"list": true, "range": true, "every": false} {
+		if got := std.exhaustedLocked(key); got != expected {
+			t.Errorf("Expected %s exhausted to be %v, got %v", key, expected, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"strconv"
+)
+
+// InjectOption passes per-call context to Inject and InjectWithContext.
+type InjectOption func(*injectOptions)
+
+type injectOptions struct {
+	attrs     Attributes
+	uncounted bool
+}
+
+// WithAttempt marks the call as attempt n of a retried operation, exposed
+// to selectors as the "attempt" attribute, so a rule can target first
+// attempts or retries with AttributeSelector("attempt", "1").
+func WithAttempt(n int) InjectOption {
+	return func(o *injectOptions) {
+		o.attr("attempt", strconv.Itoa(n))
+	}
+}
+
+// WithAttrs adds attrs to the attributes selectors match the call against,
+// over any carried by the context.
+func WithAttrs(attrs Attributes) InjectOption {
+	return func(o *injectOptions) {
+		for k, v := range attrs {
+			o.attr(k, v)
+		}
+	}
+}
+
+// WithoutCount decides the call as the key's next call would be, without
+// counting it, so it neither uses up a first-N rule nor moves an Nth rule
+// along. It suits checks that are not attempts of their own, such as a
+// readiness probe ahead of the real call.
+func WithoutCount() InjectOption {
+	return func(o *injectOptions) {
+		o.uncounted = true
+	}
+}
+
+func (o *injectOptions) attr(k, v string) {
+	if o.attrs == nil {
+		o.attrs = make(Attributes)
+	}
+	o.attrs[k] = v
+}
+
+type uncountedKey struct{}
+
+// withInjectOptions returns ctx carrying opts for the decision.
+func withInjectOptions(ctx context.Context, opts []InjectOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+	var o injectOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(o.attrs) > 0 {
+		ctx = WithAttributes(ctx, o.attrs)
+	}
+	if o.uncounted {
+		ctx = context.WithValue(ctx, uncountedKey{}, true)
+	}
+	return ctx
+}
+
+// uncounted reports whether the call was made with WithoutCount.
+func uncounted(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	u, _ := ctx.Value(uncountedKey{}).(bool)
+	return u
+}
@@ -0,0 +1,94 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInjectWithAttempt(t *testing.T) {
+	resetState()
+	RegisterSelector("first-attempt", AttributeSelector("attempt", "1"))
+	SetFailureRate("pay", 1)
+	SetSelectors("pay", "first-attempt")
+
+	tests := []struct {
+		attempt  int
+		expected bool
+	}{
+		{attempt: 1, expected: true},
+		{attempt: 2, expected: false},
+		{attempt: 3, expected: false},
+	}
+	for _, tt := range tests {
+		if got := Inject("pay", WithAttempt(tt.attempt)); got != tt.expected {
+			t.Errorf("Expected attempt %d to return %v, got %v", tt.attempt, tt.expected, got)
+		}
+	}
+	if Inject("pay") {
+		t.Error("Expected a call without an attempt not to be selected")
+	}
+}
+
+func TestInjectWithAttrs(t *testing.T) {
+	resetState()
+	RegisterSelector("eu", AttributeSelector("region", "eu"))
+	RegisterSelector("gold", AttributeSelector("plan", "gold"))
+	SetFailureRate("pay", 1)
+	SetSelectors("pay", "eu", "gold")
+
+	ctx := WithAttributes(context.Background(), Attributes{"plan": "gold", "region": "us"})
+	tests := []struct {
+		name     string
+		fired    bool
+		expected bool
+	}{
+		{name: "attrs", fired: Inject("pay", WithAttrs(Attributes{"region": "eu", "plan": "gold"})), expected: true},
+		{name: "partial attrs", fired: Inject("pay", WithAttrs(Attributes{"region": "eu"})), expected: false},
+		{name: "over context", fired: InjectWithContext(ctx, "pay", WithAttrs(Attributes{"region": "eu"})), expected: true},
+		{name: "context only", fired: InjectWithContext(ctx, "pay"), expected: false},
+	}
+	for _, tt := range tests {
+		if tt.fired != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.fired)
+		}
+	}
+	if AttributesFromContext(ctx)["region"] != "us" {
+		t.Error("Expected the caller's context to be left alone")
+	}
+}
+
+func TestInjectWithoutCount(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	SetNthFailure("cache", 2)
+
+	for i := 0; i < 3; i++ {
+		if !Inject("db", WithoutCount()) {
+			t.Errorf("Expected uncounted check %d to fire", i+1)
+		}
+	}
+	if got := FullStatus()["db"].Calls; got != 0 {
+		t.Errorf("Expected 0 calls counted, got %d", got)
+	}
+	if !Inject("db") {
+		t.Error("Expected the first counted call to fire")
+	}
+	if Inject("db") || Inject("db", WithoutCount()) {
+		t.Error("Expected calls after the first not to fire")
+	}
+
+	if Inject("cache") {
+		t.Error("Expected call 1 not to fire")
+	}
+	if !InjectWithContext(context.Background(), "cache", WithoutCount()) {
+		t.Error("Expected an uncounted check before call 2 to fire")
+	}
+	if !Inject("cache") {
+		t.Error("Expected call 2 to fire")
+	}
+	if got := FullStatus()["cache"].Calls; got != 2 {
+		t.Errorf("Expected 2 calls counted, got %d", got)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package otelfi records injected faults on OpenTelemetry spans, so they show
+// up in distributed traces during chaos experiments.
+//
+// Once installed, every fault injected with a context that carries a
+// recording span, including those of the HTTP middleware and gRPC
+// interceptors, adds a "fault.injected" event with fault.key, fault.type and
+// fault.count attributes (plus fault.latency_ms for latency) to that span and
+// sets its fault.injected attribute. Faults injected through Inject, without
+// a context, are not recorded.
+//
+// SampledSelector and TraceIDSuffixSelector go the other way and limit faults
+// to traces that will be kept, so every injected fault can be analyzed in the
+// tracing backend afterwards.
+package otelfi
+
+import (
+	"context"
+	"strings"
+
+	faultinject "github.com/talinashro/go-fi"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys set on spans.
+const (
+	KeyAttribute      = attribute.Key("fault.key")
+	TypeAttribute     = attribute.Key("fault.type")
+	CountAttribute    = attribute.Key("fault.count")
+	LatencyAttribute  = attribute.Key("fault.latency_ms")
+	InjectedAttribute = attribute.Key("fault.injected")
+	EventName         = "fault.injected"
+)
+
+// Install starts recording injected faults on spans, and trace IDs in the
+// event log, and returns a function that stops it.
+func Install() (uninstall func()) {
+	remove := faultinject.OnFault(Record)
+	faultinject.SetTraceIDFunc(TraceID)
+	return func() {
+		remove()
+		faultinject.SetTraceIDFunc(nil)
+	}
+}
+
+// TraceID returns the trace ID of the span context in ctx, in lowercase hex,
+// or "" if there is none. Install records it in faultinject.Event.TraceID.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Record adds the event for f to the span in ctx, if it is recording. Install
+// calls it for every injected fault.
+func Record(ctx context.Context, f faultinject.Fault) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		KeyAttribute.String(f.Key),
+		TypeAttribute.String(f.Type),
+		CountAttribute.Int(f.Count),
+	}
+	if f.Latency > 0 {
+		attrs = append(attrs, LatencyAttribute.Float64(float64(f.Latency.Microseconds())/1000))
+	}
+	span.AddEvent(EventName, trace.WithAttributes(attrs...))
+	span.SetAttributes(InjectedAttribute.Bool(true))
+}
+
+// SampledSelector returns a selector matching calls whose context carries a
+// sampled span context, local or propagated from the caller. Register it and
+// name it in a key's selectors to fire faults only on sampled traces:
+//
+//	faultinject.RegisterSelector("sampled", otelfi.SampledSelector())
+//	faultinject.SetSelectors("payments-call", "sampled")
+//
+// For HTTP and gRPC servers, the OpenTelemetry instrumentation must run
+// before the fault injection middleware so the span is in the context.
+func SampledSelector() faultinject.Selector {
+	return faultinject.SelectorFunc(func(ctx context.Context, _ string) bool {
+		return trace.SpanContextFromContext(ctx).IsSampled()
+	})
+}
+
+// TraceIDSuffixSelector returns a selector matching calls whose trace ID, in
+// lowercase hex, ends with one of suffixes. Trace IDs are random, so a
+// one-digit suffix selects 1/16 of traces and a two-digit one 1/256, and
+// every service in a trace makes the same choice without coordinating.
+func TraceIDSuffixSelector(suffixes ...string) faultinject.Selector {
+	lower := make([]string, len(suffixes))
+	for i, s := range suffixes {
+		lower[i] = strings.ToLower(s)
+	}
+	return faultinject.SelectorFunc(func(ctx context.Context, _ string) bool {
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.HasTraceID() {
+			return false
+		}
+		id := sc.TraceID().String()
+		for _, s := range lower {
+			if strings.HasSuffix(id, s) {
+				return true
+			}
+		}
+		return false
+	})
+}
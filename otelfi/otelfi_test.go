@@ -0,0 +1,183 @@
+//go:build faultinject
+
+package otelfi
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func resetState() {
+	faultinject.Reset()
+	os.Setenv("ENVIRONMENT", "development")
+}
+
+func TestInstall(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func()
+		call     func(ctx context.Context)
+		expected map[attribute.Key]attribute.Value
+	}{
+		{
+			name:  "failure",
+			setup: func() { faultinject.SetFailures("db", 1) },
+			call:  func(ctx context.Context) { faultinject.InjectWithContext(ctx, "db") },
+			expected: map[attribute.Key]attribute.Value{
+				KeyAttribute:   attribute.StringValue("db"),
+				TypeAttribute:  attribute.StringValue("first-n"),
+				CountAttribute: attribute.IntValue(1),
+			},
+		},
+		{
+			name:  "latency",
+			setup: func() { faultinject.SetLatency("db", faultinject.FixedLatency(2*time.Millisecond)) },
+			call:  func(ctx context.Context) { faultinject.InjectLatency(ctx, "db") },
+			expected: map[attribute.Key]attribute.Value{
+				KeyAttribute:     attribute.StringValue("db"),
+				TypeAttribute:    attribute.StringValue("latency"),
+				LatencyAttribute: attribute.Float64Value(2),
+			},
+		},
+		{
+			name:     "not fired",
+			setup:    func() {},
+			call:     func(ctx context.Context) { faultinject.InjectWithContext(ctx, "db") },
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			uninstall := Install()
+			defer uninstall()
+
+			rec := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+			ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+			tt.call(ctx)
+			span.End()
+
+			events := rec.Ended()[0].Events()
+			if tt.expected == nil {
+				if len(events) != 0 {
+					t.Errorf("Expected no events, got %v", events)
+				}
+				return
+			}
+			if len(events) != 1 || events[0].Name != EventName {
+				t.Fatalf("Expected one %s event, got %v", EventName, events)
+			}
+			got := make(map[attribute.Key]attribute.Value)
+			for _, kv := range events[0].Attributes {
+				got[kv.Key] = kv.Value
+			}
+			for k, v := range tt.expected {
+				if got[k] != v {
+					t.Errorf("Expected %s=%v, got %v", k, v.Emit(), got[k].Emit())
+				}
+			}
+			injected := false
+			for _, kv := range rec.Ended()[0].Attributes() {
+				if kv.Key == InjectedAttribute && kv.Value.AsBool() {
+					injected = true
+				}
+			}
+			if !injected {
+				t.Error("Expected the span to be marked as injected")
+			}
+		})
+	}
+}
+
+func TestSampledSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		sampler  sdktrace.Sampler
+		expected bool
+	}{
+		{name: "sampled", sampler: sdktrace.AlwaysSample(), expected: true},
+		{name: "not sampled", sampler: sdktrace.NeverSample(), expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			faultinject.RegisterSelector("sampled", SampledSelector())
+			faultinject.SetFailureRate("db", 1)
+			faultinject.SetSelectors("db", "sampled")
+
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(tt.sampler))
+			ctx, span := tp.Tracer("test").Start(context.Background(), "handler")
+			defer span.End()
+			if got := faultinject.InjectWithContext(ctx, "db"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if SampledSelector().Select(context.Background(), "db") {
+		t.Error("Expected a context without a span not to be selected")
+	}
+}
+
+func TestTraceIDSuffixSelector(t *testing.T) {
+	withTraceID := func(hex string) context.Context {
+		id, err := trace.TraceIDFromHex(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: id, SpanID: trace.SpanID{1}})
+		return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+	}
+	s := TraceIDSuffixSelector("0A", "ff")
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected bool
+	}{
+		{name: "first suffix", ctx: withTraceID("4bf92f3577b34da6a3ce929d0e0e470a"), expected: true},
+		{name: "second suffix", ctx: withTraceID("4bf92f3577b34da6a3ce929d0e0e47ff"), expected: true},
+		{name: "other suffix", ctx: withTraceID("4bf92f3577b34da6a3ce929d0e0e4736"), expected: false},
+		{name: "no trace", ctx: context.Background(), expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Select(tt.ctx, "db"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestInstallRecordsTraceIDs(t *testing.T) {
+	resetState()
+	faultinject.SetFailures("traced", 1)
+	uninstall := Install()
+	id, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: id, SpanID: trace.SpanID{1}})
+	faultinject.InjectWithContext(trace.ContextWithRemoteSpanContext(context.Background(), sc), "traced")
+	uninstall()
+	faultinject.InjectWithContext(trace.ContextWithRemoteSpanContext(context.Background(), sc), "traced")
+
+	events := faultinject.QueryEvents(faultinject.EventQuery{Key: "traced", Limit: 2})
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+	if events[0].TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace ID while installed, got %q", events[0].TraceID)
+	}
+	if events[1].TraceID != "" {
+		t.Errorf("Expected no trace ID after uninstalling, got %q", events[1].TraceID)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OverheadOptions configures MeasureOverhead. Zero values pick defaults.
+type OverheadOptions struct {
+	Iterations int // calls per scenario, default 100000
+	Rules      int // rules configured for the "many-rules" scenario, default 1000
+	Goroutines int // callers for the "contended" scenario, default GOMAXPROCS
+}
+
+// OverheadResult is the cost of one inject path.
+type OverheadResult struct {
+	Scenario    string  `json:"scenario"`
+	NsPerOp     float64 `json:"ns_per_op"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+}
+
+// OverheadReport is returned by MeasureOverhead.
+type OverheadReport struct {
+	GoVersion  string           `json:"go_version"`
+	GOMAXPROCS int              `json:"gomaxprocs"`
+	Production bool             `json:"production"` // measured with injection disabled by the environment
+	Results    []OverheadResult `json:"results"`
+}
+
+// String formats the report as a table.
+func (r OverheadReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s GOMAXPROCS=%d production=%v\n", r.GoVersion, r.GOMAXPROCS, r.Production)
+	fmt.Fprintf(&b, "%-12s %12s %12s %12s\n", "scenario", "ns/op", "allocs/op", "B/op")
+	for _, res := range r.Results {
+		fmt.Fprintf(&b, "%-12s %12.1f %12.2f %12.1f\n", res.Scenario, res.NsPerOp, res.AllocsPerOp, res.BytesPerOp)
+	}
+	return b.String()
+}
+
+// MeasureOverhead times the inject paths in the current process and
+// environment: an unconfigured key, a key among many configured rules, a
+// context-aware call, and many goroutines contending on one key. The calls
+// are made on a throwaway Injector with in's environment check, so in's
+// rules, counters, events and metrics are left alone and it is safe to call
+// at runtime.
+func (in *Injector) MeasureOverhead(opts OverheadOptions) OverheadReport {
+	if opts.Iterations <= 0 {
+		opts.Iterations = 100000
+	}
+	if opts.Rules <= 0 {
+		opts.Rules = 1000
+	}
+	if opts.Goroutines <= 0 {
+		opts.Goroutines = runtime.GOMAXPROCS(0)
+	}
+
+	m := NewInjector()
+	in.mu.RLock()
+	m.envGuard = in.envGuard
+	m.allowedEnvironments = in.allowedEnvironments
+	m.productionEnvironments = in.productionEnvironments
+	in.mu.RUnlock()
+
+	report := OverheadReport{
+		GoVersion:  runtime.Version(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Production: m.isProductionEnvironment(),
+	}
+
+	report.Results = append(report.Results, measure("no-rules", opts.Iterations, func(n int) {
+		for i := 0; i < n; i++ {
+			m.Inject("no-rule")
+		}
+	}))
+
+	m.mu.Lock() // one snapshot for every rule, as a spec would be applied
+	for i := 0; i < opts.Rules; i++ {
+		m.setRuleLocked("rule-"+strconv.Itoa(i), Rule{Mode: ModeFirstN})
+	}
+	m.mu.Unlock()
+	hit := "rule-" + strconv.Itoa(opts.Rules/2)
+	report.Results = append(report.Results, measure("many-rules", opts.Iterations, func(n int) {
+		for i := 0; i < n; i++ {
+			m.Inject(hit)
+		}
+	}))
+
+	ctx := context.Background()
+	report.Results = append(report.Results, measure("context", opts.Iterations, func(n int) {
+		for i := 0; i < n; i++ {
+			m.InjectWithContext(ctx, hit)
+		}
+	}))
+
+	report.Results = append(report.Results, measure("contended", opts.Iterations, func(n int) {
+		var wg sync.WaitGroup
+		per := n / opts.Goroutines
+		for g := 0; g < opts.Goroutines; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < per; i++ {
+					m.Inject(hit)
+				}
+			}()
+		}
+		wg.Wait()
+	}))
+
+	return report
+}
+
+// MeasureOverhead calls MeasureOverhead on the default Injector.
+func MeasureOverhead(opts OverheadOptions) OverheadReport {
+	return std.MeasureOverhead(opts)
+}
+
+// measure runs fn(n) once and reports per-call time and allocations.
+func measure(name string, n int, fn func(n int)) OverheadResult {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	fn(n)
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return OverheadResult{
+		Scenario:    name,
+		NsPerOp:     float64(elapsed.Nanoseconds()) / float64(n),
+		AllocsPerOp: float64(after.Mallocs-before.Mallocs) / float64(n),
+		BytesPerOp:  float64(after.TotalAlloc-before.TotalAlloc) / float64(n),
+	}
+}
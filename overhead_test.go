@@ -0,0 +1,62 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMeasureOverhead(t *testing.T) {
+	resetState()
+	SetFailures("app-fault", 2)
+	gen := Generation()
+
+	report := MeasureOverhead(OverheadOptions{Iterations: 1000, Rules: 50, Goroutines: 4})
+
+	expected := []string{"no-rules", "many-rules", "context", "contended"}
+	if len(report.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(report.Results))
+	}
+	for i, name := range expected {
+		res := report.Results[i]
+		if res.Scenario != name {
+			t.Errorf("Expected scenario %q, got %q", name, res.Scenario)
+		}
+		if res.NsPerOp <= 0 {
+			t.Errorf("Expected positive ns/op for %s, got %f", name, res.NsPerOp)
+		}
+	}
+	if report.Production {
+		t.Error("Expected measurement in a non-production environment")
+	}
+
+	if got := Status(); len(got) != 1 || got["app-fault"] != 2 {
+		t.Errorf("Expected application rules to be untouched, got %v", got)
+	}
+	if got := Generation(); got != gen {
+		t.Errorf("Expected the configuration generation to stay %d, got %d", gen, got)
+	}
+	metrics := Metrics()
+	for _, key := range []string{"no-rule", "rule-0", "rule-25"} {
+		if _, ok := metrics[key]; ok {
+			t.Errorf("Expected no metrics for measurement key %q", key)
+		}
+	}
+
+	if !strings.Contains(report.String(), "many-rules") {
+		t.Errorf("Expected String() to list scenarios, got %q", report.String())
+	}
+}
+
+func TestMeasureOverheadProduction(t *testing.T) {
+	resetState()
+	os.Setenv("ENVIRONMENT", "production")
+	defer os.Setenv("ENVIRONMENT", "development")
+
+	report := MeasureOverhead(OverheadOptions{Iterations: 100, Rules: 10})
+	if !report.Production {
+		t.Error("Expected report to flag the production fast path")
+	}
+}
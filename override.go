@@ -0,0 +1,27 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package faultinject
+
+import "context"
+
+// ContextKey is the context key under which WithFaultOverride stores the
+// override for a fault key. Being its own type, it cannot collide with keys
+// set by other packages.
+type ContextKey string
+
+// WithFaultOverride returns a copy of ctx that forces InjectWithContext and
+// the other context-aware checks for key to return on, regardless of its rule.
+func WithFaultOverride(ctx context.Context, key string, on bool) context.Context {
+	return context.WithValue(ctx, ContextKey(key), on)
+}
+
+// OverrideFromContext returns the override for key carried by ctx, and
+// whether there is one.
+func OverrideFromContext(ctx context.Context, key string) (on bool, ok bool) {
+	if ctx == nil {
+		return false, false
+	}
+	on, ok = ctx.Value(ContextKey(key)).(bool)
+	return on, ok
+}
@@ -0,0 +1,45 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithFaultOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		failures int
+		expected bool
+	}{
+		{name: "forced on", ctx: WithFaultOverride(context.Background(), "db", true), expected: true},
+		{name: "forced off", ctx: WithFaultOverride(context.Background(), "db", false), failures: 1, expected: false},
+		{name: "other key", ctx: WithFaultOverride(context.Background(), "cache", true), expected: false},
+		{name: "raw string key ignored", ctx: context.WithValue(context.Background(), "db", true), expected: false},
+		{name: "no override", ctx: context.Background(), failures: 1, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if tt.failures > 0 {
+				SetFailures("db", tt.failures)
+			}
+			if got := InjectWithContext(tt.ctx, "db"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOverrideFromContext(t *testing.T) {
+	if _, ok := OverrideFromContext(context.Background(), "db"); ok {
+		t.Error("Expected no override in an empty context")
+	}
+	on, ok := OverrideFromContext(WithFaultOverride(context.Background(), "db", true), "db")
+	if !on || !ok {
+		t.Errorf("Expected override true, got %v, %v", on, ok)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// PageFault is a way of breaking cursor pagination.
+type PageFault string
+
+const (
+	// PageRepeatCursor returns the requested cursor as the next one, so the
+	// client fetches the same page again.
+	PageRepeatCursor PageFault = "repeat-cursor"
+	// PageSkip returns the cursor after the next one, silently dropping a page.
+	PageSkip PageFault = "skip-page"
+	// PageEndless returns a next cursor for the last page, and keeps doing so
+	// for every page fetched with it, so the listing never terminates.
+	PageEndless PageFault = "endless"
+)
+
+// endlessPrefix marks cursors made up by PageEndless.
+const endlessPrefix = "fi-endless."
+
+var endlessSeq atomic.Uint64
+
+// PageFunc fetches the page at cursor and returns its items and the cursor
+// of the next page, which is empty after the last page.
+type PageFunc[T any] func(ctx context.Context, cursor string) (items []T, next string, err error)
+
+// SetPageFault sets how Paginate breaks pagination for key when it fires.
+// The default is PageRepeatCursor. An empty PageFault restores the default.
+func (in *Injector) SetPageFault(key string, fault PageFault) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.pageFaults, key, fault, fault == "")
+}
+
+// SetPageFault calls SetPageFault on the default Injector.
+func SetPageFault(key string, fault PageFault) {
+	std.SetPageFault(key, fault)
+}
+
+// Paginate wraps a list-API page fetcher so that when key fires, the page's
+// next cursor is broken as configured by SetPageFault. Use it to test that
+// pagination loops detect repeated cursors and cap the number of pages.
+// PageSkip evaluates key on pages that have a next page, PageRepeatCursor on
+// those that also have a non-empty cursor, and PageEndless on the last page.
+func Paginate[T any](key string, fetch PageFunc[T]) PageFunc[T] {
+	return func(ctx context.Context, cursor string) ([]T, string, error) {
+		// cursors made up by PageEndless keep the listing going
+		if rest, ok := strings.CutPrefix(cursor, endlessPrefix); ok {
+			_, orig, _ := strings.Cut(rest, ".")
+			items, next, err := fetch(ctx, orig)
+			if err == nil && next == "" {
+				next = endlessCursor(orig)
+			}
+			return items, next, err
+		}
+
+		items, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return items, next, err
+		}
+
+		fault := std.pageFaultFor(key)
+		switch {
+		case fault == PageEndless:
+			if next == "" && InjectWithContext(ctx, key) {
+				next = endlessCursor(cursor)
+			}
+		case next == "":
+		case fault != PageSkip && cursor == "":
+			// repeating the empty first cursor would end the listing
+		case !InjectWithContext(ctx, key):
+		case fault == PageSkip:
+			_, after, err := fetch(ctx, next)
+			if err != nil {
+				return nil, "", err
+			}
+			next = after
+		default:
+			next = cursor
+		}
+		return items, next, nil
+	}
+}
+
+// endlessCursor returns a new cursor that fetches the page at cursor again.
+func endlessCursor(cursor string) string {
+	return endlessPrefix + strconv.FormatUint(endlessSeq.Add(1), 10) + "." + cursor
+}
+
+func (in *Injector) pageFaultFor(key string) PageFault {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if f, ok := in.pageFaults[in.ruleKeyLocked(key)]; ok {
+		return f
+	}
+	return PageRepeatCursor
+}
@@ -0,0 +1,121 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// pages serves pages "a", "b", "c" with cursors "", "1", "2".
+func pages(ctx context.Context, cursor string) ([]string, string, error) {
+	data := []string{"a", "b", "c"}
+	i := 0
+	if cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n >= len(data) {
+			return nil, "", errors.New("bad cursor")
+		}
+		i = n
+	}
+	next := ""
+	if i+1 < len(data) {
+		next = strconv.Itoa(i + 1)
+	}
+	return []string{data[i]}, next, nil
+}
+
+// listAll follows cursors for at most limit pages.
+func listAll(fetch PageFunc[string], limit int) ([]string, error) {
+	var all []string
+	cursor := ""
+	for i := 0; i < limit; i++ {
+		items, next, err := fetch(context.Background(), cursor)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, items...)
+		if next == "" {
+			return all, nil
+		}
+		cursor = next
+	}
+	return all, nil
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name     string
+		fault    PageFault
+		failures int
+		expected []string
+	}{
+		{name: "no fault", expected: []string{"a", "b", "c"}},
+		{name: "repeat cursor", fault: PageRepeatCursor, failures: 2, expected: []string{"a", "b", "b", "b", "c"}},
+		{name: "default repeats", failures: 1, expected: []string{"a", "b", "b", "c"}},
+		{name: "skip page", fault: PageSkip, failures: 1, expected: []string{"a", "c"}},
+		{name: "endless", fault: PageEndless, failures: 1, expected: []string{"a", "b", "c", "c", "c", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetPageFault("list-orders", tt.fault)
+			if tt.failures > 0 {
+				SetFailures("list-orders", tt.failures)
+			}
+
+			got, err := listAll(Paginate("list-orders", pages), 6)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPaginateEndlessCursorsDiffer(t *testing.T) {
+	resetState()
+	SetPageFault("list-orders", PageEndless)
+	SetFailures("list-orders", 1)
+
+	fetch := Paginate("list-orders", pages)
+	_, first, _ := fetch(context.Background(), "2")
+	_, second, _ := fetch(context.Background(), first)
+	if first == "" || second == "" || first == second {
+		t.Errorf("Expected distinct made-up cursors, got %q and %q", first, second)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	resetState()
+	SetFailures("list-orders", 1)
+	if _, _, err := Paginate("list-orders", pages)(context.Background(), "9"); err == nil {
+		t.Error("Expected the fetch error to be returned")
+	}
+	if Status()["list-orders"] != 1 {
+		t.Error("Expected a failed fetch not to use up the rule")
+	}
+}
+
+func TestPageFaultSpec(t *testing.T) {
+	resetState()
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	content := "failures:\n  list-orders: 1\npage-faults:\n  list-orders: skip-page\n"
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := LoadSpec(spec); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	if got := std.pageFaultFor("list-orders"); got != PageSkip {
+		t.Errorf("Expected %q, got %q", PageSkip, got)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A rule key is a pattern when it starts with "^", as a regular expression,
+// or contains "*" or "?", as a glob where "*" matches any run of characters
+// and "?" any single one. Pattern rules apply to every key passed to Inject
+// that matches and has no exact rule of its own; when several patterns match,
+// the longest one wins. Each matching key keeps its own call count, so
+// SetFailures("api-*", 1) fails the first call to every api- key. Selectors,
+// tenants, synthetic exclusion and TTLs set for a pattern apply to the keys it
+// matches. A regular expression that does not compile is an exact key.
+
+type pattern struct {
+	key string
+	re  *regexp.Regexp
+}
+
+// compilePattern returns the matcher for key, or nil if key is not a pattern.
+func compilePattern(key string) *regexp.Regexp {
+	if strings.HasPrefix(key, "^") {
+		re, err := regexp.Compile(key)
+		if err != nil {
+			return nil
+		}
+		return re
+	}
+	if !strings.ContainsAny(key, "*?") {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range key {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// MatchKey reports whether a rule set for ruleKey, exact or pattern, would
+// apply to calls to key, ignoring rules set for other keys.
+func MatchKey(ruleKey, key string) bool {
+	if ruleKey == key {
+		return true
+	}
+	re := compilePattern(ruleKey)
+	return re != nil && re.MatchString(key)
+}
+
+// registerPatternLocked records key as a pattern if it is one.
+func (in *Injector) registerPatternLocked(key string) {
+	for _, p := range in.patterns {
+		if p.key == key {
+			return
+		}
+	}
+	re := compilePattern(key)
+	if re == nil {
+		return
+	}
+	in.patterns = append(in.patterns, pattern{key: key, re: re})
+	sort.Slice(in.patterns, func(i, j int) bool {
+		if len(in.patterns[i].key) != len(in.patterns[j].key) {
+			return len(in.patterns[i].key) > len(in.patterns[j].key)
+		}
+		return in.patterns[i].key < in.patterns[j].key
+	})
+}
+
+// restartCountsLocked restarts the call count of key and of every key that a
+// new rule for the pattern key now applies to.
+func (in *Injector) restartCountsLocked(key string) {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	in.counters[key] = 0
+	re := compilePattern(key)
+	if re == nil {
+		return
+	}
+	for k := range in.counters {
+		if !in.hasFaultRuleLocked(k) && re.MatchString(k) && in.ruleKeyLocked(k) == key {
+			in.counters[k] = 0
+		}
+	}
+}
+
+func (in *Injector) unregisterPatternLocked(key string) {
+	for i, p := range in.patterns {
+		if p.key == key {
+			in.patterns = append(in.patterns[:i:i], in.patterns[i+1:]...)
+			return
+		}
+	}
+}
+
+// ruleKeyLocked is ruleKey for the live configuration.
+func (in *Injector) ruleKeyLocked(key string) string {
+	return in.liveLocked().ruleKey(key)
+}
+
+// hasFaultRuleLocked is hasFaultRule for the live configuration.
+func (in *Injector) hasFaultRuleLocked(key string) bool {
+	return in.liveLocked().hasFaultRule(key)
+}
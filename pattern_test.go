@@ -0,0 +1,122 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPatternKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		key      string
+		expected bool
+	}{
+		{name: "glob match", rule: "api-*", key: "api-users", expected: true},
+		{name: "glob no match", rule: "api-*", key: "db-read", expected: false},
+		{name: "glob single character", rule: "db-?", key: "db-1", expected: true},
+		{name: "glob single character too long", rule: "db-?", key: "db-12", expected: false},
+		{name: "glob is anchored", rule: "api-*", key: "v2-api-users", expected: false},
+		{name: "glob escapes metacharacters", rule: "a.b*", key: "axb", expected: false},
+		{name: "regex match", rule: "^db-(read|write)$", key: "db-write", expected: true},
+		{name: "regex no match", rule: "^db-(read|write)$", key: "db-delete", expected: false},
+		{name: "invalid regex is exact", rule: "^db-(", key: "db-(", expected: false},
+		{name: "plain key is exact", rule: "api", key: "api-users", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailures(tt.rule, 1)
+			if got := Inject(tt.key); got != tt.expected {
+				t.Errorf("Expected %v for rule %q and key %q, got %v", tt.expected, tt.rule, tt.key, got)
+			}
+		})
+	}
+}
+
+func TestPatternPerKeyCounts(t *testing.T) {
+	resetState()
+	Inject("api-orders")
+	SetFailures("api-*", 1)
+
+	if !Inject("api-users") || !Inject("api-orders") {
+		t.Error("Expected the first call to each matching key to fail")
+	}
+	if Inject("api-users") {
+		t.Error("Expected the second call to api-users to succeed")
+	}
+	if h := History("api-users"); len(h) != 2 || h[1].Reason != ReasonExhausted {
+		t.Errorf("Expected history recorded under the called key, got %v", h)
+	}
+}
+
+func TestPatternPriority(t *testing.T) {
+	resetState()
+	SetFailures("api-*", 1)
+	SetFailures("api-users-*", 0)
+	SetNthFailure("api-users-admin", 2)
+
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{key: "api-orders", expected: true},      // only api-* matches
+		{key: "api-users-list", expected: false}, // longer pattern wins
+		{key: "api-users-admin", expected: false},
+		{key: "api-users-admin", expected: true}, // exact rule wins
+	}
+	for _, tt := range tests {
+		if got := Inject(tt.key); got != tt.expected {
+			t.Errorf("Expected %v for %q, got %v", tt.expected, tt.key, got)
+		}
+	}
+}
+
+func TestPatternScoping(t *testing.T) {
+	resetState()
+	SetFailures("db-*", 2)
+	SetTenants("db-*", "acme")
+	SetLatency("db-*", FixedLatency(time.Millisecond))
+
+	acme := WithTenant(context.Background(), "acme")
+	if Inject("db-read") {
+		t.Error("Expected the pattern's tenant scope to apply")
+	}
+	if !InjectWithContext(acme, "db-read") {
+		t.Error("Expected matching tenant to fail")
+	}
+	if d, _ := InjectLatency(acme, "db-write"); d != time.Millisecond {
+		t.Errorf("Expected pattern latency, got %s", d)
+	}
+
+	Prune(GCOptions{MaxIdle: time.Nanosecond})
+	std.callsMu.Lock()
+	_, ok := std.counters["db-read"]
+	std.callsMu.Unlock()
+	if !ok {
+		t.Error("Expected counters of keys matching a pattern not to be pruned as idle")
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		ruleKey  string
+		key      string
+		expected bool
+	}{
+		{ruleKey: "db-read", key: "db-read", expected: true},
+		{ruleKey: "db-*", key: "db-read", expected: true},
+		{ruleKey: "^db-(read|write)$", key: "db-read", expected: true},
+		{ruleKey: "^db-(read|write)$", key: "db-readonly", expected: false},
+		{ruleKey: "db", key: "db-read", expected: false},
+	}
+	for _, tt := range tests {
+		if got := MatchKey(tt.ruleKey, tt.key); got != tt.expected {
+			t.Errorf("Expected MatchKey(%q, %q) = %v, got %v", tt.ruleKey, tt.key, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// SizeSelector matches calls whose attribute attr is a size of at least min
+// bytes and, when max is positive, less than max bytes. Middlewares and
+// transports set "size" to the request body size, and PayloadTransport sets
+// "response.size" to the response's. Bodies of unknown size, such as chunked
+// ones, have "chunked" or "response.chunked" set instead and never match.
+func SizeSelector(attr string, min, max int64) Selector {
+	return SelectorFunc(func(ctx context.Context, _ string) bool {
+		v, ok := AttributesFromContext(ctx)[attr]
+		if !ok {
+			return false
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return false
+		}
+		return n >= min && (max <= 0 || n < max)
+	})
+}
+
+// PayloadTransport wraps an HTTP client transport so that key is evaluated
+// once the response headers arrive, with the request's attributes and the
+// response size, so rules can target large uploads and downloads with
+// SizeSelector. When key fires, the response is discarded and the caller
+// gets an InjectedError, as when a large transfer times out. A nil next uses
+// http.DefaultTransport.
+func (in *Injector) PayloadTransport(key string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attrs := in.requestAttributes(req)
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.ContentLength >= 0 {
+			attrs["response.size"] = strconv.FormatInt(resp.ContentLength, 10)
+		} else {
+			attrs["response.chunked"] = "true"
+		}
+		ctx := WithAttributes(req.Context(), attrs)
+		fired, count := in.evaluateWithContext(ctx, key)
+		if !fired {
+			return resp, nil
+		}
+		discard(resp)
+		return nil, in.newInjectedError(key, count, fmt.Sprintf("transfer failed: %s %s", req.Method, req.URL.Path))
+	})
+}
+
+// PayloadTransport calls PayloadTransport on the default Injector.
+func PayloadTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return std.PayloadTransport(key, next)
+}
+
+// addSizeAttributes sets "size" to the size of r's body, or "chunked" when
+// the size is not known up front. It works for both server and client
+// requests: a client request with a body and no ContentLength is sent
+// chunked.
+func addSizeAttributes(attrs Attributes, r *http.Request) {
+	switch {
+	case r.ContentLength > 0:
+		attrs["size"] = strconv.FormatInt(r.ContentLength, 10)
+	case r.ContentLength == 0 && (r.Body == nil || r.Body == http.NoBody):
+		attrs["size"] = "0"
+	default:
+		attrs["chunked"] = "true"
+	}
+}
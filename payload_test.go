@@ -0,0 +1,110 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSizeSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    Attributes
+		min, max int64
+		expected bool
+	}{
+		{name: "above min", attrs: Attributes{"size": "2048"}, min: 1024, expected: true},
+		{name: "at min", attrs: Attributes{"size": "1024"}, min: 1024, expected: true},
+		{name: "below min", attrs: Attributes{"size": "1023"}, min: 1024},
+		{name: "below max", attrs: Attributes{"size": "10"}, max: 1024, expected: true},
+		{name: "at max", attrs: Attributes{"size": "1024"}, max: 1024},
+		{name: "chunked", attrs: Attributes{"chunked": "true"}},
+		{name: "not a number", attrs: Attributes{"size": "big"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithAttributes(context.Background(), tt.attrs)
+			if got := SizeSelector("size", tt.min, tt.max).Select(ctx, "upload"); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSizeSelectorMiddleware(t *testing.T) {
+	resetState()
+	RegisterSelector("large", SizeSelector("size", 10, 0))
+	RegisterSelector("chunked", AttributeSelector("chunked"))
+	SetFailures("upload", 100)
+	SetSelectors("upload", "large")
+	SetFailures("stream", 100)
+	SetSelectors("stream", "chunked")
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		key      string
+		body     io.Reader
+		length   int64
+		expected int
+	}{
+		{key: "upload", body: strings.NewReader("small"), length: 5, expected: http.StatusOK},
+		{key: "upload", body: strings.NewReader("much larger body"), length: 16, expected: http.StatusInternalServerError},
+		{key: "upload", body: strings.NewReader("much larger body"), length: -1, expected: http.StatusOK},
+		{key: "stream", body: strings.NewReader("much larger body"), length: -1, expected: http.StatusInternalServerError},
+		{key: "stream", body: strings.NewReader("small"), length: 5, expected: http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, "/upload", tt.body)
+		r.ContentLength = tt.length
+		w := httptest.NewRecorder()
+		HTTPMiddleware(tt.key)(ok).ServeHTTP(w, r)
+		if w.Code != tt.expected {
+			t.Errorf("Expected status %d for %s with length %d, got %d", tt.expected, tt.key, tt.length, w.Code)
+		}
+	}
+}
+
+func TestPayloadTransport(t *testing.T) {
+	resetState()
+	RegisterSelector("large-download", SizeSelector("response.size", 1024, 0))
+	SetFailures("download", 100)
+	SetSelectors("download", "large-download")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("x", len(r.URL.Query().Get("n"))*1024))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: PayloadTransport("download", nil)}
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "", wantErr: false},
+		{query: "?n=1", wantErr: true},
+		{query: "?n=11", wantErr: true},
+	}
+	for _, tt := range tests {
+		resp, err := client.Get(server.URL + tt.query)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInjected) {
+				t.Errorf("Expected an injected error for %q, got %v", tt.query, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", tt.query, err)
+		}
+		resp.Body.Close()
+	}
+	if calls := FullStatus()["download"].Calls; calls != 2 {
+		t.Errorf("Expected 2 selected calls, got %d", calls)
+	}
+}
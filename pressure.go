@@ -0,0 +1,71 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// pressure tracks running memory and CPU pressure, so tests can wait for it
+// to end.
+var pressure sync.WaitGroup
+
+// InjectMemoryPressure allocates bytes of heap and keeps them live for hold
+// when key fires, so the caller runs with a larger heap and more frequent
+// garbage collection. It returns once the memory is allocated and reports
+// whether the key fired.
+func (in *Injector) InjectMemoryPressure(key string, bytes int, hold time.Duration) bool {
+	if bytes <= 0 || !in.Inject(key) {
+		return false
+	}
+
+	buf := make([]byte, bytes)
+	// touch every page so the memory is resident, not just reserved
+	for i := 0; i < len(buf); i += os.Getpagesize() {
+		buf[i] = 1
+	}
+	pressure.Add(1)
+	go func() {
+		defer pressure.Done()
+		time.Sleep(hold)
+		runtime.KeepAlive(buf)
+	}()
+	return true
+}
+
+// InjectMemoryPressure calls InjectMemoryPressure on the default Injector.
+func InjectMemoryPressure(key string, bytes int, hold time.Duration) bool {
+	return std.InjectMemoryPressure(key, bytes, hold)
+}
+
+// InjectCPUBurn keeps every processor busy for duration when key fires, so
+// the caller's goroutines are starved of CPU. It returns at once and reports
+// whether the key fired.
+func (in *Injector) InjectCPUBurn(key string, duration time.Duration) bool {
+	if duration <= 0 || !in.Inject(key) {
+		return false
+	}
+
+	deadline := time.Now().Add(duration)
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		pressure.Add(1)
+		go in.withProfileLabels(context.Background(), key, "cpu-burn", func(context.Context) {
+			defer pressure.Done()
+			for time.Now().Before(deadline) {
+			}
+		})
+	}
+	return true
+}
+
+// InjectCPUBurn calls InjectCPUBurn on the default Injector.
+func InjectCPUBurn(key string, duration time.Duration) bool {
+	return std.InjectCPUBurn(key, duration)
+}
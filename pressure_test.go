@@ -0,0 +1,53 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestInjectMemoryPressure(t *testing.T) {
+	resetState()
+	const size = 64 << 20
+
+	if InjectMemoryPressure("heap", size, time.Second) {
+		t.Error("Expected no pressure without a rule")
+	}
+
+	SetFailures("heap", 1)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	if !InjectMemoryPressure("heap", size, 200*time.Millisecond) {
+		t.Fatal("Expected the key to fire")
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc < before.HeapAlloc+size/2 {
+		t.Errorf("Expected the heap to grow by about %d bytes, got %d", size, int64(after.HeapAlloc)-int64(before.HeapAlloc))
+	}
+	pressure.Wait()
+}
+
+func TestInjectCPUBurn(t *testing.T) {
+	resetState()
+
+	if InjectCPUBurn("cpu", time.Second) {
+		t.Error("Expected no burn without a rule")
+	}
+
+	SetFailures("cpu", 1)
+	start := time.Now()
+	if !InjectCPUBurn("cpu", 200*time.Millisecond) {
+		t.Fatal("Expected the key to fire")
+	}
+	if time.Since(start) > 150*time.Millisecond {
+		t.Error("Expected InjectCPUBurn to return before the burn ends")
+	}
+	pressure.Wait()
+	if time.Since(start) < 200*time.Millisecond {
+		t.Error("Expected the burn to last its duration")
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// The pprof labels set by SetProfileLabels.
+const (
+	ProfileLabelKey  = "fault_key"  // the key that fired
+	ProfileLabelType = "fault_type" // "latency" or "cpu-burn"
+)
+
+// SetProfileLabels makes the goroutines that run injected faults carry pprof
+// labels naming the fault while it runs: the caller's goroutine while
+// InjectLatency delays it, and the goroutines InjectCPUBurn spins. CPU
+// profiles then attribute the burned time to the fault, and goroutine
+// profiles show which goroutines are parked in an injected delay, filtered
+// with go tool pprof -tagfocus fault_key=db-insert. Labels already on the
+// context are kept. Like the environment lists, it survives Reset.
+func (in *Injector) SetProfileLabels(on bool) {
+	in.profileLabels.Store(on)
+}
+
+// SetProfileLabels calls SetProfileLabels on the default Injector.
+func SetProfileLabels(on bool) {
+	std.SetProfileLabels(on)
+}
+
+// withProfileLabels runs fn on the calling goroutine, labeled with key and
+// typ while it runs if SetProfileLabels is on.
+func (in *Injector) withProfileLabels(ctx context.Context, key, typ string, fn func(ctx context.Context)) {
+	if !in.profileLabels.Load() {
+		fn(ctx)
+		return
+	}
+	pprof.Do(ctx, pprof.Labels(ProfileLabelKey, key, ProfileLabelType, typ), fn)
+}
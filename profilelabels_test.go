@@ -0,0 +1,72 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// goroutineLabels returns the labels of every goroutine in a goroutine
+// profile, as printed by it.
+func goroutineLabels() string {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	var labels []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "# labels: ") {
+			labels = append(labels, line)
+		}
+	}
+	return strings.Join(labels, "\n")
+}
+
+func injectLatency(in *Injector) {
+	in.InjectLatency(context.Background(), "slow-key")
+}
+
+func TestProfileLabels(t *testing.T) {
+	resetState()
+	tests := []struct {
+		name     string
+		on       bool
+		typ      string
+		run      func(in *Injector)
+		expected bool
+	}{
+		{name: "latency", on: true, typ: "latency", run: injectLatency, expected: true},
+		{name: "cpu burn", on: true, typ: "cpu-burn", run: func(in *Injector) { in.InjectCPUBurn("slow-key", 200*time.Millisecond) }, expected: true},
+		{name: "off", on: false, typ: "latency", run: injectLatency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			in.SetProfileLabels(tt.on)
+			in.SetFailures("slow-key", 1)
+			in.SetLatency("slow-key", FixedLatency(200*time.Millisecond))
+			var running sync.WaitGroup
+			running.Add(1)
+			go func() {
+				defer running.Done()
+				tt.run(in)
+			}()
+			defer pressure.Wait()
+			defer running.Wait()
+
+			label := `"fault_key":"slow-key", "fault_type":"` + tt.typ + `"`
+			found := false
+			for deadline := time.Now().Add(150 * time.Millisecond); !found && time.Now().Before(deadline); {
+				found = strings.Contains(goroutineLabels(), label)
+				time.Sleep(5 * time.Millisecond)
+			}
+			if found != tt.expected {
+				t.Errorf("Expected labels %s present=%v, got:\n%s", label, tt.expected, goroutineLabels())
+			}
+		})
+	}
+}
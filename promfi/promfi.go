@@ -0,0 +1,61 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Package promfi exports go-fi metrics through an existing Prometheus
+// registry, for services that already serve client_golang metrics and do not
+// want a second /metrics endpoint.
+package promfi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Collector is a prometheus.Collector for faultinject.Metrics. It exports the
+// same series as faultinject.MetricsHandler.
+type Collector struct {
+	evaluations *prometheus.Desc
+	injected    *prometheus.Desc
+	latency     *prometheus.Desc
+}
+
+// NewCollector returns a Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		evaluations: prometheus.NewDesc("faultinject_evaluations_total",
+			"Injection point evaluations by key and decision reason.", []string{"key", "reason"}, nil),
+		injected: prometheus.NewDesc("faultinject_injected_total",
+			"Faults injected by key.", []string{"key"}, nil),
+		latency: prometheus.NewDesc("faultinject_injected_latency_seconds",
+			"Latency injected by key.", []string{"key"}, nil),
+	}
+}
+
+// Register registers a new Collector with reg.
+func Register(reg prometheus.Registerer) error {
+	return reg.Register(NewCollector())
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.evaluations
+	ch <- c.injected
+	ch <- c.latency
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for key, m := range faultinject.Metrics() {
+		for reason, n := range m.Evaluations {
+			ch <- prometheus.MustNewConstMetric(c.evaluations, prometheus.CounterValue, float64(n), key, string(reason))
+		}
+		if len(m.Evaluations) > 0 {
+			ch <- prometheus.MustNewConstMetric(c.injected, prometheus.CounterValue, float64(m.Injected), key)
+		}
+		if m.Latency.Count > 0 {
+			ch <- prometheus.MustNewConstHistogram(c.latency, m.Latency.Count, m.Latency.Sum, m.Latency.Buckets, key)
+		}
+	}
+}
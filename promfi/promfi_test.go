@@ -0,0 +1,46 @@
+//go:build faultinject
+
+package promfi
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func TestCollector(t *testing.T) {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+	faultinject.SetFailures("promfi-db", 1)
+	faultinject.Inject("promfi-db")
+	faultinject.Inject("promfi-db")
+	faultinject.SetLatency("promfi-api", faultinject.FixedLatency(time.Millisecond))
+	faultinject.InjectLatency(context.Background(), "promfi-api")
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := Register(reg); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	expected := `
+# HELP faultinject_evaluations_total Injection point evaluations by key and decision reason.
+# TYPE faultinject_evaluations_total counter
+faultinject_evaluations_total{key="promfi-db",reason="exhausted"} 1
+faultinject_evaluations_total{key="promfi-db",reason="fired"} 1
+# HELP faultinject_injected_total Faults injected by key.
+# TYPE faultinject_injected_total counter
+faultinject_injected_total{key="promfi-db"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "faultinject_evaluations_total", "faultinject_injected_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(NewCollector(), "faultinject_injected_latency_seconds"); n != 1 {
+		t.Errorf("Expected one latency histogram, got %d", n)
+	}
+}
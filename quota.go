@@ -0,0 +1,149 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QuotaResponse is the response an upstream sends when a plan limit is hit.
+type QuotaResponse struct {
+	Status int               `yaml:"status" json:"status"`
+	Header map[string]string `yaml:"header,omitempty" json:"header,omitempty"`
+	Body   string            `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+// Quota presets. Vendor presets mimic the shape of the vendor's error body.
+var (
+	QuotaExceeded = QuotaResponse{
+		Status: http.StatusTooManyRequests,
+		Header: map[string]string{"Content-Type": "application/json", "Retry-After": "60"},
+		Body:   `{"error":"quota_exceeded","message":"Quota exceeded"}`,
+	}
+	PaymentRequired = QuotaResponse{
+		Status: http.StatusPaymentRequired,
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   `{"error":"payment_required","message":"Payment required"}`,
+	}
+	OpenAIInsufficientQuota = QuotaResponse{
+		Status: http.StatusTooManyRequests,
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   `{"error":{"message":"You exceeded your current quota, please check your plan and billing details.","type":"insufficient_quota","param":null,"code":"insufficient_quota"}}`,
+	}
+	StripeRateLimit = QuotaResponse{
+		Status: http.StatusTooManyRequests,
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   `{"error":{"code":"rate_limit","message":"Request rate limit exceeded.","type":"invalid_request_error"}}`,
+	}
+)
+
+// quotaPresets names the presets for YAML specs.
+var quotaPresets = map[string]QuotaResponse{
+	"quota-exceeded":            QuotaExceeded,
+	"payment-required":          PaymentRequired,
+	"openai-insufficient-quota": OpenAIInsufficientQuota,
+	"stripe-rate-limit":         StripeRateLimit,
+}
+
+// SetQuotaResponse sets the response QuotaTransport returns for key when it
+// fires. The default is QuotaExceeded. A zero QuotaResponse restores the
+// default.
+func (in *Injector) SetQuotaResponse(key string, resp QuotaResponse) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.quotaResponses, key, resp, resp.Status == 0)
+}
+
+// SetQuotaResponse calls SetQuotaResponse on the default Injector.
+func SetQuotaResponse(key string, resp QuotaResponse) {
+	std.SetQuotaResponse(key, resp)
+}
+
+// QuotaTransport wraps an HTTP client transport so that when key fires, the
+// upstream is not called and the response set by SetQuotaResponse is
+// returned instead. Use one key per upstream. A nil next uses
+// http.DefaultTransport.
+func (in *Injector) QuotaTransport(key string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if !in.InjectWithContext(req.Context(), key) {
+			return next.RoundTrip(req)
+		}
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return in.quotaResponseFor(key).response(req), nil
+	})
+}
+
+// QuotaTransport calls QuotaTransport on the default Injector.
+func QuotaTransport(key string, next http.RoundTripper) http.RoundTripper {
+	return std.QuotaTransport(key, next)
+}
+
+// ServeHTTP writes q, so a preset can be passed to HTTPMiddlewareWithResponse
+// as faultinject.QuotaExceeded.ServeHTTP.
+func (q QuotaResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for name, value := range q.Header {
+		w.Header().Set(name, value)
+	}
+	w.WriteHeader(q.Status)
+	io.WriteString(w, q.Body)
+}
+
+// UnmarshalYAML accepts either a preset name, such as "payment-required", or
+// a mapping with status, header and body.
+func (q *QuotaResponse) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		preset, ok := quotaPresets[value.Value]
+		if !ok {
+			return fmt.Errorf("unknown quota preset %q", value.Value)
+		}
+		*q = preset
+		return nil
+	}
+	type plain QuotaResponse
+	return value.Decode((*plain)(q))
+}
+
+func (q QuotaResponse) response(req *http.Request) *http.Response {
+	header := make(http.Header, len(q.Header))
+	for name, value := range q.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(q.Status) + " " + http.StatusText(q.Status),
+		StatusCode:    q.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(q.Body)),
+		ContentLength: int64(len(q.Body)),
+		Request:       req,
+	}
+}
+
+func (in *Injector) quotaResponseFor(key string) QuotaResponse {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if q, ok := in.quotaResponses[in.ruleKeyLocked(key)]; ok {
+		return q
+	}
+	return QuotaExceeded
+}
@@ -0,0 +1,105 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQuotaTransport(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     QuotaResponse
+		failures int
+		status   int
+		body     string
+		calls    int32
+	}{
+		{name: "no fault", status: http.StatusOK, body: "ok", calls: 1},
+		{name: "default", failures: 1, status: http.StatusTooManyRequests, body: QuotaExceeded.Body},
+		{name: "payment required", resp: PaymentRequired, failures: 1, status: http.StatusPaymentRequired, body: PaymentRequired.Body},
+		{name: "vendor preset", resp: OpenAIInsufficientQuota, failures: 1, status: http.StatusTooManyRequests, body: OpenAIInsufficientQuota.Body},
+		{name: "custom", resp: QuotaResponse{Status: http.StatusPaymentRequired, Body: "upgrade"}, failures: 1, status: http.StatusPaymentRequired, body: "upgrade"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetQuotaResponse("billing-api", tt.resp)
+			if tt.failures > 0 {
+				SetFailures("billing-api", tt.failures)
+			}
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				io.WriteString(w, "ok")
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: QuotaTransport("billing-api", nil)}
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != tt.status || string(body) != tt.body {
+				t.Errorf("Expected %d %q, got %d %q", tt.status, tt.body, resp.StatusCode, body)
+			}
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("Expected %d upstream calls, got %d", tt.calls, got)
+			}
+		})
+	}
+}
+
+func TestQuotaResponseServeHTTP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	QuotaExceeded.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Expected 429 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestQuotaResponsesSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected QuotaResponse
+		wantErr  bool
+	}{
+		{name: "preset", content: "quota-responses:\n  billing-api: stripe-rate-limit\n", expected: StripeRateLimit},
+		{name: "custom", content: "quota-responses:\n  billing-api: {status: 402, body: upgrade}\n", expected: QuotaResponse{Status: 402, Body: "upgrade"}},
+		{name: "unknown preset", content: "quota-responses:\n  billing-api: nope\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			spec := filepath.Join(t.TempDir(), "faults.yaml")
+			if err := os.WriteFile(spec, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write spec: %v", err)
+			}
+			err := LoadSpec(spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected an error for an unknown preset")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadSpec returned error: %v", err)
+			}
+			got := std.quotaResponseFor("billing-api")
+			if got.Status != tt.expected.Status || got.Body != tt.expected.Body {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// SyncOptions controls how SyncFromRemote identifies the instance and
+// applies the specs it receives.
+type SyncOptions struct {
+	// Service is sent as the "service" query parameter, so the server can
+	// pick the rules of one service.
+	Service string
+	// Instance is sent as the "instance" query parameter and, if set,
+	// passed to SetInstanceID, so rules limited to a fraction of instances
+	// pick the same ones the server sees. Default: the host name, which is
+	// the pod name on Kubernetes.
+	Instance string
+	// Labels are sent as "label.<name>" query parameters, for servers that
+	// target instances by zone, version or canary status.
+	Labels map[string]string
+	// LongPoll sends the next request as soon as a response arrives, with
+	// a "wait" query parameter asking the server to hold the request until
+	// the spec changes or the wait passes. Servers that ignore it are polled
+	// back to back, so only set it for servers that support it.
+	LongPoll bool
+	// Merge applies a changed spec on top of the current state instead of
+	// replacing it, so rules set at runtime survive syncs.
+	Merge bool
+	// Client makes the requests. Default: a client with a timeout of the
+	// interval plus 10s.
+	Client *http.Client
+	// OnError is called when a request fails or returns an invalid spec. The
+	// current state is kept until the next successful sync.
+	OnError func(error)
+	// OnSync is called after a changed spec has been applied, other than
+	// the initial one.
+	OnSync func()
+}
+
+// SyncFromRemote fetches the spec for this instance from url, then polls it
+// every interval and applies it whenever it changes, until the returned stop
+// func is called, so a fleet of instances can share one central fault
+// server instead of being configured one control server at a time. It
+// returns an error if the initial fetch fails.
+//
+// Requests are GETs of url with the service, instance and label query
+// parameters described in SyncOptions. The server responds with a spec in
+// any format LoadSpecFromBytes accepts, named by the Content-Type or
+// detected from the body. If the response carries an ETag, it is sent back
+// in If-None-Match, and a 304 Not Modified leaves the rules alone.
+func (in *Injector) SyncFromRemote(url string, interval time.Duration, opts SyncOptions) (stop func(), err error) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	s, err := newRemoteSync(in, url, interval, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Instance != "" {
+		in.SetInstanceID(opts.Instance) // rules with Instances hash the ID the server sees
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := s.sync(ctx, false); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			wait := interval
+			if opts.LongPoll {
+				wait = 0
+			}
+			if err := s.sync(ctx, opts.LongPoll); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if opts.OnError != nil {
+					opts.OnError(err)
+				}
+				wait = interval // back off instead of retrying a failing server at once
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
+
+// SyncFromRemote calls SyncFromRemote on the default Injector.
+func SyncFromRemote(url string, interval time.Duration, opts SyncOptions) (stop func(), err error) {
+	return std.SyncFromRemote(url, interval, opts)
+}
+
+// remoteSync is the state of one SyncFromRemote loop.
+type remoteSync struct {
+	in       *Injector
+	url      *url.URL
+	interval time.Duration
+	opts     SyncOptions
+	client   *http.Client
+	etag     string
+	last     []byte
+	synced   bool // whether a spec has been applied
+}
+
+func newRemoteSync(in *Injector, rawURL string, interval time.Duration, opts SyncOptions) (*remoteSync, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	if opts.Service != "" {
+		q.Set("service", opts.Service)
+	}
+	if opts.Instance == "" {
+		opts.Instance, _ = os.Hostname()
+	}
+	if opts.Instance != "" {
+		q.Set("instance", opts.Instance)
+	}
+	for name, value := range opts.Labels {
+		q.Set("label."+name, value)
+	}
+	u.RawQuery = q.Encode()
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: interval + 10*time.Second}
+	}
+	return &remoteSync{in: in, url: u, interval: interval, opts: opts, client: client}, nil
+}
+
+// sync fetches the spec once and applies it if it changed.
+func (s *remoteSync) sync(ctx context.Context, longPoll bool) error {
+	u := *s.url
+	if longPoll && s.etag != "" {
+		q := u.Query()
+		q.Set("wait", s.interval.String())
+		u.RawQuery = q.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: %s", s.url.Redacted(), resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if s.synced && bytes.Equal(data, s.last) {
+		s.etag = resp.Header.Get("ETag")
+		return nil
+	}
+	cfg, err := parseSpec(data, contentFormat(resp.Header.Get("Content-Type")))
+	if err != nil {
+		return fmt.Errorf("spec from %s: %w", s.url.Redacted(), err)
+	}
+	s.in.applySpec(cfg, s.opts.Merge)
+	s.etag, s.last = resp.Header.Get("ETag"), data
+	if s.synced && s.opts.OnSync != nil {
+		s.opts.OnSync()
+	}
+	s.synced = true
+	return nil
+}
+
+// contentFormat returns the spec format named by a Content-Type, or "" to
+// detect it.
+func contentFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/json":
+		return FormatJSON
+	case "application/toml":
+		return FormatTOML
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return FormatYAML
+	}
+	return ""
+}
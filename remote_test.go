@@ -0,0 +1,159 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// specServer serves a settable spec with an ETag of its version.
+type specServer struct {
+	mu       sync.Mutex
+	spec     string
+	version  int
+	requests atomic.Int32
+	query    atomic.Value
+}
+
+func (s *specServer) set(spec string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spec, s.version = spec, s.version+1
+}
+
+func (s *specServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	s.query.Store(r.URL.Query())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tag := `"` + strconv.Itoa(s.version) + `"`
+	if r.Header.Get("If-None-Match") == tag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", tag)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(s.spec))
+}
+
+func TestSyncFromRemote(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	remote := &specServer{}
+	remote.set(`{"failures": {"db-insert": 2}}`)
+	server := httptest.NewServer(remote)
+	defer server.Close()
+
+	synced := make(chan struct{}, 1)
+	stop, err := in.SyncFromRemote(server.URL+"/faults", 10*time.Millisecond, SyncOptions{
+		Service:  "orders",
+		Instance: "orders-7",
+		Labels:   map[string]string{"zone": "eu-west-1a"},
+		OnSync:   func() { synced <- struct{}{} },
+	})
+	if err != nil {
+		t.Fatalf("SyncFromRemote returned error: %v", err)
+	}
+	defer stop()
+
+	if st := in.FullStatus()["db-insert"]; st.Remaining != 2 {
+		t.Errorf("Expected the initial spec to be applied, got %+v", st)
+	}
+	q := remote.query.Load().(url.Values)
+	for param, expected := range map[string]string{"service": "orders", "instance": "orders-7", "label.zone": "eu-west-1a"} {
+		if got := q[param]; len(got) != 1 || got[0] != expected {
+			t.Errorf("Expected %s=%s, got %v", param, expected, got)
+		}
+	}
+
+	// unchanged specs are answered with 304 and leave the call count alone
+	in.Inject("db-insert")
+	for n := remote.requests.Load(); remote.requests.Load() < n+2; {
+		time.Sleep(time.Millisecond)
+	}
+	if st := in.FullStatus()["db-insert"]; st.Remaining != 1 {
+		t.Errorf("Expected the rule to be left alone, got %+v", st)
+	}
+
+	remote.set(`{"failures": {"cache-get": 1}}`)
+	select {
+	case <-synced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the changed spec to be synced")
+	}
+	status := in.FullStatus()
+	if status["cache-get"].Remaining != 1 {
+		t.Errorf("Expected cache-get from the new spec, got %+v", status)
+	}
+	if _, ok := status["db-insert"]; ok {
+		t.Errorf("Expected the new spec to replace the old one, got %+v", status)
+	}
+}
+
+func TestSyncFromRemoteErrors(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("failures:\n  db-insert: 1\n"))
+	}))
+	defer server.Close()
+
+	fail.Store(true)
+	if _, err := in.SyncFromRemote(server.URL, time.Millisecond, SyncOptions{}); err == nil {
+		t.Fatal("Expected the initial fetch to fail")
+	}
+
+	fail.Store(false)
+	errs := make(chan error, 1)
+	stop, err := in.SyncFromRemote(server.URL, time.Millisecond, SyncOptions{
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("SyncFromRemote returned error: %v", err)
+	}
+	defer stop()
+	fail.Store(true)
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected OnError to be called")
+	}
+	if st := in.FullStatus()["db-insert"]; st.Remaining != 1 {
+		t.Errorf("Expected the last good spec to be kept, got %+v", st)
+	}
+}
+
+func TestContentFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    string
+	}{
+		{contentType: "application/json; charset=utf-8", expected: FormatJSON},
+		{contentType: "application/toml", expected: FormatTOML},
+		{contentType: "application/yaml", expected: FormatYAML},
+		{contentType: "text/plain", expected: ""},
+		{contentType: "", expected: ""},
+	}
+	for _, tt := range tests {
+		if got := contentFormat(tt.contentType); got != tt.expected {
+			t.Errorf("Expected %q for %q, got %q", tt.expected, tt.contentType, got)
+		}
+	}
+}
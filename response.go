@@ -0,0 +1,116 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseFault is a way of breaking a response the handler has produced,
+// for testing clients against malformed upstream responses rather than clean
+// errors. With one set for a key, HTTPMiddleware lets the handler run when
+// the key fires and breaks what it wrote.
+type ResponseFault string
+
+const (
+	// ResponseTruncate sends the status, the headers and a Content-Length
+	// for the whole body, but only the first half of the body, so the client
+	// sees the connection close early.
+	ResponseTruncate ResponseFault = "truncate"
+	// ResponseGarbage replaces the status with 200 and the body with as many
+	// random bytes, and at least 64, keeping the headers, so a JSON
+	// Content-Type announces garbage.
+	ResponseGarbage ResponseFault = "garbage"
+	// ResponseDropHeaders sends the status and body without any of the
+	// headers the handler set, such as Content-Type and Cache-Control.
+	ResponseDropHeaders ResponseFault = "drop-headers"
+	// ResponseSlowDrip sends the body in ten flushed chunks spread over a
+	// sample of the key's latency distribution, set with SetLatency, or over
+	// a second without one, as a congested upstream would.
+	ResponseSlowDrip ResponseFault = "slow-drip"
+)
+
+// dripChunks is how many chunks ResponseSlowDrip sends the body in.
+const dripChunks = 10
+
+// SetResponseFault makes HTTPMiddleware break the handler's response for
+// key as fault says when it fires, instead of replacing it with an error.
+// An empty ResponseFault goes back to error responses. Fault injection is
+// disabled in production environments.
+func (in *Injector) SetResponseFault(key string, fault ResponseFault) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.responseFaults, key, fault, fault == "")
+}
+
+// SetResponseFault calls SetResponseFault on the default Injector.
+func SetResponseFault(key string, fault ResponseFault) {
+	std.SetResponseFault(key, fault)
+}
+
+func (in *Injector) responseFaultFor(key string) (ResponseFault, bool) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	f, ok := in.responseFaults[in.ruleKeyLocked(key)]
+	return f, ok
+}
+
+// breakResponse runs next with a buffered response and sends it broken as
+// fault says. Unknown faults truncate.
+func (in *Injector) breakResponse(w http.ResponseWriter, r *http.Request, next http.Handler, key string, fault ResponseFault) {
+	cw := &corruptWriter{ResponseWriter: w, code: http.StatusOK}
+	next.ServeHTTP(cw, r)
+	body := cw.body.Bytes()
+	switch fault {
+	case ResponseGarbage:
+		garbage := make([]byte, max(len(body), 64))
+		for i := range garbage {
+			garbage[i] = byte(rand.IntN(256))
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(garbage)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(garbage)
+	case ResponseDropHeaders:
+		clear(w.Header())
+		w.WriteHeader(cw.code)
+		w.Write(body)
+	case ResponseSlowDrip:
+		d := time.Second
+		s := in.snapshot()
+		if dist := s.rules[s.ruleKey(key)].Latency; len(dist) > 0 {
+			d = dist.Sample()
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(cw.code)
+		flusher, _ := w.(http.Flusher)
+		size := (len(body) + dripChunks - 1) / dripChunks
+		for len(body) > 0 {
+			n := min(size, len(body))
+			w.Write(body[:n])
+			body = body[n:]
+			if flusher != nil {
+				flusher.Flush()
+			}
+			if len(body) == 0 {
+				break
+			}
+			if _, err := in.sleep(r.Context(), d/dripChunks); err != nil {
+				return
+			}
+		}
+	default:
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(cw.code)
+		w.Write(body[:len(body)/2])
+	}
+}
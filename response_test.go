@@ -0,0 +1,116 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseFaults(t *testing.T) {
+	resetState()
+	const body = `{"id":42,"name":"widget"}`
+	tests := []struct {
+		name         string
+		fault        ResponseFault
+		expectedCode int
+		check        func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{name: "garbage", fault: ResponseGarbage, expectedCode: http.StatusOK, check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+			if rec.Body.String() == body || rec.Body.Len() < len(body) {
+				t.Errorf("Expected garbage at least as long as the body, got %q", rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Expected the handler's Content-Type to be kept, got %q", got)
+			}
+		}},
+		{name: "drop headers", fault: ResponseDropHeaders, expectedCode: http.StatusCreated, check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+			if rec.Body.String() != body {
+				t.Errorf("Expected the body unchanged, got %q", rec.Body.String())
+			}
+			if got := rec.Header().Get("X-Request-ID"); got != "" {
+				t.Errorf("Expected headers to be dropped, got X-Request-ID %q", got)
+			}
+		}},
+		{name: "slow drip", fault: ResponseSlowDrip, expectedCode: http.StatusCreated, check: func(t *testing.T, rec *httptest.ResponseRecorder) {
+			if rec.Body.String() != body {
+				t.Errorf("Expected the whole body, got %q", rec.Body.String())
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := NewInjector()
+			in.SetFailures("items", 1)
+			in.SetLatency("items", FixedLatency(20*time.Millisecond))
+			in.SetResponseFault("items", tt.fault)
+			handler := in.HTTPMiddleware("items")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("X-Request-ID", "abc")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(body))
+			}))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+			if rec.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			tt.check(t, rec)
+		})
+	}
+}
+
+func TestResponseTruncate(t *testing.T) {
+	resetState()
+	SetFailures("items", 1)
+	SetResponseFault("items", ResponseTruncate)
+	srv := httptest.NewServer(HTTPMiddleware("items")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected a response, got %v", err)
+	}
+	got, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err == nil || string(got) != "01234" {
+		t.Errorf("Expected half the body and an unexpected EOF, got %q, %v", got, err)
+	}
+
+	resp, err = http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected a response, got %v", err)
+	}
+	got, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != "0123456789" {
+		t.Errorf("Expected the whole body once the rule is exhausted, got %q", got)
+	}
+}
+
+func TestResponseFaultSpec(t *testing.T) {
+	resetState()
+	if err := LoadSpecFromBytes([]byte("rules:\n  items: {count: 1}\nresponse-faults:\n  items: drop-headers\n"), "yaml"); err != nil {
+		t.Fatalf("Expected the spec to load, got %v", err)
+	}
+	handler := HTTPMiddleware("items")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("ok"))
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "" {
+		t.Errorf("Expected 200 without Cache-Control, got %d %v", rec.Code, rec.Header())
+	}
+
+	SetResponseFault("items", "")
+	if len(std.responseFaults) != 0 {
+		t.Errorf("Expected the response fault to be removed, got %v", std.responseFaults)
+	}
+}
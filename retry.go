@@ -0,0 +1,126 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RetryPolicy is the retry behavior RetryRecorder.Verify expects of a caller.
+type RetryPolicy struct {
+	// MinAttempts and MaxAttempts bound the number of attempts, including
+	// the first. A zero MaxAttempts means exactly MinAttempts.
+	MinAttempts int
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the gap between consecutive attempts.
+	// A zero MaxBackoff means no upper bound.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Multiplier, if set, requires each gap to be at least Multiplier times
+	// the one before, for exponential backoff. Use a smaller value than the
+	// caller's to leave room for jitter.
+	Multiplier float64
+}
+
+// RetryAttempt is one call through a RetryRecorder.
+type RetryAttempt struct {
+	Time     time.Time
+	Err      error // the error returned to the caller
+	Injected bool  // whether Err was injected
+}
+
+// RetryRecorder wraps a function with a fault and records every attempt to
+// call it, so a test can check a caller's retry policy:
+//
+//	faultinject.SetFailures("payment-charge", 2)
+//	rec := faultinject.RecordRetries("payment-charge", charge)
+//	err := client.WithRetry(ctx, rec.Call)
+//	if err := rec.Verify(faultinject.RetryPolicy{MinAttempts: 3, MinBackoff: 100 * time.Millisecond}); err != nil {
+//		t.Error(err)
+//	}
+type RetryRecorder struct {
+	in  *Injector
+	key string
+	fn  func(ctx context.Context) error
+
+	mu       sync.Mutex
+	attempts []RetryAttempt
+}
+
+// RecordRetries returns a RetryRecorder whose Call fails with an
+// InjectedError when key fires and calls fn otherwise.
+func (in *Injector) RecordRetries(key string, fn func(ctx context.Context) error) *RetryRecorder {
+	return &RetryRecorder{in: in, key: key, fn: fn}
+}
+
+// RecordRetries calls RecordRetries on the default Injector.
+func RecordRetries(key string, fn func(ctx context.Context) error) *RetryRecorder {
+	return std.RecordRetries(key, fn)
+}
+
+// Call makes one attempt and records it. It is safe for concurrent use.
+func (r *RetryRecorder) Call(ctx context.Context) error {
+	now := r.in.timeNow()
+	err := r.in.InjectWithContextError(ctx, r.key, "injected failure")
+	injected := err != nil
+	if !injected && r.fn != nil {
+		err = r.fn(ctx)
+	}
+	r.mu.Lock()
+	r.attempts = append(r.attempts, RetryAttempt{Time: now, Err: err, Injected: injected})
+	r.mu.Unlock()
+	return err
+}
+
+// Attempts returns the attempts recorded so far, in order.
+func (r *RetryRecorder) Attempts() []RetryAttempt {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RetryAttempt(nil), r.attempts...)
+}
+
+// Verify checks the recorded attempts against p and returns every
+// violation found, joined, or nil.
+func (r *RetryRecorder) Verify(p RetryPolicy) error {
+	attempts := r.Attempts()
+	var errs []error
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = p.MinAttempts
+	}
+	if n := len(attempts); n < p.MinAttempts || (maxAttempts > 0 && n > maxAttempts) {
+		errs = append(errs, fmt.Errorf("%s: %d attempts, expected %s", r.key, n, attemptRange(p.MinAttempts, maxAttempts)))
+	}
+
+	var prev time.Duration
+	for i := 1; i < len(attempts); i++ {
+		gap := attempts[i].Time.Sub(attempts[i-1].Time)
+		switch {
+		case gap < p.MinBackoff:
+			errs = append(errs, fmt.Errorf("%s: attempt %d came %v after attempt %d, expected at least %v", r.key, i+1, gap, i, p.MinBackoff))
+		case p.MaxBackoff > 0 && gap > p.MaxBackoff:
+			errs = append(errs, fmt.Errorf("%s: attempt %d came %v after attempt %d, expected at most %v", r.key, i+1, gap, i, p.MaxBackoff))
+		case p.Multiplier > 0 && i > 1 && float64(gap) < p.Multiplier*float64(prev):
+			errs = append(errs, fmt.Errorf("%s: attempt %d came %v after attempt %d, expected at least %gx the previous %v", r.key, i+1, gap, i, p.Multiplier, prev))
+		}
+		prev = gap
+	}
+	return errors.Join(errs...)
+}
+
+func attemptRange(min, max int) string {
+	switch {
+	case max == 0:
+		return fmt.Sprintf("at least %d", min)
+	case min == max:
+		return fmt.Sprint(min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
@@ -0,0 +1,80 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryRecorder(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("payment-charge", 2)
+	rec := in.RecordRetries("payment-charge", func(ctx context.Context) error { return nil })
+
+	var err error
+	for backoff := 100 * time.Millisecond; ; backoff *= 2 {
+		if err = rec.Call(context.Background()); err == nil {
+			break
+		}
+		time.Sleep(backoff)
+	}
+
+	attempts := rec.Attempts()
+	if len(attempts) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(attempts))
+	}
+	for i, a := range attempts {
+		if expected := i < 2; a.Injected != expected || (a.Err != nil) != expected {
+			t.Errorf("Expected attempt %d injected=%v, got %+v", i+1, expected, a)
+		}
+	}
+	if !errors.Is(attempts[0].Err, ErrInjected) {
+		t.Errorf("Expected an injected error, got %v", attempts[0].Err)
+	}
+	if err := rec.Verify(RetryPolicy{MinAttempts: 3, MinBackoff: 100 * time.Millisecond, Multiplier: 1.5}); err != nil {
+		t.Errorf("Expected the policy to hold, got %v", err)
+	}
+}
+
+func TestRetryRecorderVerify(t *testing.T) {
+	resetState()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	// attempts at 0, 100ms, 150ms
+	rec := NewInjector().RecordRetries("api", nil)
+	for _, gap := range []time.Duration{0, 100 * time.Millisecond, 50 * time.Millisecond} {
+		now = now.Add(gap)
+		rec.Call(context.Background())
+	}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		expected string
+	}{
+		{name: "matching", policy: RetryPolicy{MinAttempts: 2, MaxAttempts: 3, MinBackoff: 50 * time.Millisecond}},
+		{name: "too many", policy: RetryPolicy{MinAttempts: 2}, expected: "api: 3 attempts, expected 2"},
+		{name: "too few", policy: RetryPolicy{MinAttempts: 4, MaxAttempts: 5}, expected: "api: 3 attempts, expected 4 to 5"},
+		{name: "too soon", policy: RetryPolicy{MinAttempts: 3, MinBackoff: 60 * time.Millisecond}, expected: "api: attempt 3 came 50ms after attempt 2, expected at least 60ms"},
+		{name: "too late", policy: RetryPolicy{MinAttempts: 3, MaxBackoff: 80 * time.Millisecond}, expected: "api: attempt 2 came 100ms after attempt 1, expected at most 80ms"},
+		{name: "not growing", policy: RetryPolicy{MinAttempts: 3, Multiplier: 1}, expected: "api: attempt 3 came 50ms after attempt 2, expected at least 1x the previous 100ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rec.Verify(tt.policy)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
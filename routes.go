@@ -0,0 +1,152 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Routes let one middleware at the root of a router inject faults for every
+// endpoint, instead of a HTTPMiddleware with its own key on each route. A
+// spec's "routes" section maps request patterns, written as for
+// http.ServeMux, to the keys HTTPMiddlewareFromSpec evaluates for them:
+//
+//	routes:
+//	  "POST /api/payments/{id}": payments-api
+//	  "GET /api/users/": users-api
+//	rules:
+//	  payments-api: {count: 1, status: 503}
+//
+// A request is matched to the most specific pattern, by http.ServeMux's
+// rules, so "GET /api/users/admin" can have a route of its own.
+
+// SetRoute makes HTTPMiddlewareFromSpec evaluate key for requests matching
+// pattern, such as "POST /api/payments/{id}". An empty key removes the
+// route. It returns an error if pattern is invalid or conflicts with another
+// route, as http.ServeMux.Handle would panic.
+func (in *Injector) SetRoute(pattern, key string) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	routes := make(map[string]string, len(in.routes)+1)
+	for p, k := range in.routes {
+		routes[p] = k
+	}
+	if key == "" {
+		delete(routes, pattern)
+	} else {
+		routes[pattern] = key
+	}
+	return in.setRoutesLocked(routes)
+}
+
+// SetRoute calls SetRoute on the default Injector.
+func SetRoute(pattern, key string) error {
+	return std.SetRoute(pattern, key)
+}
+
+// setRoutesLocked replaces the routes with routes, which must not be modified
+// afterwards.
+func (in *Injector) setRoutesLocked(routes map[string]string) error {
+	mux, err := routeMux(routes)
+	if err != nil {
+		return err
+	}
+	in.routes, in.routeMux = routes, mux
+	in.bumpGenerationLocked()
+	return nil
+}
+
+// HTTPMiddlewareFromSpec creates middleware that evaluates the key routed to
+// by each request's method and path, set in a spec's "routes" section or
+// with SetRoute, and responds like HTTPMiddleware when it fires. Requests no
+// route matches are passed on untouched. Since routes are looked up per
+// request, specs loaded later take effect without rebuilding the router.
+func (in *Injector) HTTPMiddlewareFromSpec(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return in.routeMiddleware(in.route, func(w http.ResponseWriter, r *http.Request, next http.Handler, key string, count int) {
+		in.faultResponse(key, http.StatusInternalServerError)(w, r, next, count)
+	}, opts...)
+}
+
+// HTTPMiddlewareFromSpec calls HTTPMiddlewareFromSpec on the default
+// Injector, or the one set with WithInjector.
+func HTTPMiddlewareFromSpec(opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return injectorFor(opts).HTTPMiddlewareFromSpec(opts...)
+}
+
+// HTTPMiddlewareFunc creates middleware that evaluates the key keyFunc
+// returns for each request and responds like HTTPMiddleware when it fires.
+// Requests keyFunc returns "" for are passed on untouched. It suits routers
+// that only know a request's route once it arrives, and is what the chifi,
+// ginfi and echofi adapters key requests by route pattern with.
+func (in *Injector) HTTPMiddlewareFunc(keyFunc func(*http.Request) string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return in.routeMiddleware(func(r *http.Request) (string, *http.Request, bool) {
+		key := keyFunc(r)
+		return key, r, key != ""
+	}, func(w http.ResponseWriter, r *http.Request, next http.Handler, key string, count int) {
+		in.faultResponse(key, http.StatusInternalServerError)(w, r, next, count)
+	}, opts...)
+}
+
+// HTTPMiddlewareFunc calls HTTPMiddlewareFunc on the default Injector, or
+// the one set with WithInjector.
+func HTTPMiddlewareFunc(keyFunc func(*http.Request) string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return injectorFor(opts).HTTPMiddlewareFunc(keyFunc, opts...)
+}
+
+type matchedRouteKey struct{}
+
+// route returns the key routed to by r, if any, and a copy of r matched to
+// the route, whose path wildcards are set. Requests redirected by the route,
+// such as to add a trailing slash, match once redirected.
+func (in *Injector) route(r *http.Request) (string, *http.Request, bool) {
+	s := in.snapshot()
+	if s.routeMux == nil {
+		return "", nil, false
+	}
+	var matched *http.Request
+	s.routeMux.ServeHTTP(&discardWriter{header: make(http.Header)}, r.WithContext(context.WithValue(r.Context(), matchedRouteKey{}, &matched)))
+	if matched == nil {
+		return "", nil, false
+	}
+	key, ok := s.routes[matched.Pattern]
+	return key, matched, ok
+}
+
+// matchRoute is the handler of every route, recording the request matched.
+var matchRoute = http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+	if p, ok := r.Context().Value(matchedRouteKey{}).(**http.Request); ok {
+		*p = r
+	}
+})
+
+// routeMux returns a mux with a route for each pattern in routes, or nil if
+// there are none.
+func routeMux(routes map[string]string) (*http.ServeMux, error) {
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	mux := http.NewServeMux()
+	for _, p := range sortedKeys(routes) {
+		if err := addRoute(mux, p); err != nil {
+			return nil, err
+		}
+	}
+	return mux, nil
+}
+
+// addRoute registers pattern with mux, returning the panic of an invalid or
+// conflicting pattern as an error.
+func addRoute(mux *http.ServeMux, pattern string) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+	mux.Handle(pattern, matchRoute)
+	return nil
+}
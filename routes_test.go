@@ -0,0 +1,158 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPMiddlewareFromSpec(t *testing.T) {
+	resetState()
+	err := LoadSpecFromReader(strings.NewReader(`
+routes:
+  "POST /api/payments/{id}": payments-api
+  "GET /api/users/": users-api
+  "GET /api/users/admin": admin-api
+rules:
+  payments-api: {count: 1, status: 503}
+  users-api: {rate: 1, status: 502}
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	handler := HTTPMiddlewareFromSpec()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("success"))
+	}))
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{name: "first payment", method: http.MethodPost, path: "/api/payments/42", expectedStatus: 503},
+		{name: "second payment", method: http.MethodPost, path: "/api/payments/43", expectedStatus: 200},
+		{name: "other method", method: http.MethodGet, path: "/api/payments/42", expectedStatus: 200},
+		{name: "subtree", method: http.MethodGet, path: "/api/users/7", expectedStatus: 502},
+		{name: "more specific route", method: http.MethodGet, path: "/api/users/admin", expectedStatus: 200},
+		{name: "HEAD matches GET", method: http.MethodHead, path: "/api/users/7", expectedStatus: 502},
+		{name: "no route", method: http.MethodGet, path: "/health", expectedStatus: 200},
+		{name: "trailing slash redirect", method: http.MethodGet, path: "/api/users", expectedStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+
+	status := FullStatus()
+	if status["payments-api"].Calls != 2 || status["users-api"].Calls != 2 {
+		t.Errorf("Expected 2 calls to each routed key, got %d and %d", status["payments-api"].Calls, status["users-api"].Calls)
+	}
+	if _, ok := status["/health"]; ok {
+		t.Error("Expected unrouted requests not to be evaluated")
+	}
+}
+
+func TestHTTPMiddlewareFunc(t *testing.T) {
+	resetState()
+	SetRule("GET /users/{id}", Rule{Mode: ModeFirstN, Count: 1, Status: 503})
+	handler := HTTPMiddlewareFunc(func(r *http.Request) string {
+		if strings.HasPrefix(r.URL.Path, "/users/") {
+			return r.Method + " /users/{id}"
+		}
+		return ""
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("success"))
+	}))
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{name: "first call", path: "/users/1", expectedStatus: 503},
+		{name: "second call", path: "/users/2", expectedStatus: 200},
+		{name: "no key", path: "/health", expectedStatus: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+	if calls := FullStatus()["GET /users/{id}"].Calls; calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", calls)
+	}
+}
+
+func TestSetRoute(t *testing.T) {
+	resetState()
+	SetFailures("orders", 5)
+	handler := HTTPMiddlewareFromSpec()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+		return rec.Code
+	}
+
+	if got := serve(); got != http.StatusOK {
+		t.Errorf("Expected status 200 without routes, got %d", got)
+	}
+	if err := SetRoute("/orders/{id}", "orders"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := serve(); got != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 once routed, got %d", got)
+	}
+	if err := SetRoute("/orders/{id}", ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := serve(); got != http.StatusOK {
+		t.Errorf("Expected status 200 after removing the route, got %d", got)
+	}
+
+	if err := SetRoute("BREW /{", "orders"); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	SetRoute("/orders/{id}", "orders")
+	if err := SetRoute("/orders/{name}", "other"); err == nil {
+		t.Error("Expected an error for a conflicting pattern")
+	}
+	if got := serve(); got != http.StatusInternalServerError {
+		t.Errorf("Expected the routes to be kept after an error, got status %d", got)
+	}
+}
+
+func TestRoutesSpecErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		key  string
+	}{
+		{name: "invalid pattern", spec: "routes:\n  \"GET /{\": api\n", key: "GET /{"},
+		{name: "conflict", spec: "routes:\n  \"/a/{x}\": a\n  \"/a/{y}\": b\n", key: "/a/{y}"},
+		{name: "no key", spec: "routes:\n  \"/a\": \"\"\n", key: "/a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			err := LoadSpecFromBytes([]byte(tt.spec), FormatYAML)
+			var se *SpecError
+			if !errors.As(err, &se) || se.Section != "routes" || se.Key != tt.key {
+				t.Errorf("Expected a SpecError for routes[%s], got %v", tt.key, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mode selects which calls a rule fails.
+type Mode string
+
+const (
+	ModeNone     Mode = ""         // never fails; the rule only adds latency
+	ModeFirstN   Mode = "first-n"  // fail the first Count calls
+	ModeNth      Mode = "nth"      // fail call number Nth and the calls in Ranges
+	ModeRate     Mode = "rate"     // fail each call with probability Rate
+	ModeFlapping Mode = "flapping" // fail during the unhealthy part of Flap
+	ModeActions  Mode = "actions"  // go through the phases of Actions in order
+)
+
+// Rule is the complete configuration of a key. One key can combine a failure
+// mode with latency, an error template and an expiry.
+type Rule struct {
+	Mode    Mode                `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Count   int                 `yaml:"count,omitempty" json:"count,omitempty"`
+	Nth     int                 `yaml:"nth,omitempty" json:"nth,omitempty"`
+	Rate    float64             `yaml:"rate,omitempty" json:"rate,omitempty"`
+	Flap    *FlapCycle          `yaml:"flap,omitempty" json:"flap,omitempty"`
+	Actions []Action            `yaml:"actions,omitempty" json:"actions,omitempty"`
+	Latency LatencyDistribution `yaml:"latency,omitempty" json:"latency,omitempty"`
+	// Ranges holds further calls that fail in ModeNth, such as calls 3 to 5
+	// of a retry loop. In a spec, nth also takes a list of calls and
+	// ranges, as in nth: [3, 7, "10-12"].
+	Ranges []CallRange `yaml:"ranges,omitempty" json:"ranges,omitempty"`
+	// Every repeats the calls of a ModeNth rule every Every calls, so
+	// {nth: 1, every: 10} fails calls 1, 11, 21 and so on. On its own it
+	// fails every Every-th call.
+	Every int `yaml:"every,omitempty" json:"every,omitempty"`
+	// Error replaces the message of errors returned when the rule fires.
+	// "{key}", "{count}" and "{message}" expand to the key, the call count and
+	// the call site's message.
+	Error string `yaml:"error,omitempty" json:"error,omitempty"`
+	// Code is copied to InjectedError.Code, for callers that branch on an
+	// error code such as "ECONNREFUSED" or "DEADLINE_EXCEEDED".
+	Code string `yaml:"code,omitempty" json:"code,omitempty"`
+	// Status is the HTTP status HTTPMiddleware responds with when the rule
+	// fires; zero means 500.
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+	// Header holds headers HTTPMiddleware sets on the response when the
+	// rule fires, such as Retry-After. Values expand like Error.
+	Header map[string]string `yaml:"header,omitempty" json:"header,omitempty"`
+	// Body replaces the response body HTTPMiddleware writes when the rule
+	// fires. It expands like Error, with "{message}" as the expanded Error,
+	// and is served as JSON if it starts with "{" or "[" and no Content-Type
+	// is set in Header. Expanded values are not escaped.
+	Body string `yaml:"body,omitempty" json:"body,omitempty"`
+	// Instances limits the rule to that fraction of a fleet, from 0 to 1,
+	// picked by a stable hash of each instance's ID (see SetInstanceID), so
+	// an experiment's blast radius stays the same across restarts and
+	// syncs. Zero means every instance.
+	Instances float64 `yaml:"instances,omitempty" json:"instances,omitempty"`
+	// Build limits the rule to binaries whose build matches (see SetBuild),
+	// such as {commit: "3f2a9c*"} for a canary during a progressive rollout.
+	Build Build `yaml:"build,omitempty" json:"build,omitzero"`
+	// Match limits the rule to calls with matching attributes, such as
+	// {header.X-Tenant-ID: acme} for one tenant's requests (see SetMatch).
+	Match map[string]string `yaml:"match,omitempty" json:"match,omitempty"`
+	// OnlyFrom limits the rule to calls reached through a function
+	// matching it, such as "checkout.*" (see SetOnlyFrom).
+	OnlyFrom string `yaml:"only-from,omitempty" json:"only-from,omitempty"`
+	// Labels group the rule with others, such as {team: payments}, for
+	// KeysByLabel and ResetByLabel.
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// Budget caps how often the rule fires within a window (see SetBudget).
+	Budget *Budget `yaml:"budget,omitempty" json:"budget,omitempty"`
+	// Expires is when the rule stops firing; zero means never.
+	Expires time.Time `yaml:"expires,omitempty" json:"expires,omitzero"`
+
+	start  time.Time           // when the mode was set, for flapping
+	when   func(CallInfo) bool // set by InjectWhen
+	caller *regexp.Regexp      // OnlyFrom's matcher, compiled when the rule is set
+}
+
+// UnmarshalYAML lets specs leave out the mode when it follows from the other
+// fields, as in {count: 3, error: "connection refused", status: 503}, and
+// give nth as a list of calls and ranges.
+func (r *Rule) UnmarshalYAML(value *yaml.Node) error {
+	type plain Rule
+	value, nth := splitNth(value)
+	if err := value.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	if nth != nil {
+		ranges, err := decodeRanges(nth)
+		if err != nil {
+			return err
+		}
+		r.Ranges = append(ranges, r.Ranges...)
+	}
+	if r.Mode == ModeNone {
+		switch {
+		case r.Count != 0:
+			r.Mode = ModeFirstN
+		case r.Nth != 0, len(r.Ranges) > 0, r.Every != 0:
+			r.Mode = ModeNth
+		case r.Rate != 0:
+			r.Mode = ModeRate
+		case r.Flap != nil:
+			r.Mode = ModeFlapping
+		case len(r.Actions) > 0:
+			r.Mode = ModeActions
+		}
+	}
+	return nil
+}
+
+// SetRule replaces key's whole configuration with r and restarts its call
+// count. A zero Rule removes key's rule. A rule whose OnlyFrom is an invalid
+// regular expression is rejected, leaving key's configuration unchanged, and
+// logged at Warn with SetLogger. Fault injection is disabled in production
+// environments.
+func (in *Injector) SetRule(key string, r Rule) {
+	if in.isProductionEnvironment() {
+		return
+	}
+	if _, err := compileCaller(r.OnlyFrom); err != nil {
+		if l := in.logger.Load(); l != nil {
+			l.LogAttrs(context.Background(), slog.LevelWarn, "invalid fault rule", slog.String("key", key), slog.String("error", "only-from: "+err.Error()))
+		}
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setRuleLocked(key, r)
+}
+
+// SetRule calls SetRule on the default Injector.
+func SetRule(key string, r Rule) {
+	std.SetRule(key, r)
+}
+
+func (in *Injector) setRuleLocked(key string, r Rule) {
+	delete(in.conflicts, key)
+	if r.empty() {
+		delete(in.rules, key)
+		in.restartCountsLocked(key)
+		in.bumpGenerationLocked()
+		return
+	}
+	now := in.timeNow()
+	r.start = now
+	r.Latency = append(LatencyDistribution(nil), r.Latency...)
+	r.Actions = append([]Action(nil), r.Actions...)
+	r.Ranges = append([]CallRange(nil), r.Ranges...)
+	r.Header = maps.Clone(r.Header)
+	r.Match = maps.Clone(r.Match)
+	r.Labels = maps.Clone(r.Labels)
+	if r.Budget != nil {
+		budget := *r.Budget
+		r.Budget = &budget
+	}
+	if r.Flap != nil {
+		cycle := *r.Flap
+		r.Flap = &cycle
+	}
+	r.caller, _ = compileCaller(r.OnlyFrom) // checked by SetRule and Rule.validate
+	in.rules[key] = r
+	in.registerPatternLocked(key)
+	in.restartCountsLocked(key)
+	in.touchLocked(key, now)
+	in.bumpGenerationLocked()
+}
+
+// SetFailureRate makes each call to key fail with probability rate, between
+// 0 and 1. Fault injection is disabled in production environments.
+func (in *Injector) SetFailureRate(key string, rate float64) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.setMode(ModeRate, in.timeNow())
+		r.Rate = rate
+	})
+}
+
+// SetFailureRate calls SetFailureRate on the default Injector.
+func SetFailureRate(key string, rate float64) {
+	std.SetFailureRate(key, rate)
+}
+
+// Rules returns the configuration of every key with a rule, so the full
+// picture is visible where Status only reports first-N counts.
+func (in *Injector) Rules() map[string]Rule {
+	return rulesOf(in.snapshot())
+}
+
+// Rules calls Rules on the default Injector.
+func Rules() map[string]Rule {
+	return std.Rules()
+}
+
+// HasRule reports whether calls to key are decided by a failure mode, set
+// for the key or a pattern matching it. It is false in production
+// environments. Integrations use it to skip bookkeeping no rule needs.
+func (in *Injector) HasRule(key string) bool {
+	return !in.isProductionEnvironment() && in.snapshot().hasRule(key)
+}
+
+// HasRule calls HasRule on the default Injector.
+func HasRule(key string) bool {
+	return std.HasRule(key)
+}
+
+func rulesOf(s *ruleSnapshot) map[string]Rule {
+	return maps.Clone(s.rules)
+}
+
+// updateRuleLocked applies fn to key's rule, creating it if needed and
+// removing it if fn leaves it empty.
+func (in *Injector) updateRuleLocked(key string, fn func(r *Rule)) {
+	r := in.rules[key]
+	fn(&r)
+	delete(in.conflicts, key)
+	in.bumpGenerationLocked()
+	if r.empty() {
+		delete(in.rules, key)
+		return
+	}
+	in.rules[key] = r
+	in.registerPatternLocked(key)
+	in.touchLocked(key, in.timeNow())
+}
+
+// setMode switches r to mode at now, clearing the settings and expiry of
+// the previous mode. Latency and the error template are kept.
+func (r *Rule) setMode(mode Mode, now time.Time) {
+	r.Mode, r.Count, r.Nth, r.Rate, r.Flap, r.Actions = mode, 0, 0, 0, nil, nil
+	r.Ranges, r.Every = nil, 0
+	r.Expires = time.Time{}
+	r.start = now
+}
+
+// empty reports whether r has no effect. An expiry, fleet fraction, build
+// filter, attribute match, caller limit, labels or budget on their own are
+// kept so they can be set before the rule they apply to.
+func (r Rule) empty() bool {
+	return r.Mode == ModeNone && len(r.Latency) == 0 && r.Error == "" && r.Code == "" && r.Status == 0 &&
+		len(r.Header) == 0 && r.Body == "" && r.Expires.IsZero() && r.Instances == 0 && r.Build == (Build{}) && len(r.Match) == 0 && r.OnlyFrom == "" && r.when == nil &&
+		len(r.Labels) == 0 && r.Budget == nil
+}
+
+func (r Rule) expired(now time.Time) bool {
+	return !r.Expires.IsZero() && !now.Before(r.Expires)
+}
+
+// decide returns whether call number cnt fails and why.
+func (r Rule) decide(cnt int, now time.Time) (bool, Reason) {
+	if r.expired(now) {
+		return false, ReasonExpired
+	}
+	switch r.Mode {
+	case ModeFlapping:
+		if r.unhealthy(now) {
+			return true, ReasonFired
+		}
+		return false, ReasonHealthy
+	case ModeNth:
+		if r.Nth <= 0 && len(r.Ranges) == 0 && r.Every <= 0 {
+			return false, ReasonDisabled
+		}
+		if r.nthCall(cnt) {
+			return true, ReasonFired
+		}
+		return false, ReasonNotNthCall
+	case ModeRate:
+		if r.Rate > 0 && rand.Float64() < r.Rate {
+			return true, ReasonFired
+		}
+		return false, ReasonNotSampled
+	case ModeFirstN:
+		if r.Count <= 0 {
+			return false, ReasonDisabled
+		}
+		if cnt <= r.Count {
+			return true, ReasonFired
+		}
+		return false, ReasonExhausted
+	case ModeActions:
+		a, ok := r.action(cnt)
+		switch {
+		case !ok:
+			return false, ReasonExhausted
+		case !a.Fail:
+			return false, ReasonPassingAction
+		}
+		return true, ReasonFired
+	}
+	return false, ReasonDisabled
+}
+
+func (in *Injector) newInjectedError(key string, count int, message string) *InjectedError {
+	s := in.snapshot()
+	r := s.rules[s.ruleKey(key)]
+	return &InjectedError{Key: key, Message: r.errorMessage(key, count, message), Code: r.Code, Count: count, Timestamp: in.timeNow()}
+}
+
+// errorMessage returns the message for an injected error on key, applying
+// r's error template if it has one.
+func (r Rule) errorMessage(key string, count int, message string) string {
+	if r.Error == "" {
+		return message
+	}
+	return expandTemplate(r.Error, key, count, message)
+}
+
+// expandTemplate replaces "{key}", "{count}" and "{message}" in tmpl.
+func expandTemplate(tmpl, key string, count int, message string) string {
+	return strings.NewReplacer("{key}", key, "{count}", strconv.Itoa(count), "{message}", message).Replace(tmpl)
+}
@@ -0,0 +1,190 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetRuleCombinesBehaviors(t *testing.T) {
+	resetState()
+	SetRule("db", Rule{Mode: ModeNth, Nth: 2, Latency: FixedLatency(time.Millisecond)})
+
+	ctx := context.Background()
+	for i, want := range []bool{false, true, false} {
+		if d, _ := InjectLatency(ctx, "db"); d != time.Millisecond {
+			t.Errorf("Call %d: expected 1ms latency, got %s", i+1, d)
+		}
+		if got := Inject("db"); got != want {
+			t.Errorf("Call %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestSettersKeepOtherSettings(t *testing.T) {
+	resetState()
+	SetLatency("db", FixedLatency(time.Millisecond))
+	SetNthFailure("db", 3)
+	SetFlapping("db", FlapCycle{Period: time.Minute, Unhealthy: time.Second})
+
+	r := Rules()["db"]
+	if r.Mode != ModeFlapping || r.Nth != 0 || len(r.Latency) != 1 {
+		t.Errorf("Expected the flapping mode to replace nth and keep latency, got %+v", r)
+	}
+
+	SetFlapping("db", FlapCycle{})
+	SetLatency("db", nil)
+	if _, ok := Rules()["db"]; ok {
+		t.Errorf("Expected an empty rule to be removed, got %+v", Rules()["db"])
+	}
+}
+
+func TestSetFailureRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		expected bool
+		reason   Reason
+	}{
+		{name: "always", rate: 1, expected: true, reason: ReasonFired},
+		{name: "never", rate: 0, expected: false, reason: ReasonNotSampled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailureRate("flaky", tt.rate)
+			for i := 0; i < 10; i++ {
+				if got := Inject("flaky"); got != tt.expected {
+					t.Fatalf("Call %d: expected %v, got %v", i+1, tt.expected, got)
+				}
+			}
+			if h := History("flaky"); h[0].Reason != tt.reason {
+				t.Errorf("Expected reason %s, got %s", tt.reason, h[0].Reason)
+			}
+		})
+	}
+}
+
+func TestRuleErrorTemplate(t *testing.T) {
+	resetState()
+	SetRule("db", Rule{Mode: ModeFirstN, Count: 1, Error: "{key} call {count}: {message} (ECONNRESET)"})
+
+	err := InjectWithError("db", "insert failed")
+	if !errors.Is(err, ErrInjected) {
+		t.Fatalf("Expected an injected error, got %v", err)
+	}
+	want := "injected failure: db call 1: insert failed (ECONNRESET)"
+	if err.Error() != want {
+		t.Errorf("Expected %q, got %q", want, err.Error())
+	}
+}
+
+func TestSetRuleZeroRemoves(t *testing.T) {
+	resetState()
+	SetRule("db", Rule{Mode: ModeFirstN, Count: 2})
+	SetRule("db", Rule{})
+
+	if _, ok := Rules()["db"]; ok {
+		t.Error("Expected a zero Rule to remove the rule")
+	}
+	if Inject("db") {
+		t.Error("Expected no failure after the rule was removed")
+	}
+	if err := ValidateInvariants(); err != nil {
+		t.Errorf("Expected no violations, got %v", err)
+	}
+}
+
+func TestRulesSpec(t *testing.T) {
+	resetState()
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	content := `rules:
+  db:
+    mode: nth
+    nth: 2
+    latency: [{min: 1ms, max: 1ms, weight: 1}]
+  flaky:
+    mode: rate
+    rate: 0.5
+    error: "connection reset"
+`
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := LoadSpec(spec); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+
+	got := Rules()
+	if got["db"].Mode != ModeNth || got["db"].Nth != 2 || len(got["db"].Latency) != 1 {
+		t.Errorf("Expected db rule from spec, got %+v", got["db"])
+	}
+	if got["flaky"].Rate != 0.5 || got["flaky"].Error != "connection reset" {
+		t.Errorf("Expected flaky rule from spec, got %+v", got["flaky"])
+	}
+}
+
+func TestRuleSpecInfersMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		expected Mode
+	}{
+		{name: "count", rule: "{count: 3}", expected: ModeFirstN},
+		{name: "nth", rule: "{nth: 2}", expected: ModeNth},
+		{name: "rate", rule: "{rate: 0.5}", expected: ModeRate},
+		{name: "flap", rule: "{flap: {period: 1m, unhealthy: 10s}}", expected: ModeFlapping},
+		{name: "explicit mode", rule: "{mode: nth, nth: 2}", expected: ModeNth},
+		{name: "latency only", rule: "{latency: [{min: 1ms, max: 1ms, weight: 1}]}", expected: ModeNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if err := LoadSpecFromBytes([]byte("rules:\n  db: "+tt.rule+"\n"), FormatYAML); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := Rules()["db"].Mode; got != tt.expected {
+				t.Errorf("Expected mode %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRuleErrorCodeAndStatus(t *testing.T) {
+	resetState()
+	spec := `rules:
+  db-connect: {count: 1, error: "connection refused", code: ECONNREFUSED}
+  checkout: {count: 1, error: "{key} unavailable (call {count})", status: 503}
+`
+	if err := LoadSpecFromBytes([]byte(spec), ""); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err := InjectWithError("db-connect", "dial failed")
+	var injected *InjectedError
+	if !errors.As(err, &injected) {
+		t.Fatalf("Expected an InjectedError, got %v", err)
+	}
+	if injected.Message != "connection refused" || injected.Code != "ECONNREFUSED" {
+		t.Errorf("Expected message and code from the spec, got %q and %q", injected.Message, injected.Code)
+	}
+
+	handler := HTTPMiddleware("checkout")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != "checkout unavailable (call 1)" {
+		t.Errorf("Expected the templated body, got %q", body)
+	}
+}
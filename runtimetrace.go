@@ -0,0 +1,59 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	rtrace "runtime/trace"
+)
+
+// RuntimeTraceCategory is the category of the runtime/trace log events and
+// the prefix of the region types emitted for injected faults.
+const RuntimeTraceCategory = "faultinject"
+
+// SetRuntimeTrace makes injected faults visible in execution traces taken
+// with runtime/trace, such as go test -trace: each fault is logged as an
+// event, and delays from InjectLatency run inside a "faultinject.latency"
+// region, so go tool trace shows them next to the goroutines they slowed
+// down. It only costs anything while a trace is being recorded. Like the
+// environment lists, it survives Reset.
+func (in *Injector) SetRuntimeTrace(on bool) {
+	in.runtimeTrace.Store(on)
+}
+
+// SetRuntimeTrace calls SetRuntimeTrace on the default Injector.
+func SetRuntimeTrace(on bool) {
+	std.SetRuntimeTrace(on)
+}
+
+func (in *Injector) tracingRuntime() bool {
+	return in.runtimeTrace.Load() && rtrace.IsEnabled()
+}
+
+// startLatencyRegion starts the region an injected delay runs in, or
+// returns nil when runtime tracing is off.
+func (in *Injector) startLatencyRegion(ctx context.Context) *rtrace.Region {
+	if !in.tracingRuntime() {
+		return nil
+	}
+	return rtrace.StartRegion(ctx, RuntimeTraceCategory+".latency")
+}
+
+// logRuntimeTrace logs f as a runtime/trace event.
+func (in *Injector) logRuntimeTrace(ctx context.Context, f Fault) {
+	if !in.tracingRuntime() {
+		return
+	}
+	msg := f.Key + " " + f.Type
+	if f.Count > 0 {
+		msg += fmt.Sprintf(" call %d", f.Count)
+	}
+	if f.Latency > 0 {
+		msg += " " + f.Latency.String()
+	}
+	rtrace.Log(ctx, RuntimeTraceCategory, msg)
+}
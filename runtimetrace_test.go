@@ -0,0 +1,45 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	rtrace "runtime/trace"
+	"testing"
+	"time"
+)
+
+func TestRuntimeTrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected bool
+	}{
+		{name: "enabled", enabled: true, expected: true},
+		{name: "disabled", enabled: false, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetRuntimeTrace(tt.enabled)
+			defer SetRuntimeTrace(false)
+			SetFailures("rt-db", 1)
+			SetLatency("rt-cache", FixedLatency(time.Millisecond))
+
+			var buf bytes.Buffer
+			if err := rtrace.Start(&buf); err != nil {
+				t.Skipf("Cannot start a trace: %v", err)
+			}
+			InjectWithContext(context.Background(), "rt-db")
+			InjectLatency(context.Background(), "rt-cache")
+			rtrace.Stop()
+
+			for _, want := range []string{"rt-db first-n call 1", "faultinject.latency", "rt-cache latency"} {
+				if got := bytes.Contains(buf.Bytes(), []byte(want)); got != tt.expected {
+					t.Errorf("Expected %q in the trace to be %v, got %v", want, tt.expected, got)
+				}
+			}
+		})
+	}
+}
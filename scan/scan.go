@@ -0,0 +1,362 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scan finds go-fi injection points in Go source without building it.
+//
+// It recognizes calls to the faultinject package whose key argument is a
+// string literal or a package-level string constant.
+package scan
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind distinguishes code that injects faults from code that configures them.
+type Kind string
+
+const (
+	KindInject    Kind = "inject"
+	KindConfigure Kind = "configure"
+)
+
+// Point is one faultinject call with a statically known key.
+type Point struct {
+	Key     string
+	Func    string // faultinject function called, e.g. "InjectWithError"
+	Kind    Kind
+	Package string // directory relative to the scan root, "." for the root
+	File    string // path relative to the scan root
+	Line    int
+	Message string // literal error message, when the call takes one
+	Caller  string // enclosing function or method
+	Test    bool   // whether the call is in a _test.go file
+}
+
+// call describes where a faultinject function takes its key and message.
+type call struct {
+	kind Kind
+	key  int
+	msg  int // -1 when the function takes no message
+}
+
+var funcs = map[string]call{
+	"Inject":                     {KindInject, 0, -1},
+	"InjectWithFn":               {KindInject, 0, -1},
+	"InjectWithError":            {KindInject, 0, 1},
+	"InjectWithErrorf":           {KindInject, 0, 1},
+	"InjectWithContext":          {KindInject, 1, -1},
+	"InjectWithFnContext":        {KindInject, 1, -1},
+	"InjectWithContextError":     {KindInject, 1, 2},
+	"HTTPMiddleware":             {KindInject, 0, -1},
+	"HTTPMiddlewareWithResponse": {KindInject, 0, -1},
+	"HTTPMiddlewareWithStatus":   {KindInject, 0, -1},
+	"WithFaultInjection":         {KindInject, 0, -1},
+	"WithFaultInjectionContext":  {KindInject, 0, -1},
+	"InjectLatency":              {KindInject, 1, -1},
+	"InjectContextCancel":        {KindInject, 1, -1},
+	"UnaryServerInterceptor":     {KindInject, 0, -1},
+	"StreamServerInterceptor":    {KindInject, 0, -1},
+	"UnaryClientInterceptor":     {KindInject, 0, -1},
+	"StreamClientInterceptor":    {KindInject, 0, -1},
+	"Cleanup":                    {KindInject, 0, -1},
+	"DrainMiddleware":            {KindInject, 0, -1},
+	"SignalContext":              {KindInject, 1, -1},
+	"HealthHandler":              {KindInject, 0, -1},
+	"Now":                        {KindInject, 0, -1},
+	"NowWithContext":             {KindInject, 1, -1},
+	"SkewedClock":                {KindInject, 0, -1},
+	"CorruptBytes":               {KindInject, 1, -1},
+	"CorruptString":              {KindInject, 1, -1},
+	"CorruptResponse":            {KindInject, 0, -1},
+	"PayloadTransport":           {KindInject, 0, -1},
+	"SSEMiddleware":              {KindInject, 0, -1},
+	"WrapConn":                   {KindInject, 0, -1},
+	"WrapListener":               {KindInject, 0, -1},
+	"WrapDialer":                 {KindInject, 0, -1},
+	"InjectValue":                {KindInject, 1, -1},
+	"Degrade":                    {KindInject, 1, -1},
+	"InjectMemoryPressure":       {KindInject, 0, -1},
+	"InjectCPUBurn":              {KindInject, 0, -1},
+	"Paginate":                   {KindInject, 0, -1},
+	"IdempotencyTransport":       {KindInject, 0, -1},
+	"IdempotencyMiddleware":      {KindInject, 0, -1},
+	"QuotaTransport":             {KindInject, 0, -1},
+	"SetRoute":                   {KindInject, 1, -1},
+	"Wrap":                       {KindInject, 1, -1},
+	"WrapDriver":                 {KindInject, 1, -1},
+	"WrapConnector":              {KindInject, 1, -1},
+	"NewConsumer":                {KindInject, 0, -1},
+	"WrapConsumer":               {KindInject, 0, -1},
+	"NewMutex":                   {KindInject, 0, -1},
+	"NewCache":                   {KindInject, 0, -1},
+	"Middleware":                 {KindInject, 0, -1},
+	"SetFailures":                {KindConfigure, 0, -1},
+	"SetNthFailure":              {KindConfigure, 0, -1},
+	"SetNthFailures":             {KindConfigure, 0, -1},
+	"SetNthFailureRange":         {KindConfigure, 0, -1},
+	"SetEveryNthFailure":         {KindConfigure, 0, -1},
+	"SetCounter":                 {KindConfigure, 0, -1},
+	"ResetCounter":               {KindConfigure, 0, -1},
+	"SetLatency":                 {KindConfigure, 0, -1},
+	"SetFlapping":                {KindConfigure, 0, -1},
+	"SetFailuresFor":             {KindConfigure, 0, -1},
+	"SetClockSkew":               {KindConfigure, 0, -1},
+	"SetCorruption":              {KindConfigure, 0, -1},
+	"SetNumericEdge":             {KindConfigure, 0, -1},
+	"SetPageFault":               {KindConfigure, 0, -1},
+	"SetIdempotencyFault":        {KindConfigure, 0, -1},
+	"SetQuotaResponse":           {KindConfigure, 0, -1},
+	"SetSSEFault":                {KindConfigure, 0, -1},
+	"SetConnFault":               {KindConfigure, 0, -1},
+	"SetBandwidth":               {KindConfigure, 0, -1},
+	"SetDegradation":             {KindConfigure, 0, -1},
+	"SetResponseFault":           {KindConfigure, 0, -1},
+	"SetRule":                    {KindConfigure, 0, -1},
+	"SetFailureRate":             {KindConfigure, 0, -1},
+	"SetActions":                 {KindConfigure, 0, -1},
+	"SetMatch":                   {KindConfigure, 0, -1},
+	"SetOnlyFrom":                {KindConfigure, 0, -1},
+	"SetLabels":                  {KindConfigure, 0, -1},
+	"SetBudget":                  {KindConfigure, 0, -1},
+	"InjectWhen":                 {KindConfigure, 0, -1},
+}
+
+// importPaths maps go-fi packages with injection functions to their package names.
+var importPaths = map[string]string{
+	"github.com/talinashro/go-fi":             "faultinject",
+	"github.com/talinashro/go-fi/faultinject": "faultinject",
+	"github.com/talinashro/go-fi/awsfi":       "awsfi",
+	"github.com/talinashro/go-fi/cachefi":     "cachefi",
+	"github.com/talinashro/go-fi/chifi":       "chifi",
+	"github.com/talinashro/go-fi/echofi":      "echofi",
+	"github.com/talinashro/go-fi/ginfi":       "ginfi",
+	"github.com/talinashro/go-fi/grpcfi":      "grpcfi",
+	"github.com/talinashro/go-fi/lockfi":      "lockfi",
+	"github.com/talinashro/go-fi/msgfi":       "msgfi",
+	"github.com/talinashro/go-fi/sqlfi":       "sqlfi",
+}
+
+// Dir scans every Go file under root, skipping vendor, testdata and hidden
+// directories. Points are sorted by file and line.
+func Dir(root string) ([]Point, error) {
+	pkgs := make(map[string][]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".go") {
+			dir := filepath.Dir(path)
+			pkgs[dir] = append(pkgs[dir], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	fset := token.NewFileSet()
+	for dir, files := range pkgs {
+		pkg, err := filepath.Rel(root, dir)
+		if err != nil {
+			return nil, err
+		}
+		pkg = filepath.ToSlash(pkg)
+
+		parsed := make([]*ast.File, 0, len(files))
+		for _, path := range files {
+			f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+			if err != nil {
+				return nil, err
+			}
+			parsed = append(parsed, f)
+		}
+
+		consts := stringConsts(parsed)
+		for i, f := range parsed {
+			rel, _ := filepath.Rel(root, files[i])
+			points = append(points, scanFile(fset, f, pkg, filepath.ToSlash(rel), consts)...)
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].File != points[j].File {
+			return points[i].File < points[j].File
+		}
+		return points[i].Line < points[j].Line
+	})
+	return points, nil
+}
+
+// Keys returns the distinct keys of points with the given kind, sorted.
+func Keys(points []Point, kind Kind) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, p := range points {
+		if p.Kind == kind && !seen[p.Key] {
+			seen[p.Key] = true
+			keys = append(keys, p.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringConsts collects package-level string constants across a package's files.
+func stringConsts(files []*ast.File) map[string]string {
+	out := make(map[string]string)
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if i >= len(vs.Values) {
+						break
+					}
+					if s, ok := stringLit(vs.Values[i]); ok {
+						out[name.Name] = s
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+func scanFile(fset *token.FileSet, f *ast.File, pkg, file string, consts map[string]string) []Point {
+	locals := make(map[string]bool)
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		local, ok := importPaths[path]
+		if !ok {
+			continue
+		}
+		if imp.Name != nil {
+			local = imp.Name.Name
+		}
+		if local != "_" && local != "." {
+			locals[local] = true
+		}
+	}
+	if len(locals) == 0 {
+		return nil
+	}
+
+	test := strings.HasSuffix(file, "_test.go")
+	var points []Point
+	for _, decl := range f.Decls {
+		caller := ""
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			caller = fd.Name.Name
+			if fd.Recv != nil && len(fd.Recv.List) == 1 {
+				caller = recvName(fd.Recv.List[0].Type) + "." + caller
+			}
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			ce, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			name, ok := selectorFunc(ce.Fun, locals)
+			if !ok {
+				return true
+			}
+			c, ok := funcs[name]
+			if !ok || c.key >= len(ce.Args) {
+				return true
+			}
+			key, ok := resolve(ce.Args[c.key], consts)
+			if !ok {
+				return true
+			}
+			p := Point{
+				Key:     key,
+				Func:    name,
+				Kind:    c.kind,
+				Package: pkg,
+				File:    file,
+				Line:    fset.Position(ce.Pos()).Line,
+				Caller:  caller,
+				Test:    test,
+			}
+			if c.msg >= 0 && c.msg < len(ce.Args) {
+				p.Message, _ = resolve(ce.Args[c.msg], consts)
+			}
+			points = append(points, p)
+			return true
+		})
+	}
+	return points
+}
+
+// selectorFunc returns Name for calls of the form local.Name(...) and
+// local.Name[T](...) where local is one of locals.
+func selectorFunc(fun ast.Expr, locals map[string]bool) (string, bool) {
+	switch e := fun.(type) {
+	case *ast.IndexExpr:
+		fun = e.X
+	case *ast.IndexListExpr:
+		fun = e.X
+	}
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok || !locals[id.Name] {
+		return "", false
+	}
+	return sel.Sel.Name, true
+}
+
+func recvName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return recvName(e.X)
+	case *ast.IndexExpr:
+		return recvName(e.X)
+	case *ast.IndexListExpr:
+		return recvName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+func resolve(expr ast.Expr, consts map[string]string) (string, bool) {
+	if s, ok := stringLit(expr); ok {
+		return s, true
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		s, ok := consts[id.Name]
+		return s, ok
+	}
+	return "", false
+}
+
+func stringLit(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
@@ -0,0 +1,53 @@
+package scan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDir(t *testing.T) {
+	points, err := Dir("testdata/checkout")
+	if err != nil {
+		t.Fatalf("Dir returned error: %v", err)
+	}
+
+	expected := []Point{
+		{Key: "payment-charge", Func: "InjectWithContext", Kind: KindInject, Package: ".", File: "main.go", Line: 13, Caller: "charge"},
+		{Key: "payment-refund", Func: "InjectWithError", Kind: KindInject, Package: ".", File: "main.go", Line: 16, Message: "card declined", Caller: "charge"},
+		{Key: "payment-charge", Func: "SetFailures", Kind: KindConfigure, Package: ".", File: "main_test.go", Line: 11, Caller: "TestChargeFails", Test: true},
+		{Key: "rpc-charge", Func: "UnaryServerInterceptor", Kind: KindInject, Package: "rpc", File: "rpc/server.go", Line: 9, Caller: "NewServer"},
+		{Key: "store-save", Func: "InjectWithContextError", Kind: KindInject, Package: "store", File: "store/store.go", Line: 8, Message: "disk full", Caller: "Store.Save"},
+		{Key: "store-wrap", Func: "WithFaultInjection", Kind: KindInject, Package: "store", File: "store/store.go", Line: 12, Caller: "Wrap"},
+	}
+
+	if len(points) != len(expected) {
+		t.Fatalf("Expected %d points, got %d: %+v", len(expected), len(points), points)
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(points[i], expected[i]) {
+			t.Errorf("Point %d:\n  expected %+v\n  got      %+v", i, expected[i], points[i])
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	points, err := Dir("testdata/checkout")
+	if err != nil {
+		t.Fatalf("Dir returned error: %v", err)
+	}
+
+	got := Keys(points, KindInject)
+	want := []string{"payment-charge", "payment-refund", "rpc-charge", "store-save", "store-wrap"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := Keys(points, KindConfigure); !reflect.DeepEqual(got, []string{"payment-charge"}) {
+		t.Errorf("Expected [payment-charge], got %v", got)
+	}
+}
+
+func TestDirMissing(t *testing.T) {
+	if _, err := Dir("testdata/does-not-exist"); err == nil {
+		t.Error("Expected error for missing directory")
+	}
+}
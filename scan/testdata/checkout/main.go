@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+const paymentKey = "payment-charge"
+
+func charge(ctx context.Context) error {
+	if faultinject.InjectWithContext(ctx, paymentKey) {
+		return fmt.Errorf("injected")
+	}
+	return faultinject.InjectWithError("payment-refund", "card declined")
+}
+
+func dynamic(key string) bool {
+	return faultinject.Inject(key)
+}
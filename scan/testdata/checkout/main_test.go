@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+func TestChargeFails(t *testing.T) {
+	faultinject.SetFailures(paymentKey, 1)
+	if charge(context.Background()) == nil {
+		t.Fatal("expected failure")
+	}
+}
@@ -0,0 +1,10 @@
+package rpc
+
+import (
+	"github.com/talinashro/go-fi/grpcfi"
+	"google.golang.org/grpc"
+)
+
+func NewServer() *grpc.Server {
+	return grpc.NewServer(grpc.UnaryInterceptor(grpcfi.UnaryServerInterceptor("rpc-charge")))
+}
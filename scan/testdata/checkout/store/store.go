@@ -0,0 +1,13 @@
+package store
+
+import fi "github.com/talinashro/go-fi/faultinject"
+
+type Store struct{}
+
+func (s *Store) Save() error {
+	return fi.InjectWithContextError(nil, "store-save", "disk full")
+}
+
+func Wrap(fn func(int) error) fi.Decorator[int] {
+	return fi.WithFaultInjection[int]("store-wrap", fn)
+}
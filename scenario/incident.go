@@ -0,0 +1,132 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Event is an error burst seen on one dependency during an incident. A
+// structured log line with a time and a dependency counts as one error.
+type Event struct {
+	Time       time.Time `json:"time"`
+	Dependency string    `json:"dependency"`
+	Errors     int       `json:"errors,omitempty"`
+}
+
+// Incident is the timeline of a past incident.
+type Incident struct {
+	Name   string  `json:"name"`
+	Events []Event `json:"events"`
+}
+
+// ImportOptions controls how an incident timeline becomes a scenario.
+type ImportOptions struct {
+	// Keys maps dependency names to fault keys. Unmapped dependencies use
+	// their name as the key.
+	Keys map[string]string
+	// Window merges events on the same dependency that are at most this far
+	// apart into one burst. Zero keeps every event separate.
+	Window time.Duration
+	// Speed compresses the timeline, e.g. 60 replays an hour in a minute.
+	// Zero means real time.
+	Speed float64
+}
+
+// ReadIncident reads either an Incident object or a stream of Event objects,
+// such as JSON lines exported from a log store.
+func ReadIncident(r io.Reader) (Incident, error) {
+	var inc Incident
+	dec := json.NewDecoder(r)
+	for {
+		var v struct {
+			Incident
+			Event
+		}
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Incident{}, fmt.Errorf("incident: %w", err)
+		}
+		if v.Name != "" {
+			inc.Name = v.Name
+		}
+		inc.Events = append(inc.Events, v.Incident.Events...)
+		if v.Dependency != "" {
+			inc.Events = append(inc.Events, v.Event)
+		}
+	}
+	return inc, nil
+}
+
+// FromIncident converts an incident timeline into a scenario that activates
+// each burst's error count at its offset from the first event.
+func FromIncident(inc Incident, opts ImportOptions) (Scenario, error) {
+	if len(inc.Events) == 0 {
+		return Scenario{}, fmt.Errorf("incident has no events")
+	}
+	events := append([]Event(nil), inc.Events...)
+	for i, ev := range events {
+		if ev.Dependency == "" {
+			return Scenario{}, fmt.Errorf("event %d has no dependency", i)
+		}
+		if ev.Time.IsZero() {
+			return Scenario{}, fmt.Errorf("event %d has no time", i)
+		}
+		if ev.Errors <= 0 {
+			events[i].Errors = 1
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+
+	type burst struct {
+		start, last time.Time
+		errors      int
+	}
+	open := make(map[string]*burst)
+	var bursts []struct {
+		key string
+		*burst
+	}
+	for _, ev := range events {
+		key := ev.Dependency
+		if k, ok := opts.Keys[key]; ok {
+			key = k
+		}
+		if b := open[key]; b != nil && opts.Window > 0 && ev.Time.Sub(b.last) <= opts.Window {
+			b.last = ev.Time
+			b.errors += ev.Errors
+			continue
+		}
+		b := &burst{start: ev.Time, last: ev.Time, errors: ev.Errors}
+		open[key] = b
+		bursts = append(bursts, struct {
+			key string
+			*burst
+		}{key, b})
+	}
+
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	origin := events[0].Time
+	s := Scenario{Name: inc.Name}
+	for _, b := range bursts {
+		at := time.Duration(float64(b.start.Sub(origin)) / speed).Round(time.Millisecond)
+		if n := len(s.Steps); n > 0 && s.Steps[n-1].At == at {
+			s.Steps[n-1].Failures[b.key] += b.errors
+			continue
+		}
+		s.Steps = append(s.Steps, Step{At: at, Failures: map[string]int{b.key: b.errors}})
+	}
+	return s, nil
+}
@@ -0,0 +1,100 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const timeline = `{
+  "name": "payments outage",
+  "events": [
+    {"time": "2026-09-12T14:00:00Z", "dependency": "payments", "errors": 40},
+    {"time": "2026-09-12T14:00:20Z", "dependency": "payments", "errors": 10},
+    {"time": "2026-09-12T14:00:20Z", "dependency": "db", "errors": 5},
+    {"time": "2026-09-12T14:02:00Z", "dependency": "payments", "errors": 7}
+  ]
+}`
+
+const logLines = `{"time": "2026-09-12T14:00:00Z", "dependency": "db", "level": "error"}
+{"time": "2026-09-12T14:00:01Z", "dependency": "db", "level": "error"}
+{"time": "2026-09-12T14:00:30Z", "dependency": "db", "level": "error"}
+`
+
+func TestFromIncident(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     ImportOptions
+		expected []Step
+	}{
+		{
+			name:  "separate bursts",
+			input: timeline,
+			expected: []Step{
+				{At: 0, Failures: map[string]int{"payments": 40}},
+				{At: 20 * time.Second, Failures: map[string]int{"payments": 10, "db": 5}},
+				{At: 2 * time.Minute, Failures: map[string]int{"payments": 7}},
+			},
+		},
+		{
+			name:  "merged window with key mapping and speed",
+			input: timeline,
+			opts:  ImportOptions{Keys: map[string]string{"payments": "payments-call"}, Window: 30 * time.Second, Speed: 10},
+			expected: []Step{
+				{At: 0, Failures: map[string]int{"payments-call": 50}},
+				{At: 2 * time.Second, Failures: map[string]int{"db": 5}},
+				{At: 12 * time.Second, Failures: map[string]int{"payments-call": 7}},
+			},
+		},
+		{
+			name:  "log lines count one error each",
+			input: logLines,
+			opts:  ImportOptions{Window: 5 * time.Second},
+			expected: []Step{
+				{At: 0, Failures: map[string]int{"db": 2}},
+				{At: 30 * time.Second, Failures: map[string]int{"db": 1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inc, err := ReadIncident(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadIncident returned error: %v", err)
+			}
+			s, err := FromIncident(inc, tt.opts)
+			if err != nil {
+				t.Fatalf("FromIncident returned error: %v", err)
+			}
+			if !reflect.DeepEqual(s.Steps, tt.expected) {
+				t.Errorf("Expected steps %+v, got %+v", tt.expected, s.Steps)
+			}
+		})
+	}
+}
+
+func TestFromIncidentErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "no events", input: `{"name": "empty"}`},
+		{name: "missing time", input: `{"dependency": "db"}`},
+		{name: "malformed", input: `{"events": [`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inc, err := ReadIncident(strings.NewReader(tt.input))
+			if err == nil {
+				_, err = FromIncident(inc, ImportOptions{})
+			}
+			if err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
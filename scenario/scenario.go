@@ -0,0 +1,173 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scenario replays timed sequences of fault activations, either
+// against the in-process injector or a remote control server.
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// Step activates faults at an offset from the start of the scenario. A step
+// that resets does so before applying its own faults.
+type Step struct {
+	At        time.Duration                              `yaml:"at" json:"at"`
+	Reset     bool                                       `yaml:"reset,omitempty" json:"reset,omitempty"`
+	Failures  map[string]int                             `yaml:"failures,omitempty" json:"failures,omitempty"`
+	Latencies map[string]faultinject.LatencyDistribution `yaml:"latencies,omitempty" json:"latencies,omitempty"`
+}
+
+// Scenario is a named sequence of steps.
+type Scenario struct {
+	Name  string `yaml:"name,omitempty" json:"name,omitempty"`
+	Steps []Step `yaml:"steps" json:"steps"`
+}
+
+// Target applies fault activations. *client.Client satisfies it.
+type Target interface {
+	Set(key string, count int) error
+}
+
+// Resetter is implemented by targets that can run steps with Reset set.
+// *client.Client satisfies it.
+type Resetter interface {
+	Reset() error
+}
+
+// LatencySetter is implemented by targets that can run steps with Latencies.
+type LatencySetter interface {
+	SetLatency(key string, dist faultinject.LatencyDistribution) error
+}
+
+// Local applies steps to the injector of the current process.
+var Local Target = localTarget{}
+
+type localTarget struct{}
+
+func (localTarget) Set(key string, count int) error {
+	faultinject.SetFailures(key, count)
+	return nil
+}
+
+func (localTarget) Reset() error {
+	faultinject.Reset()
+	return nil
+}
+
+func (localTarget) SetLatency(key string, dist faultinject.LatencyDistribution) error {
+	faultinject.SetLatency(key, dist)
+	return nil
+}
+
+// Load reads a scenario from a YAML file.
+func Load(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a YAML scenario. Step offsets are durations such as "30s".
+func Parse(data []byte) (Scenario, error) {
+	var s Scenario
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return Scenario{}, fmt.Errorf("scenario: %w", err)
+	}
+	return s, nil
+}
+
+// Run applies the steps of s to t in order of their offsets, waiting until
+// each step is due. It returns early with ctx's error if ctx is done.
+func Run(ctx context.Context, s Scenario, t Target) error {
+	return RunWithClock(ctx, s, t, nil)
+}
+
+// RunWithClock is Run with the steps' offsets measured on clk, such as a
+// faultinject.ManualClock, so tests of a schedule need not wait for it. A
+// nil clk is the system clock.
+func RunWithClock(ctx context.Context, s Scenario, t Target, clk faultinject.SleepClock) error {
+	if clk == nil {
+		clk = systemClock{}
+	}
+	steps := append([]Step(nil), s.Steps...)
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].At < steps[j].At })
+
+	start := clk.Now()
+	for _, step := range steps {
+		if err := clk.Sleep(ctx, start.Add(step.At).Sub(clk.Now())); err != nil {
+			return err
+		}
+		if err := apply(t, step); err != nil {
+			return fmt.Errorf("step at %s: %w", step.At, err)
+		}
+	}
+	return nil
+}
+
+func apply(t Target, step Step) error {
+	if step.Reset {
+		r, ok := t.(Resetter)
+		if !ok {
+			return errors.New("target cannot reset")
+		}
+		if err := r.Reset(); err != nil {
+			return err
+		}
+	}
+	for _, key := range sortedKeys(step.Failures) {
+		if err := t.Set(key, step.Failures[key]); err != nil {
+			return err
+		}
+	}
+	if len(step.Latencies) > 0 {
+		ls, ok := t.(LatencySetter)
+		if !ok {
+			return errors.New("target cannot set latencies")
+		}
+		for _, key := range sortedKeys(step.Latencies) {
+			if err := ls.SetLatency(key, step.Latencies[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// systemClock is the real time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
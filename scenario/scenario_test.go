@@ -0,0 +1,182 @@
+//go:build faultinject
+
+package scenario
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+type recorder struct {
+	calls []string
+	err   error
+}
+
+func (r *recorder) Set(key string, count int) error {
+	r.calls = append(r.calls, key)
+	return r.err
+}
+
+func TestRun(t *testing.T) {
+	s := Scenario{Steps: []Step{
+		{At: 20 * time.Millisecond, Failures: map[string]int{"api-call": 1}},
+		{At: 0, Failures: map[string]int{"db-connect": 3, "cache-get": 2}},
+	}}
+
+	rec := &recorder{}
+	start := time.Now()
+	if err := Run(context.Background(), s, rec); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	expected := []string{"cache-get", "db-connect", "api-call"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, rec.calls)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Expected Run to wait for the last step, took %s", elapsed)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	s := Scenario{Steps: []Step{
+		{At: 0, Failures: map[string]int{"db-connect": 1}},
+		{At: time.Hour, Failures: map[string]int{"api-call": 1}},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	rec := &recorder{}
+	if err := Run(ctx, s, rec); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if len(rec.calls) != 1 {
+		t.Errorf("Expected only the first step to apply, got %v", rec.calls)
+	}
+}
+
+func TestRunTargetError(t *testing.T) {
+	s := Scenario{Steps: []Step{{Failures: map[string]int{"db-connect": 1}}}}
+	if err := Run(context.Background(), s, &recorder{err: errors.New("unreachable")}); err == nil {
+		t.Error("Expected target error to be returned")
+	}
+}
+
+func TestRunLocal(t *testing.T) {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+	defer faultinject.Reset()
+
+	s := Scenario{Steps: []Step{{Failures: map[string]int{"db-connect": 2}}}}
+	if err := Run(context.Background(), s, Local); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if got := faultinject.Status()["db-connect"]; got != 2 {
+		t.Errorf("Expected 2 remaining failures, got %d", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := []byte(`name: db-outage
+steps:
+  - at: 0s
+    failures:
+      db-connect: 3
+  - at: 30s
+    latencies:
+      api-call:
+        - {min: 100ms, max: 200ms}
+  - at: 1m
+    reset: true
+`)
+	s, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if s.Name != "db-outage" || len(s.Steps) != 3 {
+		t.Fatalf("Expected 3 steps of db-outage, got %+v", s)
+	}
+	if s.Steps[0].Failures["db-connect"] != 3 {
+		t.Errorf("Expected 3 db-connect failures, got %v", s.Steps[0].Failures)
+	}
+	if s.Steps[1].At != 30*time.Second || len(s.Steps[1].Latencies["api-call"]) != 1 {
+		t.Errorf("Expected api-call latency at 30s, got %+v", s.Steps[1])
+	}
+	if s.Steps[2].At != time.Minute || !s.Steps[2].Reset {
+		t.Errorf("Expected a reset at 1m, got %+v", s.Steps[2])
+	}
+}
+
+func TestRunUnsupportedStep(t *testing.T) {
+	tests := []struct {
+		name string
+		step Step
+	}{
+		{name: "reset", step: Step{Reset: true}},
+		{name: "latency", step: Step{Latencies: map[string]faultinject.LatencyDistribution{"api-call": faultinject.FixedLatency(time.Second)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Run(context.Background(), Scenario{Steps: []Step{tt.step}}, &recorder{}); err == nil {
+				t.Error("Expected an error for a target without support")
+			}
+		})
+	}
+}
+
+func TestRunLocalLatencyAndReset(t *testing.T) {
+	os.Setenv("ENVIRONMENT", "development")
+	faultinject.Reset()
+	defer faultinject.Reset()
+
+	s := Scenario{Steps: []Step{
+		{At: 0, Failures: map[string]int{"db-connect": 3}},
+		{At: 0, Latencies: map[string]faultinject.LatencyDistribution{"api-call": faultinject.FixedLatency(time.Second)}},
+	}}
+	if err := Run(context.Background(), s, Local); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(faultinject.Rules()["api-call"].Latency) != 1 {
+		t.Errorf("Expected latency on api-call, got %+v", faultinject.Rules()["api-call"])
+	}
+
+	if err := Run(context.Background(), Scenario{Steps: []Step{{Reset: true}}}, Local); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(faultinject.Rules()) != 0 {
+		t.Errorf("Expected no rules after reset, got %v", faultinject.Rules())
+	}
+}
+
+func TestRunWithClock(t *testing.T) {
+	s := Scenario{Steps: []Step{
+		{At: 0, Failures: map[string]int{"db-connect": 1}},
+		{At: time.Hour, Failures: map[string]int{"api-call": 1}},
+	}}
+	clk := faultinject.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	rec := &recorder{}
+	done := make(chan error, 1)
+	go func() { done <- RunWithClock(context.Background(), s, rec, clk) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := clk.WaitForSleepers(ctx, 1); err != nil {
+		t.Fatalf("Expected Run to wait for the second step, got %v", err)
+	}
+	if expected := []string{"db-connect"}; !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected calls %v before the hour passes, got %v", expected, rec.calls)
+	}
+	clk.Advance(time.Hour)
+	if err := <-done; err != nil {
+		t.Fatalf("RunWithClock returned error: %v", err)
+	}
+	if expected := []string{"db-connect", "api-call"}; !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, rec.calls)
+	}
+}
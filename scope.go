@@ -0,0 +1,174 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Scope is a view of an Injector for a library that ships its own injection
+// points. Every key passed to it is prefixed with its prefix, and Status,
+// Rules and Reset only see keys under the prefix, reported without it, so
+// the library neither collides with nor can clear the host application's
+// faults. The host configures the library's keys by their full names, such
+// as "s3client.put-object" in a spec.
+type Scope struct {
+	in     *Injector
+	prefix string
+}
+
+// Scoped returns a Scope of in whose keys start with prefix. Use a prefix
+// ending in a separator, such as "s3client.", so that scopes do not overlap.
+func (in *Injector) Scoped(prefix string) *Scope {
+	return &Scope{in: in, prefix: prefix}
+}
+
+// Scoped calls Scoped on the default Injector.
+func Scoped(prefix string) *Scope {
+	return std.Scoped(prefix)
+}
+
+// Scoped returns a Scope nested in sc, whose keys start with sc's prefix
+// followed by prefix.
+func (sc *Scope) Scoped(prefix string) *Scope {
+	return sc.in.Scoped(sc.prefix + prefix)
+}
+
+// Prefix returns the prefix of sc's keys.
+func (sc *Scope) Prefix() string {
+	return sc.prefix
+}
+
+// Key returns the full name of sc's key, as the host configures it.
+func (sc *Scope) Key(key string) string {
+	return sc.prefix + key
+}
+
+// Inject calls Inject for sc's key.
+func (sc *Scope) Inject(key string, opts ...InjectOption) bool {
+	return sc.in.Inject(sc.Key(key), opts...)
+}
+
+// InjectWithContext calls InjectWithContext for sc's key.
+func (sc *Scope) InjectWithContext(ctx context.Context, key string, opts ...InjectOption) bool {
+	return sc.in.InjectWithContext(ctx, sc.Key(key), opts...)
+}
+
+// InjectWithError calls InjectWithError for sc's key.
+func (sc *Scope) InjectWithError(key string, message string) error {
+	return sc.in.InjectWithError(sc.Key(key), message)
+}
+
+// InjectWithContextError calls InjectWithContextError for sc's key.
+func (sc *Scope) InjectWithContextError(ctx context.Context, key string, message string) error {
+	return sc.in.InjectWithContextError(ctx, sc.Key(key), message)
+}
+
+// InjectLatency calls InjectLatency for sc's key.
+func (sc *Scope) InjectLatency(ctx context.Context, key string) (time.Duration, error) {
+	return sc.in.InjectLatency(ctx, sc.Key(key))
+}
+
+// HTTPMiddleware calls HTTPMiddleware for sc's key.
+func (sc *Scope) HTTPMiddleware(key string, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	return sc.in.HTTPMiddleware(sc.Key(key), opts...)
+}
+
+// SetFailures calls SetFailures for sc's key.
+func (sc *Scope) SetFailures(key string, count int) {
+	sc.in.SetFailures(sc.Key(key), count)
+}
+
+// SetNthFailure calls SetNthFailure for sc's key.
+func (sc *Scope) SetNthFailure(key string, nth int) {
+	sc.in.SetNthFailure(sc.Key(key), nth)
+}
+
+// SetFailureRate calls SetFailureRate for sc's key.
+func (sc *Scope) SetFailureRate(key string, rate float64) {
+	sc.in.SetFailureRate(sc.Key(key), rate)
+}
+
+// SetLatency calls SetLatency for sc's key.
+func (sc *Scope) SetLatency(key string, dist LatencyDistribution) {
+	sc.in.SetLatency(sc.Key(key), dist)
+}
+
+// SetRule calls SetRule for sc's key.
+func (sc *Scope) SetRule(key string, r Rule) {
+	sc.in.SetRule(sc.Key(key), r)
+}
+
+// Status is Status for the keys under sc's prefix, without the prefix.
+func (sc *Scope) Status() map[string]int {
+	return scopedMap(sc.prefix, sc.in.Status())
+}
+
+// FullStatus is FullStatus for the keys under sc's prefix, without the
+// prefix.
+func (sc *Scope) FullStatus() map[string]KeyStatus {
+	return scopedMap(sc.prefix, sc.in.FullStatus())
+}
+
+// Rules is Rules for the keys under sc's prefix, without the prefix.
+func (sc *Scope) Rules() map[string]Rule {
+	return scopedMap(sc.prefix, sc.in.Rules())
+}
+
+// Reset removes every rule, call count and other piece of state held for the
+// keys under sc's prefix, leaving other keys alone.
+func (sc *Scope) Reset() {
+	in := sc.in
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	for _, key := range in.keysLocked() {
+		if strings.HasPrefix(key, sc.prefix) {
+			in.deleteKeyLocked(key)
+		}
+	}
+	in.bumpGenerationLocked()
+}
+
+// keysLocked returns every key state is held for, possibly repeated. mu and
+// callsMu must be held.
+func (in *Injector) keysLocked() []string {
+	var keys []string
+	keys = appendKeys(keys, in.rules)
+	keys = appendKeys(keys, in.counters)
+	keys = appendKeys(keys, in.selectors)
+	keys = appendKeys(keys, in.tenants)
+	keys = appendKeys(keys, in.excludeSynthetic)
+	keys = appendKeys(keys, in.history)
+	keys = appendKeys(keys, in.lastSeen)
+	keys = appendKeys(keys, in.skews)
+	keys = appendKeys(keys, in.corruptions)
+	keys = appendKeys(keys, in.numericEdges)
+	keys = appendKeys(keys, in.pageFaults)
+	keys = appendKeys(keys, in.idempotencyFaults)
+	keys = appendKeys(keys, in.quotaResponses)
+	keys = appendKeys(keys, in.sseFaults)
+	keys = appendKeys(keys, in.degradations)
+	keys = appendKeys(keys, in.responseFaults)
+	keys = appendKeys(keys, in.connFaults)
+	keys = appendKeys(keys, in.bandwidths)
+	return appendKeys(keys, in.conflicts)
+}
+
+// scopedMap returns the entries of m under prefix, without the prefix.
+func scopedMap[V any](prefix string, m map[string]V) map[string]V {
+	out := make(map[string]V)
+	for k, v := range m {
+		if rest, ok := strings.CutPrefix(k, prefix); ok {
+			out[rest] = v
+		}
+	}
+	return out
+}
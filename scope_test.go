@@ -0,0 +1,90 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestScope(t *testing.T) {
+	resetState()
+	lib := Scoped("s3client.")
+	SetFailures("put-object", 1)
+	lib.SetFailures("put-object", 2)
+
+	if !lib.Inject("put-object") {
+		t.Error("Expected the library's first call to fire")
+	}
+	if lib.InjectWithError("put-object", "SlowDown") == nil {
+		t.Error("Expected the library's second call to fire")
+	}
+	if lib.InjectWithContext(context.Background(), "put-object") {
+		t.Error("Expected the library's rule to be used up")
+	}
+	if !Inject("put-object") {
+		t.Error("Expected the host's rule to be left alone")
+	}
+
+	if got := Status()["s3client.put-object"]; got != 0 {
+		t.Errorf("Expected the host to see the library's key in full, got %d remaining", got)
+	}
+	expected := map[string]int{"put-object": 0}
+	if got := lib.Status(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := lib.FullStatus()["put-object"].Calls; got != 3 {
+		t.Errorf("Expected 3 calls, got %d", got)
+	}
+	if lib.Key("get-object") != "s3client.get-object" || lib.Prefix() != "s3client." {
+		t.Errorf("Expected keys prefixed with s3client., got %q", lib.Key("get-object"))
+	}
+}
+
+func TestScopeReset(t *testing.T) {
+	resetState()
+	lib := Scoped("cache.")
+	SetFailures("db", 3)
+	SetFailures("cachefront", 3)
+	lib.SetFailures("get", 3)
+	lib.SetRule("set-*", Rule{Mode: ModeRate, Rate: 1})
+	lib.Inject("get")
+	lib.Inject("set-user")
+	SetTenants("cache.get", "acme")
+
+	lib.Reset()
+	if got := lib.Rules(); len(got) != 0 {
+		t.Errorf("Expected no rules in the scope, got %v", got)
+	}
+	if got := lib.FullStatus(); len(got) != 0 {
+		t.Errorf("Expected no state in the scope, got %v", got)
+	}
+	if lib.Inject("set-user") {
+		t.Error("Expected the scope's pattern rule to be removed")
+	}
+	if Status()["db"] != 3 || Status()["cachefront"] != 3 {
+		t.Errorf("Expected the host's rules to be kept, got %v", Status())
+	}
+	if len(Tenants()) != 0 {
+		t.Errorf("Expected the scope's tenants to be removed, got %v", Tenants())
+	}
+}
+
+func TestScopeNested(t *testing.T) {
+	resetState()
+	inner := Scoped("lib.").Scoped("db.")
+	SetRule("lib.db.query", Rule{Mode: ModeFirstN, Count: 1, Status: http.StatusServiceUnavailable})
+
+	handler := inner.HTTPMiddleware("query")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", rec.Code)
+	}
+	if _, ok := Scoped("lib.").Rules()["db.query"]; !ok {
+		t.Error("Expected the outer scope to see the nested key")
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"time"
+)
+
+// Attributes are per-call values that selectors match against, such as
+// request headers or a tenant ID.
+type Attributes map[string]string
+
+type attributesKey struct{}
+
+// WithAttributes returns a copy of ctx carrying attrs merged over any attributes already in ctx.
+func WithAttributes(ctx context.Context, attrs Attributes) context.Context {
+	merged := make(Attributes)
+	for k, v := range AttributesFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, attributesKey{}, merged)
+}
+
+// AttributesFromContext returns the attributes carried by ctx, or nil.
+func AttributesFromContext(ctx context.Context) Attributes {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attributesKey{}).(Attributes)
+	return attrs
+}
+
+// Selector decides whether a call to key is targeted by its rule.
+type Selector interface {
+	Select(ctx context.Context, key string) bool
+}
+
+// SelectorFunc adapts a function to the Selector interface.
+type SelectorFunc func(ctx context.Context, key string) bool
+
+// Select implements Selector.
+func (f SelectorFunc) Select(ctx context.Context, key string) bool {
+	return f(ctx, key)
+}
+
+// timeNow is swapped in tests.
+var timeNow = time.Now
+
+// RegisterSelector makes s available to rules under name. Registering a nil
+// selector removes it.
+func (in *Injector) RegisterSelector(name string, s Selector) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.mu.dirty = true
+	if s == nil {
+		delete(in.selectorRegistry, name)
+		return
+	}
+	in.selectorRegistry[name] = s
+}
+
+// RegisterSelector calls RegisterSelector on the default Injector.
+func RegisterSelector(name string, s Selector) {
+	std.RegisterSelector(name, s)
+}
+
+// SetSelectors restricts key to calls matched by every named selector.
+// Calls that are not selected neither fail nor count towards the rule.
+// Passing no names removes the restriction.
+func (in *Injector) SetSelectors(key string, names ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setSelectorsLocked(key, names)
+}
+
+func (in *Injector) setSelectorsLocked(key string, names []string) {
+	in.bumpGenerationLocked()
+	if len(names) == 0 {
+		delete(in.selectors, key)
+		return
+	}
+	in.selectors[key] = append([]string(nil), names...)
+}
+
+// SetSelectors calls SetSelectors on the default Injector.
+func SetSelectors(key string, names ...string) {
+	std.SetSelectors(key, names...)
+}
+
+// selected reports whether every selector configured for key matches ctx.
+// Selectors run without holding mu so they may call back into the package.
+func (in *Injector) selected(ctx context.Context, key string) bool {
+	snap := in.snapshot()
+	names := snap.selectors[snap.ruleKey(key)]
+	resolved := make([]Selector, 0, len(names))
+	for _, name := range names {
+		s, ok := snap.selectorRegistry[name]
+		if !ok {
+			return false // unknown selectors never match
+		}
+		resolved = append(resolved, s)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, s := range resolved {
+		if !s.Select(ctx, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// AttributeSelector matches calls whose attribute attr equals one of values.
+// With no values it matches whenever the attribute is present.
+func AttributeSelector(attr string, values ...string) Selector {
+	return SelectorFunc(func(ctx context.Context, _ string) bool {
+		v, ok := AttributesFromContext(ctx)[attr]
+		if !ok {
+			return false
+		}
+		if len(values) == 0 {
+			return true
+		}
+		for _, want := range values {
+			if v == want {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// HeaderSelector matches HTTP requests whose header equals one of values.
+// It relies on the attributes HTTPMiddleware adds to the request context.
+func HeaderSelector(header string, values ...string) Selector {
+	return AttributeSelector(headerAttribute(header), values...)
+}
+
+// TenantSelector matches calls made on behalf of one of tenants.
+func TenantSelector(tenants ...string) Selector {
+	return AttributeSelector("tenant", tenants...)
+}
+
+// PercentSelector matches a stable percent (0-100) of the values of attr, such
+// as user IDs. A given value is always either in or out for a given key.
+func PercentSelector(attr string, percent float64) Selector {
+	return SelectorFunc(func(ctx context.Context, key string) bool {
+		v, ok := AttributesFromContext(ctx)[attr]
+		if !ok {
+			return false
+		}
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(v))
+		return float64(h.Sum32()%10000) < percent*100
+	})
+}
+
+// TimeOfDaySelector matches calls made between start and end, given as
+// offsets from local midnight. Windows that wrap past midnight are allowed.
+func TimeOfDaySelector(start, end time.Duration) Selector {
+	return SelectorFunc(func(context.Context, string) bool {
+		now := timeNow()
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		off := now.Sub(midnight)
+		if start <= end {
+			return off >= start && off < end
+		}
+		return off >= start || off < end
+	})
+}
+
+func headerAttribute(name string) string {
+	return "header." + strings.ToLower(name)
+}
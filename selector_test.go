@@ -0,0 +1,190 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSelectors(t *testing.T) {
+	resetState()
+
+	RegisterSelector("acme", TenantSelector("acme", "globex"))
+	RegisterSelector("canary", HeaderSelector("X-Canary", "true"))
+	defer RegisterSelector("acme", nil)
+	defer RegisterSelector("canary", nil)
+
+	acme := WithAttributes(context.Background(), Attributes{"tenant": "acme"})
+	initech := WithAttributes(context.Background(), Attributes{"tenant": "initech"})
+	acmeCanary := WithAttributes(acme, Attributes{"header.x-canary": "true"})
+
+	tests := []struct {
+		name     string
+		names    []string
+		ctx      context.Context
+		expected bool
+	}{
+		{name: "no selectors", ctx: context.Background(), expected: true},
+		{name: "tenant matches", names: []string{"acme"}, ctx: acme, expected: true},
+		{name: "tenant does not match", names: []string{"acme"}, ctx: initech, expected: false},
+		{name: "missing attributes", names: []string{"acme"}, ctx: context.Background(), expected: false},
+		{name: "all selectors must match", names: []string{"acme", "canary"}, ctx: acme, expected: false},
+		{name: "all selectors match", names: []string{"acme", "canary"}, ctx: acmeCanary, expected: true},
+		{name: "unknown selector", names: []string{"nope"}, ctx: acme, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailures("selected-fault", 1)
+			SetSelectors("selected-fault", tt.names...)
+
+			if got := InjectWithContext(tt.ctx, "selected-fault"); got != tt.expected {
+				t.Errorf("InjectWithContext = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectorsDoNotConsumeCount(t *testing.T) {
+	resetState()
+	RegisterSelector("acme-only", TenantSelector("acme"))
+	defer RegisterSelector("acme-only", nil)
+
+	SetFailures("tenant-fault", 1)
+	SetSelectors("tenant-fault", "acme-only")
+
+	other := WithAttributes(context.Background(), Attributes{"tenant": "globex"})
+	for i := 0; i < 3; i++ {
+		if InjectWithContext(other, "tenant-fault") {
+			t.Fatal("Expected unselected tenant not to fail")
+		}
+	}
+	if Status()["tenant-fault"] != 1 {
+		t.Errorf("Expected unselected calls not to consume failures, got %d remaining", Status()["tenant-fault"])
+	}
+
+	acme := WithAttributes(context.Background(), Attributes{"tenant": "acme"})
+	if !InjectWithContext(acme, "tenant-fault") {
+		t.Error("Expected selected tenant to fail")
+	}
+}
+
+func TestPercentSelector(t *testing.T) {
+	s := PercentSelector("user", 25)
+
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		ctx := WithAttributes(context.Background(), Attributes{"user": fmt.Sprintf("user-%d", i)})
+		first := s.Select(ctx, "checkout")
+		if first != s.Select(ctx, "checkout") {
+			t.Fatal("Expected PercentSelector to be stable for the same user")
+		}
+		if first {
+			hits++
+		}
+	}
+	if hits < 180 || hits > 320 {
+		t.Errorf("Expected roughly 25%% of users selected, got %d/1000", hits)
+	}
+
+	if PercentSelector("user", 100).Select(context.Background(), "checkout") {
+		t.Error("Expected missing attribute not to be selected")
+	}
+	ctx := WithAttributes(context.Background(), Attributes{"user": "u"})
+	if PercentSelector("user", 0).Select(ctx, "checkout") {
+		t.Error("Expected 0% never to select")
+	}
+	if !PercentSelector("user", 100).Select(ctx, "checkout") {
+		t.Error("Expected 100% always to select")
+	}
+}
+
+func TestTimeOfDaySelector(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		name       string
+		now        string
+		start, end time.Duration
+		expected   bool
+	}{
+		{name: "inside window", now: "10:30", start: 9 * time.Hour, end: 17 * time.Hour, expected: true},
+		{name: "before window", now: "08:59", start: 9 * time.Hour, end: 17 * time.Hour, expected: false},
+		{name: "end is exclusive", now: "17:00", start: 9 * time.Hour, end: 17 * time.Hour, expected: false},
+		{name: "wrapping window late", now: "23:00", start: 22 * time.Hour, end: 2 * time.Hour, expected: true},
+		{name: "wrapping window early", now: "01:00", start: 22 * time.Hour, end: 2 * time.Hour, expected: true},
+		{name: "outside wrapping window", now: "12:00", start: 22 * time.Hour, end: 2 * time.Hour, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, _ := time.ParseInLocation("2006-01-02 15:04", "2025-06-01 "+tt.now, time.Local)
+			timeNow = func() time.Time { return now }
+			if got := TimeOfDaySelector(tt.start, tt.end).Select(context.Background(), "k"); got != tt.expected {
+				t.Errorf("Select at %s = %v, want %v", tt.now, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTTPMiddlewareHeaderSelector(t *testing.T) {
+	resetState()
+	RegisterSelector("debug-header", HeaderSelector("X-Debug-Fault", "1"))
+	defer RegisterSelector("debug-header", nil)
+
+	SetFailures("header-fault", 5)
+	SetSelectors("header-fault", "debug-header")
+
+	handler := HTTPMiddleware("header-fault")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != 200 {
+		t.Errorf("Expected status 200 without header, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Debug-Fault", "1")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != 500 {
+		t.Errorf("Expected status 500 with header, got %d", w.Code)
+	}
+}
+
+func TestLoadSpecSelectors(t *testing.T) {
+	resetState()
+	RegisterSelector("spec-tenant", TenantSelector("acme"))
+	defer RegisterSelector("spec-tenant", nil)
+
+	content := `failures:
+  spec-selected: 1
+selectors:
+  spec-selected: [spec-tenant]`
+	filename := "test-selectors.yaml"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(filename)
+
+	if err := LoadSpec(filename); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	if Inject("spec-selected") {
+		t.Error("Expected call without tenant not to fail")
+	}
+	acme := WithAttributes(context.Background(), Attributes{"tenant": "acme"})
+	if !InjectWithContext(acme, "spec-selected") {
+		t.Error("Expected call for acme to fail")
+	}
+}
@@ -1,37 +1,312 @@
 // Copyright 2025 Talina Shrotriya
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build faultinject
+
 package faultinject
 
 import (
+	"context"
 	"encoding/json"
+	"maps"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultWatchTimeout and maxWatchTimeout bound how long /faults/watch holds a request.
+const (
+	defaultWatchTimeout = 30 * time.Second
+	maxWatchTimeout     = 5 * time.Minute
 )
 
-// StartControlServer starts an HTTP server on addr with /set, /reset, /status, and optional /run.
-func StartControlServer(addr string, runHandler http.HandlerFunc) {
+// filterKeys removes every key not in the comma-separated keys from m, unless
+// keys is empty.
+func filterKeys[V any](m map[string]V, keys string) {
+	if keys == "" {
+		return
+	}
+	want := strings.Split(keys, ",")
+	for key := range m {
+		if !contains(want, key) {
+			delete(m, key)
+		}
+	}
+}
+
+// StartControlServer starts an HTTP server on addr with /set, /apply, /reset,
+// /status, /rules, /faults, /keys, /analytics/keys, /callsites, /faults/watch,
+// /watch, /events, /metrics, /history, /experiments, /state, the /tenants endpoints,
+// the /livez and /readyz probes, and optional /run. It returns once the server
+// is listening, with the server's Addr set to the address it listens on, or
+// with the error if addr cannot be listened on. Stop the server with
+// Shutdown or Close.
+func (in *Injector) StartControlServer(addr string, runHandler http.HandlerFunc) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Addr: ln.Addr().String(), Handler: in.ControlHandler(runHandler)}
+	go srv.Serve(ln)
+	return srv, nil
+}
+
+// StartControlServer calls StartControlServer on the default Injector.
+func StartControlServer(addr string, runHandler http.HandlerFunc) (*http.Server, error) {
+	return std.StartControlServer(addr, runHandler)
+}
+
+// ControlHandler returns the control server's handler so it can be mounted on an existing mux.
+func (in *Injector) ControlHandler(runHandler http.HandlerFunc) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
 		k := r.URL.Query().Get("key")
 		c, _ := strconv.Atoi(r.URL.Query().Get("count"))
-		SetFailures(k, c)
-		w.Write([]byte("OK"))
+		t := r.URL.Query().Get("tenants")
+		if !in.allowExperiment(w, r, k) {
+			return
+		}
+		in.conditional(w, r, func() {
+			if in.isProductionEnvironment() {
+				return
+			}
+			in.setFailuresLocked(k, c)
+			if t != "" {
+				in.setTenantsLocked(k, strings.Split(t, ","))
+			}
+		})
+	})
+
+	mux.HandleFunc("/apply", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var failures map[string]int
+		if err := json.NewDecoder(r.Body).Decode(&failures); err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !in.allowExperiment(w, r, sortedKeys(failures)...) {
+			return
+		}
+		in.conditional(w, r, func() {
+			if in.isProductionEnvironment() {
+				return
+			}
+			for key, count := range failures {
+				in.setFailuresLocked(key, count)
+			}
+		})
 	})
 
+	// /reset clears every fault, or with ?label= those of matching rules.
 	mux.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
-		Reset()
-		w.Write([]byte("OK"))
+		if !in.allowExperiment(w, r, "*") {
+			return
+		}
+		if l := r.URL.Query().Get("label"); l != "" {
+			in.conditional(w, r, func() { in.resetByLabelLocked(l) })
+			return
+		}
+		in.conditional(w, r, in.resetLocked)
 	})
 
 	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
-		json.NewEncoder(w).Encode(Status())
+		if full, _ := strconv.ParseBool(r.URL.Query().Get("full")); full {
+			s := in.snapshot()
+			status, gen := in.fullStatusOf(s), s.generation
+			filterKeys(status, r.URL.Query().Get("keys"))
+			w.Header().Set("ETag", etag(gen))
+			json.NewEncoder(w).Encode(status)
+			return
+		}
+
+		status, gen := in.StatusWithGeneration()
+		filterKeys(status, r.URL.Query().Get("keys"))
+		w.Header().Set("ETag", etag(gen))
+		json.NewEncoder(w).Encode(status)
+	})
+
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(in.Keys())
+	})
+
+	mux.HandleFunc("/analytics/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(in.KeyAnalytics())
+	})
+
+	mux.HandleFunc("/callsites", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(in.CallSites())
+	})
+
+	mux.HandleFunc("/rules", func(w http.ResponseWriter, r *http.Request) {
+		s := in.snapshot()
+		w.Header().Set("ETag", etag(s.generation))
+		json.NewEncoder(w).Encode(rulesOf(s))
+	})
+
+	// /faults returns the rules, or with ?label= those matching the selector.
+	mux.HandleFunc("/faults", func(w http.ResponseWriter, r *http.Request) {
+		s := in.snapshot()
+		rules := rulesOf(s)
+		if l := r.URL.Query().Get("label"); l != "" {
+			maps.DeleteFunc(rules, func(_ string, r Rule) bool { return !r.labelled(l) })
+		}
+		w.Header().Set("ETag", etag(s.generation))
+		json.NewEncoder(w).Encode(rules)
+	})
+
+	// /faults/watch long-polls until the generation differs from the one the
+	// client passes as ?generation= or If-None-Match, or until ?timeout=.
+	mux.HandleFunc("/faults/watch", func(w http.ResponseWriter, r *http.Request) {
+		known := r.URL.Query().Get("generation")
+		if known == "" {
+			known = strings.Trim(r.Header.Get("If-None-Match"), `"`)
+		}
+		gen, _ := strconv.ParseUint(known, 10, 64)
+
+		timeout := defaultWatchTimeout
+		if t := r.URL.Query().Get("timeout"); t != "" {
+			d, err := time.ParseDuration(t)
+			if err != nil || d < 0 {
+				http.Error(w, "invalid timeout", http.StatusBadRequest)
+				return
+			}
+			timeout = min(d, maxWatchTimeout)
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		if gen != 0 {
+			if _, err := in.WaitForChange(ctx, gen); err != nil {
+				w.Header().Set("ETag", etag(gen))
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		status, cur := in.StatusWithGeneration()
+		w.Header().Set("ETag", etag(cur))
+		json.NewEncoder(w).Encode(struct {
+			Generation uint64         `json:"generation"`
+			Status     map[string]int `json:"status"`
+		}{cur, status})
+	})
+
+	mux.HandleFunc("/watch", in.serveWatch)
+
+	// /events returns the event log, filtered by ?key=, ?fired=true, ?since=
+	// and ?limit= as in EventQuery.
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		q := EventQuery{Key: r.URL.Query().Get("key")}
+		var err error
+		if v := r.URL.Query().Get("fired"); v != "" {
+			q.FiredOnly, err = strconv.ParseBool(v)
+		}
+		if v := r.URL.Query().Get("since"); v != "" && err == nil {
+			q.Since, err = strconv.ParseUint(v, 10, 64)
+		}
+		if v := r.URL.Query().Get("limit"); v != "" && err == nil {
+			q.Limit, err = strconv.Atoi(v)
+		}
+		if err != nil {
+			http.Error(w, "invalid query: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		events := in.QueryEvents(q)
+		if events == nil {
+			events = []Event{}
+		}
+		json.NewEncoder(w).Encode(events)
+	})
+
+	mux.Handle("/metrics", in.MetricsHandler())
+
+	mux.HandleFunc("/history", func(w http.ResponseWriter, r *http.Request) {
+		k := r.URL.Query().Get("key")
+		if k == "" {
+			http.Error(w, "missing key", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(in.History(k))
+	})
+
+	mux.HandleFunc("/tenants", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(in.Tenants())
+	})
+
+	mux.HandleFunc("/tenants/status", func(w http.ResponseWriter, r *http.Request) {
+		t := r.URL.Query().Get("tenant")
+		if t == "" {
+			http.Error(w, "missing tenant", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(in.TenantStatus(t))
+	})
+
+	mux.HandleFunc("/tenants/reset", func(w http.ResponseWriter, r *http.Request) {
+		t := r.URL.Query().Get("tenant")
+		if t == "" {
+			http.Error(w, "missing tenant", http.StatusBadRequest)
+			return
+		}
+		if !in.allowExperiment(w, r, "*") {
+			return
+		}
+		in.conditional(w, r, func() { in.resetTenantLocked(t) })
 	})
 
+	mux.HandleFunc("/experiments", in.serveExperiments)
+
+	mux.HandleFunc("/state", in.serveState)
+
+	mux.HandleFunc("/livez", in.serveLivez)
+	mux.HandleFunc("/readyz", in.serveReadyz)
+
 	if runHandler != nil {
 		mux.HandleFunc("/run", runHandler)
 	}
 
-	go http.ListenAndServe(addr, mux)
+	return mux
+}
+
+// ControlHandler calls ControlHandler on the default Injector.
+func ControlHandler(runHandler http.HandlerFunc) http.Handler {
+	return std.ControlHandler(runHandler)
+}
+
+// conditional applies fn if the request's If-Match header names the current
+// generation, or is absent, and replies with the new generation as ETag.
+// It replies 412 Precondition Failed otherwise.
+func (in *Injector) conditional(w http.ResponseWriter, r *http.Request, fn func()) {
+	gen, ok := ifMatch(r)
+	if !ok {
+		http.Error(w, "invalid If-Match", http.StatusPreconditionFailed)
+		return
+	}
+	gen, err := in.updateIfMatch(gen, fn)
+	w.Header().Set("ETag", etag(gen))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	w.Write([]byte("OK"))
+}
+
+// ifMatch returns the generation named by the If-Match header, or zero when
+// the header is absent or "*". It reports false for other values.
+func ifMatch(r *http.Request) (uint64, bool) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return 0, true
+	}
+	gen, err := strconv.ParseUint(strings.Trim(h, `"`), 10, 64)
+	return gen, err == nil && gen != 0
+}
+
+func etag(gen uint64) string {
+	return `"` + strconv.FormatUint(gen, 10) + `"`
 }
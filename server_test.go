@@ -1,12 +1,16 @@
+//go:build faultinject
+
 package faultinject
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func TestStartControlServer(t *testing.T) {
@@ -15,11 +19,24 @@ func TestStartControlServer(t *testing.T) {
 
 	t.Run("start server on default port", func(t *testing.T) {
 		resetState()
+		SetFailures("db", 2)
 
-		// Start server
-		StartControlServer(":0", nil)
-		// Note: We can't easily test the server is running since it's in a goroutine
-		// and doesn't return a server object
+		srv, err := StartControlServer("127.0.0.1:0", nil)
+		if err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		defer srv.Close()
+
+		resp, err := http.Get("http://" + srv.Addr + "/status")
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		defer resp.Body.Close()
+		var status map[string]int
+		json.NewDecoder(resp.Body).Decode(&status)
+		if status["db"] != 2 {
+			t.Errorf("Expected db: 2, got %v", status)
+		}
 	})
 
 	t.Run("start server with run handler", func(t *testing.T) {
@@ -30,7 +47,45 @@ func TestStartControlServer(t *testing.T) {
 			w.WriteHeader(200)
 			w.Write([]byte("run handler"))
 		}
-		StartControlServer(":0", runHandler)
+		srv, err := StartControlServer("127.0.0.1:0", runHandler)
+		if err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		defer srv.Close()
+
+		resp, err := http.Post("http://"+srv.Addr+"/run", "text/plain", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("port in use", func(t *testing.T) {
+		srv, err := StartControlServer("127.0.0.1:0", nil)
+		if err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		defer srv.Close()
+
+		if _, err := StartControlServer(srv.Addr, nil); err == nil {
+			t.Error("Expected an error for an address already in use")
+		}
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		srv, err := StartControlServer("127.0.0.1:0", nil)
+		if err != nil {
+			t.Fatalf("Failed to start server: %v", err)
+		}
+		if err := srv.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown returned error: %v", err)
+		}
+		if _, err := http.Get("http://" + srv.Addr + "/status"); err == nil {
+			t.Error("Expected requests to fail after shutdown")
+		}
 	})
 }
 
@@ -284,3 +339,78 @@ func TestServerErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestControlHandler(t *testing.T) {
+	resetState()
+
+	server := httptest.NewServer(ControlHandler(nil))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/set?key=handler-fault&count=2", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := Status()["handler-fault"]; got != 2 {
+		t.Errorf("Expected 2 remaining failures, got %d", got)
+	}
+
+	resp, err = http.Get(server.URL + "/run")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected /run to be absent without a run handler, got %d", resp.StatusCode)
+	}
+}
+
+func TestControlHandlerRules(t *testing.T) {
+	resetState()
+	SetNthFailure("db", 3)
+	SetLatency("db", FixedLatency(5*time.Millisecond))
+
+	server := httptest.NewServer(ControlHandler(nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/rules")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("ETag") != etag(Generation()) {
+		t.Errorf("Expected ETag %s, got %s", etag(Generation()), resp.Header.Get("ETag"))
+	}
+
+	var got map[string]Rule
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got["db"].Mode != ModeNth || got["db"].Nth != 3 || len(got["db"].Latency) != 1 {
+		t.Errorf("Expected the combined nth and latency rule, got %+v", got["db"])
+	}
+}
+
+func TestControlHandlerFullStatus(t *testing.T) {
+	resetState()
+	SetNthFailure("db", 3)
+	SetFailures("api", 2)
+
+	server := httptest.NewServer(ControlHandler(nil))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/status?full=true&keys=db")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var got map[string]KeyStatus
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(got) != 1 || got["db"].Mode != ModeNth || got["db"].Nth != 3 || got["db"].Remaining != 1 {
+		t.Errorf("Expected only the nth rule for db, got %+v", got)
+	}
+}
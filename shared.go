@@ -0,0 +1,211 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// sharedDecision is a decision made by the process serving shared state.
+type sharedDecision struct {
+	Fired  bool   `json:"fired"`
+	Reason Reason `json:"reason"`
+	Count  int    `json:"count"`
+	Mode   Mode   `json:"mode,omitempty"`
+}
+
+// ServeShared serves the injector's rules and counters on a Unix socket at
+// path until the returned stop func is called, so other processes on the
+// machine that call UseShared(path), such as the service under test started
+// by a test driver, share one fault configuration and counter space without
+// a control server listening on a port. Configure faults in this process, or
+// through the socket, which also serves the control API:
+//
+//	curl -X POST --unix-socket /tmp/fi.sock 'http://fi/set?key=db-insert&count=2'
+//
+// It returns an error if another process already serves path. A socket left
+// behind by a process that exited is replaced. Only the user running this
+// process can connect to the socket.
+func (in *Injector) ServeShared(path string) (stop func() error, err error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("shared state %s is already served", path)
+	}
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path) // left behind by a process that exited
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil { // the control API is not for other users
+		l.Close()
+		return nil, err
+	}
+	srv := &http.Server{Handler: in.sharedHandler()}
+	go srv.Serve(l)
+	return srv.Close, nil // closing the listener removes the socket
+}
+
+// ServeShared calls ServeShared on the default Injector.
+func ServeShared(path string) (stop func() error, err error) {
+	return std.ServeShared(path)
+}
+
+// sharedCall is what /decide is told about a call made in another process,
+// so rules that match attributes or callers are decided as they would be
+// there.
+type sharedCall struct {
+	Attributes Attributes `json:"attributes,omitempty"`
+	Stack      []string   `json:"stack"`
+}
+
+// sharedHandler serves /decide for UseShared, and the control API.
+func (in *Injector) sharedHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/decide", func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			http.Error(w, "missing key", http.StatusBadRequest)
+			return
+		}
+		var call sharedCall
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			http.Error(w, "invalid call: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx := context.WithValue(r.Context(), callerStackKey{}, call.Stack)
+		if len(call.Attributes) > 0 {
+			ctx = WithAttributes(ctx, call.Attributes)
+		}
+		if r.URL.Query().Has("uncounted") {
+			ctx = context.WithValue(ctx, uncountedKey{}, true)
+		}
+		e, mode := in.evaluateEvent(ctx, key)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sharedDecision{Fired: e.Fired, Reason: e.Reason, Count: e.Count, Mode: mode})
+	})
+	mux.Handle("/", in.ControlHandler(nil))
+	return mux
+}
+
+// UseShared makes the injector decide calls in the process serving shared
+// state on the Unix socket at path with ServeShared, so its rules and call
+// counts apply across processes. An empty path goes back to deciding
+// locally. It returns an error if path cannot be reached.
+//
+// Production environments, synthetic traffic, tenants, selectors, context
+// overrides and directives are still checked here first. The call's
+// attributes and stack are sent along, so rules limited with Match, OnlyFrom
+// or InjectWhen are decided as they would be here. Only the decision
+// of whether a call fails is shared: latency, values and the other per-key
+// settings come from this process. Decisions that fired are recorded here
+// too, for OnFault, OnInject and the event log. If the serving process goes
+// away, calls are decided locally, with a warning on stderr.
+func (in *Injector) UseShared(path string) error {
+	if path == "" {
+		if old := in.shared.Swap(nil); old != nil {
+			old.client.CloseIdleConnections()
+		}
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return fmt.Errorf("shared state %s: %w", path, err)
+	}
+	conn.Close()
+	c := &sharedClient{
+		path: path,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+	if old := in.shared.Swap(c); old != nil {
+		old.client.CloseIdleConnections()
+	}
+	return nil
+}
+
+// UseShared calls UseShared on the default Injector.
+func UseShared(path string) error {
+	return std.UseShared(path)
+}
+
+// sharedClient asks the process serving shared state for decisions.
+type sharedClient struct {
+	path   string
+	client *http.Client
+	warned atomic.Bool // whether the serving process was reported unreachable
+}
+
+// decide asks the serving process to decide the call to key.
+func (c *sharedClient) decide(ctx context.Context, key string) (sharedDecision, error) {
+	var d sharedDecision
+	target := "http://fi/decide?key=" + url.QueryEscape(key)
+	if uncounted(ctx) {
+		target += "&uncounted"
+	}
+	body, err := json.Marshal(sharedCall{Attributes: AttributesFromContext(ctx), Stack: callerStack()})
+	if err != nil {
+		return d, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return d, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return d, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return d, fmt.Errorf("deciding %q: %s", key, resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&d)
+	return d, err
+}
+
+// evaluateShared decides the call to key in the process serving shared state
+// and records the decision here. It reports false if UseShared is off or the
+// serving process cannot be reached, so the call is decided locally.
+func (in *Injector) evaluateShared(ctx context.Context, key string) (Event, Mode, bool) {
+	c := in.shared.Load()
+	if c == nil {
+		return Event{}, "", false
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	d, err := c.decide(ctx, key)
+	if err != nil {
+		if !c.warned.Swap(true) {
+			fmt.Fprintf(warnOutput, "faultinject: shared state %s unavailable, deciding locally: %v\n", c.path, err)
+		}
+		return Event{}, "", false
+	}
+	c.warned.Store(false)
+	e := in.record(ctx, key, d.Fired, d.Reason, d.Count)
+	if e.Fired {
+		in.notifyFault(ctx, Fault{Key: key, Type: string(d.Mode), Count: d.Count})
+		in.notifyInject(e)
+	}
+	return e, d.Mode, true
+}
@@ -0,0 +1,185 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sharedSocket returns a socket path short enough for every platform.
+func sharedSocket(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "fi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "fi.sock")
+}
+
+func TestSharedState(t *testing.T) {
+	resetState()
+	path := sharedSocket(t)
+	owner := NewInjector()
+	stop, err := owner.ServeShared(path)
+	if err != nil {
+		t.Fatalf("ServeShared failed: %v", err)
+	}
+	defer stop()
+	owner.SetFailures("db-insert", 2)
+
+	in := NewInjector()
+	if err := in.UseShared(path); err != nil {
+		t.Fatalf("UseShared failed: %v", err)
+	}
+	var faults []Fault
+	in.OnFault(func(_ context.Context, f Fault) { faults = append(faults, f) })
+
+	got := []bool{in.Inject("db-insert"), owner.Inject("db-insert"), in.Inject("db-insert"), in.Inject("no-rule")}
+	expected := []bool{true, true, false, false}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected calls %v, got %v", expected, got)
+			break
+		}
+	}
+	if len(faults) != 1 || faults[0].Type != string(ModeFirstN) || faults[0].Count != 1 {
+		t.Errorf("Expected the fired call reported locally, got %+v", faults)
+	}
+	if n := owner.FullStatus()["db-insert"].Calls; n != 3 {
+		t.Errorf("Expected 3 calls counted by the owner, got %d", n)
+	}
+	if err := in.InjectWithError("db-insert", "boom"); err != nil {
+		t.Errorf("Expected no error once exhausted, got %v", err)
+	}
+
+	if _, err := NewInjector().ServeShared(path); err == nil {
+		t.Error("Expected an error serving a path that is already served")
+	}
+}
+
+func TestSharedStateWithoutCount(t *testing.T) {
+	resetState()
+	path := sharedSocket(t)
+	owner := NewInjector()
+	stop, err := owner.ServeShared(path)
+	if err != nil {
+		t.Fatalf("ServeShared failed: %v", err)
+	}
+	defer stop()
+	owner.SetFailures("db-insert", 1)
+
+	in := NewInjector()
+	if err := in.UseShared(path); err != nil {
+		t.Fatalf("UseShared failed: %v", err)
+	}
+	got := []bool{in.Inject("db-insert", WithoutCount()), in.Inject("db-insert", WithoutCount()), in.Inject("db-insert"), in.Inject("db-insert")}
+	expected := []bool{true, true, true, false}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected calls %v, got %v", expected, got)
+			break
+		}
+	}
+	if calls := owner.FullStatus()["db-insert"].Calls; calls != 2 {
+		t.Errorf("Expected 2 calls counted by the owner, got %d", calls)
+	}
+}
+
+func TestSharedStateUnavailable(t *testing.T) {
+	resetState()
+	var out bytes.Buffer
+	warnOutput = &out
+	defer func() { warnOutput = os.Stderr }()
+
+	path := sharedSocket(t)
+	if err := NewInjector().UseShared(path); err == nil {
+		t.Error("Expected an error for a socket nobody serves")
+	}
+	owner := NewInjector()
+	stop, _ := owner.ServeShared(path)
+	owner.SetFailures("db-insert", 5)
+	in := NewInjector()
+	in.UseShared(path)
+	in.SetNthFailure("db-insert", 2)
+	in.Inject("db-insert")
+	stop()
+
+	got := []bool{in.Inject("db-insert"), in.Inject("db-insert")}
+	if got[0] || !got[1] { // shared calls are not counted locally
+		t.Errorf("Expected local decisions after the owner stopped, got %v", got)
+	}
+	if n := strings.Count(out.String(), "shared state"); n != 1 {
+		t.Errorf("Expected one warning, got %q", out.String())
+	}
+
+	in.UseShared("")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket removed when stopped, got %v", err)
+	}
+}
+
+func TestServeSharedKeepsFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fi.sock")
+	os.WriteFile(path, []byte("data"), 0644)
+	if _, err := NewInjector().ServeShared(path); err == nil {
+		t.Error("Expected an error for a path that is not a socket")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "data" {
+		t.Errorf("Expected the file kept, got %q", data)
+	}
+}
+
+func sharedCheckout(ctx context.Context, in *Injector) bool {
+	return in.InjectWithContext(ctx, "db-insert")
+}
+
+func TestSharedStateMatchesCaller(t *testing.T) {
+	resetState()
+	path := sharedSocket(t)
+	owner := NewInjector()
+	stop, err := owner.ServeShared(path)
+	if err != nil {
+		t.Fatalf("ServeShared failed: %v", err)
+	}
+	defer stop()
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("Expected a socket only its owner can use, got %v, %v", fi.Mode(), err)
+	}
+	owner.SetFailureRate("db-insert", 1)
+	owner.SetMatch("db-insert", map[string]string{"region": "eu"})
+	if err := owner.SetOnlyFrom("db-insert", "*sharedCheckout"); err != nil {
+		t.Fatal(err)
+	}
+
+	in := NewInjector()
+	if err := in.UseShared(path); err != nil {
+		t.Fatalf("UseShared failed: %v", err)
+	}
+	tests := []struct {
+		name     string
+		region   string
+		checkout bool
+		expected bool
+	}{
+		{name: "matching call", region: "eu", checkout: true, expected: true},
+		{name: "other attributes", region: "us", checkout: true, expected: false},
+		{name: "other caller", region: "eu", checkout: false, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithAttributes(context.Background(), Attributes{"region": tt.region})
+			got := in.InjectWithContext(ctx, "db-insert")
+			if tt.checkout {
+				got = sharedCheckout(ctx, in)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected fired=%v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+)
+
+// StartDrain marks the process as draining, enabling DrainMiddleware. Call it
+// when shutdown begins, typically just before http.Server.Shutdown.
+func (in *Injector) StartDrain() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.draining = true
+}
+
+// StartDrain calls StartDrain on the default Injector.
+func StartDrain() {
+	std.StartDrain()
+}
+
+// Draining reports whether StartDrain has been called since the last Reset.
+func (in *Injector) Draining() bool {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.draining
+}
+
+// Draining calls Draining on the default Injector.
+func Draining() bool {
+	return std.Draining()
+}
+
+// Cleanup wraps a shutdown cleanup function such as closing a database pool
+// or flushing a queue. Before fn runs, the wrapper sleeps for key's latency
+// (see SetLatency), returning ctx's error if the shutdown deadline passes
+// first; if key fires, it returns an error without running fn.
+func (in *Injector) Cleanup(key string, fn func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if _, err := in.InjectLatency(ctx, key); err != nil {
+			return err
+		}
+		if fired, count := in.evaluateWithContext(ctx, key); fired {
+			return in.newInjectedError(key, count, "cleanup "+key)
+		}
+		return fn(ctx)
+	}
+}
+
+// Cleanup calls Cleanup on the default Injector.
+func Cleanup(key string, fn func(context.Context) error) func(context.Context) error {
+	return std.Cleanup(key, fn)
+}
+
+// DrainMiddleware drops in-flight requests while the process is draining.
+// The decision is made when the handler first writes its response, or returns
+// without writing, so requests accepted before StartDrain are affected too.
+// When key fires, the connection is aborted without a response, as if the
+// process had exited before the request completed.
+func (in *Injector) DrainMiddleware(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithAttributes(r.Context(), in.requestAttributes(r))
+			dw := &drainWriter{ResponseWriter: w, in: in, ctx: ctx, key: key}
+			next.ServeHTTP(dw, r.WithContext(ctx))
+			dw.check()
+		})
+	}
+}
+
+// DrainMiddleware calls DrainMiddleware on the default Injector.
+func DrainMiddleware(key string) func(http.Handler) http.Handler {
+	return std.DrainMiddleware(key)
+}
+
+// drainWriter aborts the response on its first write if the process is
+// draining and the key fires.
+type drainWriter struct {
+	http.ResponseWriter
+	in      *Injector
+	ctx     context.Context
+	key     string
+	checked bool
+}
+
+func (w *drainWriter) check() {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	if w.in.Draining() && w.in.InjectWithContext(w.ctx, w.key) {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (w *drainWriter) WriteHeader(code int) {
+	w.check()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *drainWriter) Write(b []byte) (int, error) {
+	w.check()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *drainWriter) Flush() {
+	w.check()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *drainWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// SignalContext is signal.NotifyContext, except that each arriving signal is
+// first checked against key: while key fires the signal is ignored. With
+// SetFailures(key, 1) the first SIGTERM is swallowed and the second one
+// cancels the context, to test that the orchestrator's grace period and
+// follow-up SIGKILL are handled.
+func (in *Injector) SignalContext(parent context.Context, key string, sigs ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		defer signal.Stop(ch)
+		for {
+			select {
+			case sig := <-ch:
+				if in.InjectWithContext(parent, key) {
+					fmt.Fprintf(os.Stderr, "faultinject: ignoring %s\n", sig)
+					continue
+				}
+				cancel()
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		signal.Stop(ch)
+	}
+}
+
+// SignalContext calls SignalContext on the default Injector.
+func SignalContext(parent context.Context, key string, sigs ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	return std.SignalContext(parent, key, sigs...)
+}
@@ -0,0 +1,141 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCleanup(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func()
+		timeout  time.Duration
+		expected bool // whether fn runs
+		wantErr  bool
+	}{
+		{name: "no rule", setup: func() {}, expected: true},
+		{name: "fails", setup: func() { SetFailures("db-close", 1) }, wantErr: true},
+		{name: "delayed", setup: func() { SetLatency("db-close", FixedLatency(5*time.Millisecond)) }, expected: true},
+		{
+			name:    "delayed past deadline",
+			setup:   func() { SetLatency("db-close", FixedLatency(time.Second)) },
+			timeout: 5 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+			ran := false
+			cleanup := Cleanup("db-close", func(context.Context) error {
+				ran = true
+				return nil
+			})
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			err := cleanup(ctx)
+			if ran != tt.expected {
+				t.Errorf("Expected fn to run: %v, got %v", tt.expected, ran)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error: %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDrainMiddleware(t *testing.T) {
+	resetState()
+	SetFailures("drain", 1)
+
+	handler := DrainMiddleware("drain")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Start()
+	defer srv.Close()
+
+	// without keep-alives, so the transport does not retry dropped requests
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func() error {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := get(); err != nil {
+		t.Fatalf("Expected request before drain to succeed, got %v", err)
+	}
+	if Status()["drain"] != 1 {
+		t.Errorf("Expected no rule consumed before drain, got %v", Status())
+	}
+
+	StartDrain()
+	if !Draining() {
+		t.Error("Expected Draining to report true")
+	}
+	if err := get(); err == nil {
+		t.Error("Expected request during drain to be dropped")
+	}
+	if err := get(); err != nil {
+		t.Errorf("Expected request after the rule is exhausted to succeed, got %v", err)
+	}
+
+	Reset()
+	if Draining() {
+		t.Error("Expected Reset to clear drain state")
+	}
+}
+
+func TestSignalContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending os.Interrupt is not supported on Windows")
+	}
+	resetState()
+	SetFailures("sigterm", 1)
+
+	ctx, stop := SignalContext(context.Background(), "sigterm", os.Interrupt)
+	defer stop()
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to find own process: %v", err)
+	}
+
+	if err := self.Signal(os.Interrupt); err != nil {
+		t.Fatalf("Failed to send signal: %v", err)
+	}
+	waitFor(t, func() bool { return Status()["sigterm"] == 0 })
+	select {
+	case <-ctx.Done():
+		t.Fatal("Expected the first signal to be ignored")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := self.Signal(os.Interrupt); err != nil {
+		t.Fatalf("Failed to send signal: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second signal to cancel the context")
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// The configuration Inject reads is published as an immutable ruleSnapshot
+// whenever it changes, so the decision path never waits for mu: a long
+// LoadSpec or a burst of SetFailures calls only delays readers of the live
+// maps. Call counts, history and metrics change on every call and live under
+// callsMu instead, which writers only hold for a few map operations.
+
+// configMutex guards the configuration. Unlock publishes a new snapshot when
+// the configuration changed while the lock was held, and calls published
+// after unlocking.
+type configMutex struct {
+	sync.RWMutex
+	dirty     bool
+	publish   func()
+	published func()
+}
+
+// Unlock publishes the configuration if it changed and unlocks m.
+func (m *configMutex) Unlock() {
+	if !m.dirty {
+		m.RWMutex.Unlock()
+		return
+	}
+	m.dirty = false
+	m.publish()
+	m.RWMutex.Unlock()
+	if m.published != nil {
+		m.published()
+	}
+}
+
+// ruleSnapshot is the configuration that decides calls.
+type ruleSnapshot struct {
+	generation              uint64
+	rules                   map[string]Rule
+	patterns                []pattern
+	skews                   map[string]skewRule
+	selectorRegistry        map[string]Selector
+	selectors               map[string][]string
+	tenants                 map[string][]string
+	excludeSynthetic        map[string]bool
+	excludeSyntheticDefault bool
+	strictMode              StrictMode
+	knownKeys               map[string]bool
+	knownPatterns           []pattern
+	instanceBucket          float64
+	build                   Build
+	conflicts               map[string]string
+	routes                  map[string]string
+	routeMux                *http.ServeMux
+	budget                  *Budget
+	tenantHeader            string
+}
+
+// publishLocked makes the live configuration visible to Inject.
+func (in *Injector) publishLocked() {
+	in.current.Store(in.liveLocked().clone())
+}
+
+// snapshot returns the latest published configuration. It must not be
+// modified.
+func (in *Injector) snapshot() *ruleSnapshot {
+	return in.current.Load()
+}
+
+// liveLocked returns the live configuration, without copying it.
+func (in *Injector) liveLocked() *ruleSnapshot {
+	return &ruleSnapshot{
+		generation:              in.generation,
+		rules:                   in.rules,
+		patterns:                in.patterns,
+		skews:                   in.skews,
+		selectorRegistry:        in.selectorRegistry,
+		selectors:               in.selectors,
+		tenants:                 in.tenants,
+		excludeSynthetic:        in.excludeSynthetic,
+		excludeSyntheticDefault: in.excludeSyntheticDefault,
+		strictMode:              in.strictMode,
+		knownKeys:               in.knownKeys,
+		knownPatterns:           in.knownPatterns,
+		instanceBucket:          in.instanceBucket,
+		build:                   in.build,
+		conflicts:               in.conflicts,
+		routes:                  in.routes,
+		routeMux:                in.routeMux,
+		budget:                  in.budget,
+		tenantHeader:            in.tenantHeader,
+	}
+}
+
+// clone copies s deeply enough that later changes to the live maps do not
+// show through. Rule values, selector and tenant lists, and the routes are
+// replaced rather than modified in place, so they can be shared.
+func (s *ruleSnapshot) clone() *ruleSnapshot {
+	c := *s
+	c.rules = maps.Clone(s.rules)
+	c.patterns = slices.Clone(s.patterns)
+	c.skews = maps.Clone(s.skews)
+	c.selectorRegistry = maps.Clone(s.selectorRegistry)
+	c.selectors = maps.Clone(s.selectors)
+	c.tenants = maps.Clone(s.tenants)
+	c.excludeSynthetic = maps.Clone(s.excludeSynthetic)
+	c.knownKeys = maps.Clone(s.knownKeys)
+	c.knownPatterns = slices.Clone(s.knownPatterns)
+	c.conflicts = maps.Clone(s.conflicts)
+	return &c
+}
+
+// ruleKey returns the key whose rules apply to a call to key: key itself if
+// it has a fault rule, otherwise the longest matching pattern with one,
+// otherwise key.
+func (s *ruleSnapshot) ruleKey(key string) string {
+	if len(s.patterns) == 0 || s.hasFaultRule(key) {
+		return key
+	}
+	for _, p := range s.patterns {
+		if s.hasFaultRule(p.key) && p.re.MatchString(key) {
+			return p.key
+		}
+	}
+	return key
+}
+
+// hasFaultRule reports whether key has a rule that makes calls fail or slow
+// down, as opposed to one that only scopes other rules.
+func (s *ruleSnapshot) hasFaultRule(key string) bool {
+	if r, ok := s.rules[key]; ok && (r.Mode != ModeNone || len(r.Latency) > 0) {
+		return true
+	}
+	_, ok := s.skews[key]
+	return ok
+}
+
+// hasRule reports whether calls to key are decided by a failure mode.
+func (s *ruleSnapshot) hasRule(key string) bool {
+	return s.rules[s.ruleKey(key)].Mode != ModeNone
+}
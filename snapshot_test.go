@@ -0,0 +1,65 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInjectDoesNotWaitForWriters(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+
+	// a writer holding the configuration lock, as during LoadSpec
+	std.mu.Lock()
+	defer std.mu.Unlock()
+
+	done := make(chan []bool)
+	go func() {
+		status := Status()
+		done <- []bool{Inject("db"), Inject("db"), len(status) == 1}
+	}()
+	select {
+	case got := <-done:
+		if !got[0] || got[1] || !got[2] {
+			t.Errorf("Expected the published rule to decide calls, got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected Inject and Status not to wait for mu")
+	}
+}
+
+func TestSnapshotIsImmutable(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	s := std.snapshot()
+
+	SetFailures("db", 5)
+	SetFailures("cache", 1)
+	if r := s.rules["db"]; r.Count != 1 || len(s.rules) != 1 {
+		t.Errorf("Expected the old snapshot to be unchanged, got %v", s.rules)
+	}
+	if std.snapshot().rules["db"].Count != 5 {
+		t.Errorf("Expected the new rule to be published, got %v", std.snapshot().rules)
+	}
+}
+
+func TestStatusDetailed(t *testing.T) {
+	resetState()
+	SetFailures("db", 2)
+	Inject("db")
+
+	got := StatusDetailed()
+	if got.Generation != Generation() {
+		t.Errorf("Expected generation %d, got %d", Generation(), got.Generation)
+	}
+	if ks := got.Keys["db"]; ks.Calls != 1 || ks.Remaining != 1 {
+		t.Errorf("Expected db with 1 call and 1 failure left, got %+v", ks)
+	}
+
+	SetFailures("db", 3)
+	if next := StatusDetailed().Generation; next <= got.Generation {
+		t.Errorf("Expected the generation to increase past %d, got %d", got.Generation, next)
+	}
+}
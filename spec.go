@@ -1,34 +1,371 @@
 // Copyright 2025 Talina Shrotriya
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build faultinject
+
 package faultinject
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v3"
 )
 
+// Spec formats accepted by LoadSpecFromBytes. JSON and TOML specs use the
+// same keys as YAML, such as "precise-failures", and durations are strings
+// such as "250ms".
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
 type Spec struct {
-	Failures        map[string]int `yaml:"failures"`         // first-N
-	PreciseFailures map[string]int `yaml:"precise-failures"` // Nth
+	Rules             map[string]Rule                `yaml:"rules,omitempty"`              // key -> full rule, applied before the sections below
+	Failures          map[string]int                 `yaml:"failures,omitempty"`           // first-N
+	PreciseFailures   map[string]int                 `yaml:"precise-failures,omitempty"`   // Nth
+	Selectors         map[string][]string            `yaml:"selectors,omitempty"`          // key -> registered selector names
+	Tenants           map[string][]string            `yaml:"tenants,omitempty"`            // key -> tenants the rule is scoped to
+	ExcludeSynthetic  []string                       `yaml:"exclude-synthetic,omitempty"`  // keys that never fail for synthetic traffic
+	Latencies         map[string]LatencyDistribution `yaml:"latencies,omitempty"`          // key -> delay distribution for InjectLatency
+	Flapping          map[string]FlapCycle           `yaml:"flapping,omitempty"`           // key -> unhealthy duty cycle
+	ClockSkews        map[string]ClockSkew           `yaml:"clock-skews,omitempty"`        // key -> skew applied by Now
+	Corruptions       map[string]Corruption          `yaml:"corruptions,omitempty"`        // key -> how CorruptBytes mangles payloads
+	NumericEdges      map[string]NumericEdge         `yaml:"numeric-edges,omitempty"`      // key -> value substituted by InjectValue
+	PageFaults        map[string]PageFault           `yaml:"page-faults,omitempty"`        // key -> how Paginate breaks pagination
+	IdempotencyFaults map[string]IdempotencyFault    `yaml:"idempotency-faults,omitempty"` // key -> how IdempotencyTransport breaks requests
+	QuotaResponses    map[string]QuotaResponse       `yaml:"quota-responses,omitempty"`    // key -> preset name or response returned by QuotaTransport
+	SSEFaults         map[string]SSEFault            `yaml:"sse-faults,omitempty"`         // key -> how SSEMiddleware breaks event streams
+	Degradations      map[string]Degradation         `yaml:"degradations,omitempty"`       // key -> degraded response HTTPMiddleware serves instead of an error
+	ResponseFaults    map[string]ResponseFault       `yaml:"response-faults,omitempty"`    // key -> how HTTPMiddleware breaks the handler's response
+	ConnFaults        map[string]ConnFault           `yaml:"conn-faults,omitempty"`        // key -> how wrapped connections break
+	Bandwidths        map[string]int                 `yaml:"bandwidths,omitempty"`         // key -> bytes per second of throttled connections
+	Durations         map[string]time.Duration       `yaml:"durations,omitempty"`          // key -> how long its rules stay active after loading
+	Matrix            []MatrixRule                   `yaml:"matrix,omitempty"`             // rule templates expanded into Rules when parsed
+	Routes            map[string]string              `yaml:"routes,omitempty"`             // request pattern -> key HTTPMiddlewareFromSpec evaluates
+	Budget            *Budget                        `yaml:"budget,omitempty"`             // caps the faults of every rule, as SetGlobalBudget
+}
+
+// LoadSpec replaces every rule with the spec in the file at path. The format
+// is taken from the extension (.yaml, .yml, .json or .toml), or detected from
+// the contents for any other name. If the spec is invalid, it returns a
+// *SpecError naming the entry at fault and applies none of the spec.
+func (in *Injector) LoadSpec(path string) error {
+	cfg, err := readSpec(path)
+	if err != nil {
+		return err
+	}
+	in.applySpec(cfg, false)
+	return nil
 }
 
+// LoadSpec calls LoadSpec on the default Injector.
 func LoadSpec(path string) error {
-	data, err := os.ReadFile(path)
+	return std.LoadSpec(path)
+}
+
+// LoadSpecFromBytes replaces every rule with the spec in data, written in
+// format: FormatYAML, FormatJSON, FormatTOML, or "" to detect it.
+func (in *Injector) LoadSpecFromBytes(data []byte, format string) error {
+	cfg, err := parseSpec(data, format)
+	if err != nil {
+		return err
+	}
+	in.applySpec(cfg, false)
+	return nil
+}
+
+// LoadSpecFromBytes calls LoadSpecFromBytes on the default Injector.
+func LoadSpecFromBytes(data []byte, format string) error {
+	return std.LoadSpecFromBytes(data, format)
+}
+
+// LoadSpecFromReader replaces every rule with the spec read from r, whose
+// format is detected from the contents.
+func (in *Injector) LoadSpecFromReader(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return in.LoadSpecFromBytes(data, "")
+}
+
+// LoadSpecFromReader calls LoadSpecFromReader on the default Injector.
+func LoadSpecFromReader(r io.Reader) error {
+	return std.LoadSpecFromReader(r)
+}
+
+// LoadSpecFS is LoadSpec for the file name in fsys, such as an embed.FS.
+func (in *Injector) LoadSpecFS(fsys fs.FS, name string) error {
+	data, err := fs.ReadFile(fsys, name)
 	if err != nil {
 		return err
 	}
+	return in.LoadSpecFromBytes(data, formatOf(name))
+}
+
+// LoadSpecFS calls LoadSpecFS on the default Injector.
+func LoadSpecFS(fsys fs.FS, name string) error {
+	return std.LoadSpecFS(fsys, name)
+}
+
+func readSpec(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+	cfg, err := parseSpec(data, formatOf(path))
+	return cfg, inFile(err, path)
+}
+
+// formatOf returns the spec format implied by path's extension, or "".
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	}
+	return ""
+}
+
+// tomlLine matches a TOML table header or key/value pair, neither of which
+// can start a valid YAML spec.
+var tomlLine = regexp.MustCompile(`^(\[[^\]]+\]|[A-Za-z0-9_."-]+\s*=)`)
+
+// detectFormat guesses the format of data from its first line that is not
+// blank or a comment.
+func detectFormat(data []byte) string {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if line[0] == '{' {
+			return FormatJSON
+		}
+		if tomlLine.Match(line) {
+			return FormatTOML
+		}
+		break
+	}
+	return FormatYAML
+}
+
+// parseSpec decodes and validates data as format, detecting it if format is
+// "". JSON and TOML are decoded generically and re-read as YAML, so that
+// every format goes through the same decoding of durations, presets and
+// modes. Invalid specs return a *SpecError, with the line of the entry at
+// fault for YAML and of syntax errors for JSON and TOML.
+func parseSpec(data []byte, format string) (Spec, error) {
+	source := data // YAML lines are only meaningful in the original
+	data, isYAML, err := specYAML(data, format)
+	if err != nil {
+		return Spec{}, err
+	}
+	if !isYAML {
+		source = nil
+	}
 	var cfg Spec
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return err
+		se := locateYAMLError(data, err)
+		if source == nil {
+			se.Line = 0
+		}
+		return Spec{}, se
+	}
+	err = cfg.validate()
+	if err == nil {
+		err = cfg.expandMatrix()
+	}
+	var se *SpecError
+	if errors.As(err, &se) && source != nil {
+		se.Line = specEntryLine(source, se.Section, se.Key)
+	}
+	if err != nil {
+		return Spec{}, err
+	}
+	return cfg, nil
+}
+
+// specYAML returns data, a spec in format, as YAML, and whether it was YAML
+// to begin with.
+func specYAML(data []byte, format string) ([]byte, bool, error) {
+	if format == "" {
+		format = detectFormat(data)
+	}
+	var v any
+	switch strings.ToLower(format) {
+	case FormatYAML, "yml":
+		return data, true, nil
+	case FormatJSON:
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, false, jsonSpecError(data, err)
+		}
+	case FormatTOML:
+		var m map[string]any
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return nil, false, tomlSpecError(err)
+		}
+		v = m
+	default:
+		return nil, false, fmt.Errorf("unknown spec format %q", format)
+	}
+	data, err := yaml.Marshal(v)
+	return data, false, err
+}
+
+// applySpec configures every rule in cfg, replacing all existing state unless
+// merge is set. The whole spec is applied under one lock, so Inject sees
+// either the previous configuration or the new one, never a mix.
+func (in *Injector) applySpec(cfg Spec, merge bool) {
+	in.mu.Lock()
+	conflicts := in.applySpecLocked(cfg, merge)
+	in.mu.Unlock()
+	in.logConflicts(conflicts)
+}
+
+// applySpecLocked is applySpec with mu held. It returns how mode conflicts
+// were resolved, to be logged once mu is released.
+func (in *Injector) applySpecLocked(cfg Spec, merge bool) map[string]string {
+	var current map[string]Rule
+	if merge {
+		current = maps.Clone(in.rules)
+	}
+	conflicts := resolveModeConflicts(&cfg, current, merge)
+	if !merge {
+		in.resetLocked()
+	}
+	for _, k := range cfg.keys() {
+		in.markKnownLocked(k)
+	}
+	for k, names := range cfg.Selectors {
+		in.setSelectorsLocked(k, names)
+	}
+	for k, list := range cfg.Tenants {
+		in.setTenantsLocked(k, list)
+	}
+	for _, k := range cfg.ExcludeSynthetic {
+		in.excludeSynthetic[k] = true
+		in.bumpGenerationLocked()
+	}
+	if len(cfg.Routes) > 0 {
+		routes := maps.Clone(in.routes)
+		if routes == nil {
+			routes = make(map[string]string, len(cfg.Routes))
+		}
+		maps.Copy(routes, cfg.Routes)
+		in.setRoutesLocked(routes) // validated when parsed
+	}
+	if cfg.Budget != nil {
+		in.budget = newBudget(cfg.Budget.Max, cfg.Budget.Window)
+		in.bumpGenerationLocked()
+	}
+	if in.isProductionEnvironment() {
+		return nil
+	}
+
+	for k, r := range cfg.Rules {
+		in.setRuleLocked(k, r)
 	}
-	Reset()
 	for k, v := range cfg.Failures {
-		SetFailures(k, v)
+		in.setFailuresLocked(k, v)
 	}
 	for k, v := range cfg.PreciseFailures {
-		SetNthFailure(k, v)
+		in.setNthPatternLocked(k, func(r *Rule) { r.Nth = v })
 	}
-	return nil
+	for k, dist := range cfg.Latencies {
+		in.setLatencyLocked(k, dist)
+	}
+	for k, cycle := range cfg.Flapping {
+		in.setFlappingLocked(k, cycle)
+	}
+	for k, skew := range cfg.ClockSkews {
+		in.setClockSkewLocked(k, skew)
+	}
+	for k, c := range cfg.Corruptions {
+		setKeyedLocked(in, in.corruptions, k, c, c == "")
+	}
+	for k, edge := range cfg.NumericEdges {
+		setKeyedLocked(in, in.numericEdges, k, edge, edge == "")
+	}
+	for k, f := range cfg.PageFaults {
+		setKeyedLocked(in, in.pageFaults, k, f, f == "")
+	}
+	for k, f := range cfg.IdempotencyFaults {
+		setKeyedLocked(in, in.idempotencyFaults, k, f, f == "")
+	}
+	for k, q := range cfg.QuotaResponses {
+		setKeyedLocked(in, in.quotaResponses, k, q, q.Status == 0)
+	}
+	for k, f := range cfg.SSEFaults {
+		setKeyedLocked(in, in.sseFaults, k, f, f == "")
+	}
+	for k, d := range cfg.Degradations {
+		setKeyedLocked(in, in.degradations, k, d, d.empty())
+	}
+	for k, f := range cfg.ResponseFaults {
+		setKeyedLocked(in, in.responseFaults, k, f, f == "")
+	}
+	for k, f := range cfg.ConnFaults {
+		setKeyedLocked(in, in.connFaults, k, f, f == "")
+	}
+	for k, bw := range cfg.Bandwidths {
+		setKeyedLocked(in, in.bandwidths, k, bw, bw <= 0)
+	}
+	// after the rules, which clear any TTL
+	for k, ttl := range cfg.Durations {
+		in.setTTLLocked(k, ttl)
+	}
+	in.setConflictsLocked(conflicts)
+	return conflicts
+}
+
+// keys returns every key cfg configures, in no particular order.
+func (cfg Spec) keys() []string {
+	var keys []string
+	keys = appendKeys(keys, cfg.Rules)
+	keys = appendKeys(keys, cfg.Failures)
+	keys = appendKeys(keys, cfg.PreciseFailures)
+	keys = appendKeys(keys, cfg.Selectors)
+	keys = appendKeys(keys, cfg.Tenants)
+	keys = append(keys, cfg.ExcludeSynthetic...)
+	keys = appendKeys(keys, cfg.Latencies)
+	keys = appendKeys(keys, cfg.Flapping)
+	keys = appendKeys(keys, cfg.ClockSkews)
+	keys = appendKeys(keys, cfg.Corruptions)
+	keys = appendKeys(keys, cfg.NumericEdges)
+	keys = appendKeys(keys, cfg.PageFaults)
+	keys = appendKeys(keys, cfg.IdempotencyFaults)
+	keys = appendKeys(keys, cfg.QuotaResponses)
+	keys = appendKeys(keys, cfg.SSEFaults)
+	keys = appendKeys(keys, cfg.Degradations)
+	keys = appendKeys(keys, cfg.ResponseFaults)
+	keys = appendKeys(keys, cfg.ConnFaults)
+	keys = appendKeys(keys, cfg.Bandwidths)
+	for _, k := range cfg.Routes {
+		keys = append(keys, k)
+	}
+	return appendKeys(keys, cfg.Durations)
+}
+
+func appendKeys[V any](keys []string, m map[string]V) []string {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
 }
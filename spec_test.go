@@ -1,9 +1,16 @@
+//go:build faultinject
+
 package faultinject
 
 import (
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestLoadSpec(t *testing.T) {
@@ -426,3 +433,201 @@ failures:
 		}
 	})
 }
+
+func TestLoadSpecFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+	}{
+		{
+			name:   "yaml",
+			format: FormatYAML,
+			content: `failures:
+  db: 2
+precise-failures:
+  api: 1
+latencies:
+  db: [{min: 5ms, max: 5ms, weight: 1}]
+quota-responses:
+  billing: payment-required
+`,
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			content: `{
+	"failures": {"db": 2},
+	"precise-failures": {"api": 1},
+	"latencies": {"db": [{"min": "5ms", "max": "5ms", "weight": 1}]},
+	"quota-responses": {"billing": "payment-required"}
+}`,
+		},
+		{
+			name:   "toml",
+			format: FormatTOML,
+			content: `[failures]
+db = 2
+
+[precise-failures]
+api = 1
+
+[[latencies.db]]
+min = "5ms"
+max = "5ms"
+weight = 1
+
+[quota-responses]
+billing = "payment-required"
+`,
+		},
+	}
+	for _, tt := range tests {
+		for _, format := range []string{tt.format, ""} {
+			t.Run(tt.name+"/"+format, func(t *testing.T) {
+				resetState()
+				if err := LoadSpecFromBytes([]byte(tt.content), format); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if got := Status()["db"]; got != 2 {
+					t.Errorf("Expected 2 failures for db, got %d", got)
+				}
+				if r := Rules()["api"]; r.Mode != ModeNth || r.Nth != 1 {
+					t.Errorf("Expected nth 1 for api, got %+v", r)
+				}
+				if r := Rules()["db"]; len(r.Latency) != 1 || r.Latency[0].Max != 5*time.Millisecond {
+					t.Errorf("Expected a 5ms latency for db, got %+v", r.Latency)
+				}
+				if q := std.quotaResponseFor("billing"); q.Status != PaymentRequired.Status {
+					t.Errorf("Expected the payment-required preset, got %d", q.Status)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadSpecByExtension(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"faults.json": `{"failures": {"db": 1}}`,
+		"faults.toml": "[failures]\ndb = 1\n",
+		"faults.yml":  "failures:\n  db: 1\n",
+		"faults.conf": "# detected from the contents\n[failures]\ndb = 1\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			resetState()
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := LoadSpec(path); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Inject("db") {
+				t.Error("Expected the spec to make db fail")
+			}
+		})
+	}
+}
+
+func TestLoadSpecFromBytesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+	}{
+		{name: "unknown format", format: "ini", content: "failures:\n  db: 1\n"},
+		{name: "invalid json", format: FormatJSON, content: `{"failures": `},
+		{name: "invalid toml", format: FormatTOML, content: "[failures\n"},
+		{name: "wrong type in json", format: FormatJSON, content: `{"failures": {"db": "many"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailures("kept", 1)
+			if err := LoadSpecFromBytes([]byte(tt.content), tt.format); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+			if Status()["kept"] != 1 {
+				t.Error("Expected a failed load to keep the current rules")
+			}
+		})
+	}
+}
+
+func TestLoadSpecFromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "yaml", content: "failures:\n  db: 1\n"},
+		{name: "json", content: `{"failures": {"db": 1}}`},
+		{name: "toml", content: "[failures]\ndb = 1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			if err := LoadSpecFromReader(strings.NewReader(tt.content)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !Inject("db") {
+				t.Error("Expected the spec to make db fail")
+			}
+		})
+	}
+}
+
+func TestLoadSpecFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"specs/faults.toml": {Data: []byte("[failures]\ndb = 1\n")},
+		"specs/faults.json": {Data: []byte(`{"precise-failures": {"db": 1}}`)},
+	}
+
+	resetState()
+	if err := LoadSpecFS(fsys, "specs/faults.toml"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if r := Rules()["db"]; r.Mode != ModeFirstN {
+		t.Errorf("Expected a first-n rule, got %q", r.Mode)
+	}
+	if err := LoadSpecFS(fsys, "specs/faults.json"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if r := Rules()["db"]; r.Mode != ModeNth {
+		t.Errorf("Expected the second spec to replace the first, got %q", r.Mode)
+	}
+	if err := LoadSpecFS(fsys, "specs/missing.yaml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestLoadSpecPublishesOnce(t *testing.T) {
+	resetState()
+	spec := []byte("failures:\n  db: 1000000\nlatencies:\n  db:\n    - {min: 1ms, max: 1ms, weight: 1}\n")
+	if err := LoadSpecFromBytes(spec, FormatYAML); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 2000 {
+			if err := LoadSpecFromBytes(spec, FormatYAML); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		r, ok := Rules()["db"]
+		if !ok || r.Mode != ModeFirstN || len(r.Latency) == 0 {
+			t.Fatalf("Expected a reload never to publish a partial spec, got %+v", r)
+		}
+	}
+}
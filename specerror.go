@@ -0,0 +1,327 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// SpecError reports the entry a spec was rejected for. A spec is decoded and
+// validated in full before any of it is applied, so a load that fails with a
+// SpecError leaves the injector exactly as it was: none of the spec is
+// applied and the previous rules stay in place.
+type SpecError struct {
+	File    string // path of the spec, if it was loaded from a file
+	Line    int    // line of the entry, or 0 if unknown
+	Section string // section of the entry, such as "rules" or "failures"
+	Key     string // key of the entry, or its number in a list such as "matrix"
+	Err     error
+}
+
+func (e *SpecError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ":")
+	}
+	if e.Line > 0 {
+		b.WriteString(strconv.Itoa(e.Line) + ":")
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Section != "" {
+		b.WriteString(e.Section)
+		if e.Key != "" {
+			b.WriteString("[" + e.Key + "]")
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	b.WriteString(" (spec not applied, previous rules kept)")
+	return b.String()
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// inFile sets the file of err if it is a SpecError.
+func inFile(err error, path string) error {
+	var se *SpecError
+	if errors.As(err, &se) {
+		se.File = path
+	}
+	return err
+}
+
+// yamlLine matches the line yaml.v3 reports in its messages.
+var yamlLine = regexp.MustCompile(`line (\d+): `)
+
+// locateYAMLError turns err, from decoding data into a Spec, into a
+// SpecError for the entry that caused it, by decoding the entries of each
+// section one at a time.
+func locateYAMLError(data []byte, err error) *SpecError {
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return yamlSpecError(err, 0)
+	}
+	doc := root.Content[0]
+	specType := reflect.TypeFor[Spec]()
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		section, value := doc.Content[i].Value, doc.Content[i+1]
+		field, ok := specField(specType, section)
+		if !ok {
+			continue
+		}
+		se := &SpecError{Section: section}
+		switch {
+		case field.Type.Kind() == reflect.Map && value.Kind == yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if err := value.Content[j+1].Decode(reflect.New(field.Type.Elem()).Interface()); err != nil {
+					se.Key = value.Content[j].Value
+					return yamlEntryError(se, err, value.Content[j])
+				}
+			}
+		case field.Type.Kind() == reflect.Slice && value.Kind == yaml.SequenceNode:
+			for j, item := range value.Content {
+				if err := item.Decode(reflect.New(field.Type.Elem()).Interface()); err != nil {
+					se.Key = strconv.Itoa(j + 1)
+					return yamlEntryError(se, err, item)
+				}
+			}
+		default:
+			if err := value.Decode(reflect.New(field.Type).Interface()); err != nil {
+				return yamlEntryError(se, err, doc.Content[i])
+			}
+		}
+	}
+	return yamlSpecError(err, 0)
+}
+
+// yamlEntryError completes se with err, found decoding the entry at node.
+func yamlEntryError(se *SpecError, err error, node *yaml.Node) *SpecError {
+	e := yamlSpecError(err, node.Line)
+	e.Section, e.Key = se.Section, se.Key
+	return e
+}
+
+// yamlSpecError returns a SpecError for err, taking the line from yaml.v3's
+// message if it has one, or else line. The "yaml:" prefixes are dropped.
+func yamlSpecError(err error, line int) *SpecError {
+	msg := err.Error()
+	var te *yaml.TypeError
+	if errors.As(err, &te) && len(te.Errors) > 0 {
+		msg = te.Errors[0]
+	}
+	msg = strings.TrimPrefix(msg, "yaml: ")
+	if m := yamlLine.FindStringSubmatchIndex(msg); m != nil {
+		line, _ = strconv.Atoi(msg[m[2]:m[3]])
+		msg = msg[:m[0]] + msg[m[1]:]
+	}
+	return &SpecError{Line: line, Err: errors.New(msg)}
+}
+
+// specField returns the field of t with the yaml name name.
+func specField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if tag, _, _ := strings.Cut(f.Tag.Get("yaml"), ","); tag == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// specEntryLine returns the line of the entry key in section of the YAML
+// spec data, or 0 if it cannot be found.
+func specEntryLine(data []byte, section, key string) int {
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+		return 0
+	}
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != section {
+			continue
+		}
+		value := doc.Content[i+1]
+		if key == "" {
+			return doc.Content[i].Line
+		}
+		switch value.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				if value.Content[j].Value == key {
+					return value.Content[j].Line
+				}
+			}
+		case yaml.SequenceNode:
+			if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(value.Content) {
+				return value.Content[n-1].Line
+			}
+			for _, item := range value.Content {
+				if item.Value == key {
+					return item.Line
+				}
+			}
+		}
+		return doc.Content[i].Line
+	}
+	return 0
+}
+
+// jsonSpecError returns a SpecError for err, from decoding the JSON spec
+// data, with the line of the offset it reports.
+func jsonSpecError(data []byte, err error) *SpecError {
+	offset := int64(-1)
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		offset = syntax.Offset
+	case errors.As(err, &typ):
+		offset = typ.Offset
+	}
+	if offset < 0 || offset > int64(len(data)) {
+		return &SpecError{Err: err}
+	}
+	return &SpecError{Line: bytes.Count(data[:offset], []byte("\n")) + 1, Err: err}
+}
+
+// tomlSpecError returns a SpecError for err, from decoding a TOML spec.
+func tomlSpecError(err error) *SpecError {
+	var pe toml.ParseError
+	if errors.As(err, &pe) {
+		return &SpecError{Line: pe.Position.Line, Err: errors.New(pe.Message)}
+	}
+	return &SpecError{Err: err}
+}
+
+// validate returns a SpecError for the first entry of cfg that cannot be
+// applied as written, checking sections in a fixed order and keys in sorted
+// order so the same spec always reports the same entry. Negative counts are
+// accepted, as the setters treat them as zero.
+func (cfg Spec) validate() error {
+	for _, k := range sortedKeys(cfg.Rules) {
+		if err := cfg.Rules[k].validate(); err != nil {
+			return &SpecError{Section: "rules", Key: k, Err: err}
+		}
+	}
+	for _, k := range sortedKeys(cfg.Latencies) {
+		if err := cfg.Latencies[k].validate(); err != nil {
+			return &SpecError{Section: "latencies", Key: k, Err: err}
+		}
+	}
+	for _, k := range sortedKeys(cfg.Flapping) {
+		if err := cfg.Flapping[k].validate(); err != nil {
+			return &SpecError{Section: "flapping", Key: k, Err: err}
+		}
+	}
+	for _, k := range sortedKeys(cfg.Bandwidths) {
+		if cfg.Bandwidths[k] <= 0 {
+			return &SpecError{Section: "bandwidths", Key: k, Err: fmt.Errorf("bandwidth %d is not positive", cfg.Bandwidths[k])}
+		}
+	}
+	mux := http.NewServeMux()
+	for _, p := range sortedKeys(cfg.Routes) {
+		if cfg.Routes[p] == "" {
+			return &SpecError{Section: "routes", Key: p, Err: errors.New("no key")}
+		}
+		if err := addRoute(mux, p); err != nil {
+			return &SpecError{Section: "routes", Key: p, Err: err}
+		}
+	}
+	for _, k := range sortedKeys(cfg.Durations) {
+		if cfg.Durations[k] < 0 {
+			return &SpecError{Section: "durations", Key: k, Err: fmt.Errorf("negative duration %v", cfg.Durations[k])}
+		}
+	}
+	if err := cfg.Budget.validate(); err != nil {
+		return &SpecError{Section: "budget", Err: err}
+	}
+	return nil
+}
+
+// validate reports settings of r that cannot take effect as written.
+func (r Rule) validate() error {
+	switch r.Mode {
+	case ModeNone, ModeFirstN, ModeNth, ModeRate, ModeFlapping, ModeActions:
+	default:
+		return fmt.Errorf("unknown mode %q", r.Mode)
+	}
+	if err := r.validateMode(); err != nil {
+		return err
+	}
+	if err := r.validateNth(); err != nil {
+		return err
+	}
+	if err := r.validateLabels(); err != nil {
+		return err
+	}
+	if err := r.Budget.validate(); err != nil {
+		return err
+	}
+	switch {
+	case r.Rate < 0 || r.Rate > 1 || math.IsNaN(r.Rate):
+		return fmt.Errorf("rate %g is not between 0 and 1", r.Rate)
+	case r.Instances < 0 || r.Instances > 1 || math.IsNaN(r.Instances):
+		return fmt.Errorf("instances %g is not between 0 and 1", r.Instances)
+	case r.Status != 0 && (r.Status < 100 || r.Status > 599):
+		return fmt.Errorf("invalid HTTP status %d", r.Status)
+	}
+	if _, err := compileCaller(r.OnlyFrom); err != nil {
+		return fmt.Errorf("only-from: %v", err)
+	}
+	if r.Flap != nil {
+		if err := r.Flap.validate(); err != nil {
+			return err
+		}
+	}
+	for i, a := range r.Actions {
+		switch {
+		case a.Calls < 0:
+			return fmt.Errorf("action %d: negative calls %d", i+1, a.Calls)
+		case a.Latency < 0:
+			return fmt.Errorf("action %d: negative latency %v", i+1, a.Latency)
+		case a.Calls == 0 && i < len(r.Actions)-1:
+			return fmt.Errorf("action %d: only the last action can last every remaining call", i+1)
+		}
+	}
+	return r.Latency.validate()
+}
+
+// validate reports buckets of dist that cannot be sampled as written.
+func (dist LatencyDistribution) validate() error {
+	for i, b := range dist {
+		switch {
+		case b.Min < 0 || b.Max < b.Min:
+			return fmt.Errorf("latency bucket %d: invalid range %v-%v", i+1, b.Min, b.Max)
+		case b.Weight < 0 || math.IsNaN(b.Weight):
+			return fmt.Errorf("latency bucket %d: invalid weight %g", i+1, b.Weight)
+		}
+	}
+	return nil
+}
+
+// validate reports a cycle whose unhealthy part does not fit its period.
+func (c FlapCycle) validate() error {
+	if c.Period < 0 || c.Unhealthy < 0 || c.Unhealthy > c.Period {
+		return fmt.Errorf("unhealthy %v does not fit in period %v", c.Unhealthy, c.Period)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpecError(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		spec    string
+		section string
+		key     string
+		line    int
+		msg     string
+	}{
+		{
+			name:    "wrong type in rule",
+			format:  FormatYAML,
+			spec:    "rules:\n  db: {count: 1}\n  api:\n    count: many\n",
+			section: "rules",
+			key:     "api",
+			line:    4,
+			msg:     "cannot unmarshal",
+		},
+		{
+			name:    "invalid duration",
+			format:  FormatYAML,
+			spec:    "failures:\n  db: 1\ndurations:\n  db: soon\n",
+			section: "durations",
+			key:     "db",
+			line:    4,
+			msg:     "cannot unmarshal",
+		},
+		{
+			name:    "unknown preset",
+			format:  FormatYAML,
+			spec:    "quota-responses:\n  openai: openai-insufficient-quota\n  billing: broke\n",
+			section: "quota-responses",
+			key:     "billing",
+			line:    3,
+			msg:     `unknown quota preset "broke"`,
+		},
+		{
+			name:    "rate out of range",
+			format:  FormatYAML,
+			spec:    "rules:\n  db: {count: 1}\n  api: {rate: 1.5}\n",
+			section: "rules",
+			key:     "api",
+			line:    3,
+			msg:     "rate 1.5 is not between 0 and 1",
+		},
+		{
+			name:    "unknown mode",
+			format:  FormatYAML,
+			spec:    "rules:\n  api: {mode: sometimes}\n",
+			section: "rules",
+			key:     "api",
+			line:    2,
+			msg:     `unknown mode "sometimes"`,
+		},
+		{
+			name:    "flap cycle",
+			format:  FormatYAML,
+			spec:    "flapping:\n  lb: {period: 10s, unhealthy: 20s}\n",
+			section: "flapping",
+			key:     "lb",
+			line:    2,
+			msg:     "does not fit",
+		},
+		{
+			name:    "matrix entry",
+			format:  FormatYAML,
+			spec:    "matrix:\n  - keys: [a]\n    rule: {count: 1}\n  - keys: [\"b-{x}\"]\n    rule: {count: 1}\n",
+			section: "matrix",
+			key:     "2",
+			line:    4,
+			msg:     `undefined variable "x"`,
+		},
+		{
+			name:   "yaml syntax",
+			format: FormatYAML,
+			spec:   "failures:\n  db: 1\n  api: x: y\n",
+			line:   3,
+		},
+		{
+			name:    "json entry",
+			format:  FormatJSON,
+			spec:    `{"rules": {"api": {"rate": 2}}}`,
+			section: "rules",
+			key:     "api",
+			msg:     "rate 2 is not between 0 and 1",
+		},
+		{
+			name:   "json syntax",
+			format: FormatJSON,
+			spec:   "{\n  \"failures\": {\n    \"db\": 1,\n  }\n}",
+			line:   4,
+		},
+		{
+			name:   "toml syntax",
+			format: FormatTOML,
+			spec:   "[failures]\ndb = 1\napi = \n",
+			line:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailures("kept", 1)
+			err := LoadSpecFromBytes([]byte(tt.spec), tt.format)
+			var se *SpecError
+			if !errors.As(err, &se) {
+				t.Fatalf("Expected a *SpecError, got %v", err)
+			}
+			if se.Section != tt.section || se.Key != tt.key || se.Line != tt.line {
+				t.Errorf("Expected %s[%s] at line %d, got %s[%s] at line %d", tt.section, tt.key, tt.line, se.Section, se.Key, se.Line)
+			}
+			if !strings.Contains(se.Err.Error(), tt.msg) {
+				t.Errorf("Expected error containing %q, got %q", tt.msg, se.Err)
+			}
+			if Status()["kept"] != 1 {
+				t.Error("Expected a failed load to keep the current rules")
+			}
+		})
+	}
+}
+
+func TestSpecErrorFile(t *testing.T) {
+	resetState()
+	SetFailures("kept", 1)
+	path := filepath.Join(t.TempDir(), "faults.yaml")
+	if err := os.WriteFile(path, []byte("rules:\n  api:\n    status: 1000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := LoadSpec(path)
+	want := path + `:2: rules[api]: invalid HTTP status 1000 (spec not applied, previous rules kept)`
+	if err == nil || err.Error() != want {
+		t.Errorf("Expected %q, got %v", want, err)
+	}
+	if Status()["kept"] != 1 {
+		t.Error("Expected a failed load to keep the current rules")
+	}
+
+	if _, err := WatchSpec(path, WatchOptions{}); err == nil || !strings.HasPrefix(err.Error(), path+":2: ") {
+		t.Errorf("Expected the error to name %s, got %v", path, err)
+	}
+}
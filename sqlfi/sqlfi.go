@@ -0,0 +1,323 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sqlfi injects faults at database/sql driver boundaries, so
+// connection, query and transaction failures and latency can be tested
+// without touching call sites.
+//
+// A wrapped driver evaluates one key per operation, formed from the key
+// passed to Wrap:
+//
+//	<key>.connect  opening a connection
+//	<key>.query    Query and QueryRow
+//	<key>.exec     Exec
+//	<key>.begin    starting a transaction
+//	<key>.commit   committing a transaction
+//
+// Each operation first sleeps for its key's latency (see
+// faultinject.SetLatency), then fails with a *faultinject.InjectedError if
+// the key fires. Use a pattern such as "orders-db.*" to target all of them.
+// Query and Exec calls carry the SQL text as the "query" attribute for
+// selectors.
+package sqlfi
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+var (
+	registerMu sync.Mutex
+	registered = make(map[string]bool)
+)
+
+// Wrap registers a fault-injecting copy of the database/sql driver
+// registered as driverName and returns the name to pass to sql.Open instead.
+// Wrapping the same driver and key again returns the same name.
+func Wrap(driverName, key string) (string, error) {
+	name := driverName + "+fi:" + key
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if registered[name] {
+		return name, nil
+	}
+
+	// sql.Open only looks the driver up; it does not connect
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return "", fmt.Errorf("sqlfi: %w", err)
+	}
+	d := db.Driver()
+	db.Close()
+
+	sql.Register(name, WrapDriver(d, key))
+	registered[name] = true
+	return name, nil
+}
+
+// WrapDriver returns d with faults injected under key.
+func WrapDriver(d driver.Driver, key string) driver.Driver {
+	if dc, ok := d.(driver.DriverContext); ok {
+		return &contextDriver{wrappedDriver{Driver: d, key: key}, dc}
+	}
+	return &wrappedDriver{Driver: d, key: key}
+}
+
+// WrapConnector returns c with faults injected under key, for sql.OpenDB.
+func WrapConnector(c driver.Connector, key string) driver.Connector {
+	return &connector{Connector: c, key: key}
+}
+
+// inject applies the latency and failure rules of key+"."+op.
+func inject(ctx context.Context, key, op, query string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if query != "" {
+		ctx = faultinject.WithAttributes(ctx, faultinject.Attributes{"query": query})
+	}
+	k := key + "." + op
+	if _, err := faultinject.InjectLatency(ctx, k); err != nil {
+		return err
+	}
+	return faultinject.InjectWithContextError(ctx, k, "sql "+op)
+}
+
+type wrappedDriver struct {
+	driver.Driver
+	key string
+}
+
+func (d *wrappedDriver) Open(name string) (driver.Conn, error) {
+	if err := inject(context.Background(), d.key, "connect", ""); err != nil {
+		return nil, err
+	}
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, key: d.key}, nil
+}
+
+type contextDriver struct {
+	wrappedDriver
+	dc driver.DriverContext
+}
+
+func (d *contextDriver) OpenConnector(name string) (driver.Connector, error) {
+	c, err := d.dc.OpenConnector(name)
+	if err != nil {
+		return nil, err
+	}
+	return &connector{Connector: c, key: d.key, driver: d}, nil
+}
+
+type connector struct {
+	driver.Connector
+	key    string
+	driver driver.Driver // nil to report the underlying driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if err := inject(ctx, c.key, "connect", ""); err != nil {
+		return nil, err
+	}
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, key: c.key}, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	if c.driver != nil {
+		return c.driver
+	}
+	return WrapDriver(c.Connector.Driver(), c.key)
+}
+
+// conn passes optional interfaces through to the underlying connection,
+// returning driver.ErrSkip where database/sql has a fallback.
+type conn struct {
+	driver.Conn
+	key string
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		s   driver.Stmt
+		err error
+	)
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		s, err = pc.PrepareContext(ctx, query)
+	} else {
+		s, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{Stmt: s, key: c.key, query: query}, nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := inject(ctx, c.key, "begin", ""); err != nil {
+		return nil, err
+	}
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if bc, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wrappedTx{Tx: tx, ctx: ctx, key: c.key}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip // database/sql prepares a stmt instead
+	}
+	if err := inject(ctx, c.key, "exec", query); err != nil {
+		return nil, err
+	}
+	return ec.ExecContext(ctx, query, args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip // database/sql prepares a stmt instead
+	}
+	if err := inject(ctx, c.key, "query", query); err != nil {
+		return nil, err
+	}
+	return qc.QueryContext(ctx, query, args)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type wrappedTx struct {
+	driver.Tx
+	ctx context.Context
+	key string
+}
+
+func (t *wrappedTx) Commit() error {
+	if err := inject(t.ctx, t.key, "commit", ""); err != nil {
+		// database/sql treats the transaction as done after Commit fails
+		t.Tx.Rollback()
+		return err
+	}
+	return t.Tx.Commit()
+}
+
+type stmt struct {
+	driver.Stmt
+	key   string
+	query string
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := inject(context.Background(), s.key, "exec", s.query); err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := inject(context.Background(), s.key, "query", s.query); err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(args)
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := inject(ctx, s.key, "exec", s.query); err != nil {
+		return nil, err
+	}
+	if ec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return ec.ExecContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if err := inject(ctx, s.key, "query", s.query); err != nil {
+		return nil, err
+	}
+	if qc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return qc.QueryContext(ctx, args)
+	}
+	values, err := namedValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values)
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nc, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return nc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		if a.Name != "" {
+			return nil, fmt.Errorf("sqlfi: driver does not support named parameter %s", a.Name)
+		}
+		values[i] = a.Value
+	}
+	return values, nil
+}
@@ -0,0 +1,213 @@
+//go:build faultinject
+
+package sqlfi
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi"
+)
+
+// fakeDriver is a minimal driver without the optional context interfaces,
+// so database/sql goes through Prepare and Stmt.
+type fakeDriver struct {
+	contextConn bool
+	commits     atomic.Int32
+	rollbacks   atomic.Int32
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	c := &fakeConn{d: d}
+	if d.contextConn {
+		return &fakeContextConn{c}, nil
+	}
+	return c, nil
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return fakeTx{c.d}, nil }
+
+// fakeContextConn adds ExecerContext and QueryerContext.
+type fakeContextConn struct{ *fakeConn }
+
+func (c *fakeContextConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeContextConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                               { return nil }
+func (fakeStmt) NumInput() int                              { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query([]driver.Value) (driver.Rows, error)  { return &fakeRows{}, nil }
+
+type fakeTx struct{ d *fakeDriver }
+
+func (t fakeTx) Commit() error   { t.d.commits.Add(1); return nil }
+func (t fakeTx) Rollback() error { t.d.rollbacks.Add(1); return nil }
+
+type fakeRows struct{ done bool }
+
+func (r *fakeRows) Columns() []string { return []string{"n"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func resetState() {
+	faultinject.Reset()
+	os.Setenv("ENVIRONMENT", "development")
+}
+
+func openDB(t *testing.T, d *fakeDriver) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(WrapConnector(&fakeConnector{d}, "orders-db"))
+	db.SetMaxIdleConns(0) // every operation opens a connection
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type fakeConnector struct{ d *fakeDriver }
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c *fakeConnector) Driver() driver.Driver                        { return c.d }
+
+func TestOperations(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		run  func(db *sql.DB) error
+	}{
+		{name: "connect", key: "orders-db.connect", run: func(db *sql.DB) error { return db.Ping() }},
+		{name: "query", key: "orders-db.query", run: func(db *sql.DB) error {
+			var n int
+			return db.QueryRow("SELECT 1").Scan(&n)
+		}},
+		{name: "exec", key: "orders-db.exec", run: func(db *sql.DB) error {
+			_, err := db.Exec("DELETE FROM orders")
+			return err
+		}},
+		{name: "begin", key: "orders-db.begin", run: func(db *sql.DB) error {
+			_, err := db.Begin()
+			return err
+		}},
+		{name: "commit", key: "orders-db.commit", run: func(db *sql.DB) error {
+			tx, err := db.Begin()
+			if err != nil {
+				return err
+			}
+			return tx.Commit()
+		}},
+	}
+
+	for _, contextConn := range []bool{false, true} {
+		for _, tt := range tests {
+			name := tt.name
+			if contextConn {
+				name += " with context conn"
+			}
+			t.Run(name, func(t *testing.T) {
+				resetState()
+				db := openDB(t, &fakeDriver{contextConn: contextConn})
+				if err := tt.run(db); err != nil {
+					t.Fatalf("Expected no error without a rule, got %v", err)
+				}
+
+				faultinject.SetFailures(tt.key, 1)
+				err := tt.run(db)
+				if !errors.Is(err, faultinject.ErrInjected) {
+					t.Errorf("Expected an injected error, got %v", err)
+				}
+				if err := tt.run(db); err != nil {
+					t.Errorf("Expected no error once the rule is exhausted, got %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestCommitFailureRollsBack(t *testing.T) {
+	resetState()
+	d := &fakeDriver{}
+	db := openDB(t, d)
+	faultinject.SetFailures("orders-db.commit", 1)
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin returned error: %v", err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Expected commit to fail")
+	}
+	if d.commits.Load() != 0 || d.rollbacks.Load() != 1 {
+		t.Errorf("Expected the failed commit to roll back, got %d commits and %d rollbacks", d.commits.Load(), d.rollbacks.Load())
+	}
+}
+
+func TestLatencyAndQuerySelector(t *testing.T) {
+	resetState()
+	db := openDB(t, &fakeDriver{contextConn: true})
+	faultinject.RegisterSelector("deletes", faultinject.AttributeSelector("query", "DELETE FROM orders"))
+	faultinject.SetFailures("orders-db.*", 5)
+	faultinject.SetSelectors("orders-db.*", "deletes")
+	faultinject.SetLatency("orders-db.query", faultinject.FixedLatency(20*time.Millisecond))
+
+	if _, err := db.Exec("UPDATE orders SET paid = true"); err != nil {
+		t.Errorf("Expected other statements to succeed, got %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM orders"); err == nil {
+		t.Error("Expected the selected statement to fail")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := db.QueryContext(ctx, "SELECT 1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the query to time out during injected latency, got %v", err)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	resetState()
+	sql.Register("sqlfi-fake", &fakeDriver{})
+	name, err := Wrap("sqlfi-fake", "orders-db")
+	if err != nil {
+		t.Fatalf("Wrap returned error: %v", err)
+	}
+	if again, err := Wrap("sqlfi-fake", "orders-db"); err != nil || again != name {
+		t.Errorf("Expected wrapping again to return %q, got %q, %v", name, again, err)
+	}
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	defer db.Close()
+	faultinject.SetFailures("orders-db.connect", 1)
+	if err := db.Ping(); !errors.Is(err, faultinject.ErrInjected) {
+		t.Errorf("Expected an injected connection failure, got %v", err)
+	}
+
+	if _, err := Wrap("no-such-driver", "orders-db"); err == nil {
+		t.Error("Expected error for an unknown driver")
+	}
+}
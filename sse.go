@@ -0,0 +1,181 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net/http"
+)
+
+// SSEFault is a way of breaking a server-sent events stream between or inside
+// events, for testing reconnection and parsing in SSE clients.
+type SSEFault string
+
+const (
+	// SSEDrop closes the connection before the event, after the events before
+	// it are flushed, as when a proxy or the server goes away mid-stream. A
+	// client should reconnect with Last-Event-ID.
+	SSEDrop SSEFault = "drop"
+	// SSEDelay holds the event back for a sample of the key's latency
+	// distribution, set with SetLatency, as a stalled stream would.
+	SSEDelay SSEFault = "delay"
+	// SSEMalformed cuts the event in half and terminates it there, so the
+	// client receives a complete frame with a broken field.
+	SSEMalformed SSEFault = "malformed"
+)
+
+// SetSSEFault sets how SSEMiddleware breaks the stream for key when it fires.
+// The default is SSEDrop. An empty SSEFault restores the default.
+func (in *Injector) SetSSEFault(key string, fault SSEFault) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.sseFaults, key, fault, fault == "")
+}
+
+// SetSSEFault calls SetSSEFault on the default Injector.
+func SetSSEFault(key string, fault SSEFault) {
+	std.SetSSEFault(key, fault)
+}
+
+// SSEMiddleware creates middleware for server-sent events endpoints that
+// breaks the stream as configured by SetSSEFault. The response is split into
+// events at blank lines and each event is one call to key, so
+// SetNthFailure(key, 3) breaks the third event. Responses that are not
+// text/event-stream are passed through without calls to key.
+func (in *Injector) SSEMiddleware(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithAttributes(r.Context(), in.requestAttributes(r))
+			sw := &sseWriter{ResponseWriter: w, in: in, ctx: ctx, key: key}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+			sw.finish()
+		})
+	}
+}
+
+// SSEMiddleware calls SSEMiddleware on the default Injector.
+func SSEMiddleware(key string) func(http.Handler) http.Handler {
+	return std.SSEMiddleware(key)
+}
+
+// sseWriter holds back partial events so that faults apply to whole events.
+type sseWriter struct {
+	http.ResponseWriter
+	in      *Injector
+	ctx     context.Context
+	key     string
+	checked bool
+	stream  bool
+	pending []byte
+}
+
+// check decides, on the first write, whether the response is an event stream.
+func (w *sseWriter) check() {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.stream = mediaType == "text/event-stream"
+}
+
+func (w *sseWriter) WriteHeader(code int) {
+	w.check()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sseWriter) Write(b []byte) (int, error) {
+	w.check()
+	if !w.stream {
+		return w.ResponseWriter.Write(b)
+	}
+	w.pending = append(w.pending, b...)
+	for {
+		end := eventEnd(w.pending)
+		if end < 0 {
+			return len(b), nil
+		}
+		event := w.pending[:end:end]
+		w.pending = w.pending[end:]
+		if err := w.writeEvent(event); err != nil {
+			return len(b), err
+		}
+	}
+}
+
+// writeEvent writes one complete event, applying the fault if key fires.
+func (w *sseWriter) writeEvent(event []byte) error {
+	if !w.in.InjectWithContext(w.ctx, w.key) {
+		_, err := w.ResponseWriter.Write(event)
+		return err
+	}
+	switch w.in.sseFaultFor(w.key) {
+	case SSEDelay:
+		w.in.InjectLatency(w.ctx, w.key)
+	case SSEMalformed:
+		cut := runeBoundary(event, len(event)/2)
+		event = append(event[:cut:cut], "\n\n"...)
+	default:
+		w.flush()
+		panic(http.ErrAbortHandler)
+	}
+	_, err := w.ResponseWriter.Write(event)
+	return err
+}
+
+// Flush sends the complete events written so far. A partial event stays
+// buffered until the rest of it is written.
+func (w *sseWriter) Flush() {
+	w.check()
+	w.flush()
+}
+
+func (w *sseWriter) flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish writes a trailing partial event, which the client will discard.
+func (w *sseWriter) finish() {
+	if len(w.pending) > 0 {
+		w.ResponseWriter.Write(w.pending)
+		w.pending = nil
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *sseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// eventEnd returns the index just past the blank line that ends the first
+// event in b, or -1 if b holds no complete event. Lines may end in "\n",
+// "\r\n" or "\r".
+func eventEnd(b []byte) int {
+	end := -1
+	for _, sep := range []string{"\n\n", "\r\n\r\n", "\r\r"} {
+		if i := bytes.Index(b, []byte(sep)); i >= 0 && (end < 0 || i+len(sep) < end) {
+			end = i + len(sep)
+		}
+	}
+	return end
+}
+
+func (in *Injector) sseFaultFor(key string) SSEFault {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	if f, ok := in.sseFaults[in.ruleKeyLocked(key)]; ok {
+		return f
+	}
+	return SSEDrop
+}
@@ -0,0 +1,107 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// eventServer streams three events, flushing after each one.
+func eventServer(contentType string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		for _, event := range []string{"id: 1\ndata: one\n\n", "id: 2\ndata: two\n\n", "id: 3\r\ndata: three\r\n\r\n"} {
+			// split writes must not split the event
+			io.WriteString(w, event[:3])
+			io.WriteString(w, event[3:])
+			w.(http.Flusher).Flush()
+		}
+	})
+}
+
+func TestSSEMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		fault       SSEFault
+		nth         int
+		expected    string
+		wantErr     bool
+	}{
+		{name: "no fault", contentType: "text/event-stream", expected: "id: 1\ndata: one\n\nid: 2\ndata: two\n\nid: 3\r\ndata: three\r\n\r\n"},
+		{name: "drop", contentType: "text/event-stream", fault: SSEDrop, nth: 2, expected: "id: 1\ndata: one\n\n", wantErr: true},
+		{name: "default drops", contentType: "text/event-stream", nth: 3, expected: "id: 1\ndata: one\n\nid: 2\ndata: two\n\n", wantErr: true},
+		{name: "malformed", contentType: "text/event-stream; charset=utf-8", fault: SSEMalformed, nth: 2, expected: "id: 1\ndata: one\n\nid: 2\nda\n\nid: 3\r\ndata: three\r\n\r\n"},
+		{name: "not an event stream", contentType: "text/plain", fault: SSEDrop, nth: 1, expected: "id: 1\ndata: one\n\nid: 2\ndata: two\n\nid: 3\r\ndata: three\r\n\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetSSEFault("events", tt.fault)
+			if tt.nth > 0 {
+				SetNthFailure("events", tt.nth)
+			}
+			server := httptest.NewServer(SSEMiddleware("events")(eventServer(tt.contentType)))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != tt.expected {
+				t.Errorf("Expected body %q, got %q", tt.expected, body)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected read error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSSEMiddlewareDelay(t *testing.T) {
+	resetState()
+	SetSSEFault("events", SSEDelay)
+	SetLatency("events", FixedLatency(50*time.Millisecond))
+	SetFailures("events", 1)
+	server := httptest.NewServer(SSEMiddleware("events")(eventServer("text/event-stream")))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the stream to take at least 50ms, got %v", elapsed)
+	}
+	if expected := "id: 1\ndata: one\n\nid: 2\ndata: two\n\nid: 3\r\ndata: three\r\n\r\n"; string(body) != expected {
+		t.Errorf("Expected body %q, got %q", expected, body)
+	}
+}
+
+func TestEventEnd(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected int
+	}{
+		{in: "data: x", expected: -1},
+		{in: "data: x\n", expected: -1},
+		{in: "data: x\n\n", expected: 9},
+		{in: "data: x\r\n\r\ndata: y\n\n", expected: 11},
+		{in: "data: x\r\rdata: y\n\n", expected: 9},
+	}
+	for _, tt := range tests {
+		if got := eventEnd([]byte(tt.in)); got != tt.expected {
+			t.Errorf("Expected eventEnd(%q) = %d, got %d", tt.in, tt.expected, got)
+		}
+	}
+}
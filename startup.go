@@ -0,0 +1,241 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by LoadEnv.
+const (
+	EnvSpec          = "GOFI_SPEC"           // spec file, loaded before the other variables
+	EnvFailures      = "GOFI_FAILURES"       // first-N rules: "config-load=1,migrate=2"
+	EnvNthFailures   = "GOFI_NTH_FAILURES"   // precise rules: "warmup=3"
+	EnvLatency       = "GOFI_LATENCY"        // fixed latency rules: "migrate=5s"
+	EnvReadyDelay    = "GOFI_READY_DELAY"    // Ready reports false for this long
+	EnvCrashAfter    = "GOFI_CRASH_AFTER"    // exit the process after this long
+	EnvCrashCode     = "GOFI_CRASH_CODE"     // exit code for GOFI_CRASH_AFTER, default 1
+	EnvStrict        = "GOFI_STRICT"         // strict mode for unknown keys: "warn" or "panic"
+	EnvRuntimeTrace  = "GOFI_RUNTIME_TRACE"  // "true" to mark faults in runtime/trace output
+	EnvProfileLabels = "GOFI_PROFILE_LABELS" // "true" to label faulted goroutines for pprof
+	EnvShared        = "GOFI_SHARED"         // Unix socket of shared state to decide calls with
+	EnvLog           = "GOFI_LOG"            // log level for evaluations on stderr: "debug" or "info"
+)
+
+const defaultCrashCode = 1
+
+// osExit is swapped in tests.
+var osExit = os.Exit
+
+// LoadEnv configures faults from environment variables, for startup paths
+// such as config loading, migrations and warm-up that run before the control
+// server is up. Rules from EnvSpec are applied first and the other variables
+// are merged on top. EnvReadyDelay and EnvCrashAfter start their timers when
+// LoadEnv is called. It does nothing in production environments.
+func (in *Injector) LoadEnv() error {
+	if in.isProductionEnvironment() {
+		return nil
+	}
+	if path := os.Getenv(EnvSpec); path != "" {
+		if err := in.LoadSpec(path); err != nil {
+			return fmt.Errorf("%s: %w", EnvSpec, err)
+		}
+	}
+
+	failures, err := envPairs(EnvFailures, strconv.Atoi)
+	if err != nil {
+		return err
+	}
+	nth, err := envPairs(EnvNthFailures, strconv.Atoi)
+	if err != nil {
+		return err
+	}
+	latency, err := envPairs(EnvLatency, time.ParseDuration)
+	if err != nil {
+		return err
+	}
+	readyDelay, err := envDuration(EnvReadyDelay)
+	if err != nil {
+		return err
+	}
+	crashAfter, err := envDuration(EnvCrashAfter)
+	if err != nil {
+		return err
+	}
+	strict := StrictMode(os.Getenv(EnvStrict))
+	switch strict {
+	case StrictOff, StrictWarn, StrictPanic:
+	default:
+		return fmt.Errorf("%s: invalid strict mode %q", EnvStrict, strict)
+	}
+	runtimeTrace := false
+	if v := os.Getenv(EnvRuntimeTrace); v != "" {
+		if runtimeTrace, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", EnvRuntimeTrace, v)
+		}
+	}
+	profileLabels := false
+	if v := os.Getenv(EnvProfileLabels); v != "" {
+		if profileLabels, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", EnvProfileLabels, v)
+		}
+	}
+	var logLevel slog.Level
+	logs := os.Getenv(EnvLog)
+	if logs != "" {
+		if err := logLevel.UnmarshalText([]byte(logs)); err != nil {
+			return fmt.Errorf("%s: invalid log level %q", EnvLog, logs)
+		}
+	}
+	code := defaultCrashCode
+	if v := os.Getenv(EnvCrashCode); v != "" {
+		if code, err = strconv.Atoi(v); err != nil {
+			return fmt.Errorf("%s: invalid exit code %q", EnvCrashCode, v)
+		}
+	}
+
+	if logs != "" {
+		in.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel})))
+	}
+	if path := os.Getenv(EnvShared); path != "" {
+		if err := in.UseShared(path); err != nil {
+			return fmt.Errorf("%s: %w", EnvShared, err)
+		}
+	}
+	if strict != StrictOff {
+		in.SetStrictMode(strict)
+	}
+	if runtimeTrace {
+		in.SetRuntimeTrace(true)
+	}
+	if profileLabels {
+		in.SetProfileLabels(true)
+	}
+	for k, n := range failures {
+		in.SetFailures(k, n)
+	}
+	for k, n := range nth {
+		in.SetNthFailure(k, n)
+	}
+	for k, d := range latency {
+		in.SetLatency(k, FixedLatency(d))
+	}
+	if readyDelay > 0 {
+		in.DelayReadiness(readyDelay)
+	}
+	if crashAfter > 0 {
+		in.CrashAfter(crashAfter, code)
+	}
+	return nil
+}
+
+// LoadEnv calls LoadEnv on the default Injector.
+func LoadEnv() error {
+	return std.LoadEnv()
+}
+
+// DelayReadiness makes Ready report false for d from now, to test how an
+// orchestrator treats a slow-starting instance.
+func (in *Injector) DelayReadiness(d time.Duration) {
+	if in.isProductionEnvironment() {
+		return
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.readyAt = in.timeNow().Add(d)
+}
+
+// DelayReadiness calls DelayReadiness on the default Injector.
+func DelayReadiness(d time.Duration) {
+	std.DelayReadiness(d)
+}
+
+// Ready reports whether the delay set by DelayReadiness or EnvReadyDelay has passed.
+func (in *Injector) Ready() bool {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return !in.timeNow().Before(in.readyAt)
+}
+
+// Ready calls Ready on the default Injector.
+func Ready() bool {
+	return std.Ready()
+}
+
+// ReadinessHandler wraps a readiness probe, answering 503 Service
+// Unavailable while Ready reports false.
+func (in *Injector) ReadinessHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !in.Ready() {
+			http.Error(w, "readiness delayed by fault injection", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ReadinessHandler calls ReadinessHandler on the default Injector.
+func ReadinessHandler(next http.Handler) http.Handler {
+	return std.ReadinessHandler(next)
+}
+
+// CrashAfter exits the process with code after d, unless the returned stop
+// func is called first, to test restart handling. It does nothing in
+// production environments.
+func (in *Injector) CrashAfter(d time.Duration, code int) (stop func()) {
+	if in.isProductionEnvironment() {
+		return func() {}
+	}
+	t := time.AfterFunc(d, func() {
+		fmt.Fprintf(os.Stderr, "faultinject: crashing after %s with exit code %d\n", d, code)
+		osExit(code)
+	})
+	return func() { t.Stop() }
+}
+
+// CrashAfter calls CrashAfter on the default Injector.
+func CrashAfter(d time.Duration, code int) (stop func()) {
+	return std.CrashAfter(d, code)
+}
+
+// envPairs parses a "key=value,key=value" environment variable.
+func envPairs[T any](name string, parse func(string) (T, error)) (map[string]T, error) {
+	out := make(map[string]T)
+	v := os.Getenv(name)
+	if v == "" {
+		return out, nil
+	}
+	for _, pair := range strings.Split(v, ",") {
+		k, raw, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("%s: invalid pair %q", name, pair)
+		}
+		val, err := parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid value for %s: %w", name, k, err)
+		}
+		out[k] = val
+	}
+	return out, nil
+}
+
+func envDuration(name string) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,138 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadEnv(t *testing.T) {
+	resetState()
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	if err := os.WriteFile(spec, []byte("failures:\n  config-load: 5\n  warmup: 1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	t.Setenv(EnvSpec, spec)
+	t.Setenv(EnvFailures, "config-load=1, migrate=2")
+	t.Setenv(EnvNthFailures, "warmup=3")
+	t.Setenv(EnvLatency, "migrate=5ms")
+
+	if err := LoadEnv(); err != nil {
+		t.Fatalf("LoadEnv returned error: %v", err)
+	}
+	st := Status()
+	if st["config-load"] != 1 || st["migrate"] != 2 {
+		t.Errorf("Expected env rules merged over the spec, got %v", st)
+	}
+	if _, ok := st["warmup"]; ok {
+		t.Errorf("Expected warmup to be a precise rule, got %v", st)
+	}
+	if d := Rules()["migrate"].Latency.Sample(); d != 5*time.Millisecond {
+		t.Errorf("Expected 5ms latency, got %s", d)
+	}
+}
+
+func TestLoadEnvErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		value string
+	}{
+		{name: "missing spec", env: EnvSpec, value: "missing.yaml"},
+		{name: "bad pair", env: EnvFailures, value: "config-load"},
+		{name: "bad count", env: EnvFailures, value: "config-load=many"},
+		{name: "bad latency", env: EnvLatency, value: "migrate=slow"},
+		{name: "bad ready delay", env: EnvReadyDelay, value: "soon"},
+		{name: "bad exit code", env: EnvCrashCode, value: "x"},
+		{name: "bad strict mode", env: EnvStrict, value: "loud"},
+		{name: "bad runtime trace", env: EnvRuntimeTrace, value: "sometimes"},
+		{name: "bad profile labels", env: EnvProfileLabels, value: "sometimes"},
+		{name: "unreachable shared state", env: EnvShared, value: "missing.sock"},
+		{name: "bad log level", env: EnvLog, value: "loud"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			t.Setenv(tt.env, tt.value)
+			if err := LoadEnv(); err == nil {
+				t.Errorf("Expected error for %s=%s", tt.env, tt.value)
+			}
+		})
+	}
+}
+
+func TestLoadEnvProduction(t *testing.T) {
+	resetState()
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv(EnvFailures, "config-load=1")
+	if err := LoadEnv(); err != nil {
+		t.Fatalf("LoadEnv returned error: %v", err)
+	}
+	if len(Status()) != 0 {
+		t.Errorf("Expected no rules in production, got %v", Status())
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	resetState()
+	now := time.Now()
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	t.Setenv(EnvReadyDelay, "10s")
+	if err := LoadEnv(); err != nil {
+		t.Fatalf("LoadEnv returned error: %v", err)
+	}
+
+	handler := ReadinessHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		return rec.Code
+	}
+
+	if Ready() || probe() != http.StatusServiceUnavailable {
+		t.Error("Expected not ready during the delay")
+	}
+	now = now.Add(10 * time.Second)
+	if !Ready() || probe() != http.StatusOK {
+		t.Error("Expected ready after the delay")
+	}
+}
+
+func TestCrashAfter(t *testing.T) {
+	resetState()
+	exited := make(chan int, 1)
+	osExit = func(code int) { exited <- code }
+	defer func() { osExit = os.Exit }()
+
+	t.Setenv(EnvCrashAfter, "10ms")
+	t.Setenv(EnvCrashCode, "3")
+	if err := LoadEnv(); err != nil {
+		t.Fatalf("LoadEnv returned error: %v", err)
+	}
+	select {
+	case code := <-exited:
+		if code != 3 {
+			t.Errorf("Expected exit code 3, got %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the process to crash")
+	}
+
+	stop := CrashAfter(10*time.Millisecond, 1)
+	stop()
+	select {
+	case <-exited:
+		t.Error("Expected a stopped crash timer not to fire")
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// State is an Injector's configuration and call counts, as saved by Snapshot
+// and put back by Restore. The configuration is held as a Spec with every
+// key's settings in Rules, so a state written as YAML can also be loaded
+// with LoadSpec.
+type State struct {
+	Spec `yaml:",inline"`
+	// IncludeSynthetic lists keys whose synthetic traffic can fail even when
+	// SetExcludeSyntheticByDefault is on.
+	IncludeSynthetic []string `yaml:"include-synthetic,omitempty"`
+	// ExcludeSyntheticByDefault is the setting of SetExcludeSyntheticByDefault.
+	ExcludeSyntheticByDefault bool `yaml:"exclude-synthetic-by-default,omitempty"`
+	// ReadyAt is when Ready starts reporting true, as set by DelayReadiness.
+	ReadyAt time.Time `yaml:"ready-at,omitempty"`
+	// Draining is whether StartDrain has been called.
+	Draining bool `yaml:"draining,omitempty"`
+	// Counters holds the call count of each key.
+	Counters map[string]int `yaml:"counters,omitempty"`
+	// BudgetFires holds when each rule fired within its Budget's window, and
+	// GlobalBudgetFires when any rule fired within the global budget's.
+	BudgetFires       map[string][]time.Time `yaml:"budget-fires,omitempty"`
+	GlobalBudgetFires []time.Time            `yaml:"global-budget-fires,omitempty"`
+}
+
+// Snapshot returns a copy of the configuration and call counts, so a test
+// can run a sub-scenario and Restore what it started with.
+func (in *Injector) Snapshot() State {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	return in.stateLocked()
+}
+
+// Snapshot calls Snapshot on the default Injector.
+func Snapshot() State {
+	return std.Snapshot()
+}
+
+// stateLocked is Snapshot with mu held.
+func (in *Injector) stateLocked() State {
+	var st State
+	st.Rules = maps.Clone(in.rules)
+	st.Selectors = cloneLists(in.selectors)
+	st.Tenants = cloneLists(in.tenants)
+	for _, k := range sortedKeys(in.excludeSynthetic) {
+		if in.excludeSynthetic[k] {
+			st.ExcludeSynthetic = append(st.ExcludeSynthetic, k)
+		} else {
+			st.IncludeSynthetic = append(st.IncludeSynthetic, k)
+		}
+	}
+	for k, r := range in.skews {
+		if st.ClockSkews == nil {
+			st.ClockSkews = make(map[string]ClockSkew, len(in.skews))
+		}
+		st.ClockSkews[k] = r.skew
+	}
+	st.Corruptions = maps.Clone(in.corruptions)
+	st.NumericEdges = maps.Clone(in.numericEdges)
+	st.PageFaults = maps.Clone(in.pageFaults)
+	st.IdempotencyFaults = maps.Clone(in.idempotencyFaults)
+	st.QuotaResponses = maps.Clone(in.quotaResponses)
+	st.SSEFaults = maps.Clone(in.sseFaults)
+	st.Degradations = maps.Clone(in.degradations)
+	st.ResponseFaults = maps.Clone(in.responseFaults)
+	st.ConnFaults = maps.Clone(in.connFaults)
+	st.Bandwidths = maps.Clone(in.bandwidths)
+	st.Routes = maps.Clone(in.routes)
+	if in.budget != nil {
+		budget := *in.budget
+		st.Budget = &budget
+	}
+	st.ExcludeSyntheticByDefault = in.excludeSyntheticDefault
+	st.ReadyAt, st.Draining = in.readyAt, in.draining
+
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	st.Counters = maps.Clone(in.counters)
+	st.BudgetFires = cloneTimes(in.budgetFires)
+	st.GlobalBudgetFires = slices.Clone(in.globalFires)
+	return st
+}
+
+// Restore replaces the configuration and call counts with st, as Reset
+// followed by loading st would. Flapping cycles and clock skews start over,
+// while expiry times, readiness, draining and budget spending are kept. If
+// st is invalid, as a state read from elsewhere can be, it returns a
+// *SpecError and restores none of it. Fault injection is disabled in
+// production environments.
+func (in *Injector) Restore(st State) error {
+	_, err := in.RestoreIfMatch(st, 0)
+	return err
+}
+
+// Restore calls Restore on the default Injector.
+func Restore(st State) error {
+	return std.Restore(st)
+}
+
+// RestoreIfMatch is Restore, applied only if the configuration generation is
+// still gen, as read from a Snapshot with Generation. A gen of zero always
+// applies. It returns the generation after the call, and ErrConflict if the
+// configuration changed in between.
+func (in *Injector) RestoreIfMatch(st State, gen uint64) (uint64, error) {
+	if err := st.validate(); err != nil {
+		return 0, err
+	}
+	if in.isProductionEnvironment() {
+		return in.Generation(), nil
+	}
+
+	var conflicts map[string]string
+	gen, err := in.updateIfMatch(gen, func() {
+		conflicts = in.restoreLocked(st)
+	})
+	in.logConflicts(conflicts)
+	return gen, err
+}
+
+// RestoreIfMatch calls RestoreIfMatch on the default Injector.
+func RestoreIfMatch(st State, gen uint64) (uint64, error) {
+	return std.RestoreIfMatch(st, gen)
+}
+
+// restoreLocked is Restore with mu held. It returns the mode conflicts to
+// log once mu is released.
+func (in *Injector) restoreLocked(st State) map[string]string {
+	conflicts := in.applySpecLocked(st.Spec, false)
+	if st.Budget == nil {
+		in.budget = nil // Reset keeps the global budget; st has none
+	}
+	for _, k := range st.IncludeSynthetic {
+		in.excludeSynthetic[k] = false
+	}
+	in.excludeSyntheticDefault = st.ExcludeSyntheticByDefault
+	in.readyAt, in.draining = st.ReadyAt, st.Draining
+
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	now := in.timeNow()
+	for k, n := range st.Counters {
+		in.counters[k] = n
+		in.lastSeen[k] = now
+	}
+	in.budgetFires = cloneTimes(st.BudgetFires)
+	if in.budgetFires == nil {
+		in.budgetFires = make(map[string][]time.Time)
+	}
+	in.globalFires = slices.Clone(st.GlobalBudgetFires)
+	return conflicts
+}
+
+// serveState serves /state: GET exports the state as YAML, with the
+// generation it was read at as ETag, and PUT or POST restores a state
+// exported before, in any spec format, if the If-Match header names the
+// current generation or is absent.
+func (in *Injector) serveState(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		in.mu.RLock()
+		st, gen := in.stateLocked(), in.generation
+		in.mu.RUnlock()
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("ETag", etag(gen))
+		yaml.NewEncoder(w).Encode(st)
+	case http.MethodPut, http.MethodPost:
+		gen, ok := ifMatch(r)
+		if !ok {
+			http.Error(w, "invalid If-Match", http.StatusPreconditionFailed)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		st, err := parseState(data, "")
+		if err != nil {
+			http.Error(w, "invalid state: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !in.allowExperiment(w, r, "*") {
+			return
+		}
+		gen, err = in.RestoreIfMatch(st, gen)
+		w.Header().Set("ETag", etag(gen))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		w.Write([]byte("OK"))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validate reports entries of st that cannot be restored as written.
+func (st State) validate() error {
+	if err := st.Spec.validate(); err != nil {
+		return err
+	}
+	for _, k := range sortedKeys(st.Counters) {
+		if st.Counters[k] < 0 {
+			return &SpecError{Section: "counters", Key: k, Err: fmt.Errorf("negative call count %d", st.Counters[k])}
+		}
+	}
+	return nil
+}
+
+// parseState parses a state exported by the control server, in any spec
+// format.
+func parseState(data []byte, format string) (State, error) {
+	data, _, err := specYAML(data, format)
+	if err != nil {
+		return State{}, err
+	}
+	var st State
+	if err := yaml.Unmarshal(data, &st); err != nil {
+		return State{}, locateYAMLError(data, err)
+	}
+	if err := st.validate(); err != nil {
+		return State{}, err
+	}
+	if err := st.expandMatrix(); err != nil {
+		return State{}, err
+	}
+	return st, nil
+}
+
+func cloneTimes(m map[string][]time.Time) map[string][]time.Time {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string][]time.Time, len(m))
+	for k, v := range m {
+		out[k] = slices.Clone(v)
+	}
+	return out
+}
+
+func cloneLists(m map[string][]string) map[string][]string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
@@ -0,0 +1,266 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	resetState()
+	SetFailures("db", 3)
+	SetLatency("db", FixedLatency(time.Millisecond))
+	SetNthFailures("cache", 2, 4)
+	SetTenants("db", "acme")
+	SetExcludeSynthetic("db", true)
+	SetClockSkew("clock", ClockSkew{Offset: time.Hour})
+	SetBandwidth("conn", 1024)
+	InjectWithContext(WithTenant(context.Background(), "acme"), "db")
+	Inject("cache")
+
+	saved := Snapshot()
+
+	// a sub-scenario that changes everything
+	SetFailures("db", 1)
+	SetFailureRate("other", 1)
+	SetClockSkew("clock", ClockSkew{})
+	Inject("cache")
+	if err := Restore(saved); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		check    func() bool
+		expected bool
+	}{
+		{name: "first-n count", check: func() bool { return Status()["db"] == 2 }, expected: true},
+		{name: "latency", check: func() bool { return len(Rules()["db"].Latency) == 1 }, expected: true},
+		{name: "tenants", check: func() bool { return len(Snapshot().Tenants["db"]) == 1 }, expected: true},
+		{name: "synthetic", check: func() bool { return len(Snapshot().ExcludeSynthetic) == 1 }, expected: true},
+		{name: "clock skew", check: func() bool { return Snapshot().ClockSkews["clock"].Offset == time.Hour }, expected: true},
+		{name: "bandwidth", check: func() bool { return Snapshot().Bandwidths["conn"] == 1024 }, expected: true},
+		{name: "rule added later", check: func() bool { _, ok := Rules()["other"]; return ok }, expected: false},
+		{name: "nth counter", check: func() bool { return Counter("cache") == 1 }, expected: true},
+		{name: "nth fires next", check: func() bool { return Inject("cache") }, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSnapshotRestoreRuntimeState(t *testing.T) {
+	resetState()
+	defer SetExcludeSyntheticByDefault(false)
+	SetExcludeSyntheticByDefault(true)
+	DelayReadiness(time.Hour)
+	StartDrain()
+	SetFailures("db", 5)
+	SetBudget("db", 1, time.Hour)
+	if !Inject("db") {
+		t.Fatal("Expected the first call to fire")
+	}
+
+	saved := Snapshot()
+	resetState()
+	SetExcludeSyntheticByDefault(false)
+	if err := Restore(saved); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		check    func() bool
+		expected bool
+	}{
+		{name: "synthetic default", check: func() bool { return Snapshot().ExcludeSyntheticByDefault }, expected: true},
+		{name: "readiness delay", check: Ready, expected: false},
+		{name: "draining", check: Draining, expected: true},
+		{name: "budget spent", check: func() bool { return FullStatus()["db"].BudgetUsed == 1 }, expected: true},
+		{name: "budget exhausted", check: func() bool { return Inject("db") }, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.check(); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRestoreClearsGlobalBudget(t *testing.T) {
+	resetState()
+	SetFailureRate("db", 1)
+	saved := Snapshot()
+	SetGlobalBudget(1, time.Hour)
+	defer SetGlobalBudget(0, 0)
+
+	if err := Restore(saved); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	fired := 0
+	for range 5 {
+		if Inject("db") {
+			fired++
+		}
+	}
+	if fired != 5 {
+		t.Errorf("Expected 5 faults without a global budget, got %d", fired)
+	}
+}
+
+func TestRestoreIfMatch(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	saved, gen := Snapshot(), Generation()
+
+	SetFailures("db", 2)
+	if _, err := RestoreIfMatch(saved, gen); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	if Status()["db"] != 2 {
+		t.Error("Expected a conflicting restore to change nothing")
+	}
+	if _, err := RestoreIfMatch(saved, Generation()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if Status()["db"] != 1 {
+		t.Errorf("Expected the state restored, got %v", Status())
+	}
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	tests := []struct {
+		name  string
+		state State
+	}{
+		{name: "invalid rule", state: State{Spec: Spec{Rules: map[string]Rule{"db": {Mode: ModeRate, Rate: 2}}}}},
+		{name: "negative counter", state: State{Counters: map[string]int{"db": -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Restore(tt.state); err == nil {
+				t.Error("Expected an error")
+			}
+			if Status()["db"] != 1 {
+				t.Error("Expected the current state to be kept")
+			}
+		})
+	}
+}
+
+func TestStateEndpoint(t *testing.T) {
+	resetState()
+	SetFailures("db", 2)
+	SetNthFailureRange("api", 2, 3)
+	Inject("db")
+	srv := httptest.NewServer(ControlHandler(nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/state")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var exported strings.Builder
+	_, err = io.Copy(&exported, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// an exported state loads as a spec, without the counts
+	resetState()
+	if err := LoadSpecFromBytes([]byte(exported.String()), FormatYAML); err != nil {
+		t.Fatalf("Expected the export to load as a spec, got %v", err)
+	}
+	if Status()["db"] != 2 || len(Rules()["api"].Ranges) != 1 {
+		t.Errorf("Expected the exported rules, got %v", Rules())
+	}
+
+	resetState()
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{name: "import", method: http.MethodPut, body: exported.String(), expectedStatus: http.StatusOK},
+		{name: "import json", method: http.MethodPost, body: `{"failures": {"db": 2}, "counters": {"db": 1}}`, expectedStatus: http.StatusOK},
+		{name: "invalid", method: http.MethodPut, body: "rules: {db: {rate: 2}}", expectedStatus: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodDelete, expectedStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL+"/state", strings.NewReader(tt.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus == http.StatusOK && Status()["db"] != 1 {
+				t.Errorf("Expected 1 remaining failure after import, got %d", Status()["db"])
+			}
+		})
+	}
+}
+
+func TestStateEndpointIfMatch(t *testing.T) {
+	resetState()
+	SetFailures("db", 1)
+	srv := httptest.NewServer(ControlHandler(nil))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/state")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	tag := resp.Header.Get("ETag")
+	if tag != etag(Generation()) {
+		t.Fatalf("Expected ETag %s, got %q", etag(Generation()), tag)
+	}
+	SetFailures("db", 2)
+
+	put := func(ifMatch string) int {
+		req, _ := http.NewRequest(http.MethodPut, srv.URL+"/state", strings.NewReader(string(body)))
+		req.Header.Set("If-Match", ifMatch)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if got := put(tag); got != http.StatusPreconditionFailed {
+		t.Errorf("Expected a stale If-Match to fail with 412, got %d", got)
+	}
+	if Status()["db"] != 2 {
+		t.Error("Expected a rejected import to change nothing")
+	}
+	if got := put("garbage"); got != http.StatusPreconditionFailed {
+		t.Errorf("Expected an invalid If-Match to fail with 412, got %d", got)
+	}
+	if got := put(etag(Generation())); got != http.StatusOK {
+		t.Errorf("Expected a current If-Match to succeed, got %d", got)
+	}
+	if Status()["db"] != 1 {
+		t.Errorf("Expected the exported state restored, got %v", Status())
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+// KeyStatus is the state of one key as reported by FullStatus: its rule,
+// how often it has been called and how many of the coming calls will fail.
+type KeyStatus struct {
+	Rule
+	// Pattern is the key pattern whose rule applies, when the key has no
+	// rule of its own.
+	Pattern string `json:"pattern,omitempty"`
+	Calls   int    `json:"calls"`
+	// Remaining is the number of failures left, or -1 for rates, flapping
+	// and Nth rules repeating Every calls, which have no fixed number.
+	Remaining int  `json:"remaining"`
+	Expired   bool `json:"expired,omitempty"`
+	// Conflict describes how first-N and Nth rules set for the key by a
+	// spec were merged, until the key is configured again.
+	Conflict string `json:"conflict,omitempty"`
+	// BudgetUsed is how many faults count against the rule's Budget in
+	// its current window.
+	BudgetUsed int `json:"budget-used,omitempty"`
+}
+
+// DetailedStatus is FullStatus together with the generation of the
+// configuration it was computed from and the use of the injector's budget.
+type DetailedStatus struct {
+	Generation uint64               `json:"generation"`
+	Keys       map[string]KeyStatus `json:"keys"`
+	// Budget is the injector's budget, if any, and BudgetUsed how many
+	// faults count against it in its current window.
+	Budget     *Budget `json:"budget,omitempty"`
+	BudgetUsed int     `json:"budget-used,omitempty"`
+}
+
+// StatusDetailed returns FullStatus along with the generation of the rule
+// snapshot Inject was deciding calls with when it was read, for debugging
+// changes that seem not to take effect.
+func (in *Injector) StatusDetailed() DetailedStatus {
+	s := in.snapshot()
+	st := DetailedStatus{Generation: s.generation, Keys: in.fullStatusOf(s)}
+	if s.budget != nil {
+		budget := *s.budget
+		st.Budget = &budget
+	}
+	in.callsMu.Lock()
+	st.BudgetUsed = len(s.budget.within(in.globalFires, in.timeNow()))
+	in.callsMu.Unlock()
+	return st
+}
+
+// StatusDetailed calls StatusDetailed on the default Injector.
+func StatusDetailed() DetailedStatus {
+	return std.StatusDetailed()
+}
+
+// FullStatus returns the state of every key with a rule or a call count.
+// Unlike Status it covers every failure mode, latency and pattern match.
+func (in *Injector) FullStatus() map[string]KeyStatus {
+	return in.fullStatusOf(in.snapshot())
+}
+
+// FullStatus calls FullStatus on the default Injector.
+func FullStatus() map[string]KeyStatus {
+	return std.FullStatus()
+}
+
+// fullStatusOf is FullStatus for the configuration s.
+func (in *Injector) fullStatusOf(s *ruleSnapshot) map[string]KeyStatus {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	now := in.timeNow()
+	out := make(map[string]KeyStatus, len(s.rules))
+	add := func(key string) {
+		if _, ok := out[key]; ok {
+			return
+		}
+		ks := KeyStatus{Calls: in.counters[key]}
+		rk := s.ruleKey(key)
+		if rk != key {
+			ks.Pattern = rk
+		}
+		ks.Rule = s.rules[rk]
+		ks.Expired = ks.expired(now)
+		ks.Conflict = s.conflicts[rk]
+		ks.BudgetUsed = in.budgetUsedLocked(rk, ks.Rule, now)
+		switch {
+		case ks.Expired:
+		case ks.Mode == ModeFirstN:
+			ks.Remaining = max(ks.Count-ks.Calls, 0)
+		case ks.Mode == ModeNth:
+			ks.Remaining = ks.remainingNthFailures(ks.Calls)
+		case ks.Mode == ModeRate, ks.Mode == ModeFlapping:
+			ks.Remaining = -1
+		case ks.Mode == ModeActions:
+			ks.Remaining = ks.remainingActionFailures(ks.Calls)
+		}
+		out[key] = ks
+	}
+	for k := range s.rules {
+		add(k)
+	}
+	for k := range in.counters {
+		add(k)
+	}
+	return out
+}
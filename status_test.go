@@ -0,0 +1,68 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFullStatus(t *testing.T) {
+	resetState()
+	SetFailures("first", 2)
+	SetNthFailure("nth", 3)
+	SetFailureRate("rate", 0.5)
+	SetLatency("slow", FixedLatency(time.Millisecond))
+	SetFailures("svc.*", 1)
+
+	Inject("first")
+	Inject("nth")
+	Inject("svc.a")
+	Inject("unconfigured")
+
+	tests := []struct {
+		key       string
+		calls     int
+		remaining int
+		pattern   string
+	}{
+		{key: "first", calls: 1, remaining: 1},
+		{key: "nth", calls: 1, remaining: 1},
+		{key: "rate", remaining: -1},
+		{key: "slow"},
+		{key: "svc.a", calls: 1, pattern: "svc.*"},
+		{key: "unconfigured", calls: 1},
+	}
+
+	status := FullStatus()
+	for _, tt := range tests {
+		got, ok := status[tt.key]
+		if !ok {
+			t.Errorf("Expected %s in full status", tt.key)
+			continue
+		}
+		if got.Calls != tt.calls || got.Remaining != tt.remaining || got.Pattern != tt.pattern {
+			t.Errorf("Expected %s to have calls %d, remaining %d, pattern %q, got %+v",
+				tt.key, tt.calls, tt.remaining, tt.pattern, got)
+		}
+	}
+	if len(status["slow"].Latency) != 1 {
+		t.Errorf("Expected latency for slow, got %+v", status["slow"])
+	}
+	if status["rate"].Rate != 0.5 {
+		t.Errorf("Expected rate 0.5, got %v", status["rate"].Rate)
+	}
+}
+
+func TestFullStatusExpired(t *testing.T) {
+	resetState()
+	SetFailuresFor("db", 3, time.Minute)
+	now := time.Now().Add(2 * time.Minute)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	got := FullStatus()["db"]
+	if !got.Expired || got.Remaining != 0 {
+		t.Errorf("Expected an expired rule with nothing remaining, got %+v", got)
+	}
+}
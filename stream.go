@@ -0,0 +1,156 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// decisionBuffer is how many decisions a WatchDecisions channel holds before
+// further ones are dropped.
+const decisionBuffer = 256
+
+// streamKeepAlive is how often /watch writes a comment on an idle stream, so
+// proxies do not close it.
+const streamKeepAlive = 15 * time.Second
+
+// WatchDecisions returns a channel that receives every decision recorded for
+// keys with a rule from now on, as History would, until ctx is done, when the
+// channel is closed. When the receiver falls behind, decisions are dropped
+// rather than slowing down Inject.
+func (in *Injector) WatchDecisions(ctx context.Context) <-chan Decision {
+	ch := make(chan Decision, decisionBuffer)
+	in.hooksMu.Lock()
+	var list []chan Decision
+	if cur := in.decisionSubs.Load(); cur != nil {
+		list = append(list, *cur...)
+	}
+	list = append(list, ch)
+	in.decisionSubs.Store(&list)
+	in.hooksMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		in.hooksMu.Lock()
+		var list []chan Decision
+		for _, other := range *in.decisionSubs.Load() {
+			if other != ch {
+				list = append(list, other)
+			}
+		}
+		in.decisionSubs.Store(&list)
+		in.hooksMu.Unlock()
+		// decisions are sent with callsMu held, so none is in flight after this
+		in.callsMu.Lock()
+		close(ch)
+		in.callsMu.Unlock()
+	}()
+	return ch
+}
+
+// WatchDecisions calls WatchDecisions on the default Injector.
+func WatchDecisions(ctx context.Context) <-chan Decision {
+	return std.WatchDecisions(ctx)
+}
+
+// publishDecisionLocked sends d to the WatchDecisions channels. It must be
+// called with callsMu held.
+func (in *Injector) publishDecisionLocked(d Decision) {
+	list := in.decisionSubs.Load()
+	if list == nil {
+		return
+	}
+	for _, ch := range *list {
+		select {
+		case ch <- d:
+		default:
+		}
+	}
+}
+
+// serveWatch streams the detailed status, after every configuration change,
+// and every decision as server-sent events until the client goes away. The
+// comma-separated ?keys= limits both to some keys, and ?fired=true leaves out
+// decisions that did not fire.
+func (in *Injector) serveWatch(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	keys := r.URL.Query().Get("keys")
+	firedOnly, _ := strconv.ParseBool(r.URL.Query().Get("fired"))
+	ctx := r.Context()
+	decisions := in.WatchDecisions(ctx)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+	sendStatus := func() (uint64, error) {
+		st := in.StatusDetailed()
+		filterKeys(st.Keys, keys)
+		return st.Generation, send("status", st)
+	}
+
+	gen, err := sendStatus()
+	if err != nil {
+		return
+	}
+	changes := make(chan struct{}, 1)
+	go func() {
+		for gen := gen; ; {
+			next, err := in.WaitForChange(ctx, gen)
+			if err != nil {
+				return
+			}
+			gen = next
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	want := strings.Split(keys, ",")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+			_, err = sendStatus()
+		case d, ok := <-decisions:
+			if !ok {
+				return
+			}
+			if (keys != "" && !contains(want, d.Key)) || (firedOnly && !d.Fired) {
+				continue
+			}
+			err = send("decision", d)
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(w, ": keep-alive\n\n"); err == nil {
+				err = rc.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
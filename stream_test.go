@@ -0,0 +1,117 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchDecisions(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-insert", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	decisions := in.WatchDecisions(ctx)
+
+	in.Inject("db-insert")
+	in.Inject("db-insert")
+	in.Inject("no-rule")
+	expected := []Decision{
+		{Key: "db-insert", Fired: true, Reason: ReasonFired, Count: 1},
+		{Key: "db-insert", Fired: false, Reason: ReasonExhausted, Count: 2},
+	}
+	for _, e := range expected {
+		d := <-decisions
+		if d.Key != e.Key || d.Fired != e.Fired || d.Reason != e.Reason || d.Count != e.Count {
+			t.Errorf("Expected %+v, got %+v", e, d)
+		}
+	}
+
+	cancel()
+	for range decisions {
+		t.Error("Expected no decisions for keys without a rule")
+	}
+	in.Inject("db-insert") // must not send on the closed channel
+}
+
+// sseEvent reads the next event from an event stream, skipping comments.
+func sseEvent(t *testing.T, r *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestWatchEndpoint(t *testing.T) {
+	resetState()
+	in := NewInjector()
+	in.SetFailures("db-insert", 1)
+	server := httptest.NewServer(in.ControlHandler(nil))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/watch?keys=db-insert,cache-get&fired=true", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %q", ct)
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	event, data := sseEvent(t, stream)
+	var st DetailedStatus
+	if err := json.Unmarshal([]byte(data), &st); err != nil || event != "status" {
+		t.Fatalf("Expected a status event, got %s %s", event, data)
+	}
+	if st.Keys["db-insert"].Remaining != 1 {
+		t.Errorf("Expected db-insert in the initial status, got %+v", st)
+	}
+
+	in.Inject("db-insert")
+	in.Inject("db-insert") // not fired, filtered out
+	in.SetFailures("cache-get", 2)
+	in.SetFailures("other", 2)
+
+	event, data = sseEvent(t, stream)
+	var d Decision
+	if err := json.Unmarshal([]byte(data), &d); err != nil || event != "decision" || d.Key != "db-insert" || !d.Fired {
+		t.Errorf("Expected the fired decision, got %s %s", event, data)
+	}
+	for {
+		event, data = sseEvent(t, stream)
+		if event != "status" {
+			t.Fatalf("Expected only status events after the decision, got %s %s", event, data)
+		}
+		st = DetailedStatus{}
+		json.Unmarshal([]byte(data), &st)
+		if _, ok := st.Keys["other"]; ok {
+			t.Errorf("Expected keys outside ?keys= to be left out, got %+v", st.Keys)
+		}
+		if st.Keys["cache-get"].Remaining == 2 {
+			break
+		}
+	}
+}
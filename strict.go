@@ -0,0 +1,96 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// StrictMode sets what Inject does with a key that is unknown: one that was
+// never passed to RegisterKeys, never configured and never present in a
+// loaded spec. Unknown keys usually come from a typo or a dead injection
+// point, and would otherwise return false forever.
+type StrictMode string
+
+const (
+	StrictOff   StrictMode = ""      // unknown keys are evaluated like any other
+	StrictWarn  StrictMode = "warn"  // the first call to each unknown key is reported on stderr
+	StrictPanic StrictMode = "panic" // calls to unknown keys panic
+)
+
+// warnOutput is swapped in tests.
+var warnOutput io.Writer = os.Stderr
+
+// SetStrictMode sets the strict mode, typically in TestMain or from
+// EnvStrict in CI. Like the environment lists, it survives Reset.
+func (in *Injector) SetStrictMode(m StrictMode) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.strictMode = m
+	in.mu.dirty = true
+}
+
+// SetStrictMode calls SetStrictMode on the default Injector.
+func SetStrictMode(m StrictMode) {
+	std.SetStrictMode(m)
+}
+
+// RegisterKeys declares keys as known to strict mode without configuring
+// them, for injection points that only fail in some tests. Patterns are
+// accepted and make every key they match known.
+func (in *Injector) RegisterKeys(keys ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, key := range keys {
+		in.markKnownLocked(key)
+	}
+}
+
+// RegisterKeys calls RegisterKeys on the default Injector.
+func RegisterKeys(keys ...string) {
+	std.RegisterKeys(keys...)
+}
+
+func (in *Injector) markKnownLocked(key string) {
+	if in.knownKeys[key] {
+		return
+	}
+	in.knownKeys[key] = true
+	if re := compilePattern(key); re != nil {
+		in.knownPatterns = append(in.knownPatterns, pattern{key: key, re: re})
+	}
+	in.mu.dirty = true
+}
+
+// known reports whether strict mode accepts key.
+func (s *ruleSnapshot) known(key string) bool {
+	if s.knownKeys[key] {
+		return true
+	}
+	for _, p := range s.knownPatterns {
+		if p.re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkKnown applies the strict mode to a call to key. Production
+// environments are never strict.
+func (in *Injector) checkKnown(key string) {
+	s := in.snapshot()
+	if s.strictMode == StrictOff || s.known(key) || in.isProductionEnvironment() {
+		return
+	}
+	if s.strictMode == StrictPanic {
+		panic(fmt.Sprintf("faultinject: unknown key %q", key))
+	}
+	if _, warned := in.warnedKeys.LoadOrStore(key, true); !warned {
+		fmt.Fprintf(warnOutput, "faultinject: unknown key %q\n", key)
+	}
+}
@@ -0,0 +1,78 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStrictPanic(t *testing.T) {
+	resetState()
+	SetStrictMode(StrictPanic)
+	defer SetStrictMode(StrictOff)
+
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	if err := os.WriteFile(spec, []byte("failures:\n  spec-key: 1\nselectors:\n  spec-scoped: [beta]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := LoadSpec(spec); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	RegisterKeys("registered", "batch-*")
+	SetFailures("configured", 1)
+	Reset()
+
+	tests := []struct {
+		key   string
+		panic bool
+	}{
+		{key: "registered"},
+		{key: "batch-42"},
+		{key: "configured"},
+		{key: "spec-key"},
+		{key: "spec-scoped"},
+		{key: "registerd", panic: true},
+		{key: "batch", panic: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.panic {
+					t.Errorf("Expected panic=%v for %s, got %v", tt.panic, tt.key, r)
+				}
+			}()
+			Inject(tt.key)
+		})
+	}
+}
+
+func TestStrictWarn(t *testing.T) {
+	resetState()
+	var buf bytes.Buffer
+	warnOutput = &buf
+	defer func() { warnOutput = os.Stderr }()
+	SetStrictMode(StrictWarn)
+	defer SetStrictMode(StrictOff)
+
+	Inject("warn-typo")
+	Inject("warn-typo")
+	if got := strings.Count(buf.String(), `unknown key "warn-typo"`); got != 1 {
+		t.Errorf("Expected one warning, got %q", buf.String())
+	}
+}
+
+func TestStrictOffInProduction(t *testing.T) {
+	resetState()
+	SetStrictMode(StrictPanic)
+	defer SetStrictMode(StrictOff)
+	t.Setenv("ENVIRONMENT", "production")
+
+	if Inject("prod-unknown") {
+		t.Error("Expected no injection in production")
+	}
+}
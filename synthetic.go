@@ -0,0 +1,73 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+)
+
+// SyntheticHeader marks requests as synthetic traffic, such as health checks
+// or probes sent by go-fi tooling. HTTPMiddleware honors it.
+const SyntheticHeader = "X-Go-FI-Synthetic"
+
+// MarkSynthetic returns a copy of ctx marking the call as synthetic traffic.
+func MarkSynthetic(ctx context.Context) context.Context {
+	return WithAttributes(ctx, Attributes{"synthetic": "true"})
+}
+
+// IsSynthetic reports whether ctx was marked with MarkSynthetic or carries a
+// request with SyntheticHeader set.
+func IsSynthetic(ctx context.Context) bool {
+	return AttributesFromContext(ctx)["synthetic"] == "true"
+}
+
+// MarkSyntheticRequest sets SyntheticHeader on an outgoing request so the
+// receiving service's middleware treats it as synthetic.
+func MarkSyntheticRequest(r *http.Request) {
+	r.Header.Set(SyntheticHeader, "true")
+}
+
+// SetExcludeSynthetic controls whether key ever fails for synthetic traffic.
+// Excluded synthetic calls neither fail nor count towards the rule.
+func (in *Injector) SetExcludeSynthetic(key string, exclude bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.excludeSynthetic[key] = exclude
+	in.bumpGenerationLocked()
+}
+
+// SetExcludeSynthetic calls SetExcludeSynthetic on the default Injector.
+func SetExcludeSynthetic(key string, exclude bool) {
+	std.SetExcludeSynthetic(key, exclude)
+}
+
+// SetExcludeSyntheticByDefault sets whether keys without their own
+// SetExcludeSynthetic setting skip synthetic traffic. It is off by default.
+func (in *Injector) SetExcludeSyntheticByDefault(exclude bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.excludeSyntheticDefault = exclude
+	in.bumpGenerationLocked()
+}
+
+// SetExcludeSyntheticByDefault calls SetExcludeSyntheticByDefault on the default Injector.
+func SetExcludeSyntheticByDefault(exclude bool) {
+	std.SetExcludeSyntheticByDefault(exclude)
+}
+
+// syntheticAllowed reports whether key may fail for the call described by ctx.
+func (in *Injector) syntheticAllowed(ctx context.Context, key string) bool {
+	if !IsSynthetic(ctx) {
+		return true
+	}
+	s := in.snapshot()
+	exclude, ok := s.excludeSynthetic[s.ruleKey(key)]
+	if !ok {
+		exclude = s.excludeSyntheticDefault
+	}
+	return !exclude
+}
@@ -0,0 +1,113 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestSyntheticExclusion(t *testing.T) {
+	resetState()
+	defer SetExcludeSyntheticByDefault(false)
+
+	synthetic := MarkSynthetic(context.Background())
+
+	tests := []struct {
+		name     string
+		setup    func()
+		ctx      context.Context
+		expected bool
+	}{
+		{name: "synthetic traffic included by default", ctx: synthetic, expected: true},
+		{
+			name:     "key excludes synthetic traffic",
+			setup:    func() { SetExcludeSynthetic("probe-fault", true) },
+			ctx:      synthetic,
+			expected: false,
+		},
+		{
+			name:     "excluded key still fails real traffic",
+			setup:    func() { SetExcludeSynthetic("probe-fault", true) },
+			ctx:      context.Background(),
+			expected: true,
+		},
+		{
+			name:     "default exclusion",
+			setup:    func() { SetExcludeSyntheticByDefault(true) },
+			ctx:      synthetic,
+			expected: false,
+		},
+		{
+			name: "key overrides default exclusion",
+			setup: func() {
+				SetExcludeSyntheticByDefault(true)
+				SetExcludeSynthetic("probe-fault", false)
+			},
+			ctx:      synthetic,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetExcludeSyntheticByDefault(false)
+			SetFailures("probe-fault", 1)
+			if tt.setup != nil {
+				tt.setup()
+			}
+			if got := InjectWithContext(tt.ctx, "probe-fault"); got != tt.expected {
+				t.Errorf("InjectWithContext = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHTTPMiddlewareSyntheticHeader(t *testing.T) {
+	resetState()
+	SetFailures("health", 5)
+	SetExcludeSynthetic("health", true)
+
+	handler := HTTPMiddleware("health")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsSynthetic(r.Context()) {
+			t.Error("Expected handler context to be marked synthetic")
+		}
+		w.WriteHeader(200)
+	}))
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	MarkSyntheticRequest(req)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Errorf("Expected synthetic request to pass, got %d", w.Code)
+	}
+	if Status()["health"] != 5 {
+		t.Errorf("Expected synthetic request not to consume failures, got %d remaining", Status()["health"])
+	}
+}
+
+func TestLoadSpecExcludeSynthetic(t *testing.T) {
+	resetState()
+	content := `failures:
+  probe-spec: 1
+exclude-synthetic:
+  - probe-spec`
+	filename := "test-synthetic.yaml"
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(filename)
+
+	if err := LoadSpec(filename); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	if InjectWithContext(MarkSynthetic(context.Background()), "probe-spec") {
+		t.Error("Expected synthetic call to be excluded")
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"sort"
+)
+
+// WithTenant returns a copy of ctx identifying the tenant a call is made for.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return WithAttributes(ctx, Attributes{"tenant": tenant})
+}
+
+// TenantFromContext returns the tenant carried by ctx, or "".
+func TenantFromContext(ctx context.Context) string {
+	return AttributesFromContext(ctx)["tenant"]
+}
+
+// SetTenantHeader sets the request header HTTPMiddleware reads the tenant from.
+// The default is X-Tenant-ID.
+func (in *Injector) SetTenantHeader(name string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.tenantHeader = name
+	in.mu.dirty = true
+}
+
+// SetTenantHeader calls SetTenantHeader on the default Injector.
+func SetTenantHeader(name string) {
+	std.SetTenantHeader(name)
+}
+
+// TenantHeader returns the request header the tenant is read from.
+func (in *Injector) TenantHeader() string {
+	return in.snapshot().tenantHeader
+}
+
+// TenantHeader calls TenantHeader on the default Injector.
+func TenantHeader() string {
+	return std.TenantHeader()
+}
+
+// SetTenants scopes key to calls made for one of the given tenants.
+// Passing no tenants makes the rule apply to every call again.
+func (in *Injector) SetTenants(key string, list ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setTenantsLocked(key, list)
+}
+
+// SetTenants calls SetTenants on the default Injector.
+func SetTenants(key string, list ...string) {
+	std.SetTenants(key, list...)
+}
+
+func (in *Injector) setTenantsLocked(key string, list []string) {
+	in.bumpGenerationLocked()
+	if len(list) == 0 {
+		delete(in.tenants, key)
+		return
+	}
+	in.tenants[key] = append([]string(nil), list...)
+}
+
+// Tenants returns the keys scoped to each tenant.
+func (in *Injector) Tenants() map[string][]string {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	out := make(map[string][]string)
+	for key, list := range in.tenants {
+		for _, t := range list {
+			out[t] = append(out[t], key)
+		}
+	}
+	for _, keys := range out {
+		sort.Strings(keys)
+	}
+	return out
+}
+
+// Tenants calls Tenants on the default Injector.
+func Tenants() map[string][]string {
+	return std.Tenants()
+}
+
+// TenantStatus returns remaining "first-N" failures for the keys that apply
+// to tenant: those scoped to it and those not scoped to any tenant.
+func (in *Injector) TenantStatus(tenant string) map[string]int {
+	status := in.Status()
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	for key := range status {
+		if list, ok := in.tenants[key]; ok && !contains(list, tenant) {
+			delete(status, key)
+		}
+	}
+	return status
+}
+
+// TenantStatus calls TenantStatus on the default Injector.
+func TenantStatus(tenant string) map[string]int {
+	return std.TenantStatus(tenant)
+}
+
+// ResetTenant removes tenant from every rule scoped to it. Rules left
+// without tenants are cleared entirely; unscoped rules are untouched.
+func (in *Injector) ResetTenant(tenant string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.resetTenantLocked(tenant)
+}
+
+// ResetTenant calls ResetTenant on the default Injector.
+func ResetTenant(tenant string) {
+	std.ResetTenant(tenant)
+}
+
+func (in *Injector) resetTenantLocked(tenant string) {
+	in.callsMu.Lock()
+	defer in.callsMu.Unlock()
+	in.bumpGenerationLocked()
+	for key, list := range in.tenants {
+		if !contains(list, tenant) {
+			continue
+		}
+		rest := make([]string, 0, len(list)-1)
+		for _, t := range list {
+			if t != tenant {
+				rest = append(rest, t)
+			}
+		}
+		if len(rest) > 0 {
+			in.tenants[key] = rest
+			continue
+		}
+		in.deleteKeyLocked(key)
+	}
+}
+
+// tenantAllowed reports whether key applies to the tenant carried by ctx.
+func (in *Injector) tenantAllowed(ctx context.Context, key string) bool {
+	s := in.snapshot()
+	list, ok := s.tenants[s.ruleKey(key)]
+	if !ok {
+		return true
+	}
+	return contains(list, TenantFromContext(ctx))
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTenantScoping(t *testing.T) {
+	resetState()
+
+	tests := []struct {
+		name     string
+		tenants  []string
+		ctx      context.Context
+		expected bool
+	}{
+		{name: "unscoped rule applies to everyone", ctx: context.Background(), expected: true},
+		{name: "scoped rule matches tenant", tenants: []string{"acme", "globex"}, ctx: WithTenant(context.Background(), "globex"), expected: true},
+		{name: "scoped rule skips other tenant", tenants: []string{"acme"}, ctx: WithTenant(context.Background(), "initech"), expected: false},
+		{name: "scoped rule skips calls without tenant", tenants: []string{"acme"}, ctx: context.Background(), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			SetFailures("tenant-fault", 1)
+			SetTenants("tenant-fault", tt.tenants...)
+
+			if got := InjectWithContext(tt.ctx, "tenant-fault"); got != tt.expected {
+				t.Errorf("InjectWithContext = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTenantStatusAndReset(t *testing.T) {
+	resetState()
+	SetFailures("global", 1)
+	SetFailures("acme-only", 2)
+	SetFailures("shared", 3)
+	SetTenants("acme-only", "acme")
+	SetTenants("shared", "acme", "globex")
+
+	expected := map[string][]string{"acme": {"acme-only", "shared"}, "globex": {"shared"}}
+	if got := Tenants(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Tenants() = %v, want %v", got, expected)
+	}
+
+	if got := TenantStatus("globex"); !reflect.DeepEqual(got, map[string]int{"global": 1, "shared": 3}) {
+		t.Errorf("TenantStatus(globex) = %v", got)
+	}
+
+	ResetTenant("acme")
+
+	if got := Status(); !reflect.DeepEqual(got, map[string]int{"global": 1, "shared": 3}) {
+		t.Errorf("Expected acme-only rule to be cleared, got %v", got)
+	}
+	if got := Tenants(); !reflect.DeepEqual(got, map[string][]string{"globex": {"shared"}}) {
+		t.Errorf("Expected shared rule to stay scoped to globex, got %v", got)
+	}
+}
+
+func TestHTTPMiddlewareTenantHeader(t *testing.T) {
+	resetState()
+	SetTenantHeader("X-Org")
+	defer SetTenantHeader("X-Tenant-ID")
+
+	SetFailures("tenant-api", 5)
+	SetTenants("tenant-api", "acme")
+
+	handler := HTTPMiddleware("tenant-api")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(200)
+	}))
+
+	tests := []struct {
+		tenant         string
+		expectedStatus int
+	}{
+		{tenant: "", expectedStatus: 200},
+		{tenant: "globex", expectedStatus: 200},
+		{tenant: "acme", expectedStatus: 500},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/test", nil)
+		if tt.tenant != "" {
+			req.Header.Set("X-Org", tt.tenant)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.expectedStatus {
+			t.Errorf("tenant %q: expected status %d, got %d", tt.tenant, tt.expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestServerTenantEndpoints(t *testing.T) {
+	resetState()
+	server := httptest.NewServer(ControlHandler(nil))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/set?key=tenant-db&count=2&tenants=acme,globex", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/tenants/status?tenant=acme")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	var status map[string]int
+	json.NewDecoder(resp.Body).Decode(&status)
+	resp.Body.Close()
+	if status["tenant-db"] != 2 {
+		t.Errorf("Expected tenant-db to be listed for acme, got %v", status)
+	}
+
+	resp, err = http.Get(server.URL + "/tenants/status")
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 without tenant, got %d", resp.StatusCode)
+	}
+
+	for _, tenant := range []string{"acme", "globex"} {
+		resp, err = http.Post(server.URL+"/tenants/reset?tenant="+tenant, "text/plain", nil)
+		if err != nil {
+			t.Fatalf("Failed to make request: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if len(Status()) != 0 {
+		t.Errorf("Expected rule to be cleared once all its tenants are reset, got %v", Status())
+	}
+}
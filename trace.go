@@ -0,0 +1,147 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DecisionHeader is the response header set by middleware created with
+// WithDecisionHeader.
+const DecisionHeader = "X-Fault-Decision"
+
+// maxTraced caps the decisions collected per trace so a hot loop cannot
+// produce an unbounded header.
+const maxTraced = 64
+
+type traceKey struct{}
+
+type decisionTrace struct {
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+// WithDecisionTrace returns a copy of ctx that collects every decision made
+// with it or a context derived from it, for keys with and without rules.
+func WithDecisionTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, traceKey{}, &decisionTrace{})
+}
+
+// TracedDecisions returns the decisions collected by ctx's trace, in call
+// order, or nil if ctx carries no trace.
+func TracedDecisions(ctx context.Context) []Decision {
+	t := traceFrom(ctx)
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Decision(nil), t.decisions...)
+}
+
+func traceFrom(ctx context.Context) *decisionTrace {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(traceKey{}).(*decisionTrace)
+	return t
+}
+
+func (in *Injector) traceDecision(ctx context.Context, key string, fired bool, reason Reason, count int) {
+	t := traceFrom(ctx)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.decisions) < maxTraced {
+		t.decisions = append(t.decisions, Decision{Key: key, Fired: fired, Reason: reason, Count: count, Time: in.timeNow()})
+	}
+}
+
+// FormatDecisions renders decisions in the DecisionHeader format:
+// comma-separated "key=fired;reason" entries, e.g.
+// "db-connect=true;fired, api-call=false;exhausted". Keys are query-escaped.
+func FormatDecisions(decisions []Decision) string {
+	parts := make([]string, len(decisions))
+	for i, d := range decisions {
+		parts[i] = url.QueryEscape(d.Key) + "=" + strconv.FormatBool(d.Fired) + ";" + string(d.Reason)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseDecisionHeader parses a DecisionHeader value, so client-side tests can
+// assert which injection points a request hit.
+func ParseDecisionHeader(value string) ([]Decision, error) {
+	var out []Decision
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, rest, ok := strings.Cut(part, "=")
+		fired, reason, ok2 := strings.Cut(rest, ";")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("malformed decision %q", part)
+		}
+		k, err := url.QueryUnescape(key)
+		if err != nil {
+			return nil, fmt.Errorf("malformed decision %q: %w", part, err)
+		}
+		f, err := strconv.ParseBool(fired)
+		if err != nil {
+			return nil, fmt.Errorf("malformed decision %q: %w", part, err)
+		}
+		out = append(out, Decision{Key: k, Fired: f, Reason: Reason(reason)})
+	}
+	return out, nil
+}
+
+// decisionWriter sets DecisionHeader from ctx's trace just before the
+// response headers are written.
+type decisionWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	annotated bool
+}
+
+func (w *decisionWriter) annotate() {
+	if w.annotated {
+		return
+	}
+	w.annotated = true
+	if v := FormatDecisions(TracedDecisions(w.ctx)); v != "" {
+		w.Header().Set(DecisionHeader, v)
+	}
+}
+
+func (w *decisionWriter) WriteHeader(code int) {
+	w.annotate()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *decisionWriter) Write(b []byte) (int, error) {
+	w.annotate()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *decisionWriter) Flush() {
+	w.annotate()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *decisionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
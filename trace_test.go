@@ -0,0 +1,131 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDecisionTrace(t *testing.T) {
+	resetState()
+	SetFailures("db-connect", 1)
+
+	ctx := WithDecisionTrace(context.Background())
+	InjectWithContext(ctx, "db-connect")
+	InjectWithContext(ctx, "db-connect")
+	InjectWithContext(ctx, "unconfigured")
+	Inject("db-connect") // not made with ctx
+
+	got := TracedDecisions(ctx)
+	expected := []struct {
+		key    string
+		fired  bool
+		reason Reason
+	}{
+		{"db-connect", true, ReasonFired},
+		{"db-connect", false, ReasonExhausted},
+		{"unconfigured", false, ReasonDisabled},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d decisions, got %+v", len(expected), got)
+	}
+	for i, e := range expected {
+		if got[i].Key != e.key || got[i].Fired != e.fired || got[i].Reason != e.reason {
+			t.Errorf("Decision %d: expected %s/%v/%s, got %+v", i, e.key, e.fired, e.reason, got[i])
+		}
+	}
+
+	if TracedDecisions(context.Background()) != nil {
+		t.Error("Expected no decisions for a context without a trace")
+	}
+}
+
+func TestFormatDecisions(t *testing.T) {
+	decisions := []Decision{
+		{Key: "db-connect", Fired: true, Reason: ReasonFired},
+		{Key: "odd,key=1", Fired: false, Reason: ReasonTenant},
+	}
+	header := FormatDecisions(decisions)
+	if header != "db-connect=true;fired, odd%2Ckey%3D1=false;tenant-mismatch" {
+		t.Errorf("Unexpected header %q", header)
+	}
+	parsed, err := ParseDecisionHeader(header)
+	if err != nil {
+		t.Fatalf("ParseDecisionHeader returned error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, decisions) {
+		t.Errorf("Expected %+v, got %+v", decisions, parsed)
+	}
+
+	if _, err := ParseDecisionHeader("db-connect=maybe"); err == nil {
+		t.Error("Expected error for malformed header")
+	}
+}
+
+func TestMiddlewareDecisionHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func()
+		opts     []MiddlewareOption
+		expected string
+	}{
+		{
+			name:     "successful response lists evaluated keys",
+			setup:    func() { SetFailures("db-query", 1) },
+			opts:     []MiddlewareOption{WithDecisionHeader()},
+			expected: "api=false;disabled, db-query=true;fired",
+		},
+		{
+			name:     "injected response",
+			setup:    func() { SetFailures("api", 1) },
+			opts:     []MiddlewareOption{WithDecisionHeader()},
+			expected: "api=true;fired",
+		},
+		{
+			name:     "option not set",
+			setup:    func() { SetFailures("api", 1) },
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			tt.setup()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if InjectWithContext(r.Context(), "db-query") {
+					w.Write([]byte("degraded"))
+					return
+				}
+				w.Write([]byte("ok"))
+			})
+			handler := HTTPMiddleware("api", tt.opts...)(next)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if got := rec.Header().Get(DecisionHeader); got != tt.expected {
+				t.Errorf("Expected header %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareDecisionHeaderNested(t *testing.T) {
+	resetState()
+
+	inner := HTTPMiddleware("inner", WithDecisionHeader())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	handler := HTTPMiddleware("outer", WithDecisionHeader())(inner)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get(DecisionHeader); got != "outer=false;disabled, inner=false;disabled" {
+		t.Errorf("Expected both keys in one header, got %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import "time"
+
+// SetFailuresFor fails the first count calls to key, as SetFailures does,
+// but only for ttl from now. Afterwards the rule stops firing and calls are
+// recorded with ReasonExpired. Fault injection is disabled in production
+// environments.
+func (in *Injector) SetFailuresFor(key string, count int, ttl time.Duration) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setFailuresLocked(key, count)
+	in.setTTLLocked(key, ttl)
+}
+
+// SetFailuresFor calls SetFailuresFor on the default Injector.
+func SetFailuresFor(key string, count int, ttl time.Duration) {
+	std.SetFailuresFor(key, count, ttl)
+}
+
+// SetTTL limits key's rules to ttl from now, whatever kind they are, so soak
+// tests get transient fault windows without removing rules by hand. Setting a
+// new failure mode for key clears its TTL, and a ttl of zero or less removes it.
+// Fault injection is disabled in production environments.
+func (in *Injector) SetTTL(key string, ttl time.Duration) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.setTTLLocked(key, ttl)
+}
+
+// SetTTL calls SetTTL on the default Injector.
+func SetTTL(key string, ttl time.Duration) {
+	std.SetTTL(key, ttl)
+}
+
+func (in *Injector) setTTLLocked(key string, ttl time.Duration) {
+	in.updateRuleLocked(key, func(r *Rule) {
+		r.Expires = time.Time{}
+		if ttl > 0 {
+			r.Expires = in.timeNow().Add(ttl)
+		}
+	})
+}
+
+// expiredLocked reports whether key's TTL has passed.
+func (in *Injector) expiredLocked(key string) bool {
+	return in.rules[key].expired(in.timeNow())
+}
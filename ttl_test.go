@@ -0,0 +1,125 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetFailuresFor(t *testing.T) {
+	start := time.Now()
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	resetState()
+	SetFailuresFor("soak", 100, 2*time.Minute)
+
+	if !Inject("soak") {
+		t.Error("Expected the rule to fire within its TTL")
+	}
+	now = start.Add(2 * time.Minute)
+	if Inject("soak") {
+		t.Error("Expected the rule to stop firing once its TTL passed")
+	}
+	if h := History("soak"); h[len(h)-1].Reason != ReasonExpired {
+		t.Errorf("Expected reason %s, got %s", ReasonExpired, h[len(h)-1].Reason)
+	}
+	if Status()["soak"] != 0 {
+		t.Errorf("Expected 0 remaining after expiry, got %d", Status()["soak"])
+	}
+
+	SetFailures("soak", 1)
+	if !Inject("soak") {
+		t.Error("Expected SetFailures to clear the TTL")
+	}
+}
+
+func TestSetTTL(t *testing.T) {
+	start := time.Now()
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{name: "precise", setup: func() { SetNthFailure("soak", 1) }},
+		{name: "flapping", setup: func() { SetFlapping("soak", FlapCycle{Period: time.Hour, Unhealthy: time.Hour}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			now = start
+			tt.setup()
+			SetTTL("soak", time.Minute)
+			now = start.Add(time.Minute)
+			if Inject("soak") {
+				t.Error("Expected the rule to stop firing once its TTL passed")
+			}
+		})
+	}
+
+	resetState()
+	now = start
+	SetFailures("soak", 2)
+	SetTTL("soak", time.Minute)
+	SetTTL("soak", 0)
+	now = start.Add(time.Hour)
+	if !Inject("soak") {
+		t.Error("Expected a zero TTL to remove the limit")
+	}
+}
+
+func TestPruneExpired(t *testing.T) {
+	start := time.Now()
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	resetState()
+	SetFailuresFor("soak", 5, time.Minute)
+	SetFlapping("flap", FlapCycle{Period: time.Minute, Unhealthy: time.Second})
+	SetTTL("flap", time.Minute)
+	SetFailures("active", 5)
+
+	if n := Prune(GCOptions{PruneExhausted: true}); n != 0 {
+		t.Errorf("Expected nothing pruned before expiry, got %d", n)
+	}
+	now = start.Add(time.Minute)
+	if n := Prune(GCOptions{PruneExhausted: true}); n != 2 {
+		t.Errorf("Expected 2 expired keys pruned, got %d", n)
+	}
+	if _, ok := Status()["active"]; !ok {
+		t.Error("Expected the active rule to be kept")
+	}
+}
+
+func TestDurationsSpec(t *testing.T) {
+	start := time.Now()
+	now := start
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	resetState()
+	spec := filepath.Join(t.TempDir(), "faults.yaml")
+	content := "failures:\n  soak: 100\ndurations:\n  soak: 2m\n"
+	if err := os.WriteFile(spec, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := LoadSpec(spec); err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	if !Inject("soak") {
+		t.Error("Expected the rule to fire within its duration")
+	}
+	now = start.Add(3 * time.Minute)
+	if Inject("soak") {
+		t.Error("Expected the rule to expire after its duration")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import "context"
+
+// The v1 package-level functions most programs use, kept so that switching
+// the import path to /v2 compiles before any call site is migrated. They
+// act on Default. LoadSpec has moved to the spec package.
+
+// Inject reports whether the call to key should fail.
+//
+// Deprecated: Use Default().Inject.
+func Inject(key string) bool {
+	return std.Inject(context.Background(), key)
+}
+
+// InjectWithContext reports whether the call to key should fail.
+//
+// Deprecated: Use Default().Inject.
+func InjectWithContext(ctx context.Context, key string) bool {
+	return std.Inject(ctx, key)
+}
+
+// InjectWithError returns an error if the call to key should fail.
+//
+// Deprecated: Use Default().InjectError.
+func InjectWithError(key string, message string) error {
+	return std.InjectError(context.Background(), key, message)
+}
+
+// InjectWithContextError returns an error if the call to key should fail.
+//
+// Deprecated: Use Default().InjectError.
+func InjectWithContextError(ctx context.Context, key string, message string) error {
+	return std.InjectError(ctx, key, message)
+}
+
+// SetFailures fails the first count calls to key. Zero or less removes
+// key's rule.
+//
+// Deprecated: Use Default().Set(key, FirstN(count)).
+func SetFailures(key string, count int) {
+	if count <= 0 {
+		std.Set(key, Rule{})
+		return
+	}
+	std.Set(key, FirstN(count))
+}
+
+// SetNthFailure fails only call number nth to key. Zero or less removes
+// key's rule.
+//
+// Deprecated: Use Default().Set(key, Nth(nth)).
+func SetNthFailure(key string, nth int) {
+	if nth <= 0 {
+		std.Set(key, Rule{})
+		return
+	}
+	std.Set(key, Nth(nth))
+}
+
+// SetFailureRate fails each call to key with probability rate. Zero or
+// less removes key's rule.
+//
+// Deprecated: Use Default().Set(key, Rate(min(rate, 1))).
+func SetFailureRate(key string, rate float64) {
+	if rate <= 0 {
+		std.Set(key, Rule{})
+		return
+	}
+	std.Set(key, Rate(min(rate, 1)))
+}
+
+// SetRule replaces key's rule with r, ignoring it if it is invalid.
+//
+// Deprecated: Use Default().Set, which reports invalid rules.
+func SetRule(key string, r Rule) {
+	std.Set(key, r)
+}
+
+// Status returns the remaining failures per key with a rule.
+//
+// Deprecated: Use Default().Status.
+func Status() map[string]int {
+	out := make(map[string]int)
+	for key, st := range std.Status() {
+		if st.Mode != ModeNone {
+			out[key] = st.Remaining
+		}
+	}
+	return out
+}
+
+// Reset removes every rule and call count.
+//
+// Deprecated: Use Default().Reset.
+func Reset() {
+	std.Reset()
+}
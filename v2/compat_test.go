@@ -0,0 +1,63 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompatForwards(t *testing.T) {
+	resetState(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		setup func()
+		call  func() bool
+	}{
+		{
+			name:  "SetFailures",
+			setup: func() { SetFailures("compat", 1) },
+			call:  func() bool { return Inject("compat") },
+		},
+		{
+			name:  "SetNthFailure",
+			setup: func() { SetNthFailure("compat", 1) },
+			call:  func() bool { return InjectWithContext(ctx, "compat") },
+		},
+		{
+			name:  "SetFailureRate",
+			setup: func() { SetFailureRate("compat", 1) },
+			call:  func() bool { return InjectWithError("compat", "boom") != nil },
+		},
+		{
+			name:  "SetRule",
+			setup: func() { SetRule("compat", FirstN(1)) },
+			call:  func() bool { return InjectWithContextError(ctx, "compat", "boom") != nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Reset()
+			defer Reset()
+			tt.setup()
+			if !tt.call() {
+				t.Error("Expected the forwarded call to fail")
+			}
+		})
+	}
+}
+
+func TestCompatStatusAndReset(t *testing.T) {
+	resetState(t)
+	SetFailures("compat", 2)
+	Inject("compat")
+	if got := Status()["compat"]; got != 1 {
+		t.Errorf("Expected 1 failure left, got %d", got)
+	}
+	Reset()
+	if _, ok := Default().Rule("compat"); ok {
+		t.Error("Expected Reset to clear the rules of Default")
+	}
+}
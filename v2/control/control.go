@@ -0,0 +1,112 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Package control serves an HTTP API for changing the faults of a go-fi v2
+// Injector at runtime. Its /set, /reset and /status endpoints behave like
+// those of the v1 control server, so the client package and fictl work
+// against it.
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+
+	faultinject "github.com/talinashro/go-fi/v2"
+)
+
+// Handler returns the control API of in, to be mounted on a mux:
+//
+//	/set?key=k&count=n  fail the first n calls to k; 0 removes k's rule
+//	/rules              the rules as JSON; PUT ?key=k sets k's rule from
+//	                    the JSON body
+//	/reset              remove every rule
+//	/status             remaining failures per key, or with ?full=true the
+//	                    status of every key
+//
+// Requests in a production environment fail with 403 Forbidden.
+func Handler(in *faultinject.Injector) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/set", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.URL.Query().Get("count"))
+		if err != nil {
+			http.Error(w, "invalid count", http.StatusBadRequest)
+			return
+		}
+		rule := faultinject.Rule{}
+		if n > 0 {
+			rule = faultinject.FirstN(n)
+		}
+		respond(w, in.Set(r.URL.Query().Get("key"), rule))
+	})
+
+	mux.HandleFunc("/rules", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(in.Rules())
+		case http.MethodPut:
+			var rule faultinject.Rule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			respond(w, in.Set(r.URL.Query().Get("key"), rule))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
+		if in.Production() {
+			respond(w, faultinject.ErrProduction)
+			return
+		}
+		in.Reset()
+	})
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		status := in.Status()
+		if full, _ := strconv.ParseBool(r.URL.Query().Get("full")); full {
+			json.NewEncoder(w).Encode(status)
+			return
+		}
+		remaining := make(map[string]int)
+		for key, st := range status {
+			if st.Mode != faultinject.ModeNone {
+				remaining[key] = st.Remaining
+			}
+		}
+		json.NewEncoder(w).Encode(remaining)
+	})
+
+	return mux
+}
+
+// respond writes the outcome of a change: nothing for success, 403 for
+// ErrProduction and 400 for a rule that was refused.
+func respond(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+	case errors.Is(err, faultinject.ErrProduction):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// Start serves Handler(in) on addr in the background. The listener is open
+// when it returns, so requests sent next are not refused.
+func Start(in *faultinject.Injector, addr string) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{Handler: Handler(in)}
+	go srv.Serve(ln)
+	return srv, nil
+}
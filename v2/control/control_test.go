@@ -0,0 +1,57 @@
+//go:build faultinject
+
+package control
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	faultinject "github.com/talinashro/go-fi/v2"
+)
+
+func TestHandler(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	in := faultinject.New()
+	h := Handler(in)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/set?key=db&count=2", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from /set, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/rules?key=cache", `{"mode": "nth", "nth": 3}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from /rules, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPut, "/rules?key=cache", `{"mode": "rate", "rate": 2}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid rule, got %d", rec.Code)
+	}
+
+	var remaining map[string]int
+	if err := json.NewDecoder(do(http.MethodGet, "/status", "").Body).Decode(&remaining); err != nil {
+		t.Fatal(err)
+	}
+	if remaining["db"] != 2 || remaining["cache"] != 1 {
+		t.Errorf("Expected db 2 and cache 1 remaining, got %v", remaining)
+	}
+
+	do(http.MethodPost, "/reset", "")
+	if n := len(in.Rules()); n != 0 {
+		t.Errorf("Expected /reset to remove every rule, got %d", n)
+	}
+}
+
+func TestHandlerInProduction(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	rec := httptest.NewRecorder()
+	Handler(faultinject.New()).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/set?key=db&count=1", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", rec.Code)
+	}
+}
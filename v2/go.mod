@@ -0,0 +1,5 @@
+module github.com/talinashro/go-fi/v2
+
+go 1.24.2
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,66 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Package httpfi injects go-fi v2 faults into HTTP servers and clients.
+package httpfi
+
+import (
+	"errors"
+	"net/http"
+
+	faultinject "github.com/talinashro/go-fi/v2"
+)
+
+// Middleware delays requests by key's latency and, when key's rule fires,
+// responds with the rule's Status, or 500, instead of calling the handler.
+// The response body is the rule's error message.
+func Middleware(in *faultinject.Injector, key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if _, err := in.InjectLatency(ctx, key); err != nil {
+				return // the client has gone
+			}
+			err := in.InjectError(ctx, key, "Injected failure")
+			var injected *faultinject.InjectedError
+			if !errors.As(err, &injected) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			code := http.StatusInternalServerError
+			if rule, _ := in.Rule(key); rule.Status != 0 {
+				code = rule.Status
+			}
+			http.Error(w, injected.Message, code)
+		})
+	}
+}
+
+// Transport returns a RoundTripper that delays requests by key's latency
+// and, when key's rule fires, returns an *faultinject.InjectedError instead
+// of sending them through base. A nil base means http.DefaultTransport.
+func Transport(in *faultinject.Injector, key string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return roundTripper{in: in, key: key, base: base}
+}
+
+type roundTripper struct {
+	in   *faultinject.Injector
+	key  string
+	base http.RoundTripper
+}
+
+func (t roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if _, err := t.in.InjectLatency(ctx, t.key); err != nil {
+		return nil, err
+	}
+	if err := t.in.InjectError(ctx, t.key, req.Method+" "+req.URL.Redacted()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,62 @@
+//go:build faultinject
+
+package httpfi
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	faultinject "github.com/talinashro/go-fi/v2"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	in := faultinject.New()
+	r := faultinject.FirstN(1)
+	r.Status, r.Error = http.StatusServiceUnavailable, "{key} unavailable"
+	if err := in.Set("api", r); err != nil {
+		t.Fatal(err)
+	}
+	h := Middleware(in, "api")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+
+	tests := []struct {
+		code int
+		body string
+	}{
+		{code: http.StatusServiceUnavailable, body: "api unavailable"},
+		{code: http.StatusOK, body: "ok"},
+	}
+	for i, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != tt.code || strings.TrimSpace(rec.Body.String()) != tt.body {
+			t.Errorf("Request %d: expected %d %q, got %d %q", i+1, tt.code, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestTransport(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	in := faultinject.New()
+	if err := in.Set("upstream", faultinject.FirstN(1)); err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Transport: Transport(in, "upstream", nil)}
+
+	if _, err := c.Get(srv.URL); !errors.Is(err, faultinject.ErrInjected) {
+		t.Errorf("Expected an injected error, got %v", err)
+	}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected the second request to go through, got %v", err)
+	}
+	resp.Body.Close()
+}
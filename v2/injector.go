@@ -0,0 +1,246 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Package faultinject is version 2 of go-fi. Every operation is a method of
+// an Injector, rules are set whole as Rule values, and setters return an
+// error instead of silently ignoring a rule that can never fire.
+//
+// The package is only the decision engine run at injection points, and
+// depends on neither net/http nor a YAML parser, so linking it into a
+// service adds little to the binary. Loading specs, HTTP middleware and
+// the control server live in the spec, httpfi and control subpackages,
+// which a program imports only where it uses them.
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrProduction is returned by setters in a production environment, where
+// fault injection is disabled.
+var ErrProduction = errors.New("fault injection is disabled in production")
+
+// An Injector holds fault rules and the call counts they are decided on. It
+// is safe for concurrent use.
+type Injector struct {
+	mu     sync.Mutex
+	rules  map[string]Rule
+	starts map[string]time.Time // when each rule was set, for flapping
+	calls  map[string]int
+}
+
+var std = New()
+
+// New returns an Injector with no rules, independent of Default and of
+// every other Injector.
+func New() *Injector {
+	return &Injector{
+		rules:  make(map[string]Rule),
+		starts: make(map[string]time.Time),
+		calls:  make(map[string]int),
+	}
+}
+
+// Default returns the Injector the package-level functions act on.
+func Default() *Injector {
+	return std
+}
+
+// Production reports whether the environment, taken from ENVIRONMENT, ENV
+// or GO_ENV, is a production one. Only development, staging and testing
+// are not; in production nothing fires and setters return ErrProduction.
+func (i *Injector) Production() bool {
+	env := os.Getenv("ENVIRONMENT")
+	if env == "" {
+		env = os.Getenv("ENV")
+	}
+	if env == "" {
+		env = os.Getenv("GO_ENV")
+	}
+	switch strings.ToLower(env) {
+	case "development", "staging", "testing":
+		return false
+	}
+	return true
+}
+
+// Inject reports whether the call to key at ctx should fail.
+func (i *Injector) Inject(ctx context.Context, key string) bool {
+	_, _, fire := i.decide(key)
+	return fire
+}
+
+// InjectError returns an *InjectedError carrying message if the call to key
+// at ctx should fail, and nil otherwise.
+func (i *Injector) InjectError(ctx context.Context, key string, message string) error {
+	r, count, fire := i.decide(key)
+	if !fire {
+		return nil
+	}
+	return &InjectedError{
+		Key:     key,
+		Message: r.errorMessage(key, count, message),
+		Code:    r.Code,
+		Count:   count,
+	}
+}
+
+// decide counts a call to key and reports whether it fails, with key's rule
+// and the call's number.
+func (i *Injector) decide(key string) (Rule, int, bool) {
+	if i.Production() {
+		return Rule{}, 0, false
+	}
+	now := time.Now()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.calls[key]++
+	n := i.calls[key]
+	r, ok := i.rules[key]
+	if !ok || r.expired(now) {
+		return r, n, false
+	}
+	return r, n, r.fails(n, now.Sub(i.starts[key]))
+}
+
+// InjectLatency sleeps for the delay key's rule calls for, or until ctx is
+// done, and returns the delay. Rules built with Phases use the latency of
+// the phase the next call to Inject falls in.
+func (i *Injector) InjectLatency(ctx context.Context, key string) (time.Duration, error) {
+	if i.Production() {
+		return 0, nil
+	}
+	i.mu.Lock()
+	r, ok := i.rules[key]
+	next := i.calls[key] + 1
+	i.mu.Unlock()
+	if !ok || r.expired(time.Now()) {
+		return 0, nil
+	}
+	d := r.Latency.Sample()
+	if a, ok := r.phase(next); ok && a.Latency > 0 {
+		d = a.Latency
+	}
+	if d <= 0 {
+		return 0, nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return d, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Set replaces key's rule with r and restarts its call count. A zero Rule
+// removes key's rule.
+func (i *Injector) Set(key string, r Rule) error {
+	if key == "" {
+		return errors.New("empty key")
+	}
+	if err := validate(r); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if i.Production() {
+		return ErrProduction
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.setLocked(key, r, time.Now())
+	return nil
+}
+
+// Replace replaces every rule with rules and restarts every call count. If
+// one of the rules is invalid, it returns an error naming its key and
+// changes nothing.
+func (i *Injector) Replace(rules map[string]Rule) error {
+	for key, r := range rules {
+		if key == "" {
+			return errors.New("empty key")
+		}
+		if err := validate(r); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if i.Production() {
+		return ErrProduction
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.resetLocked()
+	now := time.Now()
+	for key, r := range rules {
+		i.setLocked(key, r, now)
+	}
+	return nil
+}
+
+func (i *Injector) setLocked(key string, r Rule, now time.Time) {
+	delete(i.calls, key)
+	if r.isZero() {
+		delete(i.rules, key)
+		delete(i.starts, key)
+		return
+	}
+	i.rules[key] = r
+	i.starts[key] = now
+}
+
+// Rule returns key's rule, if it has one.
+func (i *Injector) Rule(key string) (Rule, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	r, ok := i.rules[key]
+	return r, ok
+}
+
+// Rules returns the rule of every key that has one.
+func (i *Injector) Rules() map[string]Rule {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return maps.Clone(i.rules)
+}
+
+// Status returns the state of every key with a rule or a call count.
+func (i *Injector) Status() map[string]KeyStatus {
+	now := time.Now()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	status := make(map[string]KeyStatus, len(i.rules)+len(i.calls))
+	for key, n := range i.calls {
+		status[key] = KeyStatus{Calls: n}
+	}
+	for key, r := range i.rules {
+		n := i.calls[key]
+		st := KeyStatus{Rule: r, Calls: n, Expired: r.expired(now)}
+		if !st.Expired {
+			st.Remaining = r.remaining(n)
+		}
+		status[key] = st
+	}
+	return status
+}
+
+// Reset removes every rule and call count.
+func (i *Injector) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.resetLocked()
+}
+
+func (i *Injector) resetLocked() {
+	clear(i.rules)
+	clear(i.starts)
+	clear(i.calls)
+}
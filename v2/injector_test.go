@@ -0,0 +1,269 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// resetState resets the default Injector and marks the environment as
+// development.
+func resetState(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	Default().Reset()
+}
+
+func TestSet(t *testing.T) {
+	resetState(t)
+	inj := New()
+	ctx := context.Background()
+
+	if err := inj.Set("db", FirstN(2)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, want := range []bool{true, true, false} {
+		if got := inj.Inject(ctx, "db"); got != want {
+			t.Errorf("Call %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+	if got := inj.Status()["db"].Calls; got != 3 {
+		t.Errorf("Expected 3 calls, got %d", got)
+	}
+
+	if err := inj.Set("db", Rule{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := inj.Rules()["db"]; ok {
+		t.Error("Expected a zero Rule to remove the rule")
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	resetState(t)
+	inj := New()
+
+	tests := []struct {
+		name string
+		key  string
+		rule Rule
+	}{
+		{name: "empty key", key: "", rule: FirstN(1)},
+		{name: "zero count", key: "db", rule: FirstN(0)},
+		{name: "negative nth", key: "db", rule: Nth(-1)},
+		{name: "rate above one", key: "db", rule: Rate(1.5)},
+		{name: "zero rate", key: "db", rule: Rate(0)},
+		{name: "unhealthy longer than period", key: "db", rule: Flapping(1, 2)},
+		{name: "no actions", key: "db", rule: Phases()},
+		{name: "unbounded action before the last", key: "db", rule: Phases(Action{Fail: true}, Action{Calls: 1})},
+		{name: "unknown mode", key: "db", rule: Rule{Mode: "sometimes"}},
+		{name: "count for another mode", key: "db", rule: Rule{Mode: ModeNth, Nth: 1, Count: 3}},
+		{name: "inverted latency", key: "db", rule: Rule{Latency: LatencyDistribution{{Min: 2, Max: 1, Weight: 1}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := inj.Set(tt.key, tt.rule); err == nil {
+				t.Error("Expected an error, got nil")
+			}
+		})
+	}
+	if n := len(inj.Rules()); n != 0 {
+		t.Errorf("Expected no rules to be set, got %d", n)
+	}
+}
+
+func TestSetInProduction(t *testing.T) {
+	resetState(t)
+	t.Setenv("ENVIRONMENT", "production")
+	inj := New()
+
+	if err := inj.Set("db", FirstN(1)); !errors.Is(err, ErrProduction) {
+		t.Errorf("Expected ErrProduction, got %v", err)
+	}
+	if err := inj.Replace(map[string]Rule{"db": FirstN(1)}); !errors.Is(err, ErrProduction) {
+		t.Errorf("Expected ErrProduction, got %v", err)
+	}
+}
+
+func TestInjectError(t *testing.T) {
+	resetState(t)
+	inj := New()
+	if err := inj.Set("db", Rule{Mode: ModeFirstN, Count: 1, Error: "{key}: {message}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := inj.InjectError(context.Background(), "db", "insert failed")
+	var injected *InjectedError
+	if !errors.As(err, &injected) || !errors.Is(err, ErrInjected) {
+		t.Fatalf("Expected an InjectedError, got %v", err)
+	}
+	if injected.Message != "db: insert failed" {
+		t.Errorf("Expected message %q, got %q", "db: insert failed", injected.Message)
+	}
+	if err := inj.InjectError(context.Background(), "db", "insert failed"); err != nil {
+		t.Errorf("Expected nil after the first call, got %v", err)
+	}
+}
+
+func TestNewIsIsolated(t *testing.T) {
+	resetState(t)
+	inj := New()
+	if err := inj.Set("db", FirstN(1)); err != nil {
+		t.Fatal(err)
+	}
+	if Default().Inject(context.Background(), "db") {
+		t.Error("Expected New's rules not to reach Default")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	resetState(t)
+	inj := New()
+	ctx := context.Background()
+	if err := inj.Set("old", FirstN(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := inj.Replace(map[string]Rule{"db": FirstN(1), "cache": Rate(2)}); err == nil {
+		t.Error("Expected an error for the invalid rate, got nil")
+	}
+	if _, ok := inj.Rule("old"); !ok {
+		t.Error("Expected a refused Replace to keep the old rules")
+	}
+
+	if err := inj.Replace(map[string]Rule{"db": FirstN(1)}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if inj.Inject(ctx, "old") {
+		t.Error("Expected Replace to remove the old rules")
+	}
+	if !inj.Inject(ctx, "db") {
+		t.Error("Expected Replace to set the new rules")
+	}
+}
+
+func TestModes(t *testing.T) {
+	resetState(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		rule      Rule
+		want      []bool
+		remaining int // after the calls in want
+	}{
+		{name: "first-n", rule: FirstN(2), want: []bool{true, true, false}, remaining: 0},
+		{name: "nth", rule: Nth(2), want: []bool{false, true, false}, remaining: 0},
+		{name: "nth ahead", rule: Nth(5), want: []bool{false, false}, remaining: 1},
+		{name: "rate", rule: Rate(1), want: []bool{true, true}, remaining: -1},
+		{
+			name:      "phases",
+			rule:      Phases(Action{Calls: 1}, Action{Calls: 2, Fail: true}, Action{Calls: 1}),
+			want:      []bool{false, true, true, false, false},
+			remaining: 0,
+		},
+		{
+			name:      "phases midway",
+			rule:      Phases(Action{Calls: 1}, Action{Calls: 3, Fail: true}),
+			want:      []bool{false, true},
+			remaining: 2,
+		},
+		{
+			name:      "unbounded last phase",
+			rule:      Phases(Action{Calls: 1}, Action{Fail: true}),
+			want:      []bool{false, true, true},
+			remaining: -1,
+		},
+		{name: "latency only", rule: Rule{Latency: FixedLatency(0)}, want: []bool{false}, remaining: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inj := New()
+			if err := inj.Set("db", tt.rule); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if got := inj.Inject(ctx, "db"); got != want {
+					t.Errorf("Call %d: expected %v, got %v", i+1, want, got)
+				}
+			}
+			if got := inj.Status()["db"].Remaining; got != tt.remaining {
+				t.Errorf("Expected %d remaining, got %d", tt.remaining, got)
+			}
+		})
+	}
+}
+
+func TestFlapping(t *testing.T) {
+	resetState(t)
+	inj := New()
+	if err := inj.Set("db", Flapping(time.Hour, time.Hour-time.Nanosecond)); err != nil {
+		t.Fatal(err)
+	}
+	if !inj.Inject(context.Background(), "db") {
+		t.Error("Expected the start of the cycle to be unhealthy")
+	}
+}
+
+func TestExpires(t *testing.T) {
+	resetState(t)
+	inj := New()
+	r := FirstN(1)
+	r.Expires = time.Now().Add(-time.Second)
+	if err := inj.Set("db", r); err != nil {
+		t.Fatal(err)
+	}
+	if inj.Inject(context.Background(), "db") {
+		t.Error("Expected an expired rule not to fire")
+	}
+	if st := inj.Status()["db"]; !st.Expired || st.Remaining != 0 {
+		t.Errorf("Expected an expired status with nothing remaining, got %+v", st)
+	}
+}
+
+func TestInjectLatency(t *testing.T) {
+	resetState(t)
+	inj := New()
+	ctx := context.Background()
+	if err := inj.Set("db", Phases(Action{Calls: 1, Latency: time.Millisecond}, Action{})); err != nil {
+		t.Fatal(err)
+	}
+
+	if d, err := inj.InjectLatency(ctx, "db"); err != nil || d != time.Millisecond {
+		t.Errorf("Expected 1ms in the first phase, got %v, %v", d, err)
+	}
+	inj.Inject(ctx, "db")
+	if d, _ := inj.InjectLatency(ctx, "db"); d != 0 {
+		t.Errorf("Expected no delay in the second phase, got %v", d)
+	}
+
+	if err := inj.Set("slow", Rule{Latency: FixedLatency(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := inj.InjectLatency(cancelled, "slow"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestNoHTTPOrYAML checks the point of the package split: the engine links
+// in neither net/http nor a YAML parser.
+func TestNoHTTPOrYAML(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not found")
+	}
+	out, err := exec.Command("go", "list", "-tags", "faultinject", "-deps", ".").Output()
+	if err != nil {
+		t.Fatalf("go list: %v", err)
+	}
+	for _, dep := range strings.Fields(string(out)) {
+		if dep == "net/http" || strings.HasPrefix(dep, "gopkg.in/yaml") || dep == "github.com/talinashro/go-fi" {
+			t.Errorf("Expected the engine not to depend on %s", dep)
+		}
+	}
+}
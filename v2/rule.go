@@ -0,0 +1,298 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode selects which calls a rule fails.
+type Mode string
+
+const (
+	ModeNone     Mode = ""         // never fails; the rule only adds latency
+	ModeFirstN   Mode = "first-n"  // fail the first Count calls
+	ModeNth      Mode = "nth"      // fail only call number Nth
+	ModeRate     Mode = "rate"     // fail each call with probability Rate
+	ModeFlapping Mode = "flapping" // fail during the unhealthy part of Flap
+	ModeActions  Mode = "actions"  // go through the phases of Actions in order
+)
+
+// Rule is the complete configuration of a key: a failure mode plus
+// optional latency, error template and expiry. Build one with FirstN, Nth,
+// Rate, Flapping or Phases and adjust its fields. The fields are the part
+// of v1's Rule the engine decides on, with the same names and tags.
+type Rule struct {
+	Mode    Mode                `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Count   int                 `yaml:"count,omitempty" json:"count,omitempty"`
+	Nth     int                 `yaml:"nth,omitempty" json:"nth,omitempty"`
+	Rate    float64             `yaml:"rate,omitempty" json:"rate,omitempty"`
+	Flap    *FlapCycle          `yaml:"flap,omitempty" json:"flap,omitempty"`
+	Actions []Action            `yaml:"actions,omitempty" json:"actions,omitempty"`
+	Latency LatencyDistribution `yaml:"latency,omitempty" json:"latency,omitempty"`
+	// Error replaces the message of errors returned when the rule fires.
+	// "{key}", "{count}" and "{message}" expand to the key, the call count and
+	// the call site's message.
+	Error string `yaml:"error,omitempty" json:"error,omitempty"`
+	// Code is copied to InjectedError.Code, for callers that branch on an
+	// error code such as "ECONNREFUSED" or "DEADLINE_EXCEEDED".
+	Code string `yaml:"code,omitempty" json:"code,omitempty"`
+	// Status is the HTTP status httpfi responds with when the rule fires;
+	// zero means 500.
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+	// Expires is when the rule stops firing; zero means never.
+	Expires time.Time `yaml:"expires,omitempty" json:"expires,omitzero"`
+}
+
+// FlapCycle describes a key that is unhealthy for the first Unhealthy of
+// every Period, counted from when its rule was set.
+type FlapCycle struct {
+	Period    time.Duration `yaml:"period" json:"period"`
+	Unhealthy time.Duration `yaml:"unhealthy" json:"unhealthy"`
+}
+
+// Action is one phase of a composite rule built with Phases.
+type Action struct {
+	// Calls is how many calls the phase lasts. Zero means every remaining
+	// call, so it only makes sense for the last phase.
+	Calls int `yaml:"calls,omitempty" json:"calls,omitempty"`
+	// Latency is the fixed delay InjectLatency adds during the phase,
+	// instead of the rule's latency distribution.
+	Latency time.Duration `yaml:"latency,omitempty" json:"latency,omitempty"`
+	// Fail makes Inject fail during the phase.
+	Fail bool `yaml:"fail,omitempty" json:"fail,omitempty"`
+}
+
+// LatencyBucket is a range of delays chosen with probability proportional
+// to Weight.
+type LatencyBucket struct {
+	Min    time.Duration `yaml:"min" json:"min"`
+	Max    time.Duration `yaml:"max" json:"max"`
+	Weight float64       `yaml:"weight" json:"weight"`
+}
+
+// LatencyDistribution describes the delays injected for a key. A bucket is
+// picked by weight and the delay is drawn uniformly from [Min, Max].
+type LatencyDistribution []LatencyBucket
+
+// Sample draws one delay from the distribution.
+func (dist LatencyDistribution) Sample() time.Duration {
+	total := 0.0
+	for _, b := range dist {
+		total += b.Weight
+	}
+	if total <= 0 {
+		return 0
+	}
+	r := rand.Float64() * total
+	for _, b := range dist {
+		if r < b.Weight {
+			return b.Min + time.Duration(rand.Float64()*float64(b.Max-b.Min))
+		}
+		r -= b.Weight
+	}
+	return dist[len(dist)-1].Max
+}
+
+// KeyStatus is the state of a key as reported by Status.
+type KeyStatus struct {
+	Rule
+	Calls int `json:"calls"`
+	// Remaining is the number of failures left, or -1 for rates, flapping
+	// and phases that fail every remaining call, which have no fixed number.
+	Remaining int  `json:"remaining"`
+	Expired   bool `json:"expired,omitempty"`
+}
+
+// ErrInjected matches every error returned for an injected failure.
+var ErrInjected = errors.New("injected failure")
+
+// InjectedError is returned by InjectError when a failure is injected.
+type InjectedError struct {
+	Key     string
+	Message string
+	Code    string // error code from the key's rule, such as "ECONNREFUSED"
+	Count   int    // call count the failure fired at
+}
+
+// Error returns "injected failure: <message>", or "injected failure" when
+// there is no message.
+func (e *InjectedError) Error() string {
+	if e.Message == "" {
+		return ErrInjected.Error()
+	}
+	return ErrInjected.Error() + ": " + e.Message
+}
+
+// Is reports whether target is ErrInjected.
+func (e *InjectedError) Is(target error) bool {
+	return target == ErrInjected
+}
+
+// FirstN returns a rule that fails the first n calls.
+func FirstN(n int) Rule {
+	return Rule{Mode: ModeFirstN, Count: n}
+}
+
+// Nth returns a rule that fails only call number n.
+func Nth(n int) Rule {
+	return Rule{Mode: ModeNth, Nth: n}
+}
+
+// Rate returns a rule that fails each call with probability p.
+func Rate(p float64) Rule {
+	return Rule{Mode: ModeRate, Rate: p}
+}
+
+// Flapping returns a rule that fails for the first unhealthy of every
+// period.
+func Flapping(period, unhealthy time.Duration) Rule {
+	return Rule{Mode: ModeFlapping, Flap: &FlapCycle{Period: period, Unhealthy: unhealthy}}
+}
+
+// Phases returns a composite rule that goes through actions in order, each
+// lasting its own number of calls.
+func Phases(actions ...Action) Rule {
+	return Rule{Mode: ModeActions, Actions: actions}
+}
+
+// FixedLatency returns a distribution that always delays by d.
+func FixedLatency(d time.Duration) LatencyDistribution {
+	return LatencyDistribution{{Min: d, Max: d, Weight: 1}}
+}
+
+// validate reports why r cannot be set. v1 accepts such rules and quietly
+// never fires them; v2 rejects them.
+func validate(r Rule) error {
+	switch r.Mode {
+	case ModeNone:
+	case ModeFirstN:
+		if r.Count <= 0 {
+			return fmt.Errorf("first-n count must be positive, got %d", r.Count)
+		}
+	case ModeNth:
+		if r.Nth <= 0 {
+			return fmt.Errorf("nth call must be positive, got %d", r.Nth)
+		}
+	case ModeRate:
+		if !(r.Rate > 0 && r.Rate <= 1) {
+			return fmt.Errorf("rate must be in (0, 1], got %g", r.Rate)
+		}
+	case ModeFlapping:
+		if r.Flap == nil || r.Flap.Period <= 0 || r.Flap.Unhealthy <= 0 || r.Flap.Unhealthy >= r.Flap.Period {
+			return fmt.Errorf("flapping needs 0 < unhealthy < period")
+		}
+	case ModeActions:
+		if len(r.Actions) == 0 {
+			return fmt.Errorf("actions needs at least one action")
+		}
+		for i, a := range r.Actions {
+			if a.Calls < 0 || a.Latency < 0 {
+				return fmt.Errorf("action %d: negative calls or latency", i+1)
+			}
+			if a.Calls == 0 && i < len(r.Actions)-1 {
+				return fmt.Errorf("action %d: only the last action can last every remaining call", i+1)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", r.Mode)
+	}
+	if r.Count != 0 && r.Mode != ModeFirstN || r.Nth != 0 && r.Mode != ModeNth ||
+		r.Rate != 0 && r.Mode != ModeRate || r.Flap != nil && r.Mode != ModeFlapping ||
+		len(r.Actions) > 0 && r.Mode != ModeActions {
+		return fmt.Errorf("settings for another mode than %q", r.Mode)
+	}
+	for _, b := range r.Latency {
+		if b.Min < 0 || b.Max < b.Min || b.Weight < 0 {
+			return fmt.Errorf("invalid latency bucket %v-%v weight %g", b.Min, b.Max, b.Weight)
+		}
+	}
+	return nil
+}
+
+func (r Rule) isZero() bool {
+	return r.Mode == ModeNone && len(r.Latency) == 0
+}
+
+func (r Rule) expired(now time.Time) bool {
+	return !r.Expires.IsZero() && !now.Before(r.Expires)
+}
+
+// fails reports whether call number n fails, since is how long ago the
+// rule was set.
+func (r Rule) fails(n int, since time.Duration) bool {
+	switch r.Mode {
+	case ModeFirstN:
+		return n <= r.Count
+	case ModeNth:
+		return n == r.Nth
+	case ModeRate:
+		return rand.Float64() < r.Rate
+	case ModeFlapping:
+		return since%r.Flap.Period < r.Flap.Unhealthy
+	case ModeActions:
+		a, ok := r.phase(n)
+		return ok && a.Fail
+	}
+	return false
+}
+
+// phase returns the action call number n falls in, if any.
+func (r Rule) phase(n int) (Action, bool) {
+	for _, a := range r.Actions {
+		if a.Calls == 0 || n <= a.Calls {
+			return a, true
+		}
+		n -= a.Calls
+	}
+	return Action{}, false
+}
+
+// remaining returns how many of the calls after the first n fail, or -1
+// when there is no fixed number.
+func (r Rule) remaining(n int) int {
+	switch r.Mode {
+	case ModeFirstN:
+		return max(0, r.Count-n)
+	case ModeNth:
+		if n < r.Nth {
+			return 1
+		}
+	case ModeRate, ModeFlapping:
+		return -1
+	case ModeActions:
+		left := 0
+		for _, a := range r.Actions {
+			switch {
+			case a.Calls == 0:
+				if a.Fail {
+					return -1
+				}
+			case n >= a.Calls:
+				n -= a.Calls
+			default:
+				if a.Fail {
+					left += a.Calls - n
+				}
+				n = 0
+			}
+		}
+		return left
+	}
+	return 0
+}
+
+func (r Rule) errorMessage(key string, count int, message string) string {
+	if r.Error == "" {
+		return message
+	}
+	return strings.NewReplacer("{key}", key, "{count}", strconv.Itoa(count), "{message}", message).Replace(r.Error)
+}
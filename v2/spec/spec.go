@@ -0,0 +1,125 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+// Package spec loads go-fi v2 rules from YAML or JSON spec files. It is the
+// only part of the v2 module that depends on a YAML parser, so binaries that
+// only run injection points leave it out.
+//
+// A spec uses the v1 format's rules, failures, precise-failures, latencies
+// and flapping sections:
+//
+//	rules:
+//	  payment-api: {count: 2, error: "connection refused", status: 503}
+//	failures:
+//	  db-connect: 3
+//	latencies:
+//	  db-query: [{min: 10ms, max: 50ms, weight: 1}]
+package spec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	faultinject "github.com/talinashro/go-fi/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the contents of a spec file. The sections are applied in the
+// order of the fields: a key's rule from Rules has its mode replaced by
+// Failures, PreciseFailures or Flapping and its latency by Latencies.
+type Spec struct {
+	Rules           map[string]faultinject.Rule                `yaml:"rules,omitempty" json:"rules,omitempty"`
+	Failures        map[string]int                             `yaml:"failures,omitempty" json:"failures,omitempty"`                 // first-N
+	PreciseFailures map[string]int                             `yaml:"precise-failures,omitempty" json:"precise-failures,omitempty"` // Nth
+	Flapping        map[string]faultinject.FlapCycle           `yaml:"flapping,omitempty" json:"flapping,omitempty"`
+	Latencies       map[string]faultinject.LatencyDistribution `yaml:"latencies,omitempty" json:"latencies,omitempty"`
+}
+
+// Parse parses a YAML or JSON spec. Unknown fields are errors, so a
+// misspelt section is not silently ignored.
+func Parse(data []byte) (Spec, error) {
+	var s Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return Spec{}, err
+	}
+	return s, nil
+}
+
+// Compile returns the rule of every key in s, with rules that leave out
+// their mode given the one their other fields imply, as in
+// {count: 3, status: 503}.
+func (s Spec) Compile() map[string]faultinject.Rule {
+	rules := make(map[string]faultinject.Rule, len(s.Rules))
+	for key, r := range s.Rules {
+		rules[key] = withImpliedMode(r)
+	}
+	for key, n := range s.Failures {
+		rules[key] = withMode(rules[key], faultinject.FirstN(n))
+	}
+	for key, n := range s.PreciseFailures {
+		rules[key] = withMode(rules[key], faultinject.Nth(n))
+	}
+	for key, c := range s.Flapping {
+		rules[key] = withMode(rules[key], faultinject.Flapping(c.Period, c.Unhealthy))
+	}
+	for key, dist := range s.Latencies {
+		r := rules[key]
+		r.Latency = dist
+		rules[key] = r
+	}
+	return rules
+}
+
+// Apply replaces every rule of in with those of s. If one of them is
+// invalid, it returns an error naming its key and changes nothing.
+func (s Spec) Apply(in *faultinject.Injector) error {
+	return in.Replace(s.Compile())
+}
+
+// Load replaces every rule of in with the spec in the file at path.
+func Load(in *faultinject.Injector, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := s.Apply(in); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// withMode returns r with the mode and mode settings of m.
+func withMode(r, m faultinject.Rule) faultinject.Rule {
+	r.Mode, r.Count, r.Nth, r.Rate, r.Flap, r.Actions = m.Mode, m.Count, m.Nth, m.Rate, m.Flap, m.Actions
+	return r
+}
+
+func withImpliedMode(r faultinject.Rule) faultinject.Rule {
+	if r.Mode != faultinject.ModeNone {
+		return r
+	}
+	switch {
+	case r.Count != 0:
+		r.Mode = faultinject.ModeFirstN
+	case r.Nth != 0:
+		r.Mode = faultinject.ModeNth
+	case r.Rate != 0:
+		r.Mode = faultinject.ModeRate
+	case r.Flap != nil:
+		r.Mode = faultinject.ModeFlapping
+	case len(r.Actions) > 0:
+		r.Mode = faultinject.ModeActions
+	}
+	return r
+}
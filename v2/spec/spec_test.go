@@ -0,0 +1,104 @@
+//go:build faultinject
+
+package spec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	faultinject "github.com/talinashro/go-fi/v2"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]faultinject.Rule
+	}{
+		{
+			name: "yaml sections",
+			data: `
+rules:
+  payment-api: {count: 2, error: "connection refused", status: 503}
+failures:
+  db-connect: 3
+precise-failures:
+  cache: 2
+latencies:
+  db-connect: [{min: 10ms, max: 10ms, weight: 1}]
+`,
+			want: map[string]faultinject.Rule{
+				"payment-api": {Mode: faultinject.ModeFirstN, Count: 2, Error: "connection refused", Status: 503},
+				"db-connect":  {Mode: faultinject.ModeFirstN, Count: 3, Latency: faultinject.FixedLatency(10 * time.Millisecond)},
+				"cache":       faultinject.Nth(2),
+			},
+		},
+		{
+			name: "json",
+			data: `{"failures": {"db-connect": 1}}`,
+			want: map[string]faultinject.Rule{"db-connect": faultinject.FirstN(1)},
+		},
+		{
+			name: "section overrides the mode of a rule",
+			data: "rules:\n  db: {rate: 0.5, code: ECONNREFUSED}\nfailures:\n  db: 1\n",
+			want: map[string]faultinject.Rule{"db": {Mode: faultinject.ModeFirstN, Count: 1, Code: "ECONNREFUSED"}},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: map[string]faultinject.Rule{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got := s.Compile()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d rules, got %d: %+v", len(tt.want), len(got), got)
+			}
+			for key, want := range tt.want {
+				g := got[key]
+				if g.Mode != want.Mode || g.Count != want.Count || g.Nth != want.Nth || g.Rate != want.Rate ||
+					g.Error != want.Error || g.Code != want.Code || g.Status != want.Status || len(g.Latency) != len(want.Latency) {
+					t.Errorf("%s: expected %+v, got %+v", key, want, g)
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	if _, err := Parse([]byte("failure:\n  db: 1\n")); err == nil {
+		t.Error("Expected an error for a misspelt section, got nil")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "development")
+	dir := t.TempDir()
+	good := filepath.Join(dir, "faults.yaml")
+	if err := os.WriteFile(good, []byte("failures:\n  checkout: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, "bad.yaml")
+	if err := os.WriteFile(bad, []byte("rules:\n  checkout: {mode: rate, rate: 2}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	in := faultinject.New()
+	if err := Load(in, good); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := Load(in, bad); err == nil {
+		t.Error("Expected an error for an invalid rule, got nil")
+	}
+	if !in.Inject(context.Background(), "checkout") {
+		t.Error("Expected the good spec to stay in place")
+	}
+}
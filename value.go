@@ -0,0 +1,134 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"math"
+	"reflect"
+)
+
+// Number is any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// NumericEdge is a boundary value InjectValue substitutes for a real one.
+type NumericEdge string
+
+const (
+	EdgeZero     NumericEdge = "zero"
+	EdgeNegative NumericEdge = "negative" // -1; wraps to the maximum for unsigned types
+	EdgeMax      NumericEdge = "max"      // largest value of the type
+	EdgeMin      NumericEdge = "min"      // smallest value of the type, 0 for unsigned types
+	EdgeNaN      NumericEdge = "nan"      // floats only; the maximum for integers
+	EdgeInf      NumericEdge = "inf"      // +Inf for floats; the maximum for integers
+	EdgeHuge     NumericEdge = "huge"     // 1e21 for floats, beyond exact integers and fixed notation; the maximum for integers
+)
+
+var (
+	intEdges   = []NumericEdge{EdgeZero, EdgeNegative, EdgeMax, EdgeMin}
+	floatEdges = []NumericEdge{EdgeZero, EdgeNegative, EdgeMax, EdgeMin, EdgeNaN, EdgeInf, EdgeHuge}
+)
+
+// SetNumericEdge sets the boundary value InjectValue substitutes for key.
+// Without one, successive failures cycle through every edge that applies to
+// the value's type. An empty edge restores cycling.
+func (in *Injector) SetNumericEdge(key string, edge NumericEdge) {
+	if in.isProductionEnvironment() {
+		return
+	}
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	setKeyedLocked(in, in.numericEdges, key, edge, edge == "")
+}
+
+// SetNumericEdge calls SetNumericEdge on the default Injector.
+func SetNumericEdge(key string, edge NumericEdge) {
+	std.SetNumericEdge(key, edge)
+}
+
+// InjectValue returns v, or a boundary value such as 0, -1, the type's
+// maximum or NaN when key fires, to test billing and accounting code
+// against amounts it does not expect.
+//
+//	total := faultinject.InjectValue(ctx, "invoice-total", invoice.Total())
+func InjectValue[T Number](ctx context.Context, key string, v T) T {
+	fired, count := std.evaluateWithContext(ctx, key)
+	if !fired {
+		return v
+	}
+
+	edge, ok := std.numericEdgeFor(key)
+
+	rv := reflect.New(reflect.TypeOf(v)).Elem()
+	isFloat := rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64
+	if !ok {
+		edges := intEdges
+		if isFloat {
+			edges = floatEdges
+		}
+		if count > 0 {
+			count--
+		}
+		edge = edges[count%len(edges)]
+	}
+	setEdge(rv, edge)
+	return rv.Interface().(T)
+}
+
+// setEdge sets rv, a zero number, to edge.
+func setEdge(rv reflect.Value, edge NumericEdge) {
+	bits := rv.Type().Bits()
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch edge {
+		case EdgeZero:
+		case EdgeNegative:
+			rv.SetInt(-1)
+		case EdgeMin:
+			rv.SetInt(math.MinInt64 >> (64 - bits))
+		default:
+			rv.SetInt(math.MaxInt64 >> (64 - bits))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch edge {
+		case EdgeZero, EdgeMin:
+		default:
+			rv.SetUint(math.MaxUint64 >> (64 - bits))
+		}
+	default:
+		largest := math.MaxFloat64
+		if bits == 32 {
+			largest = math.MaxFloat32
+		}
+		switch edge {
+		case EdgeZero:
+		case EdgeNegative:
+			rv.SetFloat(-1)
+		case EdgeMax:
+			rv.SetFloat(largest)
+		case EdgeMin:
+			rv.SetFloat(-largest)
+		case EdgeNaN:
+			rv.SetFloat(math.NaN())
+		case EdgeInf:
+			rv.SetFloat(math.Inf(1))
+		default:
+			rv.SetFloat(1e21)
+		}
+	}
+}
+
+func (in *Injector) numericEdgeFor(key string) (NumericEdge, bool) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+	edge, ok := in.numericEdges[in.ruleKeyLocked(key)]
+	return edge, ok
+}
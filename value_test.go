@@ -0,0 +1,75 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+type cents int64
+
+func TestInjectValueEdges(t *testing.T) {
+	tests := []struct {
+		edge     NumericEdge
+		int64v   int64
+		uint8v   uint8
+		float64v float64
+	}{
+		{edge: EdgeZero, int64v: 0, uint8v: 0, float64v: 0},
+		{edge: EdgeNegative, int64v: -1, uint8v: math.MaxUint8, float64v: -1},
+		{edge: EdgeMax, int64v: math.MaxInt64, uint8v: math.MaxUint8, float64v: math.MaxFloat64},
+		{edge: EdgeMin, int64v: math.MinInt64, uint8v: 0, float64v: -math.MaxFloat64},
+		{edge: EdgeInf, int64v: math.MaxInt64, uint8v: math.MaxUint8, float64v: math.Inf(1)},
+		{edge: EdgeHuge, int64v: math.MaxInt64, uint8v: math.MaxUint8, float64v: 1e21},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(string(tt.edge), func(t *testing.T) {
+			resetState()
+			SetNumericEdge("amount", tt.edge)
+			SetFailures("amount", 3)
+			if got := InjectValue(ctx, "amount", int64(42)); got != tt.int64v {
+				t.Errorf("Expected int64 %d, got %d", tt.int64v, got)
+			}
+			if got := InjectValue(ctx, "amount", uint8(42)); got != tt.uint8v {
+				t.Errorf("Expected uint8 %d, got %d", tt.uint8v, got)
+			}
+			if got := InjectValue(ctx, "amount", 42.5); got != tt.float64v {
+				t.Errorf("Expected float64 %g, got %g", tt.float64v, got)
+			}
+			if got := InjectValue(ctx, "amount", 42.5); got != 42.5 {
+				t.Errorf("Expected the real value once the rule is exhausted, got %g", got)
+			}
+		})
+	}
+}
+
+func TestInjectValueNaN(t *testing.T) {
+	resetState()
+	SetNumericEdge("rate", EdgeNaN)
+	SetFailures("rate", 2)
+	if got := InjectValue(context.Background(), "rate", float32(0.25)); !math.IsNaN(float64(got)) {
+		t.Errorf("Expected NaN, got %g", got)
+	}
+	if got := InjectValue(context.Background(), "rate", 7); got != math.MaxInt {
+		t.Errorf("Expected NaN to substitute the maximum for ints, got %d", got)
+	}
+}
+
+func TestInjectValueCycle(t *testing.T) {
+	resetState()
+	SetFailures("amount", 5)
+
+	expected := []cents{0, -1, math.MaxInt64, math.MinInt64, 0}
+	for i, want := range expected {
+		if got := InjectValue(context.Background(), "amount", cents(1999)); got != want {
+			t.Errorf("Call %d: expected %d, got %d", i+1, want, got)
+		}
+	}
+	if got := InjectValue(context.Background(), "amount", cents(1999)); got != 1999 {
+		t.Errorf("Expected the real value once the rule is exhausted, got %d", got)
+	}
+}
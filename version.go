@@ -0,0 +1,111 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrConflict is returned by conditional updates when the configuration has
+// changed since the caller read its generation.
+var ErrConflict = errors.New("faultinject: configuration changed concurrently")
+
+func (in *Injector) bumpGenerationLocked() {
+	in.mu.dirty = true
+	in.generation++
+	close(in.changed)
+	in.changed = make(chan struct{})
+}
+
+// Generation returns the current configuration generation. It increases with
+// every change to rules, tenants, selectors, synthetic exclusion and latency,
+// but not when calls consume a rule.
+func (in *Injector) Generation() uint64 {
+	return in.snapshot().generation
+}
+
+// Generation calls Generation on the default Injector.
+func Generation() uint64 {
+	return std.Generation()
+}
+
+// StatusWithGeneration returns Status and the generation it was read at.
+func (in *Injector) StatusWithGeneration() (map[string]int, uint64) {
+	s := in.snapshot()
+	return in.statusOf(s), s.generation
+}
+
+// StatusWithGeneration calls StatusWithGeneration on the default Injector.
+func StatusWithGeneration() (map[string]int, uint64) {
+	return std.StatusWithGeneration()
+}
+
+// WaitForChange blocks until the configuration generation differs from gen
+// and returns the new generation. It returns the current generation and
+// ctx's error if ctx is done first.
+func (in *Injector) WaitForChange(ctx context.Context, gen uint64) (uint64, error) {
+	for {
+		in.mu.RLock()
+		cur, ch := in.generation, in.changed
+		in.mu.RUnlock()
+		if cur != gen {
+			return cur, nil
+		}
+		select {
+		case <-ctx.Done():
+			return cur, ctx.Err()
+		case <-ch:
+		}
+	}
+}
+
+// WaitForChange calls WaitForChange on the default Injector.
+func WaitForChange(ctx context.Context, gen uint64) (uint64, error) {
+	return std.WaitForChange(ctx, gen)
+}
+
+// SetFailuresIfMatch sets several first-N rules at once, as SetFailures
+// does, but only if the configuration is still at generation gen. A zero gen
+// applies unconditionally. It returns the resulting generation, or the
+// current one and ErrConflict.
+func (in *Injector) SetFailuresIfMatch(gen uint64, failures map[string]int) (uint64, error) {
+	return in.updateIfMatch(gen, func() {
+		if in.isProductionEnvironment() {
+			return
+		}
+		for key, count := range failures {
+			in.setFailuresLocked(key, count)
+		}
+	})
+}
+
+// SetFailuresIfMatch calls SetFailuresIfMatch on the default Injector.
+func SetFailuresIfMatch(gen uint64, failures map[string]int) (uint64, error) {
+	return std.SetFailuresIfMatch(gen, failures)
+}
+
+// ResetIfMatch is Reset, applied only if the configuration is still at
+// generation gen. A zero gen resets unconditionally.
+func (in *Injector) ResetIfMatch(gen uint64) (uint64, error) {
+	return in.updateIfMatch(gen, in.resetLocked)
+}
+
+// ResetIfMatch calls ResetIfMatch on the default Injector.
+func ResetIfMatch(gen uint64) (uint64, error) {
+	return std.ResetIfMatch(gen)
+}
+
+// updateIfMatch runs fn under mu if the generation is gen or gen is zero.
+func (in *Injector) updateIfMatch(gen uint64, fn func()) (uint64, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if gen != 0 && gen != in.generation {
+		return in.generation, ErrConflict
+	}
+	fn()
+	return in.generation, nil
+}
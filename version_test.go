@@ -0,0 +1,218 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeneration(t *testing.T) {
+	resetState()
+
+	tests := []struct {
+		name    string
+		change  func()
+		changes bool
+	}{
+		{name: "SetFailures", change: func() { SetFailures("db", 1) }, changes: true},
+		{name: "SetNthFailure", change: func() { SetNthFailure("db", 2) }, changes: true},
+		{name: "SetTenants", change: func() { SetTenants("db", "acme") }, changes: true},
+		{name: "SetSelectors", change: func() { SetSelectors("db") }, changes: true},
+		{name: "SetLatency", change: func() { SetLatency("db", FixedLatency(0)) }, changes: true},
+		{name: "Reset", change: Reset, changes: true},
+		{name: "Inject", change: func() { Inject("db") }, changes: false},
+		{name: "Status", change: func() { Status() }, changes: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := Generation()
+			tt.change()
+			if got := Generation() > before; got != tt.changes {
+				t.Errorf("Expected generation change %v, got %v", tt.changes, got)
+			}
+		})
+	}
+}
+
+func TestSetFailuresIfMatch(t *testing.T) {
+	resetState()
+
+	_, gen := StatusWithGeneration()
+	next, err := SetFailuresIfMatch(gen, map[string]int{"db": 2, "cache": 1})
+	if err != nil {
+		t.Fatalf("SetFailuresIfMatch returned error: %v", err)
+	}
+	if next <= gen {
+		t.Errorf("Expected generation to advance past %d, got %d", gen, next)
+	}
+	if st := Status(); st["db"] != 2 || st["cache"] != 1 {
+		t.Errorf("Expected both keys set, got %v", st)
+	}
+
+	// a stale generation is rejected and nothing changes
+	if _, err := SetFailuresIfMatch(gen, map[string]int{"db": 9}); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict, got %v", err)
+	}
+	if got := Status()["db"]; got != 2 {
+		t.Errorf("Expected db unchanged at 2, got %d", got)
+	}
+
+	if _, err := ResetIfMatch(gen); !errors.Is(err, ErrConflict) {
+		t.Errorf("Expected ErrConflict from ResetIfMatch, got %v", err)
+	}
+	if _, err := ResetIfMatch(0); err != nil {
+		t.Errorf("Expected unconditional reset, got %v", err)
+	}
+}
+
+func TestControlHandlerIfMatch(t *testing.T) {
+	resetState()
+	handler := ControlHandler(nil)
+
+	do := func(method, path, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tag := do(http.MethodGet, "/status", "", "").Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("Expected ETag on /status")
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		ifMatch  string
+		body     string
+		expected int
+	}{
+		{name: "apply with current tag", method: http.MethodPost, path: "/apply", ifMatch: tag, body: `{"db":2,"cache":1}`, expected: http.StatusOK},
+		{name: "set with stale tag", method: http.MethodPost, path: "/set?key=db&count=5", ifMatch: tag, expected: http.StatusPreconditionFailed},
+		{name: "reset with stale tag", method: http.MethodPost, path: "/reset", ifMatch: tag, expected: http.StatusPreconditionFailed},
+		{name: "invalid tag", method: http.MethodPost, path: "/set?key=db&count=5", ifMatch: "W/abc", expected: http.StatusPreconditionFailed},
+		{name: "wildcard", method: http.MethodPost, path: "/set?key=api&count=1", ifMatch: "*", expected: http.StatusOK},
+		{name: "apply requires POST", method: http.MethodGet, path: "/apply", expected: http.StatusMethodNotAllowed},
+		{name: "apply bad body", method: http.MethodPost, path: "/apply", body: "{", expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(tt.method, tt.path, tt.ifMatch, tt.body)
+			if rec.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if st := Status(); st["db"] != 2 || st["api"] != 1 {
+		t.Errorf("Expected only accepted updates applied, got %v", st)
+	}
+
+	rec := do(http.MethodGet, "/status?keys=db", "", "")
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"db":2}` {
+		t.Errorf("Expected filtered status, got %s", body)
+	}
+}
+
+func TestWaitForChange(t *testing.T) {
+	resetState()
+	gen := Generation()
+
+	// an already stale generation returns at once
+	if got, err := WaitForChange(context.Background(), gen-1); err != nil || got != gen {
+		t.Errorf("Expected %d without waiting, got %d (%v)", gen, got, err)
+	}
+
+	done := make(chan uint64)
+	go func() {
+		got, _ := WaitForChange(context.Background(), gen)
+		done <- got
+	}()
+	time.Sleep(10 * time.Millisecond)
+	SetFailures("db", 1)
+	select {
+	case got := <-done:
+		if got <= gen {
+			t.Errorf("Expected a generation after %d, got %d", gen, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForChange did not return after a change")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := WaitForChange(ctx, Generation()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestControlHandlerWatch(t *testing.T) {
+	resetState()
+	SetFailures("db", 2)
+	srv := httptest.NewServer(ControlHandler(nil))
+	defer srv.Close()
+	gen := Generation()
+
+	tests := []struct {
+		name     string
+		query    string
+		change   bool
+		expected int
+	}{
+		{name: "no generation returns current state", query: "", expected: http.StatusOK},
+		{name: "stale generation returns at once", query: fmt.Sprintf("?generation=%d", gen-1), expected: http.StatusOK},
+		{name: "timeout without change", query: fmt.Sprintf("?generation=%d&timeout=20ms", gen), expected: http.StatusNotModified},
+		{name: "change during wait", query: fmt.Sprintf("?generation=%d&timeout=5s", gen), change: true, expected: http.StatusOK},
+		{name: "invalid timeout", query: "?timeout=soon", expected: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change {
+				go func() {
+					time.Sleep(20 * time.Millisecond)
+					SetFailures("api", 1)
+				}()
+			}
+			resp, err := http.Get(srv.URL + "/faults/watch" + tt.query)
+			if err != nil {
+				t.Fatalf("Failed to make request: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+			var body struct {
+				Generation uint64         `json:"generation"`
+				Status     map[string]int `json:"status"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if body.Status["db"] != 2 || resp.Header.Get("ETag") != etag(body.Generation) {
+				t.Errorf("Unexpected response %+v with ETag %s", body, resp.Header.Get("ETag"))
+			}
+			if tt.change && body.Status["api"] != 1 {
+				t.Errorf("Expected the change to be included, got %v", body.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 Talina Shrotriya
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build faultinject
+
+package faultinject
+
+import (
+	"bytes"
+	"os"
+	"time"
+)
+
+// WatchOptions controls how WatchSpec reloads a spec file.
+type WatchOptions struct {
+	// Interval is how often the file is checked for changes. Default 1s.
+	Interval time.Duration
+	// Merge applies a changed spec on top of the current state instead of
+	// replacing it, so rules set at runtime survive reloads.
+	Merge bool
+	// OnError is called once when the file becomes unreadable or invalid. The
+	// current state is kept until the file is valid again.
+	OnError func(error)
+	// OnReload is called after a changed spec has been applied.
+	OnReload func()
+}
+
+// WatchSpec loads the spec at path, then polls it and applies it again
+// whenever its contents change and settle, until the returned stop func is
+// called. It returns an error if the initial load fails. An empty file is
+// treated as a write in progress; write "{}" to clear every rule.
+func (in *Injector) WatchSpec(path string, opts WatchOptions) (stop func(), err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	format := formatOf(path)
+	cfg, err := parseSpec(data, format)
+	if err != nil {
+		return nil, inFile(err, path)
+	}
+	in.applySpec(cfg, opts.Merge)
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		st := settler{last: data}
+		missing := false // read failure already reported
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if !missing && opts.OnError != nil {
+					opts.OnError(err)
+				}
+				missing = true
+				continue
+			}
+			missing = false
+			if !st.settled(data) {
+				continue
+			}
+			cfg, err := parseSpec(data, format)
+			if err != nil {
+				st.bad = data
+				if opts.OnError != nil {
+					opts.OnError(inFile(err, path))
+				}
+				continue
+			}
+			st.last, st.pending, st.bad = data, nil, nil
+			in.applySpec(cfg, opts.Merge)
+			if opts.OnReload != nil {
+				opts.OnReload()
+			}
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
+// WatchSpec calls WatchSpec on the default Injector.
+func WatchSpec(path string, opts WatchOptions) (stop func(), err error) {
+	return std.WatchSpec(path, opts)
+}
+
+// settler follows the contents of a watched file from one poll to the next,
+// so only contents unchanged for a full interval are applied and a file
+// caught halfway through being written is not loaded.
+type settler struct {
+	last    []byte // contents applied
+	pending []byte // changed contents waiting to settle
+	bad     []byte // invalid contents already reported
+}
+
+// settled reports whether data, read by a poll, is new and was read by the
+// poll before as well. Any other contents, including a return to the ones
+// applied, restart the wait.
+func (s *settler) settled(data []byte) bool {
+	if len(data) == 0 || bytes.Equal(data, s.last) || s.bad != nil && bytes.Equal(data, s.bad) {
+		s.pending = nil
+		return false
+	}
+	if s.pending == nil || !bytes.Equal(data, s.pending) {
+		s.pending = data
+		return false
+	}
+	return true
+}
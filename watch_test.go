@@ -0,0 +1,124 @@
+//go:build faultinject
+
+package faultinject
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeSpec replaces the file at path atomically, as editors and config
+// management tools do.
+func writeSpec(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Failed to replace spec: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestWatchSpec(t *testing.T) {
+	tests := []struct {
+		name     string
+		merge    bool
+		expected map[string]int
+	}{
+		{name: "replace", merge: false, expected: map[string]int{"db": 5}},
+		{name: "merge", merge: true, expected: map[string]int{"db": 5, "runtime": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetState()
+			path := filepath.Join(t.TempDir(), "faults.yaml")
+			writeSpec(t, path, "failures:\n  db: 2\n")
+
+			var reloads, errs atomic.Int32
+			stop, err := WatchSpec(path, WatchOptions{
+				Interval: 5 * time.Millisecond,
+				Merge:    tt.merge,
+				OnError:  func(error) { errs.Add(1) },
+				OnReload: func() { reloads.Add(1) },
+			})
+			if err != nil {
+				t.Fatalf("WatchSpec returned error: %v", err)
+			}
+			defer stop()
+			if got := Status()["db"]; got != 2 {
+				t.Errorf("Expected initial load to set db to 2, got %d", got)
+			}
+			SetFailures("runtime", 1)
+
+			// an invalid spec is reported once and leaves the state alone
+			writeSpec(t, path, "failures: [\n")
+			waitFor(t, func() bool { return errs.Load() == 1 })
+			time.Sleep(20 * time.Millisecond)
+			if errs.Load() != 1 || Status()["db"] != 2 {
+				t.Errorf("Expected one error and unchanged state, got %d errors and %v", errs.Load(), Status())
+			}
+
+			writeSpec(t, path, "failures:\n  db: 5\n")
+			waitFor(t, func() bool { return reloads.Load() == 1 })
+			st := Status()
+			if len(st) != len(tt.expected) {
+				t.Errorf("Expected status %v, got %v", tt.expected, st)
+			}
+			for k, v := range tt.expected {
+				if st[k] != v {
+					t.Errorf("Expected %s to be %d, got %d", k, v, st[k])
+				}
+			}
+		})
+	}
+}
+
+func TestSettler(t *testing.T) {
+	tests := []struct {
+		name     string
+		polls    []string
+		expected []bool
+	}{
+		{name: "unchanged", polls: []string{"A", "A"}, expected: []bool{false, false}},
+		{name: "change settles", polls: []string{"B", "B"}, expected: []bool{false, true}},
+		{name: "still changing", polls: []string{"B", "C", "C"}, expected: []bool{false, false, true}},
+		{name: "flip-flop", polls: []string{"B", "A", "B", "B"}, expected: []bool{false, false, false, true}},
+		{name: "empty write", polls: []string{"B", "", "B", "B"}, expected: []bool{false, false, false, true}},
+		{name: "reported invalid", polls: []string{"X", "X", "B", "X", "B"}, expected: []bool{false, false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := settler{last: []byte("A"), bad: []byte("X")}
+			for i, data := range tt.polls {
+				if got := s.settled([]byte(data)); got != tt.expected[i] {
+					t.Errorf("Expected poll %d of %q to be %v, got %v", i, data, tt.expected[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchSpecInitialError(t *testing.T) {
+	resetState()
+	if _, err := WatchSpec(filepath.Join(t.TempDir(), "missing.yaml"), WatchOptions{}); err == nil {
+		t.Error("Expected error for missing spec")
+	}
+}